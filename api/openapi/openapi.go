@@ -0,0 +1,11 @@
+// Package openapi embeds the OpenAPI (Swagger 2.0) description of the REST
+// gateway. orderbook.swagger.json is generated from orderbook.proto by
+// `make generate-openapi` and must not be edited by hand.
+package openapi
+
+import _ "embed"
+
+// Spec is the JSON OpenAPI description of the OrderBookService REST routes
+//
+//go:embed orderbook.swagger.json
+var Spec []byte
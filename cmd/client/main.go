@@ -5,8 +5,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"text/tabwriter"
@@ -15,8 +17,11 @@ import (
 	"github.com/fatih/color"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 var (
@@ -74,6 +79,38 @@ func main() {
 		bookName := os.Args[1]
 		orderID := os.Args[2]
 		getOrder(ctx, client, bookName, orderID)
+	case "batch-get-orders":
+		if len(os.Args) < 2 {
+			fmt.Println("Usage: batch-get-orders <book> <id,id,...> | batch-get-orders <book> --file=<path>")
+			os.Exit(1)
+		}
+		bookName := os.Args[1]
+		batchFlags := flag.NewFlagSet("batch-get-orders", flag.ExitOnError)
+		file := batchFlags.String("file", "", "File listing order IDs, one per line")
+		var ids string
+		if len(os.Args) > 2 && !strings.HasPrefix(os.Args[2], "-") {
+			ids = os.Args[2]
+			batchFlags.Parse(os.Args[3:])
+		} else {
+			batchFlags.Parse(os.Args[2:])
+		}
+		orderIDs, err := readOrderIDs(ids, *file)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to read order IDs")
+		}
+		batchGetOrders(ctx, client, bookName, orderIDs)
+	case "list-stop-orders":
+		if len(os.Args) < 2 {
+			fmt.Println("Usage: list-stop-orders <book> [--side=buy|sell] [--limit=N] [--offset=N]")
+			os.Exit(1)
+		}
+		bookName := os.Args[1]
+		stopFlags := flag.NewFlagSet("list-stop-orders", flag.ExitOnError)
+		side := stopFlags.String("side", "", "Only list stop orders on this side (buy or sell)")
+		limit := stopFlags.Int("limit", 100, "Maximum number of stop orders to list")
+		offset := stopFlags.Int("offset", 0, "Number of stop orders to skip")
+		stopFlags.Parse(os.Args[2:])
+		listStopOrders(ctx, client, bookName, *side, int32(*limit), int32(*offset))
 	case "cancel-order":
 		if len(os.Args) < 3 {
 			fmt.Println("Usage: cancel-order <book> <id>")
@@ -82,13 +119,108 @@ func main() {
 		bookName := os.Args[1]
 		orderID := os.Args[2]
 		cancelOrder(ctx, client, bookName, orderID)
+	case "amend-order":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: amend-order <book> <id> [--price=P] [--qty=Q]")
+			os.Exit(1)
+		}
+		bookName := os.Args[1]
+		orderID := os.Args[2]
+		amendFlags := flag.NewFlagSet("amend-order", flag.ExitOnError)
+		price := amendFlags.String("price", "", "New limit price; unchanged if empty")
+		qty := amendFlags.String("qty", "", "New remaining quantity; unchanged if empty")
+		amendFlags.Parse(os.Args[3:])
+		amendOrder(ctx, client, bookName, orderID, *price, *qty)
 	case "get-state":
 		if len(os.Args) < 2 {
-			fmt.Println("Usage: get-state <book>")
+			fmt.Println("Usage: get-state <book> [--depth=N] [--bucket=SIZE]")
+			os.Exit(1)
+		}
+		bookName := os.Args[1]
+		stateFlags := flag.NewFlagSet("get-state", flag.ExitOnError)
+		depth := stateFlags.Int("depth", 20, "Number of price levels to show per side (at most 1000)")
+		bucket := stateFlags.String("bucket", "", "Merge the price levels within each multiple of this price step")
+		stateFlags.Parse(os.Args[2:])
+		if err := getOrderBookState(ctx, client, bookName, int32(*depth), *bucket); err != nil {
+			fatalRPCError(err, "Failed to get order book state")
+		}
+	case "get-bbo":
+		if len(os.Args) < 2 {
+			fmt.Println("Usage: get-bbo <book>")
+			os.Exit(1)
+		}
+		getBBO(ctx, client, os.Args[1])
+	case "vwap":
+		if len(os.Args) < 2 {
+			fmt.Println("Usage: vwap <book> [--window=SECONDS]")
+			os.Exit(1)
+		}
+		bookName := os.Args[1]
+		vwapFlags := flag.NewFlagSet("vwap", flag.ExitOnError)
+		window := vwapFlags.Int64("window", 0, "Only count trades from the last this many seconds; 0 counts every recorded trade")
+		vwapFlags.Parse(os.Args[2:])
+		calculateVWAP(ctx, client, bookName, *window)
+	case "twap":
+		if len(os.Args) < 2 {
+			fmt.Println("Usage: twap <book> [--window=SECONDS]")
 			os.Exit(1)
 		}
 		bookName := os.Args[1]
-		getOrderBookState(ctx, client, bookName)
+		twapFlags := flag.NewFlagSet("twap", flag.ExitOnError)
+		window := twapFlags.Int64("window", 300, "Length of the window in seconds, ending now")
+		twapFlags.Parse(os.Args[2:])
+		calculateTWAP(ctx, client, bookName, *window)
+	case "get-positions":
+		positionFlags := flag.NewFlagSet("get-positions", flag.ExitOnError)
+		user := positionFlags.String("user", "", "Only show this user's positions")
+		positionFlags.Parse(os.Args[1:])
+		getPositions(ctx, client, *user)
+	case "book-notional":
+		if len(os.Args) < 2 {
+			fmt.Println("Usage: book-notional <book> [--shock-pct=P]")
+			os.Exit(1)
+		}
+		bookName := os.Args[1]
+		notionalFlags := flag.NewFlagSet("book-notional", flag.ExitOnError)
+		shockPct := notionalFlags.Float64("shock-pct", 0, "Also estimate how far each best price moves if this percentage of its side is taken")
+		notionalFlags.Parse(os.Args[2:])
+		getBookNotional(ctx, client, bookName, *shockPct)
+	case "get-depth-at-price":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: get-depth-at-price <book> <side> <price>")
+			os.Exit(1)
+		}
+		getDepthAtPrice(ctx, client, os.Args[1], os.Args[2], os.Args[3])
+	case "route-order":
+		if len(os.Args) < 7 {
+			fmt.Println("Usage: route-order <book,book,...> <side> <type> <quantity> <price> <id> [--strategy=best|proportional]")
+			os.Exit(1)
+		}
+		routeFlags := flag.NewFlagSet("route-order", flag.ExitOnError)
+		strategy := routeFlags.String("strategy", "best", "Allocation strategy (best or proportional)")
+		routeFlags.Parse(os.Args[7:])
+		routeOrder(ctx, client, os.Args[1:7], *strategy)
+	case "watch-book":
+		if len(os.Args) < 2 {
+			fmt.Println("Usage: watch-book <book> [--format=table|json] [--filter=trade,add,cancel,amend]")
+			os.Exit(1)
+		}
+		bookName := os.Args[1]
+		watchFlags := flag.NewFlagSet("watch-book", flag.ExitOnError)
+		format := watchFlags.String("format", "table", "Output format (table or json)")
+		filter := watchFlags.String("filter", "", "Comma-separated event types to show (trade, add, cancel, amend); all when empty")
+		watchFlags.Parse(os.Args[2:])
+		runWatchBook(client, bookName, *format, *filter)
+	case "watch-trades":
+		if len(os.Args) < 2 {
+			fmt.Println("Usage: watch-trades <book> [--format=table|json]")
+			os.Exit(1)
+		}
+		bookName := os.Args[1]
+		tradeFlags := flag.NewFlagSet("watch-trades", flag.ExitOnError)
+		format := tradeFlags.String("format", "table", "Output format (table or json)")
+		tradeFlags.Parse(os.Args[2:])
+		runWatchTrades(client, bookName, *format)
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		printUsage()
@@ -100,6 +232,15 @@ func createOrderBook(ctx context.Context, client proto.OrderBookServiceClient) {
 	// Parse command line arguments
 	bookName := flag.String("name", "default", "Order book name")
 	backendType := flag.String("backend", "memory", "Backend type (memory or redis)")
+	warmUp := flag.Bool("warmup", false, "Seed the new order book with synthetic resting orders")
+	warmUpLevels := flag.Int("warmup-levels", 10, "Number of warm-up price levels per side")
+	warmUpBasePrice := flag.String("warmup-base-price", "100.0", "Price around which warm-up orders are placed")
+	warmUpTick := flag.String("warmup-tick", "1.0", "Price distance between warm-up levels")
+	warmUpQty := flag.String("warmup-qty", "1.0", "Quantity of each warm-up order")
+	maxDeviation := flag.Float64("max-price-deviation-pct", 0, "Largest move in percent allowed between consecutive fills (0 disables)")
+	maxOrderAge := flag.Duration("max-order-age", 0, "Cancel orders resting longer than this (0 uses the server default)")
+	pricePrecision := flag.Int("price-precision", 0, "Fraction digits prices are shown with (0 uses the engine's 3)")
+	qtyPrecision := flag.Int("qty-precision", 0, "Fraction digits quantities are shown with (0 uses the engine's 3)")
 	flag.Parse()
 
 	// Convert backend type string to enum
@@ -127,11 +268,21 @@ func createOrderBook(ctx context.Context, client proto.OrderBookServiceClient) {
 		BackendType: backendEnum,
 		Options:     options,
 	}
+	if *maxDeviation != 0 || *pricePrecision != 0 || *qtyPrecision != 0 {
+		req.Instrument = &proto.CreateOrderBookRequest_Instrument{
+			MaxPriceDeviationPct: *maxDeviation,
+			PricePrecision:       int32(*pricePrecision),
+			QtyPrecision:         int32(*qtyPrecision),
+		}
+	}
+	if *maxOrderAge != 0 {
+		req.Policy = &proto.CreateOrderBookRequest_Policy{MaxOrderAge: durationpb.New(*maxOrderAge)}
+	}
 
 	// Call RPC
 	resp, err := client.CreateOrderBook(ctx, req)
 	if err != nil {
-		log.Fatal().Err(err).Msg("CreateOrderBook failed")
+		fatalRPCError(err, "CreateOrderBook failed")
 	}
 
 	// Print response
@@ -140,6 +291,27 @@ func createOrderBook(ctx context.Context, client proto.OrderBookServiceClient) {
 		Str("backend", resp.BackendType.String()).
 		Time("created_at", resp.CreatedAt.AsTime()).
 		Msg("Created order book")
+
+	if !*warmUp {
+		return
+	}
+
+	warmResp, err := client.WarmUpOrderBook(ctx, &proto.WarmUpRequest{
+		BookName:    resp.Name,
+		NumLevels:   int32(*warmUpLevels),
+		BasePrice:   *warmUpBasePrice,
+		TickSize:    *warmUpTick,
+		QtyPerLevel: *warmUpQty,
+	})
+	if err != nil {
+		fatalRPCError(err, "WarmUpOrderBook failed")
+	}
+
+	log.Info().
+		Str("name", resp.Name).
+		Int32("orders_created", warmResp.OrdersCreated).
+		Dur("elapsed", warmResp.Elapsed.AsDuration()).
+		Msg("Warmed up order book")
 }
 
 func getOrderBook(ctx context.Context, client proto.OrderBookServiceClient) {
@@ -230,7 +402,11 @@ func createOrder(ctx context.Context, client proto.OrderBookServiceClient, args
 	orderType := flag.String("type", "", "Order type (MARKET/LIMIT/STOP/STOP_LIMIT)")
 	quantity := flag.String("qty", "", "Order quantity")
 	price := flag.String("price", "", "Order price")
+	stopPrice := flag.String("stop-price", "", "Price whose trade triggers a STOP or STOP_LIMIT order")
 	userAddress := flag.String("user", "", "User's wallet address")
+	postOnly := flag.Bool("post-only", false, "Reject the LIMIT order instead of letting it match on arrival")
+	visibleQty := flag.String("visible-qty", "", "Quantity an ICEBERG order shows at a time")
+	clientOrderID := flag.String("client-order-id", "", "Idempotency key; retries with the same key return the first response")
 	flag.Parse()
 
 	// If no flags are set, use positional arguments
@@ -242,12 +418,20 @@ func createOrder(ctx context.Context, client proto.OrderBookServiceClient, args
 		price = &args[4]
 		orderID = &args[5]
 		userAddress = &args[6]
+
+		// Flags may follow the positional arguments
+		trailingFlags := flag.NewFlagSet("create-order", flag.ExitOnError)
+		stopPrice = trailingFlags.String("stop-price", "", "Price whose trade triggers a STOP or STOP_LIMIT order")
+		postOnly = trailingFlags.Bool("post-only", false, "Reject the LIMIT order instead of letting it match on arrival")
+		visibleQty = trailingFlags.String("visible-qty", "", "Quantity an ICEBERG order shows at a time")
+		clientOrderID = trailingFlags.String("client-order-id", "", "Idempotency key; retries with the same key return the first response")
+		trailingFlags.Parse(args[7:])
 	}
 
 	// Validate required fields
 	if *bookName == "" || *orderID == "" || *side == "" || *orderType == "" || *quantity == "" || *userAddress == "" {
-		fmt.Println("Usage: create-order <book> <side> <type> <quantity> <price> <id> <user_address>")
-		fmt.Println("   or: create-order --book=<name> --id=<id> --side=<side> --type=<type> --qty=<quantity> --price=<price> --user=<user_address>")
+		fmt.Println("Usage: create-order <book> <side> <type> <quantity> <price> <id> <user_address> [--stop-price=<price>] [--post-only] [--visible-qty=<quantity>] [--client-order-id=<key>]")
+		fmt.Println("   or: create-order --book=<name> --id=<id> --side=<side> --type=<type> --qty=<quantity> --price=<price> --user=<user_address> [--stop-price=<price>] [--post-only] [--visible-qty=<quantity>] [--client-order-id=<key>]")
 		os.Exit(1)
 	}
 
@@ -273,26 +457,34 @@ func createOrder(ctx context.Context, client proto.OrderBookServiceClient, args
 		typeEnum = proto.OrderType_STOP
 	case "STOP_LIMIT":
 		typeEnum = proto.OrderType_STOP_LIMIT
+	case "MIDPOINT":
+		typeEnum = proto.OrderType_MIDPOINT
+	case "ICEBERG":
+		typeEnum = proto.OrderType_ICEBERG
 	default:
 		log.Fatal().Str("type", *orderType).Msg("Unsupported order type")
 	}
 
 	// Create request
 	req := &proto.CreateOrderRequest{
-		OrderBookName: *bookName,
-		OrderId:       *orderID,
-		Side:          sideEnum,
-		OrderType:     typeEnum,
-		Quantity:      *quantity,
-		Price:         *price,
-		TimeInForce:   proto.TimeInForce_GTC,
-		UserAddress:   *userAddress,
+		OrderBookName:   *bookName,
+		OrderId:         *orderID,
+		Side:            sideEnum,
+		OrderType:       typeEnum,
+		Quantity:        *quantity,
+		Price:           *price,
+		StopPrice:       *stopPrice,
+		TimeInForce:     proto.TimeInForce_GTC,
+		UserAddress:     *userAddress,
+		PostOnly:        *postOnly,
+		ClientOrderId:   *clientOrderID,
+		VisibleQuantity: *visibleQty,
 	}
 
 	// Call RPC
 	resp, err := client.CreateOrder(ctx, req)
 	if err != nil {
-		log.Fatal().Err(err).Msg("CreateOrder failed")
+		fatalRPCError(err, "CreateOrder failed")
 	}
 
 	// Print response
@@ -303,6 +495,12 @@ func createOrder(ctx context.Context, client proto.OrderBookServiceClient, args
 		Str("remaining_quantity", resp.RemainingQuantity).
 		Msg("Created order")
 
+	if resp.Status == proto.OrderStatus_PENDING {
+		log.Info().
+			Str("stop_price", resp.StopPrice).
+			Msg("Stop order is waiting for a trade at its stop price")
+	}
+
 	if len(resp.Fills) > 0 {
 		for i, fill := range resp.Fills {
 			log.Info().
@@ -349,6 +547,83 @@ func getOrder(ctx context.Context, client proto.OrderBookServiceClient, bookName
 	}
 }
 
+// readOrderIDs returns the IDs in the comma-separated list ids, or in file
+// when ids is empty. Blank entries are skipped.
+func readOrderIDs(ids, file string) ([]string, error) {
+	if ids == "" {
+		if file == "" {
+			return nil, fmt.Errorf("no order IDs given")
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		ids = strings.ReplaceAll(string(data), "\n", ",")
+	}
+
+	var orderIDs []string
+	for _, id := range strings.Split(ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			orderIDs = append(orderIDs, id)
+		}
+	}
+	return orderIDs, nil
+}
+
+func batchGetOrders(ctx context.Context, client proto.OrderBookServiceClient, bookName string, orderIDs []string) {
+	resp, err := client.BatchGetOrders(ctx, &proto.BatchGetOrdersRequest{
+		OrderBookName: bookName,
+		OrderIds:      orderIDs,
+	})
+	if err != nil {
+		fatalRPCError(err, "BatchGetOrders failed")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSIDE\tPRICE\tSTATUS\tFILLED\tREMAINING")
+	for _, order := range resp.Orders {
+		if order.ErrorCode != proto.OrderErrorCode_NO_ERROR {
+			fmt.Fprintf(w, "%s\t\t\t%s (%s)\t\t\n", order.OrderId, order.Status, order.ErrorCode)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			order.OrderId, order.Side, order.Price, order.Status, order.FilledQuantity, order.RemainingQuantity)
+	}
+	w.Flush()
+}
+
+func listStopOrders(ctx context.Context, client proto.OrderBookServiceClient, bookName, side string, limit, offset int32) {
+	req := &proto.ListStopOrdersRequest{
+		OrderBookName: bookName,
+		Limit:         limit,
+		Offset:        offset,
+	}
+	switch strings.ToUpper(side) {
+	case "":
+	case "BUY":
+		req.Side = proto.OrderSide_BUY.Enum()
+	case "SELL":
+		req.Side = proto.OrderSide_SELL.Enum()
+	default:
+		log.Fatal().Str("side", side).Msg("Side must be buy or sell")
+	}
+
+	resp, err := client.ListStopOrders(ctx, req)
+	if err != nil {
+		fatalRPCError(err, "ListStopOrders failed")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSIDE\tTRIGGER\tLIMIT\tQUANTITY\tUSER\tCREATED")
+	for _, stop := range resp.StopOrders {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			stop.OrderId, stop.Side, stop.TriggerPrice, stop.LimitPrice, stop.Quantity, stop.UserAddress,
+			stop.CreatedAt.AsTime().Format(time.RFC3339))
+	}
+	w.Flush()
+	fmt.Printf("Showing %d of %d stop orders\n", len(resp.StopOrders), resp.Total)
+}
+
 func cancelOrder(ctx context.Context, client proto.OrderBookServiceClient, bookName, orderID string) {
 	// Create request
 	req := &proto.CancelOrderRequest{
@@ -365,14 +640,254 @@ func cancelOrder(ctx context.Context, client proto.OrderBookServiceClient, bookN
 	log.Info().Str("order_id", orderID).Msg("Order canceled")
 }
 
-func getOrderBookState(ctx context.Context, client proto.OrderBookServiceClient, name string) error {
+func amendOrder(ctx context.Context, client proto.OrderBookServiceClient, bookName, orderID, price, qty string) {
+	resp, err := client.AmendOrder(ctx, &proto.AmendOrderRequest{
+		OrderBookName: bookName,
+		OrderId:       orderID,
+		Price:         price,
+		Quantity:      qty,
+	})
+	if err != nil {
+		fatalRPCError(err, "AmendOrder failed")
+	}
+
+	log.Info().
+		Str("order_id", orderID).
+		Str("price", resp.Price).
+		Str("remaining_quantity", resp.RemainingQuantity).
+		Msg("Order amended")
+}
+
+func getDepthAtPrice(ctx context.Context, client proto.OrderBookServiceClient, bookName, side, price string) {
+	var sideEnum proto.OrderSide
+	switch strings.ToUpper(side) {
+	case "BUY":
+		sideEnum = proto.OrderSide_BUY
+	case "SELL":
+		sideEnum = proto.OrderSide_SELL
+	default:
+		log.Fatal().Str("side", side).Msg("Unsupported side")
+	}
+
+	resp, err := client.GetDepthAtPrice(ctx, &proto.GetDepthAtPriceRequest{
+		OrderBookName: bookName,
+		Side:          sideEnum,
+		Price:         price,
+	})
+	if err != nil {
+		fatalRPCError(err, "GetDepthAtPrice failed")
+	}
+
+	log.Info().
+		Str("book", bookName).
+		Str("side", sideEnum.String()).
+		Str("price", resp.Price).
+		Str("total_quantity", resp.TotalQuantity).
+		Int32("order_count", resp.OrderCount).
+		Strs("user_addresses", resp.UserAddresses).
+		Msg("Depth at price")
+}
+
+func getBBO(ctx context.Context, client proto.OrderBookServiceClient, bookName string) {
+	resp, err := client.GetBBO(ctx, &proto.GetBBORequest{OrderBookName: bookName})
+	if err != nil {
+		fatalRPCError(err, "GetBBO failed")
+	}
+
+	log.Info().
+		Str("book", bookName).
+		Str("bid_price", resp.BidPrice).
+		Str("bid_qty", resp.BidQty).
+		Str("ask_price", resp.AskPrice).
+		Str("ask_qty", resp.AskQty).
+		Str("spread", resp.Spread).
+		Str("mid_price", resp.MidPrice).
+		Msg("Best bid and offer")
+}
+
+func calculateVWAP(ctx context.Context, client proto.OrderBookServiceClient, bookName string, windowSeconds int64) {
+	resp, err := client.CalculateVWAP(ctx, &proto.VWAPRequest{OrderBookName: bookName, WindowSeconds: windowSeconds})
+	if err != nil {
+		fatalRPCError(err, "CalculateVWAP failed")
+	}
+
+	log.Info().
+		Str("book", bookName).
+		Str("vwap", resp.Vwap).
+		Str("volume", resp.Volume).
+		Int32("trade_count", resp.TradeCount).
+		Msg("VWAP")
+}
+
+func calculateTWAP(ctx context.Context, client proto.OrderBookServiceClient, bookName string, windowSeconds int64) {
+	resp, err := client.CalculateTWAP(ctx, &proto.TWAPRequest{OrderBookName: bookName, WindowSeconds: windowSeconds})
+	if err != nil {
+		fatalRPCError(err, "CalculateTWAP failed")
+	}
+
+	log.Info().
+		Str("book", bookName).
+		Str("twap", resp.Twap).
+		Int32("trade_count", resp.TradeCount).
+		Msg("TWAP")
+}
+
+func getPositions(ctx context.Context, client proto.OrderBookServiceClient, userAddress string) {
+	resp, err := client.GetPositions(ctx, &proto.GetPositionsRequest{UserAddress: userAddress})
+	if err != nil {
+		fatalRPCError(err, "GetPositions failed")
+	}
+
+	for _, user := range resp.Users {
+		for _, book := range user.Books {
+			log.Info().
+				Str("user_address", user.UserAddress).
+				Str("book", book.OrderBookName).
+				Str("net", book.Net).
+				Msg("Position")
+		}
+		log.Info().
+			Str("user_address", user.UserAddress).
+			Str("total", user.Total).
+			Msg("Total position")
+	}
+}
+
+func getBookNotional(ctx context.Context, client proto.OrderBookServiceClient, bookName string, shockPct float64) {
+	resp, err := client.GetBookNotional(ctx, &proto.GetBookNotionalRequest{
+		OrderBookName: bookName,
+		ShockPct:      shockPct,
+	})
+	if err != nil {
+		fatalRPCError(err, "GetBookNotional failed")
+	}
+
+	event := log.Info().
+		Str("book", bookName).
+		Str("bid_notional", resp.BidNotional).
+		Str("ask_notional", resp.AskNotional)
+	if shockPct > 0 {
+		event = event.
+			Float64("shock_pct", shockPct).
+			Str("bid_drawdown", resp.BidDrawdown).
+			Str("ask_drawdown", resp.AskDrawdown)
+	}
+	event.Msg("Book notional")
+}
+
+func routeOrder(ctx context.Context, client proto.OrderBookServiceClient, args []string, strategy string) {
+	books, side, orderType, quantity, price, orderID := args[0], args[1], args[2], args[3], args[4], args[5]
+
+	req := &proto.RouteOrderRequest{
+		OrderBookNames: strings.Split(books, ","),
+		OrderId:        orderID,
+		Quantity:       quantity,
+		Price:          price,
+	}
+
+	switch strings.ToUpper(side) {
+	case "BUY":
+		req.Side = proto.OrderSide_BUY
+	case "SELL":
+		req.Side = proto.OrderSide_SELL
+	default:
+		log.Fatal().Str("side", side).Msg("Unsupported side")
+	}
+
+	switch strings.ToUpper(orderType) {
+	case "MARKET":
+		req.OrderType = proto.OrderType_MARKET
+	case "LIMIT":
+		req.OrderType = proto.OrderType_LIMIT
+	default:
+		log.Fatal().Str("type", orderType).Msg("Unsupported order type")
+	}
+
+	switch strategy {
+	case "best":
+		req.Strategy = proto.AllocationStrategy_BEST_PRICE_FIRST
+	case "proportional":
+		req.Strategy = proto.AllocationStrategy_PROPORTIONAL_SPLIT
+	default:
+		log.Fatal().Str("strategy", strategy).Msg("Unsupported strategy")
+	}
+
+	resp, err := client.RouteOrder(ctx, req)
+	if err != nil {
+		fatalRPCError(err, "RouteOrder failed")
+	}
+
+	for _, order := range resp.Orders {
+		log.Info().
+			Str("book", order.OrderBookName).
+			Str("order_id", order.OrderId).
+			Str("quantity", order.Quantity).
+			Str("executed_quantity", order.ExecutedQuantity).
+			Str("remaining_quantity", order.RemainingQuantity).
+			Bool("stored", order.Stored).
+			Msg("Child order")
+	}
+	if resp.Error != "" {
+		log.Error().Str("executed_quantity", resp.ExecutedQuantity).Str("error", resp.Error).Msg("Order routed in part")
+		return
+	}
+	log.Info().Str("executed_quantity", resp.ExecutedQuantity).Msg("Order routed")
+}
+
+// runWatchBook streams the events of book to stdout until interrupted, then
+// prints how many events arrived. It does not use main's context, whose
+// timeout would end the stream.
+func runWatchBook(client proto.OrderBookServiceClient, book, format, filter string) {
+	eventTypes, err := parseEventTypes(filter)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid filter")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	start := time.Now()
+	received, err := watchBook(ctx, client, book, watchOptions{
+		format:     format,
+		eventTypes: eventTypes,
+		maxRetries: watchMaxRetries,
+		baseDelay:  watchBaseDelay,
+	}, os.Stdout)
+	// The summary goes to stderr so --format=json output stays parseable
+	fmt.Fprintf(os.Stderr, "Received %d events in %.1f seconds\n", received, time.Since(start).Seconds())
+	if err != nil {
+		fatalRPCError(err, "WatchOrderBook failed")
+	}
+}
+
+// runWatchTrades streams the trades of book to stdout until interrupted,
+// then prints how many trades arrived
+func runWatchTrades(client proto.OrderBookServiceClient, book, format string) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	start := time.Now()
+	received, err := subscribeTrades(ctx, client, book, tradeFeedOptions{
+		format:     format,
+		maxRetries: watchMaxRetries,
+		baseDelay:  watchBaseDelay,
+	}, os.Stdout)
+	fmt.Fprintf(os.Stderr, "Received %d trades in %.1f seconds\n", received, time.Since(start).Seconds())
+	if err != nil {
+		fatalRPCError(err, "SubscribeTrades failed")
+	}
+}
+
+func getOrderBookState(ctx context.Context, client proto.OrderBookServiceClient, name string, depth int32, bucketSize string) error {
 	color.NoColor = false
 	cyan := color.New(color.FgCyan).SprintfFunc()
 	red := color.New(color.FgRed).SprintfFunc()
 	green := color.New(color.FgGreen).SprintfFunc()
 
 	req := &proto.GetOrderBookStateRequest{
-		Name: name,
+		Name:       name,
+		Depth:      depth,
+		BucketSize: bucketSize,
 	}
 
 	resp, err := client.GetOrderBookState(ctx, req)
@@ -399,12 +914,12 @@ func getOrderBookState(ctx context.Context, client proto.OrderBookServiceClient,
 		"----")
 
 	// Print asks (sells)
+	// Prices and quantities are shown as formatted by the server, with the
+	// book's precision
 	for _, level := range resp.Asks {
-		price, _ := strconv.ParseFloat(level.Price, 64)
-		qty, _ := strconv.ParseFloat(level.TotalQuantity, 64)
-		fmt.Fprintf(w, "%15.3f|%15.3f|%15d|%15s|%s\n",
-			price,
-			qty,
+		fmt.Fprintf(w, "%15s|%15s|%15d|%15s|%s\n",
+			level.Price,
+			level.TotalQuantity,
 			level.OrderCount,
 			level.UserAddress,
 			red("ASK"))
@@ -420,11 +935,9 @@ func getOrderBookState(ctx context.Context, client proto.OrderBookServiceClient,
 
 	// Print bids (buys)
 	for _, level := range resp.Bids {
-		price, _ := strconv.ParseFloat(level.Price, 64)
-		qty, _ := strconv.ParseFloat(level.TotalQuantity, 64)
-		fmt.Fprintf(w, "%15.3f|%15.3f|%15d|%15s|%s\n",
-			price,
-			qty,
+		fmt.Fprintf(w, "%15s|%15s|%15d|%15s|%s\n",
+			level.Price,
+			level.TotalQuantity,
 			level.OrderCount,
 			level.UserAddress,
 			green("BID"))
@@ -433,6 +946,36 @@ func getOrderBookState(ctx context.Context, client proto.OrderBookServiceClient,
 	return w.Flush()
 }
 
+// fatalRPCError logs a failed RPC and exits. Field violations attached to
+// the error by the server are printed as a table first.
+func fatalRPCError(err error, msg string) {
+	printFieldViolations(err)
+	log.Fatal().Err(err).Msg(msg)
+}
+
+// printFieldViolations prints any BadRequest field violations carried by err
+func printFieldViolations(err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return
+	}
+
+	for _, detail := range st.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok || len(badRequest.FieldViolations) == 0 {
+			continue
+		}
+
+		red := color.New(color.FgRed).SprintfFunc()
+		w := tabwriter.NewWriter(os.Stderr, 0, 0, 3, ' ', 0)
+		fmt.Fprintf(w, "%s\t%s\n", red("FIELD"), red("ERROR"))
+		for _, v := range badRequest.FieldViolations {
+			fmt.Fprintf(w, "%s\t%s\n", v.Field, v.Description)
+		}
+		w.Flush()
+	}
+}
+
 // Helper function to parse float strings safely
 func parseFloat(s string) float64 {
 	f, _ := strconv.ParseFloat(s, 64)
@@ -441,19 +984,45 @@ func parseFloat(s string) float64 {
 
 func printUsage() {
 	fmt.Println("Usage:")
-	fmt.Println("  create-book <name> [--backend=memory|redis]")
+	fmt.Println("  create-book <name> [--backend=memory|redis] [--max-price-deviation-pct=P] [--price-precision=N] [--qty-precision=N] [--max-order-age=D] [--warmup [--warmup-levels=N] [--warmup-base-price=P] [--warmup-tick=T] [--warmup-qty=Q]]")
 	fmt.Println("  get-book <name>")
 	fmt.Println("  list-books [--limit=N] [--offset=N]")
 	fmt.Println("  delete-book <name>")
-	fmt.Println("  create-order <book> <side> <type> <quantity> <price> <id> <user_address>")
+	fmt.Println("  create-order <book> <side> <type> <quantity> <price> <id> <user_address> [--stop-price=<price>] [--post-only] [--visible-qty=<quantity>] [--client-order-id=<key>]")
 	fmt.Println("  get-order <book> <id>")
+	fmt.Println("  batch-get-orders <book> <id,id,...> | batch-get-orders <book> --file=<path>")
+	fmt.Println("  list-stop-orders <book> [--side=buy|sell] [--limit=N] [--offset=N]")
 	fmt.Println("  cancel-order <book> <id>")
-	fmt.Println("  get-state <book>")
+	fmt.Println("  amend-order <book> <id> [--price=P] [--qty=Q]")
+	fmt.Println("  get-state <book> [--depth=N]")
+	fmt.Println("  get-depth-at-price <book> <side> <price>")
+	fmt.Println("  get-bbo <book>")
+	fmt.Println("  vwap <book> [--window=SECONDS]")
+	fmt.Println("  twap <book> [--window=SECONDS]")
+	fmt.Println("  get-positions [--user=ADDR]")
+	fmt.Println("  book-notional <book> [--shock-pct=P]")
+	fmt.Println("  route-order <book,book,...> <side> <type> <quantity> <price> <id> [--strategy=best|proportional]")
+	fmt.Println("  watch-book <book> [--format=table|json] [--filter=trade,add,cancel,amend]")
+	fmt.Println("  watch-trades <book> [--format=table|json]")
 	fmt.Println("\nExamples:")
 	fmt.Println("  create-book mybook --backend=memory")
+	fmt.Println("  create-book mybook --warmup --warmup-levels=5 --warmup-base-price=100.0 --warmup-tick=0.5")
 	fmt.Println("  create-order default SELL LIMIT 0.5 100.0 sell1 0x1234567890123456789012345678901234567890")
 	fmt.Println("  create-order default BUY MARKET 1.0 0.0 buy1 0x1234567890123456789012345678901234567890")
+	fmt.Println("  create-order default SELL LIMIT 0.5 101.0 sell2 0x1234567890123456789012345678901234567890 --post-only")
 	fmt.Println("  get-order default sell1")
+	fmt.Println("  batch-get-orders default sell1,sell2,buy1")
+	fmt.Println("  list-stop-orders default --side=buy --limit=10")
 	fmt.Println("  cancel-order default sell1")
-	fmt.Println("  get-state default")
+	fmt.Println("  amend-order default sell1 --qty=0.25")
+	fmt.Println("  get-state default --depth=5")
+	fmt.Println("  get-depth-at-price default SELL 100.0")
+	fmt.Println("  get-bbo default")
+	fmt.Println("  vwap default --window=3600")
+	fmt.Println("  twap default --window=300")
+	fmt.Println("  get-positions --user=0x1234567890123456789012345678901234567890")
+	fmt.Println("  book-notional default --shock-pct=10")
+	fmt.Println("  route-order book1,book2 BUY MARKET 12.0 0.0 buy2 --strategy=proportional")
+	fmt.Println("  watch-book default --filter=trade,cancel")
+	fmt.Println("  watch-trades default --format=json")
 }
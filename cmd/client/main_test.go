@@ -265,5 +265,5 @@ func TestGetOrderBookState(t *testing.T) {
 	}
 
 	// Run the test
-	getOrderBookState(ctx, client, bookName)
+	getOrderBookState(ctx, client, bookName, 20, "")
 }
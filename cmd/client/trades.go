@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/fatih/color"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// tradeFeedOptions configures subscribeTrades
+type tradeFeedOptions struct {
+	// format is "table" or "json"
+	format     string
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+// subscribeTrades prints the trades of book to out until ctx is canceled.
+// Streams that drop, including those the server ends because the client fell
+// behind, are resubscribed with exponential backoff, giving up after
+// opts.maxRetries attempts without receiving a trade. It returns the number
+// of trades received.
+func subscribeTrades(ctx context.Context, client proto.OrderBookServiceClient, book string, opts tradeFeedOptions, out io.Writer) (int, error) {
+	printer, err := newTradePrinter(out, opts.format)
+	if err != nil {
+		return 0, err
+	}
+
+	req := &proto.SubscribeTradesRequest{OrderBookName: book}
+
+	received := 0
+	retries := 0
+	delay := opts.baseDelay
+	for {
+		stream, err := client.SubscribeTrades(ctx, req)
+		for err == nil {
+			var trade *proto.TradeEvent
+			trade, err = stream.Recv()
+			if err != nil {
+				break
+			}
+			received++
+			retries, delay = 0, opts.baseDelay
+			if err = printer.print(trade); err != nil {
+				return received, err
+			}
+		}
+
+		if ctx.Err() != nil {
+			return received, nil
+		}
+		switch status.Code(err) {
+		case codes.NotFound, codes.InvalidArgument, codes.Unimplemented:
+			return received, err
+		}
+		if retries >= opts.maxRetries {
+			return received, fmt.Errorf("trade stream dropped after %d reconnect attempts: %w", retries, err)
+		}
+		retries++
+
+		log.Warn().Err(err).Int("attempt", retries).Dur("delay", delay).Msg("Trade stream dropped, resubscribing")
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return received, nil
+		}
+		delay *= 2
+	}
+}
+
+// tradePrinter writes trades to an output in one format
+type tradePrinter struct {
+	out    io.Writer
+	format string
+	table  *tabwriter.Writer
+	header bool
+}
+
+func newTradePrinter(out io.Writer, format string) (*tradePrinter, error) {
+	switch format {
+	case "table":
+		return &tradePrinter{out: out, format: format, table: tabwriter.NewWriter(out, 14, 0, 2, ' ', 0)}, nil
+	case "json":
+		return &tradePrinter{out: out, format: format}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func (p *tradePrinter) print(trade *proto.TradeEvent) error {
+	if p.format == "json" {
+		data, err := protojson.Marshal(trade)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.out, string(data))
+		return err
+	}
+
+	if !p.header {
+		fmt.Fprintln(p.table, "Time\tSide\tPrice\tQuantity\tMaker\tTaker")
+		p.header = true
+	}
+
+	timestamp := ""
+	if trade.Timestamp != nil {
+		timestamp = trade.Timestamp.AsTime().Local().Format("15:04:05.000")
+	}
+	sideColor := color.New(color.FgGreen)
+	if trade.Side == proto.OrderSide_SELL {
+		sideColor = color.New(color.FgRed)
+	}
+	fmt.Fprintf(p.table, "%s\t%s\t%s\t%s\t%s\t%s\n",
+		timestamp,
+		sideColor.Sprint(trade.Side),
+		trade.Price,
+		trade.Quantity,
+		trade.MakerOrderId,
+		trade.TakerOrderId)
+	return p.table.Flush()
+}
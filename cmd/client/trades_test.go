@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tradeServer streams scripted trades. Its first stream sends two trades and
+// then drops the subscriber as too slow; later streams send the rest and
+// stay open.
+type tradeServer struct {
+	proto.UnimplementedOrderBookServiceServer
+
+	mu      sync.Mutex
+	streams int
+}
+
+func (s *tradeServer) SubscribeTrades(req *proto.SubscribeTradesRequest, stream proto.OrderBookService_SubscribeTradesServer) error {
+	s.mu.Lock()
+	s.streams++
+	first := s.streams == 1
+	s.mu.Unlock()
+
+	from, to := 3, 4
+	if first {
+		from, to = 1, 2
+	}
+	for i := from; i <= to; i++ {
+		if err := stream.Send(&proto.TradeEvent{
+			OrderBookName: req.OrderBookName,
+			MakerOrderId:  fmt.Sprintf("maker-%d", i),
+			TakerOrderId:  "taker",
+			Price:         "100.000",
+			Quantity:      "1.000",
+			Side:          proto.OrderSide_BUY,
+		}); err != nil {
+			return err
+		}
+	}
+	if first {
+		return status.Error(codes.ResourceExhausted, "trade subscriber fell behind")
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestSubscribeTrades(t *testing.T) {
+	for _, format := range []string{"table", "json"} {
+		t.Run(format, func(t *testing.T) {
+			conn := dialTestServer(t, &tradeServer{})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var out syncBuffer
+			type result struct {
+				received int
+				err      error
+			}
+			results := make(chan result, 1)
+			go func() {
+				received, err := subscribeTrades(ctx, proto.NewOrderBookServiceClient(conn), "test-book", tradeFeedOptions{
+					format:     format,
+					maxRetries: watchMaxRetries,
+					baseDelay:  time.Millisecond,
+				}, &out)
+				results <- result{received, err}
+			}()
+
+			require.Eventually(t, func() bool {
+				return strings.Contains(out.String(), "maker-4")
+			}, 5*time.Second, 10*time.Millisecond)
+			cancel()
+
+			res := <-results
+			require.NoError(t, res.err)
+			assert.Equal(t, 4, res.received)
+			for i := 1; i <= 4; i++ {
+				assert.Contains(t, out.String(), fmt.Sprintf("maker-%d", i))
+			}
+		})
+	}
+}
+
+func TestSubscribeTradesUnimplemented(t *testing.T) {
+	conn := dialTestServer(t, &proto.UnimplementedOrderBookServiceServer{})
+	_, err := subscribeTrades(context.Background(), proto.NewOrderBookServiceClient(conn), "test-book", tradeFeedOptions{
+		format:     "table",
+		maxRetries: watchMaxRetries,
+		baseDelay:  time.Millisecond,
+	}, &syncBuffer{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/fatih/color"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// watchMaxRetries is how many times in a row watch-book reconnects
+	// after its stream drops before giving up
+	watchMaxRetries = 5
+	// watchBaseDelay is the wait before the first reconnect; it doubles
+	// after every failed attempt
+	watchBaseDelay = 500 * time.Millisecond
+)
+
+// watchOptions configures watchBook
+type watchOptions struct {
+	// format is "table" or "json"
+	format     string
+	eventTypes []proto.OrderBookEventType
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+// parseEventTypes parses a comma-separated list of event type names such as
+// "trade,cancel". An empty filter selects every event type.
+func parseEventTypes(filter string) ([]proto.OrderBookEventType, error) {
+	var types []proto.OrderBookEventType
+	for _, name := range strings.Split(filter, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		value, ok := proto.OrderBookEventType_value[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown event type %q", name)
+		}
+		types = append(types, proto.OrderBookEventType(value))
+	}
+	return types, nil
+}
+
+// watchBook prints the events of book to out until ctx is canceled. When the
+// stream drops it reconnects with exponential backoff, giving up after
+// opts.maxRetries attempts without receiving an event. It returns the number
+// of events received.
+func watchBook(ctx context.Context, client proto.OrderBookServiceClient, book string, opts watchOptions, out io.Writer) (int, error) {
+	printer, err := newEventPrinter(out, opts.format)
+	if err != nil {
+		return 0, err
+	}
+
+	req := &proto.WatchOrderBookRequest{
+		OrderBookName: book,
+		EventTypes:    opts.eventTypes,
+	}
+
+	received := 0
+	retries := 0
+	delay := opts.baseDelay
+	for {
+		stream, err := client.WatchOrderBook(ctx, req)
+		for err == nil {
+			var event *proto.OrderBookEvent
+			event, err = stream.Recv()
+			if err != nil {
+				break
+			}
+			received++
+			retries, delay = 0, opts.baseDelay
+			if err = printer.print(event); err != nil {
+				return received, err
+			}
+		}
+
+		if ctx.Err() != nil {
+			return received, nil
+		}
+		switch status.Code(err) {
+		case codes.NotFound, codes.InvalidArgument, codes.Unimplemented:
+			return received, err
+		}
+		if retries >= opts.maxRetries {
+			return received, fmt.Errorf("stream dropped after %d reconnect attempts: %w", retries, err)
+		}
+		retries++
+
+		log.Warn().Err(err).Int("attempt", retries).Dur("delay", delay).Msg("Order book stream dropped, reconnecting")
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return received, nil
+		}
+		delay *= 2
+	}
+}
+
+// eventPrinter writes order book events to an output in one format
+type eventPrinter struct {
+	out    io.Writer
+	format string
+	table  *tabwriter.Writer
+	header bool
+}
+
+func newEventPrinter(out io.Writer, format string) (*eventPrinter, error) {
+	switch format {
+	case "table":
+		// Rows are flushed one at a time, so a minimum cell width keeps
+		// the columns lined up from one event to the next
+		return &eventPrinter{out: out, format: format, table: tabwriter.NewWriter(out, 14, 0, 2, ' ', 0)}, nil
+	case "json":
+		return &eventPrinter{out: out, format: format}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func (p *eventPrinter) print(event *proto.OrderBookEvent) error {
+	if p.format == "json" {
+		data, err := protojson.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.out, string(data))
+		return err
+	}
+
+	if !p.header {
+		fmt.Fprintln(p.table, "Time\tType\tOrder\tSide\tPrice\tQuantity\tMaker")
+		p.header = true
+	}
+
+	timestamp := ""
+	if event.Timestamp != nil {
+		timestamp = event.Timestamp.AsTime().Local().Format("15:04:05.000")
+	}
+	fmt.Fprintf(p.table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		timestamp,
+		eventTypeColor(event.Type).Sprint(event.Type),
+		event.OrderId,
+		event.Side.String(),
+		event.Price,
+		event.Quantity,
+		event.MakerOrderId)
+	// Flush every row so events show up as they arrive
+	return p.table.Flush()
+}
+
+func eventTypeColor(eventType proto.OrderBookEventType) *color.Color {
+	switch eventType {
+	case proto.OrderBookEventType_TRADE:
+		return color.New(color.FgGreen)
+	case proto.OrderBookEventType_CANCEL:
+		return color.New(color.FgRed)
+	case proto.OrderBookEventType_AMEND:
+		return color.New(color.FgCyan)
+	default:
+		return color.New(color.FgYellow)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// watchServer streams scripted events. Its first stream sends three events
+// and then drops; later streams send the rest and stay open.
+type watchServer struct {
+	proto.UnimplementedOrderBookServiceServer
+
+	mu      sync.Mutex
+	streams int
+}
+
+func (s *watchServer) WatchOrderBook(req *proto.WatchOrderBookRequest, stream proto.OrderBookService_WatchOrderBookServer) error {
+	s.mu.Lock()
+	s.streams++
+	first := s.streams == 1
+	s.mu.Unlock()
+
+	from, to := 4, 5
+	if first {
+		from, to = 1, 3
+	}
+	for i := from; i <= to; i++ {
+		if err := stream.Send(&proto.OrderBookEvent{
+			Type:          proto.OrderBookEventType_ADD,
+			OrderBookName: req.OrderBookName,
+			OrderId:       fmt.Sprintf("order-%d", i),
+			Side:          proto.OrderSide_BUY,
+			Price:         "100.000",
+			Quantity:      "1.000",
+		}); err != nil {
+			return err
+		}
+	}
+	if first {
+		return status.Error(codes.Unavailable, "connection reset")
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+// syncBuffer is a bytes.Buffer safe to read while watchBook writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// dialWatchServer starts a fresh watchServer on an in-memory listener and
+// returns a client connected to it
+func dialWatchServer(t *testing.T) *grpc.ClientConn {
+	return dialTestServer(t, &watchServer{})
+}
+
+// dialTestServer serves impl on an in-memory listener and returns a client
+// connected to it
+func dialTestServer(t *testing.T, impl proto.OrderBookServiceServer) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	proto.RegisterOrderBookServiceServer(server, impl)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestWatchBook(t *testing.T) {
+	for _, format := range []string{"table", "json"} {
+		t.Run(format, func(t *testing.T) {
+			conn := dialWatchServer(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var out syncBuffer
+			type result struct {
+				received int
+				err      error
+			}
+			results := make(chan result, 1)
+			go func() {
+				received, err := watchBook(ctx, proto.NewOrderBookServiceClient(conn), "test-book", watchOptions{
+					format:     format,
+					maxRetries: watchMaxRetries,
+					baseDelay:  time.Millisecond,
+				}, &out)
+				results <- result{received, err}
+			}()
+
+			require.Eventually(t, func() bool {
+				return strings.Contains(out.String(), "order-5")
+			}, 5*time.Second, 10*time.Millisecond)
+			cancel()
+
+			res := <-results
+			require.NoError(t, res.err)
+			assert.Equal(t, 5, res.received)
+			for i := 1; i <= 5; i++ {
+				assert.Contains(t, out.String(), fmt.Sprintf("order-%d", i))
+			}
+		})
+	}
+}
+
+func TestParseEventTypes(t *testing.T) {
+	types, err := parseEventTypes("trade, Cancel")
+	require.NoError(t, err)
+	assert.Equal(t, []proto.OrderBookEventType{proto.OrderBookEventType_TRADE, proto.OrderBookEventType_CANCEL}, types)
+
+	types, err = parseEventTypes("")
+	require.NoError(t, err)
+	assert.Empty(t, types)
+
+	_, err = parseEventTypes("fill")
+	assert.Error(t, err)
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -37,14 +39,18 @@ func main() {
 	defer orderPlacer.Close()
 
 	// Initialize the price fetcher
-	priceFetcher, err := marketmaker.NewPriceFetcher(cfg, logger)
+	priceFetcher, err := marketmaker.NewPriceFetcherChain(cfg, logger)
 	if err != nil {
 		logger.Error("Failed to create price fetcher", "error", err)
 		os.Exit(1)
 	}
 
 	// Initialize the market maker strategy
-	strategy := marketmaker.NewLayeredSymmetricQuoting(cfg, logger)
+	strategy, err := marketmaker.NewStrategy(cfg, logger)
+	if err != nil {
+		logger.Error("Failed to create strategy", "error", err)
+		os.Exit(1)
+	}
 
 	// Create and start the market maker service
 	mm, err := marketmaker.NewMarketMaker(cfg, logger, orderPlacer, priceFetcher, strategy)
@@ -58,6 +64,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Start the introspection HTTP server
+	httpServer := &http.Server{
+		Addr:    cfg.HTTPAddr,
+		Handler: marketmaker.NewHTTPHandler(strategy),
+	}
+	go func() {
+		logger.Info("Starting HTTP server", "addr", cfg.HTTPAddr, "strategy", strategy.Name())
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("HTTP server error", "error", err)
+		}
+	}()
+
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -70,6 +88,10 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP server shutdown error", "error", err)
+	}
+
 	// Stop the market maker service
 	if err := mm.Stop(shutdownCtx); err != nil {
 		logger.Error("Error during shutdown", "error", err)
@@ -8,21 +8,39 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/erain9/matchingo/config"
 	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
 	"github.com/erain9/matchingo/pkg/db/queue"
+	"github.com/erain9/matchingo/pkg/lock"
+	"github.com/erain9/matchingo/pkg/messaging"
 	"github.com/erain9/matchingo/pkg/messaging/kafka"
+	"github.com/erain9/matchingo/pkg/messaging/nats"
+	"github.com/erain9/matchingo/pkg/metrics"
+	"github.com/erain9/matchingo/pkg/middleware"
 	"github.com/erain9/matchingo/pkg/otel"
+	"github.com/erain9/matchingo/pkg/replay"
 	"github.com/erain9/matchingo/pkg/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
+// Messaging backends, picked with the MESSAGING_BACKEND environment variable
+const (
+	messagingKafka = "kafka"
+	messagingNATS  = "nats"
+)
+
 func main() {
 	// Load configuration
 	cfg, err := config.LoadConfig()
@@ -45,25 +63,81 @@ func main() {
 	// Create default context with logger
 	ctx := logger.WithContext(context.Background())
 
+	// Pick the broker done messages are published to
+	backend := os.Getenv("MESSAGING_BACKEND")
+	if backend == "" {
+		backend = messagingKafka
+	}
+	switch backend {
+	case messagingKafka:
+		// Send each order book's messages to a Kafka topic of its own
+		if cfg.Kafka.TopicPrefix != "" {
+			sender, err := kafka.NewKafkaMessageSender(cfg.Kafka.BrokerAddr, cfg.Kafka.Topic,
+				kafka.WithSerializer(messaging.ProtoSerializer{}),
+				kafka.WithTopicRouter(messaging.PrefixRouter{Prefix: cfg.Kafka.TopicPrefix}),
+			)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to create Kafka sender")
+			}
+			defer sender.Close()
+			core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+		}
+	case messagingNATS:
+		sender, err := nats.NewNATSMessageSender(cfg.NATS.URL)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to create NATS sender")
+		}
+		defer sender.Close()
+		core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	default:
+		logger.Fatal().Str("backend", backend).Msg("Unknown MESSAGING_BACKEND, expected kafka or nats")
+	}
+	logger.Info().Str("backend", backend).Msg("Publishing done messages")
+
 	// Create a new order book manager
 	manager := server.NewOrderBookManager()
-	defer manager.Close()
 
-	// Create a test order book
-	_, err = manager.CreateMemoryOrderBook(ctx, "test")
+	// Report NOT_SERVING to health probes until the first order book exists
+	healthServer := server.NewHealthServer(manager)
+
+	// Purge soft-deleted order books once their retention period expires
+	manager.SetRetentionPeriod(cfg.Server.OrderBookRetention)
+	manager.StartPurger(ctx, time.Minute)
+
+	// Export the notional value resting on each book
+	manager.StartNotionalRecorder(ctx, 15*time.Second)
+
+	// Cancel orders past their GTD expiry or resting longer than the configured maximum age
+	manager.SetOrderBookPolicy(core.OrderBookPolicy{
+		MaxOrderAge:   cfg.Server.MaxOrderAge,
+		SweepInterval: cfg.Server.SweepInterval,
+	})
+
+	// Keep recent trades for CalculateVWAP and CalculateTWAP
+	manager.SetTradeHistorySize(cfg.Server.TradeHistorySize)
+
+	// Create a test order book, restoring its orders from the last snapshot
+	_, err = manager.CreateMemoryOrderBook(ctx, "test", "", core.WithSnapshotPath(cfg.Server.SnapshotPath))
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to create test order book")
 	}
 
 	logger.Info().Str("name", "test").Msg("Created test order book")
 
-	// Initialize Kafka consumer (optional)
+	// Initialize the done message consumer (optional)
 	// The consumer is for developer purpose which helps pretty print the message
 	// in the queue.
-	var kafkaConsumer *queue.QueueMessageConsumer
-	kafkaConsumer, err = kafka.SetupConsumer(ctx, logger)
-	if err == nil && kafkaConsumer != nil {
-		defer kafkaConsumer.Close()
+	if backend == messagingNATS {
+		natsConsumer, err := nats.SetupConsumer(ctx, logger, cfg.NATS.URL)
+		if err == nil && natsConsumer != nil {
+			defer natsConsumer.Close()
+		}
+	} else {
+		var kafkaConsumer *queue.QueueMessageConsumer
+		kafkaConsumer, err = kafka.SetupConsumer(ctx, logger, cfg.Kafka.TopicPrefix)
+		if err == nil && kafkaConsumer != nil {
+			defer kafkaConsumer.Close()
+		}
 	}
 
 	// Initialize OpenTelemetry
@@ -84,18 +158,73 @@ func main() {
 			Msg("OpenTelemetry initialized with multiple services")
 	}
 
+	orderBookService := server.NewGRPCOrderBookService(manager)
+	orderBookService.SetMatchingTimeout(cfg.Server.MatchingTimeout)
+	orderBookService.SetMatchLatencySLO(time.Duration(cfg.Metrics.SLOMatchLatencyMs * float64(time.Millisecond)))
+	orderBookService.SetIdempotencyCache(server.NewIdempotencyCache(server.DefaultIdempotencyCacheSize, cfg.Server.IdempotencyTTL))
+	if cfg.RateLimit.OrdersPerSecond > 0 {
+		orderBookService.SetOrderRateLimiter(server.NewOrderRateLimiter(cfg.RateLimit.OrdersPerSecond, cfg.RateLimit.Burst))
+	}
+	// Keep other instances sharing the Redis server out of a book while it changes
+	if cfg.Redis.BookLockTTL > 0 {
+		lockClient := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr, Password: cfg.Redis.Password, DB: cfg.Redis.DB})
+		defer lockClient.Close()
+		orderBookService.SetBookLocker(lock.NewRedisLock(lockClient, cfg.Redis.BookLockTTL))
+	}
+
 	// Setup gRPC server
-	grpcServer, err := setupGRPCServer(ctx, cfg, manager)
+	grpcServer, err := setupGRPCServer(ctx, cfg, orderBookService, healthServer)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to setup gRPC server")
 	}
 
+	// Stream order book diffs to WebSocket clients
+	sockets := server.NewWebSocketHub()
+	feeds := server.NewOrderBookFeeds(manager, sockets)
+
+	// Expose the order books to Prometheus scrapes
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics.NewCollector(manager))
+
+	// Serve the REST/JSON API by calling the gRPC server
+	gateway, err := server.NewGateway(ctx, cfg.Server.GRPCAddr)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to setup REST gateway")
+	}
+
+	// Rebuild memory order books from the messages they published to Kafka
+	replays := server.NewReplayHandler(manager, func(name string) replay.Source {
+		topic := cfg.Kafka.Topic
+		if cfg.Kafka.TopicPrefix != "" {
+			topic = messaging.PrefixRouter{Prefix: cfg.Kafka.TopicPrefix}.Route(name)
+		}
+		return &replay.KafkaSource{Brokers: []string{cfg.Kafka.BrokerAddr}, Topic: topic}
+	})
+
 	// Setup HTTP server
-	httpServer, err := setupHTTPServer(ctx, cfg, cfg.Server.GRPCAddr)
+	httpServer, err := setupHTTPServer(ctx, cfg, cfg.Server.GRPCAddr, server.NewVizHandler(orderBookService), server.NewStrategiesHandler(manager), server.NewSnapshotHandler(manager), replays, feeds, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), gateway)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to setup HTTP server")
 	}
 
+	// Serve pprof on its own listener so it is never exposed on the public HTTP address
+	var adminServer *server.AdminServer
+	if cfg.Admin.PProfEnabled {
+		adminServer = server.NewAdminServer(cfg.Admin.PProfAddr)
+		if err := adminServer.Start(ctx); err != nil {
+			logger.Fatal().Err(err).Msg("Failed to start admin server")
+		}
+	}
+
+	// Serve the admin gRPC service on its own listener, away from the public API
+	var adminGRPCServer *grpc.Server
+	if cfg.Admin.GRPCAddr != "" {
+		adminGRPCServer, err = setupAdminGRPCServer(ctx, cfg.Admin.GRPCAddr, server.NewAdminService(manager, orderBookService))
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to setup admin gRPC server")
+		}
+	}
+
 	// Wait for interrupt signal
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
@@ -103,22 +232,42 @@ func main() {
 
 	logger.Info().Str("signal", sig.String()).Msg("Received signal, shutting down")
 
-	// Graceful shutdown
-	grpcServer.GracefulStop()
+	// Graceful shutdown. Health probes and Watch streams see NOT_SERVING
+	// before connections are drained.
+	healthServer.Shutdown()
+	gracefulStop(grpcServer, 5*time.Second)
+	if adminGRPCServer != nil {
+		adminGRPCServer.GracefulStop()
+	}
 
-	// Create a context with timeout for HTTP server shutdown
+	// Create a context with timeout for order book and HTTP server shutdown
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if err := manager.CloseWithTimeout(shutdownCtx); err != nil {
+		logger.Error().Err(err).Msg("Order book manager shutdown error")
+	}
+
+	// Hijacked WebSocket connections are not closed by httpServer.Shutdown
+	if err := sockets.Shutdown(shutdownCtx); err != nil {
+		logger.Error().Err(err).Msg("WebSocket shutdown error")
+	}
+
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
 		logger.Error().Err(err).Msg("HTTP server shutdown error")
 	}
 
+	if adminServer != nil {
+		if err := adminServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error().Err(err).Msg("Admin server shutdown error")
+		}
+	}
+
 	logger.Info().Msg("Servers shutdown complete")
 }
 
 // setupGRPCServer initializes and starts a gRPC server
-func setupGRPCServer(ctx context.Context, cfg *config.Config, manager *server.OrderBookManager) (*grpc.Server, error) {
+func setupGRPCServer(ctx context.Context, cfg *config.Config, orderBookService *server.GRPCOrderBookService, healthServer healthpb.HealthServer) (*grpc.Server, error) {
 	logger := zerolog.Ctx(ctx)
 
 	// Start gRPC server
@@ -150,15 +299,19 @@ func setupGRPCServer(ctx context.Context, cfg *config.Config, manager *server.Or
 	grpcServer := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			otelgrpc.UnaryServerInterceptor(otelOpts...),
+			middleware.UnaryLoggingInterceptor(*logger),
 			metricsUnaryInterceptor,
+			server.MaxRequestSizeInterceptor(0, server.DefaultRequestSizeLimits),
+			server.MaxResponseSizeInterceptor(server.DefaultMaxResponseSize, nil),
 		),
 		grpc.ChainStreamInterceptor(
 			otelgrpc.StreamServerInterceptor(otelOpts...),
+			middleware.StreamLoggingInterceptor(*logger),
 			metricsStreamInterceptor,
 		),
 	)
-	orderBookService := server.NewGRPCOrderBookService(manager)
 	proto.RegisterOrderBookServiceServer(grpcServer, orderBookService)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
 
 	// Enable reflection for tools like grpcurl
 	reflection.Register(grpcServer)
@@ -173,9 +326,48 @@ func setupGRPCServer(ctx context.Context, cfg *config.Config, manager *server.Or
 	return grpcServer, nil
 }
 
+// gracefulStop stops grpcServer once its RPCs finish, or at once when they
+// take longer than timeout. Streams such as health Watch calls only end when
+// their client cancels them.
+func gracefulStop(grpcServer *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		grpcServer.Stop()
+	}
+}
+
+// setupAdminGRPCServer starts a gRPC server serving only the admin service
+func setupAdminGRPCServer(ctx context.Context, addr string, adminService *server.AdminService) (*grpc.Server, error) {
+	logger := zerolog.Ctx(ctx)
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(middleware.UnaryLoggingInterceptor(*logger)))
+	proto.RegisterAdminServiceServer(grpcServer, adminService)
+	reflection.Register(grpcServer)
+
+	go func() {
+		logger.Info().Str("addr", addr).Msg("Starting admin gRPC server")
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Fatal().Err(err).Msg("Failed to serve admin gRPC")
+		}
+	}()
+	return grpcServer, nil
+}
+
 // setupHTTPServer initializes and starts an HTTP server
-func setupHTTPServer(ctx context.Context, cfg *config.Config, grpcAddr string) (*http.Server, error) {
+func setupHTTPServer(ctx context.Context, cfg *config.Config, grpcAddr string, viz, strategies, snapshots, replays, feeds, exporter, gateway http.Handler) (*http.Server, error) {
 	logger := zerolog.Ctx(ctx)
+	openAPI := server.NewOpenAPIHandler()
 
 	// Start HTTP server for REST API (optional)
 	httpAddr := cfg.Server.HTTPAddr
@@ -196,11 +388,52 @@ func setupHTTPServer(ctx context.Context, cfg *config.Config, grpcAddr string) (
 				fmt.Fprintf(w, "<html><body>")
 				fmt.Fprintf(w, "<h1>Matchingo Order Book Server</h1>")
 				fmt.Fprintf(w, "<p>The gRPC server is running on %s</p>", grpcAddr)
-				fmt.Fprintf(w, "<p>This is a simple HTTP interface. Use the gRPC client for full functionality.</p>")
+				fmt.Fprintf(w, "<p>The REST API is served under %s</p>", server.GatewayPath)
+				fmt.Fprintf(w, "<p>Its OpenAPI description is at %s</p>", server.OpenAPIPath)
 				fmt.Fprintf(w, "</body></html>")
 				return
 			}
 
+			if r.URL.Path == "/viz" {
+				viz.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if r.URL.Path == "/admin/strategies" {
+				strategies.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if r.URL.Path == "/metrics" {
+				exporter.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if strings.HasPrefix(r.URL.Path, server.SnapshotPath) {
+				snapshots.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if strings.HasPrefix(r.URL.Path, server.ReplayPath) {
+				replays.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if strings.HasPrefix(r.URL.Path, server.OrderBookFeedPath) {
+				feeds.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if strings.HasPrefix(r.URL.Path, server.GatewayPath) {
+				gateway.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if r.URL.Path == server.OpenAPIPath {
+				openAPI.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			http.NotFound(w, r.WithContext(ctx))
 		}),
 	}
@@ -4,12 +4,22 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/erain9/matchingo/pkg/db/queue"
+	"github.com/rs/zerolog"
+	"go.uber.org/multierr"
 	"gopkg.in/yaml.v3"
 )
 
+// dialTimeout bounds each reachability check made by Validate
+const dialTimeout = 2 * time.Second
+
 // Config represents the application configuration
 type Config struct {
 	Server struct {
@@ -17,27 +27,89 @@ type Config struct {
 		HTTPAddr  string `yaml:"http_addr"`
 		LogLevel  string `yaml:"log_level"`
 		LogFormat string `yaml:"log_format"`
+		// OrderBookRetention is how long soft-deleted order books are kept before being purged
+		OrderBookRetention time.Duration `yaml:"order_book_retention"`
+		// MaxOrderAge is how long an order may rest before it is canceled; zero disables the limit
+		MaxOrderAge time.Duration `yaml:"max_order_age"`
+		// SweepInterval is how often resting orders are checked against MaxOrderAge
+		// and their GTD expiry
+		SweepInterval time.Duration `yaml:"sweep_interval"`
+		// MatchingTimeout bounds how long CreateOrder may spend matching one
+		// order; zero disables the limit
+		MatchingTimeout time.Duration `yaml:"matching_timeout"`
+		// IdempotencyTTL is how long CreateOrder remembers a client order ID
+		// and answers repeats of it with the first response
+		IdempotencyTTL time.Duration `yaml:"idempotency_ttl"`
+		// SnapshotPath is the file the startup order book is restored from
+		// and saved to by POST /admin/snapshot/{name}; empty disables both
+		SnapshotPath string `yaml:"snapshot_path"`
+		// TradeHistorySize is how many recent trades each order book keeps
+		// for CalculateVWAP and CalculateTWAP
+		TradeHistorySize int `yaml:"trade_history_size"`
 	} `yaml:"server"`
 
 	Redis struct {
 		Addr     string `yaml:"addr"`
 		Password string `yaml:"password"`
 		DB       int    `yaml:"db"`
+		// BookLockTTL is the lease of the lock CreateOrder, CancelOrder and
+		// AmendOrder hold on an order book in Redis, so instances sharing the
+		// server change a book one at a time. Zero disables the lock.
+		BookLockTTL time.Duration `yaml:"book_lock_ttl"`
 	} `yaml:"redis"`
 
 	Kafka struct {
 		BrokerAddr string `yaml:"broker_addr"`
 		Topic      string `yaml:"topic"`
+		// TopicPrefix sends the messages of each order book to a topic of
+		// its own, named TopicPrefix.{book}; empty sends them all to Topic
+		TopicPrefix string `yaml:"topic_prefix"`
 	} `yaml:"kafka"`
+
+	NATS struct {
+		// URL is the NATS server, or a comma-separated list of servers,
+		// done messages are published to when the server runs with
+		// MESSAGING_BACKEND=nats
+		URL string `yaml:"url"`
+	} `yaml:"nats"`
+
+	Metrics struct {
+		// SLOMatchLatencyMs is the objective for the 99th percentile of match
+		// durations in milliseconds; a warning is logged while it is missed.
+		// Zero disables the check.
+		SLOMatchLatencyMs float64 `yaml:"slo_match_latency_ms"`
+	} `yaml:"metrics"`
+
+	Admin struct {
+		// PProfEnabled serves pprof profiles on a separate listener at PProfAddr
+		PProfEnabled bool   `yaml:"pprof_enabled"`
+		PProfAddr    string `yaml:"pprof_addr"`
+		// GRPCAddr is where the admin gRPC service listens; empty disables it
+		GRPCAddr string `yaml:"grpc_addr"`
+	} `yaml:"admin"`
+
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+}
+
+// RateLimitConfig limits how fast each user address may submit orders with
+// a token bucket: OrdersPerSecond tokens are added every second, up to Burst.
+// Zero OrdersPerSecond disables the limit.
+type RateLimitConfig struct {
+	OrdersPerSecond float64 `yaml:"orders_per_second"`
+	Burst           int     `yaml:"burst"`
 }
 
 // Default configuration values
 var (
-	configFile = flag.String("config", "", "Path to config file (YAML)")
-	grpcPort   = flag.Int("grpc_port", 50051, "The gRPC server port")
-	httpPort   = flag.Int("http_port", 8080, "The HTTP server port")
-	logLevel   = flag.String("log_level", "info", "Log level: debug, info, warn, error")
-	logFormat  = flag.String("log_format", "pretty", "Log format: json, pretty")
+	configFile  = flag.String("config", "", "Path to config file (YAML)")
+	grpcPort    = flag.Int("grpc_port", 50051, "The gRPC server port")
+	httpPort    = flag.Int("http_port", 8080, "The HTTP server port")
+	logLevel    = flag.String("log_level", "info", "Log level: debug, info, warn, error")
+	logFormat   = flag.String("log_format", "pretty", "Log format: json, pretty")
+	pprof       = flag.Bool("pprof", false, "Serve pprof profiles on the admin address")
+	pprofAddr   = flag.String("pprof_addr", "localhost:6060", "The admin address pprof is served on")
+	adminGRPC   = flag.String("admin_grpc_addr", "localhost:50052", "The admin-only address the admin gRPC service is served on; empty disables it")
+	topicPrefix = flag.String("kafka-topic-prefix", "", "Send each order book's messages to the Kafka topic <prefix>.<book>")
 )
 
 // LoadConfig loads the configuration from command line flags and optionally from a config file
@@ -51,9 +123,20 @@ func LoadConfig() (*Config, error) {
 	config.Server.HTTPAddr = fmt.Sprintf(":%d", *httpPort)
 	config.Server.LogLevel = *logLevel
 	config.Server.LogFormat = *logFormat
+	config.Server.OrderBookRetention = 24 * time.Hour
+	config.Server.SweepInterval = time.Second
+	config.Server.IdempotencyTTL = 24 * time.Hour
+	config.Server.TradeHistorySize = 10000
 	config.Redis.Addr = "localhost:6379"
 	config.Kafka.BrokerAddr = "localhost:9092"
 	config.Kafka.Topic = "test-msg-queue"
+	config.Kafka.TopicPrefix = *topicPrefix
+	config.NATS.URL = "nats://localhost:4222"
+	config.Metrics.SLOMatchLatencyMs = 10
+	config.Admin.PProfEnabled = *pprof
+	config.Admin.PProfAddr = *pprofAddr
+	config.Admin.GRPCAddr = *adminGRPC
+	config.RateLimit.Burst = 10
 
 	// Load configuration from file if specified
 	if *configFile != "" {
@@ -75,5 +158,122 @@ func LoadConfig() (*Config, error) {
 		log.Printf("Loaded configuration from %s", *configFile)
 	}
 
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	return config, nil
 }
+
+// Validate checks every field and returns all failures combined with
+// multierr, each prefixed by the field's YAML path. Redis and Kafka are
+// dialed to make sure they are reachable; an empty address skips the check.
+func (c *Config) Validate() error {
+	var err error
+
+	if !validHostPort(c.Server.GRPCAddr) {
+		err = multierr.Append(err, fmt.Errorf("server.grpc_addr: invalid format %q, expected host:port", c.Server.GRPCAddr))
+	}
+	if !validHostPort(c.Server.HTTPAddr) {
+		err = multierr.Append(err, fmt.Errorf("server.http_addr: invalid format %q, expected host:port", c.Server.HTTPAddr))
+	}
+	if _, parseErr := zerolog.ParseLevel(c.Server.LogLevel); parseErr != nil {
+		err = multierr.Append(err, fmt.Errorf("server.log_level: unknown level %q", c.Server.LogLevel))
+	}
+	if c.Server.MaxOrderAge < 0 {
+		err = multierr.Append(err, fmt.Errorf("server.max_order_age: must be positive, got %s", c.Server.MaxOrderAge))
+	}
+	if c.Server.MatchingTimeout < 0 {
+		err = multierr.Append(err, fmt.Errorf("server.matching_timeout: must be positive, got %s", c.Server.MatchingTimeout))
+	}
+	if c.Server.IdempotencyTTL < 0 {
+		err = multierr.Append(err, fmt.Errorf("server.idempotency_ttl: must be positive, got %s", c.Server.IdempotencyTTL))
+	}
+	if c.Server.TradeHistorySize < 0 {
+		err = multierr.Append(err, fmt.Errorf("server.trade_history_size: must be positive, got %d", c.Server.TradeHistorySize))
+	}
+
+	if c.Metrics.SLOMatchLatencyMs < 0 {
+		err = multierr.Append(err, fmt.Errorf("metrics.slo_match_latency_ms: must be positive, got %g", c.Metrics.SLOMatchLatencyMs))
+	}
+
+	if c.Redis.BookLockTTL < 0 {
+		err = multierr.Append(err, fmt.Errorf("redis.book_lock_ttl: must be positive, got %s", c.Redis.BookLockTTL))
+	}
+	if c.Redis.Addr != "" {
+		if dialErr := checkReachable(c.Redis.Addr); dialErr != nil {
+			err = multierr.Append(err, fmt.Errorf("redis.addr: %w", dialErr))
+		}
+	}
+	if c.Kafka.BrokerAddr != "" {
+		if dialErr := checkReachable(c.Kafka.BrokerAddr); dialErr != nil {
+			err = multierr.Append(err, fmt.Errorf("kafka.broker_addr: %w", dialErr))
+		}
+	}
+	if !validTopicPrefix(c.Kafka.TopicPrefix) {
+		err = multierr.Append(err, fmt.Errorf("kafka.topic_prefix: invalid topic name %q, expected letters, digits, '.', '_' or '-'", c.Kafka.TopicPrefix))
+	}
+	if c.NATS.URL != "" && !validNATSURL(c.NATS.URL) {
+		err = multierr.Append(err, fmt.Errorf("nats.url: invalid format %q, expected nats://host:port", c.NATS.URL))
+	}
+	if c.Admin.PProfEnabled && !validHostPort(c.Admin.PProfAddr) {
+		err = multierr.Append(err, fmt.Errorf("admin.pprof_addr: invalid format %q, expected host:port", c.Admin.PProfAddr))
+	}
+	if c.Admin.GRPCAddr != "" && !validHostPort(c.Admin.GRPCAddr) {
+		err = multierr.Append(err, fmt.Errorf("admin.grpc_addr: invalid format %q, expected host:port", c.Admin.GRPCAddr))
+	}
+	if c.RateLimit.OrdersPerSecond < 0 {
+		err = multierr.Append(err, fmt.Errorf("rate_limit.orders_per_second: must be positive, got %g", c.RateLimit.OrdersPerSecond))
+	}
+	if c.RateLimit.OrdersPerSecond > 0 && c.RateLimit.Burst < 1 {
+		err = multierr.Append(err, fmt.Errorf("rate_limit.burst: must be at least 1, got %d", c.RateLimit.Burst))
+	}
+
+	return err
+}
+
+// validHostPort reports whether addr is a host:port pair with a numeric port.
+// The host may be empty to listen on all interfaces.
+func validHostPort(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 0 && n <= 65535
+}
+
+// validTopicPrefix reports whether prefix only holds characters Kafka
+// allows in topic names
+func validTopicPrefix(prefix string) bool {
+	for _, r := range prefix {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// validNATSURL reports whether every comma-separated server in urls is a
+// URL with a host
+func validNATSURL(urls string) bool {
+	for _, server := range strings.Split(urls, ",") {
+		u, err := url.Parse(strings.TrimSpace(server))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// checkReachable opens and closes a TCP connection to addr
+func checkReachable(addr string) error {
+	if !validHostPort(addr) {
+		return fmt.Errorf("invalid format %q, expected host:port", addr)
+	}
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return fmt.Errorf("unreachable: %w", err)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+)
+
+// listen returns the address of a TCP listener that is closed when the test ends
+func listen(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+	return lis.Addr().String()
+}
+
+// closedAddr returns an address nothing is listening on
+func closedAddr(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+	return addr
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		cfg := &Config{}
+		cfg.Server.GRPCAddr = ":50051"
+		cfg.Server.HTTPAddr = "localhost:8080"
+		cfg.Server.LogLevel = "debug"
+		cfg.Server.MaxOrderAge = time.Hour
+		cfg.Redis.Addr = listen(t)
+		cfg.Kafka.BrokerAddr = listen(t)
+
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("ReportsEveryViolation", func(t *testing.T) {
+		cfg := &Config{}
+		cfg.Server.GRPCAddr = "50051"
+		cfg.Server.HTTPAddr = "localhost:http-port"
+		cfg.Server.LogLevel = "loud"
+		cfg.Server.MaxOrderAge = -time.Minute
+		cfg.Server.MatchingTimeout = -time.Second
+		cfg.Metrics.SLOMatchLatencyMs = -1
+		cfg.Redis.Addr = closedAddr(t)
+		cfg.Redis.BookLockTTL = -time.Second
+		cfg.Kafka.BrokerAddr = listen(t)
+
+		err := cfg.Validate()
+		require.Error(t, err)
+
+		errs := multierr.Errors(err)
+		require.Len(t, errs, 8, err.Error())
+		assert.Contains(t, errs[0].Error(), "server.grpc_addr: invalid format")
+		assert.Contains(t, errs[1].Error(), "server.http_addr: invalid format")
+		assert.Contains(t, errs[2].Error(), "server.log_level: unknown level")
+		assert.Contains(t, errs[3].Error(), "server.max_order_age: must be positive")
+		assert.Contains(t, errs[4].Error(), "server.matching_timeout: must be positive")
+		assert.Contains(t, errs[5].Error(), "metrics.slo_match_latency_ms: must be positive")
+		assert.Contains(t, errs[6].Error(), "redis.book_lock_ttl: must be positive")
+		assert.Contains(t, errs[7].Error(), "redis.addr: unreachable")
+	})
+
+	t.Run("TopicPrefix", func(t *testing.T) {
+		cfg := &Config{}
+		cfg.Server.GRPCAddr = ":50051"
+		cfg.Server.HTTPAddr = ":8080"
+		cfg.Server.LogLevel = "info"
+		cfg.Kafka.TopicPrefix = "matchingo.v1-books_"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Kafka.TopicPrefix = "matchingo/books"
+		assert.ErrorContains(t, cfg.Validate(), "kafka.topic_prefix: invalid topic name")
+	})
+
+	t.Run("NATSURL", func(t *testing.T) {
+		cfg := &Config{}
+		cfg.Server.GRPCAddr = ":50051"
+		cfg.Server.HTTPAddr = ":8080"
+		cfg.Server.LogLevel = "info"
+		cfg.NATS.URL = "nats://nats-1:4222, nats://nats-2:4222"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.NATS.URL = "nats-1:4222"
+		assert.ErrorContains(t, cfg.Validate(), "nats.url: invalid format")
+	})
+
+	t.Run("EmptyAddressesSkipReachability", func(t *testing.T) {
+		cfg := &Config{}
+		cfg.Server.GRPCAddr = ":50051"
+		cfg.Server.HTTPAddr = ":8080"
+		cfg.Server.LogLevel = "info"
+
+		assert.NoError(t, cfg.Validate())
+	})
+}
@@ -0,0 +1,517 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pkg/api/proto/admin.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type UpdateRateLimitRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Orders each user address may submit per second on average; zero
+	// disables the limit
+	OrdersPerSecond float64 `protobuf:"fixed64,1,opt,name=orders_per_second,json=ordersPerSecond,proto3" json:"orders_per_second,omitempty"`
+	// How many orders a user address may submit at once; at least 1 when
+	// orders_per_second is set
+	Burst         int32 `protobuf:"varint,2,opt,name=burst,proto3" json:"burst,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRateLimitRequest) Reset() {
+	*x = UpdateRateLimitRequest{}
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRateLimitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRateLimitRequest) ProtoMessage() {}
+
+func (x *UpdateRateLimitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRateLimitRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRateLimitRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *UpdateRateLimitRequest) GetOrdersPerSecond() float64 {
+	if x != nil {
+		return x.OrdersPerSecond
+	}
+	return 0
+}
+
+func (x *UpdateRateLimitRequest) GetBurst() int32 {
+	if x != nil {
+		return x.Burst
+	}
+	return 0
+}
+
+type RateLimitResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	OrdersPerSecond float64                `protobuf:"fixed64,1,opt,name=orders_per_second,json=ordersPerSecond,proto3" json:"orders_per_second,omitempty"`
+	Burst           int32                  `protobuf:"varint,2,opt,name=burst,proto3" json:"burst,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RateLimitResponse) Reset() {
+	*x = RateLimitResponse{}
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitResponse) ProtoMessage() {}
+
+func (x *RateLimitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitResponse.ProtoReflect.Descriptor instead.
+func (*RateLimitResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RateLimitResponse) GetOrdersPerSecond() float64 {
+	if x != nil {
+		return x.OrdersPerSecond
+	}
+	return 0
+}
+
+func (x *RateLimitResponse) GetBurst() int32 {
+	if x != nil {
+		return x.Burst
+	}
+	return 0
+}
+
+type UpdateCircuitBreakerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// The new settings; a zero max_price_move_pct disables the breaker
+	CircuitBreaker *CreateOrderBookRequest_CircuitBreaker `protobuf:"bytes,2,opt,name=circuit_breaker,json=circuitBreaker,proto3" json:"circuit_breaker,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UpdateCircuitBreakerRequest) Reset() {
+	*x = UpdateCircuitBreakerRequest{}
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCircuitBreakerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCircuitBreakerRequest) ProtoMessage() {}
+
+func (x *UpdateCircuitBreakerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCircuitBreakerRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCircuitBreakerRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UpdateCircuitBreakerRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *UpdateCircuitBreakerRequest) GetCircuitBreaker() *CreateOrderBookRequest_CircuitBreaker {
+	if x != nil {
+		return x.CircuitBreaker
+	}
+	return nil
+}
+
+type SetOrderBookHaltedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// True halts matching until the book is resumed; false resumes it, also
+	// ending a halt by the circuit breaker
+	Halted        bool `protobuf:"varint,2,opt,name=halted,proto3" json:"halted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetOrderBookHaltedRequest) Reset() {
+	*x = SetOrderBookHaltedRequest{}
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetOrderBookHaltedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetOrderBookHaltedRequest) ProtoMessage() {}
+
+func (x *SetOrderBookHaltedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetOrderBookHaltedRequest.ProtoReflect.Descriptor instead.
+func (*SetOrderBookHaltedRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SetOrderBookHaltedRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *SetOrderBookHaltedRequest) GetHalted() bool {
+	if x != nil {
+		return x.Halted
+	}
+	return false
+}
+
+type SetOrderBookHaltedResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	Halted        bool                   `protobuf:"varint,2,opt,name=halted,proto3" json:"halted,omitempty"`
+	// Why matching is halted; empty when it is not
+	HaltReason    string `protobuf:"bytes,3,opt,name=halt_reason,json=haltReason,proto3" json:"halt_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetOrderBookHaltedResponse) Reset() {
+	*x = SetOrderBookHaltedResponse{}
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetOrderBookHaltedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetOrderBookHaltedResponse) ProtoMessage() {}
+
+func (x *SetOrderBookHaltedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetOrderBookHaltedResponse.ProtoReflect.Descriptor instead.
+func (*SetOrderBookHaltedResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SetOrderBookHaltedResponse) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *SetOrderBookHaltedResponse) GetHalted() bool {
+	if x != nil {
+		return x.Halted
+	}
+	return false
+}
+
+func (x *SetOrderBookHaltedResponse) GetHaltReason() string {
+	if x != nil {
+		return x.HaltReason
+	}
+	return ""
+}
+
+type GetServerStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServerStatsRequest) Reset() {
+	*x = GetServerStatsRequest{}
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServerStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServerStatsRequest) ProtoMessage() {}
+
+func (x *GetServerStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServerStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetServerStatsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_admin_proto_rawDescGZIP(), []int{5}
+}
+
+type ServerStatsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Order books that are not deleted
+	ActiveOrderBooks int32 `protobuf:"varint,1,opt,name=active_order_books,json=activeOrderBooks,proto3" json:"active_order_books,omitempty"`
+	// Orders matched by CreateOrder since the server started
+	OrdersProcessed uint64 `protobuf:"varint,2,opt,name=orders_processed,json=ordersProcessed,proto3" json:"orders_processed,omitempty"`
+	Goroutines      int32  `protobuf:"varint,3,opt,name=goroutines,proto3" json:"goroutines,omitempty"`
+	// Bytes of allocated heap objects
+	HeapAllocBytes uint64 `protobuf:"varint,4,opt,name=heap_alloc_bytes,json=heapAllocBytes,proto3" json:"heap_alloc_bytes,omitempty"`
+	// Bytes of heap memory obtained from the OS
+	HeapSysBytes  uint64 `protobuf:"varint,5,opt,name=heap_sys_bytes,json=heapSysBytes,proto3" json:"heap_sys_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerStatsResponse) Reset() {
+	*x = ServerStatsResponse{}
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerStatsResponse) ProtoMessage() {}
+
+func (x *ServerStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerStatsResponse.ProtoReflect.Descriptor instead.
+func (*ServerStatsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ServerStatsResponse) GetActiveOrderBooks() int32 {
+	if x != nil {
+		return x.ActiveOrderBooks
+	}
+	return 0
+}
+
+func (x *ServerStatsResponse) GetOrdersProcessed() uint64 {
+	if x != nil {
+		return x.OrdersProcessed
+	}
+	return 0
+}
+
+func (x *ServerStatsResponse) GetGoroutines() int32 {
+	if x != nil {
+		return x.Goroutines
+	}
+	return 0
+}
+
+func (x *ServerStatsResponse) GetHeapAllocBytes() uint64 {
+	if x != nil {
+		return x.HeapAllocBytes
+	}
+	return 0
+}
+
+func (x *ServerStatsResponse) GetHeapSysBytes() uint64 {
+	if x != nil {
+		return x.HeapSysBytes
+	}
+	return 0
+}
+
+var File_pkg_api_proto_admin_proto protoreflect.FileDescriptor
+
+const file_pkg_api_proto_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x19pkg/api/proto/admin.proto\x12\rmatchingo.api\x1a\x1dpkg/api/proto/orderbook.proto\"Z\n" +
+	"\x16UpdateRateLimitRequest\x12*\n" +
+	"\x11orders_per_second\x18\x01 \x01(\x01R\x0fordersPerSecond\x12\x14\n" +
+	"\x05burst\x18\x02 \x01(\x05R\x05burst\"U\n" +
+	"\x11RateLimitResponse\x12*\n" +
+	"\x11orders_per_second\x18\x01 \x01(\x01R\x0fordersPerSecond\x12\x14\n" +
+	"\x05burst\x18\x02 \x01(\x05R\x05burst\"\xa4\x01\n" +
+	"\x1bUpdateCircuitBreakerRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12]\n" +
+	"\x0fcircuit_breaker\x18\x02 \x01(\v24.matchingo.api.CreateOrderBookRequest.CircuitBreakerR\x0ecircuitBreaker\"[\n" +
+	"\x19SetOrderBookHaltedRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x16\n" +
+	"\x06halted\x18\x02 \x01(\bR\x06halted\"}\n" +
+	"\x1aSetOrderBookHaltedResponse\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x16\n" +
+	"\x06halted\x18\x02 \x01(\bR\x06halted\x12\x1f\n" +
+	"\vhalt_reason\x18\x03 \x01(\tR\n" +
+	"haltReason\"\x17\n" +
+	"\x15GetServerStatsRequest\"\xde\x01\n" +
+	"\x13ServerStatsResponse\x12,\n" +
+	"\x12active_order_books\x18\x01 \x01(\x05R\x10activeOrderBooks\x12)\n" +
+	"\x10orders_processed\x18\x02 \x01(\x04R\x0fordersProcessed\x12\x1e\n" +
+	"\n" +
+	"goroutines\x18\x03 \x01(\x05R\n" +
+	"goroutines\x12(\n" +
+	"\x10heap_alloc_bytes\x18\x04 \x01(\x04R\x0eheapAllocBytes\x12$\n" +
+	"\x0eheap_sys_bytes\x18\x05 \x01(\x04R\fheapSysBytes2\x9f\x03\n" +
+	"\fAdminService\x12\\\n" +
+	"\x0fUpdateRateLimit\x12%.matchingo.api.UpdateRateLimitRequest\x1a .matchingo.api.RateLimitResponse\"\x00\x12f\n" +
+	"\x14UpdateCircuitBreaker\x12*.matchingo.api.UpdateCircuitBreakerRequest\x1a .matchingo.api.OrderBookResponse\"\x00\x12k\n" +
+	"\x12SetOrderBookHalted\x12(.matchingo.api.SetOrderBookHaltedRequest\x1a).matchingo.api.SetOrderBookHaltedResponse\"\x00\x12\\\n" +
+	"\x0eGetServerStats\x12$.matchingo.api.GetServerStatsRequest\x1a\".matchingo.api.ServerStatsResponse\"\x00B+Z)github.com/erain9/matchingo/pkg/api/protob\x06proto3"
+
+var (
+	file_pkg_api_proto_admin_proto_rawDescOnce sync.Once
+	file_pkg_api_proto_admin_proto_rawDescData []byte
+)
+
+func file_pkg_api_proto_admin_proto_rawDescGZIP() []byte {
+	file_pkg_api_proto_admin_proto_rawDescOnce.Do(func() {
+		file_pkg_api_proto_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_proto_admin_proto_rawDesc), len(file_pkg_api_proto_admin_proto_rawDesc)))
+	})
+	return file_pkg_api_proto_admin_proto_rawDescData
+}
+
+var file_pkg_api_proto_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_pkg_api_proto_admin_proto_goTypes = []any{
+	(*UpdateRateLimitRequest)(nil),                // 0: matchingo.api.UpdateRateLimitRequest
+	(*RateLimitResponse)(nil),                     // 1: matchingo.api.RateLimitResponse
+	(*UpdateCircuitBreakerRequest)(nil),           // 2: matchingo.api.UpdateCircuitBreakerRequest
+	(*SetOrderBookHaltedRequest)(nil),             // 3: matchingo.api.SetOrderBookHaltedRequest
+	(*SetOrderBookHaltedResponse)(nil),            // 4: matchingo.api.SetOrderBookHaltedResponse
+	(*GetServerStatsRequest)(nil),                 // 5: matchingo.api.GetServerStatsRequest
+	(*ServerStatsResponse)(nil),                   // 6: matchingo.api.ServerStatsResponse
+	(*CreateOrderBookRequest_CircuitBreaker)(nil), // 7: matchingo.api.CreateOrderBookRequest.CircuitBreaker
+	(*OrderBookResponse)(nil),                     // 8: matchingo.api.OrderBookResponse
+}
+var file_pkg_api_proto_admin_proto_depIdxs = []int32{
+	7, // 0: matchingo.api.UpdateCircuitBreakerRequest.circuit_breaker:type_name -> matchingo.api.CreateOrderBookRequest.CircuitBreaker
+	0, // 1: matchingo.api.AdminService.UpdateRateLimit:input_type -> matchingo.api.UpdateRateLimitRequest
+	2, // 2: matchingo.api.AdminService.UpdateCircuitBreaker:input_type -> matchingo.api.UpdateCircuitBreakerRequest
+	3, // 3: matchingo.api.AdminService.SetOrderBookHalted:input_type -> matchingo.api.SetOrderBookHaltedRequest
+	5, // 4: matchingo.api.AdminService.GetServerStats:input_type -> matchingo.api.GetServerStatsRequest
+	1, // 5: matchingo.api.AdminService.UpdateRateLimit:output_type -> matchingo.api.RateLimitResponse
+	8, // 6: matchingo.api.AdminService.UpdateCircuitBreaker:output_type -> matchingo.api.OrderBookResponse
+	4, // 7: matchingo.api.AdminService.SetOrderBookHalted:output_type -> matchingo.api.SetOrderBookHaltedResponse
+	6, // 8: matchingo.api.AdminService.GetServerStats:output_type -> matchingo.api.ServerStatsResponse
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_proto_admin_proto_init() }
+func file_pkg_api_proto_admin_proto_init() {
+	if File_pkg_api_proto_admin_proto != nil {
+		return
+	}
+	file_pkg_api_proto_orderbook_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_proto_admin_proto_rawDesc), len(file_pkg_api_proto_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_proto_admin_proto_goTypes,
+		DependencyIndexes: file_pkg_api_proto_admin_proto_depIdxs,
+		MessageInfos:      file_pkg_api_proto_admin_proto_msgTypes,
+	}.Build()
+	File_pkg_api_proto_admin_proto = out.File
+	file_pkg_api_proto_admin_proto_goTypes = nil
+	file_pkg_api_proto_admin_proto_depIdxs = nil
+}
@@ -0,0 +1,251 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: pkg/api/proto/admin.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AdminService_UpdateRateLimit_FullMethodName      = "/matchingo.api.AdminService/UpdateRateLimit"
+	AdminService_UpdateCircuitBreaker_FullMethodName = "/matchingo.api.AdminService/UpdateCircuitBreaker"
+	AdminService_SetOrderBookHalted_FullMethodName   = "/matchingo.api.AdminService/SetOrderBookHalted"
+	AdminService_GetServerStats_FullMethodName       = "/matchingo.api.AdminService/GetServerStats"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AdminService changes the configuration of a running server. It is served
+// on a listener of its own, bound to localhost, never alongside the public
+// OrderBookService.
+type AdminServiceClient interface {
+	// UpdateRateLimit changes how fast each user address may create orders
+	UpdateRateLimit(ctx context.Context, in *UpdateRateLimitRequest, opts ...grpc.CallOption) (*RateLimitResponse, error)
+	// UpdateCircuitBreaker changes the circuit breaker of an order book
+	UpdateCircuitBreaker(ctx context.Context, in *UpdateCircuitBreakerRequest, opts ...grpc.CallOption) (*OrderBookResponse, error)
+	// SetOrderBookHalted halts or resumes matching on an order book
+	SetOrderBookHalted(ctx context.Context, in *SetOrderBookHaltedRequest, opts ...grpc.CallOption) (*SetOrderBookHaltedResponse, error)
+	// GetServerStats reports the load of the server
+	GetServerStats(ctx context.Context, in *GetServerStatsRequest, opts ...grpc.CallOption) (*ServerStatsResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) UpdateRateLimit(ctx context.Context, in *UpdateRateLimitRequest, opts ...grpc.CallOption) (*RateLimitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RateLimitResponse)
+	err := c.cc.Invoke(ctx, AdminService_UpdateRateLimit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) UpdateCircuitBreaker(ctx context.Context, in *UpdateCircuitBreakerRequest, opts ...grpc.CallOption) (*OrderBookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderBookResponse)
+	err := c.cc.Invoke(ctx, AdminService_UpdateCircuitBreaker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetOrderBookHalted(ctx context.Context, in *SetOrderBookHaltedRequest, opts ...grpc.CallOption) (*SetOrderBookHaltedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetOrderBookHaltedResponse)
+	err := c.cc.Invoke(ctx, AdminService_SetOrderBookHalted_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) GetServerStats(ctx context.Context, in *GetServerStatsRequest, opts ...grpc.CallOption) (*ServerStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ServerStatsResponse)
+	err := c.cc.Invoke(ctx, AdminService_GetServerStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+//
+// AdminService changes the configuration of a running server. It is served
+// on a listener of its own, bound to localhost, never alongside the public
+// OrderBookService.
+type AdminServiceServer interface {
+	// UpdateRateLimit changes how fast each user address may create orders
+	UpdateRateLimit(context.Context, *UpdateRateLimitRequest) (*RateLimitResponse, error)
+	// UpdateCircuitBreaker changes the circuit breaker of an order book
+	UpdateCircuitBreaker(context.Context, *UpdateCircuitBreakerRequest) (*OrderBookResponse, error)
+	// SetOrderBookHalted halts or resumes matching on an order book
+	SetOrderBookHalted(context.Context, *SetOrderBookHaltedRequest) (*SetOrderBookHaltedResponse, error)
+	// GetServerStats reports the load of the server
+	GetServerStats(context.Context, *GetServerStatsRequest) (*ServerStatsResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) UpdateRateLimit(context.Context, *UpdateRateLimitRequest) (*RateLimitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateRateLimit not implemented")
+}
+func (UnimplementedAdminServiceServer) UpdateCircuitBreaker(context.Context, *UpdateCircuitBreakerRequest) (*OrderBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCircuitBreaker not implemented")
+}
+func (UnimplementedAdminServiceServer) SetOrderBookHalted(context.Context, *SetOrderBookHaltedRequest) (*SetOrderBookHaltedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetOrderBookHalted not implemented")
+}
+func (UnimplementedAdminServiceServer) GetServerStats(context.Context, *GetServerStatsRequest) (*ServerStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServerStats not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_UpdateRateLimit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRateLimitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).UpdateRateLimit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_UpdateRateLimit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).UpdateRateLimit(ctx, req.(*UpdateRateLimitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_UpdateCircuitBreaker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCircuitBreakerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).UpdateCircuitBreaker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_UpdateCircuitBreaker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).UpdateCircuitBreaker(ctx, req.(*UpdateCircuitBreakerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetOrderBookHalted_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetOrderBookHaltedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetOrderBookHalted(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetOrderBookHalted_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetOrderBookHalted(ctx, req.(*SetOrderBookHaltedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_GetServerStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServerStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetServerStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetServerStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetServerStats(ctx, req.(*GetServerStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "matchingo.api.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "UpdateRateLimit",
+			Handler:    _AdminService_UpdateRateLimit_Handler,
+		},
+		{
+			MethodName: "UpdateCircuitBreaker",
+			Handler:    _AdminService_UpdateCircuitBreaker_Handler,
+		},
+		{
+			MethodName: "SetOrderBookHalted",
+			Handler:    _AdminService_SetOrderBookHalted_Handler,
+		},
+		{
+			MethodName: "GetServerStats",
+			Handler:    _AdminService_GetServerStats_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/api/proto/admin.proto",
+}
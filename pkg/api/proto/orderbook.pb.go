@@ -7,8 +7,11 @@
 package proto
 
 import (
+	_ "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
@@ -27,8 +30,8 @@ const (
 type BackendType int32
 
 const (
-	BackendType_MEMORY BackendType = 0
-	BackendType_REDIS  BackendType = 1
+	BackendType_MEMORY BackendType = 0 // Kept in the server's memory; lost on restart
+	BackendType_REDIS  BackendType = 1 // Stored in Redis; survives restarts
 )
 
 // Enum value maps for BackendType.
@@ -70,14 +73,70 @@ func (BackendType) EnumDescriptor() ([]byte, []int) {
 	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{0}
 }
 
+// Self-trade prevention: what an order book does when an incoming order
+// would trade with a resting order of the same user_address
+type STPMode int32
+
+const (
+	STPMode_STP_NONE         STPMode = 0 // The orders trade
+	STPMode_STP_CANCEL_MAKER STPMode = 1 // Cancel the resting order and go on matching
+	STPMode_STP_CANCEL_TAKER STPMode = 2 // Cancel what is left of the incoming order
+	STPMode_STP_CANCEL_BOTH  STPMode = 3 // Cancel the resting order and what is left of the incoming one
+)
+
+// Enum value maps for STPMode.
+var (
+	STPMode_name = map[int32]string{
+		0: "STP_NONE",
+		1: "STP_CANCEL_MAKER",
+		2: "STP_CANCEL_TAKER",
+		3: "STP_CANCEL_BOTH",
+	}
+	STPMode_value = map[string]int32{
+		"STP_NONE":         0,
+		"STP_CANCEL_MAKER": 1,
+		"STP_CANCEL_TAKER": 2,
+		"STP_CANCEL_BOTH":  3,
+	}
+)
+
+func (x STPMode) Enum() *STPMode {
+	p := new(STPMode)
+	*p = x
+	return p
+}
+
+func (x STPMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (STPMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_api_proto_orderbook_proto_enumTypes[1].Descriptor()
+}
+
+func (STPMode) Type() protoreflect.EnumType {
+	return &file_pkg_api_proto_orderbook_proto_enumTypes[1]
+}
+
+func (x STPMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use STPMode.Descriptor instead.
+func (STPMode) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{1}
+}
+
 // Types of orders
 type OrderType int32
 
 const (
-	OrderType_LIMIT      OrderType = 0
-	OrderType_MARKET     OrderType = 1
-	OrderType_STOP       OrderType = 2
-	OrderType_STOP_LIMIT OrderType = 3
+	OrderType_LIMIT      OrderType = 0 // Trades at price or better; what is left rests on the book
+	OrderType_MARKET     OrderType = 1 // Trades at the best prices available; never rests
+	OrderType_STOP       OrderType = 2 // Limit order at stop_price; takes no price
+	OrderType_STOP_LIMIT OrderType = 3 // Becomes a limit order at price once stop_price trades
+	OrderType_MIDPOINT   OrderType = 4 // Pegged to the midpoint of the best bid and ask; takes no price
+	OrderType_ICEBERG    OrderType = 5 // Limit order showing only visible_quantity at a time while resting
 )
 
 // Enum value maps for OrderType.
@@ -87,12 +146,16 @@ var (
 		1: "MARKET",
 		2: "STOP",
 		3: "STOP_LIMIT",
+		4: "MIDPOINT",
+		5: "ICEBERG",
 	}
 	OrderType_value = map[string]int32{
 		"LIMIT":      0,
 		"MARKET":     1,
 		"STOP":       2,
 		"STOP_LIMIT": 3,
+		"MIDPOINT":   4,
+		"ICEBERG":    5,
 	}
 )
 
@@ -107,11 +170,11 @@ func (x OrderType) String() string {
 }
 
 func (OrderType) Descriptor() protoreflect.EnumDescriptor {
-	return file_pkg_api_proto_orderbook_proto_enumTypes[1].Descriptor()
+	return file_pkg_api_proto_orderbook_proto_enumTypes[2].Descriptor()
 }
 
 func (OrderType) Type() protoreflect.EnumType {
-	return &file_pkg_api_proto_orderbook_proto_enumTypes[1]
+	return &file_pkg_api_proto_orderbook_proto_enumTypes[2]
 }
 
 func (x OrderType) Number() protoreflect.EnumNumber {
@@ -120,15 +183,15 @@ func (x OrderType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use OrderType.Descriptor instead.
 func (OrderType) EnumDescriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{1}
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{2}
 }
 
 // Order side: buy or sell
 type OrderSide int32
 
 const (
-	OrderSide_BUY  OrderSide = 0
-	OrderSide_SELL OrderSide = 1
+	OrderSide_BUY  OrderSide = 0 // Bid: trades against asks
+	OrderSide_SELL OrderSide = 1 // Ask: trades against bids
 )
 
 // Enum value maps for OrderSide.
@@ -154,11 +217,11 @@ func (x OrderSide) String() string {
 }
 
 func (OrderSide) Descriptor() protoreflect.EnumDescriptor {
-	return file_pkg_api_proto_orderbook_proto_enumTypes[2].Descriptor()
+	return file_pkg_api_proto_orderbook_proto_enumTypes[3].Descriptor()
 }
 
 func (OrderSide) Type() protoreflect.EnumType {
-	return &file_pkg_api_proto_orderbook_proto_enumTypes[2]
+	return &file_pkg_api_proto_orderbook_proto_enumTypes[3]
 }
 
 func (x OrderSide) Number() protoreflect.EnumNumber {
@@ -167,7 +230,7 @@ func (x OrderSide) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use OrderSide.Descriptor instead.
 func (OrderSide) EnumDescriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{2}
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{3}
 }
 
 // Time in force for orders
@@ -177,6 +240,7 @@ const (
 	TimeInForce_GTC TimeInForce = 0 // Good Till Canceled
 	TimeInForce_IOC TimeInForce = 1 // Immediate or Cancel
 	TimeInForce_FOK TimeInForce = 2 // Fill or Kill
+	TimeInForce_GTD TimeInForce = 3 // Good Till Date: rests until gtd_expires_at
 )
 
 // Enum value maps for TimeInForce.
@@ -185,11 +249,13 @@ var (
 		0: "GTC",
 		1: "IOC",
 		2: "FOK",
+		3: "GTD",
 	}
 	TimeInForce_value = map[string]int32{
 		"GTC": 0,
 		"IOC": 1,
 		"FOK": 2,
+		"GTD": 3,
 	}
 )
 
@@ -204,11 +270,11 @@ func (x TimeInForce) String() string {
 }
 
 func (TimeInForce) Descriptor() protoreflect.EnumDescriptor {
-	return file_pkg_api_proto_orderbook_proto_enumTypes[3].Descriptor()
+	return file_pkg_api_proto_orderbook_proto_enumTypes[4].Descriptor()
 }
 
 func (TimeInForce) Type() protoreflect.EnumType {
-	return &file_pkg_api_proto_orderbook_proto_enumTypes[3]
+	return &file_pkg_api_proto_orderbook_proto_enumTypes[4]
 }
 
 func (x TimeInForce) Number() protoreflect.EnumNumber {
@@ -217,7 +283,54 @@ func (x TimeInForce) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use TimeInForce.Descriptor instead.
 func (TimeInForce) EnumDescriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{3}
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{4}
+}
+
+// How RouteOrder divides an order between books
+type AllocationStrategy int32
+
+const (
+	AllocationStrategy_BEST_PRICE_FIRST   AllocationStrategy = 0 // Fill the best-priced book first, then the next
+	AllocationStrategy_PROPORTIONAL_SPLIT AllocationStrategy = 1 // Split in proportion to each book's liquidity
+)
+
+// Enum value maps for AllocationStrategy.
+var (
+	AllocationStrategy_name = map[int32]string{
+		0: "BEST_PRICE_FIRST",
+		1: "PROPORTIONAL_SPLIT",
+	}
+	AllocationStrategy_value = map[string]int32{
+		"BEST_PRICE_FIRST":   0,
+		"PROPORTIONAL_SPLIT": 1,
+	}
+)
+
+func (x AllocationStrategy) Enum() *AllocationStrategy {
+	p := new(AllocationStrategy)
+	*p = x
+	return p
+}
+
+func (x AllocationStrategy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AllocationStrategy) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_api_proto_orderbook_proto_enumTypes[5].Descriptor()
+}
+
+func (AllocationStrategy) Type() protoreflect.EnumType {
+	return &file_pkg_api_proto_orderbook_proto_enumTypes[5]
+}
+
+func (x AllocationStrategy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AllocationStrategy.Descriptor instead.
+func (AllocationStrategy) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{5}
 }
 
 // Status of an order
@@ -230,6 +343,7 @@ const (
 	OrderStatus_PARTIALLY_FILLED OrderStatus = 3
 	OrderStatus_CANCELED         OrderStatus = 4
 	OrderStatus_REJECTED         OrderStatus = 5
+	OrderStatus_UNKNOWN          OrderStatus = 6 // The order could not be found
 )
 
 // Enum value maps for OrderStatus.
@@ -241,6 +355,7 @@ var (
 		3: "PARTIALLY_FILLED",
 		4: "CANCELED",
 		5: "REJECTED",
+		6: "UNKNOWN",
 	}
 	OrderStatus_value = map[string]int32{
 		"PENDING":          0,
@@ -249,6 +364,7 @@ var (
 		"PARTIALLY_FILLED": 3,
 		"CANCELED":         4,
 		"REJECTED":         5,
+		"UNKNOWN":          6,
 	}
 )
 
@@ -263,11 +379,11 @@ func (x OrderStatus) String() string {
 }
 
 func (OrderStatus) Descriptor() protoreflect.EnumDescriptor {
-	return file_pkg_api_proto_orderbook_proto_enumTypes[4].Descriptor()
+	return file_pkg_api_proto_orderbook_proto_enumTypes[6].Descriptor()
 }
 
 func (OrderStatus) Type() protoreflect.EnumType {
-	return &file_pkg_api_proto_orderbook_proto_enumTypes[4]
+	return &file_pkg_api_proto_orderbook_proto_enumTypes[6]
 }
 
 func (x OrderStatus) Number() protoreflect.EnumNumber {
@@ -276,7 +392,163 @@ func (x OrderStatus) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use OrderStatus.Descriptor instead.
 func (OrderStatus) EnumDescriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{4}
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{6}
+}
+
+// Why an order in a batch response carries no order details
+type OrderErrorCode int32
+
+const (
+	OrderErrorCode_NO_ERROR  OrderErrorCode = 0
+	OrderErrorCode_NOT_FOUND OrderErrorCode = 1
+)
+
+// Enum value maps for OrderErrorCode.
+var (
+	OrderErrorCode_name = map[int32]string{
+		0: "NO_ERROR",
+		1: "NOT_FOUND",
+	}
+	OrderErrorCode_value = map[string]int32{
+		"NO_ERROR":  0,
+		"NOT_FOUND": 1,
+	}
+)
+
+func (x OrderErrorCode) Enum() *OrderErrorCode {
+	p := new(OrderErrorCode)
+	*p = x
+	return p
+}
+
+func (x OrderErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_api_proto_orderbook_proto_enumTypes[7].Descriptor()
+}
+
+func (OrderErrorCode) Type() protoreflect.EnumType {
+	return &file_pkg_api_proto_orderbook_proto_enumTypes[7]
+}
+
+func (x OrderErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderErrorCode.Descriptor instead.
+func (OrderErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{7}
+}
+
+// Reason an order was canceled
+type CancelReason int32
+
+const (
+	CancelReason_USER_REQUESTED CancelReason = 0
+	CancelReason_OCO_TRIGGERED  CancelReason = 1
+	CancelReason_STOP_ACTIVATED CancelReason = 2
+	CancelReason_EXPIRED        CancelReason = 3
+	CancelReason_STP            CancelReason = 4 // Self-trade prevention
+)
+
+// Enum value maps for CancelReason.
+var (
+	CancelReason_name = map[int32]string{
+		0: "USER_REQUESTED",
+		1: "OCO_TRIGGERED",
+		2: "STOP_ACTIVATED",
+		3: "EXPIRED",
+		4: "STP",
+	}
+	CancelReason_value = map[string]int32{
+		"USER_REQUESTED": 0,
+		"OCO_TRIGGERED":  1,
+		"STOP_ACTIVATED": 2,
+		"EXPIRED":        3,
+		"STP":            4,
+	}
+)
+
+func (x CancelReason) Enum() *CancelReason {
+	p := new(CancelReason)
+	*p = x
+	return p
+}
+
+func (x CancelReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CancelReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_api_proto_orderbook_proto_enumTypes[8].Descriptor()
+}
+
+func (CancelReason) Type() protoreflect.EnumType {
+	return &file_pkg_api_proto_orderbook_proto_enumTypes[8]
+}
+
+func (x CancelReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CancelReason.Descriptor instead.
+func (CancelReason) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{8}
+}
+
+// Kind of change reported by WatchOrderBook
+type OrderBookEventType int32
+
+const (
+	OrderBookEventType_TRADE  OrderBookEventType = 0 // An incoming order matched a resting order
+	OrderBookEventType_ADD    OrderBookEventType = 1 // An order came to rest on the book
+	OrderBookEventType_CANCEL OrderBookEventType = 2 // A resting order was canceled
+	OrderBookEventType_AMEND  OrderBookEventType = 3 // A resting order's price or quantity was changed
+)
+
+// Enum value maps for OrderBookEventType.
+var (
+	OrderBookEventType_name = map[int32]string{
+		0: "TRADE",
+		1: "ADD",
+		2: "CANCEL",
+		3: "AMEND",
+	}
+	OrderBookEventType_value = map[string]int32{
+		"TRADE":  0,
+		"ADD":    1,
+		"CANCEL": 2,
+		"AMEND":  3,
+	}
+)
+
+func (x OrderBookEventType) Enum() *OrderBookEventType {
+	p := new(OrderBookEventType)
+	*p = x
+	return p
+}
+
+func (x OrderBookEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderBookEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_api_proto_orderbook_proto_enumTypes[9].Descriptor()
+}
+
+func (OrderBookEventType) Type() protoreflect.EnumType {
+	return &file_pkg_api_proto_orderbook_proto_enumTypes[9]
+}
+
+func (x OrderBookEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderBookEventType.Descriptor instead.
+func (OrderBookEventType) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{9}
 }
 
 // Request to create a new order book
@@ -285,7 +557,26 @@ type CreateOrderBookRequest struct {
 	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	BackendType BackendType            `protobuf:"varint,2,opt,name=backend_type,json=backendType,proto3,enum=matchingo.api.BackendType" json:"backend_type,omitempty"`
 	// Backend-specific options, such as Redis connection details
-	Options       map[string]string `protobuf:"bytes,3,rep,name=options,proto3" json:"options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Options map[string]string `protobuf:"bytes,3,rep,name=options,proto3" json:"options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Trading rules for the instrument the book lists
+	Instrument *CreateOrderBookRequest_Instrument `protobuf:"bytes,4,opt,name=instrument,proto3" json:"instrument,omitempty"`
+	// Limits enforced on the book in the background; unset fields use the server defaults
+	Policy *CreateOrderBookRequest_Policy `protobuf:"bytes,5,opt,name=policy,proto3" json:"policy,omitempty"`
+	// Named strategy whose matching rules the book uses, such as "equity",
+	// "crypto" or "futures"; empty uses price-time priority with no limits
+	StrategyName string `protobuf:"bytes,6,opt,name=strategy_name,json=strategyName,proto3" json:"strategy_name,omitempty"`
+	// Step prices must be multiples of, as a decimal; empty keeps the strategy's
+	TickSize string `protobuf:"bytes,7,opt,name=tick_size,json=tickSize,proto3" json:"tick_size,omitempty"`
+	// Step quantities must be multiples of, as a decimal; empty keeps the strategy's
+	LotSize string `protobuf:"bytes,8,opt,name=lot_size,json=lotSize,proto3" json:"lot_size,omitempty"`
+	// What happens when an order would trade with a resting order of the same user_address
+	StpMode STPMode `protobuf:"varint,9,opt,name=stp_mode,json=stpMode,proto3,enum=matchingo.api.STPMode" json:"stp_mode,omitempty"`
+	// Halts matching for a while after a trade moves the price too fast
+	CircuitBreaker *CreateOrderBookRequest_CircuitBreaker `protobuf:"bytes,10,opt,name=circuit_breaker,json=circuitBreaker,proto3" json:"circuit_breaker,omitempty"`
+	// Fee, in basis points of a fill's notional, charged to the resting order
+	MakerFeeBps int32 `protobuf:"varint,11,opt,name=maker_fee_bps,json=makerFeeBps,proto3" json:"maker_fee_bps,omitempty"`
+	// Fee, in basis points of a fill's notional, charged to the incoming order
+	TakerFeeBps   int32 `protobuf:"varint,12,opt,name=taker_fee_bps,json=takerFeeBps,proto3" json:"taker_fee_bps,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -341,15 +632,90 @@ func (x *CreateOrderBookRequest) GetOptions() map[string]string {
 	return nil
 }
 
+func (x *CreateOrderBookRequest) GetInstrument() *CreateOrderBookRequest_Instrument {
+	if x != nil {
+		return x.Instrument
+	}
+	return nil
+}
+
+func (x *CreateOrderBookRequest) GetPolicy() *CreateOrderBookRequest_Policy {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+func (x *CreateOrderBookRequest) GetStrategyName() string {
+	if x != nil {
+		return x.StrategyName
+	}
+	return ""
+}
+
+func (x *CreateOrderBookRequest) GetTickSize() string {
+	if x != nil {
+		return x.TickSize
+	}
+	return ""
+}
+
+func (x *CreateOrderBookRequest) GetLotSize() string {
+	if x != nil {
+		return x.LotSize
+	}
+	return ""
+}
+
+func (x *CreateOrderBookRequest) GetStpMode() STPMode {
+	if x != nil {
+		return x.StpMode
+	}
+	return STPMode_STP_NONE
+}
+
+func (x *CreateOrderBookRequest) GetCircuitBreaker() *CreateOrderBookRequest_CircuitBreaker {
+	if x != nil {
+		return x.CircuitBreaker
+	}
+	return nil
+}
+
+func (x *CreateOrderBookRequest) GetMakerFeeBps() int32 {
+	if x != nil {
+		return x.MakerFeeBps
+	}
+	return 0
+}
+
+func (x *CreateOrderBookRequest) GetTakerFeeBps() int32 {
+	if x != nil {
+		return x.TakerFeeBps
+	}
+	return 0
+}
+
 // Response containing order book information
 type OrderBookResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	BackendType   BackendType            `protobuf:"varint,2,opt,name=backend_type,json=backendType,proto3,enum=matchingo.api.BackendType" json:"backend_type,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	OrderCount    uint64                 `protobuf:"varint,4,opt,name=order_count,json=orderCount,proto3" json:"order_count,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	BackendType BackendType            `protobuf:"varint,2,opt,name=backend_type,json=backendType,proto3,enum=matchingo.api.BackendType" json:"backend_type,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	OrderCount  uint64                 `protobuf:"varint,4,opt,name=order_count,json=orderCount,proto3" json:"order_count,omitempty"`
+	IsDeleted   bool                   `protobuf:"varint,5,opt,name=is_deleted,json=isDeleted,proto3" json:"is_deleted,omitempty"`
+	// Set only when the order book is soft-deleted
+	DeletedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	// Step prices must be multiples of; "0" when any price is accepted
+	TickSize string `protobuf:"bytes,7,opt,name=tick_size,json=tickSize,proto3" json:"tick_size,omitempty"`
+	// Step quantities must be multiples of; "0" when any quantity is accepted
+	LotSize string  `protobuf:"bytes,8,opt,name=lot_size,json=lotSize,proto3" json:"lot_size,omitempty"`
+	StpMode STPMode `protobuf:"varint,9,opt,name=stp_mode,json=stpMode,proto3,enum=matchingo.api.STPMode" json:"stp_mode,omitempty"`
+	// Set only when the book has a circuit breaker
+	CircuitBreaker *CreateOrderBookRequest_CircuitBreaker `protobuf:"bytes,10,opt,name=circuit_breaker,json=circuitBreaker,proto3" json:"circuit_breaker,omitempty"`
+	MakerFeeBps    int32                                  `protobuf:"varint,11,opt,name=maker_fee_bps,json=makerFeeBps,proto3" json:"maker_fee_bps,omitempty"`
+	TakerFeeBps    int32                                  `protobuf:"varint,12,opt,name=taker_fee_bps,json=takerFeeBps,proto3" json:"taker_fee_bps,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *OrderBookResponse) Reset() {
@@ -410,6 +776,62 @@ func (x *OrderBookResponse) GetOrderCount() uint64 {
 	return 0
 }
 
+func (x *OrderBookResponse) GetIsDeleted() bool {
+	if x != nil {
+		return x.IsDeleted
+	}
+	return false
+}
+
+func (x *OrderBookResponse) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+func (x *OrderBookResponse) GetTickSize() string {
+	if x != nil {
+		return x.TickSize
+	}
+	return ""
+}
+
+func (x *OrderBookResponse) GetLotSize() string {
+	if x != nil {
+		return x.LotSize
+	}
+	return ""
+}
+
+func (x *OrderBookResponse) GetStpMode() STPMode {
+	if x != nil {
+		return x.StpMode
+	}
+	return STPMode_STP_NONE
+}
+
+func (x *OrderBookResponse) GetCircuitBreaker() *CreateOrderBookRequest_CircuitBreaker {
+	if x != nil {
+		return x.CircuitBreaker
+	}
+	return nil
+}
+
+func (x *OrderBookResponse) GetMakerFeeBps() int32 {
+	if x != nil {
+		return x.MakerFeeBps
+	}
+	return 0
+}
+
+func (x *OrderBookResponse) GetTakerFeeBps() int32 {
+	if x != nil {
+		return x.TakerFeeBps
+	}
+	return 0
+}
+
 // Request to retrieve an order book
 type GetOrderBookRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -461,9 +883,11 @@ type ListOrderBooksRequest struct {
 	// For pagination, the maximum number of items to return
 	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
 	// For pagination, the offset from which to start returning items
-	Offset        int32 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Offset int32 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Include soft-deleted order books in the result
+	IncludeDeleted bool `protobuf:"varint,3,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ListOrderBooksRequest) Reset() {
@@ -510,11 +934,20 @@ func (x *ListOrderBooksRequest) GetOffset() int32 {
 	return 0
 }
 
+func (x *ListOrderBooksRequest) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
 // Response containing a list of order books
 type ListOrderBooksResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OrderBooks    []*OrderBookResponse   `protobuf:"bytes,1,rep,name=order_books,json=orderBooks,proto3" json:"order_books,omitempty"`
-	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	OrderBooks []*OrderBookResponse   `protobuf:"bytes,1,rep,name=order_books,json=orderBooks,proto3" json:"order_books,omitempty"`
+	Total      int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	// Set when trailing entries were dropped to fit the server's response size limit
+	Truncated     bool `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -563,10 +996,17 @@ func (x *ListOrderBooksResponse) GetTotal() int32 {
 	return 0
 }
 
-// Request to delete an order book
-type DeleteOrderBookRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+func (x *ListOrderBooksResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// Request to delete an order book
+type DeleteOrderBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -608,26 +1048,345 @@ func (x *DeleteOrderBookRequest) GetName() string {
 	return ""
 }
 
-// Request to create a new order
-type CreateOrderRequest struct {
+// Request to restore a soft-deleted order book
+type UndeleteRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
-	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	Side          OrderSide              `protobuf:"varint,3,opt,name=side,proto3,enum=matchingo.api.OrderSide" json:"side,omitempty"`
-	Quantity      string                 `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
-	Price         string                 `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
-	OrderType     OrderType              `protobuf:"varint,6,opt,name=order_type,json=orderType,proto3,enum=matchingo.api.OrderType" json:"order_type,omitempty"`
-	TimeInForce   TimeInForce            `protobuf:"varint,7,opt,name=time_in_force,json=timeInForce,proto3,enum=matchingo.api.TimeInForce" json:"time_in_force,omitempty"`
-	StopPrice     string                 `protobuf:"bytes,8,opt,name=stop_price,json=stopPrice,proto3" json:"stop_price,omitempty"`        // Only for stop orders
-	OcoId         string                 `protobuf:"bytes,9,opt,name=oco_id,json=ocoId,proto3" json:"oco_id,omitempty"`                    // Only for OCO orders
-	UserAddress   string                 `protobuf:"bytes,10,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"` // User's wallet address
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UndeleteRequest) Reset() {
+	*x = UndeleteRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndeleteRequest) ProtoMessage() {}
+
+func (x *UndeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UndeleteRequest.ProtoReflect.Descriptor instead.
+func (*UndeleteRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UndeleteRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// Response containing the restored order book
+type UndeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBook     *OrderBookResponse     `protobuf:"bytes,1,opt,name=order_book,json=orderBook,proto3" json:"order_book,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UndeleteResponse) Reset() {
+	*x = UndeleteResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndeleteResponse) ProtoMessage() {}
+
+func (x *UndeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UndeleteResponse.ProtoReflect.Descriptor instead.
+func (*UndeleteResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UndeleteResponse) GetOrderBook() *OrderBookResponse {
+	if x != nil {
+		return x.OrderBook
+	}
+	return nil
+}
+
+// Request to remove all orders from an order book
+type ResetOrderBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetOrderBookRequest) Reset() {
+	*x = ResetOrderBookRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetOrderBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetOrderBookRequest) ProtoMessage() {}
+
+func (x *ResetOrderBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetOrderBookRequest.ProtoReflect.Descriptor instead.
+func (*ResetOrderBookRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ResetOrderBookRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// Response containing the order book after the reset
+type ResetOrderBookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBook     *OrderBookResponse     `protobuf:"bytes,1,opt,name=order_book,json=orderBook,proto3" json:"order_book,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetOrderBookResponse) Reset() {
+	*x = ResetOrderBookResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetOrderBookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetOrderBookResponse) ProtoMessage() {}
+
+func (x *ResetOrderBookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetOrderBookResponse.ProtoReflect.Descriptor instead.
+func (*ResetOrderBookResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ResetOrderBookResponse) GetOrderBook() *OrderBookResponse {
+	if x != nil {
+		return x.OrderBook
+	}
+	return nil
+}
+
+// Request to pre-seed an order book with synthetic liquidity
+type WarmUpRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	BookName string                 `protobuf:"bytes,1,opt,name=book_name,json=bookName,proto3" json:"book_name,omitempty"`
+	// Number of price levels to create on each side of the book
+	NumLevels int32 `protobuf:"varint,2,opt,name=num_levels,json=numLevels,proto3" json:"num_levels,omitempty"`
+	// Bids are placed below and asks above this price
+	BasePrice string `protobuf:"bytes,3,opt,name=base_price,json=basePrice,proto3" json:"base_price,omitempty"`
+	// Distance between consecutive price levels
+	TickSize      string `protobuf:"bytes,4,opt,name=tick_size,json=tickSize,proto3" json:"tick_size,omitempty"`
+	QtyPerLevel   string `protobuf:"bytes,5,opt,name=qty_per_level,json=qtyPerLevel,proto3" json:"qty_per_level,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WarmUpRequest) Reset() {
+	*x = WarmUpRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WarmUpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WarmUpRequest) ProtoMessage() {}
+
+func (x *WarmUpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WarmUpRequest.ProtoReflect.Descriptor instead.
+func (*WarmUpRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *WarmUpRequest) GetBookName() string {
+	if x != nil {
+		return x.BookName
+	}
+	return ""
+}
+
+func (x *WarmUpRequest) GetNumLevels() int32 {
+	if x != nil {
+		return x.NumLevels
+	}
+	return 0
+}
+
+func (x *WarmUpRequest) GetBasePrice() string {
+	if x != nil {
+		return x.BasePrice
+	}
+	return ""
+}
+
+func (x *WarmUpRequest) GetTickSize() string {
+	if x != nil {
+		return x.TickSize
+	}
+	return ""
+}
+
+func (x *WarmUpRequest) GetQtyPerLevel() string {
+	if x != nil {
+		return x.QtyPerLevel
+	}
+	return ""
+}
+
+// Response summarizing a warm-up run
+type WarmUpResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrdersCreated int32                  `protobuf:"varint,1,opt,name=orders_created,json=ordersCreated,proto3" json:"orders_created,omitempty"`
+	Elapsed       *durationpb.Duration   `protobuf:"bytes,2,opt,name=elapsed,proto3" json:"elapsed,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *WarmUpResponse) Reset() {
+	*x = WarmUpResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WarmUpResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WarmUpResponse) ProtoMessage() {}
+
+func (x *WarmUpResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WarmUpResponse.ProtoReflect.Descriptor instead.
+func (*WarmUpResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WarmUpResponse) GetOrdersCreated() int32 {
+	if x != nil {
+		return x.OrdersCreated
+	}
+	return 0
+}
+
+func (x *WarmUpResponse) GetElapsed() *durationpb.Duration {
+	if x != nil {
+		return x.Elapsed
+	}
+	return nil
+}
+
+// Request to create a new order
+type CreateOrderRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName   string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	OrderId         string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Side            OrderSide              `protobuf:"varint,3,opt,name=side,proto3,enum=matchingo.api.OrderSide" json:"side,omitempty"`
+	Quantity        string                 `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Price           string                 `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
+	OrderType       OrderType              `protobuf:"varint,6,opt,name=order_type,json=orderType,proto3,enum=matchingo.api.OrderType" json:"order_type,omitempty"`
+	TimeInForce     TimeInForce            `protobuf:"varint,7,opt,name=time_in_force,json=timeInForce,proto3,enum=matchingo.api.TimeInForce" json:"time_in_force,omitempty"`
+	StopPrice       string                 `protobuf:"bytes,8,opt,name=stop_price,json=stopPrice,proto3" json:"stop_price,omitempty"`                    // Only for stop orders
+	OcoId           string                 `protobuf:"bytes,9,opt,name=oco_id,json=ocoId,proto3" json:"oco_id,omitempty"`                                // Only for OCO orders
+	UserAddress     string                 `protobuf:"bytes,10,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`             // User's wallet address
+	RequestId       string                 `protobuf:"bytes,11,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`                   // Correlates logs and messages; generated by the server if empty
+	PostOnly        bool                   `protobuf:"varint,12,opt,name=post_only,json=postOnly,proto3" json:"post_only,omitempty"`                     // LIMIT orders only: reject instead of matching on arrival
+	ClientOrderId   string                 `protobuf:"bytes,13,opt,name=client_order_id,json=clientOrderId,proto3" json:"client_order_id,omitempty"`     // Idempotency key: a repeat of an accepted request gets the first response back
+	VisibleQuantity string                 `protobuf:"bytes,14,opt,name=visible_quantity,json=visibleQuantity,proto3" json:"visible_quantity,omitempty"` // ICEBERG orders only: how much of the quantity is shown while resting
+	GtdExpiresAt    *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=gtd_expires_at,json=gtdExpiresAt,proto3" json:"gtd_expires_at,omitempty"`        // GTD orders only: when the resting order is canceled
+	IsQuote         bool                   `protobuf:"varint,16,opt,name=is_quote,json=isQuote,proto3" json:"is_quote,omitempty"`                        // MARKET orders only: quantity is a budget in the quote currency, e.g. "500" to buy $500 worth
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
 func (x *CreateOrderRequest) Reset() {
 	*x = CreateOrderRequest{}
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[6]
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -639,7 +1398,7 @@ func (x *CreateOrderRequest) String() string {
 func (*CreateOrderRequest) ProtoMessage() {}
 
 func (x *CreateOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[6]
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -652,7 +1411,7 @@ func (x *CreateOrderRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateOrderRequest.ProtoReflect.Descriptor instead.
 func (*CreateOrderRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{6}
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *CreateOrderRequest) GetOrderBookName() string {
@@ -694,75 +1453,2907 @@ func (x *CreateOrderRequest) GetOrderType() OrderType {
 	if x != nil {
 		return x.OrderType
 	}
-	return OrderType_LIMIT
+	return OrderType_LIMIT
+}
+
+func (x *CreateOrderRequest) GetTimeInForce() TimeInForce {
+	if x != nil {
+		return x.TimeInForce
+	}
+	return TimeInForce_GTC
+}
+
+func (x *CreateOrderRequest) GetStopPrice() string {
+	if x != nil {
+		return x.StopPrice
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetOcoId() string {
+	if x != nil {
+		return x.OcoId
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetPostOnly() bool {
+	if x != nil {
+		return x.PostOnly
+	}
+	return false
+}
+
+func (x *CreateOrderRequest) GetClientOrderId() string {
+	if x != nil {
+		return x.ClientOrderId
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetVisibleQuantity() string {
+	if x != nil {
+		return x.VisibleQuantity
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetGtdExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.GtdExpiresAt
+	}
+	return nil
+}
+
+func (x *CreateOrderRequest) GetIsQuote() bool {
+	if x != nil {
+		return x.IsQuote
+	}
+	return false
+}
+
+// Request to submit several orders, in order
+type BatchCreateOrdersRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Orders []*CreateOrderRequest  `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"` // At most 500
+	// If set, no order is submitted unless every order passes validation
+	Atomic        bool `protobuf:"varint,2,opt,name=atomic,proto3" json:"atomic,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateOrdersRequest) Reset() {
+	*x = BatchCreateOrdersRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateOrdersRequest) ProtoMessage() {}
+
+func (x *BatchCreateOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateOrdersRequest.ProtoReflect.Descriptor instead.
+func (*BatchCreateOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BatchCreateOrdersRequest) GetOrders() []*CreateOrderRequest {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *BatchCreateOrdersRequest) GetAtomic() bool {
+	if x != nil {
+		return x.Atomic
+	}
+	return false
+}
+
+// Response with one entry per requested order, in request order. Orders that
+// were not submitted have an entry with status REJECTED and are listed in errors.
+type BatchCreateOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*OrderResponse       `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	Errors        []*BatchOrderError     `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateOrdersResponse) Reset() {
+	*x = BatchCreateOrdersResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateOrdersResponse) ProtoMessage() {}
+
+func (x *BatchCreateOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateOrdersResponse.ProtoReflect.Descriptor instead.
+func (*BatchCreateOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BatchCreateOrdersResponse) GetOrders() []*OrderResponse {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *BatchCreateOrdersResponse) GetErrors() []*BatchOrderError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+// Why an order of a batch was not submitted
+type BatchOrderError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"` // Position of the order in the request
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Code          int32                  `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"` // gRPC status code the order would have failed with alone
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchOrderError) Reset() {
+	*x = BatchOrderError{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchOrderError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchOrderError) ProtoMessage() {}
+
+func (x *BatchOrderError) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchOrderError.ProtoReflect.Descriptor instead.
+func (*BatchOrderError) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BatchOrderError) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *BatchOrderError) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *BatchOrderError) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *BatchOrderError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Request to simulate an order; order fields match CreateOrderRequest
+type SimulateOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Side          OrderSide              `protobuf:"varint,3,opt,name=side,proto3,enum=matchingo.api.OrderSide" json:"side,omitempty"`
+	Quantity      string                 `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Price         string                 `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
+	OrderType     OrderType              `protobuf:"varint,6,opt,name=order_type,json=orderType,proto3,enum=matchingo.api.OrderType" json:"order_type,omitempty"`
+	TimeInForce   TimeInForce            `protobuf:"varint,7,opt,name=time_in_force,json=timeInForce,proto3,enum=matchingo.api.TimeInForce" json:"time_in_force,omitempty"`
+	StopPrice     string                 `protobuf:"bytes,8,opt,name=stop_price,json=stopPrice,proto3" json:"stop_price,omitempty"`
+	OcoId         string                 `protobuf:"bytes,9,opt,name=oco_id,json=ocoId,proto3" json:"oco_id,omitempty"`
+	UserAddress   string                 `protobuf:"bytes,10,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`
+	GtdExpiresAt  *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=gtd_expires_at,json=gtdExpiresAt,proto3" json:"gtd_expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimulateOrderRequest) Reset() {
+	*x = SimulateOrderRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimulateOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimulateOrderRequest) ProtoMessage() {}
+
+func (x *SimulateOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimulateOrderRequest.ProtoReflect.Descriptor instead.
+func (*SimulateOrderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SimulateOrderRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *SimulateOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *SimulateOrderRequest) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_BUY
+}
+
+func (x *SimulateOrderRequest) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *SimulateOrderRequest) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *SimulateOrderRequest) GetOrderType() OrderType {
+	if x != nil {
+		return x.OrderType
+	}
+	return OrderType_LIMIT
+}
+
+func (x *SimulateOrderRequest) GetTimeInForce() TimeInForce {
+	if x != nil {
+		return x.TimeInForce
+	}
+	return TimeInForce_GTC
+}
+
+func (x *SimulateOrderRequest) GetStopPrice() string {
+	if x != nil {
+		return x.StopPrice
+	}
+	return ""
+}
+
+func (x *SimulateOrderRequest) GetOcoId() string {
+	if x != nil {
+		return x.OcoId
+	}
+	return ""
+}
+
+func (x *SimulateOrderRequest) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *SimulateOrderRequest) GetGtdExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.GtdExpiresAt
+	}
+	return nil
+}
+
+// A resting order that a simulated order would trade against
+type SimulatedMatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Quantity      string                 `protobuf:"bytes,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Price         string                 `protobuf:"bytes,3,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimulatedMatch) Reset() {
+	*x = SimulatedMatch{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimulatedMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimulatedMatch) ProtoMessage() {}
+
+func (x *SimulatedMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimulatedMatch.ProtoReflect.Descriptor instead.
+func (*SimulatedMatch) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SimulatedMatch) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *SimulatedMatch) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *SimulatedMatch) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+// Estimated outcome of a simulated order
+type SimulateOrderResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	EstimatedFillQty string                 `protobuf:"bytes,1,opt,name=estimated_fill_qty,json=estimatedFillQty,proto3" json:"estimated_fill_qty,omitempty"`
+	// Volume-weighted price of the estimated fills, zero when nothing fills
+	EstimatedAvgPrice string `protobuf:"bytes,2,opt,name=estimated_avg_price,json=estimatedAvgPrice,proto3" json:"estimated_avg_price,omitempty"`
+	// Distance of the average price from the best opposite price, in basis points
+	EstimatedSlippageBps string            `protobuf:"bytes,3,opt,name=estimated_slippage_bps,json=estimatedSlippageBps,proto3" json:"estimated_slippage_bps,omitempty"`
+	MatchedOrders        []*SimulatedMatch `protobuf:"bytes,4,rep,name=matched_orders,json=matchedOrders,proto3" json:"matched_orders,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *SimulateOrderResponse) Reset() {
+	*x = SimulateOrderResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimulateOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimulateOrderResponse) ProtoMessage() {}
+
+func (x *SimulateOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimulateOrderResponse.ProtoReflect.Descriptor instead.
+func (*SimulateOrderResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SimulateOrderResponse) GetEstimatedFillQty() string {
+	if x != nil {
+		return x.EstimatedFillQty
+	}
+	return ""
+}
+
+func (x *SimulateOrderResponse) GetEstimatedAvgPrice() string {
+	if x != nil {
+		return x.EstimatedAvgPrice
+	}
+	return ""
+}
+
+func (x *SimulateOrderResponse) GetEstimatedSlippageBps() string {
+	if x != nil {
+		return x.EstimatedSlippageBps
+	}
+	return ""
+}
+
+func (x *SimulateOrderResponse) GetMatchedOrders() []*SimulatedMatch {
+	if x != nil {
+		return x.MatchedOrders
+	}
+	return nil
+}
+
+// Request to route one order across several order books
+type RouteOrderRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookNames []string               `protobuf:"bytes,1,rep,name=order_book_names,json=orderBookNames,proto3" json:"order_book_names,omitempty"`
+	OrderId        string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Side           OrderSide              `protobuf:"varint,3,opt,name=side,proto3,enum=matchingo.api.OrderSide" json:"side,omitempty"`
+	Quantity       string                 `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Price          string                 `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
+	OrderType      OrderType              `protobuf:"varint,6,opt,name=order_type,json=orderType,proto3,enum=matchingo.api.OrderType" json:"order_type,omitempty"`
+	TimeInForce    TimeInForce            `protobuf:"varint,7,opt,name=time_in_force,json=timeInForce,proto3,enum=matchingo.api.TimeInForce" json:"time_in_force,omitempty"`
+	UserAddress    string                 `protobuf:"bytes,8,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`
+	Strategy       AllocationStrategy     `protobuf:"varint,9,opt,name=strategy,proto3,enum=matchingo.api.AllocationStrategy" json:"strategy,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RouteOrderRequest) Reset() {
+	*x = RouteOrderRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteOrderRequest) ProtoMessage() {}
+
+func (x *RouteOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteOrderRequest.ProtoReflect.Descriptor instead.
+func (*RouteOrderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RouteOrderRequest) GetOrderBookNames() []string {
+	if x != nil {
+		return x.OrderBookNames
+	}
+	return nil
+}
+
+func (x *RouteOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *RouteOrderRequest) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_BUY
+}
+
+func (x *RouteOrderRequest) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *RouteOrderRequest) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *RouteOrderRequest) GetOrderType() OrderType {
+	if x != nil {
+		return x.OrderType
+	}
+	return OrderType_LIMIT
+}
+
+func (x *RouteOrderRequest) GetTimeInForce() TimeInForce {
+	if x != nil {
+		return x.TimeInForce
+	}
+	return TimeInForce_GTC
+}
+
+func (x *RouteOrderRequest) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *RouteOrderRequest) GetStrategy() AllocationStrategy {
+	if x != nil {
+		return x.Strategy
+	}
+	return AllocationStrategy_BEST_PRICE_FIRST
+}
+
+// The slice of a routed order processed by one book
+type RoutedOrder struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// ID of the child order, "<order_id>-<order_book_name>"
+	OrderId           string `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Quantity          string `protobuf:"bytes,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	ExecutedQuantity  string `protobuf:"bytes,4,opt,name=executed_quantity,json=executedQuantity,proto3" json:"executed_quantity,omitempty"`
+	RemainingQuantity string `protobuf:"bytes,5,opt,name=remaining_quantity,json=remainingQuantity,proto3" json:"remaining_quantity,omitempty"`
+	// Whether the unfilled remainder rests in the book
+	Stored        bool `protobuf:"varint,6,opt,name=stored,proto3" json:"stored,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoutedOrder) Reset() {
+	*x = RoutedOrder{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoutedOrder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoutedOrder) ProtoMessage() {}
+
+func (x *RoutedOrder) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoutedOrder.ProtoReflect.Descriptor instead.
+func (*RoutedOrder) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RoutedOrder) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *RoutedOrder) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *RoutedOrder) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *RoutedOrder) GetExecutedQuantity() string {
+	if x != nil {
+		return x.ExecutedQuantity
+	}
+	return ""
+}
+
+func (x *RoutedOrder) GetRemainingQuantity() string {
+	if x != nil {
+		return x.RemainingQuantity
+	}
+	return ""
+}
+
+func (x *RoutedOrder) GetStored() bool {
+	if x != nil {
+		return x.Stored
+	}
+	return false
+}
+
+// Child orders created by RouteOrder, best-priced book first
+type RouteOrderResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Orders           []*RoutedOrder         `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	ExecutedQuantity string                 `protobuf:"bytes,2,opt,name=executed_quantity,json=executedQuantity,proto3" json:"executed_quantity,omitempty"`
+	// Why routing stopped when a book failed to process its child order;
+	// orders holds the child orders processed before it
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouteOrderResponse) Reset() {
+	*x = RouteOrderResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteOrderResponse) ProtoMessage() {}
+
+func (x *RouteOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteOrderResponse.ProtoReflect.Descriptor instead.
+func (*RouteOrderResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RouteOrderResponse) GetOrders() []*RoutedOrder {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *RouteOrderResponse) GetExecutedQuantity() string {
+	if x != nil {
+		return x.ExecutedQuantity
+	}
+	return ""
+}
+
+func (x *RouteOrderResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Response containing order information
+type OrderResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	OrderId           string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	OrderBookName     string                 `protobuf:"bytes,2,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	Side              OrderSide              `protobuf:"varint,3,opt,name=side,proto3,enum=matchingo.api.OrderSide" json:"side,omitempty"`
+	Quantity          string                 `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Price             string                 `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
+	OrderType         OrderType              `protobuf:"varint,6,opt,name=order_type,json=orderType,proto3,enum=matchingo.api.OrderType" json:"order_type,omitempty"`
+	TimeInForce       TimeInForce            `protobuf:"varint,7,opt,name=time_in_force,json=timeInForce,proto3,enum=matchingo.api.TimeInForce" json:"time_in_force,omitempty"`
+	StopPrice         string                 `protobuf:"bytes,8,opt,name=stop_price,json=stopPrice,proto3" json:"stop_price,omitempty"`
+	Status            OrderStatus            `protobuf:"varint,9,opt,name=status,proto3,enum=matchingo.api.OrderStatus" json:"status,omitempty"`
+	FilledQuantity    string                 `protobuf:"bytes,10,opt,name=filled_quantity,json=filledQuantity,proto3" json:"filled_quantity,omitempty"`
+	RemainingQuantity string                 `protobuf:"bytes,11,opt,name=remaining_quantity,json=remainingQuantity,proto3" json:"remaining_quantity,omitempty"`
+	CreatedAt         *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt         *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Fills             []*Fill                `protobuf:"bytes,14,rep,name=fills,proto3" json:"fills,omitempty"`
+	OcoId             string                 `protobuf:"bytes,15,opt,name=oco_id,json=ocoId,proto3" json:"oco_id,omitempty"`
+	UserAddress       string                 `protobuf:"bytes,16,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`                              // User's wallet address
+	OrderState        OrderStatus            `protobuf:"varint,17,opt,name=order_state,json=orderState,proto3,enum=matchingo.api.OrderStatus" json:"order_state,omitempty"` // Lifecycle state tracked by the matching engine
+	RequestId         string                 `protobuf:"bytes,18,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	ErrorCode         OrderErrorCode         `protobuf:"varint,19,opt,name=error_code,json=errorCode,proto3,enum=matchingo.api.OrderErrorCode" json:"error_code,omitempty"` // Set by BatchGetOrders for orders it could not return
+	ClientOrderId     string                 `protobuf:"bytes,20,opt,name=client_order_id,json=clientOrderId,proto3" json:"client_order_id,omitempty"`
+	// How long matching the order took in milliseconds; set by CreateOrder
+	ProcessingTimeMs float64                `protobuf:"fixed64,21,opt,name=processing_time_ms,json=processingTimeMs,proto3" json:"processing_time_ms,omitempty"`
+	GtdExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,22,opt,name=gtd_expires_at,json=gtdExpiresAt,proto3" json:"gtd_expires_at,omitempty"` // Set for GTD orders
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *OrderResponse) Reset() {
+	*x = OrderResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderResponse) ProtoMessage() {}
+
+func (x *OrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderResponse.ProtoReflect.Descriptor instead.
+func (*OrderResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *OrderResponse) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_BUY
+}
+
+func (x *OrderResponse) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetOrderType() OrderType {
+	if x != nil {
+		return x.OrderType
+	}
+	return OrderType_LIMIT
+}
+
+func (x *OrderResponse) GetTimeInForce() TimeInForce {
+	if x != nil {
+		return x.TimeInForce
+	}
+	return TimeInForce_GTC
+}
+
+func (x *OrderResponse) GetStopPrice() string {
+	if x != nil {
+		return x.StopPrice
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetStatus() OrderStatus {
+	if x != nil {
+		return x.Status
+	}
+	return OrderStatus_PENDING
+}
+
+func (x *OrderResponse) GetFilledQuantity() string {
+	if x != nil {
+		return x.FilledQuantity
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetRemainingQuantity() string {
+	if x != nil {
+		return x.RemainingQuantity
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *OrderResponse) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *OrderResponse) GetFills() []*Fill {
+	if x != nil {
+		return x.Fills
+	}
+	return nil
+}
+
+func (x *OrderResponse) GetOcoId() string {
+	if x != nil {
+		return x.OcoId
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetOrderState() OrderStatus {
+	if x != nil {
+		return x.OrderState
+	}
+	return OrderStatus_PENDING
+}
+
+func (x *OrderResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetErrorCode() OrderErrorCode {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return OrderErrorCode_NO_ERROR
+}
+
+func (x *OrderResponse) GetClientOrderId() string {
+	if x != nil {
+		return x.ClientOrderId
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetProcessingTimeMs() float64 {
+	if x != nil {
+		return x.ProcessingTimeMs
+	}
+	return 0
+}
+
+func (x *OrderResponse) GetGtdExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.GtdExpiresAt
+	}
+	return nil
+}
+
+// Represents a fill (trade) that has occurred
+type Fill struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Price         string                 `protobuf:"bytes,1,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity      string                 `protobuf:"bytes,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fill) Reset() {
+	*x = Fill{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fill) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fill) ProtoMessage() {}
+
+func (x *Fill) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fill.ProtoReflect.Descriptor instead.
+func (*Fill) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Fill) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Fill) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *Fill) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+// Request to retrieve an order
+type GetOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderRequest) Reset() {
+	*x = GetOrderRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderRequest) ProtoMessage() {}
+
+func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetOrderRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *GetOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+// Request to retrieve several orders from one order book
+type BatchGetOrdersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	OrderIds      []string               `protobuf:"bytes,2,rep,name=order_ids,json=orderIds,proto3" json:"order_ids,omitempty"` // At most 500
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetOrdersRequest) Reset() {
+	*x = BatchGetOrdersRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetOrdersRequest) ProtoMessage() {}
+
+func (x *BatchGetOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetOrdersRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *BatchGetOrdersRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *BatchGetOrdersRequest) GetOrderIds() []string {
+	if x != nil {
+		return x.OrderIds
+	}
+	return nil
+}
+
+// Response with one entry per requested order ID, in request order
+type BatchGetOrdersResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Orders []*OrderResponse       `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	// Set when trailing entries were dropped to fit the server's response size limit
+	Truncated     bool `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetOrdersResponse) Reset() {
+	*x = BatchGetOrdersResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetOrdersResponse) ProtoMessage() {}
+
+func (x *BatchGetOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetOrdersResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *BatchGetOrdersResponse) GetOrders() []*OrderResponse {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *BatchGetOrdersResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// Request to list the pending stop orders of an order book
+type ListStopOrdersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// Only list stop orders on this side when set
+	Side *OrderSide `protobuf:"varint,2,opt,name=side,proto3,enum=matchingo.api.OrderSide,oneof" json:"side,omitempty"`
+	// For pagination, the maximum number of items to return
+	Limit int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	// For pagination, the offset from which to start returning items
+	Offset        int32 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStopOrdersRequest) Reset() {
+	*x = ListStopOrdersRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStopOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStopOrdersRequest) ProtoMessage() {}
+
+func (x *ListStopOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStopOrdersRequest.ProtoReflect.Descriptor instead.
+func (*ListStopOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListStopOrdersRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *ListStopOrdersRequest) GetSide() OrderSide {
+	if x != nil && x.Side != nil {
+		return *x.Side
+	}
+	return OrderSide_BUY
+}
+
+func (x *ListStopOrdersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListStopOrdersRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// A stop order waiting for its trigger price
+type StopOrder struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	OrderId string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Side    OrderSide              `protobuf:"varint,2,opt,name=side,proto3,enum=matchingo.api.OrderSide" json:"side,omitempty"`
+	// Last trade price at which the order becomes active
+	TriggerPrice string `protobuf:"bytes,3,opt,name=trigger_price,json=triggerPrice,proto3" json:"trigger_price,omitempty"`
+	// Price of the limit order the stop becomes once triggered
+	LimitPrice    string                 `protobuf:"bytes,4,opt,name=limit_price,json=limitPrice,proto3" json:"limit_price,omitempty"`
+	Quantity      string                 `protobuf:"bytes,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	UserAddress   string                 `protobuf:"bytes,6,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopOrder) Reset() {
+	*x = StopOrder{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopOrder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopOrder) ProtoMessage() {}
+
+func (x *StopOrder) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopOrder.ProtoReflect.Descriptor instead.
+func (*StopOrder) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *StopOrder) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *StopOrder) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_BUY
+}
+
+func (x *StopOrder) GetTriggerPrice() string {
+	if x != nil {
+		return x.TriggerPrice
+	}
+	return ""
+}
+
+func (x *StopOrder) GetLimitPrice() string {
+	if x != nil {
+		return x.LimitPrice
+	}
+	return ""
+}
+
+func (x *StopOrder) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *StopOrder) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *StopOrder) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// Response listing pending stop orders, buys first, each by trigger price
+type ListStopOrdersResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	StopOrders []*StopOrder           `protobuf:"bytes,1,rep,name=stop_orders,json=stopOrders,proto3" json:"stop_orders,omitempty"`
+	// Number of stop orders matching the filter before pagination
+	Total int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	// Set when trailing entries were dropped to fit the server's response size limit
+	Truncated     bool `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStopOrdersResponse) Reset() {
+	*x = ListStopOrdersResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStopOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStopOrdersResponse) ProtoMessage() {}
+
+func (x *ListStopOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStopOrdersResponse.ProtoReflect.Descriptor instead.
+func (*ListStopOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListStopOrdersResponse) GetStopOrders() []*StopOrder {
+	if x != nil {
+		return x.StopOrders
+	}
+	return nil
+}
+
+func (x *ListStopOrdersResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListStopOrdersResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// Request to cancel an order
+type CancelOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderRequest) Reset() {
+	*x = CancelOrderRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderRequest) ProtoMessage() {}
+
+func (x *CancelOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderRequest.ProtoReflect.Descriptor instead.
+func (*CancelOrderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *CancelOrderRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *CancelOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+// Request to amend a resting limit order; at least one of price and quantity must be set
+type AmendOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Price         string                 `protobuf:"bytes,3,opt,name=price,proto3" json:"price,omitempty"`       // New limit price; empty keeps the current one
+	Quantity      string                 `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"` // New remaining quantity; empty keeps the current one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AmendOrderRequest) Reset() {
+	*x = AmendOrderRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AmendOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AmendOrderRequest) ProtoMessage() {}
+
+func (x *AmendOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AmendOrderRequest.ProtoReflect.Descriptor instead.
+func (*AmendOrderRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *AmendOrderRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *AmendOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *AmendOrderRequest) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *AmendOrderRequest) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+// Request to get the current state of an order book
+type GetOrderBookStateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Number of price levels to retrieve per side; defaults to 20, at most 1000
+	Depth int32 `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`
+	// Merges the levels whose prices round down to the same multiple of this
+	// decimal into one level at that multiple; empty lists every price
+	BucketSize    string `protobuf:"bytes,3,opt,name=bucket_size,json=bucketSize,proto3" json:"bucket_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderBookStateRequest) Reset() {
+	*x = GetOrderBookStateRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderBookStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderBookStateRequest) ProtoMessage() {}
+
+func (x *GetOrderBookStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderBookStateRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderBookStateRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GetOrderBookStateRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GetOrderBookStateRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *GetOrderBookStateRequest) GetBucketSize() string {
+	if x != nil {
+		return x.BucketSize
+	}
+	return ""
+}
+
+// Response containing order book state
+type OrderBookStateResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Name      string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Bids      []*PriceLevel          `protobuf:"bytes,2,rep,name=bids,proto3" json:"bids,omitempty"`
+	Asks      []*PriceLevel          `protobuf:"bytes,3,rep,name=asks,proto3" json:"asks,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Set when the deepest levels were dropped to fit the server's response size limit
+	Truncated     bool `protobuf:"varint,5,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderBookStateResponse) Reset() {
+	*x = OrderBookStateResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderBookStateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBookStateResponse) ProtoMessage() {}
+
+func (x *OrderBookStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBookStateResponse.ProtoReflect.Descriptor instead.
+func (*OrderBookStateResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *OrderBookStateResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OrderBookStateResponse) GetBids() []*PriceLevel {
+	if x != nil {
+		return x.Bids
+	}
+	return nil
+}
+
+func (x *OrderBookStateResponse) GetAsks() []*PriceLevel {
+	if x != nil {
+		return x.Asks
+	}
+	return nil
+}
+
+func (x *OrderBookStateResponse) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *OrderBookStateResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// Request for the depth at one price level
+type GetDepthAtPriceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	Side          OrderSide              `protobuf:"varint,2,opt,name=side,proto3,enum=matchingo.api.OrderSide" json:"side,omitempty"`
+	Price         string                 `protobuf:"bytes,3,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDepthAtPriceRequest) Reset() {
+	*x = GetDepthAtPriceRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDepthAtPriceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDepthAtPriceRequest) ProtoMessage() {}
+
+func (x *GetDepthAtPriceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDepthAtPriceRequest.ProtoReflect.Descriptor instead.
+func (*GetDepthAtPriceRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *GetDepthAtPriceRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *GetDepthAtPriceRequest) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_BUY
+}
+
+func (x *GetDepthAtPriceRequest) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+// Resting orders at one price level
+type DepthAtPriceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Price         string                 `protobuf:"bytes,1,opt,name=price,proto3" json:"price,omitempty"`
+	TotalQuantity string                 `protobuf:"bytes,2,opt,name=total_quantity,json=totalQuantity,proto3" json:"total_quantity,omitempty"`
+	OrderCount    int32                  `protobuf:"varint,3,opt,name=order_count,json=orderCount,proto3" json:"order_count,omitempty"`
+	// Distinct addresses of the users with orders at this price
+	UserAddresses []string `protobuf:"bytes,4,rep,name=user_addresses,json=userAddresses,proto3" json:"user_addresses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DepthAtPriceResponse) Reset() {
+	*x = DepthAtPriceResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DepthAtPriceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DepthAtPriceResponse) ProtoMessage() {}
+
+func (x *DepthAtPriceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DepthAtPriceResponse.ProtoReflect.Descriptor instead.
+func (*DepthAtPriceResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *DepthAtPriceResponse) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *DepthAtPriceResponse) GetTotalQuantity() string {
+	if x != nil {
+		return x.TotalQuantity
+	}
+	return ""
+}
+
+func (x *DepthAtPriceResponse) GetOrderCount() int32 {
+	if x != nil {
+		return x.OrderCount
+	}
+	return 0
+}
+
+func (x *DepthAtPriceResponse) GetUserAddresses() []string {
+	if x != nil {
+		return x.UserAddresses
+	}
+	return nil
+}
+
+// Request for the value locked in an order book
+type GetBookNotionalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// Percentage of each side's depth, by value, to take when estimating
+	// drawdowns; 0 skips the estimate
+	ShockPct      float64 `protobuf:"fixed64,2,opt,name=shock_pct,json=shockPct,proto3" json:"shock_pct,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBookNotionalRequest) Reset() {
+	*x = GetBookNotionalRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBookNotionalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBookNotionalRequest) ProtoMessage() {}
+
+func (x *GetBookNotionalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBookNotionalRequest.ProtoReflect.Descriptor instead.
+func (*GetBookNotionalRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *GetBookNotionalRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *GetBookNotionalRequest) GetShockPct() float64 {
+	if x != nil {
+		return x.ShockPct
+	}
+	return 0
+}
+
+// Value, price times quantity, of the resting orders on each side
+type BookNotionalResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	BidNotional   string                 `protobuf:"bytes,2,opt,name=bid_notional,json=bidNotional,proto3" json:"bid_notional,omitempty"`
+	AskNotional   string                 `protobuf:"bytes,3,opt,name=ask_notional,json=askNotional,proto3" json:"ask_notional,omitempty"`
+	// How far the best bid and best ask would move if shock_pct of their
+	// side were taken; zero when shock_pct is 0
+	BidDrawdown   string `protobuf:"bytes,4,opt,name=bid_drawdown,json=bidDrawdown,proto3" json:"bid_drawdown,omitempty"`
+	AskDrawdown   string `protobuf:"bytes,5,opt,name=ask_drawdown,json=askDrawdown,proto3" json:"ask_drawdown,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookNotionalResponse) Reset() {
+	*x = BookNotionalResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookNotionalResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookNotionalResponse) ProtoMessage() {}
+
+func (x *BookNotionalResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookNotionalResponse.ProtoReflect.Descriptor instead.
+func (*BookNotionalResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *BookNotionalResponse) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *BookNotionalResponse) GetBidNotional() string {
+	if x != nil {
+		return x.BidNotional
+	}
+	return ""
+}
+
+func (x *BookNotionalResponse) GetAskNotional() string {
+	if x != nil {
+		return x.AskNotional
+	}
+	return ""
+}
+
+func (x *BookNotionalResponse) GetBidDrawdown() string {
+	if x != nil {
+		return x.BidDrawdown
+	}
+	return ""
+}
+
+func (x *BookNotionalResponse) GetAskDrawdown() string {
+	if x != nil {
+		return x.AskDrawdown
+	}
+	return ""
+}
+
+// Request for the best bid and offer of an order book
+type GetBBORequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBBORequest) Reset() {
+	*x = GetBBORequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBBORequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBBORequest) ProtoMessage() {}
+
+func (x *GetBBORequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBBORequest.ProtoReflect.Descriptor instead.
+func (*GetBBORequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *GetBBORequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+// Best bid and offer. The price and quantity of an empty side are empty, as
+// are spread and mid_price unless both sides have orders.
+type BBOResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BidPrice      string                 `protobuf:"bytes,1,opt,name=bid_price,json=bidPrice,proto3" json:"bid_price,omitempty"`
+	BidQty        string                 `protobuf:"bytes,2,opt,name=bid_qty,json=bidQty,proto3" json:"bid_qty,omitempty"`
+	AskPrice      string                 `protobuf:"bytes,3,opt,name=ask_price,json=askPrice,proto3" json:"ask_price,omitempty"`
+	AskQty        string                 `protobuf:"bytes,4,opt,name=ask_qty,json=askQty,proto3" json:"ask_qty,omitempty"`
+	Spread        string                 `protobuf:"bytes,5,opt,name=spread,proto3" json:"spread,omitempty"`
+	MidPrice      string                 `protobuf:"bytes,6,opt,name=mid_price,json=midPrice,proto3" json:"mid_price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BBOResponse) Reset() {
+	*x = BBOResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BBOResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BBOResponse) ProtoMessage() {}
+
+func (x *BBOResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BBOResponse.ProtoReflect.Descriptor instead.
+func (*BBOResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *BBOResponse) GetBidPrice() string {
+	if x != nil {
+		return x.BidPrice
+	}
+	return ""
+}
+
+func (x *BBOResponse) GetBidQty() string {
+	if x != nil {
+		return x.BidQty
+	}
+	return ""
+}
+
+func (x *BBOResponse) GetAskPrice() string {
+	if x != nil {
+		return x.AskPrice
+	}
+	return ""
+}
+
+func (x *BBOResponse) GetAskQty() string {
+	if x != nil {
+		return x.AskQty
+	}
+	return ""
+}
+
+func (x *BBOResponse) GetSpread() string {
+	if x != nil {
+		return x.Spread
+	}
+	return ""
+}
+
+func (x *BBOResponse) GetMidPrice() string {
+	if x != nil {
+		return x.MidPrice
+	}
+	return ""
+}
+
+// Request for the VWAP of an order book's recent trades
+type VWAPRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// Only trades from the last window_seconds count; 0 counts every trade in the book's history
+	WindowSeconds int64 `protobuf:"varint,2,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VWAPRequest) Reset() {
+	*x = VWAPRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VWAPRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VWAPRequest) ProtoMessage() {}
+
+func (x *VWAPRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VWAPRequest.ProtoReflect.Descriptor instead.
+func (*VWAPRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *VWAPRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *VWAPRequest) GetWindowSeconds() int64 {
+	if x != nil {
+		return x.WindowSeconds
+	}
+	return 0
+}
+
+type VWAPResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// Volume-weighted average price; "0" when there were no trades
+	Vwap string `protobuf:"bytes,2,opt,name=vwap,proto3" json:"vwap,omitempty"`
+	// Total quantity traded
+	Volume        string `protobuf:"bytes,3,opt,name=volume,proto3" json:"volume,omitempty"`
+	TradeCount    int32  `protobuf:"varint,4,opt,name=trade_count,json=tradeCount,proto3" json:"trade_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VWAPResponse) Reset() {
+	*x = VWAPResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VWAPResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VWAPResponse) ProtoMessage() {}
+
+func (x *VWAPResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VWAPResponse.ProtoReflect.Descriptor instead.
+func (*VWAPResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *VWAPResponse) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *VWAPResponse) GetVwap() string {
+	if x != nil {
+		return x.Vwap
+	}
+	return ""
+}
+
+func (x *VWAPResponse) GetVolume() string {
+	if x != nil {
+		return x.Volume
+	}
+	return ""
+}
+
+func (x *VWAPResponse) GetTradeCount() int32 {
+	if x != nil {
+		return x.TradeCount
+	}
+	return 0
+}
+
+// Request for the TWAP of an order book over a window
+type TWAPRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// Length of the window, ending now; must be positive
+	WindowSeconds int64 `protobuf:"varint,2,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TWAPRequest) Reset() {
+	*x = TWAPRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TWAPRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TWAPRequest) ProtoMessage() {}
+
+func (x *TWAPRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TWAPRequest.ProtoReflect.Descriptor instead.
+func (*TWAPRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *TWAPRequest) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *TWAPRequest) GetWindowSeconds() int64 {
+	if x != nil {
+		return x.WindowSeconds
+	}
+	return 0
+}
+
+type TWAPResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// Time-weighted average of the last trade price; "0" when no price is known
+	Twap string `protobuf:"bytes,2,opt,name=twap,proto3" json:"twap,omitempty"`
+	// Trades made in the window
+	TradeCount    int32 `protobuf:"varint,3,opt,name=trade_count,json=tradeCount,proto3" json:"trade_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TWAPResponse) Reset() {
+	*x = TWAPResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TWAPResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TWAPResponse) ProtoMessage() {}
+
+func (x *TWAPResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TWAPResponse.ProtoReflect.Descriptor instead.
+func (*TWAPResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *TWAPResponse) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *TWAPResponse) GetTwap() string {
+	if x != nil {
+		return x.Twap
+	}
+	return ""
+}
+
+func (x *TWAPResponse) GetTradeCount() int32 {
+	if x != nil {
+		return x.TradeCount
+	}
+	return 0
+}
+
+// Represents a price level in the order book
+type PriceLevel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Price         string                 `protobuf:"bytes,1,opt,name=price,proto3" json:"price,omitempty"`
+	TotalQuantity string                 `protobuf:"bytes,2,opt,name=total_quantity,json=totalQuantity,proto3" json:"total_quantity,omitempty"`
+	OrderCount    int32                  `protobuf:"varint,3,opt,name=order_count,json=orderCount,proto3" json:"order_count,omitempty"`
+	UserAddress   string                 `protobuf:"bytes,4,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"` // User's wallet address
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PriceLevel) Reset() {
+	*x = PriceLevel{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PriceLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceLevel) ProtoMessage() {}
+
+func (x *PriceLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceLevel.ProtoReflect.Descriptor instead.
+func (*PriceLevel) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *PriceLevel) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *PriceLevel) GetTotalQuantity() string {
+	if x != nil {
+		return x.TotalQuantity
+	}
+	return ""
+}
+
+func (x *PriceLevel) GetOrderCount() int32 {
+	if x != nil {
+		return x.OrderCount
+	}
+	return 0
+}
+
+func (x *PriceLevel) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+// Represents a trade that has occurred
+type Trade struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	OrderId     string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Role        string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	Price       string                 `protobuf:"bytes,3,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity    string                 `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	IsQuote     bool                   `protobuf:"varint,5,opt,name=is_quote,json=isQuote,proto3" json:"is_quote,omitempty"`
+	UserAddress string                 `protobuf:"bytes,6,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"` // User's wallet address
+	// Fee the maker of this fill owes, from the order book's fee schedule;
+	// empty when nothing is owed, as on the taker's own entry
+	MakerFee string `protobuf:"bytes,7,opt,name=maker_fee,json=makerFee,proto3" json:"maker_fee,omitempty"`
+	// Fee the taker owes for this fill; empty when nothing is owed
+	TakerFee      string `protobuf:"bytes,8,opt,name=taker_fee,json=takerFee,proto3" json:"taker_fee,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Trade) Reset() {
+	*x = Trade{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Trade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trade) ProtoMessage() {}
+
+func (x *Trade) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trade.ProtoReflect.Descriptor instead.
+func (*Trade) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *Trade) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Trade) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Trade) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Trade) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *Trade) GetIsQuote() bool {
+	if x != nil {
+		return x.IsQuote
+	}
+	return false
+}
+
+func (x *Trade) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *Trade) GetMakerFee() string {
+	if x != nil {
+		return x.MakerFee
+	}
+	return ""
+}
+
+func (x *Trade) GetTakerFee() string {
+	if x != nil {
+		return x.TakerFee
+	}
+	return ""
+}
+
+// DoneMessage represents the message structure for the Done object
+// to be sent to the message queue
+type DoneMessage struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	OrderId           string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	ExecutedQuantity  string                 `protobuf:"bytes,2,opt,name=executed_quantity,json=executedQuantity,proto3" json:"executed_quantity,omitempty"`
+	RemainingQuantity string                 `protobuf:"bytes,3,opt,name=remaining_quantity,json=remainingQuantity,proto3" json:"remaining_quantity,omitempty"`
+	Trades            []*Trade               `protobuf:"bytes,4,rep,name=trades,proto3" json:"trades,omitempty"`
+	Canceled          []string               `protobuf:"bytes,5,rep,name=canceled,proto3" json:"canceled,omitempty"`
+	Activated         []string               `protobuf:"bytes,6,rep,name=activated,proto3" json:"activated,omitempty"`
+	Stored            bool                   `protobuf:"varint,7,opt,name=stored,proto3" json:"stored,omitempty"`
+	Quantity          string                 `protobuf:"bytes,8,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Processed         string                 `protobuf:"bytes,9,opt,name=processed,proto3" json:"processed,omitempty"`
+	Left              string                 `protobuf:"bytes,10,opt,name=left,proto3" json:"left,omitempty"`
+	UserAddress       string                 `protobuf:"bytes,11,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"` // User's wallet address
+	// Set when the message reports an order cancellation
+	Cancel *CancelMessage `protobuf:"bytes,12,opt,name=cancel,proto3" json:"cancel,omitempty"`
+	// ID of the request that produced this message, if known
+	RequestId string `protobuf:"bytes,13,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Orders the done messages of one order book, starting from 1; zero for cancellations
+	SequenceNumber uint64 `protobuf:"varint,14,opt,name=sequence_number,json=sequenceNumber,proto3" json:"sequence_number,omitempty"`
+	// How long matching the order took in milliseconds; zero when not timed
+	MatchDurationMs float64 `protobuf:"fixed64,15,opt,name=match_duration_ms,json=matchDurationMs,proto3" json:"match_duration_ms,omitempty"`
+	// Set when the order is a stop order that was activated
+	StopActivated bool `protobuf:"varint,16,opt,name=stop_activated,json=stopActivated,proto3" json:"stop_activated,omitempty"`
+	// Price a pegged order follows (MID, BEST_BID or BEST_ASK); empty for other orders
+	PegType string `protobuf:"bytes,17,opt,name=peg_type,json=pegType,proto3" json:"peg_type,omitempty"`
+	// Price a pegged order was placed at; empty for other orders
+	EffectivePrice string `protobuf:"bytes,18,opt,name=effective_price,json=effectivePrice,proto3" json:"effective_price,omitempty"`
+	// Set when the message reports the other leg of an OCO pair being canceled because one leg filled
+	CancelledByOco bool `protobuf:"varint,19,opt,name=cancelled_by_oco,json=cancelledByOco,proto3" json:"cancelled_by_oco,omitempty"`
+	// Set when self-trade prevention canceled the order or a resting order it reached
+	StpTriggered bool `protobuf:"varint,20,opt,name=stp_triggered,json=stpTriggered,proto3" json:"stp_triggered,omitempty"`
+	// Set when the order's trade tripped the book's circuit breaker: why matching is halted
+	HaltReason string `protobuf:"bytes,21,opt,name=halt_reason,json=haltReason,proto3" json:"halt_reason,omitempty"`
+	// Order book the message comes from; empty for books created without a name
+	OrderBookName string `protobuf:"bytes,22,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// The processed order: BUY or SELL, its limit price, type and time in force
+	Side        string `protobuf:"bytes,23,opt,name=side,proto3" json:"side,omitempty"`
+	Price       string `protobuf:"bytes,24,opt,name=price,proto3" json:"price,omitempty"`
+	OrderType   string `protobuf:"bytes,25,opt,name=order_type,json=orderType,proto3" json:"order_type,omitempty"`
+	TimeInForce string `protobuf:"bytes,26,opt,name=time_in_force,json=timeInForce,proto3" json:"time_in_force,omitempty"`
+	// When a GTD order expires; unset for other orders
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,27,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Set when the message reports an amendment of a resting order: price,
+	// left and remaining_quantity are its new price and remaining quantity
+	Amended bool `protobuf:"varint,28,opt,name=amended,proto3" json:"amended,omitempty"`
+	// Set when the order is an iceberg order, showing part of its quantity
+	Iceberg bool `protobuf:"varint,29,opt,name=iceberg,proto3" json:"iceberg,omitempty"`
+	// The OCO group the order belongs to; empty for orders placed alone
+	OcoId         string `protobuf:"bytes,30,opt,name=oco_id,json=ocoId,proto3" json:"oco_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DoneMessage) Reset() {
+	*x = DoneMessage{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DoneMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DoneMessage) ProtoMessage() {}
+
+func (x *DoneMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DoneMessage.ProtoReflect.Descriptor instead.
+func (*DoneMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *DoneMessage) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetExecutedQuantity() string {
+	if x != nil {
+		return x.ExecutedQuantity
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetRemainingQuantity() string {
+	if x != nil {
+		return x.RemainingQuantity
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetTrades() []*Trade {
+	if x != nil {
+		return x.Trades
+	}
+	return nil
+}
+
+func (x *DoneMessage) GetCanceled() []string {
+	if x != nil {
+		return x.Canceled
+	}
+	return nil
+}
+
+func (x *DoneMessage) GetActivated() []string {
+	if x != nil {
+		return x.Activated
+	}
+	return nil
+}
+
+func (x *DoneMessage) GetStored() bool {
+	if x != nil {
+		return x.Stored
+	}
+	return false
+}
+
+func (x *DoneMessage) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetProcessed() string {
+	if x != nil {
+		return x.Processed
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetLeft() string {
+	if x != nil {
+		return x.Left
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetCancel() *CancelMessage {
+	if x != nil {
+		return x.Cancel
+	}
+	return nil
+}
+
+func (x *DoneMessage) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetSequenceNumber() uint64 {
+	if x != nil {
+		return x.SequenceNumber
+	}
+	return 0
+}
+
+func (x *DoneMessage) GetMatchDurationMs() float64 {
+	if x != nil {
+		return x.MatchDurationMs
+	}
+	return 0
+}
+
+func (x *DoneMessage) GetStopActivated() bool {
+	if x != nil {
+		return x.StopActivated
+	}
+	return false
+}
+
+func (x *DoneMessage) GetPegType() string {
+	if x != nil {
+		return x.PegType
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetEffectivePrice() string {
+	if x != nil {
+		return x.EffectivePrice
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetCancelledByOco() bool {
+	if x != nil {
+		return x.CancelledByOco
+	}
+	return false
+}
+
+func (x *DoneMessage) GetStpTriggered() bool {
+	if x != nil {
+		return x.StpTriggered
+	}
+	return false
+}
+
+func (x *DoneMessage) GetHaltReason() string {
+	if x != nil {
+		return x.HaltReason
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetOrderBookName() string {
+	if x != nil {
+		return x.OrderBookName
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetOrderType() string {
+	if x != nil {
+		return x.OrderType
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetTimeInForce() string {
+	if x != nil {
+		return x.TimeInForce
+	}
+	return ""
+}
+
+func (x *DoneMessage) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *DoneMessage) GetAmended() bool {
+	if x != nil {
+		return x.Amended
+	}
+	return false
+}
+
+func (x *DoneMessage) GetIceberg() bool {
+	if x != nil {
+		return x.Iceberg
+	}
+	return false
+}
+
+func (x *DoneMessage) GetOcoId() string {
+	if x != nil {
+		return x.OcoId
+	}
+	return ""
+}
+
+// CancelMessage describes an order cancellation sent to the message queue
+type CancelMessage struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	OrderId           string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	CanceledAt        *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=canceled_at,json=canceledAt,proto3" json:"canceled_at,omitempty"`
+	CancelReason      CancelReason           `protobuf:"varint,3,opt,name=cancel_reason,json=cancelReason,proto3,enum=matchingo.api.CancelReason" json:"cancel_reason,omitempty"`
+	RemainingQuantity string                 `protobuf:"bytes,4,opt,name=remaining_quantity,json=remainingQuantity,proto3" json:"remaining_quantity,omitempty"`
+	UserAddress       string                 `protobuf:"bytes,5,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"` // User's wallet address
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CancelMessage) Reset() {
+	*x = CancelMessage{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelMessage) ProtoMessage() {}
+
+func (x *CancelMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelMessage.ProtoReflect.Descriptor instead.
+func (*CancelMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *CancelMessage) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
 }
 
-func (x *CreateOrderRequest) GetTimeInForce() TimeInForce {
+func (x *CancelMessage) GetCanceledAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.TimeInForce
+		return x.CanceledAt
 	}
-	return TimeInForce_GTC
+	return nil
 }
 
-func (x *CreateOrderRequest) GetStopPrice() string {
+func (x *CancelMessage) GetCancelReason() CancelReason {
 	if x != nil {
-		return x.StopPrice
+		return x.CancelReason
 	}
-	return ""
+	return CancelReason_USER_REQUESTED
 }
 
-func (x *CreateOrderRequest) GetOcoId() string {
+func (x *CancelMessage) GetRemainingQuantity() string {
 	if x != nil {
-		return x.OcoId
+		return x.RemainingQuantity
 	}
 	return ""
 }
 
-func (x *CreateOrderRequest) GetUserAddress() string {
+func (x *CancelMessage) GetUserAddress() string {
 	if x != nil {
 		return x.UserAddress
 	}
 	return ""
 }
 
-// Response containing order information
-type OrderResponse struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	OrderId           string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	OrderBookName     string                 `protobuf:"bytes,2,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
-	Side              OrderSide              `protobuf:"varint,3,opt,name=side,proto3,enum=matchingo.api.OrderSide" json:"side,omitempty"`
-	Quantity          string                 `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
-	Price             string                 `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
-	OrderType         OrderType              `protobuf:"varint,6,opt,name=order_type,json=orderType,proto3,enum=matchingo.api.OrderType" json:"order_type,omitempty"`
-	TimeInForce       TimeInForce            `protobuf:"varint,7,opt,name=time_in_force,json=timeInForce,proto3,enum=matchingo.api.TimeInForce" json:"time_in_force,omitempty"`
-	StopPrice         string                 `protobuf:"bytes,8,opt,name=stop_price,json=stopPrice,proto3" json:"stop_price,omitempty"`
-	Status            OrderStatus            `protobuf:"varint,9,opt,name=status,proto3,enum=matchingo.api.OrderStatus" json:"status,omitempty"`
-	FilledQuantity    string                 `protobuf:"bytes,10,opt,name=filled_quantity,json=filledQuantity,proto3" json:"filled_quantity,omitempty"`
-	RemainingQuantity string                 `protobuf:"bytes,11,opt,name=remaining_quantity,json=remainingQuantity,proto3" json:"remaining_quantity,omitempty"`
-	CreatedAt         *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt         *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	Fills             []*Fill                `protobuf:"bytes,14,rep,name=fills,proto3" json:"fills,omitempty"`
-	OcoId             string                 `protobuf:"bytes,15,opt,name=oco_id,json=ocoId,proto3" json:"oco_id,omitempty"`
-	UserAddress       string                 `protobuf:"bytes,16,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"` // User's wallet address
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+// Request to stream an order book's events
+type WatchOrderBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// Event types to stream; empty streams every type
+	EventTypes    []OrderBookEventType `protobuf:"varint,2,rep,packed,name=event_types,json=eventTypes,proto3,enum=matchingo.api.OrderBookEventType" json:"event_types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *OrderResponse) Reset() {
-	*x = OrderResponse{}
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[7]
+func (x *WatchOrderBookRequest) Reset() {
+	*x = WatchOrderBookRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *OrderResponse) String() string {
+func (x *WatchOrderBookRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OrderResponse) ProtoMessage() {}
+func (*WatchOrderBookRequest) ProtoMessage() {}
 
-func (x *OrderResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[7]
+func (x *WatchOrderBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -773,148 +4364,150 @@ func (x *OrderResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OrderResponse.ProtoReflect.Descriptor instead.
-func (*OrderResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{7}
-}
-
-func (x *OrderResponse) GetOrderId() string {
-	if x != nil {
-		return x.OrderId
-	}
-	return ""
+// Deprecated: Use WatchOrderBookRequest.ProtoReflect.Descriptor instead.
+func (*WatchOrderBookRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{48}
 }
 
-func (x *OrderResponse) GetOrderBookName() string {
+func (x *WatchOrderBookRequest) GetOrderBookName() string {
 	if x != nil {
 		return x.OrderBookName
 	}
 	return ""
 }
 
-func (x *OrderResponse) GetSide() OrderSide {
+func (x *WatchOrderBookRequest) GetEventTypes() []OrderBookEventType {
 	if x != nil {
-		return x.Side
+		return x.EventTypes
 	}
-	return OrderSide_BUY
+	return nil
 }
 
-func (x *OrderResponse) GetQuantity() string {
-	if x != nil {
-		return x.Quantity
-	}
-	return ""
+// A single change to an order book
+type OrderBookEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          OrderBookEventType     `protobuf:"varint,1,opt,name=type,proto3,enum=matchingo.api.OrderBookEventType" json:"type,omitempty"`
+	OrderBookName string                 `protobuf:"bytes,2,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	OrderId       string                 `protobuf:"bytes,3,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Side          OrderSide              `protobuf:"varint,4,opt,name=side,proto3,enum=matchingo.api.OrderSide" json:"side,omitempty"`
+	Price         string                 `protobuf:"bytes,5,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity      string                 `protobuf:"bytes,6,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// The resting order an incoming order matched; set for TRADE events only
+	MakerOrderId  string                 `protobuf:"bytes,7,opt,name=maker_order_id,json=makerOrderId,proto3" json:"maker_order_id,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *OrderResponse) GetPrice() string {
-	if x != nil {
-		return x.Price
-	}
-	return ""
+func (x *OrderBookEvent) Reset() {
+	*x = OrderBookEvent{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *OrderResponse) GetOrderType() OrderType {
-	if x != nil {
-		return x.OrderType
-	}
-	return OrderType_LIMIT
+func (x *OrderBookEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *OrderResponse) GetTimeInForce() TimeInForce {
+func (*OrderBookEvent) ProtoMessage() {}
+
+func (x *OrderBookEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[49]
 	if x != nil {
-		return x.TimeInForce
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return TimeInForce_GTC
+	return mi.MessageOf(x)
 }
 
-func (x *OrderResponse) GetStopPrice() string {
-	if x != nil {
-		return x.StopPrice
-	}
-	return ""
+// Deprecated: Use OrderBookEvent.ProtoReflect.Descriptor instead.
+func (*OrderBookEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{49}
 }
 
-func (x *OrderResponse) GetStatus() OrderStatus {
+func (x *OrderBookEvent) GetType() OrderBookEventType {
 	if x != nil {
-		return x.Status
+		return x.Type
 	}
-	return OrderStatus_PENDING
+	return OrderBookEventType_TRADE
 }
 
-func (x *OrderResponse) GetFilledQuantity() string {
+func (x *OrderBookEvent) GetOrderBookName() string {
 	if x != nil {
-		return x.FilledQuantity
+		return x.OrderBookName
 	}
 	return ""
 }
 
-func (x *OrderResponse) GetRemainingQuantity() string {
+func (x *OrderBookEvent) GetOrderId() string {
 	if x != nil {
-		return x.RemainingQuantity
+		return x.OrderId
 	}
 	return ""
 }
 
-func (x *OrderResponse) GetCreatedAt() *timestamppb.Timestamp {
+func (x *OrderBookEvent) GetSide() OrderSide {
 	if x != nil {
-		return x.CreatedAt
+		return x.Side
 	}
-	return nil
+	return OrderSide_BUY
 }
 
-func (x *OrderResponse) GetUpdatedAt() *timestamppb.Timestamp {
+func (x *OrderBookEvent) GetPrice() string {
 	if x != nil {
-		return x.UpdatedAt
+		return x.Price
 	}
-	return nil
+	return ""
 }
 
-func (x *OrderResponse) GetFills() []*Fill {
+func (x *OrderBookEvent) GetQuantity() string {
 	if x != nil {
-		return x.Fills
+		return x.Quantity
 	}
-	return nil
+	return ""
 }
 
-func (x *OrderResponse) GetOcoId() string {
+func (x *OrderBookEvent) GetMakerOrderId() string {
 	if x != nil {
-		return x.OcoId
+		return x.MakerOrderId
 	}
 	return ""
 }
 
-func (x *OrderResponse) GetUserAddress() string {
+func (x *OrderBookEvent) GetTimestamp() *timestamppb.Timestamp {
 	if x != nil {
-		return x.UserAddress
+		return x.Timestamp
 	}
-	return ""
+	return nil
 }
 
-// Represents a fill (trade) that has occurred
-type Fill struct {
+// Request to stream the trades of an order book
+type SubscribeTradesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Price         string                 `protobuf:"bytes,1,opt,name=price,proto3" json:"price,omitempty"`
-	Quantity      string                 `protobuf:"bytes,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Fill) Reset() {
-	*x = Fill{}
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[8]
+func (x *SubscribeTradesRequest) Reset() {
+	*x = SubscribeTradesRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Fill) String() string {
+func (x *SubscribeTradesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Fill) ProtoMessage() {}
+func (*SubscribeTradesRequest) ProtoMessage() {}
 
-func (x *Fill) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[8]
+func (x *SubscribeTradesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -925,56 +4518,48 @@ func (x *Fill) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Fill.ProtoReflect.Descriptor instead.
-func (*Fill) Descriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{8}
-}
-
-func (x *Fill) GetPrice() string {
-	if x != nil {
-		return x.Price
-	}
-	return ""
+// Deprecated: Use SubscribeTradesRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeTradesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{50}
 }
 
-func (x *Fill) GetQuantity() string {
+func (x *SubscribeTradesRequest) GetOrderBookName() string {
 	if x != nil {
-		return x.Quantity
+		return x.OrderBookName
 	}
 	return ""
 }
 
-func (x *Fill) GetTimestamp() *timestamppb.Timestamp {
-	if x != nil {
-		return x.Timestamp
-	}
-	return nil
-}
-
-// Request to retrieve an order
-type GetOrderRequest struct {
+// A match between an incoming (taker) order and a resting (maker) order
+type TradeEvent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
-	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	MakerOrderId  string                 `protobuf:"bytes,2,opt,name=maker_order_id,json=makerOrderId,proto3" json:"maker_order_id,omitempty"`
+	TakerOrderId  string                 `protobuf:"bytes,3,opt,name=taker_order_id,json=takerOrderId,proto3" json:"taker_order_id,omitempty"`
+	Price         string                 `protobuf:"bytes,4,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity      string                 `protobuf:"bytes,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// Side of the taker order
+	Side          OrderSide              `protobuf:"varint,6,opt,name=side,proto3,enum=matchingo.api.OrderSide" json:"side,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetOrderRequest) Reset() {
-	*x = GetOrderRequest{}
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[9]
+func (x *TradeEvent) Reset() {
+	*x = TradeEvent{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetOrderRequest) String() string {
+func (x *TradeEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetOrderRequest) ProtoMessage() {}
+func (*TradeEvent) ProtoMessage() {}
 
-func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[9]
+func (x *TradeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -985,49 +4570,83 @@ func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
-func (*GetOrderRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use TradeEvent.ProtoReflect.Descriptor instead.
+func (*TradeEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{51}
 }
 
-func (x *GetOrderRequest) GetOrderBookName() string {
+func (x *TradeEvent) GetOrderBookName() string {
 	if x != nil {
 		return x.OrderBookName
 	}
 	return ""
 }
 
-func (x *GetOrderRequest) GetOrderId() string {
+func (x *TradeEvent) GetMakerOrderId() string {
 	if x != nil {
-		return x.OrderId
+		return x.MakerOrderId
 	}
 	return ""
 }
 
-// Request to cancel an order
-type CancelOrderRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
-	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+func (x *TradeEvent) GetTakerOrderId() string {
+	if x != nil {
+		return x.TakerOrderId
+	}
+	return ""
+}
+
+func (x *TradeEvent) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *TradeEvent) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *TradeEvent) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_BUY
+}
+
+func (x *TradeEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type GetPositionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only return this user's positions; empty returns every user's
+	UserAddress   string `protobuf:"bytes,1,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CancelOrderRequest) Reset() {
-	*x = CancelOrderRequest{}
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[10]
+func (x *GetPositionsRequest) Reset() {
+	*x = GetPositionsRequest{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CancelOrderRequest) String() string {
+func (x *GetPositionsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CancelOrderRequest) ProtoMessage() {}
+func (*GetPositionsRequest) ProtoMessage() {}
 
-func (x *CancelOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[10]
+func (x *GetPositionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1038,50 +4657,41 @@ func (x *CancelOrderRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CancelOrderRequest.ProtoReflect.Descriptor instead.
-func (*CancelOrderRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{10}
-}
-
-func (x *CancelOrderRequest) GetOrderBookName() string {
-	if x != nil {
-		return x.OrderBookName
-	}
-	return ""
+// Deprecated: Use GetPositionsRequest.ProtoReflect.Descriptor instead.
+func (*GetPositionsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{52}
 }
 
-func (x *CancelOrderRequest) GetOrderId() string {
+func (x *GetPositionsRequest) GetUserAddress() string {
 	if x != nil {
-		return x.OrderId
+		return x.UserAddress
 	}
 	return ""
 }
 
-// Request to get the current state of an order book
-type GetOrderBookStateRequest struct {
+type GetPositionsResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	// Optional number of price levels to retrieve
-	Depth         int32 `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`
+	// Users with a position, sorted by address
+	Users         []*UserPositions `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetOrderBookStateRequest) Reset() {
-	*x = GetOrderBookStateRequest{}
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[11]
+func (x *GetPositionsResponse) Reset() {
+	*x = GetPositionsResponse{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetOrderBookStateRequest) String() string {
+func (x *GetPositionsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetOrderBookStateRequest) ProtoMessage() {}
+func (*GetPositionsResponse) ProtoMessage() {}
 
-func (x *GetOrderBookStateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[11]
+func (x *GetPositionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1092,51 +4702,44 @@ func (x *GetOrderBookStateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetOrderBookStateRequest.ProtoReflect.Descriptor instead.
-func (*GetOrderBookStateRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{11}
-}
-
-func (x *GetOrderBookStateRequest) GetName() string {
-	if x != nil {
-		return x.Name
-	}
-	return ""
+// Deprecated: Use GetPositionsResponse.ProtoReflect.Descriptor instead.
+func (*GetPositionsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{53}
 }
 
-func (x *GetOrderBookStateRequest) GetDepth() int32 {
+func (x *GetPositionsResponse) GetUsers() []*UserPositions {
 	if x != nil {
-		return x.Depth
+		return x.Users
 	}
-	return 0
+	return nil
 }
 
-// Response containing order book state
-type OrderBookStateResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Bids          []*PriceLevel          `protobuf:"bytes,2,rep,name=bids,proto3" json:"bids,omitempty"`
-	Asks          []*PriceLevel          `protobuf:"bytes,3,rep,name=asks,proto3" json:"asks,omitempty"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+type UserPositions struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	UserAddress string                 `protobuf:"bytes,1,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`
+	// Sum of the net positions in all books
+	Total string `protobuf:"bytes,2,opt,name=total,proto3" json:"total,omitempty"`
+	// Net position in each book the user traded in, sorted by book name
+	Books         []*BookPosition `protobuf:"bytes,3,rep,name=books,proto3" json:"books,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *OrderBookStateResponse) Reset() {
-	*x = OrderBookStateResponse{}
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[12]
+func (x *UserPositions) Reset() {
+	*x = UserPositions{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *OrderBookStateResponse) String() string {
+func (x *UserPositions) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OrderBookStateResponse) ProtoMessage() {}
+func (*UserPositions) ProtoMessage() {}
 
-func (x *OrderBookStateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[12]
+func (x *UserPositions) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1147,65 +4750,56 @@ func (x *OrderBookStateResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OrderBookStateResponse.ProtoReflect.Descriptor instead.
-func (*OrderBookStateResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use UserPositions.ProtoReflect.Descriptor instead.
+func (*UserPositions) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{54}
 }
 
-func (x *OrderBookStateResponse) GetName() string {
+func (x *UserPositions) GetUserAddress() string {
 	if x != nil {
-		return x.Name
+		return x.UserAddress
 	}
 	return ""
 }
 
-func (x *OrderBookStateResponse) GetBids() []*PriceLevel {
-	if x != nil {
-		return x.Bids
-	}
-	return nil
-}
-
-func (x *OrderBookStateResponse) GetAsks() []*PriceLevel {
+func (x *UserPositions) GetTotal() string {
 	if x != nil {
-		return x.Asks
+		return x.Total
 	}
-	return nil
+	return ""
 }
 
-func (x *OrderBookStateResponse) GetTimestamp() *timestamppb.Timestamp {
+func (x *UserPositions) GetBooks() []*BookPosition {
 	if x != nil {
-		return x.Timestamp
+		return x.Books
 	}
 	return nil
 }
 
-// Represents a price level in the order book
-type PriceLevel struct {
+type BookPosition struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Price         string                 `protobuf:"bytes,1,opt,name=price,proto3" json:"price,omitempty"`
-	TotalQuantity string                 `protobuf:"bytes,2,opt,name=total_quantity,json=totalQuantity,proto3" json:"total_quantity,omitempty"`
-	OrderCount    int32                  `protobuf:"varint,3,opt,name=order_count,json=orderCount,proto3" json:"order_count,omitempty"`
-	UserAddress   string                 `protobuf:"bytes,4,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"` // User's wallet address
+	OrderBookName string                 `protobuf:"bytes,1,opt,name=order_book_name,json=orderBookName,proto3" json:"order_book_name,omitempty"`
+	// Quantity bought less quantity sold
+	Net           string `protobuf:"bytes,2,opt,name=net,proto3" json:"net,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PriceLevel) Reset() {
-	*x = PriceLevel{}
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[13]
+func (x *BookPosition) Reset() {
+	*x = BookPosition{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PriceLevel) String() string {
+func (x *BookPosition) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PriceLevel) ProtoMessage() {}
+func (*BookPosition) ProtoMessage() {}
 
-func (x *PriceLevel) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[13]
+func (x *BookPosition) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1216,67 +4810,52 @@ func (x *PriceLevel) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PriceLevel.ProtoReflect.Descriptor instead.
-func (*PriceLevel) Descriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{13}
-}
-
-func (x *PriceLevel) GetPrice() string {
-	if x != nil {
-		return x.Price
-	}
-	return ""
+// Deprecated: Use BookPosition.ProtoReflect.Descriptor instead.
+func (*BookPosition) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{55}
 }
 
-func (x *PriceLevel) GetTotalQuantity() string {
+func (x *BookPosition) GetOrderBookName() string {
 	if x != nil {
-		return x.TotalQuantity
+		return x.OrderBookName
 	}
 	return ""
 }
 
-func (x *PriceLevel) GetOrderCount() int32 {
-	if x != nil {
-		return x.OrderCount
-	}
-	return 0
-}
-
-func (x *PriceLevel) GetUserAddress() string {
+func (x *BookPosition) GetNet() string {
 	if x != nil {
-		return x.UserAddress
+		return x.Net
 	}
 	return ""
 }
 
-// Represents a trade that has occurred
-type Trade struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
-	Price         string                 `protobuf:"bytes,3,opt,name=price,proto3" json:"price,omitempty"`
-	Quantity      string                 `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
-	IsQuote       bool                   `protobuf:"varint,5,opt,name=is_quote,json=isQuote,proto3" json:"is_quote,omitempty"`
-	UserAddress   string                 `protobuf:"bytes,6,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"` // User's wallet address
+type CreateOrderBookRequest_Instrument struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Largest move, in percent, allowed between a fill and the trade before it; zero disables the check
+	MaxPriceDeviationPct float64 `protobuf:"fixed64,1,opt,name=max_price_deviation_pct,json=maxPriceDeviationPct,proto3" json:"max_price_deviation_pct,omitempty"`
+	// Fraction digits prices are shown with, at most 18; zero uses the engine's 3
+	PricePrecision int32 `protobuf:"varint,2,opt,name=price_precision,json=pricePrecision,proto3" json:"price_precision,omitempty"`
+	// Fraction digits quantities are shown with, at most 18; zero uses the engine's 3
+	QtyPrecision  int32 `protobuf:"varint,3,opt,name=qty_precision,json=qtyPrecision,proto3" json:"qty_precision,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Trade) Reset() {
-	*x = Trade{}
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[14]
+func (x *CreateOrderBookRequest_Instrument) Reset() {
+	*x = CreateOrderBookRequest_Instrument{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Trade) String() string {
+func (x *CreateOrderBookRequest_Instrument) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Trade) ProtoMessage() {}
+func (*CreateOrderBookRequest_Instrument) ProtoMessage() {}
 
-func (x *Trade) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[14]
+func (x *CreateOrderBookRequest_Instrument) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1287,87 +4866,55 @@ func (x *Trade) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Trade.ProtoReflect.Descriptor instead.
-func (*Trade) Descriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{14}
-}
-
-func (x *Trade) GetOrderId() string {
-	if x != nil {
-		return x.OrderId
-	}
-	return ""
-}
-
-func (x *Trade) GetRole() string {
-	if x != nil {
-		return x.Role
-	}
-	return ""
-}
-
-func (x *Trade) GetPrice() string {
-	if x != nil {
-		return x.Price
-	}
-	return ""
+// Deprecated: Use CreateOrderBookRequest_Instrument.ProtoReflect.Descriptor instead.
+func (*CreateOrderBookRequest_Instrument) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{0, 1}
 }
 
-func (x *Trade) GetQuantity() string {
+func (x *CreateOrderBookRequest_Instrument) GetMaxPriceDeviationPct() float64 {
 	if x != nil {
-		return x.Quantity
+		return x.MaxPriceDeviationPct
 	}
-	return ""
+	return 0
 }
 
-func (x *Trade) GetIsQuote() bool {
+func (x *CreateOrderBookRequest_Instrument) GetPricePrecision() int32 {
 	if x != nil {
-		return x.IsQuote
+		return x.PricePrecision
 	}
-	return false
+	return 0
 }
 
-func (x *Trade) GetUserAddress() string {
+func (x *CreateOrderBookRequest_Instrument) GetQtyPrecision() int32 {
 	if x != nil {
-		return x.UserAddress
+		return x.QtyPrecision
 	}
-	return ""
+	return 0
 }
 
-// DoneMessage represents the message structure for the Done object
-// to be sent to the message queue
-type DoneMessage struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	OrderId           string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	ExecutedQuantity  string                 `protobuf:"bytes,2,opt,name=executed_quantity,json=executedQuantity,proto3" json:"executed_quantity,omitempty"`
-	RemainingQuantity string                 `protobuf:"bytes,3,opt,name=remaining_quantity,json=remainingQuantity,proto3" json:"remaining_quantity,omitempty"`
-	Trades            []*Trade               `protobuf:"bytes,4,rep,name=trades,proto3" json:"trades,omitempty"`
-	Canceled          []string               `protobuf:"bytes,5,rep,name=canceled,proto3" json:"canceled,omitempty"`
-	Activated         []string               `protobuf:"bytes,6,rep,name=activated,proto3" json:"activated,omitempty"`
-	Stored            bool                   `protobuf:"varint,7,opt,name=stored,proto3" json:"stored,omitempty"`
-	Quantity          string                 `protobuf:"bytes,8,opt,name=quantity,proto3" json:"quantity,omitempty"`
-	Processed         string                 `protobuf:"bytes,9,opt,name=processed,proto3" json:"processed,omitempty"`
-	Left              string                 `protobuf:"bytes,10,opt,name=left,proto3" json:"left,omitempty"`
-	UserAddress       string                 `protobuf:"bytes,11,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"` // User's wallet address
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+type CreateOrderBookRequest_Policy struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// How long an order may rest before it is canceled
+	MaxOrderAge   *durationpb.Duration `protobuf:"bytes,1,opt,name=max_order_age,json=maxOrderAge,proto3" json:"max_order_age,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DoneMessage) Reset() {
-	*x = DoneMessage{}
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[15]
+func (x *CreateOrderBookRequest_Policy) Reset() {
+	*x = CreateOrderBookRequest_Policy{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DoneMessage) String() string {
+func (x *CreateOrderBookRequest_Policy) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DoneMessage) ProtoMessage() {}
+func (*CreateOrderBookRequest_Policy) ProtoMessage() {}
 
-func (x *DoneMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[15]
+func (x *CreateOrderBookRequest_Policy) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1378,118 +4925,159 @@ func (x *DoneMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DoneMessage.ProtoReflect.Descriptor instead.
-func (*DoneMessage) Descriptor() ([]byte, []int) {
-	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{15}
-}
-
-func (x *DoneMessage) GetOrderId() string {
-	if x != nil {
-		return x.OrderId
-	}
-	return ""
-}
-
-func (x *DoneMessage) GetExecutedQuantity() string {
-	if x != nil {
-		return x.ExecutedQuantity
-	}
-	return ""
-}
-
-func (x *DoneMessage) GetRemainingQuantity() string {
-	if x != nil {
-		return x.RemainingQuantity
-	}
-	return ""
+// Deprecated: Use CreateOrderBookRequest_Policy.ProtoReflect.Descriptor instead.
+func (*CreateOrderBookRequest_Policy) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{0, 2}
 }
 
-func (x *DoneMessage) GetTrades() []*Trade {
+func (x *CreateOrderBookRequest_Policy) GetMaxOrderAge() *durationpb.Duration {
 	if x != nil {
-		return x.Trades
+		return x.MaxOrderAge
 	}
 	return nil
 }
 
-func (x *DoneMessage) GetCanceled() []string {
-	if x != nil {
-		return x.Canceled
-	}
-	return nil
+type CreateOrderBookRequest_CircuitBreaker struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Largest move, in percent, an order's last fill may make from the last
+	// trade price before matching halts; zero disables the breaker
+	MaxPriceMovePct float64 `protobuf:"fixed64,1,opt,name=max_price_move_pct,json=maxPriceMovePct,proto3" json:"max_price_move_pct,omitempty"`
+	// How long matching stays halted
+	CooldownSeconds int32 `protobuf:"varint,2,opt,name=cooldown_seconds,json=cooldownSeconds,proto3" json:"cooldown_seconds,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CreateOrderBookRequest_CircuitBreaker) Reset() {
+	*x = CreateOrderBookRequest_CircuitBreaker{}
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *DoneMessage) GetActivated() []string {
-	if x != nil {
-		return x.Activated
-	}
-	return nil
+func (x *CreateOrderBookRequest_CircuitBreaker) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *DoneMessage) GetStored() bool {
-	if x != nil {
-		return x.Stored
-	}
-	return false
-}
+func (*CreateOrderBookRequest_CircuitBreaker) ProtoMessage() {}
 
-func (x *DoneMessage) GetQuantity() string {
+func (x *CreateOrderBookRequest_CircuitBreaker) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_proto_orderbook_proto_msgTypes[59]
 	if x != nil {
-		return x.Quantity
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *DoneMessage) GetProcessed() string {
-	if x != nil {
-		return x.Processed
-	}
-	return ""
+// Deprecated: Use CreateOrderBookRequest_CircuitBreaker.ProtoReflect.Descriptor instead.
+func (*CreateOrderBookRequest_CircuitBreaker) Descriptor() ([]byte, []int) {
+	return file_pkg_api_proto_orderbook_proto_rawDescGZIP(), []int{0, 3}
 }
 
-func (x *DoneMessage) GetLeft() string {
+func (x *CreateOrderBookRequest_CircuitBreaker) GetMaxPriceMovePct() float64 {
 	if x != nil {
-		return x.Left
+		return x.MaxPriceMovePct
 	}
-	return ""
+	return 0
 }
 
-func (x *DoneMessage) GetUserAddress() string {
+func (x *CreateOrderBookRequest_CircuitBreaker) GetCooldownSeconds() int32 {
 	if x != nil {
-		return x.UserAddress
+		return x.CooldownSeconds
 	}
-	return ""
+	return 0
 }
 
 var File_pkg_api_proto_orderbook_proto protoreflect.FileDescriptor
 
 const file_pkg_api_proto_orderbook_proto_rawDesc = "" +
 	"\n" +
-	"\x1dpkg/api/proto/orderbook.proto\x12\rmatchingo.api\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\"\xf5\x01\n" +
+	"\x1dpkg/api/proto/orderbook.proto\x12\rmatchingo.api\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1cgoogle/api/annotations.proto\x1a.protoc-gen-openapiv2/options/annotations.proto\"\x8b\b\n" +
 	"\x16CreateOrderBookRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12=\n" +
 	"\fbackend_type\x18\x02 \x01(\x0e2\x1a.matchingo.api.BackendTypeR\vbackendType\x12L\n" +
-	"\aoptions\x18\x03 \x03(\v22.matchingo.api.CreateOrderBookRequest.OptionsEntryR\aoptions\x1a:\n" +
+	"\aoptions\x18\x03 \x03(\v22.matchingo.api.CreateOrderBookRequest.OptionsEntryR\aoptions\x12P\n" +
+	"\n" +
+	"instrument\x18\x04 \x01(\v20.matchingo.api.CreateOrderBookRequest.InstrumentR\n" +
+	"instrument\x12D\n" +
+	"\x06policy\x18\x05 \x01(\v2,.matchingo.api.CreateOrderBookRequest.PolicyR\x06policy\x12#\n" +
+	"\rstrategy_name\x18\x06 \x01(\tR\fstrategyName\x12\x1b\n" +
+	"\ttick_size\x18\a \x01(\tR\btickSize\x12\x19\n" +
+	"\blot_size\x18\b \x01(\tR\alotSize\x121\n" +
+	"\bstp_mode\x18\t \x01(\x0e2\x16.matchingo.api.STPModeR\astpMode\x12]\n" +
+	"\x0fcircuit_breaker\x18\n" +
+	" \x01(\v24.matchingo.api.CreateOrderBookRequest.CircuitBreakerR\x0ecircuitBreaker\x12\"\n" +
+	"\rmaker_fee_bps\x18\v \x01(\x05R\vmakerFeeBps\x12\"\n" +
+	"\rtaker_fee_bps\x18\f \x01(\x05R\vtakerFeeBps\x1a:\n" +
 	"\fOptionsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc2\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a\x91\x01\n" +
+	"\n" +
+	"Instrument\x125\n" +
+	"\x17max_price_deviation_pct\x18\x01 \x01(\x01R\x14maxPriceDeviationPct\x12'\n" +
+	"\x0fprice_precision\x18\x02 \x01(\x05R\x0epricePrecision\x12#\n" +
+	"\rqty_precision\x18\x03 \x01(\x05R\fqtyPrecision\x1aG\n" +
+	"\x06Policy\x12=\n" +
+	"\rmax_order_age\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\vmaxOrderAge\x1ah\n" +
+	"\x0eCircuitBreaker\x12+\n" +
+	"\x12max_price_move_pct\x18\x01 \x01(\x01R\x0fmaxPriceMovePct\x12)\n" +
+	"\x10cooldown_seconds\x18\x02 \x01(\x05R\x0fcooldownSeconds\"\xae\x04\n" +
 	"\x11OrderBookResponse\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12=\n" +
 	"\fbackend_type\x18\x02 \x01(\x0e2\x1a.matchingo.api.BackendTypeR\vbackendType\x129\n" +
 	"\n" +
 	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x1f\n" +
 	"\vorder_count\x18\x04 \x01(\x04R\n" +
-	"orderCount\")\n" +
+	"orderCount\x12\x1d\n" +
+	"\n" +
+	"is_deleted\x18\x05 \x01(\bR\tisDeleted\x129\n" +
+	"\n" +
+	"deleted_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\x12\x1b\n" +
+	"\ttick_size\x18\a \x01(\tR\btickSize\x12\x19\n" +
+	"\blot_size\x18\b \x01(\tR\alotSize\x121\n" +
+	"\bstp_mode\x18\t \x01(\x0e2\x16.matchingo.api.STPModeR\astpMode\x12]\n" +
+	"\x0fcircuit_breaker\x18\n" +
+	" \x01(\v24.matchingo.api.CreateOrderBookRequest.CircuitBreakerR\x0ecircuitBreaker\x12\"\n" +
+	"\rmaker_fee_bps\x18\v \x01(\x05R\vmakerFeeBps\x12\"\n" +
+	"\rtaker_fee_bps\x18\f \x01(\x05R\vtakerFeeBps\")\n" +
 	"\x13GetOrderBookRequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\"E\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"n\n" +
 	"\x15ListOrderBooksRequest\x12\x14\n" +
 	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
-	"\x06offset\x18\x02 \x01(\x05R\x06offset\"q\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12'\n" +
+	"\x0finclude_deleted\x18\x03 \x01(\bR\x0eincludeDeleted\"\x8f\x01\n" +
 	"\x16ListOrderBooksResponse\x12A\n" +
 	"\vorder_books\x18\x01 \x03(\v2 .matchingo.api.OrderBookResponseR\n" +
 	"orderBooks\x12\x14\n" +
-	"\x05total\x18\x02 \x01(\x05R\x05total\",\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x1c\n" +
+	"\ttruncated\x18\x03 \x01(\bR\ttruncated\",\n" +
 	"\x16DeleteOrderBookRequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\"\x89\x03\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"%\n" +
+	"\x0fUndeleteRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"S\n" +
+	"\x10UndeleteResponse\x12?\n" +
+	"\n" +
+	"order_book\x18\x01 \x01(\v2 .matchingo.api.OrderBookResponseR\torderBook\"+\n" +
+	"\x15ResetOrderBookRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"Y\n" +
+	"\x16ResetOrderBookResponse\x12?\n" +
+	"\n" +
+	"order_book\x18\x01 \x01(\v2 .matchingo.api.OrderBookResponseR\torderBook\"\xab\x01\n" +
+	"\rWarmUpRequest\x12\x1b\n" +
+	"\tbook_name\x18\x01 \x01(\tR\bbookName\x12\x1d\n" +
+	"\n" +
+	"num_levels\x18\x02 \x01(\x05R\tnumLevels\x12\x1d\n" +
+	"\n" +
+	"base_price\x18\x03 \x01(\tR\tbasePrice\x12\x1b\n" +
+	"\ttick_size\x18\x04 \x01(\tR\btickSize\x12\"\n" +
+	"\rqty_per_level\x18\x05 \x01(\tR\vqtyPerLevel\"l\n" +
+	"\x0eWarmUpResponse\x12%\n" +
+	"\x0eorders_created\x18\x01 \x01(\x05R\rordersCreated\x123\n" +
+	"\aelapsed\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\aelapsed\"\x94\x06\n" +
 	"\x12CreateOrderRequest\x12&\n" +
 	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x19\n" +
 	"\border_id\x18\x02 \x01(\tR\aorderId\x12,\n" +
@@ -1503,7 +5091,71 @@ const file_pkg_api_proto_orderbook_proto_rawDesc = "" +
 	"stop_price\x18\b \x01(\tR\tstopPrice\x12\x15\n" +
 	"\x06oco_id\x18\t \x01(\tR\x05ocoId\x12!\n" +
 	"\fuser_address\x18\n" +
-	" \x01(\tR\vuserAddress\"\xb1\x05\n" +
+	" \x01(\tR\vuserAddress\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\v \x01(\tR\trequestId\x12\x1b\n" +
+	"\tpost_only\x18\f \x01(\bR\bpostOnly\x12&\n" +
+	"\x0fclient_order_id\x18\r \x01(\tR\rclientOrderId\x12)\n" +
+	"\x10visible_quantity\x18\x0e \x01(\tR\x0fvisibleQuantity\x12@\n" +
+	"\x0egtd_expires_at\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\fgtdExpiresAt\x12\x19\n" +
+	"\bis_quote\x18\x10 \x01(\bR\aisQuote:\x9c\x01\x92A\x98\x012\x95\x01{\"order_id\": \"order-1\", \"side\": \"BUY\", \"quantity\": \"1.5\", \"price\": \"100.25\", \"order_type\": \"LIMIT\", \"time_in_force\": \"GTC\", \"user_address\": \"0x1234\"}\"m\n" +
+	"\x18BatchCreateOrdersRequest\x129\n" +
+	"\x06orders\x18\x01 \x03(\v2!.matchingo.api.CreateOrderRequestR\x06orders\x12\x16\n" +
+	"\x06atomic\x18\x02 \x01(\bR\x06atomic\"\x89\x01\n" +
+	"\x19BatchCreateOrdersResponse\x124\n" +
+	"\x06orders\x18\x01 \x03(\v2\x1c.matchingo.api.OrderResponseR\x06orders\x126\n" +
+	"\x06errors\x18\x02 \x03(\v2\x1e.matchingo.api.BatchOrderErrorR\x06errors\"p\n" +
+	"\x0fBatchOrderError\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"\xcd\x03\n" +
+	"\x14SimulateOrderRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\x12,\n" +
+	"\x04side\x18\x03 \x01(\x0e2\x18.matchingo.api.OrderSideR\x04side\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\tR\bquantity\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\tR\x05price\x127\n" +
+	"\n" +
+	"order_type\x18\x06 \x01(\x0e2\x18.matchingo.api.OrderTypeR\torderType\x12>\n" +
+	"\rtime_in_force\x18\a \x01(\x0e2\x1a.matchingo.api.TimeInForceR\vtimeInForce\x12\x1d\n" +
+	"\n" +
+	"stop_price\x18\b \x01(\tR\tstopPrice\x12\x15\n" +
+	"\x06oco_id\x18\t \x01(\tR\x05ocoId\x12!\n" +
+	"\fuser_address\x18\n" +
+	" \x01(\tR\vuserAddress\x12@\n" +
+	"\x0egtd_expires_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\fgtdExpiresAt\"]\n" +
+	"\x0eSimulatedMatch\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\tR\bquantity\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\tR\x05price\"\xf1\x01\n" +
+	"\x15SimulateOrderResponse\x12,\n" +
+	"\x12estimated_fill_qty\x18\x01 \x01(\tR\x10estimatedFillQty\x12.\n" +
+	"\x13estimated_avg_price\x18\x02 \x01(\tR\x11estimatedAvgPrice\x124\n" +
+	"\x16estimated_slippage_bps\x18\x03 \x01(\tR\x14estimatedSlippageBps\x12D\n" +
+	"\x0ematched_orders\x18\x04 \x03(\v2\x1d.matchingo.api.SimulatedMatchR\rmatchedOrders\"\x93\x03\n" +
+	"\x11RouteOrderRequest\x12(\n" +
+	"\x10order_book_names\x18\x01 \x03(\tR\x0eorderBookNames\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\x12,\n" +
+	"\x04side\x18\x03 \x01(\x0e2\x18.matchingo.api.OrderSideR\x04side\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\tR\bquantity\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\tR\x05price\x127\n" +
+	"\n" +
+	"order_type\x18\x06 \x01(\x0e2\x18.matchingo.api.OrderTypeR\torderType\x12>\n" +
+	"\rtime_in_force\x18\a \x01(\x0e2\x1a.matchingo.api.TimeInForceR\vtimeInForce\x12!\n" +
+	"\fuser_address\x18\b \x01(\tR\vuserAddress\x12=\n" +
+	"\bstrategy\x18\t \x01(\x0e2!.matchingo.api.AllocationStrategyR\bstrategy\"\xe0\x01\n" +
+	"\vRoutedOrder\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\tR\bquantity\x12+\n" +
+	"\x11executed_quantity\x18\x04 \x01(\tR\x10executedQuantity\x12-\n" +
+	"\x12remaining_quantity\x18\x05 \x01(\tR\x11remainingQuantity\x12\x16\n" +
+	"\x06stored\x18\x06 \x01(\bR\x06stored\"\x8b\x01\n" +
+	"\x12RouteOrderResponse\x122\n" +
+	"\x06orders\x18\x01 \x03(\v2\x1a.matchingo.api.RoutedOrderR\x06orders\x12+\n" +
+	"\x11executed_quantity\x18\x02 \x01(\tR\x10executedQuantity\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xe3\a\n" +
 	"\rOrderResponse\x12\x19\n" +
 	"\border_id\x18\x01 \x01(\tR\aorderId\x12&\n" +
 	"\x0forder_book_name\x18\x02 \x01(\tR\rorderBookName\x12,\n" +
@@ -1525,39 +5177,130 @@ const file_pkg_api_proto_orderbook_proto_rawDesc = "" +
 	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12)\n" +
 	"\x05fills\x18\x0e \x03(\v2\x13.matchingo.api.FillR\x05fills\x12\x15\n" +
 	"\x06oco_id\x18\x0f \x01(\tR\x05ocoId\x12!\n" +
-	"\fuser_address\x18\x10 \x01(\tR\vuserAddress\"r\n" +
+	"\fuser_address\x18\x10 \x01(\tR\vuserAddress\x12;\n" +
+	"\vorder_state\x18\x11 \x01(\x0e2\x1a.matchingo.api.OrderStatusR\n" +
+	"orderState\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x12 \x01(\tR\trequestId\x12<\n" +
+	"\n" +
+	"error_code\x18\x13 \x01(\x0e2\x1d.matchingo.api.OrderErrorCodeR\terrorCode\x12&\n" +
+	"\x0fclient_order_id\x18\x14 \x01(\tR\rclientOrderId\x12,\n" +
+	"\x12processing_time_ms\x18\x15 \x01(\x01R\x10processingTimeMs\x12@\n" +
+	"\x0egtd_expires_at\x18\x16 \x01(\v2\x1a.google.protobuf.TimestampR\fgtdExpiresAt\"r\n" +
 	"\x04Fill\x12\x14\n" +
 	"\x05price\x18\x01 \x01(\tR\x05price\x12\x1a\n" +
 	"\bquantity\x18\x02 \x01(\tR\bquantity\x128\n" +
 	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"T\n" +
 	"\x0fGetOrderRequest\x12&\n" +
 	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x19\n" +
-	"\border_id\x18\x02 \x01(\tR\aorderId\"W\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\"\\\n" +
+	"\x15BatchGetOrdersRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x1b\n" +
+	"\torder_ids\x18\x02 \x03(\tR\borderIds\"l\n" +
+	"\x16BatchGetOrdersResponse\x124\n" +
+	"\x06orders\x18\x01 \x03(\v2\x1c.matchingo.api.OrderResponseR\x06orders\x12\x1c\n" +
+	"\ttruncated\x18\x02 \x01(\bR\ttruncated\"\xa9\x01\n" +
+	"\x15ListStopOrdersRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x121\n" +
+	"\x04side\x18\x02 \x01(\x0e2\x18.matchingo.api.OrderSideH\x00R\x04side\x88\x01\x01\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offsetB\a\n" +
+	"\x05_side\"\x94\x02\n" +
+	"\tStopOrder\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12,\n" +
+	"\x04side\x18\x02 \x01(\x0e2\x18.matchingo.api.OrderSideR\x04side\x12#\n" +
+	"\rtrigger_price\x18\x03 \x01(\tR\ftriggerPrice\x12\x1f\n" +
+	"\vlimit_price\x18\x04 \x01(\tR\n" +
+	"limitPrice\x12\x1a\n" +
+	"\bquantity\x18\x05 \x01(\tR\bquantity\x12!\n" +
+	"\fuser_address\x18\x06 \x01(\tR\vuserAddress\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x87\x01\n" +
+	"\x16ListStopOrdersResponse\x129\n" +
+	"\vstop_orders\x18\x01 \x03(\v2\x18.matchingo.api.StopOrderR\n" +
+	"stopOrders\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x1c\n" +
+	"\ttruncated\x18\x03 \x01(\bR\ttruncated\"W\n" +
 	"\x12CancelOrderRequest\x12&\n" +
 	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x19\n" +
-	"\border_id\x18\x02 \x01(\tR\aorderId\"D\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\"\x88\x01\n" +
+	"\x11AmendOrderRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\tR\x05price\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\tR\bquantity\"e\n" +
 	"\x18GetOrderBookStateRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
-	"\x05depth\x18\x02 \x01(\x05R\x05depth\"\xc4\x01\n" +
+	"\x05depth\x18\x02 \x01(\x05R\x05depth\x12\x1f\n" +
+	"\vbucket_size\x18\x03 \x01(\tR\n" +
+	"bucketSize\"\xe2\x01\n" +
 	"\x16OrderBookStateResponse\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12-\n" +
 	"\x04bids\x18\x02 \x03(\v2\x19.matchingo.api.PriceLevelR\x04bids\x12-\n" +
 	"\x04asks\x18\x03 \x03(\v2\x19.matchingo.api.PriceLevelR\x04asks\x128\n" +
-	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\x8d\x01\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1c\n" +
+	"\ttruncated\x18\x05 \x01(\bR\ttruncated\"\x84\x01\n" +
+	"\x16GetDepthAtPriceRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12,\n" +
+	"\x04side\x18\x02 \x01(\x0e2\x18.matchingo.api.OrderSideR\x04side\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\tR\x05price\"\x9b\x01\n" +
+	"\x14DepthAtPriceResponse\x12\x14\n" +
+	"\x05price\x18\x01 \x01(\tR\x05price\x12%\n" +
+	"\x0etotal_quantity\x18\x02 \x01(\tR\rtotalQuantity\x12\x1f\n" +
+	"\vorder_count\x18\x03 \x01(\x05R\n" +
+	"orderCount\x12%\n" +
+	"\x0euser_addresses\x18\x04 \x03(\tR\ruserAddresses\"]\n" +
+	"\x16GetBookNotionalRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x1b\n" +
+	"\tshock_pct\x18\x02 \x01(\x01R\bshockPct\"\xca\x01\n" +
+	"\x14BookNotionalResponse\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12!\n" +
+	"\fbid_notional\x18\x02 \x01(\tR\vbidNotional\x12!\n" +
+	"\fask_notional\x18\x03 \x01(\tR\vaskNotional\x12!\n" +
+	"\fbid_drawdown\x18\x04 \x01(\tR\vbidDrawdown\x12!\n" +
+	"\fask_drawdown\x18\x05 \x01(\tR\vaskDrawdown\"7\n" +
+	"\rGetBBORequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\"\xae\x01\n" +
+	"\vBBOResponse\x12\x1b\n" +
+	"\tbid_price\x18\x01 \x01(\tR\bbidPrice\x12\x17\n" +
+	"\abid_qty\x18\x02 \x01(\tR\x06bidQty\x12\x1b\n" +
+	"\task_price\x18\x03 \x01(\tR\baskPrice\x12\x17\n" +
+	"\aask_qty\x18\x04 \x01(\tR\x06askQty\x12\x16\n" +
+	"\x06spread\x18\x05 \x01(\tR\x06spread\x12\x1b\n" +
+	"\tmid_price\x18\x06 \x01(\tR\bmidPrice\"\\\n" +
+	"\vVWAPRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12%\n" +
+	"\x0ewindow_seconds\x18\x02 \x01(\x03R\rwindowSeconds\"\x83\x01\n" +
+	"\fVWAPResponse\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x12\n" +
+	"\x04vwap\x18\x02 \x01(\tR\x04vwap\x12\x16\n" +
+	"\x06volume\x18\x03 \x01(\tR\x06volume\x12\x1f\n" +
+	"\vtrade_count\x18\x04 \x01(\x05R\n" +
+	"tradeCount\"\\\n" +
+	"\vTWAPRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12%\n" +
+	"\x0ewindow_seconds\x18\x02 \x01(\x03R\rwindowSeconds\"k\n" +
+	"\fTWAPResponse\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x12\n" +
+	"\x04twap\x18\x02 \x01(\tR\x04twap\x12\x1f\n" +
+	"\vtrade_count\x18\x03 \x01(\x05R\n" +
+	"tradeCount\"\x8d\x01\n" +
 	"\n" +
 	"PriceLevel\x12\x14\n" +
 	"\x05price\x18\x01 \x01(\tR\x05price\x12%\n" +
 	"\x0etotal_quantity\x18\x02 \x01(\tR\rtotalQuantity\x12\x1f\n" +
 	"\vorder_count\x18\x03 \x01(\x05R\n" +
 	"orderCount\x12!\n" +
-	"\fuser_address\x18\x04 \x01(\tR\vuserAddress\"\xa6\x01\n" +
+	"\fuser_address\x18\x04 \x01(\tR\vuserAddress\"\xe0\x01\n" +
 	"\x05Trade\x12\x19\n" +
 	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x12\n" +
 	"\x04role\x18\x02 \x01(\tR\x04role\x12\x14\n" +
 	"\x05price\x18\x03 \x01(\tR\x05price\x12\x1a\n" +
 	"\bquantity\x18\x04 \x01(\tR\bquantity\x12\x19\n" +
 	"\bis_quote\x18\x05 \x01(\bR\aisQuote\x12!\n" +
-	"\fuser_address\x18\x06 \x01(\tR\vuserAddress\"\xf5\x02\n" +
+	"\fuser_address\x18\x06 \x01(\tR\vuserAddress\x12\x1b\n" +
+	"\tmaker_fee\x18\a \x01(\tR\bmakerFee\x12\x1b\n" +
+	"\ttaker_fee\x18\b \x01(\tR\btakerFee\"\x95\b\n" +
 	"\vDoneMessage\x12\x19\n" +
 	"\border_id\x18\x01 \x01(\tR\aorderId\x12+\n" +
 	"\x11executed_quantity\x18\x02 \x01(\tR\x10executedQuantity\x12-\n" +
@@ -1570,25 +5313,101 @@ const file_pkg_api_proto_orderbook_proto_rawDesc = "" +
 	"\tprocessed\x18\t \x01(\tR\tprocessed\x12\x12\n" +
 	"\x04left\x18\n" +
 	" \x01(\tR\x04left\x12!\n" +
-	"\fuser_address\x18\v \x01(\tR\vuserAddress*$\n" +
+	"\fuser_address\x18\v \x01(\tR\vuserAddress\x124\n" +
+	"\x06cancel\x18\f \x01(\v2\x1c.matchingo.api.CancelMessageR\x06cancel\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\r \x01(\tR\trequestId\x12'\n" +
+	"\x0fsequence_number\x18\x0e \x01(\x04R\x0esequenceNumber\x12*\n" +
+	"\x11match_duration_ms\x18\x0f \x01(\x01R\x0fmatchDurationMs\x12%\n" +
+	"\x0estop_activated\x18\x10 \x01(\bR\rstopActivated\x12\x19\n" +
+	"\bpeg_type\x18\x11 \x01(\tR\apegType\x12'\n" +
+	"\x0feffective_price\x18\x12 \x01(\tR\x0eeffectivePrice\x12(\n" +
+	"\x10cancelled_by_oco\x18\x13 \x01(\bR\x0ecancelledByOco\x12#\n" +
+	"\rstp_triggered\x18\x14 \x01(\bR\fstpTriggered\x12\x1f\n" +
+	"\vhalt_reason\x18\x15 \x01(\tR\n" +
+	"haltReason\x12&\n" +
+	"\x0forder_book_name\x18\x16 \x01(\tR\rorderBookName\x12\x12\n" +
+	"\x04side\x18\x17 \x01(\tR\x04side\x12\x14\n" +
+	"\x05price\x18\x18 \x01(\tR\x05price\x12\x1d\n" +
+	"\n" +
+	"order_type\x18\x19 \x01(\tR\torderType\x12\"\n" +
+	"\rtime_in_force\x18\x1a \x01(\tR\vtimeInForce\x129\n" +
+	"\n" +
+	"expires_at\x18\x1b \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x18\n" +
+	"\aamended\x18\x1c \x01(\bR\aamended\x12\x18\n" +
+	"\aiceberg\x18\x1d \x01(\bR\aiceberg\x12\x15\n" +
+	"\x06oco_id\x18\x1e \x01(\tR\x05ocoId\"\xfb\x01\n" +
+	"\rCancelMessage\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12;\n" +
+	"\vcanceled_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"canceledAt\x12@\n" +
+	"\rcancel_reason\x18\x03 \x01(\x0e2\x1b.matchingo.api.CancelReasonR\fcancelReason\x12-\n" +
+	"\x12remaining_quantity\x18\x04 \x01(\tR\x11remainingQuantity\x12!\n" +
+	"\fuser_address\x18\x05 \x01(\tR\vuserAddress\"\x83\x01\n" +
+	"\x15WatchOrderBookRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12B\n" +
+	"\vevent_types\x18\x02 \x03(\x0e2!.matchingo.api.OrderBookEventTypeR\n" +
+	"eventTypes\"\xca\x02\n" +
+	"\x0eOrderBookEvent\x125\n" +
+	"\x04type\x18\x01 \x01(\x0e2!.matchingo.api.OrderBookEventTypeR\x04type\x12&\n" +
+	"\x0forder_book_name\x18\x02 \x01(\tR\rorderBookName\x12\x19\n" +
+	"\border_id\x18\x03 \x01(\tR\aorderId\x12,\n" +
+	"\x04side\x18\x04 \x01(\x0e2\x18.matchingo.api.OrderSideR\x04side\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\tR\x05price\x12\x1a\n" +
+	"\bquantity\x18\x06 \x01(\tR\bquantity\x12$\n" +
+	"\x0emaker_order_id\x18\a \x01(\tR\fmakerOrderId\x128\n" +
+	"\ttimestamp\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"@\n" +
+	"\x16SubscribeTradesRequest\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\"\x9a\x02\n" +
+	"\n" +
+	"TradeEvent\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12$\n" +
+	"\x0emaker_order_id\x18\x02 \x01(\tR\fmakerOrderId\x12$\n" +
+	"\x0etaker_order_id\x18\x03 \x01(\tR\ftakerOrderId\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\tR\x05price\x12\x1a\n" +
+	"\bquantity\x18\x05 \x01(\tR\bquantity\x12,\n" +
+	"\x04side\x18\x06 \x01(\x0e2\x18.matchingo.api.OrderSideR\x04side\x128\n" +
+	"\ttimestamp\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"8\n" +
+	"\x13GetPositionsRequest\x12!\n" +
+	"\fuser_address\x18\x01 \x01(\tR\vuserAddress\"J\n" +
+	"\x14GetPositionsResponse\x122\n" +
+	"\x05users\x18\x01 \x03(\v2\x1c.matchingo.api.UserPositionsR\x05users\"{\n" +
+	"\rUserPositions\x12!\n" +
+	"\fuser_address\x18\x01 \x01(\tR\vuserAddress\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\tR\x05total\x121\n" +
+	"\x05books\x18\x03 \x03(\v2\x1b.matchingo.api.BookPositionR\x05books\"H\n" +
+	"\fBookPosition\x12&\n" +
+	"\x0forder_book_name\x18\x01 \x01(\tR\rorderBookName\x12\x10\n" +
+	"\x03net\x18\x02 \x01(\tR\x03net*$\n" +
 	"\vBackendType\x12\n" +
 	"\n" +
 	"\x06MEMORY\x10\x00\x12\t\n" +
-	"\x05REDIS\x10\x01*<\n" +
+	"\x05REDIS\x10\x01*X\n" +
+	"\aSTPMode\x12\f\n" +
+	"\bSTP_NONE\x10\x00\x12\x14\n" +
+	"\x10STP_CANCEL_MAKER\x10\x01\x12\x14\n" +
+	"\x10STP_CANCEL_TAKER\x10\x02\x12\x13\n" +
+	"\x0fSTP_CANCEL_BOTH\x10\x03*W\n" +
 	"\tOrderType\x12\t\n" +
 	"\x05LIMIT\x10\x00\x12\n" +
 	"\n" +
 	"\x06MARKET\x10\x01\x12\b\n" +
 	"\x04STOP\x10\x02\x12\x0e\n" +
 	"\n" +
-	"STOP_LIMIT\x10\x03*\x1e\n" +
+	"STOP_LIMIT\x10\x03\x12\f\n" +
+	"\bMIDPOINT\x10\x04\x12\v\n" +
+	"\aICEBERG\x10\x05*\x1e\n" +
 	"\tOrderSide\x12\a\n" +
 	"\x03BUY\x10\x00\x12\b\n" +
-	"\x04SELL\x10\x01*(\n" +
+	"\x04SELL\x10\x01*1\n" +
 	"\vTimeInForce\x12\a\n" +
 	"\x03GTC\x10\x00\x12\a\n" +
 	"\x03IOC\x10\x01\x12\a\n" +
-	"\x03FOK\x10\x02*b\n" +
+	"\x03FOK\x10\x02\x12\a\n" +
+	"\x03GTD\x10\x03*B\n" +
+	"\x12AllocationStrategy\x12\x14\n" +
+	"\x10BEST_PRICE_FIRST\x10\x00\x12\x16\n" +
+	"\x12PROPORTIONAL_SPLIT\x10\x01*o\n" +
 	"\vOrderStatus\x12\v\n" +
 	"\aPENDING\x10\x00\x12\b\n" +
 	"\x04OPEN\x10\x01\x12\n" +
@@ -1596,16 +5415,52 @@ const file_pkg_api_proto_orderbook_proto_rawDesc = "" +
 	"\x06FILLED\x10\x02\x12\x14\n" +
 	"\x10PARTIALLY_FILLED\x10\x03\x12\f\n" +
 	"\bCANCELED\x10\x04\x12\f\n" +
-	"\bREJECTED\x10\x052\xbe\x05\n" +
-	"\x10OrderBookService\x12Z\n" +
-	"\x0fCreateOrderBook\x12%.matchingo.api.CreateOrderBookRequest\x1a .matchingo.api.OrderBookResponse\x12T\n" +
-	"\fGetOrderBook\x12\".matchingo.api.GetOrderBookRequest\x1a .matchingo.api.OrderBookResponse\x12]\n" +
-	"\x0eListOrderBooks\x12$.matchingo.api.ListOrderBooksRequest\x1a%.matchingo.api.ListOrderBooksResponse\x12P\n" +
-	"\x0fDeleteOrderBook\x12%.matchingo.api.DeleteOrderBookRequest\x1a\x16.google.protobuf.Empty\x12N\n" +
-	"\vCreateOrder\x12!.matchingo.api.CreateOrderRequest\x1a\x1c.matchingo.api.OrderResponse\x12H\n" +
-	"\bGetOrder\x12\x1e.matchingo.api.GetOrderRequest\x1a\x1c.matchingo.api.OrderResponse\x12H\n" +
-	"\vCancelOrder\x12!.matchingo.api.CancelOrderRequest\x1a\x16.google.protobuf.Empty\x12c\n" +
-	"\x11GetOrderBookState\x12'.matchingo.api.GetOrderBookStateRequest\x1a%.matchingo.api.OrderBookStateResponseB+Z)github.com/erain9/matchingo/pkg/api/protob\x06proto3"
+	"\bREJECTED\x10\x05\x12\v\n" +
+	"\aUNKNOWN\x10\x06*-\n" +
+	"\x0eOrderErrorCode\x12\f\n" +
+	"\bNO_ERROR\x10\x00\x12\r\n" +
+	"\tNOT_FOUND\x10\x01*_\n" +
+	"\fCancelReason\x12\x12\n" +
+	"\x0eUSER_REQUESTED\x10\x00\x12\x11\n" +
+	"\rOCO_TRIGGERED\x10\x01\x12\x12\n" +
+	"\x0eSTOP_ACTIVATED\x10\x02\x12\v\n" +
+	"\aEXPIRED\x10\x03\x12\a\n" +
+	"\x03STP\x10\x04*?\n" +
+	"\x12OrderBookEventType\x12\t\n" +
+	"\x05TRADE\x10\x00\x12\a\n" +
+	"\x03ADD\x10\x01\x12\n" +
+	"\n" +
+	"\x06CANCEL\x10\x02\x12\t\n" +
+	"\x05AMEND\x10\x032\xd0\x18\n" +
+	"\x10OrderBookService\x12p\n" +
+	"\x0fCreateOrderBook\x12%.matchingo.api.CreateOrderBookRequest\x1a .matchingo.api.OrderBookResponse\"\x14\x82\xd3\xe4\x93\x02\x0e:\x01*\"\t/v1/books\x12n\n" +
+	"\fGetOrderBook\x12\".matchingo.api.GetOrderBookRequest\x1a .matchingo.api.OrderBookResponse\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/v1/books/{name}\x12p\n" +
+	"\x0eListOrderBooks\x12$.matchingo.api.ListOrderBooksRequest\x1a%.matchingo.api.ListOrderBooksResponse\"\x11\x82\xd3\xe4\x93\x02\v\x12\t/v1/books\x12j\n" +
+	"\x0fDeleteOrderBook\x12%.matchingo.api.DeleteOrderBookRequest\x1a\x16.google.protobuf.Empty\"\x18\x82\xd3\xe4\x93\x02\x12*\x10/v1/books/{name}\x12z\n" +
+	"\x11UndeleteOrderBook\x12\x1e.matchingo.api.UndeleteRequest\x1a\x1f.matchingo.api.UndeleteResponse\"$\x82\xd3\xe4\x93\x02\x1e:\x01*\"\x19/v1/books/{name}:undelete\x12\x80\x01\n" +
+	"\x0eResetOrderBook\x12$.matchingo.api.ResetOrderBookRequest\x1a%.matchingo.api.ResetOrderBookResponse\"!\x82\xd3\xe4\x93\x02\x1b:\x01*\"\x16/v1/books/{name}:reset\x12}\n" +
+	"\vCreateOrder\x12!.matchingo.api.CreateOrderRequest\x1a\x1c.matchingo.api.OrderResponse\"-\x82\xd3\xe4\x93\x02':\x01*\"\"/v1/books/{order_book_name}/orders\x12\x89\x01\n" +
+	"\x11BatchCreateOrders\x12'.matchingo.api.BatchCreateOrdersRequest\x1a(.matchingo.api.BatchCreateOrdersResponse\"!\x82\xd3\xe4\x93\x02\x1b:\x01*\"\x16/v1/orders:batchCreate\x12\x92\x01\n" +
+	"\rSimulateOrder\x12#.matchingo.api.SimulateOrderRequest\x1a$.matchingo.api.SimulateOrderResponse\"6\x82\xd3\xe4\x93\x020:\x01*\"+/v1/books/{order_book_name}/orders:simulate\x12n\n" +
+	"\n" +
+	"RouteOrder\x12 .matchingo.api.RouteOrderRequest\x1a!.matchingo.api.RouteOrderResponse\"\x1b\x82\xd3\xe4\x93\x02\x15:\x01*\"\x10/v1/orders:route\x12\x7f\n" +
+	"\bGetOrder\x12\x1e.matchingo.api.GetOrderRequest\x1a\x1c.matchingo.api.OrderResponse\"5\x82\xd3\xe4\x93\x02/\x12-/v1/books/{order_book_name}/orders/{order_id}\x12\x92\x01\n" +
+	"\x0eBatchGetOrders\x12$.matchingo.api.BatchGetOrdersRequest\x1a%.matchingo.api.BatchGetOrdersResponse\"3\x82\xd3\xe4\x93\x02-\x12+/v1/books/{order_book_name}/orders:batchGet\x12\x8e\x01\n" +
+	"\x0eListStopOrders\x12$.matchingo.api.ListStopOrdersRequest\x1a%.matchingo.api.ListStopOrdersResponse\"/\x82\xd3\xe4\x93\x02)\x12'/v1/books/{order_book_name}/stop-orders\x12\x7f\n" +
+	"\vCancelOrder\x12!.matchingo.api.CancelOrderRequest\x1a\x16.google.protobuf.Empty\"5\x82\xd3\xe4\x93\x02/*-/v1/books/{order_book_name}/orders/{order_id}\x12\x86\x01\n" +
+	"\n" +
+	"AmendOrder\x12 .matchingo.api.AmendOrderRequest\x1a\x1c.matchingo.api.OrderResponse\"8\x82\xd3\xe4\x93\x022:\x01*2-/v1/books/{order_book_name}/orders/{order_id}\x12\x83\x01\n" +
+	"\x11GetOrderBookState\x12'.matchingo.api.GetOrderBookStateRequest\x1a%.matchingo.api.OrderBookStateResponse\"\x1e\x82\xd3\xe4\x93\x02\x18\x12\x16/v1/books/{name}/state\x12\x88\x01\n" +
+	"\x0fGetDepthAtPrice\x12%.matchingo.api.GetDepthAtPriceRequest\x1a#.matchingo.api.DepthAtPriceResponse\")\x82\xd3\xe4\x93\x02#\x12!/v1/books/{order_book_name}/depth\x12\x8b\x01\n" +
+	"\x0fGetBookNotional\x12%.matchingo.api.GetBookNotionalRequest\x1a#.matchingo.api.BookNotionalResponse\",\x82\xd3\xe4\x93\x02&\x12$/v1/books/{order_book_name}/notional\x12k\n" +
+	"\x06GetBBO\x12\x1c.matchingo.api.GetBBORequest\x1a\x1a.matchingo.api.BBOResponse\"'\x82\xd3\xe4\x93\x02!\x12\x1f/v1/books/{order_book_name}/bbo\x12r\n" +
+	"\rCalculateVWAP\x12\x1a.matchingo.api.VWAPRequest\x1a\x1b.matchingo.api.VWAPResponse\"(\x82\xd3\xe4\x93\x02\"\x12 /v1/books/{order_book_name}/vwap\x12r\n" +
+	"\rCalculateTWAP\x12\x1a.matchingo.api.TWAPRequest\x1a\x1b.matchingo.api.TWAPResponse\"(\x82\xd3\xe4\x93\x02\"\x12 /v1/books/{order_book_name}/twap\x12n\n" +
+	"\fGetPositions\x12\".matchingo.api.GetPositionsRequest\x1a#.matchingo.api.GetPositionsResponse\"\x15\x82\xd3\xe4\x93\x02\x0f\x12\r/v1/positions\x12N\n" +
+	"\x0fWarmUpOrderBook\x12\x1c.matchingo.api.WarmUpRequest\x1a\x1d.matchingo.api.WarmUpResponse\x12\x83\x01\n" +
+	"\x0eWatchOrderBook\x12$.matchingo.api.WatchOrderBookRequest\x1a\x1d.matchingo.api.OrderBookEvent\"*\x82\xd3\xe4\x93\x02$\x12\"/v1/books/{order_book_name}/events0\x01\x12\x81\x01\n" +
+	"\x0fSubscribeTrades\x12%.matchingo.api.SubscribeTradesRequest\x1a\x19.matchingo.api.TradeEvent\"*\x82\xd3\xe4\x93\x02$\x12\"/v1/books/{order_book_name}/trades0\x01B\xc0\x01\x92A\x91\x01\x12h\n" +
+	"\x18Matchingo Order Book API\x12GREST/JSON gateway to the OrderBookService. Decimal amounts are strings.2\x031.0*\x01\x012\x10application/json:\x10application/jsonZ)github.com/erain9/matchingo/pkg/api/protob\x06proto3"
 
 var (
 	file_pkg_api_proto_orderbook_proto_rawDescOnce sync.Once
@@ -1619,76 +5474,205 @@ func file_pkg_api_proto_orderbook_proto_rawDescGZIP() []byte {
 	return file_pkg_api_proto_orderbook_proto_rawDescData
 }
 
-var file_pkg_api_proto_orderbook_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
-var file_pkg_api_proto_orderbook_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_pkg_api_proto_orderbook_proto_enumTypes = make([]protoimpl.EnumInfo, 10)
+var file_pkg_api_proto_orderbook_proto_msgTypes = make([]protoimpl.MessageInfo, 60)
 var file_pkg_api_proto_orderbook_proto_goTypes = []any{
-	(BackendType)(0),                 // 0: matchingo.api.BackendType
-	(OrderType)(0),                   // 1: matchingo.api.OrderType
-	(OrderSide)(0),                   // 2: matchingo.api.OrderSide
-	(TimeInForce)(0),                 // 3: matchingo.api.TimeInForce
-	(OrderStatus)(0),                 // 4: matchingo.api.OrderStatus
-	(*CreateOrderBookRequest)(nil),   // 5: matchingo.api.CreateOrderBookRequest
-	(*OrderBookResponse)(nil),        // 6: matchingo.api.OrderBookResponse
-	(*GetOrderBookRequest)(nil),      // 7: matchingo.api.GetOrderBookRequest
-	(*ListOrderBooksRequest)(nil),    // 8: matchingo.api.ListOrderBooksRequest
-	(*ListOrderBooksResponse)(nil),   // 9: matchingo.api.ListOrderBooksResponse
-	(*DeleteOrderBookRequest)(nil),   // 10: matchingo.api.DeleteOrderBookRequest
-	(*CreateOrderRequest)(nil),       // 11: matchingo.api.CreateOrderRequest
-	(*OrderResponse)(nil),            // 12: matchingo.api.OrderResponse
-	(*Fill)(nil),                     // 13: matchingo.api.Fill
-	(*GetOrderRequest)(nil),          // 14: matchingo.api.GetOrderRequest
-	(*CancelOrderRequest)(nil),       // 15: matchingo.api.CancelOrderRequest
-	(*GetOrderBookStateRequest)(nil), // 16: matchingo.api.GetOrderBookStateRequest
-	(*OrderBookStateResponse)(nil),   // 17: matchingo.api.OrderBookStateResponse
-	(*PriceLevel)(nil),               // 18: matchingo.api.PriceLevel
-	(*Trade)(nil),                    // 19: matchingo.api.Trade
-	(*DoneMessage)(nil),              // 20: matchingo.api.DoneMessage
-	nil,                              // 21: matchingo.api.CreateOrderBookRequest.OptionsEntry
-	(*timestamppb.Timestamp)(nil),    // 22: google.protobuf.Timestamp
-	(*emptypb.Empty)(nil),            // 23: google.protobuf.Empty
+	(BackendType)(0),                              // 0: matchingo.api.BackendType
+	(STPMode)(0),                                  // 1: matchingo.api.STPMode
+	(OrderType)(0),                                // 2: matchingo.api.OrderType
+	(OrderSide)(0),                                // 3: matchingo.api.OrderSide
+	(TimeInForce)(0),                              // 4: matchingo.api.TimeInForce
+	(AllocationStrategy)(0),                       // 5: matchingo.api.AllocationStrategy
+	(OrderStatus)(0),                              // 6: matchingo.api.OrderStatus
+	(OrderErrorCode)(0),                           // 7: matchingo.api.OrderErrorCode
+	(CancelReason)(0),                             // 8: matchingo.api.CancelReason
+	(OrderBookEventType)(0),                       // 9: matchingo.api.OrderBookEventType
+	(*CreateOrderBookRequest)(nil),                // 10: matchingo.api.CreateOrderBookRequest
+	(*OrderBookResponse)(nil),                     // 11: matchingo.api.OrderBookResponse
+	(*GetOrderBookRequest)(nil),                   // 12: matchingo.api.GetOrderBookRequest
+	(*ListOrderBooksRequest)(nil),                 // 13: matchingo.api.ListOrderBooksRequest
+	(*ListOrderBooksResponse)(nil),                // 14: matchingo.api.ListOrderBooksResponse
+	(*DeleteOrderBookRequest)(nil),                // 15: matchingo.api.DeleteOrderBookRequest
+	(*UndeleteRequest)(nil),                       // 16: matchingo.api.UndeleteRequest
+	(*UndeleteResponse)(nil),                      // 17: matchingo.api.UndeleteResponse
+	(*ResetOrderBookRequest)(nil),                 // 18: matchingo.api.ResetOrderBookRequest
+	(*ResetOrderBookResponse)(nil),                // 19: matchingo.api.ResetOrderBookResponse
+	(*WarmUpRequest)(nil),                         // 20: matchingo.api.WarmUpRequest
+	(*WarmUpResponse)(nil),                        // 21: matchingo.api.WarmUpResponse
+	(*CreateOrderRequest)(nil),                    // 22: matchingo.api.CreateOrderRequest
+	(*BatchCreateOrdersRequest)(nil),              // 23: matchingo.api.BatchCreateOrdersRequest
+	(*BatchCreateOrdersResponse)(nil),             // 24: matchingo.api.BatchCreateOrdersResponse
+	(*BatchOrderError)(nil),                       // 25: matchingo.api.BatchOrderError
+	(*SimulateOrderRequest)(nil),                  // 26: matchingo.api.SimulateOrderRequest
+	(*SimulatedMatch)(nil),                        // 27: matchingo.api.SimulatedMatch
+	(*SimulateOrderResponse)(nil),                 // 28: matchingo.api.SimulateOrderResponse
+	(*RouteOrderRequest)(nil),                     // 29: matchingo.api.RouteOrderRequest
+	(*RoutedOrder)(nil),                           // 30: matchingo.api.RoutedOrder
+	(*RouteOrderResponse)(nil),                    // 31: matchingo.api.RouteOrderResponse
+	(*OrderResponse)(nil),                         // 32: matchingo.api.OrderResponse
+	(*Fill)(nil),                                  // 33: matchingo.api.Fill
+	(*GetOrderRequest)(nil),                       // 34: matchingo.api.GetOrderRequest
+	(*BatchGetOrdersRequest)(nil),                 // 35: matchingo.api.BatchGetOrdersRequest
+	(*BatchGetOrdersResponse)(nil),                // 36: matchingo.api.BatchGetOrdersResponse
+	(*ListStopOrdersRequest)(nil),                 // 37: matchingo.api.ListStopOrdersRequest
+	(*StopOrder)(nil),                             // 38: matchingo.api.StopOrder
+	(*ListStopOrdersResponse)(nil),                // 39: matchingo.api.ListStopOrdersResponse
+	(*CancelOrderRequest)(nil),                    // 40: matchingo.api.CancelOrderRequest
+	(*AmendOrderRequest)(nil),                     // 41: matchingo.api.AmendOrderRequest
+	(*GetOrderBookStateRequest)(nil),              // 42: matchingo.api.GetOrderBookStateRequest
+	(*OrderBookStateResponse)(nil),                // 43: matchingo.api.OrderBookStateResponse
+	(*GetDepthAtPriceRequest)(nil),                // 44: matchingo.api.GetDepthAtPriceRequest
+	(*DepthAtPriceResponse)(nil),                  // 45: matchingo.api.DepthAtPriceResponse
+	(*GetBookNotionalRequest)(nil),                // 46: matchingo.api.GetBookNotionalRequest
+	(*BookNotionalResponse)(nil),                  // 47: matchingo.api.BookNotionalResponse
+	(*GetBBORequest)(nil),                         // 48: matchingo.api.GetBBORequest
+	(*BBOResponse)(nil),                           // 49: matchingo.api.BBOResponse
+	(*VWAPRequest)(nil),                           // 50: matchingo.api.VWAPRequest
+	(*VWAPResponse)(nil),                          // 51: matchingo.api.VWAPResponse
+	(*TWAPRequest)(nil),                           // 52: matchingo.api.TWAPRequest
+	(*TWAPResponse)(nil),                          // 53: matchingo.api.TWAPResponse
+	(*PriceLevel)(nil),                            // 54: matchingo.api.PriceLevel
+	(*Trade)(nil),                                 // 55: matchingo.api.Trade
+	(*DoneMessage)(nil),                           // 56: matchingo.api.DoneMessage
+	(*CancelMessage)(nil),                         // 57: matchingo.api.CancelMessage
+	(*WatchOrderBookRequest)(nil),                 // 58: matchingo.api.WatchOrderBookRequest
+	(*OrderBookEvent)(nil),                        // 59: matchingo.api.OrderBookEvent
+	(*SubscribeTradesRequest)(nil),                // 60: matchingo.api.SubscribeTradesRequest
+	(*TradeEvent)(nil),                            // 61: matchingo.api.TradeEvent
+	(*GetPositionsRequest)(nil),                   // 62: matchingo.api.GetPositionsRequest
+	(*GetPositionsResponse)(nil),                  // 63: matchingo.api.GetPositionsResponse
+	(*UserPositions)(nil),                         // 64: matchingo.api.UserPositions
+	(*BookPosition)(nil),                          // 65: matchingo.api.BookPosition
+	nil,                                           // 66: matchingo.api.CreateOrderBookRequest.OptionsEntry
+	(*CreateOrderBookRequest_Instrument)(nil),     // 67: matchingo.api.CreateOrderBookRequest.Instrument
+	(*CreateOrderBookRequest_Policy)(nil),         // 68: matchingo.api.CreateOrderBookRequest.Policy
+	(*CreateOrderBookRequest_CircuitBreaker)(nil), // 69: matchingo.api.CreateOrderBookRequest.CircuitBreaker
+	(*timestamppb.Timestamp)(nil),                 // 70: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),                   // 71: google.protobuf.Duration
+	(*emptypb.Empty)(nil),                         // 72: google.protobuf.Empty
 }
 var file_pkg_api_proto_orderbook_proto_depIdxs = []int32{
 	0,  // 0: matchingo.api.CreateOrderBookRequest.backend_type:type_name -> matchingo.api.BackendType
-	21, // 1: matchingo.api.CreateOrderBookRequest.options:type_name -> matchingo.api.CreateOrderBookRequest.OptionsEntry
-	0,  // 2: matchingo.api.OrderBookResponse.backend_type:type_name -> matchingo.api.BackendType
-	22, // 3: matchingo.api.OrderBookResponse.created_at:type_name -> google.protobuf.Timestamp
-	6,  // 4: matchingo.api.ListOrderBooksResponse.order_books:type_name -> matchingo.api.OrderBookResponse
-	2,  // 5: matchingo.api.CreateOrderRequest.side:type_name -> matchingo.api.OrderSide
-	1,  // 6: matchingo.api.CreateOrderRequest.order_type:type_name -> matchingo.api.OrderType
-	3,  // 7: matchingo.api.CreateOrderRequest.time_in_force:type_name -> matchingo.api.TimeInForce
-	2,  // 8: matchingo.api.OrderResponse.side:type_name -> matchingo.api.OrderSide
-	1,  // 9: matchingo.api.OrderResponse.order_type:type_name -> matchingo.api.OrderType
-	3,  // 10: matchingo.api.OrderResponse.time_in_force:type_name -> matchingo.api.TimeInForce
-	4,  // 11: matchingo.api.OrderResponse.status:type_name -> matchingo.api.OrderStatus
-	22, // 12: matchingo.api.OrderResponse.created_at:type_name -> google.protobuf.Timestamp
-	22, // 13: matchingo.api.OrderResponse.updated_at:type_name -> google.protobuf.Timestamp
-	13, // 14: matchingo.api.OrderResponse.fills:type_name -> matchingo.api.Fill
-	22, // 15: matchingo.api.Fill.timestamp:type_name -> google.protobuf.Timestamp
-	18, // 16: matchingo.api.OrderBookStateResponse.bids:type_name -> matchingo.api.PriceLevel
-	18, // 17: matchingo.api.OrderBookStateResponse.asks:type_name -> matchingo.api.PriceLevel
-	22, // 18: matchingo.api.OrderBookStateResponse.timestamp:type_name -> google.protobuf.Timestamp
-	19, // 19: matchingo.api.DoneMessage.trades:type_name -> matchingo.api.Trade
-	5,  // 20: matchingo.api.OrderBookService.CreateOrderBook:input_type -> matchingo.api.CreateOrderBookRequest
-	7,  // 21: matchingo.api.OrderBookService.GetOrderBook:input_type -> matchingo.api.GetOrderBookRequest
-	8,  // 22: matchingo.api.OrderBookService.ListOrderBooks:input_type -> matchingo.api.ListOrderBooksRequest
-	10, // 23: matchingo.api.OrderBookService.DeleteOrderBook:input_type -> matchingo.api.DeleteOrderBookRequest
-	11, // 24: matchingo.api.OrderBookService.CreateOrder:input_type -> matchingo.api.CreateOrderRequest
-	14, // 25: matchingo.api.OrderBookService.GetOrder:input_type -> matchingo.api.GetOrderRequest
-	15, // 26: matchingo.api.OrderBookService.CancelOrder:input_type -> matchingo.api.CancelOrderRequest
-	16, // 27: matchingo.api.OrderBookService.GetOrderBookState:input_type -> matchingo.api.GetOrderBookStateRequest
-	6,  // 28: matchingo.api.OrderBookService.CreateOrderBook:output_type -> matchingo.api.OrderBookResponse
-	6,  // 29: matchingo.api.OrderBookService.GetOrderBook:output_type -> matchingo.api.OrderBookResponse
-	9,  // 30: matchingo.api.OrderBookService.ListOrderBooks:output_type -> matchingo.api.ListOrderBooksResponse
-	23, // 31: matchingo.api.OrderBookService.DeleteOrderBook:output_type -> google.protobuf.Empty
-	12, // 32: matchingo.api.OrderBookService.CreateOrder:output_type -> matchingo.api.OrderResponse
-	12, // 33: matchingo.api.OrderBookService.GetOrder:output_type -> matchingo.api.OrderResponse
-	23, // 34: matchingo.api.OrderBookService.CancelOrder:output_type -> google.protobuf.Empty
-	17, // 35: matchingo.api.OrderBookService.GetOrderBookState:output_type -> matchingo.api.OrderBookStateResponse
-	28, // [28:36] is the sub-list for method output_type
-	20, // [20:28] is the sub-list for method input_type
-	20, // [20:20] is the sub-list for extension type_name
-	20, // [20:20] is the sub-list for extension extendee
-	0,  // [0:20] is the sub-list for field type_name
+	66, // 1: matchingo.api.CreateOrderBookRequest.options:type_name -> matchingo.api.CreateOrderBookRequest.OptionsEntry
+	67, // 2: matchingo.api.CreateOrderBookRequest.instrument:type_name -> matchingo.api.CreateOrderBookRequest.Instrument
+	68, // 3: matchingo.api.CreateOrderBookRequest.policy:type_name -> matchingo.api.CreateOrderBookRequest.Policy
+	1,  // 4: matchingo.api.CreateOrderBookRequest.stp_mode:type_name -> matchingo.api.STPMode
+	69, // 5: matchingo.api.CreateOrderBookRequest.circuit_breaker:type_name -> matchingo.api.CreateOrderBookRequest.CircuitBreaker
+	0,  // 6: matchingo.api.OrderBookResponse.backend_type:type_name -> matchingo.api.BackendType
+	70, // 7: matchingo.api.OrderBookResponse.created_at:type_name -> google.protobuf.Timestamp
+	70, // 8: matchingo.api.OrderBookResponse.deleted_at:type_name -> google.protobuf.Timestamp
+	1,  // 9: matchingo.api.OrderBookResponse.stp_mode:type_name -> matchingo.api.STPMode
+	69, // 10: matchingo.api.OrderBookResponse.circuit_breaker:type_name -> matchingo.api.CreateOrderBookRequest.CircuitBreaker
+	11, // 11: matchingo.api.ListOrderBooksResponse.order_books:type_name -> matchingo.api.OrderBookResponse
+	11, // 12: matchingo.api.UndeleteResponse.order_book:type_name -> matchingo.api.OrderBookResponse
+	11, // 13: matchingo.api.ResetOrderBookResponse.order_book:type_name -> matchingo.api.OrderBookResponse
+	71, // 14: matchingo.api.WarmUpResponse.elapsed:type_name -> google.protobuf.Duration
+	3,  // 15: matchingo.api.CreateOrderRequest.side:type_name -> matchingo.api.OrderSide
+	2,  // 16: matchingo.api.CreateOrderRequest.order_type:type_name -> matchingo.api.OrderType
+	4,  // 17: matchingo.api.CreateOrderRequest.time_in_force:type_name -> matchingo.api.TimeInForce
+	70, // 18: matchingo.api.CreateOrderRequest.gtd_expires_at:type_name -> google.protobuf.Timestamp
+	22, // 19: matchingo.api.BatchCreateOrdersRequest.orders:type_name -> matchingo.api.CreateOrderRequest
+	32, // 20: matchingo.api.BatchCreateOrdersResponse.orders:type_name -> matchingo.api.OrderResponse
+	25, // 21: matchingo.api.BatchCreateOrdersResponse.errors:type_name -> matchingo.api.BatchOrderError
+	3,  // 22: matchingo.api.SimulateOrderRequest.side:type_name -> matchingo.api.OrderSide
+	2,  // 23: matchingo.api.SimulateOrderRequest.order_type:type_name -> matchingo.api.OrderType
+	4,  // 24: matchingo.api.SimulateOrderRequest.time_in_force:type_name -> matchingo.api.TimeInForce
+	70, // 25: matchingo.api.SimulateOrderRequest.gtd_expires_at:type_name -> google.protobuf.Timestamp
+	27, // 26: matchingo.api.SimulateOrderResponse.matched_orders:type_name -> matchingo.api.SimulatedMatch
+	3,  // 27: matchingo.api.RouteOrderRequest.side:type_name -> matchingo.api.OrderSide
+	2,  // 28: matchingo.api.RouteOrderRequest.order_type:type_name -> matchingo.api.OrderType
+	4,  // 29: matchingo.api.RouteOrderRequest.time_in_force:type_name -> matchingo.api.TimeInForce
+	5,  // 30: matchingo.api.RouteOrderRequest.strategy:type_name -> matchingo.api.AllocationStrategy
+	30, // 31: matchingo.api.RouteOrderResponse.orders:type_name -> matchingo.api.RoutedOrder
+	3,  // 32: matchingo.api.OrderResponse.side:type_name -> matchingo.api.OrderSide
+	2,  // 33: matchingo.api.OrderResponse.order_type:type_name -> matchingo.api.OrderType
+	4,  // 34: matchingo.api.OrderResponse.time_in_force:type_name -> matchingo.api.TimeInForce
+	6,  // 35: matchingo.api.OrderResponse.status:type_name -> matchingo.api.OrderStatus
+	70, // 36: matchingo.api.OrderResponse.created_at:type_name -> google.protobuf.Timestamp
+	70, // 37: matchingo.api.OrderResponse.updated_at:type_name -> google.protobuf.Timestamp
+	33, // 38: matchingo.api.OrderResponse.fills:type_name -> matchingo.api.Fill
+	6,  // 39: matchingo.api.OrderResponse.order_state:type_name -> matchingo.api.OrderStatus
+	7,  // 40: matchingo.api.OrderResponse.error_code:type_name -> matchingo.api.OrderErrorCode
+	70, // 41: matchingo.api.OrderResponse.gtd_expires_at:type_name -> google.protobuf.Timestamp
+	70, // 42: matchingo.api.Fill.timestamp:type_name -> google.protobuf.Timestamp
+	32, // 43: matchingo.api.BatchGetOrdersResponse.orders:type_name -> matchingo.api.OrderResponse
+	3,  // 44: matchingo.api.ListStopOrdersRequest.side:type_name -> matchingo.api.OrderSide
+	3,  // 45: matchingo.api.StopOrder.side:type_name -> matchingo.api.OrderSide
+	70, // 46: matchingo.api.StopOrder.created_at:type_name -> google.protobuf.Timestamp
+	38, // 47: matchingo.api.ListStopOrdersResponse.stop_orders:type_name -> matchingo.api.StopOrder
+	54, // 48: matchingo.api.OrderBookStateResponse.bids:type_name -> matchingo.api.PriceLevel
+	54, // 49: matchingo.api.OrderBookStateResponse.asks:type_name -> matchingo.api.PriceLevel
+	70, // 50: matchingo.api.OrderBookStateResponse.timestamp:type_name -> google.protobuf.Timestamp
+	3,  // 51: matchingo.api.GetDepthAtPriceRequest.side:type_name -> matchingo.api.OrderSide
+	55, // 52: matchingo.api.DoneMessage.trades:type_name -> matchingo.api.Trade
+	57, // 53: matchingo.api.DoneMessage.cancel:type_name -> matchingo.api.CancelMessage
+	70, // 54: matchingo.api.DoneMessage.expires_at:type_name -> google.protobuf.Timestamp
+	70, // 55: matchingo.api.CancelMessage.canceled_at:type_name -> google.protobuf.Timestamp
+	8,  // 56: matchingo.api.CancelMessage.cancel_reason:type_name -> matchingo.api.CancelReason
+	9,  // 57: matchingo.api.WatchOrderBookRequest.event_types:type_name -> matchingo.api.OrderBookEventType
+	9,  // 58: matchingo.api.OrderBookEvent.type:type_name -> matchingo.api.OrderBookEventType
+	3,  // 59: matchingo.api.OrderBookEvent.side:type_name -> matchingo.api.OrderSide
+	70, // 60: matchingo.api.OrderBookEvent.timestamp:type_name -> google.protobuf.Timestamp
+	3,  // 61: matchingo.api.TradeEvent.side:type_name -> matchingo.api.OrderSide
+	70, // 62: matchingo.api.TradeEvent.timestamp:type_name -> google.protobuf.Timestamp
+	64, // 63: matchingo.api.GetPositionsResponse.users:type_name -> matchingo.api.UserPositions
+	65, // 64: matchingo.api.UserPositions.books:type_name -> matchingo.api.BookPosition
+	71, // 65: matchingo.api.CreateOrderBookRequest.Policy.max_order_age:type_name -> google.protobuf.Duration
+	10, // 66: matchingo.api.OrderBookService.CreateOrderBook:input_type -> matchingo.api.CreateOrderBookRequest
+	12, // 67: matchingo.api.OrderBookService.GetOrderBook:input_type -> matchingo.api.GetOrderBookRequest
+	13, // 68: matchingo.api.OrderBookService.ListOrderBooks:input_type -> matchingo.api.ListOrderBooksRequest
+	15, // 69: matchingo.api.OrderBookService.DeleteOrderBook:input_type -> matchingo.api.DeleteOrderBookRequest
+	16, // 70: matchingo.api.OrderBookService.UndeleteOrderBook:input_type -> matchingo.api.UndeleteRequest
+	18, // 71: matchingo.api.OrderBookService.ResetOrderBook:input_type -> matchingo.api.ResetOrderBookRequest
+	22, // 72: matchingo.api.OrderBookService.CreateOrder:input_type -> matchingo.api.CreateOrderRequest
+	23, // 73: matchingo.api.OrderBookService.BatchCreateOrders:input_type -> matchingo.api.BatchCreateOrdersRequest
+	26, // 74: matchingo.api.OrderBookService.SimulateOrder:input_type -> matchingo.api.SimulateOrderRequest
+	29, // 75: matchingo.api.OrderBookService.RouteOrder:input_type -> matchingo.api.RouteOrderRequest
+	34, // 76: matchingo.api.OrderBookService.GetOrder:input_type -> matchingo.api.GetOrderRequest
+	35, // 77: matchingo.api.OrderBookService.BatchGetOrders:input_type -> matchingo.api.BatchGetOrdersRequest
+	37, // 78: matchingo.api.OrderBookService.ListStopOrders:input_type -> matchingo.api.ListStopOrdersRequest
+	40, // 79: matchingo.api.OrderBookService.CancelOrder:input_type -> matchingo.api.CancelOrderRequest
+	41, // 80: matchingo.api.OrderBookService.AmendOrder:input_type -> matchingo.api.AmendOrderRequest
+	42, // 81: matchingo.api.OrderBookService.GetOrderBookState:input_type -> matchingo.api.GetOrderBookStateRequest
+	44, // 82: matchingo.api.OrderBookService.GetDepthAtPrice:input_type -> matchingo.api.GetDepthAtPriceRequest
+	46, // 83: matchingo.api.OrderBookService.GetBookNotional:input_type -> matchingo.api.GetBookNotionalRequest
+	48, // 84: matchingo.api.OrderBookService.GetBBO:input_type -> matchingo.api.GetBBORequest
+	50, // 85: matchingo.api.OrderBookService.CalculateVWAP:input_type -> matchingo.api.VWAPRequest
+	52, // 86: matchingo.api.OrderBookService.CalculateTWAP:input_type -> matchingo.api.TWAPRequest
+	62, // 87: matchingo.api.OrderBookService.GetPositions:input_type -> matchingo.api.GetPositionsRequest
+	20, // 88: matchingo.api.OrderBookService.WarmUpOrderBook:input_type -> matchingo.api.WarmUpRequest
+	58, // 89: matchingo.api.OrderBookService.WatchOrderBook:input_type -> matchingo.api.WatchOrderBookRequest
+	60, // 90: matchingo.api.OrderBookService.SubscribeTrades:input_type -> matchingo.api.SubscribeTradesRequest
+	11, // 91: matchingo.api.OrderBookService.CreateOrderBook:output_type -> matchingo.api.OrderBookResponse
+	11, // 92: matchingo.api.OrderBookService.GetOrderBook:output_type -> matchingo.api.OrderBookResponse
+	14, // 93: matchingo.api.OrderBookService.ListOrderBooks:output_type -> matchingo.api.ListOrderBooksResponse
+	72, // 94: matchingo.api.OrderBookService.DeleteOrderBook:output_type -> google.protobuf.Empty
+	17, // 95: matchingo.api.OrderBookService.UndeleteOrderBook:output_type -> matchingo.api.UndeleteResponse
+	19, // 96: matchingo.api.OrderBookService.ResetOrderBook:output_type -> matchingo.api.ResetOrderBookResponse
+	32, // 97: matchingo.api.OrderBookService.CreateOrder:output_type -> matchingo.api.OrderResponse
+	24, // 98: matchingo.api.OrderBookService.BatchCreateOrders:output_type -> matchingo.api.BatchCreateOrdersResponse
+	28, // 99: matchingo.api.OrderBookService.SimulateOrder:output_type -> matchingo.api.SimulateOrderResponse
+	31, // 100: matchingo.api.OrderBookService.RouteOrder:output_type -> matchingo.api.RouteOrderResponse
+	32, // 101: matchingo.api.OrderBookService.GetOrder:output_type -> matchingo.api.OrderResponse
+	36, // 102: matchingo.api.OrderBookService.BatchGetOrders:output_type -> matchingo.api.BatchGetOrdersResponse
+	39, // 103: matchingo.api.OrderBookService.ListStopOrders:output_type -> matchingo.api.ListStopOrdersResponse
+	72, // 104: matchingo.api.OrderBookService.CancelOrder:output_type -> google.protobuf.Empty
+	32, // 105: matchingo.api.OrderBookService.AmendOrder:output_type -> matchingo.api.OrderResponse
+	43, // 106: matchingo.api.OrderBookService.GetOrderBookState:output_type -> matchingo.api.OrderBookStateResponse
+	45, // 107: matchingo.api.OrderBookService.GetDepthAtPrice:output_type -> matchingo.api.DepthAtPriceResponse
+	47, // 108: matchingo.api.OrderBookService.GetBookNotional:output_type -> matchingo.api.BookNotionalResponse
+	49, // 109: matchingo.api.OrderBookService.GetBBO:output_type -> matchingo.api.BBOResponse
+	51, // 110: matchingo.api.OrderBookService.CalculateVWAP:output_type -> matchingo.api.VWAPResponse
+	53, // 111: matchingo.api.OrderBookService.CalculateTWAP:output_type -> matchingo.api.TWAPResponse
+	63, // 112: matchingo.api.OrderBookService.GetPositions:output_type -> matchingo.api.GetPositionsResponse
+	21, // 113: matchingo.api.OrderBookService.WarmUpOrderBook:output_type -> matchingo.api.WarmUpResponse
+	59, // 114: matchingo.api.OrderBookService.WatchOrderBook:output_type -> matchingo.api.OrderBookEvent
+	61, // 115: matchingo.api.OrderBookService.SubscribeTrades:output_type -> matchingo.api.TradeEvent
+	91, // [91:116] is the sub-list for method output_type
+	66, // [66:91] is the sub-list for method input_type
+	66, // [66:66] is the sub-list for extension type_name
+	66, // [66:66] is the sub-list for extension extendee
+	0,  // [0:66] is the sub-list for field type_name
 }
 
 func init() { file_pkg_api_proto_orderbook_proto_init() }
@@ -1696,13 +5680,14 @@ func file_pkg_api_proto_orderbook_proto_init() {
 	if File_pkg_api_proto_orderbook_proto != nil {
 		return
 	}
+	file_pkg_api_proto_orderbook_proto_msgTypes[27].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_proto_orderbook_proto_rawDesc), len(file_pkg_api_proto_orderbook_proto_rawDesc)),
-			NumEnums:      5,
-			NumMessages:   17,
+			NumEnums:      10,
+			NumMessages:   60,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -24,10 +24,27 @@ const (
 	OrderBookService_GetOrderBook_FullMethodName      = "/matchingo.api.OrderBookService/GetOrderBook"
 	OrderBookService_ListOrderBooks_FullMethodName    = "/matchingo.api.OrderBookService/ListOrderBooks"
 	OrderBookService_DeleteOrderBook_FullMethodName   = "/matchingo.api.OrderBookService/DeleteOrderBook"
+	OrderBookService_UndeleteOrderBook_FullMethodName = "/matchingo.api.OrderBookService/UndeleteOrderBook"
+	OrderBookService_ResetOrderBook_FullMethodName    = "/matchingo.api.OrderBookService/ResetOrderBook"
 	OrderBookService_CreateOrder_FullMethodName       = "/matchingo.api.OrderBookService/CreateOrder"
+	OrderBookService_BatchCreateOrders_FullMethodName = "/matchingo.api.OrderBookService/BatchCreateOrders"
+	OrderBookService_SimulateOrder_FullMethodName     = "/matchingo.api.OrderBookService/SimulateOrder"
+	OrderBookService_RouteOrder_FullMethodName        = "/matchingo.api.OrderBookService/RouteOrder"
 	OrderBookService_GetOrder_FullMethodName          = "/matchingo.api.OrderBookService/GetOrder"
+	OrderBookService_BatchGetOrders_FullMethodName    = "/matchingo.api.OrderBookService/BatchGetOrders"
+	OrderBookService_ListStopOrders_FullMethodName    = "/matchingo.api.OrderBookService/ListStopOrders"
 	OrderBookService_CancelOrder_FullMethodName       = "/matchingo.api.OrderBookService/CancelOrder"
+	OrderBookService_AmendOrder_FullMethodName        = "/matchingo.api.OrderBookService/AmendOrder"
 	OrderBookService_GetOrderBookState_FullMethodName = "/matchingo.api.OrderBookService/GetOrderBookState"
+	OrderBookService_GetDepthAtPrice_FullMethodName   = "/matchingo.api.OrderBookService/GetDepthAtPrice"
+	OrderBookService_GetBookNotional_FullMethodName   = "/matchingo.api.OrderBookService/GetBookNotional"
+	OrderBookService_GetBBO_FullMethodName            = "/matchingo.api.OrderBookService/GetBBO"
+	OrderBookService_CalculateVWAP_FullMethodName     = "/matchingo.api.OrderBookService/CalculateVWAP"
+	OrderBookService_CalculateTWAP_FullMethodName     = "/matchingo.api.OrderBookService/CalculateTWAP"
+	OrderBookService_GetPositions_FullMethodName      = "/matchingo.api.OrderBookService/GetPositions"
+	OrderBookService_WarmUpOrderBook_FullMethodName   = "/matchingo.api.OrderBookService/WarmUpOrderBook"
+	OrderBookService_WatchOrderBook_FullMethodName    = "/matchingo.api.OrderBookService/WatchOrderBook"
+	OrderBookService_SubscribeTrades_FullMethodName   = "/matchingo.api.OrderBookService/SubscribeTrades"
 )
 
 // OrderBookServiceClient is the client API for OrderBookService service.
@@ -40,18 +57,53 @@ type OrderBookServiceClient interface {
 	CreateOrderBook(ctx context.Context, in *CreateOrderBookRequest, opts ...grpc.CallOption) (*OrderBookResponse, error)
 	// GetOrderBook retrieves information about an order book
 	GetOrderBook(ctx context.Context, in *GetOrderBookRequest, opts ...grpc.CallOption) (*OrderBookResponse, error)
-	// ListOrderBooks lists all available order books
+	// ListOrderBooks lists all available order books. Over HTTP, the limit,
+	// offset and include_deleted fields are query parameters.
 	ListOrderBooks(ctx context.Context, in *ListOrderBooksRequest, opts ...grpc.CallOption) (*ListOrderBooksResponse, error)
-	// DeleteOrderBook deletes an order book
+	// DeleteOrderBook soft-deletes an order book
 	DeleteOrderBook(ctx context.Context, in *DeleteOrderBookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// UndeleteOrderBook restores a soft-deleted order book within its retention period
+	UndeleteOrderBook(ctx context.Context, in *UndeleteRequest, opts ...grpc.CallOption) (*UndeleteResponse, error)
+	// ResetOrderBook removes all orders from an order book without deleting it
+	ResetOrderBook(ctx context.Context, in *ResetOrderBookRequest, opts ...grpc.CallOption) (*ResetOrderBookResponse, error)
 	// CreateOrder submits a new order to the specified order book
 	CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*OrderResponse, error)
+	// BatchCreateOrders submits up to 500 orders in a single call
+	BatchCreateOrders(ctx context.Context, in *BatchCreateOrdersRequest, opts ...grpc.CallOption) (*BatchCreateOrdersResponse, error)
+	// SimulateOrder estimates the fills of an order against a copy of the book without submitting it
+	SimulateOrder(ctx context.Context, in *SimulateOrderRequest, opts ...grpc.CallOption) (*SimulateOrderResponse, error)
+	// RouteOrder splits an order across several order books by price and liquidity
+	RouteOrder(ctx context.Context, in *RouteOrderRequest, opts ...grpc.CallOption) (*RouteOrderResponse, error)
 	// GetOrder retrieves an order by ID
 	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*OrderResponse, error)
+	// BatchGetOrders retrieves up to 500 orders from one order book in a single call
+	BatchGetOrders(ctx context.Context, in *BatchGetOrdersRequest, opts ...grpc.CallOption) (*BatchGetOrdersResponse, error)
+	// ListStopOrders lists the stop orders of an order book that have not been triggered
+	ListStopOrders(ctx context.Context, in *ListStopOrdersRequest, opts ...grpc.CallOption) (*ListStopOrdersResponse, error)
 	// CancelOrder cancels an existing order
 	CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// AmendOrder changes the price and/or remaining quantity of a resting limit order
+	AmendOrder(ctx context.Context, in *AmendOrderRequest, opts ...grpc.CallOption) (*OrderResponse, error)
 	// GetOrderBookState retrieves the current state of an order book
 	GetOrderBookState(ctx context.Context, in *GetOrderBookStateRequest, opts ...grpc.CallOption) (*OrderBookStateResponse, error)
+	// GetDepthAtPrice retrieves the resting quantity at a single price level
+	GetDepthAtPrice(ctx context.Context, in *GetDepthAtPriceRequest, opts ...grpc.CallOption) (*DepthAtPriceResponse, error)
+	// GetBookNotional retrieves the value of the resting orders on each side of an order book
+	GetBookNotional(ctx context.Context, in *GetBookNotionalRequest, opts ...grpc.CallOption) (*BookNotionalResponse, error)
+	// GetBBO retrieves the best bid and best offer of an order book
+	GetBBO(ctx context.Context, in *GetBBORequest, opts ...grpc.CallOption) (*BBOResponse, error)
+	// CalculateVWAP returns the volume-weighted average price of an order book's recent trades
+	CalculateVWAP(ctx context.Context, in *VWAPRequest, opts ...grpc.CallOption) (*VWAPResponse, error)
+	// CalculateTWAP returns the time-weighted average trade price of an order book over a window
+	CalculateTWAP(ctx context.Context, in *TWAPRequest, opts ...grpc.CallOption) (*TWAPResponse, error)
+	// Returns users' net positions in each order book and across all books
+	GetPositions(ctx context.Context, in *GetPositionsRequest, opts ...grpc.CallOption) (*GetPositionsResponse, error)
+	// WarmUpOrderBook seeds an order book with synthetic resting orders (admin only)
+	WarmUpOrderBook(ctx context.Context, in *WarmUpRequest, opts ...grpc.CallOption) (*WarmUpResponse, error)
+	// WatchOrderBook streams an order book's trade, add and cancel events as they happen
+	WatchOrderBook(ctx context.Context, in *WatchOrderBookRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderBookEvent], error)
+	// SubscribeTrades streams every match made in an order book as it happens
+	SubscribeTrades(ctx context.Context, in *SubscribeTradesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TradeEvent], error)
 }
 
 type orderBookServiceClient struct {
@@ -102,6 +154,26 @@ func (c *orderBookServiceClient) DeleteOrderBook(ctx context.Context, in *Delete
 	return out, nil
 }
 
+func (c *orderBookServiceClient) UndeleteOrderBook(ctx context.Context, in *UndeleteRequest, opts ...grpc.CallOption) (*UndeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UndeleteResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_UndeleteOrderBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) ResetOrderBook(ctx context.Context, in *ResetOrderBookRequest, opts ...grpc.CallOption) (*ResetOrderBookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetOrderBookResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_ResetOrderBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *orderBookServiceClient) CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*OrderResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(OrderResponse)
@@ -112,6 +184,36 @@ func (c *orderBookServiceClient) CreateOrder(ctx context.Context, in *CreateOrde
 	return out, nil
 }
 
+func (c *orderBookServiceClient) BatchCreateOrders(ctx context.Context, in *BatchCreateOrdersRequest, opts ...grpc.CallOption) (*BatchCreateOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCreateOrdersResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_BatchCreateOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) SimulateOrder(ctx context.Context, in *SimulateOrderRequest, opts ...grpc.CallOption) (*SimulateOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SimulateOrderResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_SimulateOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) RouteOrder(ctx context.Context, in *RouteOrderRequest, opts ...grpc.CallOption) (*RouteOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RouteOrderResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_RouteOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *orderBookServiceClient) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*OrderResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(OrderResponse)
@@ -122,6 +224,26 @@ func (c *orderBookServiceClient) GetOrder(ctx context.Context, in *GetOrderReque
 	return out, nil
 }
 
+func (c *orderBookServiceClient) BatchGetOrders(ctx context.Context, in *BatchGetOrdersRequest, opts ...grpc.CallOption) (*BatchGetOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetOrdersResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_BatchGetOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) ListStopOrders(ctx context.Context, in *ListStopOrdersRequest, opts ...grpc.CallOption) (*ListStopOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListStopOrdersResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_ListStopOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *orderBookServiceClient) CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -132,6 +254,16 @@ func (c *orderBookServiceClient) CancelOrder(ctx context.Context, in *CancelOrde
 	return out, nil
 }
 
+func (c *orderBookServiceClient) AmendOrder(ctx context.Context, in *AmendOrderRequest, opts ...grpc.CallOption) (*OrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_AmendOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *orderBookServiceClient) GetOrderBookState(ctx context.Context, in *GetOrderBookStateRequest, opts ...grpc.CallOption) (*OrderBookStateResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(OrderBookStateResponse)
@@ -142,6 +274,114 @@ func (c *orderBookServiceClient) GetOrderBookState(ctx context.Context, in *GetO
 	return out, nil
 }
 
+func (c *orderBookServiceClient) GetDepthAtPrice(ctx context.Context, in *GetDepthAtPriceRequest, opts ...grpc.CallOption) (*DepthAtPriceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DepthAtPriceResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_GetDepthAtPrice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) GetBookNotional(ctx context.Context, in *GetBookNotionalRequest, opts ...grpc.CallOption) (*BookNotionalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BookNotionalResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_GetBookNotional_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) GetBBO(ctx context.Context, in *GetBBORequest, opts ...grpc.CallOption) (*BBOResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BBOResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_GetBBO_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) CalculateVWAP(ctx context.Context, in *VWAPRequest, opts ...grpc.CallOption) (*VWAPResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VWAPResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_CalculateVWAP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) CalculateTWAP(ctx context.Context, in *TWAPRequest, opts ...grpc.CallOption) (*TWAPResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TWAPResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_CalculateTWAP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) GetPositions(ctx context.Context, in *GetPositionsRequest, opts ...grpc.CallOption) (*GetPositionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPositionsResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_GetPositions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) WarmUpOrderBook(ctx context.Context, in *WarmUpRequest, opts ...grpc.CallOption) (*WarmUpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WarmUpResponse)
+	err := c.cc.Invoke(ctx, OrderBookService_WarmUpOrderBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) WatchOrderBook(ctx context.Context, in *WatchOrderBookRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderBookEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderBookService_ServiceDesc.Streams[0], OrderBookService_WatchOrderBook_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchOrderBookRequest, OrderBookEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBookService_WatchOrderBookClient = grpc.ServerStreamingClient[OrderBookEvent]
+
+func (c *orderBookServiceClient) SubscribeTrades(ctx context.Context, in *SubscribeTradesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TradeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderBookService_ServiceDesc.Streams[1], OrderBookService_SubscribeTrades_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeTradesRequest, TradeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBookService_SubscribeTradesClient = grpc.ServerStreamingClient[TradeEvent]
+
 // OrderBookServiceServer is the server API for OrderBookService service.
 // All implementations must embed UnimplementedOrderBookServiceServer
 // for forward compatibility.
@@ -152,18 +392,53 @@ type OrderBookServiceServer interface {
 	CreateOrderBook(context.Context, *CreateOrderBookRequest) (*OrderBookResponse, error)
 	// GetOrderBook retrieves information about an order book
 	GetOrderBook(context.Context, *GetOrderBookRequest) (*OrderBookResponse, error)
-	// ListOrderBooks lists all available order books
+	// ListOrderBooks lists all available order books. Over HTTP, the limit,
+	// offset and include_deleted fields are query parameters.
 	ListOrderBooks(context.Context, *ListOrderBooksRequest) (*ListOrderBooksResponse, error)
-	// DeleteOrderBook deletes an order book
+	// DeleteOrderBook soft-deletes an order book
 	DeleteOrderBook(context.Context, *DeleteOrderBookRequest) (*emptypb.Empty, error)
+	// UndeleteOrderBook restores a soft-deleted order book within its retention period
+	UndeleteOrderBook(context.Context, *UndeleteRequest) (*UndeleteResponse, error)
+	// ResetOrderBook removes all orders from an order book without deleting it
+	ResetOrderBook(context.Context, *ResetOrderBookRequest) (*ResetOrderBookResponse, error)
 	// CreateOrder submits a new order to the specified order book
 	CreateOrder(context.Context, *CreateOrderRequest) (*OrderResponse, error)
+	// BatchCreateOrders submits up to 500 orders in a single call
+	BatchCreateOrders(context.Context, *BatchCreateOrdersRequest) (*BatchCreateOrdersResponse, error)
+	// SimulateOrder estimates the fills of an order against a copy of the book without submitting it
+	SimulateOrder(context.Context, *SimulateOrderRequest) (*SimulateOrderResponse, error)
+	// RouteOrder splits an order across several order books by price and liquidity
+	RouteOrder(context.Context, *RouteOrderRequest) (*RouteOrderResponse, error)
 	// GetOrder retrieves an order by ID
 	GetOrder(context.Context, *GetOrderRequest) (*OrderResponse, error)
+	// BatchGetOrders retrieves up to 500 orders from one order book in a single call
+	BatchGetOrders(context.Context, *BatchGetOrdersRequest) (*BatchGetOrdersResponse, error)
+	// ListStopOrders lists the stop orders of an order book that have not been triggered
+	ListStopOrders(context.Context, *ListStopOrdersRequest) (*ListStopOrdersResponse, error)
 	// CancelOrder cancels an existing order
 	CancelOrder(context.Context, *CancelOrderRequest) (*emptypb.Empty, error)
+	// AmendOrder changes the price and/or remaining quantity of a resting limit order
+	AmendOrder(context.Context, *AmendOrderRequest) (*OrderResponse, error)
 	// GetOrderBookState retrieves the current state of an order book
 	GetOrderBookState(context.Context, *GetOrderBookStateRequest) (*OrderBookStateResponse, error)
+	// GetDepthAtPrice retrieves the resting quantity at a single price level
+	GetDepthAtPrice(context.Context, *GetDepthAtPriceRequest) (*DepthAtPriceResponse, error)
+	// GetBookNotional retrieves the value of the resting orders on each side of an order book
+	GetBookNotional(context.Context, *GetBookNotionalRequest) (*BookNotionalResponse, error)
+	// GetBBO retrieves the best bid and best offer of an order book
+	GetBBO(context.Context, *GetBBORequest) (*BBOResponse, error)
+	// CalculateVWAP returns the volume-weighted average price of an order book's recent trades
+	CalculateVWAP(context.Context, *VWAPRequest) (*VWAPResponse, error)
+	// CalculateTWAP returns the time-weighted average trade price of an order book over a window
+	CalculateTWAP(context.Context, *TWAPRequest) (*TWAPResponse, error)
+	// Returns users' net positions in each order book and across all books
+	GetPositions(context.Context, *GetPositionsRequest) (*GetPositionsResponse, error)
+	// WarmUpOrderBook seeds an order book with synthetic resting orders (admin only)
+	WarmUpOrderBook(context.Context, *WarmUpRequest) (*WarmUpResponse, error)
+	// WatchOrderBook streams an order book's trade, add and cancel events as they happen
+	WatchOrderBook(*WatchOrderBookRequest, grpc.ServerStreamingServer[OrderBookEvent]) error
+	// SubscribeTrades streams every match made in an order book as it happens
+	SubscribeTrades(*SubscribeTradesRequest, grpc.ServerStreamingServer[TradeEvent]) error
 	mustEmbedUnimplementedOrderBookServiceServer()
 }
 
@@ -186,18 +461,69 @@ func (UnimplementedOrderBookServiceServer) ListOrderBooks(context.Context, *List
 func (UnimplementedOrderBookServiceServer) DeleteOrderBook(context.Context, *DeleteOrderBookRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteOrderBook not implemented")
 }
+func (UnimplementedOrderBookServiceServer) UndeleteOrderBook(context.Context, *UndeleteRequest) (*UndeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndeleteOrderBook not implemented")
+}
+func (UnimplementedOrderBookServiceServer) ResetOrderBook(context.Context, *ResetOrderBookRequest) (*ResetOrderBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetOrderBook not implemented")
+}
 func (UnimplementedOrderBookServiceServer) CreateOrder(context.Context, *CreateOrderRequest) (*OrderResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateOrder not implemented")
 }
+func (UnimplementedOrderBookServiceServer) BatchCreateOrders(context.Context, *BatchCreateOrdersRequest) (*BatchCreateOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateOrders not implemented")
+}
+func (UnimplementedOrderBookServiceServer) SimulateOrder(context.Context, *SimulateOrderRequest) (*SimulateOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SimulateOrder not implemented")
+}
+func (UnimplementedOrderBookServiceServer) RouteOrder(context.Context, *RouteOrderRequest) (*RouteOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RouteOrder not implemented")
+}
 func (UnimplementedOrderBookServiceServer) GetOrder(context.Context, *GetOrderRequest) (*OrderResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
 }
+func (UnimplementedOrderBookServiceServer) BatchGetOrders(context.Context, *BatchGetOrdersRequest) (*BatchGetOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetOrders not implemented")
+}
+func (UnimplementedOrderBookServiceServer) ListStopOrders(context.Context, *ListStopOrdersRequest) (*ListStopOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListStopOrders not implemented")
+}
 func (UnimplementedOrderBookServiceServer) CancelOrder(context.Context, *CancelOrderRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CancelOrder not implemented")
 }
+func (UnimplementedOrderBookServiceServer) AmendOrder(context.Context, *AmendOrderRequest) (*OrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AmendOrder not implemented")
+}
 func (UnimplementedOrderBookServiceServer) GetOrderBookState(context.Context, *GetOrderBookStateRequest) (*OrderBookStateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetOrderBookState not implemented")
 }
+func (UnimplementedOrderBookServiceServer) GetDepthAtPrice(context.Context, *GetDepthAtPriceRequest) (*DepthAtPriceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDepthAtPrice not implemented")
+}
+func (UnimplementedOrderBookServiceServer) GetBookNotional(context.Context, *GetBookNotionalRequest) (*BookNotionalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBookNotional not implemented")
+}
+func (UnimplementedOrderBookServiceServer) GetBBO(context.Context, *GetBBORequest) (*BBOResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBBO not implemented")
+}
+func (UnimplementedOrderBookServiceServer) CalculateVWAP(context.Context, *VWAPRequest) (*VWAPResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CalculateVWAP not implemented")
+}
+func (UnimplementedOrderBookServiceServer) CalculateTWAP(context.Context, *TWAPRequest) (*TWAPResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CalculateTWAP not implemented")
+}
+func (UnimplementedOrderBookServiceServer) GetPositions(context.Context, *GetPositionsRequest) (*GetPositionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPositions not implemented")
+}
+func (UnimplementedOrderBookServiceServer) WarmUpOrderBook(context.Context, *WarmUpRequest) (*WarmUpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WarmUpOrderBook not implemented")
+}
+func (UnimplementedOrderBookServiceServer) WatchOrderBook(*WatchOrderBookRequest, grpc.ServerStreamingServer[OrderBookEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrderBook not implemented")
+}
+func (UnimplementedOrderBookServiceServer) SubscribeTrades(*SubscribeTradesRequest, grpc.ServerStreamingServer[TradeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeTrades not implemented")
+}
 func (UnimplementedOrderBookServiceServer) mustEmbedUnimplementedOrderBookServiceServer() {}
 func (UnimplementedOrderBookServiceServer) testEmbeddedByValue()                          {}
 
@@ -291,6 +617,42 @@ func _OrderBookService_DeleteOrderBook_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderBookService_UndeleteOrderBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).UndeleteOrderBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_UndeleteOrderBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).UndeleteOrderBook(ctx, req.(*UndeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_ResetOrderBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetOrderBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).ResetOrderBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_ResetOrderBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).ResetOrderBook(ctx, req.(*ResetOrderBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _OrderBookService_CreateOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateOrderRequest)
 	if err := dec(in); err != nil {
@@ -309,6 +671,60 @@ func _OrderBookService_CreateOrder_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderBookService_BatchCreateOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).BatchCreateOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_BatchCreateOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).BatchCreateOrders(ctx, req.(*BatchCreateOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_SimulateOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimulateOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).SimulateOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_SimulateOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).SimulateOrder(ctx, req.(*SimulateOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_RouteOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RouteOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).RouteOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_RouteOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).RouteOrder(ctx, req.(*RouteOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _OrderBookService_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetOrderRequest)
 	if err := dec(in); err != nil {
@@ -327,6 +743,42 @@ func _OrderBookService_GetOrder_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderBookService_BatchGetOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).BatchGetOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_BatchGetOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).BatchGetOrders(ctx, req.(*BatchGetOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_ListStopOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListStopOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).ListStopOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_ListStopOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).ListStopOrders(ctx, req.(*ListStopOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _OrderBookService_CancelOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CancelOrderRequest)
 	if err := dec(in); err != nil {
@@ -345,6 +797,24 @@ func _OrderBookService_CancelOrder_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderBookService_AmendOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AmendOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).AmendOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_AmendOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).AmendOrder(ctx, req.(*AmendOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _OrderBookService_GetOrderBookState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetOrderBookStateRequest)
 	if err := dec(in); err != nil {
@@ -363,6 +833,154 @@ func _OrderBookService_GetOrderBookState_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderBookService_GetDepthAtPrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDepthAtPriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).GetDepthAtPrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_GetDepthAtPrice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).GetDepthAtPrice(ctx, req.(*GetDepthAtPriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_GetBookNotional_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBookNotionalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).GetBookNotional(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_GetBookNotional_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).GetBookNotional(ctx, req.(*GetBookNotionalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_GetBBO_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBBORequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).GetBBO(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_GetBBO_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).GetBBO(ctx, req.(*GetBBORequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_CalculateVWAP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VWAPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).CalculateVWAP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_CalculateVWAP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).CalculateVWAP(ctx, req.(*VWAPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_CalculateTWAP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TWAPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).CalculateTWAP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_CalculateTWAP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).CalculateTWAP(ctx, req.(*TWAPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_GetPositions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPositionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).GetPositions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_GetPositions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).GetPositions(ctx, req.(*GetPositionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_WarmUpOrderBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WarmUpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).WarmUpOrderBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_WarmUpOrderBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).WarmUpOrderBook(ctx, req.(*WarmUpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_WatchOrderBook_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrderBookRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderBookServiceServer).WatchOrderBook(m, &grpc.GenericServerStream[WatchOrderBookRequest, OrderBookEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBookService_WatchOrderBookServer = grpc.ServerStreamingServer[OrderBookEvent]
+
+func _OrderBookService_SubscribeTrades_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeTradesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderBookServiceServer).SubscribeTrades(m, &grpc.GenericServerStream[SubscribeTradesRequest, TradeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBookService_SubscribeTradesServer = grpc.ServerStreamingServer[TradeEvent]
+
 // OrderBookService_ServiceDesc is the grpc.ServiceDesc for OrderBookService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -386,23 +1004,94 @@ var OrderBookService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteOrderBook",
 			Handler:    _OrderBookService_DeleteOrderBook_Handler,
 		},
+		{
+			MethodName: "UndeleteOrderBook",
+			Handler:    _OrderBookService_UndeleteOrderBook_Handler,
+		},
+		{
+			MethodName: "ResetOrderBook",
+			Handler:    _OrderBookService_ResetOrderBook_Handler,
+		},
 		{
 			MethodName: "CreateOrder",
 			Handler:    _OrderBookService_CreateOrder_Handler,
 		},
+		{
+			MethodName: "BatchCreateOrders",
+			Handler:    _OrderBookService_BatchCreateOrders_Handler,
+		},
+		{
+			MethodName: "SimulateOrder",
+			Handler:    _OrderBookService_SimulateOrder_Handler,
+		},
+		{
+			MethodName: "RouteOrder",
+			Handler:    _OrderBookService_RouteOrder_Handler,
+		},
 		{
 			MethodName: "GetOrder",
 			Handler:    _OrderBookService_GetOrder_Handler,
 		},
+		{
+			MethodName: "BatchGetOrders",
+			Handler:    _OrderBookService_BatchGetOrders_Handler,
+		},
+		{
+			MethodName: "ListStopOrders",
+			Handler:    _OrderBookService_ListStopOrders_Handler,
+		},
 		{
 			MethodName: "CancelOrder",
 			Handler:    _OrderBookService_CancelOrder_Handler,
 		},
+		{
+			MethodName: "AmendOrder",
+			Handler:    _OrderBookService_AmendOrder_Handler,
+		},
 		{
 			MethodName: "GetOrderBookState",
 			Handler:    _OrderBookService_GetOrderBookState_Handler,
 		},
+		{
+			MethodName: "GetDepthAtPrice",
+			Handler:    _OrderBookService_GetDepthAtPrice_Handler,
+		},
+		{
+			MethodName: "GetBookNotional",
+			Handler:    _OrderBookService_GetBookNotional_Handler,
+		},
+		{
+			MethodName: "GetBBO",
+			Handler:    _OrderBookService_GetBBO_Handler,
+		},
+		{
+			MethodName: "CalculateVWAP",
+			Handler:    _OrderBookService_CalculateVWAP_Handler,
+		},
+		{
+			MethodName: "CalculateTWAP",
+			Handler:    _OrderBookService_CalculateTWAP_Handler,
+		},
+		{
+			MethodName: "GetPositions",
+			Handler:    _OrderBookService_GetPositions_Handler,
+		},
+		{
+			MethodName: "WarmUpOrderBook",
+			Handler:    _OrderBookService_WarmUpOrderBook_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchOrderBook",
+			Handler:       _OrderBookService_WatchOrderBook_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeTrades",
+			Handler:       _OrderBookService_SubscribeTrades_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/api/proto/orderbook.proto",
 }
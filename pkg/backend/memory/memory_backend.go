@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"container/list"
 	"fmt"
 	"strings"
 	"sync"
@@ -9,9 +10,14 @@ import (
 	"github.com/nikolaydubina/fpdecimal"
 )
 
-// OrderQueue represents a price level in the order book
+// OrderQueue represents a price level in the order book. Its orders are
+// kept in time priority, first to be filled at the front.
 type OrderQueue struct {
-	orders    map[string]*core.Order
+	orders map[string]*core.Order
+	// fifo holds the orders in priority order; elements maps an order ID
+	// to its element in fifo
+	fifo      *list.List
+	elements  map[string]*list.Element
 	priceStr  string
 	priceDecm fpdecimal.Decimal
 	next      *OrderQueue
@@ -22,21 +28,93 @@ type OrderQueue struct {
 func NewOrderQueue(price fpdecimal.Decimal) *OrderQueue {
 	return &OrderQueue{
 		orders:    make(map[string]*core.Order),
+		fifo:      list.New(),
+		elements:  make(map[string]*list.Element),
 		priceStr:  price.String(),
 		priceDecm: price,
 	}
 }
 
+// pushBack adds order behind every order in the queue
+func (oq *OrderQueue) pushBack(order *core.Order) {
+	oq.orders[order.ID()] = order
+	oq.elements[order.ID()] = oq.fifo.PushBack(order)
+}
+
+// pushFront adds order ahead of every order in the queue
+func (oq *OrderQueue) pushFront(order *core.Order) {
+	oq.orders[order.ID()] = order
+	oq.elements[order.ID()] = oq.fifo.PushFront(order)
+}
+
+// insertByTime adds order ahead of the first order created after it, so it
+// keeps the priority its creation time gives it
+func (oq *OrderQueue) insertByTime(order *core.Order) {
+	for e := oq.fifo.Front(); e != nil; e = e.Next() {
+		if e.Value.(*core.Order).CreatedAt().After(order.CreatedAt()) {
+			oq.orders[order.ID()] = order
+			oq.elements[order.ID()] = oq.fifo.InsertBefore(order, e)
+			return
+		}
+	}
+	oq.pushBack(order)
+}
+
+// removeOrder deletes the order with orderID from the queue
+func (oq *OrderQueue) removeOrder(orderID string) {
+	if e, ok := oq.elements[orderID]; ok {
+		oq.fifo.Remove(e)
+		delete(oq.elements, orderID)
+	}
+	delete(oq.orders, orderID)
+}
+
+// each calls fn with the queue's orders in priority order until fn returns false
+func (oq *OrderQueue) each(fn func(order *core.Order) bool) {
+	for e := oq.fifo.Front(); e != nil; e = e.Next() {
+		if !fn(e.Value.(*core.Order)) {
+			return
+		}
+	}
+}
+
 // OrderSide represents one side (bid/ask) of the order book
 type OrderSide struct {
 	sync.RWMutex
-	head    *OrderQueue
-	tail    *OrderQueue
+	head *OrderQueue
+	tail *OrderQueue
+	// orderID maps a price string to its queue
 	orderID map[string]*OrderQueue
+	// orderToQueue maps an order ID to the queue holding it, so an order
+	// can be removed without relying on its current price
+	orderToQueue map[string]*OrderQueue
 }
 
-// String implements fmt.Stringer interface
+// newOrderSide creates an empty order side
+func newOrderSide() *OrderSide {
+	return &OrderSide{
+		orderID:      make(map[string]*OrderQueue),
+		orderToQueue: make(map[string]*OrderQueue),
+	}
+}
+
+// maxOrdersPerLevel is how many orders String shows for each price level
+const maxOrdersPerLevel = 5
+
+// String implements fmt.Stringer interface. At most maxOrdersPerLevel orders
+// are listed per price level; use DebugDump to see all of them.
 func (os *OrderSide) String() string {
+	return os.format(maxOrdersPerLevel)
+}
+
+// DebugDump returns every price level with all of its orders, without truncation
+func (os *OrderSide) DebugDump() string {
+	return os.format(0)
+}
+
+// format writes each price level as "price -> orders: n [id:qty@price, ...]",
+// listing at most limit orders per level. A limit of zero lists all orders.
+func (os *OrderSide) format(limit int) string {
 	os.RLock()
 	defer os.RUnlock()
 
@@ -46,21 +124,48 @@ func (os *OrderSide) String() string {
 	for current != nil {
 		orderCount := len(current.orders)
 		sb.WriteString(fmt.Sprintf("\n%s -> orders: %d", current.priceStr, orderCount))
+		if orderCount > 0 {
+			sb.WriteString(" [")
+			sb.WriteString(current.describeOrders(limit))
+			sb.WriteString("]")
+		}
 		current = current.next
 	}
 
 	return sb.String()
 }
 
+// describeOrders lists the queue's orders as "id:qty@price" in priority order
+func (oq *OrderQueue) describeOrders(limit int) string {
+	parts := make([]string, 0, len(oq.orders))
+	oq.each(func(order *core.Order) bool {
+		if limit > 0 && len(parts) == limit {
+			return false
+		}
+		parts = append(parts, fmt.Sprintf("%s:%s@%s", order.ID(), order.Quantity(), oq.priceStr))
+		return true
+	})
+	if hidden := len(oq.orders) - len(parts); hidden > 0 {
+		parts = append(parts, fmt.Sprintf("... %d more", hidden))
+	}
+	return strings.Join(parts, ", ")
+}
+
 // Prices returns all prices in the order side
 func (os *OrderSide) Prices() []fpdecimal.Decimal {
+	return os.TopPrices(0)
+}
+
+// TopPrices returns the n best prices of the order side, or all prices if n
+// is not positive
+func (os *OrderSide) TopPrices(n int) []fpdecimal.Decimal {
 	os.RLock()
 	defer os.RUnlock()
 
 	prices := make([]fpdecimal.Decimal, 0)
 	current := os.head
 
-	for current != nil {
+	for current != nil && (n <= 0 || len(prices) < n) {
 		prices = append(prices, current.priceDecm)
 		current = current.next
 	}
@@ -68,7 +173,7 @@ func (os *OrderSide) Prices() []fpdecimal.Decimal {
 	return prices
 }
 
-// Orders returns all orders at a given price level
+// Orders returns all orders at a given price level in time priority
 func (os *OrderSide) Orders(price fpdecimal.Decimal) []*core.Order {
 	os.RLock()
 	defer os.RUnlock()
@@ -80,93 +185,14 @@ func (os *OrderSide) Orders(price fpdecimal.Decimal) []*core.Order {
 	}
 
 	orders := make([]*core.Order, 0, len(queue.orders))
-	for _, order := range queue.orders {
+	queue.each(func(order *core.Order) bool {
 		orders = append(orders, order)
-	}
+		return true
+	})
 
 	return orders
 }
 
-// StopBook stores stop orders
-type StopBook struct {
-	buy  *OrderSide
-	sell *OrderSide
-}
-
-// Orders returns all orders at a given price level for both buy and sell sides
-func (sb *StopBook) Orders(price fpdecimal.Decimal) []*core.Order {
-	buyOrders := sb.buy.Orders(price)
-	sellOrders := sb.sell.Orders(price)
-	allOrders := make([]*core.Order, 0, len(buyOrders)+len(sellOrders))
-	allOrders = append(allOrders, buyOrders...)
-	allOrders = append(allOrders, sellOrders...)
-	return allOrders
-}
-
-// Prices returns all unique prices from both buy and sell sides
-func (sb *StopBook) Prices() []fpdecimal.Decimal {
-	buyPrices := sb.buy.Prices()
-	sellPrices := sb.sell.Prices()
-
-	// Create a map to deduplicate prices
-	priceMap := make(map[string]fpdecimal.Decimal)
-	for _, price := range buyPrices {
-		priceMap[price.String()] = price
-	}
-	for _, price := range sellPrices {
-		priceMap[price.String()] = price
-	}
-
-	// Convert map back to slice
-	prices := make([]fpdecimal.Decimal, 0, len(priceMap))
-	for _, price := range priceMap {
-		prices = append(prices, price)
-	}
-	return prices
-}
-
-// BuyOrders returns all buy stop orders
-func (sb *StopBook) BuyOrders() []*core.Order {
-	var allOrders []*core.Order
-
-	// Iterate through all price levels
-	prices := sb.buy.Prices()
-	for _, price := range prices {
-		orders := sb.buy.Orders(price)
-		allOrders = append(allOrders, orders...)
-	}
-
-	return allOrders
-}
-
-// SellOrders returns all sell stop orders
-func (sb *StopBook) SellOrders() []*core.Order {
-	var allOrders []*core.Order
-
-	// Iterate through all price levels
-	prices := sb.sell.Prices()
-	for _, price := range prices {
-		orders := sb.sell.Orders(price)
-		allOrders = append(allOrders, orders...)
-	}
-
-	return allOrders
-}
-
-// String implements fmt.Stringer interface
-func (sb *StopBook) String() string {
-	builder := strings.Builder{}
-
-	builder.WriteString("Buy Stop Orders:")
-	builder.WriteString(sb.buy.String())
-	builder.WriteString("\n")
-
-	builder.WriteString("Sell Stop Orders:")
-	builder.WriteString(sb.sell.String())
-
-	return builder.String()
-}
-
 // MemoryBackend implements OrderBookBackend interface with in-memory storage
 type MemoryBackend struct {
 	sync.RWMutex
@@ -175,28 +201,34 @@ type MemoryBackend struct {
 	asks       *OrderSide
 	stopBook   *StopBook
 	ocoMapping map[string]string
+	// midBuy and midSell queue the resting midpoint orders, oldest first
+	midBuy  []*core.Order
+	midSell []*core.Order
 }
 
 // NewMemoryBackend creates new instance of MemoryBackend
 func NewMemoryBackend() *MemoryBackend {
-	return &MemoryBackend{
-		orders: make(map[string]*core.Order),
-		bids: &OrderSide{
-			orderID: make(map[string]*OrderQueue),
-		},
-		asks: &OrderSide{
-			orderID: make(map[string]*OrderQueue),
-		},
-		stopBook: &StopBook{
-			buy: &OrderSide{
-				orderID: make(map[string]*OrderQueue),
-			},
-			sell: &OrderSide{
-				orderID: make(map[string]*OrderQueue),
-			},
-		},
-		ocoMapping: make(map[string]string),
-	}
+	b := &MemoryBackend{}
+	b.init()
+	return b
+}
+
+// init (re)creates all order storage
+func (b *MemoryBackend) init() {
+	b.orders = make(map[string]*core.Order)
+	b.bids = newOrderSide()
+	b.asks = newOrderSide()
+	b.stopBook = newStopBook()
+	b.ocoMapping = make(map[string]string)
+	b.midBuy = nil
+	b.midSell = nil
+}
+
+// ClearAll removes every order, price level and OCO mapping
+func (b *MemoryBackend) ClearAll() {
+	b.Lock()
+	defer b.Unlock()
+	b.init()
 }
 
 // GetOrder retrieves an order by ID
@@ -258,8 +290,27 @@ func (b *MemoryBackend) DeleteOrder(orderID string) {
 	delete(b.orders, orderID)
 }
 
-// AppendToSide adds an order to the specified side
+// AppendToSide adds an order to the specified side, behind the orders
+// already at its price
 func (b *MemoryBackend) AppendToSide(side core.Side, order *core.Order) {
+	b.placeOnSide(side, order, (*OrderQueue).pushBack)
+}
+
+// PrependToSide adds an order to the specified side, ahead of the orders
+// already at its price
+func (b *MemoryBackend) PrependToSide(side core.Side, order *core.Order) {
+	b.placeOnSide(side, order, (*OrderQueue).pushFront)
+}
+
+// InsertToSide adds an order to the specified side, ahead of the orders at
+// its price that were created after it
+func (b *MemoryBackend) InsertToSide(side core.Side, order *core.Order) {
+	b.placeOnSide(side, order, (*OrderQueue).insertByTime)
+}
+
+// placeOnSide adds an order to the specified side, using place to put it in
+// the queue of its price level
+func (b *MemoryBackend) placeOnSide(side core.Side, order *core.Order, place func(*OrderQueue, *core.Order)) {
 	if order.IsMarketOrder() {
 		return
 	}
@@ -282,14 +333,16 @@ func (b *MemoryBackend) AppendToSide(side core.Side, order *core.Order) {
 
 	if q, ok := orderSide.orderID[priceStr]; ok {
 		// Price level exists, add order to queue
-		q.orders[order.ID()] = order
+		place(q, order)
+		orderSide.orderToQueue[order.ID()] = q
 		return
 	}
 
 	// Create new price level
 	newQueue := NewOrderQueue(price)
-	newQueue.orders[order.ID()] = order
+	place(newQueue, order)
 	orderSide.orderID[priceStr] = newQueue
+	orderSide.orderToQueue[order.ID()] = newQueue
 
 	// Add to linked list
 	if orderSide.head == nil {
@@ -368,38 +421,7 @@ func (b *MemoryBackend) RemoveFromSide(side core.Side, order *core.Order) bool {
 	orderSide.Lock()
 	defer orderSide.Unlock()
 
-	priceStr := order.Price().String()
-	queue, ok := orderSide.orderID[priceStr]
-	if !ok {
-		return false
-	}
-
-	// Check if the order exists at this price level
-	if _, exists := queue.orders[order.ID()]; !exists {
-		return false
-	}
-
-	delete(queue.orders, order.ID())
-
-	// If queue is empty, remove it and update linked list
-	if len(queue.orders) == 0 {
-		delete(orderSide.orderID, priceStr)
-
-		// Update linked list
-		if queue.prev != nil {
-			queue.prev.next = queue.next
-		} else {
-			orderSide.head = queue.next
-		}
-
-		if queue.next != nil {
-			queue.next.prev = queue.prev
-		} else {
-			orderSide.tail = queue.prev
-		}
-	}
-
-	return true
+	return orderSide.remove(order.ID())
 }
 
 // AppendToStopBook adds a stop order to the stop book
@@ -418,19 +440,23 @@ func (b *MemoryBackend) AppendToStopBook(order *core.Order) {
 	b.Lock()
 	defer b.Unlock()
 
+	b.stopBook.push(order)
+
 	price := order.StopPrice()
 	priceStr := price.String()
 
 	if q, ok := stopSide.orderID[priceStr]; ok {
 		// Price level exists, add order to queue
-		q.orders[order.ID()] = order
+		q.pushBack(order)
+		stopSide.orderToQueue[order.ID()] = q
 		return
 	}
 
 	// Create new price level
 	newQueue := NewOrderQueue(price)
-	newQueue.orders[order.ID()] = order
+	newQueue.pushBack(order)
 	stopSide.orderID[priceStr] = newQueue
+	stopSide.orderToQueue[order.ID()] = newQueue
 
 	// Add to linked list
 	if stopSide.head == nil {
@@ -506,29 +532,37 @@ func (b *MemoryBackend) RemoveFromStopBook(order *core.Order) bool {
 	b.Lock()
 	defer b.Unlock()
 
-	priceStr := order.StopPrice().String()
-	queue, ok := stopSide.orderID[priceStr]
+	b.stopBook.remove(order.ID())
+
+	return stopSide.remove(order.ID())
+}
+
+// remove deletes the order with orderID from the queue holding it, dropping
+// the price level once it is empty. It reports whether the order was found.
+// The caller must hold the side's lock.
+func (os *OrderSide) remove(orderID string) bool {
+	queue, ok := os.orderToQueue[orderID]
 	if !ok {
 		return false
 	}
 
-	delete(queue.orders, order.ID())
+	queue.removeOrder(orderID)
+	delete(os.orderToQueue, orderID)
 
-	// If queue is empty, remove it
+	// If queue is empty, remove it and update linked list
 	if len(queue.orders) == 0 {
-		delete(stopSide.orderID, priceStr)
+		delete(os.orderID, queue.priceStr)
 
-		// Update linked list
 		if queue.prev != nil {
 			queue.prev.next = queue.next
 		} else {
-			stopSide.head = queue.next
+			os.head = queue.next
 		}
 
 		if queue.next != nil {
 			queue.next.prev = queue.prev
 		} else {
-			stopSide.tail = queue.prev
+			os.tail = queue.prev
 		}
 	}
 
@@ -558,6 +592,47 @@ func (b *MemoryBackend) GetAsks() interface{} {
 	return b.asks
 }
 
+// GetAllOrders returns copies of every bid, ask, stop and midpoint order:
+// bids and asks best price first, then buy and sell stops, then the buy and
+// sell midpoint queues oldest first. The backend stays locked while they are
+// collected.
+func (b *MemoryBackend) GetAllOrders() []*core.Order {
+	b.RLock()
+	defer b.RUnlock()
+
+	orders := make([]*core.Order, 0, len(b.orders))
+	for _, side := range []*OrderSide{b.bids, b.asks, b.stopBook.buy, b.stopBook.sell} {
+		for _, price := range side.Prices() {
+			for _, order := range side.Orders(price) {
+				c := *order
+				orders = append(orders, &c)
+			}
+		}
+	}
+	for _, queue := range [][]*core.Order{b.midBuy, b.midSell} {
+		for _, order := range queue {
+			c := *order
+			orders = append(orders, &c)
+		}
+	}
+	return orders
+}
+
+// OrderCount returns how many orders rest on the bid and ask sides, in the
+// stop book and in the midpoint queues
+func (b *MemoryBackend) OrderCount() int {
+	b.RLock()
+	defer b.RUnlock()
+
+	count := len(b.midBuy) + len(b.midSell)
+	for _, side := range []*OrderSide{b.bids, b.asks, b.stopBook.buy, b.stopBook.sell} {
+		side.RLock()
+		count += len(side.orderToQueue)
+		side.RUnlock()
+	}
+	return count
+}
+
 // GetStopBook returns the stop book for iteration
 func (b *MemoryBackend) GetStopBook() interface{} {
 	b.RLock()
@@ -1,10 +1,15 @@
 package memory
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
+	"unsafe"
 
 	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
 	"github.com/nikolaydubina/fpdecimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -206,6 +211,63 @@ func TestMemoryBackend_StopBookOperations(t *testing.T) {
 	}
 }
 
+func TestMemoryBackend_GetAllOrders(t *testing.T) {
+	backend := NewMemoryBackend()
+	bid, err := core.NewLimitOrder("bid-1", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(99), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	ask, err := core.NewLimitOrder("ask-1", core.Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(101), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	stop, err := core.NewStopLimitOrder("stop-1", core.Buy, fpdecimal.FromInt(3), fpdecimal.FromInt(110), fpdecimal.FromInt(105), "", "test_user")
+	require.NoError(t, err)
+	mid, err := core.NewMidpointPeggedOrder("mid-1", core.Sell, fpdecimal.FromInt(4), "", "test_user")
+	require.NoError(t, err)
+	for _, order := range []*core.Order{bid, ask, stop, mid} {
+		require.NoError(t, backend.StoreOrder(order))
+	}
+	backend.AppendToSide(core.Buy, bid)
+	backend.AppendToSide(core.Sell, ask)
+	backend.AppendToStopBook(stop)
+	backend.AppendToMidpoint(mid)
+
+	orders := backend.GetAllOrders()
+	assert.Equal(t, []string{"bid-1", "ask-1", "stop-1", "mid-1"}, queueOrderIDs(orders))
+	assert.Equal(t, 4, backend.OrderCount())
+
+	// The result is a copy: later fills and removals do not reach it
+	bid.SetQuantity(fpdecimal.FromInt(5))
+	backend.RemoveFromSide(core.Sell, ask)
+	assert.Equal(t, fpdecimal.FromInt(1), orders[0].Quantity())
+	assert.Len(t, orders, 4)
+	assert.Len(t, backend.GetAllOrders(), 3)
+	assert.True(t, backend.RemoveFromMidpoint(mid))
+	assert.False(t, backend.RemoveFromMidpoint(mid))
+	assert.Len(t, backend.GetAllOrders(), 2)
+}
+
+func TestMemoryBackend_GetAllOrders_Performance(t *testing.T) {
+	const count = 10000
+	backend := NewMemoryBackend()
+	for i := 0; i < count; i++ {
+		side := core.Buy
+		price := fpdecimal.FromInt(1000 - i%500)
+		if i%2 == 1 {
+			side = core.Sell
+			price = fpdecimal.FromInt(1001 + i%500)
+		}
+		order, err := core.NewLimitOrder(fmt.Sprintf("order-%d", i), side, fpdecimal.FromInt(1), price, core.GTC, "", "test_user")
+		require.NoError(t, err)
+		require.NoError(t, backend.StoreOrder(order))
+		backend.AppendToSide(side, order)
+	}
+
+	start := time.Now()
+	orders := backend.GetAllOrders()
+	elapsed := time.Since(start)
+
+	assert.Len(t, orders, count)
+	assert.Less(t, elapsed, 100*time.Millisecond)
+}
+
 func TestMemoryBackend_GetSides(t *testing.T) {
 	backend := NewMemoryBackend()
 
@@ -300,6 +362,25 @@ func TestOrderSide_RemoveNonExistent(t *testing.T) {
 	assert.Equal(t, "order-1", ordersAtPrice[0].ID())
 }
 
+func TestRemoveFromSide_StalePrice(t *testing.T) {
+	backend := NewMemoryBackend()
+	price := fpdecimal.FromInt(100)
+	order, err := core.NewLimitOrder("order-1", core.Buy, fpdecimal.FromInt(1), price, core.GTC, "", "test_user")
+	require.NoError(t, err)
+	backend.AppendToSide(core.Buy, order)
+
+	// Change the price behind the backend's back; Order has no setter for it
+	field := reflect.ValueOf(order).Elem().FieldByName("price")
+	reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Set(reflect.ValueOf(fpdecimal.FromInt(101)))
+	require.Equal(t, fpdecimal.FromInt(101), order.Price())
+
+	assert.True(t, backend.RemoveFromSide(core.Buy, order))
+	assert.Empty(t, backend.bids.Orders(price))
+	assert.Empty(t, backend.bids.Prices())
+	assert.Empty(t, backend.bids.orderToQueue)
+	assert.False(t, backend.RemoveFromSide(core.Buy, order))
+}
+
 func TestPriceSorting(t *testing.T) {
 	backend := NewMemoryBackend()
 
@@ -373,6 +454,41 @@ func TestStopBook(t *testing.T) {
 	assert.Len(t, stopOrdersAfterRemove, 0)
 }
 
+func TestStopBook_PopTriggered(t *testing.T) {
+	backend := NewMemoryBackend()
+	stop := func(id string, side core.Side, stopPrice float64) *core.Order {
+		order, err := core.NewStopLimitOrder(id, side, fpdecimal.FromInt(1), fpdecimal.FromFloat(stopPrice), fpdecimal.FromFloat(stopPrice), "", "test_user")
+		require.NoError(t, err)
+		backend.AppendToStopBook(order)
+		return order
+	}
+
+	stop("buy-110", core.Buy, 110)
+	stop("buy-105", core.Buy, 105)
+	canceled := stop("buy-102", core.Buy, 102)
+	stop("buy-105-late", core.Buy, 105)
+	stop("sell-95", core.Sell, 95)
+	stop("sell-98", core.Sell, 98)
+	require.True(t, backend.RemoveFromStopBook(canceled))
+
+	stopBook := backend.GetStopBook().(*StopBook)
+	popAll := func(lastPrice float64) []string {
+		var ids []string
+		for order := stopBook.PopTriggered(fpdecimal.FromFloat(lastPrice)); order != nil; order = stopBook.PopTriggered(fpdecimal.FromFloat(lastPrice)) {
+			ids = append(ids, order.ID())
+		}
+		return ids
+	}
+
+	assert.Empty(t, popAll(100))
+	// Lowest buy stops first, FIFO within a price
+	assert.Equal(t, []string{"buy-105", "buy-105-late"}, popAll(107))
+	// Highest sell stops first
+	assert.Equal(t, []string{"sell-98", "sell-95"}, popAll(90))
+	assert.Equal(t, []string{"buy-110"}, popAll(110))
+	assert.Empty(t, popAll(110))
+}
+
 func TestStoreGetUpdateDeleteOrder(t *testing.T) {
 	backend := NewMemoryBackend()
 	price := fpdecimal.FromFloat(100.0)
@@ -439,6 +555,41 @@ func TestOrderSide_String(t *testing.T) {
 	}
 }
 
+func TestOrderSideString(t *testing.T) {
+	backend := NewMemoryBackend()
+
+	var ids []string
+	for level := 0; level < 3; level++ {
+		price := fpdecimal.FromInt(100 + level)
+		for i := 0; i < 2; i++ {
+			id := fmt.Sprintf("ask-%d-%d", level, i)
+			order, err := core.NewLimitOrder(id, core.Sell, fpdecimal.FromInt(i+1), price, core.GTC, "", "test_user")
+			require.NoError(t, err)
+			backend.AppendToSide(core.Sell, order)
+			ids = append(ids, id)
+		}
+	}
+
+	str := backend.asks.String()
+	for _, id := range ids {
+		assert.Contains(t, str, id)
+	}
+	assert.Contains(t, str, "\n100.000 -> orders: 2 [ask-0-0:1.000@100.000, ask-0-1:2.000@100.000]")
+	assert.Equal(t, str, backend.asks.DebugDump())
+
+	// Levels with more than maxOrdersPerLevel orders are truncated by String only
+	price := fpdecimal.FromInt(200)
+	for i := 0; i < maxOrdersPerLevel+2; i++ {
+		order, err := core.NewLimitOrder(fmt.Sprintf("deep-%d", i), core.Sell, fpdecimal.FromInt(1), price, core.GTC, "", "test_user")
+		require.NoError(t, err)
+		backend.AppendToSide(core.Sell, order)
+	}
+	assert.Contains(t, backend.asks.String(), "... 2 more]")
+	assert.NotContains(t, backend.asks.String(), "deep-6")
+	assert.Contains(t, backend.asks.DebugDump(), "deep-6")
+	assert.NotContains(t, backend.asks.DebugDump(), "more]")
+}
+
 func TestStopBook_String(t *testing.T) {
 	stopBook := &StopBook{
 		buy: &OrderSide{
@@ -597,3 +748,198 @@ func TestMemoryBackend_OCOOrders(t *testing.T) {
 	backend.DeleteOrder("oco1")
 	assert.Empty(t, backend.CheckOCO("oco2"))
 }
+
+func TestMemoryBackend_ClearAll(t *testing.T) {
+	backend := NewMemoryBackend()
+
+	bid, err := core.NewLimitOrder("bid", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(99), core.GTC, "ask", "test_user")
+	require.NoError(t, err)
+	ask, err := core.NewLimitOrder("ask", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(101), core.GTC, "bid", "test_user")
+	require.NoError(t, err)
+	stop, err := core.NewStopLimitOrder("stop", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(90), fpdecimal.FromInt(95), "", "test_user")
+	require.NoError(t, err)
+
+	for _, o := range []*core.Order{bid, ask, stop} {
+		require.NoError(t, backend.StoreOrder(o))
+	}
+	backend.AppendToSide(core.Buy, bid)
+	backend.AppendToSide(core.Sell, ask)
+	backend.AppendToStopBook(stop)
+
+	backend.ClearAll()
+
+	assert.Nil(t, backend.GetOrder("bid"))
+	assert.Nil(t, backend.GetOrder("stop"))
+	assert.Empty(t, backend.CheckOCO("bid"))
+	assert.Empty(t, backend.GetBids().(*OrderSide).Prices())
+	assert.Empty(t, backend.GetAsks().(*OrderSide).Prices())
+	assert.Empty(t, backend.GetStopBook().(*StopBook).Prices())
+
+	// The backend is usable after clearing
+	require.NoError(t, backend.StoreOrder(bid))
+	backend.AppendToSide(core.Buy, bid)
+	assert.Len(t, backend.GetBids().(*OrderSide).Prices(), 1)
+}
+
+func TestOrderSide_TopPrices(t *testing.T) {
+	backend := NewMemoryBackend()
+	for i := 1; i <= 5; i++ {
+		order, err := core.NewLimitOrder(fmt.Sprintf("bid-%d", i), core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(int64(i)), core.GTC, "", "test_user")
+		require.NoError(t, err)
+		backend.AppendToSide(core.Buy, order)
+	}
+
+	assert.Equal(t, []fpdecimal.Decimal{fpdecimal.FromInt(5), fpdecimal.FromInt(4), fpdecimal.FromInt(3)}, backend.bids.TopPrices(3))
+	assert.Equal(t, backend.bids.Prices(), backend.bids.TopPrices(0))
+	assert.Len(t, backend.bids.TopPrices(10), 5)
+}
+
+func TestAmendOrderQueuePosition(t *testing.T) {
+	ctx := context.Background()
+	target := fpdecimal.FromInt(100)
+
+	// Two bids of 2 rest in the order given: the reference order at 100 and
+	// the order amended to 100 from its starting price
+	tests := []struct {
+		name    string
+		first   string
+		second  string
+		from    int64
+		newQty  int64
+		wantIDs []string
+	}{
+		{"better price goes to the front", "ref", "amended", 99, 2, []string{"amended", "ref"}},
+		{"same price with more quantity goes to the back", "amended", "ref", 100, 3, []string{"ref", "amended"}},
+		{"same price with less quantity keeps its place", "amended", "ref", 100, 1, []string{"amended", "ref"}},
+		{"worse price keeps its time priority", "amended", "ref", 101, 2, []string{"amended", "ref"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewMemoryBackend()
+			book := core.NewOrderBook(backend)
+			for _, id := range []string{tt.first, tt.second} {
+				price := target
+				if id == "amended" {
+					price = fpdecimal.FromInt(tt.from)
+				}
+				order, err := core.NewLimitOrder(id, core.Buy, fpdecimal.FromInt(2), price, core.GTC, "", "")
+				require.NoError(t, err)
+				require.NoError(t, backend.StoreOrder(order))
+				backend.AppendToSide(core.Buy, order)
+			}
+
+			done, err := book.AmendOrder(ctx, "amended", target, fpdecimal.FromInt(tt.newQty))
+			require.NoError(t, err)
+			assert.Equal(t, fpdecimal.FromInt(tt.newQty), done.Order.Quantity())
+
+			var ids []string
+			for _, order := range backend.bids.Orders(target) {
+				ids = append(ids, order.ID())
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			if tt.from != 100 {
+				assert.Empty(t, backend.bids.Orders(fpdecimal.FromInt(tt.from)), "amended order left at its old price")
+			}
+		})
+	}
+}
+
+func TestIcebergOrderOnSide(t *testing.T) {
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return messaging.NewMockMessageSender() })
+	defer core.SetMessageSenderFactory(nil)
+
+	ctx := context.Background()
+	price := fpdecimal.FromInt(100)
+	backend := NewMemoryBackend()
+	book := core.NewOrderBook(backend)
+
+	iceberg, err := core.NewIcebergOrder("iceberg", core.Sell, fpdecimal.FromInt(5), fpdecimal.FromInt(2), price, core.GTC, "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, iceberg)
+	require.NoError(t, err)
+	plain, err := core.NewLimitOrder("plain", core.Sell, fpdecimal.FromInt(1), price, core.GTC, "", "")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, plain)
+	require.NoError(t, err)
+
+	levelOf := func() []string {
+		var level []string
+		for _, order := range backend.asks.Orders(price) {
+			level = append(level, fmt.Sprintf("%s:%s", order.ID(), order.Quantity()))
+		}
+		return level
+	}
+	assert.Equal(t, []string{"iceberg:2.000", "plain:1.000"}, levelOf())
+	assert.Equal(t, "5.000", backend.GetOrder("iceberg").RemainingQty().String())
+
+	// Filling the shown 2 refills it from the reserve at the back of the level
+	taker, err := core.NewMarketOrder("taker", core.Buy, fpdecimal.FromInt(2), "")
+	require.NoError(t, err)
+	done, err := book.Process(ctx, taker)
+	require.NoError(t, err)
+	assert.True(t, done.Replenished)
+	assert.Equal(t, []string{"plain:1.000", "iceberg:2.000"}, levelOf())
+	assert.Equal(t, "1.000", backend.GetOrder("iceberg").HiddenQty().String())
+}
+
+func TestMemoryBackend_SnapshotRestore(t *testing.T) {
+	backend := NewMemoryBackend()
+	place := func(order *core.Order, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		require.NoError(t, backend.StoreOrder(order))
+		switch {
+		case order.IsMidpointOrder():
+			backend.AppendToMidpoint(order)
+		case order.IsStopOrder():
+			backend.AppendToStopBook(order)
+		default:
+			backend.AppendToSide(order.Side(), order)
+		}
+	}
+	place(core.NewLimitOrder("bid-1", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(99), core.GTC, "", "user-1"))
+	place(core.NewLimitOrder("bid-2", core.Buy, fpdecimal.FromInt(2), fpdecimal.FromInt(100), core.GTC, "", ""))
+	place(core.NewLimitOrder("bid-3", core.Buy, fpdecimal.FromInt(3), fpdecimal.FromInt(100), core.GTC, "", ""))
+	place(core.NewIcebergOrder("ask-1", core.Sell, fpdecimal.FromInt(10), fpdecimal.FromInt(2), fpdecimal.FromInt(101), core.GTC, "", "test_user"))
+	place(core.NewLimitOrder("ask-2", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(102), core.GTC, "stop-1", ""))
+	place(core.NewStopLimitOrder("stop-1", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(106), fpdecimal.FromInt(105), "ask-2", ""))
+	place(core.NewStopLimitOrder("stop-2", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(94), fpdecimal.FromInt(95), "", ""))
+	place(core.NewMidpointPeggedOrder("mid-1", core.Buy, fpdecimal.FromInt(1), "", "test_user"))
+	place(core.NewMidpointPeggedOrder("mid-2", core.Buy, fpdecimal.FromInt(2), "", "test_user"))
+	place(core.NewMidpointPeggedOrder("mid-3", core.Sell, fpdecimal.FromInt(1), "", "test_user"))
+
+	data, err := backend.Snapshot()
+	require.NoError(t, err)
+
+	restored := NewMemoryBackend()
+	place = func(order *core.Order, err error) {
+		require.NoError(t, err)
+		require.NoError(t, restored.StoreOrder(order))
+		restored.AppendToSide(order.Side(), order)
+	}
+	place(core.NewLimitOrder("stale", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(50), core.GTC, "", ""))
+	require.NoError(t, restored.Restore(data))
+
+	assert.Nil(t, restored.GetOrder("stale"), "Restore replaces the existing state")
+	assert.Equal(t, []string{"bid-2", "bid-3", "bid-1"}, sideOrderIDs(restored.bids))
+	assert.Equal(t, []string{"ask-1", "ask-2"}, sideOrderIDs(restored.asks))
+	assert.Equal(t, []string{"stop-1"}, sideOrderIDs(restored.stopBook.buy))
+	assert.Equal(t, []string{"stop-2"}, sideOrderIDs(restored.stopBook.sell))
+	assert.Equal(t, []string{"mid-1", "mid-2"}, queueOrderIDs(restored.GetMidpointOrders(core.Buy)))
+	assert.Equal(t, []string{"mid-3"}, queueOrderIDs(restored.GetMidpointOrders(core.Sell)))
+	assert.Equal(t, "stop-1", restored.CheckOCO("ask-2"))
+	assert.Equal(t, backend.OrderCount(), restored.OrderCount())
+
+	iceberg := restored.GetOrder("ask-1")
+	require.NotNil(t, iceberg)
+	assert.Equal(t, "2.000", iceberg.DisplayQty().String())
+	assert.Equal(t, "10.000", iceberg.RemainingQty().String())
+	assert.Equal(t, "user-1", restored.GetOrder("bid-1").UserAddress())
+	// The sides hold the stored orders, not copies of them
+	assert.Same(t, restored.GetOrder("bid-2"), restored.bids.Orders(fpdecimal.FromInt(100))[0])
+
+	// A snapshot that cannot be read leaves the backend as it was
+	assert.Error(t, restored.Restore([]byte("not a snapshot")))
+	assert.Equal(t, backend.OrderCount(), restored.OrderCount())
+}
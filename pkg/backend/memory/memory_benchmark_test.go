@@ -214,3 +214,43 @@ func BenchmarkOrderBook_LargeOrderBook_Memory(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+// BenchmarkStopOrderTrigger measures finding the single stop order a trade
+// triggers among benchSize resting buy stops, popping it from the stop heap
+// versus scanning every stop order
+func BenchmarkStopOrderTrigger(b *testing.B) {
+	backend := NewMemoryBackend()
+	for i := 0; i < benchSize; i++ {
+		price := fpdecimal.FromInt(int64(10000 + i))
+		order, err := core.NewStopLimitOrder(fmt.Sprintf("stop-%d", i), core.Buy, fpdecimal.FromInt(1), price, price, "", "test_user")
+		require.NoError(b, err)
+		backend.AppendToStopBook(order)
+	}
+	stopBook := backend.GetStopBook().(*StopBook)
+	lastPrice := fpdecimal.FromInt(10000)
+
+	b.Run("Heap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			order := stopBook.PopTriggered(lastPrice)
+			if order == nil {
+				b.Fatal("expected a triggered stop order")
+			}
+			// Put it back so every iteration triggers the same order
+			stopBook.push(order)
+		}
+	})
+
+	b.Run("Slice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			triggered := 0
+			for _, order := range stopBook.BuyOrders() {
+				if lastPrice.GreaterThanOrEqual(order.StopPrice()) {
+					triggered++
+				}
+			}
+			if triggered != 1 {
+				b.Fatalf("expected 1 triggered stop order, got %d", triggered)
+			}
+		}
+	})
+}
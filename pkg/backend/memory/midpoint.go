@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"slices"
+
+	"github.com/erain9/matchingo/pkg/core"
+)
+
+// midpointQueue returns the queue of resting midpoint orders on side. The
+// caller must hold the backend's lock.
+func (b *MemoryBackend) midpointQueue(side core.Side) *[]*core.Order {
+	if side == core.Buy {
+		return &b.midBuy
+	}
+	return &b.midSell
+}
+
+// AppendToMidpoint adds a midpoint order to the back of its side's queue
+func (b *MemoryBackend) AppendToMidpoint(order *core.Order) {
+	b.Lock()
+	defer b.Unlock()
+
+	queue := b.midpointQueue(order.Side())
+	*queue = append(*queue, order)
+}
+
+// RemoveFromMidpoint removes a midpoint order from its side's queue. It
+// reports whether the order was queued.
+func (b *MemoryBackend) RemoveFromMidpoint(order *core.Order) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	queue := b.midpointQueue(order.Side())
+	i := slices.IndexFunc(*queue, func(queued *core.Order) bool {
+		return queued.ID() == order.ID()
+	})
+	if i < 0 {
+		return false
+	}
+	*queue = slices.Delete(*queue, i, i+1)
+	return true
+}
+
+// GetMidpointOrders returns the midpoint orders resting on side, oldest
+// first. The slice is a copy, so the queue may change while it is walked.
+func (b *MemoryBackend) GetMidpointOrders(side core.Side) []*core.Order {
+	b.RLock()
+	defer b.RUnlock()
+	return slices.Clone(*b.midpointQueue(side))
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+
+	"github.com/erain9/matchingo/pkg/core"
+)
+
+// snapshotVersion is the version of the snapshot format Snapshot writes
+const snapshotVersion = 1
+
+// ErrSnapshotVersion is returned by Restore for snapshots in a format it does not read
+var ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+// backendSnapshot is the gob form of a MemoryBackend. The sides list order
+// IDs in priority order, best price first and oldest first within a price,
+// so appending them in turn rebuilds every queue as it was. The midpoint
+// queues list their orders oldest first; snapshots taken before they were
+// kept by the backend have none.
+type backendSnapshot struct {
+	Version      int
+	Orders       []*core.Order
+	Bids         []string
+	Asks         []string
+	StopBuy      []string
+	StopSell     []string
+	MidpointBuy  []string
+	MidpointSell []string
+}
+
+// Snapshot serialises every stored order, the bid and ask queues, the stop
+// book and the midpoint queues with encoding/gob. The backend stays locked
+// while it is read.
+func (b *MemoryBackend) Snapshot() ([]byte, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	snapshot := backendSnapshot{
+		Version:      snapshotVersion,
+		Orders:       make([]*core.Order, 0, len(b.orders)),
+		Bids:         sideOrderIDs(b.bids),
+		Asks:         sideOrderIDs(b.asks),
+		StopBuy:      sideOrderIDs(b.stopBook.buy),
+		StopSell:     sideOrderIDs(b.stopBook.sell),
+		MidpointBuy:  queueOrderIDs(b.midBuy),
+		MidpointSell: queueOrderIDs(b.midSell),
+	}
+	for _, order := range b.orders {
+		snapshot.Orders = append(snapshot.Orders, order)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&snapshot); err != nil {
+		return nil, fmt.Errorf("encode snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Restore replaces the backend's state with a snapshot taken by Snapshot.
+// The new state is built aside and swapped in at once, so a snapshot that
+// fails to load leaves the backend as it was.
+func (b *MemoryBackend) Restore(data []byte) error {
+	var snapshot backendSnapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snapshot); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, snapshot.Version)
+	}
+
+	restored := NewMemoryBackend()
+	for _, order := range snapshot.Orders {
+		if err := restored.StoreOrder(order); err != nil {
+			return fmt.Errorf("restore order %s: %w", order.ID(), err)
+		}
+	}
+	for _, side := range []struct {
+		ids   []string
+		place func(order *core.Order)
+	}{
+		{snapshot.Bids, func(order *core.Order) { restored.AppendToSide(core.Buy, order) }},
+		{snapshot.Asks, func(order *core.Order) { restored.AppendToSide(core.Sell, order) }},
+		{snapshot.StopBuy, restored.AppendToStopBook},
+		{snapshot.StopSell, restored.AppendToStopBook},
+		{snapshot.MidpointBuy, restored.AppendToMidpoint},
+		{snapshot.MidpointSell, restored.AppendToMidpoint},
+	} {
+		for _, id := range side.ids {
+			order := restored.orders[id]
+			if order == nil {
+				return fmt.Errorf("restore order %s: %w", id, core.ErrNonexistentOrder)
+			}
+			side.place(order)
+		}
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	b.orders = restored.orders
+	b.bids = restored.bids
+	b.asks = restored.asks
+	b.stopBook = restored.stopBook
+	b.ocoMapping = restored.ocoMapping
+	b.midBuy = restored.midBuy
+	b.midSell = restored.midSell
+	return nil
+}
+
+// sideOrderIDs returns the IDs of the orders on side in priority order
+func sideOrderIDs(side *OrderSide) []string {
+	side.RLock()
+	defer side.RUnlock()
+
+	var ids []string
+	for queue := side.head; queue != nil; queue = queue.next {
+		queue.each(func(order *core.Order) bool {
+			ids = append(ids, order.ID())
+			return true
+		})
+	}
+	return ids
+}
+
+// queueOrderIDs returns the IDs of the orders in a midpoint queue, oldest first
+func queueOrderIDs(queue []*core.Order) []string {
+	ids := make([]string, len(queue))
+	for i, order := range queue {
+		ids[i] = order.ID()
+	}
+	return ids
+}
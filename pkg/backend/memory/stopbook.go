@@ -0,0 +1,250 @@
+package memory
+
+import (
+	"container/heap"
+	"strings"
+	"sync"
+
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// minCompactEntries is how many removed entries the stop heaps may hold
+// before they are considered for compaction
+const minCompactEntries = 64
+
+// stopEntry is a stop order queued in a stopHeap. Entries are deleted lazily:
+// removing the order only sets removed, and the entry is dropped once it
+// reaches the top of its heap.
+type stopEntry struct {
+	order   *core.Order
+	seq     uint64
+	removed bool
+}
+
+// stopHeap implements heap.Interface over stop entries. before reports
+// whether one stop price triggers ahead of another; equal prices keep
+// insertion order.
+type stopHeap struct {
+	entries []*stopEntry
+	before  func(a, b fpdecimal.Decimal) bool
+}
+
+func (h *stopHeap) Len() int { return len(h.entries) }
+
+func (h *stopHeap) Less(i, j int) bool {
+	a, b := h.entries[i], h.entries[j]
+	if a.order.StopPrice().Equal(b.order.StopPrice()) {
+		return a.seq < b.seq
+	}
+	return h.before(a.order.StopPrice(), b.order.StopPrice())
+}
+
+func (h *stopHeap) Swap(i, j int) { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+
+func (h *stopHeap) Push(x any) { h.entries = append(h.entries, x.(*stopEntry)) }
+
+func (h *stopHeap) Pop() any {
+	last := len(h.entries) - 1
+	entry := h.entries[last]
+	h.entries[last] = nil
+	h.entries = h.entries[:last]
+	return entry
+}
+
+// StopBook stores stop orders. Besides the price levels of each side, buy
+// stops are kept in a min-heap and sell stops in a max-heap by stop price,
+// so a trade only visits the stops it actually triggers.
+type StopBook struct {
+	buy  *OrderSide
+	sell *OrderSide
+
+	mu       sync.Mutex
+	buyHeap  *stopHeap
+	sellHeap *stopHeap
+	entries  map[string]*stopEntry
+	removed  int
+	seq      uint64
+}
+
+// newStopBook creates an empty stop book
+func newStopBook() *StopBook {
+	return &StopBook{
+		buy:  newOrderSide(),
+		sell: newOrderSide(),
+		// A buy stop triggers once the price rises to it, so the lowest goes first
+		buyHeap: &stopHeap{before: fpdecimal.Decimal.LessThan},
+		// A sell stop triggers once the price falls to it, so the highest goes first
+		sellHeap: &stopHeap{before: fpdecimal.Decimal.GreaterThan},
+		entries:  make(map[string]*stopEntry),
+	}
+}
+
+// push queues order on the heap for its side, replacing any earlier entry
+// with the same ID
+func (sb *StopBook) push(order *core.Order) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	sb.markRemoved(order.ID())
+
+	sb.seq++
+	entry := &stopEntry{order: order, seq: sb.seq}
+	sb.entries[order.ID()] = entry
+	if order.Side() == core.Buy {
+		heap.Push(sb.buyHeap, entry)
+	} else {
+		heap.Push(sb.sellHeap, entry)
+	}
+}
+
+// remove flags the heap entry of orderID as removed
+func (sb *StopBook) remove(orderID string) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	sb.markRemoved(orderID)
+
+	// Rebuild the heaps once removed entries make up most of them
+	if sb.removed > minCompactEntries && sb.removed*2 > sb.buyHeap.Len()+sb.sellHeap.Len() {
+		sb.compact()
+	}
+}
+
+// markRemoved flags the live entry of orderID, if any, as removed
+func (sb *StopBook) markRemoved(orderID string) {
+	if entry, ok := sb.entries[orderID]; ok {
+		entry.removed = true
+		delete(sb.entries, orderID)
+		sb.removed++
+	}
+}
+
+// compact drops every removed entry from the heaps
+func (sb *StopBook) compact() {
+	for _, h := range []*stopHeap{sb.buyHeap, sb.sellHeap} {
+		live := h.entries[:0]
+		for _, entry := range h.entries {
+			if !entry.removed {
+				live = append(live, entry)
+			}
+		}
+		clear(h.entries[len(live):])
+		h.entries = live
+		heap.Init(h)
+	}
+	sb.removed = 0
+}
+
+// PopTriggered takes the next stop order triggered by a trade at lastPrice
+// off the heaps and returns it, or nil if lastPrice triggers none. Buy stops
+// come before sell stops; within a side the stop closest to being crossed
+// first comes first. The order stays in its price level until it is removed
+// with RemoveFromStopBook.
+func (sb *StopBook) PopTriggered(lastPrice fpdecimal.Decimal) *core.Order {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if top := sb.popIf(sb.buyHeap, lastPrice.GreaterThanOrEqual); top != nil {
+		return top
+	}
+	return sb.popIf(sb.sellHeap, lastPrice.LessThanOrEqual)
+}
+
+// peek returns the top entry of h, first dropping removed entries, or nil
+// if h is empty
+func (sb *StopBook) peek(h *stopHeap) *stopEntry {
+	for h.Len() > 0 {
+		if top := h.entries[0]; !top.removed {
+			return top
+		}
+		heap.Pop(h)
+		sb.removed--
+	}
+	return nil
+}
+
+// popIf pops the top of h if its stop price satisfies triggered
+func (sb *StopBook) popIf(h *stopHeap, triggered func(stop fpdecimal.Decimal) bool) *core.Order {
+	top := sb.peek(h)
+	if top == nil || !triggered(top.order.StopPrice()) {
+		return nil
+	}
+	heap.Pop(h)
+	delete(sb.entries, top.order.ID())
+	return top.order
+}
+
+// Orders returns all orders at a given price level for both buy and sell sides
+func (sb *StopBook) Orders(price fpdecimal.Decimal) []*core.Order {
+	buyOrders := sb.buy.Orders(price)
+	sellOrders := sb.sell.Orders(price)
+	allOrders := make([]*core.Order, 0, len(buyOrders)+len(sellOrders))
+	allOrders = append(allOrders, buyOrders...)
+	allOrders = append(allOrders, sellOrders...)
+	return allOrders
+}
+
+// Prices returns all unique prices from both buy and sell sides
+func (sb *StopBook) Prices() []fpdecimal.Decimal {
+	buyPrices := sb.buy.Prices()
+	sellPrices := sb.sell.Prices()
+
+	// Create a map to deduplicate prices
+	priceMap := make(map[string]fpdecimal.Decimal)
+	for _, price := range buyPrices {
+		priceMap[price.String()] = price
+	}
+	for _, price := range sellPrices {
+		priceMap[price.String()] = price
+	}
+
+	// Convert map back to slice
+	prices := make([]fpdecimal.Decimal, 0, len(priceMap))
+	for _, price := range priceMap {
+		prices = append(prices, price)
+	}
+	return prices
+}
+
+// BuyOrders returns all buy stop orders
+func (sb *StopBook) BuyOrders() []*core.Order {
+	var allOrders []*core.Order
+
+	// Iterate through all price levels
+	prices := sb.buy.Prices()
+	for _, price := range prices {
+		orders := sb.buy.Orders(price)
+		allOrders = append(allOrders, orders...)
+	}
+
+	return allOrders
+}
+
+// SellOrders returns all sell stop orders
+func (sb *StopBook) SellOrders() []*core.Order {
+	var allOrders []*core.Order
+
+	// Iterate through all price levels
+	prices := sb.sell.Prices()
+	for _, price := range prices {
+		orders := sb.sell.Orders(price)
+		allOrders = append(allOrders, orders...)
+	}
+
+	return allOrders
+}
+
+// String implements fmt.Stringer interface
+func (sb *StopBook) String() string {
+	builder := strings.Builder{}
+
+	builder.WriteString("Buy Stop Orders:")
+	builder.WriteString(sb.buy.String())
+	builder.WriteString("\n")
+
+	builder.WriteString("Sell Stop Orders:")
+	builder.WriteString(sb.sell.String())
+
+	return builder.String()
+}
@@ -0,0 +1,597 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nikolaydubina/fpdecimal"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidBookName is returned for book names that are not 1 to 64
+// letters, digits, underscores or hyphens
+var ErrInvalidBookName = errors.New("book name must be 1 to 64 letters, digits, underscores or hyphens")
+
+var bookNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// schema creates the tables shared by every book. orders holds each order
+// as JSON. order_levels records which orders rest on which side of a book,
+// in arrival order; a row is soft-deleted by marking it removed when its
+// order leaves the side, and the partial indexes cover only the open rows.
+const schema = `
+CREATE TABLE IF NOT EXISTS orders (
+	book     TEXT NOT NULL,
+	id       TEXT NOT NULL,
+	oco      TEXT NOT NULL DEFAULT '',
+	metadata JSONB NOT NULL,
+	PRIMARY KEY (book, id)
+);
+
+CREATE TABLE IF NOT EXISTS order_levels (
+	position   BIGSERIAL PRIMARY KEY,
+	book       TEXT NOT NULL,
+	order_id   TEXT NOT NULL,
+	side       TEXT NOT NULL,
+	price      NUMERIC NOT NULL,
+	status     TEXT NOT NULL DEFAULT 'open',
+	removed_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS order_levels_open_orders
+	ON order_levels (book, side, order_id) WHERE status = 'open';
+
+CREATE INDEX IF NOT EXISTS order_levels_open_prices
+	ON order_levels (book, price, side, status) WHERE status = 'open';
+`
+
+// Sides of order_levels. Stop orders are kept by their stop price and
+// midpoint orders, which have no price, at zero.
+const (
+	sideBids         = "bids"
+	sideAsks         = "asks"
+	sideStopBuy      = "stop_buy"
+	sideStopSell     = "stop_sell"
+	sideMidpointBuy  = "midpoint_buy"
+	sideMidpointSell = "midpoint_sell"
+)
+
+// EnsureSchema creates the tables and indexes the backend uses if they do
+// not exist yet
+func EnsureSchema(ctx context.Context, pool *pgxpool.Pool) error {
+	if _, err := pool.Exec(ctx, schema); err != nil {
+		return fmt.Errorf("failed to create order book schema: %w", err)
+	}
+	return nil
+}
+
+// PostgresBackend implements OrderBookBackend interface with PostgreSQL
+// storage, so a book survives restarts of every process using it. Books
+// share the tables and are told apart by name. Every method is a round trip
+// to the database; nothing is cached in memory.
+type PostgresBackend struct {
+	pool   *pgxpool.Pool
+	ctx    context.Context
+	book   string
+	logger *zap.Logger
+}
+
+// NewPostgresBackend creates a PostgresBackend storing the book named book
+// through pool, creating the schema if needed. It returns ErrInvalidBookName
+// if book is malformed. The pool is not closed by the backend.
+func NewPostgresBackend(ctx context.Context, pool *pgxpool.Pool, book string, logger *zap.Logger) (*PostgresBackend, error) {
+	if !bookNamePattern.MatchString(book) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidBookName, book)
+	}
+	if err := EnsureSchema(ctx, pool); err != nil {
+		return nil, err
+	}
+	return &PostgresBackend{
+		pool:   pool,
+		ctx:    context.Background(),
+		book:   book,
+		logger: logger,
+	}, nil
+}
+
+// GetOrder retrieves an order by its ID
+func (b *PostgresBackend) GetOrder(orderID string) *core.Order {
+	var data []byte
+	err := b.pool.QueryRow(b.ctx,
+		`SELECT metadata FROM orders WHERE book = $1 AND id = $2`,
+		b.book, orderID).Scan(&data)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			b.logger.Error("failed to get order",
+				zap.String("orderID", orderID),
+				zap.Error(err))
+		}
+		return nil
+	}
+	return b.decodeOrder(orderID, data)
+}
+
+// GetOrders retrieves the orders with the given IDs in one query. The result
+// lines up with orderIDs, holding nil for orders that do not exist or cannot
+// be read.
+func (b *PostgresBackend) GetOrders(orderIDs []string) []*core.Order {
+	orders := make([]*core.Order, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return orders
+	}
+
+	rows, err := b.pool.Query(b.ctx,
+		`SELECT id, metadata FROM orders WHERE book = $1 AND id = ANY($2)`,
+		b.book, orderIDs)
+	if err != nil {
+		b.logger.Error("failed to get orders", zap.Error(err))
+		return orders
+	}
+	byID := make(map[string]*core.Order, len(orderIDs))
+	for _, order := range b.collectOrders(rows, true) {
+		byID[order.ID()] = order
+	}
+	for i, orderID := range orderIDs {
+		orders[i] = byID[orderID]
+	}
+	return orders
+}
+
+// StoreOrder stores a new order
+func (b *PostgresBackend) StoreOrder(order *core.Order) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	tag, err := b.pool.Exec(b.ctx,
+		`INSERT INTO orders (book, id, oco, metadata) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT DO NOTHING`,
+		b.book, order.ID(), order.OCO(), data)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return core.ErrOrderExists
+	}
+	return nil
+}
+
+// UpdateOrder updates an existing order
+func (b *PostgresBackend) UpdateOrder(order *core.Order) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	tag, err := b.pool.Exec(b.ctx,
+		`UPDATE orders SET oco = $3, metadata = $4 WHERE book = $1 AND id = $2`,
+		b.book, order.ID(), order.OCO(), data)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return core.ErrNonexistentOrder
+	}
+	return nil
+}
+
+// DeleteOrder deletes an order. Its removed side entries are kept.
+func (b *PostgresBackend) DeleteOrder(orderID string) {
+	if _, err := b.pool.Exec(b.ctx,
+		`DELETE FROM orders WHERE book = $1 AND id = $2`,
+		b.book, orderID); err != nil {
+		b.logger.Error("failed to delete order",
+			zap.String("orderID", orderID),
+			zap.Error(err))
+	}
+}
+
+// AppendToSide adds an order to the back of its price level on the
+// specified side. An order already on the side is left where it is.
+func (b *PostgresBackend) AppendToSide(side core.Side, order *core.Order) {
+	b.appendLevel(sideName(side), order.ID(), order.Price())
+}
+
+// RemoveFromSide soft-deletes an order from the specified side
+func (b *PostgresBackend) RemoveFromSide(side core.Side, order *core.Order) bool {
+	return b.removeLevel(sideName(side), order.ID())
+}
+
+// AppendToStopBook adds a stop order to the stop book at its stop price
+func (b *PostgresBackend) AppendToStopBook(order *core.Order) {
+	b.appendLevel(stopSideName(order.Side()), order.ID(), order.StopPrice())
+}
+
+// RemoveFromStopBook soft-deletes a stop order from the stop book
+func (b *PostgresBackend) RemoveFromStopBook(order *core.Order) bool {
+	return b.removeLevel(stopSideName(order.Side()), order.ID())
+}
+
+// AppendToMidpoint adds a midpoint order to the back of its side's queue
+func (b *PostgresBackend) AppendToMidpoint(order *core.Order) {
+	b.appendLevel(midpointSideName(order.Side()), order.ID(), fpdecimal.Zero)
+}
+
+// RemoveFromMidpoint soft-deletes a midpoint order from its side's queue
+func (b *PostgresBackend) RemoveFromMidpoint(order *core.Order) bool {
+	return b.removeLevel(midpointSideName(order.Side()), order.ID())
+}
+
+// GetMidpointOrders returns the midpoint orders resting on side, oldest first
+func (b *PostgresBackend) GetMidpointOrders(side core.Side) []*core.Order {
+	return b.sideOrders(midpointSideName(side))
+}
+
+// appendLevel inserts an open order_levels row. The partial unique index on
+// open rows turns a second insert for the same order into a no-op.
+func (b *PostgresBackend) appendLevel(side, orderID string, price fpdecimal.Decimal) {
+	if _, err := b.pool.Exec(b.ctx,
+		`INSERT INTO order_levels (book, order_id, side, price) VALUES ($1, $2, $3, $4::numeric)
+		 ON CONFLICT (book, side, order_id) WHERE status = 'open' DO NOTHING`,
+		b.book, orderID, side, price.String()); err != nil {
+		b.logger.Error("failed to add order to side",
+			zap.String("orderID", orderID),
+			zap.String("side", side),
+			zap.Error(err))
+	}
+}
+
+// removeLevel marks the open order_levels row of an order removed
+func (b *PostgresBackend) removeLevel(side, orderID string) bool {
+	tag, err := b.pool.Exec(b.ctx,
+		`UPDATE order_levels SET status = 'removed', removed_at = now()
+		 WHERE book = $1 AND side = $2 AND order_id = $3 AND status = 'open'`,
+		b.book, side, orderID)
+	if err != nil {
+		b.logger.Error("failed to remove order from side",
+			zap.String("orderID", orderID),
+			zap.String("side", side),
+			zap.Error(err))
+		return false
+	}
+	return tag.RowsAffected() > 0
+}
+
+// CheckOCO returns the ID of the order linked to orderID as one-cancels-other
+func (b *PostgresBackend) CheckOCO(orderID string) string {
+	var oco string
+	err := b.pool.QueryRow(b.ctx,
+		`SELECT oco FROM orders WHERE book = $1 AND id = $2`,
+		b.book, orderID).Scan(&oco)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			b.logger.Error("failed to check OCO",
+				zap.String("orderID", orderID),
+				zap.Error(err))
+		}
+		return ""
+	}
+	return oco
+}
+
+// GetBids returns the bid side of the order book for iteration
+func (b *PostgresBackend) GetBids() interface{} {
+	return &PostgresSide{backend: b, side: sideBids, descending: true}
+}
+
+// GetAsks returns the ask side of the order book for iteration
+func (b *PostgresBackend) GetAsks() interface{} {
+	return &PostgresSide{backend: b, side: sideAsks}
+}
+
+// GetStopBook returns the stop book for iteration
+func (b *PostgresBackend) GetStopBook() interface{} {
+	return &PostgresStopBook{backend: b}
+}
+
+// GetAllOrders returns every bid, ask, stop and midpoint order, read in one
+// query
+func (b *PostgresBackend) GetAllOrders() []*core.Order {
+	rows, err := b.pool.Query(b.ctx,
+		`SELECT o.metadata FROM orders o
+		 WHERE o.book = $1 AND EXISTS (
+			SELECT 1 FROM order_levels l
+			WHERE l.book = o.book AND l.order_id = o.id AND l.status = 'open')`,
+		b.book)
+	if err != nil {
+		b.logger.Error("failed to list orders", zap.Error(err))
+		return nil
+	}
+	return b.collectOrders(rows, false)
+}
+
+// OrderCount returns how many orders rest on the bid and ask sides, in the
+// stop book and in the midpoint queues, without reading the orders
+func (b *PostgresBackend) OrderCount() int {
+	var count int
+	err := b.pool.QueryRow(b.ctx,
+		`SELECT count(DISTINCT order_id) FROM order_levels WHERE book = $1 AND status = 'open'`,
+		b.book).Scan(&count)
+	if err != nil {
+		b.logger.Error("failed to count orders", zap.Error(err))
+		return 0
+	}
+	return count
+}
+
+// FlushOrderBook deletes every order of the book together with its side
+// entries, removed ones included, in one transaction
+func (b *PostgresBackend) FlushOrderBook(ctx context.Context) error {
+	tx, err := b.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM order_levels WHERE book = $1`, b.book); err != nil {
+		return fmt.Errorf("failed to delete side entries of %s: %w", b.book, err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM orders WHERE book = $1`, b.book); err != nil {
+		return fmt.Errorf("failed to delete orders of %s: %w", b.book, err)
+	}
+	return tx.Commit(ctx)
+}
+
+// PurgeRemoved hard-deletes the side entries soft-deleted more than
+// olderThan ago, returning how many were deleted
+func (b *PostgresBackend) PurgeRemoved(ctx context.Context, olderThan time.Duration) (int64, error) {
+	tag, err := b.pool.Exec(ctx,
+		`DELETE FROM order_levels
+		 WHERE book = $1 AND status = 'removed' AND removed_at < $2`,
+		b.book, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// WithContext returns a new PostgresBackend with the given context
+func (b *PostgresBackend) WithContext(ctx context.Context) *PostgresBackend {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	clone := *b
+	clone.ctx = ctx
+	return &clone
+}
+
+// levelOrders returns the open orders of side at price in arrival order
+func (b *PostgresBackend) levelOrders(side string, price fpdecimal.Decimal) []*core.Order {
+	rows, err := b.pool.Query(b.ctx,
+		`SELECT o.metadata FROM order_levels l
+		 JOIN orders o ON o.book = l.book AND o.id = l.order_id
+		 WHERE l.book = $1 AND l.side = $2 AND l.price = $3::numeric AND l.status = 'open'
+		 ORDER BY l.position`,
+		b.book, side, price.String())
+	if err != nil {
+		b.logger.Error("failed to read price level",
+			zap.String("side", side),
+			zap.String("price", price.String()),
+			zap.Error(err))
+		return []*core.Order{}
+	}
+	return b.collectOrders(rows, false)
+}
+
+// sideOrders returns the open orders of side, lowest price first and in
+// arrival order within a price
+func (b *PostgresBackend) sideOrders(side string) []*core.Order {
+	rows, err := b.pool.Query(b.ctx,
+		`SELECT o.metadata FROM order_levels l
+		 JOIN orders o ON o.book = l.book AND o.id = l.order_id
+		 WHERE l.book = $1 AND l.side = $2 AND l.status = 'open'
+		 ORDER BY l.price, l.position`,
+		b.book, side)
+	if err != nil {
+		b.logger.Error("failed to read side",
+			zap.String("side", side),
+			zap.Error(err))
+		return []*core.Order{}
+	}
+	return b.collectOrders(rows, false)
+}
+
+// prices returns the distinct open prices of side, at most limit of them
+// if limit is positive
+func (b *PostgresBackend) prices(side string, descending bool, limit int) []fpdecimal.Decimal {
+	order := "ASC"
+	if descending {
+		order = "DESC"
+	}
+	// Grouping by the numeric price rather than selecting DISTINCT text
+	// keeps the numeric order and merges equal prices written differently
+	query := `SELECT price::text FROM order_levels
+		WHERE book = $1 AND side = $2 AND status = 'open'
+		GROUP BY price ORDER BY price ` + order
+	args := []any{b.book, side}
+	if limit > 0 {
+		query += ` LIMIT $3`
+		args = append(args, limit)
+	}
+
+	rows, err := b.pool.Query(b.ctx, query, args...)
+	if err != nil {
+		b.logger.Error("failed to read prices",
+			zap.String("side", side),
+			zap.Error(err))
+		return []fpdecimal.Decimal{}
+	}
+	defer rows.Close()
+
+	prices := []fpdecimal.Decimal{}
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			b.logger.Error("failed to scan price", zap.Error(err))
+			continue
+		}
+		price, err := fpdecimal.FromString(text)
+		if err != nil {
+			b.logger.Error("failed to parse price",
+				zap.String("price", text),
+				zap.Error(err))
+			continue
+		}
+		prices = append(prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		b.logger.Error("failed to read prices", zap.Error(err))
+	}
+	return prices
+}
+
+// collectOrders decodes the orders of rows, whose columns are the metadata,
+// preceded by the order ID if withID is set. rows is closed.
+func (b *PostgresBackend) collectOrders(rows pgx.Rows, withID bool) []*core.Order {
+	defer rows.Close()
+
+	orders := []*core.Order{}
+	for rows.Next() {
+		var orderID string
+		var data []byte
+		var err error
+		if withID {
+			err = rows.Scan(&orderID, &data)
+		} else {
+			err = rows.Scan(&data)
+		}
+		if err != nil {
+			b.logger.Error("failed to scan order", zap.Error(err))
+			continue
+		}
+		if order := b.decodeOrder(orderID, data); order != nil {
+			orders = append(orders, order)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		b.logger.Error("failed to read orders", zap.Error(err))
+	}
+	return orders
+}
+
+// decodeOrder unmarshals the metadata of an order, logging failures
+func (b *PostgresBackend) decodeOrder(orderID string, data []byte) *core.Order {
+	var order core.Order
+	if err := json.Unmarshal(data, &order); err != nil {
+		b.logger.Error("failed to unmarshal order",
+			zap.String("orderID", orderID),
+			zap.Error(err))
+		return nil
+	}
+	return &order
+}
+
+// sideName returns the order_levels side of a book side
+func sideName(side core.Side) string {
+	if side == core.Buy {
+		return sideBids
+	}
+	return sideAsks
+}
+
+// stopSideName returns the order_levels side of the stop orders of a side
+func stopSideName(side core.Side) string {
+	if side == core.Buy {
+		return sideStopBuy
+	}
+	return sideStopSell
+}
+
+// midpointSideName returns the order_levels side of the midpoint orders of a
+// side
+func midpointSideName(side core.Side) string {
+	if side == core.Buy {
+		return sideMidpointBuy
+	}
+	return sideMidpointSell
+}
+
+// PostgresSide represents one side (bid/ask) of the PostgreSQL order book.
+// Like the Redis side it keeps no order state in memory.
+type PostgresSide struct {
+	backend    *PostgresBackend
+	side       string
+	descending bool // Bids list their highest price first
+}
+
+// String implements fmt.Stringer interface
+func (ps *PostgresSide) String() string {
+	sb := strings.Builder{}
+	for _, price := range ps.Prices() {
+		sb.WriteString(fmt.Sprintf("\n%s -> orders: %d", price, len(ps.Orders(price))))
+	}
+	return sb.String()
+}
+
+// Prices returns all prices in the order side, best first
+func (ps *PostgresSide) Prices() []fpdecimal.Decimal {
+	return ps.TopPrices(0)
+}
+
+// TopPrices returns the n best prices of the order side, or all prices if n
+// is not positive
+func (ps *PostgresSide) TopPrices(n int) []fpdecimal.Decimal {
+	return ps.backend.prices(ps.side, ps.descending, n)
+}
+
+// Orders returns all orders at a given price level, oldest first
+func (ps *PostgresSide) Orders(price fpdecimal.Decimal) []*core.Order {
+	return ps.backend.levelOrders(ps.side, price)
+}
+
+// PostgresStopBook represents the PostgreSQL stop book
+type PostgresStopBook struct {
+	backend *PostgresBackend
+}
+
+// String implements fmt.Stringer interface
+func (psb *PostgresStopBook) String() string {
+	sb := strings.Builder{}
+	sb.WriteString("Buy Stop Orders:")
+	for _, order := range psb.BuyOrders() {
+		sb.WriteString(fmt.Sprintf("\n%s -> %s", order.StopPrice(), order.ID()))
+	}
+	sb.WriteString("\nSell Stop Orders:")
+	for _, order := range psb.SellOrders() {
+		sb.WriteString(fmt.Sprintf("\n%s -> %s", order.StopPrice(), order.ID()))
+	}
+	return sb.String()
+}
+
+// Prices returns the stop prices of both sides, lowest first, each once
+func (psb *PostgresStopBook) Prices() []fpdecimal.Decimal {
+	seen := make(map[string]bool)
+	var prices []fpdecimal.Decimal
+	for _, side := range []string{sideStopBuy, sideStopSell} {
+		for _, price := range psb.backend.prices(side, false, 0) {
+			if !seen[price.String()] {
+				seen[price.String()] = true
+				prices = append(prices, price)
+			}
+		}
+	}
+	return prices
+}
+
+// Orders returns the buy and sell stop orders at a stop price
+func (psb *PostgresStopBook) Orders(price fpdecimal.Decimal) []*core.Order {
+	return append(psb.backend.levelOrders(sideStopBuy, price), psb.backend.levelOrders(sideStopSell, price)...)
+}
+
+// BuyOrders returns all buy stop orders, lowest stop price first
+func (psb *PostgresStopBook) BuyOrders() []*core.Order {
+	return psb.backend.sideOrders(sideStopBuy)
+}
+
+// SellOrders returns all sell stop orders, lowest stop price first
+func (psb *PostgresStopBook) SellOrders() []*core.Order {
+	return psb.backend.sideOrders(sideStopSell)
+}
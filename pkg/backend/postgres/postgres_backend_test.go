@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// setupTestBackend connects to the database in MATCHINGO_POSTGRES_URL, or a
+// local matchingo_test database, and returns an empty backend for book.
+// The test is skipped if the database cannot be reached.
+func setupTestBackend(t *testing.T, book string) *PostgresBackend {
+	t.Helper()
+	url := os.Getenv("MATCHINGO_POSTGRES_URL")
+	if url == "" {
+		url = "postgres://postgres@localhost:5432/matchingo_test?sslmode=disable"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Skipf("Skipping PostgreSQL tests: invalid database URL (%v)", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		t.Skipf("Skipping PostgreSQL tests: Cannot connect to PostgreSQL (%v)", err)
+	}
+	t.Cleanup(pool.Close)
+
+	backend, err := NewPostgresBackend(context.Background(), pool, book, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, backend.FlushOrderBook(context.Background()))
+	return backend
+}
+
+func TestNewPostgresBackendInvalidBook(t *testing.T) {
+	_, err := NewPostgresBackend(context.Background(), nil, "bad book", zap.NewNop())
+	assert.ErrorIs(t, err, ErrInvalidBookName)
+}
+
+func TestPostgresBackendOrders(t *testing.T) {
+	backend := setupTestBackend(t, "test_orders")
+
+	order, err := core.NewLimitOrder("order1", core.Buy, fpdecimal.FromFloat(10.0), fpdecimal.FromFloat(100.0), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	require.NoError(t, backend.StoreOrder(order))
+	assert.ErrorIs(t, backend.StoreOrder(order), core.ErrOrderExists)
+
+	stored := backend.GetOrder("order1")
+	require.NotNil(t, stored)
+	assert.Equal(t, order.Price(), stored.Price())
+	assert.Equal(t, order.Quantity(), stored.Quantity())
+	assert.Nil(t, backend.GetOrder("missing"))
+
+	orders := backend.GetOrders([]string{"missing", "order1"})
+	require.Len(t, orders, 2)
+	assert.Nil(t, orders[0])
+	assert.Equal(t, "order1", orders[1].ID())
+
+	missing, err := core.NewLimitOrder("missing", core.Buy, fpdecimal.FromFloat(1.0), fpdecimal.FromFloat(100.0), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	assert.ErrorIs(t, backend.UpdateOrder(missing), core.ErrNonexistentOrder)
+
+	backend.DeleteOrder("order1")
+	assert.Nil(t, backend.GetOrder("order1"))
+}
+
+func TestPostgresBackendSides(t *testing.T) {
+	backend := setupTestBackend(t, "test_sides")
+
+	add := func(id string, side core.Side, price float64) *core.Order {
+		order, err := core.NewLimitOrder(id, side, fpdecimal.FromFloat(1.0), fpdecimal.FromFloat(price), core.GTC, "", "test_user")
+		require.NoError(t, err)
+		require.NoError(t, backend.StoreOrder(order))
+		backend.AppendToSide(side, order)
+		return order
+	}
+	add("bid1", core.Buy, 99.0)
+	bid2 := add("bid2", core.Buy, 100.0)
+	add("bid3", core.Buy, 100.0)
+	add("ask1", core.Sell, 101.0)
+	add("ask2", core.Sell, 102.0)
+
+	// A second append leaves the order at its place in the queue
+	backend.AppendToSide(core.Buy, bid2)
+
+	bids := backend.GetBids().(*PostgresSide)
+	assert.Equal(t, []fpdecimal.Decimal{fpdecimal.FromFloat(100.0), fpdecimal.FromFloat(99.0)}, bids.Prices())
+	assert.Equal(t, []fpdecimal.Decimal{fpdecimal.FromFloat(100.0)}, bids.TopPrices(1))
+	level := bids.Orders(fpdecimal.FromFloat(100.0))
+	require.Len(t, level, 2)
+	assert.Equal(t, "bid2", level[0].ID())
+	assert.Equal(t, "bid3", level[1].ID())
+
+	asks := backend.GetAsks().(*PostgresSide)
+	assert.Equal(t, []fpdecimal.Decimal{fpdecimal.FromFloat(101.0), fpdecimal.FromFloat(102.0)}, asks.Prices())
+	assert.Equal(t, 5, backend.OrderCount())
+	assert.Len(t, backend.GetAllOrders(), 5)
+
+	assert.True(t, backend.RemoveFromSide(core.Buy, bid2))
+	assert.False(t, backend.RemoveFromSide(core.Buy, bid2))
+	level = bids.Orders(fpdecimal.FromFloat(100.0))
+	require.Len(t, level, 1)
+	assert.Equal(t, "bid3", level[0].ID())
+
+	// Removed entries stay until purged
+	purged, err := backend.PurgeRemoved(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
+
+func TestPostgresBackendStopBook(t *testing.T) {
+	backend := setupTestBackend(t, "test_stops")
+
+	order, err := core.NewStopLimitOrder("stop1", core.Sell, fpdecimal.FromFloat(1.0), fpdecimal.FromFloat(95.0), fpdecimal.FromFloat(96.0), "", "test_user")
+	require.NoError(t, err)
+	require.NoError(t, backend.StoreOrder(order))
+	backend.AppendToStopBook(order)
+
+	stopBook := backend.GetStopBook().(*PostgresStopBook)
+	assert.Empty(t, stopBook.BuyOrders())
+	require.Len(t, stopBook.SellOrders(), 1)
+	assert.Equal(t, []fpdecimal.Decimal{fpdecimal.FromFloat(96.0)}, stopBook.Prices())
+	assert.Len(t, stopBook.Orders(fpdecimal.FromFloat(96.0)), 1)
+
+	assert.True(t, backend.RemoveFromStopBook(order))
+	assert.Empty(t, stopBook.SellOrders())
+}
+
+func TestPostgresBackendOrderBook(t *testing.T) {
+	backend := setupTestBackend(t, "test_book")
+	book := core.NewOrderBook(backend)
+
+	sell, err := core.NewLimitOrder("sell1", core.Sell, fpdecimal.FromFloat(5.0), fpdecimal.FromFloat(100.0), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(context.Background(), sell)
+	require.NoError(t, err)
+
+	buy, err := core.NewLimitOrder("buy1", core.Buy, fpdecimal.FromFloat(2.0), fpdecimal.FromFloat(100.0), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	done, err := book.Process(context.Background(), buy)
+	require.NoError(t, err)
+	assert.Len(t, done.Trades, 2)
+
+	resting := backend.GetOrder("sell1")
+	require.NotNil(t, resting)
+	assert.Equal(t, fpdecimal.FromFloat(3.0), resting.Quantity())
+}
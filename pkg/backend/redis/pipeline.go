@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/otel"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// PipelineConfig controls when a PipelinePool flushes the commands queued
+// on it
+type PipelineConfig struct {
+	// MaxCmds is how many queued commands trigger a flush. One or less
+	// flushes every command as soon as it is queued.
+	MaxCmds int
+	// MaxAge is the longest a queued command waits before it is flushed.
+	// Zero leaves commands queued until MaxCmds is reached or the pool is
+	// flushed explicitly.
+	MaxAge time.Duration
+}
+
+// PipelineStats counts the round trips a PipelinePool has made
+type PipelineStats struct {
+	// FlushCount is how many pipelines were executed
+	FlushCount uint64
+	// CmdCount is how many commands those pipelines held
+	CmdCount        uint64
+	AvgCmdsPerFlush float64
+}
+
+// PipelinePool hands out reusable pipelines and holds a shared pipeline of
+// queued writes, which it flushes once MaxCmds commands are queued or the
+// oldest has waited MaxAge. Queued writes are not visible to readers until
+// they are flushed, so callers flush before reading.
+type PipelinePool struct {
+	client *redis.Client
+	logger *zap.Logger
+	// prefix labels the pool's metrics
+	prefix string
+	pool   sync.Pool
+	// scripts are the scripts queued writes run by SHA1, by SHA1
+	scripts map[string]*redis.Script
+
+	mu      sync.Mutex
+	config  PipelineConfig
+	pending redis.Pipeliner
+	// timer flushes pending MaxAge after its first command was queued
+	timer *time.Timer
+	// failed holds the errors of queued writes flushed other than by
+	// Flush, until Flush returns them
+	failed error
+
+	flushes atomic.Uint64
+	cmds    atomic.Uint64
+}
+
+// NewPipelinePool creates a PipelinePool on client that flushes every
+// queued command at once until SetConfig says otherwise. Queued writes may
+// run scripts by SHA1 with EvalSha; a script Redis reports missing is loaded
+// again and the writes that ran it are sent once more.
+func NewPipelinePool(client *redis.Client, prefix string, logger *zap.Logger, scripts ...*redis.Script) *PipelinePool {
+	p := &PipelinePool{
+		client:  client,
+		logger:  logger,
+		prefix:  prefix,
+		scripts: make(map[string]*redis.Script, len(scripts)),
+	}
+	for _, script := range scripts {
+		p.scripts[script.Hash()] = script
+	}
+	p.pool.New = func() any { return client.Pipeline() }
+	return p
+}
+
+// SetConfig changes when queued commands are flushed. Commands already
+// queued are flushed first, and their errors returned as Flush does.
+func (p *PipelinePool) SetConfig(ctx context.Context, config PipelineConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	err := p.flushAllLocked(ctx)
+	p.config = config
+	return err
+}
+
+// Get returns an empty pipeline for the caller's own commands. Hand it back
+// with Exec, or with Put if it was not executed.
+func (p *PipelinePool) Get() redis.Pipeliner {
+	return p.pool.Get().(redis.Pipeliner)
+}
+
+// Put returns pipe to the pool, discarding any commands left on it
+func (p *PipelinePool) Put(pipe redis.Pipeliner) {
+	pipe.Discard()
+	p.pool.Put(pipe)
+}
+
+// Exec executes pipe in one round trip, counts it, and returns it to the
+// pool. The commands returned keep their results after pipe is reused.
+func (p *PipelinePool) Exec(ctx context.Context, pipe redis.Pipeliner) ([]redis.Cmder, error) {
+	n := pipe.Len()
+	cmds, err := pipe.Exec(ctx)
+	p.record(ctx, n)
+	p.Put(pipe)
+	return cmds, err
+}
+
+// Queue adds the commands queue writes to the shared pipeline, flushing it
+// when it reaches MaxCmds. The commands' results are only known once they
+// are flushed: failures are logged then and returned by the next Flush.
+func (p *PipelinePool) Queue(ctx context.Context, queue func(pipe redis.Pipeliner)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending == nil {
+		p.pending = p.Get()
+	}
+	queue(p.pending)
+
+	if p.pending.Len() >= p.config.MaxCmds {
+		p.flushQueuedLocked(ctx)
+		return
+	}
+	if p.timer == nil && p.config.MaxAge > 0 {
+		p.timer = time.AfterFunc(p.config.MaxAge, func() {
+			p.FlushQueued(context.Background())
+		})
+	}
+}
+
+// Flush executes the queued commands and returns their errors, along with
+// those of queued commands flushed since the last Flush
+func (p *PipelinePool) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.flushAllLocked(ctx)
+}
+
+// FlushQueued executes the queued commands like Flush, but keeps their
+// errors for the next Flush to return. Readers use it to see the writes
+// queued before them.
+func (p *PipelinePool) FlushQueued(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flushQueuedLocked(ctx)
+}
+
+// flushAllLocked is Flush for callers holding p.mu
+func (p *PipelinePool) flushAllLocked(ctx context.Context) error {
+	err := errors.Join(p.failed, p.flushLocked(ctx))
+	p.failed = nil
+	return err
+}
+
+// flushQueuedLocked is FlushQueued for callers holding p.mu
+func (p *PipelinePool) flushQueuedLocked(ctx context.Context) {
+	if err := p.flushLocked(ctx); err != nil {
+		p.failed = errors.Join(p.failed, err)
+	}
+}
+
+// flushLocked executes the queued commands and returns their errors. The
+// caller holds p.mu.
+func (p *PipelinePool) flushLocked(ctx context.Context) error {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if p.pending == nil {
+		return nil
+	}
+
+	pipe := p.pending
+	p.pending = nil
+	cmds, _ := p.Exec(ctx, pipe)
+	cmds = p.retryMissingScripts(ctx, cmds)
+
+	var errs []error
+	for _, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil {
+			p.logger.Error("failed to execute queued command",
+				zap.String("command", cmd.Name()),
+				zap.Error(cmdErr))
+			errs = append(errs, cmdErr)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retryMissingScripts loads the scripts Redis reported missing for cmds,
+// for example after SCRIPT FLUSH or a restart, and sends the commands that
+// ran them again in one more round trip. It returns cmds with those
+// commands replaced by their second attempt. The commands sent again run
+// after the rest of the pipeline, which queued writes allow since each
+// touches keys of its own.
+func (p *PipelinePool) retryMissingScripts(ctx context.Context, cmds []redis.Cmder) []redis.Cmder {
+	var retry []int
+	loaded := make(map[string]bool)
+	for i, cmd := range cmds {
+		if !redis.HasErrorPrefix(cmd.Err(), "NOSCRIPT") {
+			continue
+		}
+		sha, _ := cmd.Args()[1].(string)
+		script, ok := p.scripts[sha]
+		if !ok {
+			continue
+		}
+		if !loaded[sha] {
+			if err := script.Load(ctx, p.client).Err(); err != nil {
+				p.logger.Error("failed to reload script", zap.String("sha", sha), zap.Error(err))
+				continue
+			}
+			loaded[sha] = true
+		}
+		retry = append(retry, i)
+	}
+	if len(retry) == 0 {
+		return cmds
+	}
+
+	pipe := p.Get()
+	for _, i := range retry {
+		pipe.Do(ctx, cmds[i].Args()...)
+	}
+	retried, _ := p.Exec(ctx, pipe)
+	for j, i := range retry {
+		cmds[i] = retried[j]
+	}
+	return cmds
+}
+
+// record counts one round trip carrying n commands
+func (p *PipelinePool) record(ctx context.Context, n int) {
+	if n == 0 {
+		return
+	}
+	p.flushes.Add(1)
+	p.cmds.Add(uint64(n))
+	otel.GetOrderBookMetrics().RecordRedisPipelineFlush(ctx, p.prefix, int64(n))
+}
+
+// Stats returns the round trips made by the pool so far
+func (p *PipelinePool) Stats() PipelineStats {
+	stats := PipelineStats{
+		FlushCount: p.flushes.Load(),
+		CmdCount:   p.cmds.Load(),
+	}
+	if stats.FlushCount > 0 {
+		stats.AvgCmdsPerFlush = float64(stats.CmdCount) / float64(stats.FlushCount)
+	}
+	return stats
+}
@@ -3,12 +3,17 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/erain9/matchingo/pkg/core"
+	"github.com/google/uuid"
 	"github.com/nikolaydubina/fpdecimal"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
@@ -41,6 +46,128 @@ func GetRedisClient() *redis.Client {
 	})
 }
 
+var (
+	// ErrPrefixConflict is returned when another backend already uses an order prefix
+	ErrPrefixConflict = errors.New("order prefix is already in use by another backend")
+	// ErrInvalidPrefix is returned for order prefixes that are not 1 to 64
+	// letters, digits, underscores or hyphens
+	ErrInvalidPrefix = errors.New("order prefix must be 1 to 64 letters, digits, underscores or hyphens")
+)
+
+var prefixPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// PrefixLockTTL is how long a backend's prefix lock outlives a process that
+// died without releasing it. The lease is renewed every third of it while
+// the backend is open.
+var PrefixLockTTL = 30 * time.Second
+
+// activePrefixes holds the lock token of every prefix locked by a backend in
+// this process, keyed by prefixRegistryKey
+var activePrefixes sync.Map
+
+// releaseLockScript deletes a prefix lock only if it still holds the caller's token
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renewLockScript extends a prefix lock's lease only if it still holds the
+// caller's token. KEYS: lock. ARGV: token, lease in milliseconds.
+var renewLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// appendToSideScript adds a price to a side's sorted set and an order ID to
+// the price level's set in one step, so no reader sees a price without orders.
+// KEYS: side, price level. ARGV: score, price, order ID.
+var appendToSideScript = redis.NewScript(`
+redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+return redis.call("SADD", KEYS[2], ARGV[3])
+`)
+
+// removeFromSideScript removes an order ID from its price level and, if the
+// level is left empty, removes the level and its price in the same step.
+// KEYS: side, price level. ARGV: price, order ID.
+var removeFromSideScript = redis.NewScript(`
+local removed = redis.call("SREM", KEYS[2], ARGV[2])
+if redis.call("SCARD", KEYS[2]) == 0 then
+	redis.call("ZREM", KEYS[1], ARGV[1])
+	redis.call("DEL", KEYS[2])
+end
+return removed
+`)
+
+// fillOrderScript fills up to a quantity of a resting order: it reads the
+// stored quantity, decreases it by what can be filled, updates the order's
+// state and takes the order off its price level once no shown quantity is
+// left. Running as one script, two matchers can never both fill the same
+// quantity. It returns the quantity filled and the quantity left, scaled by
+// 10^digits like the quantity passed.
+// KEYS: order, side, price level. ARGV: quantity, price, order ID, digits.
+var fillOrderScript = redis.NewScript(`
+local data = redis.call("GET", KEYS[1])
+if not data then
+	return {0, 0}
+end
+local order = cjson.decode(data)
+if order.state ~= "OPEN" and order.state ~= "PARTIALLY_FILLED" then
+	return {0, 0}
+end
+
+local digits = tonumber(ARGV[4])
+local unit = 10 ^ digits
+local function scaled(value)
+	if type(value) ~= "string" or value == "" then
+		return 0
+	end
+	local int, frac = string.match(value, "^(-?%d*)%.?(%d*)$")
+	if not int then
+		return 0
+	end
+	local sign = 1
+	if string.sub(int, 1, 1) == "-" then
+		sign = -1
+		int = string.sub(int, 2)
+	end
+	frac = string.sub(frac .. string.rep("0", digits), 1, digits)
+	return sign * ((tonumber(int) or 0) * unit + (tonumber(frac) or 0))
+end
+local function decimal(value)
+	return string.format("%d.%0" .. digits .. "d", math.floor(value / unit), value % unit)
+end
+
+local available = scaled(order.quantity)
+local matched = math.min(tonumber(ARGV[1]), available)
+if matched <= 0 then
+	return {0, math.max(available, 0)}
+end
+local remaining = available - matched
+order.quantity = decimal(remaining)
+if remaining + scaled(order.hidden) <= 0 then
+	order.state = "FILLED"
+else
+	order.state = "PARTIALLY_FILLED"
+end
+redis.call("SET", KEYS[1], cjson.encode(order))
+
+if remaining <= 0 then
+	redis.call("SREM", KEYS[3], ARGV[3])
+	if redis.call("SCARD", KEYS[3]) == 0 then
+		redis.call("ZREM", KEYS[2], ARGV[2])
+		redis.call("DEL", KEYS[3])
+	end
+end
+return {matched, remaining}
+`)
+
+// sideScripts are loaded into Redis when a backend is created
+var sideScripts = []*redis.Script{appendToSideScript, removeFromSideScript, fillOrderScript}
+
 // RedisBackend implements OrderBookBackend interface with Redis storage
 type RedisBackend struct {
 	sync.RWMutex
@@ -51,13 +178,33 @@ type RedisBackend struct {
 	asksKey     string
 	stopBuyKey  string
 	stopSellKey string
-	ocoKey      string
+	// midBuyKey and midSellKey are lists of the resting midpoint orders'
+	// IDs, oldest first
+	midBuyKey  string
+	midSellKey string
+	ocoKey     string
+	lockKey    string
+	lockToken  string
+	// lockStop ends the renewal of the prefix lock, which closes
+	// lockRenewed once it has stopped
+	lockStop    chan struct{}
+	lockRenewed chan struct{}
 	logger      *zap.Logger
+	// pipelines reuses pipelines and queues writes that need no reply
+	pipelines *PipelinePool
 }
 
-// NewRedisBackend creates a new instance of RedisBackend
-func NewRedisBackend(client *redis.Client, orderPrefix string, logger *zap.Logger) *RedisBackend {
-	return &RedisBackend{
+// NewRedisBackend creates a new instance of RedisBackend and locks
+// orderPrefix for it. It returns ErrInvalidPrefix if orderPrefix is malformed
+// and ErrPrefixConflict if another backend, in this process or any other,
+// holds the prefix on the same Redis server. The lock is released by Close or
+// Release.
+func NewRedisBackend(client *redis.Client, orderPrefix string, logger *zap.Logger) (*RedisBackend, error) {
+	if !prefixPattern.MatchString(orderPrefix) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPrefix, orderPrefix)
+	}
+
+	b := &RedisBackend{
 		client:      client,
 		ctx:         context.Background(),
 		orderPrefix: orderPrefix,
@@ -65,15 +212,134 @@ func NewRedisBackend(client *redis.Client, orderPrefix string, logger *zap.Logge
 		asksKey:     fmt.Sprintf("%s:asks", orderPrefix),
 		stopBuyKey:  fmt.Sprintf("%s:stop:buy", orderPrefix),
 		stopSellKey: fmt.Sprintf("%s:stop:sell", orderPrefix),
+		midBuyKey:   fmt.Sprintf("%s:midpoint:buy", orderPrefix),
+		midSellKey:  fmt.Sprintf("%s:midpoint:sell", orderPrefix),
 		ocoKey:      fmt.Sprintf("%s:oco", orderPrefix),
+		lockKey:     fmt.Sprintf("%s:lock", orderPrefix),
+		lockToken:   uuid.NewString(),
 		logger:      logger,
+		pipelines:   NewPipelinePool(client, orderPrefix, logger, sideScripts...),
+	}
+	if err := b.lockPrefix(); err != nil {
+		return nil, err
+	}
+
+	// Scripts are run by SHA1; one that Redis has since dropped, for example
+	// after SCRIPT FLUSH or a restart, is sent again on its NOSCRIPT error
+	for _, script := range sideScripts {
+		if err := script.Load(b.ctx, client).Err(); err != nil {
+			b.Release()
+			return nil, fmt.Errorf("failed to load side scripts: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// prefixRegistryKey identifies the backend's prefix on its Redis server
+func (b *RedisBackend) prefixRegistryKey() string {
+	opts := b.client.Options()
+	return fmt.Sprintf("%s/%d/%s", opts.Addr, opts.DB, b.orderPrefix)
+}
+
+// lockPrefix claims the backend's prefix among the backends of this process,
+// then on the Redis server for backends in other processes. The Redis lock
+// is a lease of PrefixLockTTL, renewed until the backend is released, so
+// one left behind by a process that died expires on its own.
+func (b *RedisBackend) lockPrefix() error {
+	registryKey := b.prefixRegistryKey()
+	if _, loaded := activePrefixes.LoadOrStore(registryKey, b.lockToken); loaded {
+		return fmt.Errorf("%w: %s", ErrPrefixConflict, b.orderPrefix)
+	}
+
+	ttl := PrefixLockTTL
+	locked, err := b.client.SetNX(b.ctx, b.lockKey, b.lockToken, ttl).Result()
+	if err != nil {
+		activePrefixes.Delete(registryKey)
+		return fmt.Errorf("failed to lock order prefix %s: %w", b.orderPrefix, err)
+	}
+	if !locked {
+		activePrefixes.Delete(registryKey)
+		return fmt.Errorf("%w: %s", ErrPrefixConflict, b.orderPrefix)
+	}
+
+	b.lockStop = make(chan struct{})
+	b.lockRenewed = make(chan struct{})
+	go b.renewPrefixLock(ttl)
+	return nil
+}
+
+// renewPrefixLock extends the lease on the prefix lock every third of ttl
+// until lockStop is closed or the lock is lost. A failed renewal is retried
+// at the next tick.
+func (b *RedisBackend) renewPrefixLock(ttl time.Duration) {
+	defer close(b.lockRenewed)
+
+	interval := ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.lockStop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			renewed, err := renewLockScript.Run(ctx, b.client, []string{b.lockKey}, b.lockToken, ttl.Milliseconds()).Int()
+			cancel()
+			if err != nil {
+				b.logger.Warn("failed to renew order prefix lock", zap.String("prefix", b.orderPrefix), zap.Error(err))
+				continue
+			}
+			if renewed == 0 {
+				b.logger.Error("order prefix lock lost", zap.String("prefix", b.orderPrefix))
+				return
+			}
+		}
 	}
 }
 
+// Release unlocks the backend's prefix so a new backend can use it, without
+// closing the Redis client. Calling it again is a no-op.
+func (b *RedisBackend) Release() error {
+	if !activePrefixes.CompareAndDelete(b.prefixRegistryKey(), b.lockToken) {
+		return nil
+	}
+	close(b.lockStop)
+	<-b.lockRenewed
+	return releaseLockScript.Run(b.ctx, b.client, []string{b.lockKey}, b.lockToken).Err()
+}
+
+// SetPipelineConfig sets when the writes the backend queues are sent to
+// Redis. By default each is sent at once; a larger MaxCmds batches appends to
+// the sides and stop book into fewer round trips. The backend sends what is
+// queued before every read, so it always reads its own writes, but other
+// Redis clients may see them up to MaxAge late.
+func (b *RedisBackend) SetPipelineConfig(config PipelineConfig) error {
+	return b.pipelines.SetConfig(b.ctx, config)
+}
+
+// PipelineStats returns the pipelines the backend has executed so far
+func (b *RedisBackend) PipelineStats() PipelineStats {
+	return b.pipelines.Stats()
+}
+
+// Flush sends the queued writes to Redis and returns the errors of every
+// queued write sent since the last Flush, including those sent before reads
+// or by the pipeline's own MaxCmds and MaxAge
+func (b *RedisBackend) Flush(ctx context.Context) error {
+	return b.pipelines.Flush(ctx)
+}
+
+// flushPending sends the queued writes before a read. Failures are logged
+// by the pool and returned by the next Flush.
+func (b *RedisBackend) flushPending() {
+	b.pipelines.FlushQueued(b.ctx)
+}
+
 // GetOrder retrieves an order from Redis by its ID
 func (b *RedisBackend) GetOrder(orderID string) *core.Order {
 	b.RLock()
 	defer b.RUnlock()
+	b.flushPending()
 
 	key := b.getOrderKey(orderID)
 	data, err := b.client.Get(b.ctx, key).Bytes()
@@ -97,8 +363,51 @@ func (b *RedisBackend) GetOrder(orderID string) *core.Order {
 	return &order
 }
 
+// GetOrders retrieves the orders with the given IDs in one pipelined round
+// trip. The result lines up with orderIDs, holding nil for orders that do not
+// exist or cannot be read.
+func (b *RedisBackend) GetOrders(orderIDs []string) []*core.Order {
+	b.RLock()
+	defer b.RUnlock()
+
+	orders := make([]*core.Order, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return orders
+	}
+	b.flushPending()
+
+	pipe := b.pipelines.Get()
+	gets := make([]*redis.StringCmd, len(orderIDs))
+	for i, orderID := range orderIDs {
+		gets[i] = pipe.Get(b.ctx, b.getOrderKey(orderID))
+	}
+	// Exec reports redis.Nil when any key is missing; each command keeps its own error
+	if _, err := b.pipelines.Exec(b.ctx, pipe); err != nil && err != redis.Nil {
+		b.logger.Error("failed to get orders", zap.Error(err))
+		return orders
+	}
+
+	for i, cmd := range gets {
+		data, err := cmd.Bytes()
+		if err != nil {
+			continue
+		}
+		var order core.Order
+		if err := json.Unmarshal(data, &order); err != nil {
+			b.logger.Error("failed to unmarshal order",
+				zap.String("orderID", orderIDs[i]),
+				zap.Error(err))
+			continue
+		}
+		orders[i] = &order
+	}
+	return orders
+}
+
 // StoreOrder stores an order in Redis
 func (b *RedisBackend) StoreOrder(order *core.Order) error {
+	b.flushPending()
+
 	// Check if order exists
 	key := b.getOrderKey(order.ID())
 	exists, err := b.client.Exists(b.ctx, key).Result()
@@ -123,10 +432,10 @@ func (b *RedisBackend) StoreOrder(order *core.Order) error {
 
 	// Store OCO mapping if exists
 	if oco := order.OCO(); oco != "" {
-		pipe := b.client.Pipeline()
+		pipe := b.pipelines.Get()
 		pipe.HSet(b.ctx, b.ocoKey, order.ID(), oco)
 		pipe.HSet(b.ctx, b.ocoKey, oco, order.ID())
-		_, err = pipe.Exec(b.ctx)
+		_, err = b.pipelines.Exec(b.ctx, pipe)
 		if err != nil {
 			return err
 		}
@@ -137,6 +446,8 @@ func (b *RedisBackend) StoreOrder(order *core.Order) error {
 
 // UpdateOrder updates an existing order in Redis
 func (b *RedisBackend) UpdateOrder(order *core.Order) error {
+	b.flushPending()
+
 	// Check if order exists
 	key := b.getOrderKey(order.ID())
 	exists, err := b.client.Exists(b.ctx, key).Result()
@@ -167,10 +478,10 @@ func (b *RedisBackend) DeleteOrder(orderID string) {
 
 	// Clean up OCO references
 	if oco := order.OCO(); oco != "" {
-		pipe := b.client.Pipeline()
+		pipe := b.pipelines.Get()
 		pipe.HDel(b.ctx, b.ocoKey, orderID)
 		pipe.HDel(b.ctx, b.ocoKey, oco)
-		pipe.Exec(b.ctx)
+		b.pipelines.Exec(b.ctx, pipe)
 	}
 
 	// Delete order
@@ -183,67 +494,66 @@ func (b *RedisBackend) AppendToSide(side core.Side, order *core.Order) {
 	b.Lock()
 	defer b.Unlock()
 
-	pipe := b.client.Pipeline()
 	sideKey := b.getSideKey(side)
-	priceKey := fmt.Sprintf("%s:%s", sideKey, order.Price().String())
+	price := order.Price().String()
+	priceKey := fmt.Sprintf("%s:%s", sideKey, price)
+	score := strconv.FormatFloat(order.Price().Float64(), 'f', -1, 64)
 
-	// Add to sorted set with score as price
-	pipe.ZAdd(b.ctx, sideKey, redis.Z{
-		Score:  order.Price().Float64(),
-		Member: order.Price().String(),
+	// The pool loads the script again if Redis has dropped it
+	b.pipelines.Queue(b.ctx, func(pipe redis.Pipeliner) {
+		appendToSideScript.EvalSha(b.ctx, pipe, []string{sideKey, priceKey}, score, price, order.ID())
 	})
-
-	// Add order ID to the set at this price level
-	pipe.SAdd(b.ctx, priceKey, order.ID())
-
-	// Execute pipeline
-	if _, err := pipe.Exec(b.ctx); err != nil {
-		b.logger.Error("failed to execute pipeline",
-			zap.String("order_id", order.ID()),
-			zap.Error(err))
-		return
-	}
 }
 
 // RemoveFromSide removes an order from the specified side of the order book
 func (b *RedisBackend) RemoveFromSide(side core.Side, order *core.Order) bool {
 	b.Lock()
 	defer b.Unlock()
+	b.flushPending()
 
-	pipe := b.client.Pipeline()
 	sideKey := b.getSideKey(side)
-	priceKey := fmt.Sprintf("%s:%s", sideKey, order.Price().String())
+	price := order.Price().String()
+	priceKey := fmt.Sprintf("%s:%s", sideKey, price)
 
-	// Remove order from price level set
-	pipe.SRem(b.ctx, priceKey, order.ID())
-
-	// Check if price level is empty after removal
-	pipe.SCard(b.ctx, priceKey).Result()
-
-	// Execute pipeline
-	cmders, err := pipe.Exec(b.ctx)
+	err := removeFromSideScript.Run(b.ctx, b.client, []string{sideKey, priceKey}, price, order.ID()).Err()
 	if err != nil {
-		b.logger.Error("failed to execute pipeline",
+		b.logger.Error("failed to remove order from side",
 			zap.String("orderID", order.ID()),
 			zap.String("side", side.String()),
 			zap.Error(err))
 		return false
 	}
 
-	// If price level is empty, remove it from sorted set and delete the set
-	if cmders[1].(*redis.IntCmd).Val() == 0 {
-		pipe.ZRem(b.ctx, sideKey, order.Price().String())
-		pipe.Del(b.ctx, priceKey)
-		if _, err := pipe.Exec(b.ctx); err != nil {
-			b.logger.Error("failed to clean up empty price level",
-				zap.String("orderID", order.ID()),
-				zap.Error(err))
-		}
-	}
-
 	return true
 }
 
+// FillOrder fills up to quantity of a resting order in one atomic step and
+// returns the quantity filled and the shown quantity the stored order has
+// left. The fill is less than quantity, or zero, when the stored order has
+// less left, for instance because it was filled or canceled since it was
+// read. An order with no shown quantity left is removed from its side; the
+// order itself stays stored until DeleteOrder.
+func (b *RedisBackend) FillOrder(order *core.Order, quantity fpdecimal.Decimal) (filled, remaining fpdecimal.Decimal, err error) {
+	b.Lock()
+	defer b.Unlock()
+	b.flushPending()
+
+	sideKey := b.getSideKey(order.Side())
+	price := order.Price().String()
+	priceKey := fmt.Sprintf("%s:%s", sideKey, price)
+
+	keys := []string{b.getOrderKey(order.ID()), sideKey, priceKey}
+	result, err := fillOrderScript.Run(b.ctx, b.client, keys,
+		quantity.Scaled(), price, order.ID(), fpdecimal.FractionDigits).Int64Slice()
+	if err != nil {
+		return fpdecimal.Zero, fpdecimal.Zero, fmt.Errorf("failed to fill order %s: %w", order.ID(), err)
+	}
+	if len(result) != 2 {
+		return fpdecimal.Zero, fpdecimal.Zero, fmt.Errorf("failed to fill order %s: unexpected reply %v", order.ID(), result)
+	}
+	return fpdecimal.FromIntScaled(result[0]), fpdecimal.FromIntScaled(result[1]), nil
+}
+
 // AppendToStopBook adds a stop order to the stop book
 func (b *RedisBackend) AppendToStopBook(order *core.Order) {
 	b.Lock()
@@ -257,24 +567,18 @@ func (b *RedisBackend) AppendToStopBook(order *core.Order) {
 		stopKey = b.stopSellKey
 	}
 
-	pipe := b.client.Pipeline()
 	priceKey := fmt.Sprintf("%s:%s", stopKey, order.StopPrice().String())
 
-	// Add to sorted set with score as stop price
-	pipe.ZAdd(b.ctx, stopKey, redis.Z{
-		Score:  order.StopPrice().Float64(),
-		Member: order.StopPrice().String(),
-	})
-
-	// Add order ID to the set at this price level
-	pipe.SAdd(b.ctx, priceKey, order.ID())
+	b.pipelines.Queue(b.ctx, func(pipe redis.Pipeliner) {
+		// Add to sorted set with score as stop price
+		pipe.ZAdd(b.ctx, stopKey, redis.Z{
+			Score:  order.StopPrice().Float64(),
+			Member: order.StopPrice().String(),
+		})
 
-	// Execute pipeline
-	if _, err := pipe.Exec(b.ctx); err != nil {
-		b.logger.Error("failed to execute pipeline",
-			zap.String("order_id", order.ID()),
-			zap.Error(err))
-	}
+		// Add order ID to the set at this price level
+		pipe.SAdd(b.ctx, priceKey, order.ID())
+	})
 }
 
 // RemoveFromStopBook removes a stop order from the stop book
@@ -290,7 +594,8 @@ func (b *RedisBackend) RemoveFromStopBook(order *core.Order) bool {
 		stopKey = b.stopSellKey
 	}
 
-	pipe := b.client.Pipeline()
+	b.flushPending()
+	pipe := b.pipelines.Get()
 	priceKey := fmt.Sprintf("%s:%s", stopKey, order.StopPrice().String())
 
 	// Remove order from price level set
@@ -300,7 +605,7 @@ func (b *RedisBackend) RemoveFromStopBook(order *core.Order) bool {
 	pipe.SCard(b.ctx, priceKey).Result()
 
 	// Execute pipeline
-	cmders, err := pipe.Exec(b.ctx)
+	cmders, err := b.pipelines.Exec(b.ctx, pipe)
 	if err != nil {
 		b.logger.Error("failed to execute pipeline",
 			zap.String("orderID", order.ID()),
@@ -310,9 +615,10 @@ func (b *RedisBackend) RemoveFromStopBook(order *core.Order) bool {
 
 	// If price level is empty, remove it from sorted set and delete the set
 	if cmders[1].(*redis.IntCmd).Val() == 0 {
+		pipe := b.pipelines.Get()
 		pipe.ZRem(b.ctx, stopKey, order.StopPrice().String())
 		pipe.Del(b.ctx, priceKey)
-		if _, err := pipe.Exec(b.ctx); err != nil {
+		if _, err := b.pipelines.Exec(b.ctx, pipe); err != nil {
 			b.logger.Error("failed to clean up empty price level",
 				zap.String("orderID", order.ID()),
 				zap.Error(err))
@@ -322,6 +628,53 @@ func (b *RedisBackend) RemoveFromStopBook(order *core.Order) bool {
 	return true
 }
 
+// AppendToMidpoint adds a midpoint order to the back of its side's queue
+func (b *RedisBackend) AppendToMidpoint(order *core.Order) {
+	b.Lock()
+	defer b.Unlock()
+
+	key := b.getMidpointKey(order.Side())
+	b.pipelines.Queue(b.ctx, func(pipe redis.Pipeliner) {
+		pipe.RPush(b.ctx, key, order.ID())
+	})
+}
+
+// RemoveFromMidpoint removes a midpoint order from its side's queue. It
+// reports whether the order was queued.
+func (b *RedisBackend) RemoveFromMidpoint(order *core.Order) bool {
+	b.Lock()
+	defer b.Unlock()
+	b.flushPending()
+
+	removed, err := b.client.LRem(b.ctx, b.getMidpointKey(order.Side()), 1, order.ID()).Result()
+	if err != nil {
+		b.logger.Error("failed to remove midpoint order",
+			zap.String("orderID", order.ID()),
+			zap.Error(err))
+		return false
+	}
+	return removed > 0
+}
+
+// GetMidpointOrders returns the midpoint orders resting on side, oldest
+// first, decoded fresh from Redis. Orders deleted since their ID was read
+// are skipped.
+func (b *RedisBackend) GetMidpointOrders(side core.Side) []*core.Order {
+	b.RLock()
+	b.flushPending()
+	orderIDs, err := b.client.LRange(b.ctx, b.getMidpointKey(side), 0, -1).Result()
+	b.RUnlock()
+	if err != nil {
+		b.logger.Error("failed to read midpoint orders",
+			zap.String("side", side.String()),
+			zap.Error(err))
+		return nil
+	}
+
+	orders := b.GetOrders(orderIDs)
+	return slices.DeleteFunc(orders, func(order *core.Order) bool { return order == nil })
+}
+
 // CheckOCO checks and returns any OCO (One Cancels Other) orders in Redis
 func (b *RedisBackend) CheckOCO(orderID string) string {
 	order := b.GetOrder(orderID)
@@ -360,9 +713,156 @@ func (b *RedisBackend) GetStopBook() interface{} {
 	}
 }
 
+// scanCount is the COUNT hint given to each SCAN call
+const scanCount = 100
+
+// GetAllOrders returns every bid, ask, stop and midpoint order, in no
+// particular order. Price level sets are found with SCAN and read with SSCAN,
+// the midpoint queues are read with LRANGE, and every order is then read with
+// a single MGET. The backend stays locked throughout, so writes made through it
+// cannot interleave with the read; orders deleted by another process between
+// the scan and the MGET are skipped.
+func (b *RedisBackend) GetAllOrders() []*core.Order {
+	b.RLock()
+	defer b.RUnlock()
+	b.flushPending()
+
+	_, orderKeys, err := b.scanBook(b.ctx)
+	if err != nil {
+		b.logger.Error("failed to list orders", zap.Error(err))
+		return nil
+	}
+	if len(orderKeys) == 0 {
+		return []*core.Order{}
+	}
+
+	values, err := b.client.MGet(b.ctx, orderKeys...).Result()
+	if err != nil {
+		b.logger.Error("failed to read orders", zap.Error(err))
+		return nil
+	}
+
+	orders := make([]*core.Order, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			// Deleted since its price level was read
+			continue
+		}
+		var order core.Order
+		if err := json.Unmarshal([]byte(data), &order); err != nil {
+			b.logger.Error("failed to unmarshal order",
+				zap.String("key", orderKeys[i]),
+				zap.Error(err))
+			continue
+		}
+		orders = append(orders, &order)
+	}
+	return orders
+}
+
+// scanBook returns the keys of the book's price level sets and of the orders
+// resting in them or in the midpoint queues, each order once
+func (b *RedisBackend) scanBook(ctx context.Context) (levelKeys, orderKeys []string, err error) {
+	for _, key := range []string{b.bidsKey, b.asksKey, b.stopBuyKey, b.stopSellKey} {
+		iter := b.client.Scan(ctx, 0, key+":*", scanCount).Iterator()
+		for iter.Next(ctx) {
+			levelKeys = append(levelKeys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan price levels of %s: %w", key, err)
+		}
+	}
+
+	seen := make(map[string]struct{})
+	for _, levelKey := range levelKeys {
+		iter := b.client.SScan(ctx, levelKey, 0, "", scanCount).Iterator()
+		for iter.Next(ctx) {
+			orderID := iter.Val()
+			if _, ok := seen[orderID]; ok {
+				continue
+			}
+			seen[orderID] = struct{}{}
+			orderKeys = append(orderKeys, b.getOrderKey(orderID))
+		}
+		if err := iter.Err(); err != nil {
+			return nil, nil, fmt.Errorf("failed to read price level %s: %w", levelKey, err)
+		}
+	}
+	for _, key := range []string{b.midBuyKey, b.midSellKey} {
+		orderIDs, err := b.client.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read midpoint queue %s: %w", key, err)
+		}
+		for _, orderID := range orderIDs {
+			if _, ok := seen[orderID]; ok {
+				continue
+			}
+			seen[orderID] = struct{}{}
+			orderKeys = append(orderKeys, b.getOrderKey(orderID))
+		}
+	}
+	return levelKeys, orderKeys, nil
+}
+
+// OrderCount returns how many orders rest on the bid and ask sides, in the
+// stop book and in the midpoint queues, counting the members of the price
+// levels and queues without reading the orders. Changes made by other processes are included.
+func (b *RedisBackend) OrderCount() int {
+	b.RLock()
+	defer b.RUnlock()
+	b.flushPending()
+
+	_, orderKeys, err := b.scanBook(b.ctx)
+	if err != nil {
+		b.logger.Error("failed to count orders", zap.Error(err))
+		return 0
+	}
+	return len(orderKeys)
+}
+
+// flushBatchSize is how many keys each DEL sent by FlushOrderBook names
+const flushBatchSize = 1000
+
+// FlushOrderBook deletes every bid, ask, stop and midpoint order of the book
+// together with its sides, price levels, stop book, midpoint queues and OCO
+// links, sending the deletes in a single MULTI/EXEC transaction. Order keys
+// are not prefixed, so the orders are found through the book's price levels
+// rather than by pattern. The prefix lock is kept.
+func (b *RedisBackend) FlushOrderBook(ctx context.Context) error {
+	b.Lock()
+	defer b.Unlock()
+	if err := b.pipelines.Flush(ctx); err != nil {
+		return fmt.Errorf("failed to send queued writes: %w", err)
+	}
+
+	levelKeys, orderKeys, err := b.scanBook(ctx)
+	if err != nil {
+		return err
+	}
+	keys := append([]string{b.bidsKey, b.asksKey, b.stopBuyKey, b.stopSellKey, b.midBuyKey, b.midSellKey, b.ocoKey}, levelKeys...)
+	keys = append(keys, orderKeys...)
+
+	// MULTI/EXEC applies the deletes at once: other clients never see the
+	// book half deleted
+	pipe := b.client.TxPipeline()
+	for start := 0; start < len(keys); start += flushBatchSize {
+		pipe.Del(ctx, keys[start:min(start+flushBatchSize, len(keys))]...)
+	}
+	n := pipe.Len()
+	_, err = pipe.Exec(ctx)
+	b.pipelines.record(ctx, n)
+	if err != nil {
+		return fmt.Errorf("failed to delete order book %s: %w", b.orderPrefix, err)
+	}
+	return nil
+}
+
 // Helper functions and types for Redis iteration
 
-// RedisSide represents one side (bid/ask) of the Redis order book
+// RedisSide represents one side (bid/ask) of the Redis order book.
+// It keeps no order state in memory: every read decodes fresh orders from
+// Redis, so reading a side never races with a concurrent Process.
 type RedisSide struct {
 	backend *RedisBackend
 	sideKey string
@@ -371,6 +871,7 @@ type RedisSide struct {
 
 // String implements fmt.Stringer interface
 func (rs *RedisSide) String() string {
+	rs.backend.flushPending()
 	sb := strings.Builder{}
 
 	// Get all members from the sorted set
@@ -415,15 +916,27 @@ func (rs *RedisSide) String() string {
 
 // Prices returns all prices in the order side
 func (rs *RedisSide) Prices() []fpdecimal.Decimal {
+	return rs.TopPrices(0)
+}
+
+// TopPrices returns the n best prices of the order side, or all prices if n
+// is not positive. Only the requested range is read from Redis.
+func (rs *RedisSide) TopPrices(n int) []fpdecimal.Decimal {
+	rs.backend.flushPending()
+	stop := int64(n) - 1
+	if n <= 0 {
+		stop = -1
+	}
+
 	var members []string
 	var err error
 
 	if rs.reverse {
 		// For bids (highest first)
-		members, err = rs.backend.client.ZRevRange(rs.backend.ctx, rs.sideKey, 0, -1).Result()
+		members, err = rs.backend.client.ZRevRange(rs.backend.ctx, rs.sideKey, 0, stop).Result()
 	} else {
 		// For asks (lowest first)
-		members, err = rs.backend.client.ZRange(rs.backend.ctx, rs.sideKey, 0, -1).Result()
+		members, err = rs.backend.client.ZRange(rs.backend.ctx, rs.sideKey, 0, stop).Result()
 	}
 
 	if err != nil {
@@ -445,23 +958,94 @@ func (rs *RedisSide) Prices() []fpdecimal.Decimal {
 
 // Orders returns all orders at a given price level
 func (rs *RedisSide) Orders(price fpdecimal.Decimal) []*core.Order {
-	priceKey := fmt.Sprintf("%s:%s", rs.sideKey, price.String())
+	orders := []*core.Order{}
+	iter := rs.OrderIterator(price)
+	for order, ok := iter.Next(); ok; order, ok = iter.Next() {
+		orders = append(orders, order)
+	}
+	return orders
+}
 
-	// Get all order IDs at this price level
-	orderIDs, err := rs.backend.client.SMembers(rs.backend.ctx, priceKey).Result()
-	if err != nil {
-		return []*core.Order{}
+// OrderIterator returns an iterator over the orders at a price level. The
+// level is read with SSCAN in batches of about scanCount orders, so Redis
+// serves other clients between batches however large the level is.
+func (rs *RedisSide) OrderIterator(price fpdecimal.Decimal) *RedisOrderIterator {
+	return &RedisOrderIterator{
+		backend: rs.backend,
+		key:     fmt.Sprintf("%s:%s", rs.sideKey, price.String()),
+		seen:    make(map[string]struct{}),
 	}
+}
 
-	orders := make([]*core.Order, 0, len(orderIDs))
-	for _, orderID := range orderIDs {
-		order := rs.backend.GetOrder(orderID)
-		if order != nil {
-			orders = append(orders, order)
+// IterateOrders returns OrderIterator(price) as the core.OrderIterator the
+// order book matches through
+func (rs *RedisSide) IterateOrders(price fpdecimal.Decimal) core.OrderIterator {
+	return rs.OrderIterator(price)
+}
+
+// RedisOrderIterator iterates the orders of a price level set with SSCAN.
+// Orders present for the whole iteration are returned exactly once; orders
+// added or removed meanwhile may or may not be.
+type RedisOrderIterator struct {
+	backend *RedisBackend
+	key     string
+	cursor  uint64
+	batch   []*core.Order
+	seen    map[string]struct{} // SSCAN may return an ID more than once
+	done    bool                // the cursor has wrapped around to 0
+	err     error
+}
+
+// Next returns the next order at the level, or false once every order has
+// been returned or reading the level failed
+func (it *RedisOrderIterator) Next() (*core.Order, bool) {
+	for len(it.batch) == 0 {
+		if it.done {
+			return nil, false
+		}
+		if err := it.scan(); err != nil {
+			it.err = err
+			it.done = true
+			it.backend.logger.Error("failed to scan price level",
+				zap.String("key", it.key),
+				zap.Error(err))
+			return nil, false
 		}
 	}
+	order := it.batch[0]
+	it.batch = it.batch[1:]
+	return order, true
+}
 
-	return orders
+// Err returns the error that ended the iteration, if any
+func (it *RedisOrderIterator) Err() error {
+	return it.err
+}
+
+// scan reads the next batch of the level, skipping IDs already returned and
+// orders deleted since they were added to it
+func (it *RedisOrderIterator) scan() error {
+	it.backend.flushPending()
+	ids, cursor, err := it.backend.client.SScan(it.backend.ctx, it.key, it.cursor, "", scanCount).Result()
+	if err != nil {
+		return err
+	}
+	it.cursor = cursor
+	it.done = cursor == 0
+
+	fresh := ids[:0]
+	for _, id := range ids {
+		if _, ok := it.seen[id]; !ok {
+			it.seen[id] = struct{}{}
+			fresh = append(fresh, id)
+		}
+	}
+	for _, order := range it.backend.GetOrders(fresh) {
+		if order != nil {
+			it.batch = append(it.batch, order)
+		}
+	}
+	return nil
 }
 
 // RedisStopBook represents the Redis stop book
@@ -473,6 +1057,7 @@ type RedisStopBook struct {
 
 // String implements fmt.Stringer interface
 func (rsb *RedisStopBook) String() string {
+	rsb.backend.flushPending()
 	sb := strings.Builder{}
 
 	// Buy stop orders
@@ -530,6 +1115,8 @@ func (rsb *RedisStopBook) String() string {
 
 // Prices returns all unique prices from both buy and sell sides
 func (rsb *RedisStopBook) Prices() []fpdecimal.Decimal {
+	rsb.backend.flushPending()
+
 	// Get prices from buy side
 	buyPrices := make([]fpdecimal.Decimal, 0)
 	buyMembers, err := rsb.backend.client.ZRange(rsb.backend.ctx, rsb.stopBuyKey, 0, -1).Result()
@@ -576,6 +1163,8 @@ func (rsb *RedisStopBook) Prices() []fpdecimal.Decimal {
 
 // Orders returns all orders at a given price level for both buy and sell sides
 func (rsb *RedisStopBook) Orders(price fpdecimal.Decimal) []*core.Order {
+	rsb.backend.flushPending()
+
 	// Get buy orders at this price
 	buyPriceKey := fmt.Sprintf("%s:%s", rsb.stopBuyKey, price.String())
 	buyOrderIDs, err := rsb.backend.client.SMembers(rsb.backend.ctx, buyPriceKey).Result()
@@ -607,6 +1196,7 @@ func (rsb *RedisStopBook) Orders(price fpdecimal.Decimal) []*core.Order {
 
 // BuyOrders returns all buy stop orders
 func (rsb *RedisStopBook) BuyOrders() []*core.Order {
+	rsb.backend.flushPending()
 	var allOrders []*core.Order
 
 	// Get all buy stop price levels
@@ -636,6 +1226,7 @@ func (rsb *RedisStopBook) BuyOrders() []*core.Order {
 
 // SellOrders returns all sell stop orders
 func (rsb *RedisStopBook) SellOrders() []*core.Order {
+	rsb.backend.flushPending()
 	var allOrders []*core.Order
 
 	// Get all sell stop price levels
@@ -678,15 +1269,23 @@ func (b *RedisBackend) getSideKey(side core.Side) string {
 	return b.asksKey
 }
 
+func (b *RedisBackend) getMidpointKey(side core.Side) string {
+	if side == core.Buy {
+		return b.midBuyKey
+	}
+	return b.midSellKey
+}
+
 func (b *RedisBackend) getOrderKey(orderID string) string {
 	return fmt.Sprintf("order:%s", orderID)
 }
 
-// Close closes the Redis client and cleans up resources
+// Close sends the queued writes, releases the backend's prefix and closes
+// the Redis client
 func (b *RedisBackend) Close() error {
 	b.Lock()
 	defer b.Unlock()
-	return b.client.Close()
+	return errors.Join(b.pipelines.Flush(b.ctx), b.Release(), b.client.Close())
 }
 
 // WithContext returns a new RedisBackend with the given context
@@ -3,9 +3,14 @@ package redis
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
 	"github.com/nikolaydubina/fpdecimal"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
@@ -42,10 +47,19 @@ func setupTestRedis(t *testing.T) *redis.Client {
 	return client
 }
 
+// newTestBackend creates a backend on client and releases its prefix when the test ends
+func newTestBackend(tb testing.TB, client *redis.Client, prefix string) *RedisBackend {
+	tb.Helper()
+	backend, err := NewRedisBackend(client, prefix, testLogger)
+	require.NoError(tb, err)
+	tb.Cleanup(func() { backend.Release() })
+	return backend
+}
+
 func TestNewRedisBackend(t *testing.T) {
 	client := setupTestRedis(t)
-	prefix := "test:newredis:"
-	backend := NewRedisBackend(client, prefix, testLogger)
+	prefix := "test_newredis"
+	backend := newTestBackend(t, client, prefix)
 
 	assert.NotNil(t, backend)
 	assert.Equal(t, client, backend.client)
@@ -59,7 +73,7 @@ func TestNewRedisBackend(t *testing.T) {
 
 func TestRedisBackend_StoreGetUpdateDeleteOrder(t *testing.T) {
 	client := setupTestRedis(t)
-	backend := NewRedisBackend(client, "test:orders:", testLogger)
+	backend := newTestBackend(t, client, "test_orders")
 
 	// Create test order
 	order, err := core.NewLimitOrder("test1", core.Buy, fpdecimal.FromFloat(1.0), fpdecimal.FromFloat(100.0), core.GTC, "", "test_user")
@@ -92,7 +106,7 @@ func TestRedisBackend_StoreGetUpdateDeleteOrder(t *testing.T) {
 
 func TestRedisBackend_AppendAndRemoveFromSide(t *testing.T) {
 	client := setupTestRedis(t)
-	backend := NewRedisBackend(client, "test:sides:", testLogger)
+	backend := newTestBackend(t, client, "test_sides")
 
 	// Create test order
 	order, err := core.NewLimitOrder("test1", core.Buy, fpdecimal.FromFloat(1.0), fpdecimal.FromFloat(100.0), core.GTC, "", "test_user")
@@ -119,7 +133,7 @@ func TestRedisBackend_AppendAndRemoveFromSide(t *testing.T) {
 
 func TestRedisBackend_AppendToSide_MultipleOrdersSamePrice(t *testing.T) {
 	client := setupTestRedis(t)
-	backend := NewRedisBackend(client, "test:multisameprice:", testLogger)
+	backend := newTestBackend(t, client, "test_multisameprice")
 	price := fpdecimal.FromFloat(100.0)
 	qty := fpdecimal.FromFloat(1.0)
 
@@ -178,7 +192,7 @@ func TestRedisBackend_AppendToSide_MultipleOrdersSamePrice(t *testing.T) {
 
 func TestRedisBackend_GetComponents(t *testing.T) {
 	client := setupTestRedis(t)
-	backend := NewRedisBackend(client, "test", testLogger)
+	backend := newTestBackend(t, client, "test")
 
 	bids := backend.GetBids()
 	if bids == nil {
@@ -198,7 +212,7 @@ func TestRedisBackend_GetComponents(t *testing.T) {
 
 func TestRedisBackend_BasicOperations(t *testing.T) {
 	client := setupTestRedis(t)
-	backend := NewRedisBackend(client, "test", testLogger)
+	backend := newTestBackend(t, client, "test")
 
 	// Test storing and retrieving an order
 	orderID := "test-123"
@@ -243,7 +257,7 @@ func TestRedisBackend_StopBookInterface(t *testing.T) {
 	client := setupTestRedis(t)
 	defer client.Close()
 
-	backend := NewRedisBackend(client, "test:testrstopbook", testLogger)
+	backend := newTestBackend(t, client, "test_testrstopbook")
 
 	// 1. Create some stop orders
 	buyStopOrder1, err := core.NewStopLimitOrder("stop-buy-1", core.Buy, fpdecimal.FromFloat(5.0), fpdecimal.FromFloat(100.0), fpdecimal.FromFloat(105.0), "", "test_user")
@@ -321,3 +335,571 @@ func TestRedisBackend_StopBookInterface(t *testing.T) {
 	require.Len(t, sellOrders100, 1, "Should have 1 sell order at price 100")
 	assert.Equal(t, "stop-sell-1", sellOrders100[0].ID())
 }
+
+func TestRedisSide_TopPrices(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	backend := newTestBackend(t, client, "top-prices")
+
+	for i := 1; i <= 5; i++ {
+		bid, err := core.NewLimitOrder(fmt.Sprintf("bid-%d", i), core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(int64(i)), core.GTC, "", "test_user")
+		require.NoError(t, err)
+		backend.AppendToSide(core.Buy, bid)
+		ask, err := core.NewLimitOrder(fmt.Sprintf("ask-%d", i), core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(int64(10+i)), core.GTC, "", "test_user")
+		require.NoError(t, err)
+		backend.AppendToSide(core.Sell, ask)
+	}
+
+	bids := backend.GetBids().(*RedisSide)
+	asks := backend.GetAsks().(*RedisSide)
+	assert.Equal(t, []fpdecimal.Decimal{fpdecimal.FromInt(5), fpdecimal.FromInt(4)}, bids.TopPrices(2))
+	assert.Equal(t, []fpdecimal.Decimal{fpdecimal.FromInt(11), fpdecimal.FromInt(12)}, asks.TopPrices(2))
+	assert.Len(t, bids.TopPrices(0), 5)
+	assert.Len(t, asks.TopPrices(10), 5)
+}
+
+func TestRedisBackend_GetAllOrders(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	backend := newTestBackend(t, client, "all-orders")
+	// Price levels of another book must not be picked up
+	other := newTestBackend(t, client, "all-orders-other")
+
+	bid, err := core.NewLimitOrder("bid-1", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(99), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	ask, err := core.NewLimitOrder("ask-1", core.Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(101), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	stop, err := core.NewStopLimitOrder("stop-1", core.Sell, fpdecimal.FromInt(3), fpdecimal.FromInt(90), fpdecimal.FromInt(95), "", "test_user")
+	require.NoError(t, err)
+	otherAsk, err := core.NewLimitOrder("other-ask-1", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(101), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	for _, order := range []*core.Order{bid, ask, stop} {
+		require.NoError(t, backend.StoreOrder(order))
+	}
+	require.NoError(t, other.StoreOrder(otherAsk))
+	backend.AppendToSide(core.Buy, bid)
+	backend.AppendToSide(core.Sell, ask)
+	backend.AppendToStopBook(stop)
+	other.AppendToSide(core.Sell, otherAsk)
+
+	orders := backend.GetAllOrders()
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.ID())
+	}
+	assert.ElementsMatch(t, []string{"bid-1", "ask-1", "stop-1"}, ids)
+	assert.Equal(t, 3, backend.OrderCount())
+	assert.Equal(t, 1, other.OrderCount())
+
+	// An order whose data is gone is skipped
+	backend.DeleteOrder("ask-1")
+	assert.Len(t, backend.GetAllOrders(), 2)
+}
+
+func TestRedisBackend_GetOrders(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	backend := newTestBackend(t, client, "get-orders")
+
+	for i := 0; i < 3; i++ {
+		order, err := core.NewLimitOrder(fmt.Sprintf("order-%d", i), core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(int64(100+i)), core.GTC, "", "test_user")
+		require.NoError(t, err)
+		require.NoError(t, backend.StoreOrder(order))
+	}
+
+	orders := backend.GetOrders([]string{"order-2", "missing", "order-0"})
+	require.Len(t, orders, 3)
+	assert.Equal(t, "order-2", orders[0].ID())
+	assert.Equal(t, fpdecimal.FromInt(102), orders[0].Price())
+	assert.Nil(t, orders[1])
+	assert.Equal(t, "order-0", orders[2].ID())
+
+	assert.Empty(t, backend.GetOrders(nil))
+}
+
+// sideConsistentScript reports, atomically, the prices of a side that have no
+// orders and the non-empty price levels whose price is missing from the side
+var sideConsistentScript = redis.NewScript(`
+local bad = {}
+for _, price in ipairs(redis.call("ZRANGE", KEYS[1], 0, -1)) do
+	if redis.call("SCARD", KEYS[1] .. ":" .. price) == 0 then
+		table.insert(bad, "empty:" .. price)
+	end
+end
+for _, price in ipairs(ARGV) do
+	if redis.call("SCARD", KEYS[1] .. ":" .. price) > 0 and not redis.call("ZSCORE", KEYS[1], price) then
+		table.insert(bad, "unlisted:" .. price)
+	end
+end
+return bad
+`)
+
+func TestRedisBackend_SideUpdatesAreAtomic(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	backend := newTestBackend(t, client, "atomic-side")
+	ctx := context.Background()
+
+	orders := make([]*core.Order, 20)
+	prices := make([]interface{}, len(orders))
+	for i := range orders {
+		order, err := core.NewLimitOrder(fmt.Sprintf("order-%d", i), core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(int64(100+i%5)), core.GTC, "", "test_user")
+		require.NoError(t, err)
+		orders[i] = order
+		prices[i] = order.Price().String()
+	}
+
+	// Append and remove orders while another client checks the side between
+	// every command it can
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for round := 0; round < 20; round++ {
+			for _, order := range orders {
+				backend.AppendToSide(core.Buy, order)
+			}
+			for _, order := range orders {
+				backend.RemoveFromSide(core.Buy, order)
+			}
+		}
+	}()
+
+	checks := 0
+	for running := true; running; checks++ {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		bad, err := sideConsistentScript.Run(ctx, client, []string{backend.bidsKey}, prices...).StringSlice()
+		require.NoError(t, err)
+		require.Empty(t, bad, "inconsistent side seen after %d checks", checks)
+	}
+
+	// Everything was removed, including the emptied levels
+	assert.Equal(t, []string{"atomic-side:lock"}, mr.Keys())
+
+	// Scripts dropped by Redis are sent again
+	require.NoError(t, client.ScriptFlush(ctx).Err())
+	backend.AppendToSide(core.Buy, orders[0])
+	assert.Equal(t, []fpdecimal.Decimal{orders[0].Price()}, backend.GetBids().(*RedisSide).Prices())
+	assert.True(t, backend.RemoveFromSide(core.Buy, orders[0]))
+	assert.Empty(t, backend.GetBids().(*RedisSide).Prices())
+}
+
+func TestRedisBackend_FillOrder(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	backend := newTestBackend(t, client, "fill-order")
+
+	order, err := core.NewLimitOrder("ask-1", core.Sell, fpdecimal.FromInt(5), fpdecimal.FromInt(100), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	require.NoError(t, order.Transition(core.StateOpen))
+	require.NoError(t, backend.StoreOrder(order))
+	backend.AppendToSide(core.Sell, order)
+
+	filled, remaining, err := backend.FillOrder(order, fpdecimal.FromFloat(1.5))
+	require.NoError(t, err)
+	assert.Equal(t, fpdecimal.FromFloat(1.5), filled)
+	assert.Equal(t, fpdecimal.FromFloat(3.5), remaining)
+	stored := backend.GetOrder("ask-1")
+	require.NotNil(t, stored)
+	assert.Equal(t, fpdecimal.FromFloat(3.5), stored.Quantity())
+	assert.Equal(t, core.StatePartiallyFilled, stored.State())
+	assert.Equal(t, "test_user", stored.UserAddress())
+
+	// Matchers racing for the rest fill it exactly once
+	var wg sync.WaitGroup
+	results := make(chan fpdecimal.Decimal, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			filled, _, err := backend.FillOrder(order, fpdecimal.FromInt(1))
+			assert.NoError(t, err)
+			results <- filled
+		}()
+	}
+	wg.Wait()
+	close(results)
+	total := fpdecimal.Zero
+	for filled := range results {
+		total = total.Add(filled)
+	}
+	assert.Equal(t, fpdecimal.FromFloat(3.5), total)
+
+	// The filled order left the book but is still stored
+	stored = backend.GetOrder("ask-1")
+	require.NotNil(t, stored)
+	assert.True(t, stored.Quantity().Equal(fpdecimal.Zero))
+	assert.Equal(t, core.StateFilled, stored.State())
+	assert.Empty(t, backend.GetAsks().(*RedisSide).Prices())
+
+	filled, remaining, err = backend.FillOrder(order, fpdecimal.FromInt(1))
+	require.NoError(t, err)
+	assert.True(t, filled.Equal(fpdecimal.Zero))
+	assert.True(t, remaining.Equal(fpdecimal.Zero))
+
+	missing, err := core.NewLimitOrder("missing", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	filled, _, err = backend.FillOrder(missing, fpdecimal.FromInt(1))
+	require.NoError(t, err)
+	assert.True(t, filled.Equal(fpdecimal.Zero))
+}
+
+func TestRedisBackend_GetAllOrders_Performance(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+	backend := newTestBackend(t, client, "test_getallorders")
+
+	const count = 10000
+	for i := 0; i < count; i++ {
+		side := core.Buy
+		price := fpdecimal.FromInt(int64(1000 - i%500))
+		if i%2 == 1 {
+			side = core.Sell
+			price = fpdecimal.FromInt(int64(1001 + i%500))
+		}
+		order, err := core.NewLimitOrder(fmt.Sprintf("order-%d", i), side, fpdecimal.FromInt(1), price, core.GTC, "", "test_user")
+		require.NoError(t, err)
+		require.NoError(t, backend.StoreOrder(order))
+		backend.AppendToSide(side, order)
+	}
+
+	start := time.Now()
+	orders := backend.GetAllOrders()
+	elapsed := time.Since(start)
+
+	assert.Len(t, orders, count)
+	assert.Less(t, elapsed, 500*time.Millisecond)
+}
+
+func TestNewRedisBackend_PrefixLock(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newClient := func() *redis.Client {
+		return redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	}
+
+	backend, err := NewRedisBackend(newClient(), "locked", testLogger)
+	require.NoError(t, err)
+	assert.True(t, mr.Exists("locked:lock"))
+
+	// Same process
+	_, err = NewRedisBackend(newClient(), "locked", testLogger)
+	assert.ErrorIs(t, err, ErrPrefixConflict)
+
+	// Another process holding the lock on the same server
+	mr.Set("other:lock", "another-process")
+	_, err = NewRedisBackend(newClient(), "other", testLogger)
+	assert.ErrorIs(t, err, ErrPrefixConflict)
+	assert.True(t, mr.Exists("other:lock"), "a failed attempt must not release someone else's lock")
+
+	// Close releases the lock, so the prefix can be used again
+	require.NoError(t, backend.Close())
+	assert.False(t, mr.Exists("locked:lock"))
+	backend, err = NewRedisBackend(newClient(), "locked", testLogger)
+	require.NoError(t, err)
+	require.NoError(t, backend.Close())
+}
+
+func TestNewRedisBackend_PrefixLockLease(t *testing.T) {
+	ttl := 90 * time.Millisecond
+	defer func(old time.Duration) { PrefixLockTTL = old }(PrefixLockTTL)
+	PrefixLockTTL = ttl
+
+	mr := miniredis.RunT(t)
+	backend, err := NewRedisBackend(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "leased", testLogger)
+	require.NoError(t, err)
+	assert.Equal(t, ttl, mr.TTL("leased:lock"))
+
+	// The lease is extended back to its full length while the backend is open
+	mr.FastForward(60 * time.Millisecond)
+	assert.Eventually(t, func() bool { return mr.TTL("leased:lock") == ttl }, time.Second, 5*time.Millisecond)
+	require.NoError(t, backend.Close())
+	assert.False(t, mr.Exists("leased:lock"))
+
+	// The lock of a process that died expires, freeing the prefix
+	mr.Set("abandoned:lock", "dead-process")
+	mr.SetTTL("abandoned:lock", ttl)
+	mr.FastForward(ttl)
+	backend, err = NewRedisBackend(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "abandoned", testLogger)
+	require.NoError(t, err)
+	require.NoError(t, backend.Close())
+}
+
+func TestNewRedisBackend_InvalidPrefix(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	for _, prefix := range []string{"", "orders:btc", "has space", "emoji-\u2603", strings.Repeat("a", 65)} {
+		_, err := NewRedisBackend(client, prefix, testLogger)
+		assert.ErrorIs(t, err, ErrInvalidPrefix, "prefix %q", prefix)
+	}
+	assert.Empty(t, mr.Keys())
+
+	backend, err := NewRedisBackend(client, strings.Repeat("a", 64), testLogger)
+	require.NoError(t, err)
+	require.NoError(t, backend.Release())
+}
+
+func TestRedisSide_OrderIterator(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	backend := newTestBackend(t, client, "iterator")
+	book := core.NewOrderBook(backend)
+
+	// Enough orders for the scan to take several batches before its cursor
+	// wraps around to 0
+	const count = 3*scanCount + 7
+	price := fpdecimal.FromInt(100)
+	want := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		order, err := core.NewLimitOrder(fmt.Sprintf("ask-%d", i), core.Sell, fpdecimal.FromInt(1), price, core.GTC, "", "test_user")
+		require.NoError(t, err)
+		_, err = book.Process(context.Background(), order)
+		require.NoError(t, err)
+		want = append(want, order.ID())
+	}
+	// An order whose data is gone is skipped
+	require.NoError(t, client.Del(context.Background(), backend.getOrderKey("ask-0")).Err())
+	want = want[1:]
+
+	asks := backend.GetAsks().(*RedisSide)
+	iter := asks.OrderIterator(price)
+	var got []string
+	for order, ok := iter.Next(); ok; order, ok = iter.Next() {
+		assert.Equal(t, price, order.Price())
+		got = append(got, order.ID())
+	}
+	require.NoError(t, iter.Err())
+	assert.ElementsMatch(t, want, got)
+	_, ok := iter.Next()
+	assert.False(t, ok, "an exhausted iterator stays exhausted")
+
+	assert.Len(t, asks.Orders(price), len(want))
+	_, ok = asks.OrderIterator(fpdecimal.FromInt(101)).Next()
+	assert.False(t, ok)
+}
+
+func TestRedisSide_OrderIterator_Matching(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return messaging.NewMockMessageSender() })
+	defer core.SetMessageSenderFactory(nil)
+	backend := newTestBackend(t, client, "iterator-matching")
+	book := core.NewOrderBook(backend)
+
+	// miniredis cursors are offsets into the sorted members, so the orders
+	// removed as a batch matches shift as many later ones behind the
+	// cursor, where real Redis would still return them. The level holds
+	// enough orders for the taker to fill either way.
+	const count = 6 * scanCount
+	price := fpdecimal.FromInt(100)
+	for i := 0; i < count; i++ {
+		order, err := core.NewLimitOrder(fmt.Sprintf("ask-%d", i), core.Sell, fpdecimal.FromInt(1), price, core.GTC, "", "test_user")
+		require.NoError(t, err)
+		_, err = book.Process(context.Background(), order)
+		require.NoError(t, err)
+	}
+
+	// A market order matching through the iterator fills across batches
+	fill := fpdecimal.FromInt(2*scanCount + 10)
+	taker, err := core.NewMarketOrder("taker", core.Buy, fill, "test_user")
+	require.NoError(t, err)
+	done, err := book.Process(context.Background(), taker)
+	require.NoError(t, err)
+	assert.Equal(t, fill, done.Processed)
+	assert.Len(t, backend.GetAsks().(*RedisSide).Orders(price), count-(2*scanCount+10))
+}
+
+func TestRedisBackend_PipelineFlushing(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	backend := newTestBackend(t, client, "pipeline")
+	require.NoError(t, backend.SetPipelineConfig(PipelineConfig{MaxCmds: 10}))
+
+	appendAsks := func(from, to int) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			order, err := core.NewLimitOrder(fmt.Sprintf("ask-%d", i), core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(int64(100+i)), core.GTC, "", "test_user")
+			require.NoError(t, err)
+			backend.AppendToSide(core.Sell, order)
+		}
+	}
+	levels := func() int64 {
+		t.Helper()
+		n, err := client.ZCard(context.Background(), backend.asksKey).Result()
+		require.NoError(t, err)
+		return n
+	}
+
+	// Appends are sent ten at a time, with the rest held back
+	appendAsks(0, 25)
+	assert.Equal(t, int64(20), levels())
+	assert.Equal(t, PipelineStats{FlushCount: 2, CmdCount: 20, AvgCmdsPerFlush: 10}, backend.PipelineStats())
+
+	// Reading through the backend sends them first
+	assert.Len(t, backend.GetAsks().(*RedisSide).Prices(), 25)
+	assert.Equal(t, int64(25), levels())
+
+	// MaxAge sends a short batch without waiting for a read
+	require.NoError(t, backend.SetPipelineConfig(PipelineConfig{MaxCmds: 10, MaxAge: 10 * time.Millisecond}))
+	appendAsks(25, 28)
+	assert.Eventually(t, func() bool { return levels() == 28 }, time.Second, 5*time.Millisecond)
+
+	// So does closing the order book
+	require.NoError(t, backend.SetPipelineConfig(PipelineConfig{MaxCmds: 10}))
+	appendAsks(28, 30)
+	require.NoError(t, core.NewOrderBook(backend).Close(context.Background()))
+	assert.Equal(t, int64(30), levels())
+}
+
+func TestRedisBackend_QueuedWriteErrors(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+	backend := newTestBackend(t, client, "queued")
+	require.NoError(t, backend.SetPipelineConfig(PipelineConfig{MaxCmds: 10}))
+
+	ask := func(id string, price int64) *core.Order {
+		t.Helper()
+		order, err := core.NewLimitOrder(id, core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(price), core.GTC, "", "test_user")
+		require.NoError(t, err)
+		return order
+	}
+
+	// Redis drops the append script; it is loaded again when the queued
+	// appends are sent
+	require.NoError(t, client.ScriptFlush(ctx).Err())
+	backend.AppendToSide(core.Sell, ask("ask-1", 100))
+	backend.AppendToSide(core.Sell, ask("ask-2", 101))
+	require.NoError(t, backend.Flush(ctx))
+	assert.Len(t, backend.GetAsks().(*RedisSide).Prices(), 2)
+	assert.Equal(t, PipelineStats{FlushCount: 2, CmdCount: 4, AvgCmdsPerFlush: 2}, backend.PipelineStats())
+
+	// A write that fails when a read sends it is reported by the next Flush
+	priceKey := fmt.Sprintf("%s:%s", backend.asksKey, fpdecimal.FromInt(102).String())
+	require.NoError(t, client.Set(ctx, priceKey, "not a set", 0).Err())
+	backend.AppendToSide(core.Sell, ask("ask-3", 102))
+	backend.GetAsks()
+	assert.Error(t, backend.Flush(ctx))
+	assert.NoError(t, backend.Flush(ctx), "errors are returned once")
+}
+
+func TestRedisBackend_FlushOrderBook(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return messaging.NewMockMessageSender() })
+	defer core.SetMessageSenderFactory(nil)
+
+	backend := newTestBackend(t, client, "flush")
+	other := newTestBackend(t, client, "flush_other")
+	book := core.NewOrderBook(backend)
+	otherBook := core.NewOrderBook(other)
+
+	processIn := func(book *core.OrderBook) func(*core.Order, error) *core.Done {
+		return func(order *core.Order, err error) *core.Done {
+			t.Helper()
+			require.NoError(t, err)
+			done, err := book.Process(ctx, order)
+			require.NoError(t, err)
+			return done
+		}
+	}
+	process, processOther := processIn(book), processIn(otherBook)
+	for i := 0; i < 10; i++ {
+		process(core.NewLimitOrder(fmt.Sprintf("ask-%d", i), core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(int64(110+i%3)), core.GTC, "", "test_user"))
+		process(core.NewLimitOrder(fmt.Sprintf("bid-%d", i), core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(int64(90-i%3)), core.GTC, fmt.Sprintf("oco-%d", i), "test_user"))
+	}
+	process(core.NewStopLimitOrder("stop", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(121), fpdecimal.FromInt(120), "", "test_user"))
+	process(core.NewMidpointPeggedOrder("mid", core.Sell, fpdecimal.FromInt(1), "", "test_user"))
+	processOther(core.NewLimitOrder("other-ask", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), core.GTC, "", "test_user"))
+
+	require.NoError(t, book.Reset())
+
+	assert.Empty(t, backend.GetAllOrders())
+	assert.Empty(t, backend.GetBids().(*RedisSide).Prices())
+	assert.Empty(t, backend.GetAsks().(*RedisSide).Prices())
+	assert.Nil(t, backend.GetOrder("ask-0"))
+	assert.Nil(t, backend.GetOrder("stop"))
+	assert.Nil(t, backend.GetOrder("mid"))
+	keys, err := client.Keys(ctx, "flush:*").Result()
+	require.NoError(t, err)
+	assert.Equal(t, []string{backend.lockKey}, keys, "only the prefix lock is left")
+
+	// Other books on the server are untouched
+	assert.Len(t, other.GetAllOrders(), 1)
+	assert.NotNil(t, other.GetOrder("other-ask"))
+
+	// The flushed book matches new orders as usual
+	process(core.NewLimitOrder("ask-0", core.Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(100), core.GTC, "", "test_user"))
+	done := process(core.NewLimitOrder("bid-0", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), core.GTC, "", "test_user"))
+	assert.Equal(t, "1.000", done.Processed.String())
+	asks := backend.GetAsks().(*RedisSide)
+	require.Len(t, asks.Prices(), 1)
+	assert.Equal(t, "1.000", backend.GetOrder("ask-0").Quantity().String())
+}
+
+func TestRedisBackend_MidpointQueue(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return messaging.NewMockMessageSender() })
+	defer core.SetMessageSenderFactory(nil)
+
+	backend, err := NewRedisBackend(client, "mid", testLogger)
+	require.NoError(t, err)
+	book := core.NewOrderBook(backend)
+	// process runs order on whichever book is current
+	process := func(order *core.Order, err error) *core.Done {
+		t.Helper()
+		require.NoError(t, err)
+		done, err := book.Process(ctx, order)
+		require.NoError(t, err)
+		return done
+	}
+	process(core.NewLimitOrder("bid", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), core.GTC, "", "test_user"))
+	process(core.NewLimitOrder("ask", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(110), core.GTC, "", "test_user"))
+	process(core.NewMidpointPeggedOrder("mid-1", core.Buy, fpdecimal.FromInt(2), "", "test_user"))
+	process(core.NewMidpointPeggedOrder("mid-2", core.Buy, fpdecimal.FromInt(1), "", "test_user"))
+
+	ids := func(orders []*core.Order) []string {
+		var ids []string
+		for _, order := range orders {
+			ids = append(ids, order.ID())
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"mid-1", "mid-2"}, ids(backend.GetMidpointOrders(core.Buy)))
+	assert.Empty(t, backend.GetMidpointOrders(core.Sell))
+	assert.ElementsMatch(t, []string{"bid", "ask", "mid-1", "mid-2"}, ids(backend.GetAllOrders()))
+	assert.Equal(t, 4, book.OrderCount())
+	assert.Equal(t, []string{"mid-1", "mid-2"}, ids(book.Snapshot().Midpoint))
+
+	// A process that takes over the prefix finds the queue as it was left
+	require.NoError(t, backend.Release())
+	reopened := newTestBackend(t, client, "mid")
+	book = core.NewOrderBook(reopened)
+	done := process(core.NewMidpointPeggedOrder("mid-sell", core.Sell, fpdecimal.FromInt(2), "", "test_user"))
+	assert.Equal(t, "2.000", done.Processed.String())
+	assert.Equal(t, "105.000", done.GetTradeOrder("mid-1").Price.String())
+	assert.Nil(t, reopened.GetOrder("mid-1"))
+	assert.Equal(t, []string{"mid-2"}, ids(reopened.GetMidpointOrders(core.Buy)))
+
+	require.NotNil(t, book.CancelOrder("mid-2"))
+	assert.Empty(t, reopened.GetMidpointOrders(core.Buy))
+	assert.Equal(t, 2, book.OrderCount())
+}
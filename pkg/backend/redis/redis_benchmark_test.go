@@ -3,9 +3,11 @@ package redis
 import (
 	"context"
 	"fmt"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/erain9/matchingo/pkg/core"
 	"github.com/nikolaydubina/fpdecimal"
 	"github.com/redis/go-redis/v9"
@@ -62,7 +64,7 @@ func BenchmarkAppendToSide_Bids(b *testing.B) {
 	defer cancel()
 	client.FlushDB(ctx)
 
-	backend := NewRedisBackend(client, "bench:bids:", testLogger)
+	backend := newTestBackend(b, client, "bench_bids")
 	benchmarkAppendToSide(b, backend, core.Buy)
 }
 
@@ -78,7 +80,7 @@ func BenchmarkAppendToSide_Asks(b *testing.B) {
 	defer cancel()
 	client.FlushDB(ctx)
 
-	backend := NewRedisBackend(client, "bench:asks:", testLogger)
+	backend := newTestBackend(b, client, "bench_asks")
 	benchmarkAppendToSide(b, backend, core.Sell)
 }
 
@@ -111,7 +113,7 @@ func BenchmarkRemoveFromSide_Bids(b *testing.B) {
 	defer cancel()
 	client.FlushDB(ctx)
 
-	backend := NewRedisBackend(client, "bench:bids:", testLogger)
+	backend := newTestBackend(b, client, "bench_bids")
 	benchmarkRemoveFromSide(b, backend, core.Buy)
 }
 
@@ -127,7 +129,7 @@ func BenchmarkRemoveFromSide_Asks(b *testing.B) {
 	defer cancel()
 	client.FlushDB(ctx)
 
-	backend := NewRedisBackend(client, "bench:asks:", testLogger)
+	backend := newTestBackend(b, client, "bench_asks")
 	benchmarkRemoveFromSide(b, backend, core.Sell)
 }
 
@@ -143,7 +145,7 @@ func BenchmarkRedisBackend_StoreOrder(b *testing.B) {
 	defer cancel()
 	client.FlushDB(ctx)
 
-	backend := NewRedisBackend(client, "bench:store:", testLogger)
+	backend := newTestBackend(b, client, "bench_store")
 	orders := make([]*core.Order, b.N)
 	for i := 0; i < b.N; i++ {
 		orderID := fmt.Sprintf("order-%d", i)
@@ -172,7 +174,7 @@ func BenchmarkRedisBackend_GetOrder(b *testing.B) {
 	defer cancel()
 	client.FlushDB(ctx)
 
-	backend := NewRedisBackend(client, "bench:get:", testLogger)
+	backend := newTestBackend(b, client, "bench_get")
 	orderIDs := make([]string, benchSize)
 	for i := 0; i < benchSize; i++ {
 		orderID := fmt.Sprintf("order-%d", i)
@@ -202,7 +204,7 @@ func BenchmarkRedisBackend_UpdateOrder(b *testing.B) {
 	defer cancel()
 	client.FlushDB(ctx)
 
-	backend := NewRedisBackend(client, "bench:update:", testLogger)
+	backend := newTestBackend(b, client, "bench_update")
 	orders := make([]*core.Order, benchSize)
 	for i := 0; i < benchSize; i++ {
 		orderID := fmt.Sprintf("order-%d", i)
@@ -234,7 +236,7 @@ func BenchmarkRedisBackend_DeleteOrder(b *testing.B) {
 	defer cancel()
 	client.FlushDB(ctx)
 
-	backend := NewRedisBackend(client, "bench:delete:", testLogger)
+	backend := newTestBackend(b, client, "bench_delete")
 	orders := make([]*core.Order, benchSize)
 	for i := 0; i < benchSize; i++ {
 		orderID := fmt.Sprintf("order-%d", i)
@@ -264,7 +266,7 @@ func BenchmarkOrderBook_Process_Redis(b *testing.B) {
 	defer cancel()
 	client.FlushDB(ctx)
 
-	backend := NewRedisBackend(client, "bench:process:", testLogger)
+	backend := newTestBackend(b, client, "bench_process")
 	book := core.NewOrderBook(backend)
 
 	// Create sell orders to match against
@@ -301,7 +303,7 @@ func BenchmarkOrderBook_LargeOrderBook_Redis(b *testing.B) {
 	defer cancel()
 	client.FlushDB(ctx)
 
-	backend := NewRedisBackend(client, "bench:large:", testLogger)
+	backend := newTestBackend(b, client, "bench_large")
 	book := core.NewOrderBook(backend)
 
 	// Create a large order book with many price levels
@@ -336,3 +338,189 @@ func BenchmarkOrderBook_LargeOrderBook_Redis(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+// BenchmarkRedisSide_LargeLevel reads a price level of benchSize orders while
+// another client pings Redis, and reports the p99 latency of the pings. A
+// single SMEMBERS keeps Redis busy for the whole level; SSCAN lets the pings
+// through between batches.
+func BenchmarkRedisSide_LargeLevel(b *testing.B) {
+	client := skipIfNoRedis(b)
+	if client == nil {
+		return
+	}
+	defer client.Close()
+
+	// Flush the database to start fresh
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client.FlushDB(ctx)
+
+	backend := newTestBackend(b, client, "bench_level")
+	price := fpdecimal.FromInt(100)
+	for i := 0; i < benchSize; i++ {
+		order, err := core.NewLimitOrder(fmt.Sprintf("order-%d", i), core.Sell, fpdecimal.FromInt(1), price, core.GTC, "", "test_user")
+		require.NoError(b, err)
+		require.NoError(b, backend.StoreOrder(order))
+		backend.AppendToSide(core.Sell, order)
+	}
+	asks := backend.GetAsks().(*RedisSide)
+	levelKey := fmt.Sprintf("%s:%s", asks.sideKey, price.String())
+
+	read := map[string]func() int{
+		"SMEMBERS": func() int {
+			ids := client.SMembers(context.Background(), levelKey).Val()
+			return len(backend.GetOrders(ids))
+		},
+		"SSCAN": func() int {
+			return len(asks.Orders(price))
+		},
+	}
+	for _, name := range []string{"SMEMBERS", "SSCAN"} {
+		b.Run(name, func(b *testing.B) {
+			var latencies []time.Duration
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				stop := make(chan struct{})
+				pinged := make(chan []time.Duration)
+				go func() {
+					var pings []time.Duration
+					for {
+						select {
+						case <-stop:
+							pinged <- pings
+							return
+						default:
+						}
+						start := time.Now()
+						client.Ping(context.Background())
+						pings = append(pings, time.Since(start))
+					}
+				}()
+
+				require.Equal(b, benchSize, read[name]())
+				close(stop)
+				latencies = append(latencies, <-pinged...)
+			}
+			b.StopTimer()
+
+			if len(latencies) > 0 {
+				sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+				b.ReportMetric(float64(latencies[len(latencies)*99/100].Microseconds()), "p99-ping-us")
+			}
+		})
+	}
+}
+
+func BenchmarkAppendToSide_Pipelining(b *testing.B) {
+	client := skipIfNoRedis(b)
+	if client == nil {
+		return
+	}
+	defer client.Close()
+
+	// Flush the database to start fresh
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client.FlushDB(ctx)
+
+	orders := make([]*core.Order, benchSize)
+	for i := range orders {
+		order, err := core.NewLimitOrder(fmt.Sprintf("order-%d", i), core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(int64(10000+i%100)), core.GTC, "", "test_user")
+		require.NoError(b, err)
+		orders[i] = order
+	}
+
+	modes := []struct {
+		name   string
+		config PipelineConfig
+	}{
+		{"Individual", PipelineConfig{}},
+		{"Adaptive", PipelineConfig{MaxCmds: 100, MaxAge: time.Millisecond}},
+	}
+	roundTrips := make(map[string]float64)
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			backend := newTestBackend(b, client, "bench_pipeline")
+			require.NoError(b, backend.SetPipelineConfig(mode.config))
+			before := backend.PipelineStats().FlushCount
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, order := range orders {
+					backend.AppendToSide(core.Sell, order)
+				}
+				require.NoError(b, backend.Flush(context.Background()))
+			}
+			b.StopTimer()
+
+			roundTrips[mode.name] = float64(backend.PipelineStats().FlushCount-before) / float64(b.N)
+			b.ReportMetric(roundTrips[mode.name], "round-trips/op")
+		})
+	}
+
+	if roundTrips["Adaptive"] > 0 {
+		reduction := roundTrips["Individual"] / roundTrips["Adaptive"]
+		b.Logf("adaptive pipelining made %.1fx fewer round trips", reduction)
+		if reduction < 5 {
+			b.Errorf("adaptive pipelining made only %.1fx fewer round trips, want at least 5x", reduction)
+		}
+	}
+}
+
+// iterativeBackend hides FlushOrderBook, so OrderBook.Reset removes the
+// orders of a RedisBackend one at a time
+type iterativeBackend struct {
+	core.OrderBookBackend
+}
+
+func BenchmarkFlushOrderBook(b *testing.B) {
+	mr := miniredis.RunT(b)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	modes := []struct {
+		name string
+		book func(backend *RedisBackend) *core.OrderBook
+	}{
+		{"IterativeCancel", func(backend *RedisBackend) *core.OrderBook {
+			return core.NewOrderBook(iterativeBackend{backend})
+		}},
+		{"FlushOrderBook", func(backend *RedisBackend) *core.OrderBook {
+			return core.NewOrderBook(backend)
+		}},
+	}
+	perReset := make(map[string]time.Duration)
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			backend := newTestBackend(b, client, "bench_flush")
+			require.NoError(b, backend.SetPipelineConfig(PipelineConfig{MaxCmds: 1000}))
+			book := mode.book(backend)
+
+			var elapsed time.Duration
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				for j := 0; j < benchSize; j++ {
+					order, err := core.NewLimitOrder(fmt.Sprintf("order-%d", j), core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(int64(10000+j%100)), core.GTC, "", "test_user")
+					require.NoError(b, err)
+					require.NoError(b, backend.StoreOrder(order))
+					backend.AppendToSide(core.Sell, order)
+				}
+				require.NoError(b, backend.Flush(context.Background()))
+				b.StartTimer()
+
+				start := time.Now()
+				require.NoError(b, book.Reset())
+				elapsed += time.Since(start)
+			}
+			perReset[mode.name] = elapsed / time.Duration(b.N)
+		})
+	}
+
+	if perReset["FlushOrderBook"] > 0 {
+		speedup := float64(perReset["IterativeCancel"]) / float64(perReset["FlushOrderBook"])
+		b.Logf("FlushOrderBook reset %d orders %.1fx faster than cancelling them one by one", benchSize, speedup)
+		if speedup < 50 {
+			b.Errorf("FlushOrderBook was only %.1fx faster, want at least 50x", speedup)
+		}
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
 )
 
 // ERC20AddressPrefix is the standard prefix for ERC20 addresses
@@ -27,3 +30,45 @@ func GenerateFakeERC20Address() (string, error) {
 func IsERC20Address(address string) bool {
 	return len(address) == 42 && address[:2] == ERC20AddressPrefix
 }
+
+// NormalizeAddress returns the EIP-55 checksum form of an Ethereum address,
+// so that addresses differing only in letter case compare equal. addr must be
+// 40 hex digits in any case, optionally prefixed with 0x; otherwise
+// ErrInvalidAddress is returned.
+func NormalizeAddress(addr string) (string, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(addr, "0x"), "0X")
+	if len(digits) != 40 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidAddress, addr)
+	}
+	if _, err := hex.DecodeString(digits); err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidAddress, addr)
+	}
+
+	// A letter is upper case when the matching nibble of the Keccak-256
+	// hash of the lower case address is 8 or more
+	lower := strings.ToLower(digits)
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(lower))
+	sum := hash.Sum(nil)
+
+	checksummed := []byte(lower)
+	for i, c := range checksummed {
+		nibble := sum[i/2] >> 4
+		if i%2 == 1 {
+			nibble = sum[i/2] & 0x0f
+		}
+		if c >= 'a' && c <= 'f' && nibble >= 8 {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(checksummed), nil
+}
+
+// normalizeUserAddress returns the checksum form of userAddress when it is an
+// Ethereum address. Any other user identifier is kept as given.
+func normalizeUserAddress(userAddress string) string {
+	if normalized, err := NormalizeAddress(userAddress); err == nil {
+		return normalized
+	}
+	return userAddress
+}
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -75,3 +77,29 @@ func TestIsERC20Address(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeAddress(t *testing.T) {
+	// Test vectors from EIP-55
+	for _, want := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		for _, addr := range []string{want, strings.ToLower(want), "0x" + strings.ToUpper(want[2:]), want[2:]} {
+			got, err := NormalizeAddress(addr)
+			if err != nil {
+				t.Fatalf("NormalizeAddress(%q) error = %v", addr, err)
+			}
+			if got != want {
+				t.Errorf("NormalizeAddress(%q) = %q, want %q", addr, got, want)
+			}
+		}
+	}
+
+	for _, addr := range []string{"", "0x", "0x1234", "test_user", "0x" + strings.Repeat("g", 40), "0x" + strings.Repeat("a", 41)} {
+		if _, err := NormalizeAddress(addr); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("NormalizeAddress(%q) error = %v, want ErrInvalidAddress", addr, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"context"
+
+	"github.com/nikolaydubina/fpdecimal"
+	zlog "github.com/rs/zerolog/log"
+)
+
+// AmendOrder changes the price and remaining quantity of the resting limit
+// order with orderID. The returned Done reports the amended order, with
+// Amended set and Left its new remaining quantity; it is published like the
+// Done of a processed order, numbered, so the book can be rebuilt from its
+// messages. Where the order then sits in the queue at its price depends on
+// the change:
+//
+//   - A strictly better price, a higher bid or a lower ask, improves the
+//     market, so the order goes to the front of the queue at the new price.
+//   - The same price with a larger quantity goes to the back of the queue,
+//     as a new order would, so size cannot be added ahead of earlier orders.
+//   - The same price with a smaller or equal quantity is updated in place.
+//   - A worse price keeps the order's time priority instead of sending it
+//     to the back: it is placed at the new price ahead of every order
+//     created after it.
+//
+// Backends whose price levels are not queues, such as Redis with its sets,
+// place the order as AppendToSide does. A price that would match the other
+// side fails with ErrAmendWouldTake; cancel and replace the order to trade.
+// Iceberg and pegged orders cannot be amended either.
+//
+// A price change does not always send the order to the back of the queue,
+// as a cancel and replace would: the rules above reward improving the
+// market and stop a worse price being used to jump the queue.
+func (ob *OrderBook) AmendOrder(ctx context.Context, orderID string, price, quantity fpdecimal.Decimal) (*Done, error) {
+	if !price.GreaterThan(fpdecimal.Zero) {
+		return nil, ErrInvalidPrice
+	}
+	if !quantity.GreaterThan(fpdecimal.Zero) {
+		return nil, ErrInvalidQuantity
+	}
+	return ob.amendOrder(ctx, orderID, price, quantity)
+}
+
+// AmendPrice is AmendOrder keeping the order's remaining quantity, read
+// under the same lock so fills made meanwhile are not undone
+func (ob *OrderBook) AmendPrice(ctx context.Context, orderID string, price fpdecimal.Decimal) (*Done, error) {
+	if !price.GreaterThan(fpdecimal.Zero) {
+		return nil, ErrInvalidPrice
+	}
+	return ob.amendOrder(ctx, orderID, price, fpdecimal.Zero)
+}
+
+// AmendQuantity is AmendOrder keeping the order's price
+func (ob *OrderBook) AmendQuantity(ctx context.Context, orderID string, quantity fpdecimal.Decimal) (*Done, error) {
+	if !quantity.GreaterThan(fpdecimal.Zero) {
+		return nil, ErrInvalidQuantity
+	}
+	return ob.amendOrder(ctx, orderID, fpdecimal.Zero, quantity)
+}
+
+// amendOrder amends the order as AmendOrder does, keeping its current price
+// or quantity where the one given is zero
+func (ob *OrderBook) amendOrder(ctx context.Context, orderID string, price, quantity fpdecimal.Decimal) (*Done, error) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	// The amended order may be the price pegged orders follow
+	defer ob.recomputePegs(ctx)
+
+	order := ob.backend.GetOrder(orderID)
+	if order == nil {
+		return nil, ErrNonexistentOrder
+	}
+	if !order.IsLimitOrder() || order.IsStopOrder() || order.IsIceberg() || order.IsPegged() {
+		return nil, ErrNotAmendable
+	}
+	if price.Equal(fpdecimal.Zero) {
+		price = order.Price()
+	}
+	if quantity.Equal(fpdecimal.Zero) {
+		quantity = order.Quantity()
+	}
+
+	amended := *order
+	amended.price = price
+	amended.quantity = quantity
+	if err := ob.checkRules(&amended); err != nil {
+		return nil, err
+	}
+	if ob.amendCrosses(order.Side(), price) {
+		return nil, ErrAmendWouldTake
+	}
+
+	better := price.GreaterThan(order.Price())
+	if order.Side() == Sell {
+		better = price.LessThan(order.Price())
+	}
+	samePrice := price.Equal(order.Price())
+
+	logger := zlog.Ctx(ctx)
+	logger.Debug().
+		Str("order_id", orderID).
+		Str("price", price.String()).
+		Str("quantity", quantity.String()).
+		Bool("better_price", better).
+		Msg("Amending order")
+
+	if samePrice && !quantity.GreaterThan(order.Quantity()) {
+		order.setAmended(price, quantity)
+		if err := ob.backend.UpdateOrder(order); err != nil {
+			return nil, err
+		}
+		return ob.publishAmendment(ctx, order), nil
+	}
+
+	// The order is found on its side by its current price, so it is taken
+	// off before the price changes
+	ob.backend.RemoveFromSide(order.Side(), order)
+	order.setAmended(price, quantity)
+	if err := ob.backend.UpdateOrder(order); err != nil {
+		ob.emit(changeDone(order))
+		return nil, err
+	}
+	ob.placeAmended(order, better, samePrice)
+	return ob.publishAmendment(ctx, order), nil
+}
+
+// placeAmended puts the amended order back on its side, at the front of its
+// price level when better, ahead of the orders created after it when its
+// price changed otherwise, and at the back when only its quantity grew
+func (ob *OrderBook) placeAmended(order *Order, better, samePrice bool) {
+	switch {
+	case better:
+		if prepender, ok := ob.backend.(interface {
+			PrependToSide(side Side, order *Order)
+		}); ok {
+			prepender.PrependToSide(order.Side(), order)
+			return
+		}
+	case !samePrice:
+		if inserter, ok := ob.backend.(interface {
+			InsertToSide(side Side, order *Order)
+		}); ok {
+			inserter.InsertToSide(order.Side(), order)
+			return
+		}
+	}
+	ob.backend.AppendToSide(order.Side(), order)
+}
+
+// publishAmendment sends and emits the Done reporting order's amendment
+// and returns it
+func (ob *OrderBook) publishAmendment(ctx context.Context, order *Order) *Done {
+	done := ob.newDone(order)
+	done.Amended = true
+	done.Left = order.Quantity()
+	done.Stored = true
+	ob.sendToKafka(ctx, done)
+	ob.emit(done)
+	return done
+}
+
+// amendCrosses reports whether an order on side at price would match the
+// best order on the other side
+func (ob *OrderBook) amendCrosses(side Side, price fpdecimal.Decimal) bool {
+	if side == Buy {
+		best, ok := bestPrice(ob.backend.GetAsks())
+		return ok && price.GreaterThanOrEqual(best)
+	}
+	best, ok := bestPrice(ob.backend.GetBids())
+	return ok && price.LessThanOrEqual(best)
+}
+
+// setAmended sets the order's price and remaining quantity, moving its
+// original quantity by as much as the remaining quantity changed so the
+// quantity already filled stays the same
+func (o *Order) setAmended(price, quantity fpdecimal.Decimal) {
+	o.originalQty = o.originalQty.Add(quantity.Sub(o.quantity))
+	o.price = price
+	o.quantity = quantity
+}
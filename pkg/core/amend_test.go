@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAmendOrder(t *testing.T) {
+	sender := setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+
+	process := func(order *Order, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+	process(NewLimitOrder("bid", Buy, fpdecimal.FromInt(4), fpdecimal.FromInt(100), GTC, "", ""))
+	process(NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(110), GTC, "", ""))
+	process(NewStopLimitOrder("stop", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(120), fpdecimal.FromInt(115), "", ""))
+	process(NewMarketOrder("taker", Sell, fpdecimal.FromInt(1), ""))
+
+	_, err := book.AmendOrder(ctx, "missing", fpdecimal.FromInt(100), fpdecimal.FromInt(1))
+	assert.ErrorIs(t, err, ErrNonexistentOrder)
+	_, err = book.AmendOrder(ctx, "stop", fpdecimal.FromInt(100), fpdecimal.FromInt(1))
+	assert.ErrorIs(t, err, ErrNotAmendable)
+	_, err = book.AmendOrder(ctx, "bid", fpdecimal.FromInt(110), fpdecimal.FromInt(1))
+	assert.ErrorIs(t, err, ErrAmendWouldTake)
+	_, err = book.AmendOrder(ctx, "bid", fpdecimal.FromInt(105), fpdecimal.Zero)
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+
+	// The bid has 1 filled; raising what remains keeps that
+	done, err := book.AmendOrder(ctx, "bid", fpdecimal.FromInt(105), fpdecimal.FromInt(5))
+	require.NoError(t, err)
+	amended := done.Order
+	assert.True(t, done.Amended)
+	assert.Equal(t, "5.000", done.Left.String())
+	assert.Equal(t, "105.000", amended.Price().String())
+	assert.Equal(t, "6.000", amended.OriginalQty().String())
+	assert.Len(t, book.backend.GetBids().(*mockOrderSide).Orders(fpdecimal.FromInt(105)), 1)
+	assert.Empty(t, book.backend.GetBids().(*mockOrderSide).Orders(fpdecimal.FromInt(100)))
+
+	// The amendment is published and numbered after the orders before it
+	sent := sender.GetSentMessages()
+	msg := sent[len(sent)-1]
+	assert.True(t, msg.Amended)
+	assert.Equal(t, "bid", msg.OrderID)
+	assert.Equal(t, "105.000", msg.Price)
+	assert.Equal(t, "5.000", msg.RemainingQty)
+	assert.Equal(t, sent[len(sent)-2].SequenceNumber+1, msg.SequenceNumber)
+	assert.Equal(t, done.Seq(), msg.SequenceNumber)
+}
+
+func TestAmendPriceAndQuantity(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+
+	order, err := NewLimitOrder("bid", Buy, fpdecimal.FromInt(4), fpdecimal.FromInt(100), GTC, "", "")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, order)
+	require.NoError(t, err)
+
+	_, err = book.AmendPrice(ctx, "bid", fpdecimal.Zero)
+	assert.ErrorIs(t, err, ErrInvalidPrice)
+	_, err = book.AmendQuantity(ctx, "bid", fpdecimal.Zero)
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+
+	done, err := book.AmendQuantity(ctx, "bid", fpdecimal.FromInt(3))
+	require.NoError(t, err)
+	amended := done.Order
+	assert.Equal(t, "100.000", amended.Price().String())
+	assert.Equal(t, "3.000", amended.Quantity().String())
+
+	done, err = book.AmendPrice(ctx, "bid", fpdecimal.FromInt(98))
+	require.NoError(t, err)
+	amended = done.Order
+	assert.Equal(t, "98.000", amended.Price().String())
+	assert.Equal(t, "3.000", amended.Quantity().String())
+}
@@ -16,6 +16,12 @@ type OrderBookBackend interface {
 	AppendToStopBook(order *Order)
 	RemoveFromStopBook(order *Order) bool
 
+	// Midpoint queue operations. Resting midpoint orders have no price
+	// level, so each side keeps them in one queue, oldest first.
+	AppendToMidpoint(order *Order)
+	RemoveFromMidpoint(order *Order) bool
+	GetMidpointOrders(side Side) []*Order
+
 	// OCO operations
 	CheckOCO(orderID string) string
 
@@ -23,4 +29,35 @@ type OrderBookBackend interface {
 	GetBids() interface{}
 	GetAsks() interface{}
 	GetStopBook() interface{}
+
+	// GetAllOrders returns copies of every order on the bid and ask sides,
+	// in the stop book and in the midpoint queues, read as of one moment: later changes to the backend
+	// do not show up in the result
+	GetAllOrders() []*Order
+}
+
+// OrderIterator yields the orders at a price level one at a time. A side
+// whose levels can grow large implements
+//
+//	IterateOrders(price fpdecimal.Decimal) OrderIterator
+//
+// so matching reads a level as it goes instead of all at once. Sides without
+// it are read with Orders.
+type OrderIterator interface {
+	// Next returns the next order, or false once the level is exhausted
+	Next() (*Order, bool)
+}
+
+// sliceIterator is an OrderIterator over orders already read
+type sliceIterator struct {
+	orders []*Order
+}
+
+func (it *sliceIterator) Next() (*Order, bool) {
+	if len(it.orders) == 0 {
+		return nil, false
+	}
+	order := it.orders[0]
+	it.orders = it.orders[1:]
+	return order, true
 }
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// CircuitBreakerConfig halts matching on an order book for a while when a
+// trade moves the price too far from the trade before it
+type CircuitBreakerConfig struct {
+	// MaxPriceMovePct is the largest move, in percent, an order's last fill
+	// may make from the last trade price. Zero disables the breaker.
+	MaxPriceMovePct float64
+	// CooldownSeconds is how long the book stays halted once tripped
+	CooldownSeconds int
+}
+
+// WithCircuitBreaker sets the book's circuit breaker
+func WithCircuitBreaker(cfg CircuitBreakerConfig) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.circuitBreaker = cfg
+	}
+}
+
+// HaltReasonOperator is the reason given for a halt set with SetHalted
+const HaltReasonOperator = "halted by an operator"
+
+// CircuitBreaker returns the book's circuit breaker settings
+func (ob *OrderBook) CircuitBreaker() CircuitBreakerConfig {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.circuitBreaker
+}
+
+// SetCircuitBreaker replaces the book's circuit breaker settings. A halt in
+// progress keeps the cooldown it was tripped with.
+func (ob *OrderBook) SetCircuitBreaker(cfg CircuitBreakerConfig) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.circuitBreaker = cfg
+}
+
+// SetHalted halts matching on the book until it is called again with false,
+// which also ends a halt by the circuit breaker. Orders are rejected with
+// ErrMarketHalted meanwhile; resting orders can still be canceled.
+func (ob *OrderBook) SetHalted(halted bool) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.operatorHalted = halted
+	if !halted {
+		ob.haltedUntil, ob.haltReason = time.Time{}, ""
+	}
+}
+
+// Halted reports whether matching is halted, by SetHalted or the circuit
+// breaker, and why. A circuit breaker halt ends by itself once the cooldown
+// is over.
+func (ob *OrderBook) Halted() (reason string, halted bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.halted(time.Now())
+}
+
+// halted is Halted at now. The caller must hold mu.
+func (ob *OrderBook) halted(now time.Time) (string, bool) {
+	if ob.operatorHalted {
+		return HaltReasonOperator, true
+	}
+	if !now.Before(ob.haltedUntil) {
+		return "", false
+	}
+	return ob.haltReason, true
+}
+
+// checkCircuitBreaker halts the book if an order's trades, the last at
+// price, moved the price more than MaxPriceMovePct from the last trade
+// price, recording why in done. Call it before lastTradePrice is updated;
+// the caller must hold mu.
+func (ob *OrderBook) checkCircuitBreaker(price fpdecimal.Decimal, done *Done) {
+	pct := ob.circuitBreaker.MaxPriceMovePct
+	if pct <= 0 || !ob.lastTradePrice.GreaterThan(fpdecimal.Zero) {
+		return
+	}
+	if !exceedsDeviation(price, ob.lastTradePrice, fpdecimal.FromFloat(pct)) {
+		return
+	}
+
+	ob.haltedUntil = time.Now().Add(time.Duration(ob.circuitBreaker.CooldownSeconds) * time.Second)
+	ob.haltReason = fmt.Sprintf("price moved from %s to %s, more than %g%%", ob.lastTradePrice, price, pct)
+	done.HaltReason = ob.haltReason
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	sender := setupMockSender(t)
+	book := NewOrderBook(newMockBackend(), WithCircuitBreaker(CircuitBreakerConfig{MaxPriceMovePct: 10, CooldownSeconds: 60}))
+
+	// trade crosses a resting ask at price with a buy
+	trade := func(t *testing.T, id string, price int64) (*Done, error) {
+		ask, err := NewLimitOrder(id+"-ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(price), GTC, "", "")
+		require.NoError(t, err)
+		if _, err := book.Process(ctx, ask); err != nil {
+			return nil, err
+		}
+		bid, err := NewLimitOrder(id+"-bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(price), GTC, "", "")
+		require.NoError(t, err)
+		return book.Process(ctx, bid)
+	}
+
+	// The first trade has no price to compare with, and 5% is within bounds
+	for _, tr := range []struct {
+		id    string
+		price int64
+	}{{"first", 100}, {"second", 105}} {
+		done, err := trade(t, tr.id, tr.price)
+		require.NoError(t, err)
+		assert.Empty(t, done.HaltReason)
+	}
+	_, halted := book.Halted()
+	assert.False(t, halted)
+
+	// 105 to 120 is a move of more than 14%
+	done, err := trade(t, "jump", 120)
+	require.NoError(t, err)
+	assert.Equal(t, "1.000", done.Processed.String(), "the trade that trips the breaker stands")
+	assert.Equal(t, "price moved from 105.000 to 120.000, more than 10%", done.HaltReason)
+	messages := sender.GetSentMessages()
+	require.NotEmpty(t, messages)
+	assert.Equal(t, done.HaltReason, messages[len(messages)-1].HaltReason)
+
+	reason, halted := book.Halted()
+	assert.True(t, halted)
+	assert.Equal(t, done.HaltReason, reason)
+	_, err = trade(t, "halted", 120)
+	assert.ErrorIs(t, err, ErrMarketHalted)
+
+	// Matching resumes once the cooldown is over
+	book.mu.Lock()
+	book.haltedUntil = time.Now().Add(-time.Second)
+	book.mu.Unlock()
+	_, halted = book.Halted()
+	assert.False(t, halted)
+	done, err = trade(t, "resumed", 121)
+	require.NoError(t, err)
+	assert.Empty(t, done.HaltReason)
+}
+
+func TestSetHalted(t *testing.T) {
+	ctx := context.Background()
+	setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+
+	ask, err := NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, ask)
+	require.NoError(t, err)
+
+	book.SetHalted(true)
+	reason, halted := book.Halted()
+	assert.True(t, halted)
+	assert.Equal(t, HaltReasonOperator, reason)
+	bid, err := NewLimitOrder("bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, bid)
+	assert.ErrorIs(t, err, ErrMarketHalted)
+	assert.NotNil(t, book.CancelOrder("ask"), "resting orders can be canceled while halted")
+
+	// Resuming also ends a circuit breaker halt
+	book.mu.Lock()
+	book.haltedUntil, book.haltReason = time.Now().Add(time.Hour), "price moved"
+	book.mu.Unlock()
+	book.SetHalted(false)
+	_, halted = book.Halted()
+	assert.False(t, halted)
+	_, err = book.Process(ctx, bid)
+	assert.NoError(t, err)
+}
+
+func TestSetCircuitBreaker(t *testing.T) {
+	book := NewOrderBook(newMockBackend())
+	assert.Equal(t, CircuitBreakerConfig{}, book.CircuitBreaker())
+	cfg := CircuitBreakerConfig{MaxPriceMovePct: 5, CooldownSeconds: 30}
+	book.SetCircuitBreaker(cfg)
+	assert.Equal(t, cfg, book.CircuitBreaker())
+}
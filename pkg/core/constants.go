@@ -1,14 +1,36 @@
 package core
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Errors
 var (
-	ErrInvalidQuantity      = errors.New("invalid quantity")
-	ErrInvalidPrice         = errors.New("invalid price")
-	ErrInvalidArgument      = errors.New("invalid argument")
-	ErrInvalidTif           = errors.New("invalid TIF")
-	ErrOrderExists          = errors.New("order exists")
-	ErrNonexistentOrder     = errors.New("nonexistent order")
-	ErrInsufficientQuantity = errors.New("insufficient quantity")
+	ErrInvalidQuantity        = errors.New("invalid quantity")
+	ErrInvalidPrice           = errors.New("invalid price")
+	ErrInvalidArgument        = errors.New("invalid argument")
+	ErrInvalidTif             = errors.New("invalid TIF")
+	ErrOrderExists            = errors.New("order exists")
+	ErrNonexistentOrder       = errors.New("nonexistent order")
+	ErrInsufficientQuantity   = errors.New("insufficient quantity")
+	ErrResetIncomplete        = errors.New("orders remain after reset")
+	ErrInvalidStateTransition = errors.New("invalid order state transition")
+	ErrOrderBookClosed        = errors.New("order book closed")
+	ErrPriceDeviationExceeded = errors.New("price deviation exceeded")
+	ErrPostOnlyWouldTake      = errors.New("post-only order would take liquidity")
+	ErrMatchingTimeout        = errors.New("matching stopped before the order was filled: context done")
+	ErrInvalidAddress         = errors.New("invalid Ethereum address")
+	ErrInvalidTickSize        = fmt.Errorf("%w: not a multiple of the tick size", ErrInvalidPrice)
+	ErrInvalidLotSize         = fmt.Errorf("%w: not a multiple of the lot size", ErrInvalidQuantity)
+	ErrBelowMinQty            = errors.New("quantity is below the minimum")
+	ErrNotAmendable           = errors.New("only resting limit orders can be amended")
+	ErrAmendWouldTake         = errors.New("amended order would take liquidity")
+	ErrInvalidVisibleQty      = errors.New("visible quantity must be positive and at most the order quantity")
+	ErrInvalidPegType         = errors.New("invalid peg type")
+	ErrNoPegReference         = errors.New("no price to peg the order to")
+	ErrSnapshotUnsupported    = errors.New("backend does not support snapshots")
+	ErrStateVersion           = errors.New("unsupported order book state version")
+	ErrInvalidExpiry          = errors.New("GTD orders need an expiry time")
+	ErrMarketHalted           = errors.New("market halted")
 )
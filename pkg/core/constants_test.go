@@ -19,6 +19,9 @@ func TestErrors(t *testing.T) {
 		{"ErrOrderExists", ErrOrderExists, "order exists"},
 		{"ErrNonexistentOrder", ErrNonexistentOrder, "nonexistent order"},
 		{"ErrInsufficientQuantity", ErrInsufficientQuantity, "insufficient quantity"},
+		{"ErrResetIncomplete", ErrResetIncomplete, "orders remain after reset"},
+		{"ErrInvalidStateTransition", ErrInvalidStateTransition, "invalid order state transition"},
+		{"ErrOrderBookClosed", ErrOrderBookClosed, "order book closed"},
 	}
 
 	for _, tt := range errorTests {
@@ -0,0 +1,168 @@
+package core
+
+import "github.com/nikolaydubina/fpdecimal"
+
+// Merge combines the results of processing slices of one order, such as the
+// child orders of a routed order, into a single Done. Order, Quantity and the
+// taker trade come from the first Done; Processed is the sum of all of them
+// and Left is taken from the last, which tracks what remains of the taker.
+// Maker trades, cancellations and activations are concatenated in order, and
+// the sequence number is the last Done's. Matching is timed from the first
+// Done's start to the last one's completion. Nil entries are skipped, and
+// Merge returns nil if every entry is nil.
+func Merge(dones ...*Done) *Done {
+	var merged *Done
+	for _, d := range dones {
+		if d == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &Done{
+				Order:     d.Order,
+				Quantity:  d.Quantity,
+				Trades:    make([]TradeOrder, 0, len(d.Trades)),
+				Canceled:  make([]*Order, 0, len(d.Canceled)),
+				Activated: make([]*Order, 0, len(d.Activated)),
+				Processed: fpdecimal.Zero,
+
+				MatchStartedAt: d.MatchStartedAt,
+			}
+			if taker := d.takerTrade(); taker != nil {
+				merged.Trades = append(merged.Trades, *taker)
+			}
+		}
+
+		merged.Processed = merged.Processed.Add(d.Processed)
+		merged.Left = d.Left
+		merged.Stored = merged.Stored || d.Stored
+		merged.StopActivated = merged.StopActivated || d.StopActivated
+		merged.CancelledByOCO = merged.CancelledByOCO || d.CancelledByOCO
+		merged.STPTriggered = merged.STPTriggered || d.STPTriggered
+		if d.HaltReason != "" {
+			merged.HaltReason = d.HaltReason
+		}
+		merged.seq = d.seq
+		merged.MatchCompletedAt = d.MatchCompletedAt
+		merged.Trades = append(merged.Trades, d.makerTrades()...)
+		merged.Canceled = append(merged.Canceled, d.Canceled...)
+		merged.Activated = append(merged.Activated, d.Activated...)
+	}
+
+	if merged != nil {
+		if taker := merged.takerTrade(); taker != nil {
+			taker.Quantity = merged.Processed
+		}
+	}
+	return merged
+}
+
+// Split divides d into one Done per portion of its quantity. Maker trades
+// are handed out in order, each portion taking fills until it is full, so a
+// trade may be divided between two portions. Fills beyond the sum of the
+// portions are left out. Cancellations and activations go to the first
+// portion, and a portion is stored if d was stored and it is not filled.
+// Every portion keeps d's sequence number and match times.
+func (d *Done) Split(portions []fpdecimal.Decimal) []*Done {
+	makers := d.makerTrades()
+	next := 0
+	// remaining is what is left of makers[next] after earlier portions took their share
+	var remaining fpdecimal.Decimal
+	if len(makers) > 0 {
+		remaining = makers[0].Quantity
+	}
+
+	parts := make([]*Done, 0, len(portions))
+	for i, portion := range portions {
+		part := &Done{
+			Order:         d.Order,
+			Quantity:      portion,
+			Trades:        make([]TradeOrder, 0),
+			Canceled:      make([]*Order, 0),
+			Activated:     make([]*Order, 0),
+			Processed:     fpdecimal.Zero,
+			StopActivated: d.StopActivated,
+			seq:           d.seq,
+
+			MatchStartedAt:   d.MatchStartedAt,
+			MatchCompletedAt: d.MatchCompletedAt,
+		}
+		if i == 0 {
+			part.Canceled = append(part.Canceled, d.Canceled...)
+			part.Activated = append(part.Activated, d.Activated...)
+			part.CancelledByOCO = d.CancelledByOCO
+			part.STPTriggered = d.STPTriggered
+			part.HaltReason = d.HaltReason
+		}
+
+		for next < len(makers) && part.Processed.LessThan(portion) {
+			fill := min(remaining, portion.Sub(part.Processed))
+			trade := makers[next]
+			trade.Quantity = fill
+			part.Trades = append(part.Trades, trade)
+			part.Processed = part.Processed.Add(fill)
+
+			remaining = remaining.Sub(fill)
+			if remaining.Equal(fpdecimal.Zero) {
+				next++
+				if next < len(makers) {
+					remaining = makers[next].Quantity
+				}
+			}
+		}
+
+		if taker := d.takerTrade(); taker != nil {
+			entry := *taker
+			entry.Quantity = part.Processed
+			part.Trades = append([]TradeOrder{entry}, part.Trades...)
+		}
+		part.Left = portion.Sub(part.Processed)
+		part.Stored = d.Stored && part.Left.GreaterThan(fpdecimal.Zero)
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// VWAP returns the volume-weighted average price of the maker trades, or zero
+// if there are none
+func (d *Done) VWAP() fpdecimal.Decimal {
+	notional := fpdecimal.Zero
+	volume := fpdecimal.Zero
+	for _, trade := range d.makerTrades() {
+		notional = notional.Add(trade.Price.Mul(trade.Quantity))
+		volume = volume.Add(trade.Quantity)
+	}
+	if volume.Equal(fpdecimal.Zero) {
+		return fpdecimal.Zero
+	}
+	return notional.Div(volume)
+}
+
+// FormatVWAP returns VWAP formatted with the price precision of d's book
+func (d *Done) FormatVWAP() string {
+	return format(d.VWAP(), d.pricePrecision)
+}
+
+// takerTrade returns the trade entry of the processed order itself, if any
+func (d *Done) takerTrade() *TradeOrder {
+	if d.Order == nil {
+		return nil
+	}
+	for i := range d.Trades {
+		if d.Trades[i].OrderID == d.Order.ID() {
+			return &d.Trades[i]
+		}
+	}
+	return nil
+}
+
+// makerTrades returns the trades against resting orders
+func (d *Done) makerTrades() []TradeOrder {
+	makers := make([]TradeOrder, 0, len(d.Trades))
+	for _, trade := range d.Trades {
+		if d.Order != nil && trade.OrderID == d.Order.ID() {
+			continue
+		}
+		makers = append(makers, trade)
+	}
+	return makers
+}
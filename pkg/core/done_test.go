@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fillFromNewBook processes a market buy of quantity against a fresh book
+// holding one ask of the same quantity at price
+func fillFromNewBook(t *testing.T, id string, quantity, price int64) *Done {
+	t.Helper()
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+
+	ask, err := NewLimitOrder("ask-"+id, Sell, fpdecimal.FromInt(quantity), fpdecimal.FromInt(price), GTC, "", "maker")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, ask)
+	require.NoError(t, err)
+
+	buy, err := NewMarketOrder(id, Buy, fpdecimal.FromInt(quantity), "taker")
+	require.NoError(t, err)
+	done, err := book.Process(ctx, buy)
+	require.NoError(t, err)
+	return done
+}
+
+func TestMerge(t *testing.T) {
+	setupMockSender(t)
+	first := fillFromNewBook(t, "buy-a", 3, 100)
+	second := fillFromNewBook(t, "buy-b", 7, 110)
+
+	merged := Merge(first, nil, second)
+	require.NotNil(t, merged)
+	assert.Equal(t, first.Order, merged.Order)
+	assert.Equal(t, fpdecimal.FromInt(3), merged.Quantity)
+	assert.Equal(t, fpdecimal.FromInt(10), merged.Processed)
+	assert.Equal(t, fpdecimal.Zero, merged.Left)
+	assert.False(t, merged.Stored)
+
+	// Both fills are kept, under the first Done's taker entry only
+	assert.Len(t, merged.makerTrades(), len(first.makerTrades())+len(second.makerTrades()))
+	require.Len(t, merged.Trades, 3)
+	assert.Equal(t, "buy-a", merged.Trades[0].OrderID)
+	assert.Equal(t, fpdecimal.FromInt(10), merged.Trades[0].Quantity)
+	assert.Equal(t, "ask-buy-a", merged.Trades[1].OrderID)
+	assert.Equal(t, "ask-buy-b", merged.Trades[2].OrderID)
+
+	// (3*100 + 7*110) / 10
+	assert.Equal(t, fpdecimal.FromInt(107), merged.VWAP())
+
+	assert.Nil(t, Merge())
+	assert.Nil(t, Merge(nil))
+}
+
+func TestDone_Split(t *testing.T) {
+	setupMockSender(t)
+	done := Merge(fillFromNewBook(t, "buy-a", 3, 100), fillFromNewBook(t, "buy-b", 7, 110))
+
+	parts := done.Split([]fpdecimal.Decimal{fpdecimal.FromInt(4), fpdecimal.FromInt(8)})
+	require.Len(t, parts, 2)
+
+	// The first portion takes the fill of 3 and 1 of the fill of 7
+	assert.Equal(t, fpdecimal.FromInt(4), parts[0].Processed)
+	assert.Equal(t, fpdecimal.Zero, parts[0].Left)
+	require.Len(t, parts[0].Trades, 3)
+	assert.Equal(t, fpdecimal.FromInt(4), parts[0].Trades[0].Quantity)
+	assert.Equal(t, fpdecimal.FromInt(1), parts[0].Trades[2].Quantity)
+
+	// The second gets the remaining 6 and is 2 short
+	assert.Equal(t, fpdecimal.FromInt(6), parts[1].Processed)
+	assert.Equal(t, fpdecimal.FromInt(2), parts[1].Left)
+	require.Len(t, parts[1].Trades, 2)
+	assert.Equal(t, "ask-buy-b", parts[1].Trades[1].OrderID)
+	assert.Equal(t, fpdecimal.FromInt(110), parts[1].VWAP())
+
+	assert.Equal(t, done.Processed, Merge(parts...).Processed)
+}
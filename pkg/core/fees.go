@@ -0,0 +1,54 @@
+package core
+
+import "github.com/nikolaydubina/fpdecimal"
+
+// bpsPerUnit is how many basis points make the whole notional
+const bpsPerUnit = 10000
+
+// FeeSchedule is what an order book charges on each fill, in basis points of
+// its notional, the matched quantity times the fill price
+type FeeSchedule struct {
+	// MakerFeeBps is charged to the resting order
+	MakerFeeBps int
+	// TakerFeeBps is charged to the incoming order
+	TakerFeeBps int
+}
+
+// WithFeeSchedule sets the fees the book charges on its fills
+func WithFeeSchedule(fees FeeSchedule) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.fees = fees
+	}
+}
+
+// FeeSchedule returns the fees the book charges on its fills
+func (ob *OrderBook) FeeSchedule() FeeSchedule {
+	return ob.fees
+}
+
+// fee returns bps basis points of quantity at price, truncated to the
+// engine's precision
+func fee(quantity, price fpdecimal.Decimal, bps int) fpdecimal.Decimal {
+	if bps == 0 {
+		return fpdecimal.Zero
+	}
+	return quantity.Mul(price).Mul(fpdecimal.FromInt(bps)).Div(fpdecimal.FromInt(bpsPerUnit))
+}
+
+// chargeFees records the fees of a fill of quantity at price on the maker
+// entry appended last to done. The taker's own entry carries no fees: what
+// it owes is the sum of TakerFee over the fills.
+func (ob *OrderBook) chargeFees(done *Done, quantity, price fpdecimal.Decimal) {
+	fill := &done.Trades[len(done.Trades)-1]
+	fill.MakerFee = fee(quantity, price, ob.fees.MakerFeeBps)
+	fill.TakerFee = fee(quantity, price, ob.fees.TakerFeeBps)
+}
+
+// formatFee formats fee like a price, or as an empty string when nothing is
+// owed
+func formatFee(fee fpdecimal.Decimal, precision int) string {
+	if fee.Equal(fpdecimal.Zero) {
+		return ""
+	}
+	return format(fee, precision)
+}
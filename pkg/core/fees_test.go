@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeSchedule(t *testing.T) {
+	ctx := context.Background()
+	sender := setupMockSender(t)
+	book := NewOrderBook(newMockBackend(), WithFeeSchedule(FeeSchedule{MakerFeeBps: 2, TakerFeeBps: 5}))
+	assert.Equal(t, FeeSchedule{MakerFeeBps: 2, TakerFeeBps: 5}, book.FeeSchedule())
+
+	for _, ask := range []struct {
+		id    string
+		price int64
+	}{{"ask-1", 100}, {"ask-2", 200}} {
+		order, err := NewLimitOrder(ask.id, Sell, fpdecimal.FromInt(10), fpdecimal.FromInt(ask.price), GTC, "", "")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+
+	bid, err := NewLimitOrder("bid", Buy, fpdecimal.FromInt(15), fpdecimal.FromInt(200), GTC, "", "")
+	require.NoError(t, err)
+	done, err := book.Process(ctx, bid)
+	require.NoError(t, err)
+
+	// 10 at 100 is a notional of 1000 and 5 at 200 one of 1000
+	require.Len(t, done.Trades, 3)
+	assert.Equal(t, "bid", done.Trades[0].OrderID)
+	assert.Equal(t, fpdecimal.Zero, done.Trades[0].MakerFee)
+	assert.Equal(t, fpdecimal.Zero, done.Trades[0].TakerFee)
+	for _, fill := range done.Trades[1:] {
+		assert.Equal(t, "0.200", fill.MakerFee.String(), fill.OrderID)
+		assert.Equal(t, "0.500", fill.TakerFee.String(), fill.OrderID)
+	}
+
+	messages := sender.GetSentMessages()
+	require.NotEmpty(t, messages)
+	trades := messages[len(messages)-1].Trades
+	require.Len(t, trades, 3)
+	assert.Empty(t, trades[0].MakerFee)
+	assert.Empty(t, trades[0].TakerFee)
+	assert.Equal(t, "0.200", trades[1].MakerFee)
+	assert.Equal(t, "0.500", trades[2].TakerFee)
+
+	// Books without a schedule charge nothing
+	free := NewOrderBook(newMockBackend())
+	ask, err := NewLimitOrder("free-ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "")
+	require.NoError(t, err)
+	_, err = free.Process(ctx, ask)
+	require.NoError(t, err)
+	bid, err = NewLimitOrder("free-bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "")
+	require.NoError(t, err)
+	done, err = free.Process(ctx, bid)
+	require.NoError(t, err)
+	require.Len(t, done.Trades, 2)
+	assert.Equal(t, fpdecimal.Zero, done.Trades[1].MakerFee)
+	assert.Equal(t, fpdecimal.Zero, done.Trades[1].TakerFee)
+}
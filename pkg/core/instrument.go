@@ -0,0 +1,128 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// InstrumentConfig holds trading rules for the instrument an order book lists
+type InstrumentConfig struct {
+	// MaxPriceDeviationPct is the largest move, in percent, allowed between a
+	// fill and the trade before it. Zero disables the check.
+	MaxPriceDeviationPct float64
+	// PricePrecision and QtyPrecision are the number of fraction digits
+	// prices and quantities are shown with. Zero uses the engine's precision,
+	// fpdecimal.FractionDigits; values are always stored at that precision,
+	// so more digits only pad with zeros and fewer round.
+	PricePrecision int
+	QtyPrecision   int
+}
+
+// SetInstrumentConfig replaces the book's instrument trading rules
+func (ob *OrderBook) SetInstrumentConfig(cfg InstrumentConfig) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.instrument = cfg
+}
+
+// InstrumentConfig returns the book's instrument trading rules
+func (ob *OrderBook) InstrumentConfig() InstrumentConfig {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.instrument
+}
+
+// FormatPrice formats a price with the book's price precision
+func (ob *OrderBook) FormatPrice(d fpdecimal.Decimal) string {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return format(d, ob.instrument.PricePrecision)
+}
+
+// FormatQty formats a quantity with the book's quantity precision
+func (ob *OrderBook) FormatQty(d fpdecimal.Decimal) string {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return format(d, ob.instrument.QtyPrecision)
+}
+
+// format returns d with precision fraction digits, rounding half away from
+// zero. A precision of zero or less uses fpdecimal.FractionDigits, so every
+// stored digit is shown, zero included.
+func format(d fpdecimal.Decimal, precision int) string {
+	if precision <= 0 {
+		precision = int(fpdecimal.FractionDigits)
+	}
+	r, ok := new(big.Rat).SetString(d.String())
+	if !ok {
+		return d.String()
+	}
+	return r.FloatString(precision)
+}
+
+// checkPriceDeviation walks the fills taker would get from the opposite side,
+// comparing each fill price with the trade before it, starting from the last
+// trade price. It returns ErrPriceDeviationExceeded as soon as one moves more
+// than MaxPriceDeviationPct, before anything in the book has changed. A
+// quote order's budget stops the walk where matching would, once it cannot
+// buy a lot at the next price.
+func (ob *OrderBook) checkPriceDeviation(taker *Order, opposite interface {
+	Prices() []fpdecimal.Decimal
+	Orders(price fpdecimal.Decimal) []*Order
+}) error {
+	if ob.instrument.MaxPriceDeviationPct <= 0 {
+		return nil
+	}
+	maxPct := fpdecimal.FromFloat(ob.instrument.MaxPriceDeviationPct)
+
+	remaining := taker.Quantity()
+	reference := ob.lastTradePrice
+	for _, price := range opposite.Prices() {
+		if remaining.LessThanOrEqual(fpdecimal.Zero) {
+			break
+		}
+		if taker.IsLimitOrder() && !limitCrosses(taker, price) {
+			break
+		}
+
+		// A quote order's budget is walked in base quantity, as matching does
+		levelQty := ob.affordable(taker, remaining, price)
+		if !levelQty.GreaterThan(fpdecimal.Zero) {
+			break
+		}
+		for _, maker := range opposite.Orders(price) {
+			if !levelQty.GreaterThan(fpdecimal.Zero) {
+				break
+			}
+			if reference.GreaterThan(fpdecimal.Zero) && exceedsDeviation(price, reference, maxPct) {
+				return ErrPriceDeviationExceeded
+			}
+			reference = price
+			matchQty := maker.Quantity()
+			if levelQty.LessThan(matchQty) {
+				matchQty = levelQty
+			}
+			levelQty = levelQty.Sub(matchQty)
+			remaining = remaining.Sub(spent(taker, matchQty, price))
+		}
+	}
+	return nil
+}
+
+// limitCrosses reports whether limit order taker trades at price
+func limitCrosses(taker *Order, price fpdecimal.Decimal) bool {
+	if taker.Side() == Buy {
+		return price.LessThanOrEqual(taker.Price())
+	}
+	return price.GreaterThanOrEqual(taker.Price())
+}
+
+// exceedsDeviation reports whether |price - reference| / reference * 100 > maxPct
+func exceedsDeviation(price, reference, maxPct fpdecimal.Decimal) bool {
+	diff := price.Sub(reference)
+	if diff.LessThan(fpdecimal.Zero) {
+		diff = fpdecimal.Zero.Sub(diff)
+	}
+	return diff.Mul(fpdecimal.FromInt(100)).GreaterThan(reference.Mul(maxPct))
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// MatchingAlgo decides how an incoming order's quantity is shared among the
+// orders resting at a price level
+type MatchingAlgo string
+
+const (
+	// PriceTime fills the orders at a level oldest first
+	PriceTime MatchingAlgo = "price-time"
+	// ProRata shares the quantity among the orders at a level in proportion
+	// to their size. What rounding leaves over goes to them oldest first.
+	ProRata MatchingAlgo = "pro-rata"
+)
+
+// MatchingRules are the rules an order book matches and accepts orders by.
+// The zero value is price-time priority accepting any price and quantity.
+type MatchingRules struct {
+	// Algo is how a level's orders are filled; empty means PriceTime
+	Algo MatchingAlgo
+	// NoPriceImprovement fills a crossing limit order at its own limit
+	// price instead of at the better price of the orders it matches
+	NoPriceImprovement bool
+	// PostOnlyDefault makes every GTC limit order post-only
+	PostOnlyDefault bool
+	// MinQty is the smallest quantity an order may have. Zero allows any.
+	MinQty fpdecimal.Decimal
+	// TickSize and LotSize are the steps prices and quantities must be
+	// multiples of. Zero allows any value the engine can represent.
+	TickSize fpdecimal.Decimal
+	LotSize  fpdecimal.Decimal
+	// STP is what happens when an order would trade with a resting order of
+	// the same user; empty lets it trade
+	STP STPMode
+}
+
+// WithMatchingRules sets the rules the book matches and accepts orders by
+func WithMatchingRules(rules MatchingRules) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.rules = rules
+	}
+}
+
+// WithTickSize sets the step the book's prices must be multiples of, keeping
+// its other matching rules. Apply it after WithMatchingRules.
+func WithTickSize(tickSize fpdecimal.Decimal) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.rules.TickSize = tickSize
+	}
+}
+
+// WithLotSize sets the step the book's quantities must be multiples of,
+// keeping its other matching rules. Apply it after WithMatchingRules.
+func WithLotSize(lotSize fpdecimal.Decimal) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.rules.LotSize = lotSize
+	}
+}
+
+// MatchingRules returns the rules the book matches and accepts orders by
+func (ob *OrderBook) MatchingRules() MatchingRules {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.rules
+}
+
+// CheckRules returns the error Process would reject order with for breaking
+// the book's matching rules, without processing it
+func (ob *OrderBook) CheckRules(order *Order) error {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.checkRules(order)
+}
+
+// checkRules returns an error if order's prices or quantity break the book's
+// matching rules
+func (ob *OrderBook) checkRules(order *Order) error {
+	rules := ob.rules
+	// A quote order's quantity is a budget, not a quantity the rules apply to
+	if !order.IsQuote() {
+		if rules.MinQty.GreaterThan(fpdecimal.Zero) && order.Quantity().LessThan(rules.MinQty) {
+			return ErrBelowMinQty
+		}
+		if !isMultiple(order.Quantity(), rules.LotSize) {
+			return ErrInvalidLotSize
+		}
+	}
+	for _, price := range []fpdecimal.Decimal{order.Price(), order.StopPrice()} {
+		if price.GreaterThan(fpdecimal.Zero) && !isMultiple(price, rules.TickSize) {
+			return ErrInvalidTickSize
+		}
+	}
+	return nil
+}
+
+// isMultiple reports whether d is a whole multiple of step. Every value is
+// a multiple of a step that is not positive.
+func isMultiple(d, step fpdecimal.Decimal) bool {
+	if !step.GreaterThan(fpdecimal.Zero) {
+		return true
+	}
+	return d.Scaled()%step.Scaled() == 0
+}
+
+// affordable returns how much taker can fill at price with remaining left
+// of its quantity. For a quote order remaining is a budget in the quote
+// currency, and the quantity it buys is rounded down to the lot size.
+func (ob *OrderBook) affordable(taker *Order, remaining, price fpdecimal.Decimal) fpdecimal.Decimal {
+	if !taker.IsQuote() {
+		return remaining
+	}
+	quantity := remaining.Div(price)
+	if lot := ob.rules.LotSize.Scaled(); lot > 0 {
+		quantity = fpdecimal.FromIntScaled(quantity.Scaled() - quantity.Scaled()%lot)
+	}
+	return quantity
+}
+
+// spent returns what a fill of quantity at price takes from taker's
+// remaining quantity: the quantity itself, or for a quote order its cost
+func spent(taker *Order, quantity, price fpdecimal.Decimal) fpdecimal.Decimal {
+	if !taker.IsQuote() {
+		return quantity
+	}
+	return quantity.Mul(price)
+}
+
+// makersAt returns the orders at price on side for a taker with quantity
+// left to fill, and the most the taker may fill of each. The limits are nil
+// under price-time priority, where each order is filled in full before the
+// next.
+func (ob *OrderBook) makersAt(side interface {
+	Orders(price fpdecimal.Decimal) []*Order
+}, price, quantity fpdecimal.Decimal) (*levelIterator, map[string]fpdecimal.Decimal) {
+	if ob.rules.Algo != ProRata {
+		return &levelIterator{OrderIterator: makerIterator(side, price)}, nil
+	}
+	orders := side.Orders(price)
+	return &levelIterator{OrderIterator: &sliceIterator{orders: orders}}, proRataAllocation(orders, quantity, ob.rules.LotSize)
+}
+
+// levelIterator yields the orders at a price level, then the iceberg orders
+// requeued at the back of the level after showing more of their reserve
+type levelIterator struct {
+	OrderIterator
+	requeued []*Order
+}
+
+// Next returns the next order at the level
+func (it *levelIterator) Next() (*Order, bool) {
+	if order, ok := it.OrderIterator.Next(); ok {
+		return order, true
+	}
+	if len(it.requeued) == 0 {
+		return nil, false
+	}
+	order := it.requeued[0]
+	it.requeued = it.requeued[1:]
+	return order, true
+}
+
+// requeue yields order again once the orders ahead of it are done
+func (it *levelIterator) requeue(order *Order) {
+	it.requeued = append(it.requeued, order)
+}
+
+// proRataAllocation shares quantity among orders in proportion to their
+// quantities, in whole lots when lot is positive. Shares are rounded down
+// and the remainder is handed out oldest order first.
+func proRataAllocation(orders []*Order, quantity, lot fpdecimal.Decimal) map[string]fpdecimal.Decimal {
+	allocation := make(map[string]fpdecimal.Decimal, len(orders))
+	total := fpdecimal.Zero
+	for _, order := range orders {
+		if order.Quantity().GreaterThan(fpdecimal.Zero) {
+			total = total.Add(order.Quantity())
+		}
+	}
+
+	if quantity.GreaterThanOrEqual(total) {
+		for _, order := range orders {
+			allocation[order.ID()] = order.Quantity()
+		}
+		return allocation
+	}
+
+	step := int64(1)
+	if lot.GreaterThan(fpdecimal.Zero) {
+		step = lot.Scaled()
+	}
+	left := quantity
+	for _, order := range orders {
+		if !order.Quantity().GreaterThan(fpdecimal.Zero) {
+			continue
+		}
+		// quantity * order / total, computed exactly since the product of
+		// two scaled quantities can overflow an int64
+		share := new(big.Int).Mul(big.NewInt(quantity.Scaled()), big.NewInt(order.Quantity().Scaled()))
+		share.Quo(share, big.NewInt(total.Scaled()))
+		scaled := share.Int64() / step * step
+		allocation[order.ID()] = fpdecimal.FromIntScaled(scaled)
+		left = left.Sub(fpdecimal.FromIntScaled(scaled))
+	}
+
+	for _, order := range orders {
+		if !left.GreaterThan(fpdecimal.Zero) {
+			break
+		}
+		room := order.Quantity().Sub(allocation[order.ID()])
+		if !room.GreaterThan(fpdecimal.Zero) {
+			continue
+		}
+		extra := room
+		if left.LessThan(room) {
+			extra = left
+		}
+		allocation[order.ID()] = allocation[order.ID()].Add(extra)
+		left = left.Sub(extra)
+	}
+	return allocation
+}
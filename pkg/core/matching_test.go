@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProRataMatching(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend(), WithMatchingRules(MatchingRules{Algo: ProRata}))
+
+	// Three asks at one level, sized 1:3:6
+	for _, ask := range []struct {
+		id  string
+		qty int64
+	}{{"small", 1}, {"medium", 3}, {"large", 6}} {
+		order, err := NewLimitOrder(ask.id, Sell, fpdecimal.FromInt(ask.qty), fpdecimal.FromInt(100), GTC, "", "")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+
+	taker, err := NewMarketOrder("taker", Buy, fpdecimal.FromInt(5), "")
+	require.NoError(t, err)
+	done, err := book.Process(ctx, taker)
+	require.NoError(t, err)
+	assert.Equal(t, "5.000", done.Processed.String())
+
+	filled := make(map[string]string)
+	for _, trade := range done.makerTrades() {
+		filled[trade.OrderID] = trade.Quantity.String()
+	}
+	// Each maker gets a tenth of the taker per tenth of the level it holds
+	assert.Equal(t, map[string]string{"small": "0.500", "medium": "1.500", "large": "3.000"}, filled)
+	assert.Equal(t, "0.500", book.GetOrder("small").Quantity().String())
+	assert.Equal(t, "3.000", book.GetOrder("large").Quantity().String())
+}
+
+func TestProRataAllocation(t *testing.T) {
+	orders := make([]*Order, 0, 3)
+	for _, id := range []string{"a", "b", "c"} {
+		order, err := NewLimitOrder(id, Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "")
+		require.NoError(t, err)
+		orders = append(orders, order)
+	}
+
+	// Each share of 2 lots out of 3 rounds down to none; the leftover goes oldest first
+	allocation := proRataAllocation(orders, fpdecimal.FromInt(2), fpdecimal.FromInt(1))
+	assert.Equal(t, "1.000", allocation["a"].String())
+	assert.Equal(t, "1.000", allocation["b"].String())
+	assert.True(t, allocation["c"].Equal(fpdecimal.Zero))
+
+	// Enough quantity fills every order
+	allocation = proRataAllocation(orders, fpdecimal.FromInt(5), fpdecimal.Zero)
+	for _, order := range orders {
+		assert.Equal(t, "1.000", allocation[order.ID()].String())
+	}
+}
+
+func TestMatchingRules(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend(), WithMatchingRules(MatchingRules{
+		PostOnlyDefault: true,
+		MinQty:          fpdecimal.FromInt(2),
+		TickSize:        fpdecimal.FromFloat(0.25),
+		LotSize:         fpdecimal.FromInt(1),
+	}))
+
+	limit := func(id string, side Side, qty, price float64, tif TIF) error {
+		order, err := NewLimitOrder(id, side, fpdecimal.FromFloat(qty), fpdecimal.FromFloat(price), tif, "", "")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		return err
+	}
+
+	assert.ErrorIs(t, limit("small", Buy, 1, 100, GTC), ErrBelowMinQty)
+	assert.ErrorIs(t, limit("odd-lot", Buy, 2.5, 100, GTC), ErrInvalidLotSize)
+	assert.ErrorIs(t, limit("off-tick", Buy, 2, 100.1, GTC), ErrInvalidTickSize)
+	assert.ErrorIs(t, limit("odd-lot", Buy, 2.5, 100, GTC), ErrInvalidQuantity)
+	assert.ErrorIs(t, limit("off-tick", Buy, 2, 100.1, GTC), ErrInvalidPrice)
+	require.NoError(t, limit("bid", Buy, 2, 100.25, GTC))
+
+	// GTC limit orders rest only; IOC orders may still take
+	assert.ErrorIs(t, limit("gtc-ask", Sell, 2, 100, GTC), ErrPostOnlyWouldTake)
+	require.NoError(t, limit("ioc-ask", Sell, 2, 100, IOC))
+	assert.Nil(t, book.GetOrder("bid"))
+}
+
+func TestNoPriceImprovement(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend(), WithMatchingRules(MatchingRules{NoPriceImprovement: true}))
+
+	ask, err := NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, ask)
+	require.NoError(t, err)
+
+	bid, err := NewLimitOrder("bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(105), GTC, "", "")
+	require.NoError(t, err)
+	done, err := book.Process(ctx, bid)
+	require.NoError(t, err)
+	for _, trade := range done.Trades {
+		assert.Equal(t, "105.000", trade.Price.String(), "order %s", trade.OrderID)
+	}
+}
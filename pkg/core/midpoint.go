@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// Midpoint returns the mean of the best bid and best ask, the price resting
+// midpoint orders currently trade at. It reports false when either side of
+// the book is empty.
+func (ob *OrderBook) Midpoint() (fpdecimal.Decimal, bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.midpoint()
+}
+
+// midpoint is Midpoint without locking, for use while mu is held
+func (ob *OrderBook) midpoint() (fpdecimal.Decimal, bool) {
+	bid, ok := bestPrice(ob.backend.GetBids())
+	if !ok {
+		return fpdecimal.Zero, false
+	}
+	ask, ok := bestPrice(ob.backend.GetAsks())
+	if !ok {
+		return fpdecimal.Zero, false
+	}
+	return MidPrice(bid, ask), true
+}
+
+// MidPrice returns the mean of a bid and an ask price, the way Midpoint
+// computes it
+func MidPrice(bid, ask fpdecimal.Decimal) fpdecimal.Decimal {
+	return bid.Add(ask).Div(fpdecimal.FromInt(2))
+}
+
+// bestPrice returns the best price level of a backend side, if it has one
+func bestPrice(side interface{}) (fpdecimal.Decimal, bool) {
+	var best []fpdecimal.Decimal
+	switch side := side.(type) {
+	case interface {
+		TopPrices(n int) []fpdecimal.Decimal
+	}:
+		best = side.TopPrices(1)
+	case interface{ Prices() []fpdecimal.Decimal }:
+		best = side.Prices()
+	}
+	if len(best) == 0 {
+		return fpdecimal.Zero, false
+	}
+	return best[0], true
+}
+
+// fillPegged matches quantity of taker against the resting midpoint orders
+// on the other side at price, oldest first. It returns what is left of
+// quantity, how much was filled, and whether self-trade prevention stopped
+// the taker at a midpoint order of its own user.
+func (ob *OrderBook) fillPegged(ctx context.Context, taker *Order, quantity, price fpdecimal.Decimal, done *Done) (left, filled fpdecimal.Decimal, stopped bool, err error) {
+	filled = fpdecimal.Zero
+	for _, maker := range ob.backend.GetMidpointOrders(oppositeOrder(taker.Side())) {
+		if !quantity.GreaterThan(fpdecimal.Zero) {
+			break
+		}
+		if ob.selfTrade(taker, maker) {
+			if ob.preventSelfTrade(ctx, maker, done) {
+				return quantity, filled, true, nil
+			}
+			continue
+		}
+		maker.SetMaker()
+		matchQty := min(quantity, maker.Quantity())
+		if err := maker.DecreaseQuantity(matchQty); err != nil {
+			return quantity, filled, false, fmt.Errorf("filling maker order %s: %w", maker.ID(), err)
+		}
+		quantity = quantity.Sub(matchQty)
+		filled = filled.Add(matchQty)
+
+		done.appendOrder(taker, matchQty, price)
+		done.appendOrder(maker, matchQty, price)
+		ob.chargeFees(done, matchQty, price)
+
+		if maker.Quantity().Equal(fpdecimal.Zero) {
+			ob.backend.RemoveFromMidpoint(maker)
+			ob.backend.DeleteOrder(maker.ID())
+			ob.checkOCO(ctx, maker, done)
+		} else {
+			ob.backend.UpdateOrder(maker)
+		}
+	}
+	return quantity, filled, false, nil
+}
+
+// processMidpointOrder fills a midpoint order against the resting midpoint
+// orders on the other side, if the book has a midpoint, and rests the rest,
+// unless self-trade prevention canceled it. Orders on the lit book trade
+// with it only when they arrive.
+func (ob *OrderBook) processMidpointOrder(ctx context.Context, order *Order) (*Done, error) {
+	if existing := ob.backend.GetOrder(order.ID()); existing != nil {
+		return nil, ErrOrderExists
+	}
+
+	if err := order.Transition(StateOpen); err != nil {
+		return nil, err
+	}
+
+	done := ob.newDone(order)
+	if err := ob.backend.StoreOrder(order); err != nil {
+		return nil, fmt.Errorf("error storing midpoint order: %w", err)
+	}
+	order.SetTaker()
+
+	quantity := order.Quantity()
+	processed := fpdecimal.Zero
+	stopped := false
+	price, ok := ob.midpoint()
+	if ok {
+		var err error
+		quantity, processed, stopped, err = ob.fillPegged(ctx, order, quantity, price, done)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if processed.GreaterThan(fpdecimal.Zero) {
+		transition(ctx, order, fillState(quantity))
+	}
+	if stopped {
+		transition(ctx, order, StateCanceled)
+		done.appendCanceled(order)
+		ob.backend.DeleteOrder(order.ID())
+	} else if quantity.GreaterThan(fpdecimal.Zero) {
+		order.SetQuantity(quantity)
+		ob.backend.UpdateOrder(order)
+		ob.backend.AppendToMidpoint(order)
+		done.Stored = true
+	} else {
+		ob.backend.DeleteOrder(order.ID())
+	}
+	done.appendOrder(order, processed, price)
+	done.Left = quantity
+	done.Processed = processed
+
+	if processed.GreaterThan(fpdecimal.Zero) {
+		ob.lastTradePrice = price
+		ob.checkStopOrderTrigger(ctx, ob.lastTradePrice)
+	}
+	if processed.GreaterThan(fpdecimal.Zero) || done.STPTriggered {
+		ob.publishMatch(ctx, done)
+	}
+	return done, nil
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderBook_MidpointPeggedOrder(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+
+	process := func(order *Order, err error) *Done {
+		t.Helper()
+		require.NoError(t, err)
+		done, err := book.Process(ctx, order)
+		require.NoError(t, err)
+		return done
+	}
+
+	process(NewLimitOrder("bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "buyer"))
+	process(NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(110), GTC, "", "seller"))
+	mid, ok := book.Midpoint()
+	require.True(t, ok)
+	assert.Equal(t, fpdecimal.FromInt(105), mid)
+
+	// The midpoint buy has no price and rests
+	done := process(NewMidpointPeggedOrder("peg", Buy, fpdecimal.FromInt(3), "", "test_user"))
+	assert.True(t, done.Stored)
+	assert.Equal(t, fpdecimal.Zero, done.Processed)
+	peg := book.GetOrder("peg")
+	require.NotNil(t, peg)
+	assert.Equal(t, fpdecimal.Zero, peg.Price())
+	// It rests in the backend's midpoint queue, not on the bid side
+	snapshot := book.Snapshot()
+	require.Len(t, snapshot.Midpoint, 1)
+	assert.Equal(t, "peg", snapshot.Midpoint[0].ID())
+	assert.Len(t, snapshot.Bids, 1)
+	assert.Equal(t, 3, book.OrderCount())
+
+	// A sell above the midpoint does not reach the peg; it rests and narrows
+	// the spread to 100/106
+	done = process(NewLimitOrder("narrow", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(106), GTC, "", "seller"))
+	assert.True(t, done.Stored)
+	assert.Equal(t, fpdecimal.FromInt(3), book.GetOrder("peg").Quantity())
+
+	// A sell at the new midpoint of 103 fills against the peg there
+	done = process(NewLimitOrder("sell", Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(103), GTC, "", "seller"))
+	assert.Equal(t, fpdecimal.FromInt(2), done.Processed)
+	assert.False(t, done.Stored)
+	maker := done.GetTradeOrder("peg")
+	require.NotNil(t, maker)
+	assert.Equal(t, fpdecimal.FromInt(103), maker.Price)
+	assert.Equal(t, fpdecimal.FromInt(2), maker.Quantity)
+	assert.Equal(t, fpdecimal.FromInt(1), book.GetOrder("peg").Quantity())
+	assert.Equal(t, fpdecimal.FromInt(1), book.GetOrder("bid").Quantity(), "the lit bid is not touched")
+
+	// An opposite midpoint order fills the rest of the peg at the midpoint
+	done = process(NewMidpointPeggedOrder("peg-sell", Sell, fpdecimal.FromInt(1), "", "test_user"))
+	assert.Equal(t, fpdecimal.FromInt(1), done.Processed)
+	assert.Equal(t, fpdecimal.FromInt(103), done.GetTradeOrder("peg").Price)
+	assert.Nil(t, book.GetOrder("peg"))
+	assert.Nil(t, book.GetOrder("peg-sell"))
+}
+
+func TestOrderBook_MidpointPeggedOrderCancel(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+
+	peg, err := NewMidpointPeggedOrder("peg", Sell, fpdecimal.FromInt(1), "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, peg)
+	require.NoError(t, err)
+	require.NotNil(t, book.CancelOrder("peg"))
+
+	bid, err := NewLimitOrder("bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "buyer")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, bid)
+	require.NoError(t, err)
+	ask, err := NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(110), GTC, "", "seller")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, ask)
+	require.NoError(t, err)
+
+	// The canceled peg no longer trades
+	buy, err := NewLimitOrder("buy", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(105), IOC, "", "buyer")
+	require.NoError(t, err)
+	done, err := book.Process(ctx, buy)
+	require.NoError(t, err)
+	assert.Equal(t, fpdecimal.Zero, done.Processed)
+
+	_, err = NewMidpointPeggedOrder("empty", Buy, fpdecimal.Zero, "", "test_user")
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+}
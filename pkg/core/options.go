@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrRiskCheckFailed is returned by Process when the book's RiskChecker rejects an order
+var ErrRiskCheckFailed = errors.New("order rejected by risk check")
+
+// OrderBookOption configures an OrderBook created by NewOrderBook
+type OrderBookOption func(*OrderBook)
+
+// RiskChecker vets orders before the book processes them
+type RiskChecker interface {
+	// CheckOrder returns an error to reject order. It is called with the
+	// book locked, so it must not call back into the book.
+	CheckOrder(ctx context.Context, order *Order) error
+}
+
+// TradeHandler is called with the Done of every order that traded, whether
+// the caller's, a stop order it activated or one that ran out of time with
+// some fills. It is called with the book locked, so it must not call back
+// into the book.
+type TradeHandler func(ctx context.Context, done *Done)
+
+// ProcessFunc processes an order the way OrderBook.Process does
+type ProcessFunc func(ctx context.Context, order *Order) (*Done, error)
+
+// MatchingMiddleware wraps the processing of every order, for example to add
+// logging or metrics. It runs before the book is locked.
+type MatchingMiddleware func(next ProcessFunc) ProcessFunc
+
+// WithName sets the name the book's messages are sent with, which message
+// senders may route them by
+func WithName(name string) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.name = name
+	}
+}
+
+// Name returns the name set with WithName, or an empty string
+func (ob *OrderBook) Name() string {
+	return ob.name
+}
+
+// WithInstrumentConfig sets the book's instrument trading rules
+func WithInstrumentConfig(cfg InstrumentConfig) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.instrument = cfg
+	}
+}
+
+// WithMaxOrderAge makes the book's sweeper cancel orders resting longer than
+// maxAge, taking precedence over the MaxOrderAge of the policy it is started with
+func WithMaxOrderAge(maxAge time.Duration) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.maxOrderAge = maxAge
+	}
+}
+
+// WithRiskChecker makes the book reject orders that checker rejects with
+// ErrRiskCheckFailed
+func WithRiskChecker(checker RiskChecker) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.riskChecker = checker
+	}
+}
+
+// WithTradeHandler makes the book call handler after every order that traded.
+// Handlers given in several options are all called, in the order given.
+func WithTradeHandler(handler TradeHandler) OrderBookOption {
+	return func(ob *OrderBook) {
+		if prev := ob.tradeHandler; prev != nil {
+			ob.tradeHandler = func(ctx context.Context, done *Done) {
+				prev(ctx, done)
+				handler(ctx, done)
+			}
+			return
+		}
+		ob.tradeHandler = handler
+	}
+}
+
+// WithMiddleware adds middleware around order processing. The first
+// middleware given is the outermost.
+func WithMiddleware(middleware ...MatchingMiddleware) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.middleware = append(ob.middleware, middleware...)
+	}
+}
+
+// MaxOrderAge returns the age set with WithMaxOrderAge, or zero
+func (ob *OrderBook) MaxOrderAge() time.Duration {
+	return ob.maxOrderAge
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type maxQuantityChecker struct {
+	max fpdecimal.Decimal
+}
+
+func (c maxQuantityChecker) CheckOrder(ctx context.Context, order *Order) error {
+	if order.Quantity().GreaterThan(c.max) {
+		return errors.New("quantity over limit")
+	}
+	return nil
+}
+
+func TestNewOrderBookOptions(t *testing.T) {
+	sender := setupMockSender(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls []string
+	var traded []*Done
+	book := NewOrderBook(newMockBackend(),
+		WithName("options"),
+		WithInstrumentConfig(InstrumentConfig{MaxPriceDeviationPct: 5}),
+		WithMaxOrderAge(50*time.Millisecond),
+		WithRiskChecker(maxQuantityChecker{max: fpdecimal.FromInt(10)}),
+		WithTradeHandler(func(ctx context.Context, done *Done) {
+			traded = append(traded, done)
+		}),
+		WithMiddleware(
+			func(next ProcessFunc) ProcessFunc {
+				return func(ctx context.Context, order *Order) (*Done, error) {
+					calls = append(calls, "outer:"+order.ID())
+					return next(ctx, order)
+				}
+			},
+			func(next ProcessFunc) ProcessFunc {
+				return func(ctx context.Context, order *Order) (*Done, error) {
+					calls = append(calls, "inner:"+order.ID())
+					return next(ctx, order)
+				}
+			},
+		),
+	)
+
+	process := func(id string, side Side, quantity, price int64) (*Done, error) {
+		t.Helper()
+		order, err := NewLimitOrder(id, side, fpdecimal.FromInt(quantity), fpdecimal.FromInt(price), GTC, "", "test_user")
+		require.NoError(t, err)
+		return book.Process(ctx, order)
+	}
+
+	// Middleware runs in the order given, around every order
+	_, err := process("bid", Buy, 1, 100)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer:bid", "inner:bid"}, calls)
+	assert.Empty(t, traded, "resting orders do not reach the trade handler")
+
+	// The trade handler sees fills
+	done, err := process("ask", Sell, 1, 100)
+	require.NoError(t, err)
+	require.Len(t, traded, 1)
+	assert.Same(t, done, traded[0])
+
+	// Messages carry the book's name
+	assert.Equal(t, "options", book.Name())
+	messages := sender.GetSentMessages()
+	require.NotEmpty(t, messages)
+	assert.Equal(t, "options", messages[len(messages)-1].OrderBookName)
+
+	// The risk checker rejects large orders before they reach the book
+	_, err = process("big", Buy, 11, 100)
+	assert.ErrorIs(t, err, ErrRiskCheckFailed)
+	assert.Nil(t, book.GetOrder("big"))
+
+	// The instrument config rejects a fill 10% away from the last trade
+	_, err = process("low-bid", Buy, 1, 90)
+	require.NoError(t, err)
+	_, err = process("low-ask", Sell, 1, 90)
+	assert.ErrorIs(t, err, ErrPriceDeviationExceeded)
+
+	// The max order age applies even though the policy sets none
+	assert.Equal(t, 50*time.Millisecond, book.MaxOrderAge())
+	book.StartSweeper(ctx, "options", OrderBookPolicy{SweepInterval: 10 * time.Millisecond})
+	require.Eventually(t, func() bool {
+		return book.GetOrder("low-bid") == nil
+	}, time.Second, 10*time.Millisecond)
+	cancels := cancelMessages(sender)
+	require.NotEmpty(t, cancels)
+	assert.Equal(t, "options", cancels[len(cancels)-1].OrderBookName)
+}
+
+func TestTradeHandler_StopActivation(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+
+	var traded []string
+	book := NewOrderBook(newMockBackend(), WithTradeHandler(func(ctx context.Context, done *Done) {
+		traded = append(traded, done.Order.ID())
+	}))
+	process := func(order *Order, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+
+	process(NewLimitOrder("ask-1", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "maker"))
+	process(NewLimitOrder("ask-2", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(101), GTC, "", "maker"))
+	process(NewStopLimitOrder("stop", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(101), fpdecimal.FromInt(100), "", "stopper"))
+	assert.Empty(t, traded)
+
+	// The trade at 100 activates the stop, which takes ask-2; each order's
+	// fills reach the handler once
+	process(NewLimitOrder("buy", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "taker"))
+	assert.ElementsMatch(t, []string{"buy", "stop"}, traded)
+	assert.Nil(t, book.GetOrder("ask-2"))
+}
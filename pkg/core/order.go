@@ -2,6 +2,8 @@ package core
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/nikolaydubina/fpdecimal"
 )
@@ -44,6 +46,7 @@ const (
 	TypeMarket    OrderType = "MARKET"
 	TypeLimit     OrderType = "LIMIT"
 	TypeStopLimit OrderType = "STOP_LIMIT"
+	TypeMidpoint  OrderType = "MIDPOINT"
 )
 
 // TIF represents time in force parameter
@@ -54,8 +57,29 @@ const (
 	GTC TIF = "GTC" // Good Till Canceled
 	IOC TIF = "IOC" // Immediate Or Cancel
 	FOK TIF = "FOK" // Fill Or Kill
+	GTD TIF = "GTD" // Good Till Date
 )
 
+// OrderState represents where an order is in its lifecycle
+type OrderState string
+
+// Order states
+const (
+	StatePending         OrderState = "PENDING" // Created, or resting in the stop book
+	StateOpen            OrderState = "OPEN"
+	StatePartiallyFilled OrderState = "PARTIALLY_FILLED"
+	StateFilled          OrderState = "FILLED"
+	StateCanceled        OrderState = "CANCELED"
+)
+
+// validTransitions lists the states each state may move to. Filled and
+// canceled orders are final.
+var validTransitions = map[OrderState][]OrderState{
+	StatePending:         {StateOpen, StateCanceled},
+	StateOpen:            {StatePartiallyFilled, StateFilled, StateCanceled},
+	StatePartiallyFilled: {StatePartiallyFilled, StateFilled, StateCanceled},
+}
+
 // Order stores information about order
 type Order struct {
 	id          string
@@ -65,108 +89,199 @@ type Order struct {
 	quantity    fpdecimal.Decimal
 	originalQty fpdecimal.Decimal
 	price       fpdecimal.Decimal
-	canceled    bool
+	state       OrderState
 	role        Role
 	stop        fpdecimal.Decimal
 	tif         TIF
 	oco         string
+	postOnly    bool
 	userAddress string
+	createdAt   time.Time
+	// clientOrderID is the client's idempotency key, distinct from id
+	clientOrderID string
+	// displayQty is how much of a resting iceberg order is shown; zero for
+	// other orders. hidden is the reserve not shown, which refills the
+	// shown quantity once it is filled.
+	displayQty fpdecimal.Decimal
+	hidden     fpdecimal.Decimal
+	// pegType is the price a pegged limit order follows, empty for other
+	// orders; its price is the reference price plus pegOffset
+	pegType   PegType
+	pegOffset fpdecimal.Decimal
+	// expiresAt is when a GTD order is canceled; zero for other TIFs
+	expiresAt time.Time
+}
+
+// orderJSON is the JSON form of Order, which the Redis backend stores. It
+// holds every field needed to restore an order exactly.
+type orderJSON struct {
+	ID            string     `json:"id"`
+	OrderType     OrderType  `json:"orderType"`
+	Side          Side       `json:"side"`
+	IsQuote       bool       `json:"isQuote"`
+	Quantity      string     `json:"quantity"`
+	OriginalQty   string     `json:"originalQty"`
+	Price         string     `json:"price"`
+	Canceled      bool       `json:"canceled"`
+	State         OrderState `json:"state"`
+	Role          Role       `json:"role"`
+	Stop          string     `json:"stop"`
+	TIF           TIF        `json:"tif"`
+	OCO           string     `json:"oco"`
+	PostOnly      bool       `json:"postOnly"`
+	UserAddress   string     `json:"userAddress"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ClientOrderID string     `json:"clientOrderId,omitempty"`
+	DisplayQty    string     `json:"displayQty,omitempty"`
+	Hidden        string     `json:"hidden,omitempty"`
+	PegType       PegType    `json:"pegType,omitempty"`
+	PegOffset     string     `json:"pegOffset,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
 }
 
 // MarshalJSON implements custom JSON marshaling for Order
 func (o *Order) MarshalJSON() ([]byte, error) {
-	type OrderJSON struct {
-		ID          string    `json:"id"`
-		OrderType   OrderType `json:"orderType"`
-		Side        Side      `json:"side"`
-		IsQuote     bool      `json:"isQuote"`
-		Quantity    string    `json:"quantity"`
-		OriginalQty string    `json:"originalQty"`
-		Price       string    `json:"price"`
-		Canceled    bool      `json:"canceled"`
-		Role        Role      `json:"role"`
-		Stop        string    `json:"stop"`
-		TIF         TIF       `json:"tif"`
-		OCO         string    `json:"oco"`
-		UserAddress string    `json:"userAddress"`
-	}
-
-	return json.Marshal(OrderJSON{
-		ID:          o.id,
-		OrderType:   o.orderType,
-		Side:        o.side,
-		IsQuote:     o.isQuote,
-		Quantity:    o.quantity.String(),
-		OriginalQty: o.originalQty.String(),
-		Price:       o.price.String(),
-		Canceled:    o.canceled,
-		Role:        o.role,
-		Stop:        o.stop.String(),
-		TIF:         o.tif,
-		OCO:         o.oco,
-		UserAddress: o.userAddress,
+	var displayQty, hidden, pegOffset string
+	if o.IsIceberg() {
+		displayQty, hidden = o.displayQty.String(), o.hidden.String()
+	}
+	if o.IsPegged() {
+		pegOffset = o.pegOffset.String()
+	}
+	var expiresAt *time.Time
+	if !o.expiresAt.IsZero() {
+		expiresAt = &o.expiresAt
+	}
+	return json.Marshal(orderJSON{
+		ID:            o.id,
+		OrderType:     o.orderType,
+		Side:          o.side,
+		IsQuote:       o.isQuote,
+		Quantity:      o.quantity.String(),
+		OriginalQty:   o.originalQty.String(),
+		Price:         o.price.String(),
+		Canceled:      o.IsCanceled(),
+		State:         o.state,
+		Role:          o.role,
+		Stop:          o.stop.String(),
+		TIF:           o.tif,
+		OCO:           o.oco,
+		PostOnly:      o.postOnly,
+		UserAddress:   o.userAddress,
+		CreatedAt:     o.createdAt,
+		ClientOrderID: o.clientOrderID,
+		DisplayQty:    displayQty,
+		Hidden:        hidden,
+		PegType:       o.pegType,
+		PegOffset:     pegOffset,
+		ExpiresAt:     expiresAt,
 	})
 }
 
-// UnmarshalJSON implements custom JSON unmarshaling for Order
+// UnmarshalJSON implements custom JSON unmarshaling for Order. Missing
+// decimal fields are read as zero; malformed ones are an error.
 func (o *Order) UnmarshalJSON(data []byte) error {
-	type OrderJSON struct {
-		ID          string    `json:"id"`
-		OrderType   OrderType `json:"orderType"`
-		Side        Side      `json:"side"`
-		IsQuote     bool      `json:"isQuote"`
-		Quantity    string    `json:"quantity"`
-		OriginalQty string    `json:"originalQty"`
-		Price       string    `json:"price"`
-		Canceled    bool      `json:"canceled"`
-		Role        Role      `json:"role"`
-		Stop        string    `json:"stop"`
-		TIF         TIF       `json:"tif"`
-		OCO         string    `json:"oco"`
-		UserAddress string    `json:"userAddress"`
-	}
-
-	var orderJSON OrderJSON
-	if err := json.Unmarshal(data, &orderJSON); err != nil {
+	var j orderJSON
+	if err := json.Unmarshal(data, &j); err != nil {
 		return err
 	}
 
-	var err error
-
-	o.id = orderJSON.ID
-	o.orderType = orderJSON.OrderType
-	o.side = orderJSON.Side
-	o.isQuote = orderJSON.IsQuote
-	o.quantity, err = fpdecimal.FromString(orderJSON.Quantity)
+	quantity, err := decimalFromJSON("quantity", j.Quantity)
 	if err != nil {
-		o.quantity = fpdecimal.Zero
+		return err
 	}
-
-	o.originalQty, err = fpdecimal.FromString(orderJSON.OriginalQty)
+	originalQty, err := decimalFromJSON("originalQty", j.OriginalQty)
 	if err != nil {
-		o.originalQty = fpdecimal.Zero
+		return err
 	}
-
-	o.price, err = fpdecimal.FromString(orderJSON.Price)
+	price, err := decimalFromJSON("price", j.Price)
 	if err != nil {
-		o.price = fpdecimal.Zero
+		return err
 	}
-
-	o.canceled = orderJSON.Canceled
-	o.role = orderJSON.Role
-
-	o.stop, err = fpdecimal.FromString(orderJSON.Stop)
+	stop, err := decimalFromJSON("stop", j.Stop)
+	if err != nil {
+		return err
+	}
+	displayQty, err := decimalFromJSON("displayQty", j.DisplayQty)
+	if err != nil {
+		return err
+	}
+	hidden, err := decimalFromJSON("hidden", j.Hidden)
 	if err != nil {
-		o.stop = fpdecimal.Zero
+		return err
+	}
+	pegOffset, err := decimalFromJSON("pegOffset", j.PegOffset)
+	if err != nil {
+		return err
 	}
 
-	o.tif = orderJSON.TIF
-	o.oco = orderJSON.OCO
-	o.userAddress = orderJSON.UserAddress
+	state := j.State
+	if state == "" {
+		// Orders stored before states were tracked
+		switch {
+		case j.Canceled:
+			state = StateCanceled
+		case j.OrderType == TypeStopLimit:
+			state = StatePending
+		default:
+			state = StateOpen
+		}
+	}
+
+	var expiresAt time.Time
+	if j.ExpiresAt != nil {
+		expiresAt = *j.ExpiresAt
+	}
 
+	*o = Order{
+		id:            j.ID,
+		orderType:     j.OrderType,
+		side:          j.Side,
+		isQuote:       j.IsQuote,
+		quantity:      quantity,
+		originalQty:   originalQty,
+		price:         price,
+		state:         state,
+		role:          j.Role,
+		stop:          stop,
+		tif:           j.TIF,
+		oco:           j.OCO,
+		postOnly:      j.PostOnly,
+		userAddress:   j.UserAddress,
+		createdAt:     j.CreatedAt,
+		clientOrderID: j.ClientOrderID,
+		displayQty:    displayQty,
+		hidden:        hidden,
+		pegType:       j.PegType,
+		pegOffset:     pegOffset,
+		expiresAt:     expiresAt,
+	}
 	return nil
 }
 
+// GobEncode implements gob.GobEncoder. Orders are encoded in their JSON form,
+// so gob streams restore them exactly like the Redis backend does.
+func (o *Order) GobEncode() ([]byte, error) {
+	return o.MarshalJSON()
+}
+
+// GobDecode implements gob.GobDecoder
+func (o *Order) GobDecode(data []byte) error {
+	return o.UnmarshalJSON(data)
+}
+
+// decimalFromJSON parses the decimal field name of an order's JSON form
+func decimalFromJSON(name, value string) (fpdecimal.Decimal, error) {
+	if value == "" {
+		return fpdecimal.Zero, nil
+	}
+	d, err := fpdecimal.FromString(value)
+	if err != nil {
+		return fpdecimal.Zero, fmt.Errorf("order %s %q: %w", name, value, err)
+	}
+	return d, nil
+}
+
 // NewMarketOrder creates new constant object Order
 func NewMarketOrder(orderID string, side Side, quantity fpdecimal.Decimal, userAddress string) (*Order, error) {
 	if quantity.LessThanOrEqual(fpdecimal.Zero) {
@@ -180,12 +295,25 @@ func NewMarketOrder(orderID string, side Side, quantity fpdecimal.Decimal, userA
 		quantity:    quantity,
 		originalQty: quantity,
 		price:       fpdecimal.Zero,
-		canceled:    false,
-		userAddress: userAddress,
+		state:       StatePending,
+		userAddress: normalizeUserAddress(userAddress),
+		createdAt:   time.Now(),
 	}, nil
 }
 
-// NewMarketQuoteOrder creates new constant object Order, but quantity is in Quote mode
+// NewFOKMarketOrder creates a fill-or-kill market order: it is canceled
+// without trading unless the opposite side holds its whole quantity.
+func NewFOKMarketOrder(orderID string, side Side, quantity fpdecimal.Decimal, userAddress string) (*Order, error) {
+	order, err := NewMarketOrder(orderID, side, quantity, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	order.tif = FOK
+	return order, nil
+}
+
+// NewMarketQuoteOrder creates new constant object Order, but quantity is in Quote mode:
+// it is a budget in the quote currency, spending matchQty * price per fill
 func NewMarketQuoteOrder(orderID string, side Side, quantity fpdecimal.Decimal, userAddress string) (*Order, error) {
 	if quantity.LessThanOrEqual(fpdecimal.Zero) {
 		return nil, ErrInvalidQuantity
@@ -198,12 +326,24 @@ func NewMarketQuoteOrder(orderID string, side Side, quantity fpdecimal.Decimal,
 		quantity:    quantity,
 		originalQty: quantity,
 		price:       fpdecimal.Zero,
-		canceled:    false,
+		state:       StatePending,
 		isQuote:     true,
-		userAddress: userAddress,
+		userAddress: normalizeUserAddress(userAddress),
+		createdAt:   time.Now(),
 	}, nil
 }
 
+// NewFOKMarketQuoteOrder creates a fill-or-kill quote order: it is canceled
+// without trading unless the opposite side can take its whole budget.
+func NewFOKMarketQuoteOrder(orderID string, side Side, quantity fpdecimal.Decimal, userAddress string) (*Order, error) {
+	order, err := NewMarketQuoteOrder(orderID, side, quantity, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	order.tif = FOK
+	return order, nil
+}
+
 // NewLimitOrder creates new constant object Order
 func NewLimitOrder(orderID string, side Side, quantity, price fpdecimal.Decimal, tif TIF, oco string, userAddress string) (*Order, error) {
 	if quantity.LessThanOrEqual(fpdecimal.Zero) {
@@ -225,13 +365,58 @@ func NewLimitOrder(orderID string, side Side, quantity, price fpdecimal.Decimal,
 		quantity:    quantity,
 		originalQty: quantity,
 		price:       price,
-		canceled:    false,
+		state:       StatePending,
 		oco:         oco,
 		tif:         tif,
-		userAddress: userAddress,
+		userAddress: normalizeUserAddress(userAddress),
+		createdAt:   time.Now(),
 	}, nil
 }
 
+// NewGTDLimitOrder creates a limit order that rests on the book until
+// expiresAt, when the book's expiry worker cancels it. An order that arrives
+// after expiresAt only takes liquidity, like an IOC order.
+func NewGTDLimitOrder(orderID string, side Side, quantity, price fpdecimal.Decimal, expiresAt time.Time, oco string, userAddress string) (*Order, error) {
+	if expiresAt.IsZero() {
+		return nil, ErrInvalidExpiry
+	}
+	order, err := NewLimitOrder(orderID, side, quantity, price, GTC, oco, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	order.tif = GTD
+	order.expiresAt = expiresAt
+	return order, nil
+}
+
+// NewPostOnlyLimitOrder creates a GTC limit order that may only rest on the
+// book. Processing it fails with ErrPostOnlyWouldTake if it would match.
+func NewPostOnlyLimitOrder(orderID string, side Side, quantity, price fpdecimal.Decimal, oco string, userAddress string) (*Order, error) {
+	order, err := NewLimitOrder(orderID, side, quantity, price, GTC, oco, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	order.postOnly = true
+	return order, nil
+}
+
+// NewIcebergOrder creates a limit order that shows only visibleQty of its
+// totalQty while it rests on the book. The rest is kept in a hidden reserve:
+// each time the shown quantity is filled, up to visibleQty more is shown and
+// the order goes to the back of the queue at its price. On arrival the order
+// takes liquidity with its whole quantity.
+func NewIcebergOrder(orderID string, side Side, totalQty, visibleQty, price fpdecimal.Decimal, tif TIF, oco string, userAddress string) (*Order, error) {
+	order, err := NewLimitOrder(orderID, side, totalQty, price, tif, oco, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	if visibleQty.LessThanOrEqual(fpdecimal.Zero) || visibleQty.GreaterThan(totalQty) {
+		return nil, ErrInvalidVisibleQty
+	}
+	order.displayQty = visibleQty
+	return order, nil
+}
+
 // NewStopLimitOrder creates new constant object Order
 func NewStopLimitOrder(orderID string, side Side, quantity, price, stop fpdecimal.Decimal, oco string, userAddress string) (*Order, error) {
 	if quantity.LessThanOrEqual(fpdecimal.Zero) {
@@ -249,10 +434,70 @@ func NewStopLimitOrder(orderID string, side Side, quantity, price, stop fpdecima
 		quantity:    quantity,
 		originalQty: quantity,
 		price:       price,
-		canceled:    false,
+		state:       StatePending,
 		stop:        stop,
 		oco:         oco,
-		userAddress: userAddress,
+		userAddress: normalizeUserAddress(userAddress),
+		createdAt:   time.Now(),
+	}, nil
+}
+
+// NewMidpointPeggedOrder creates an order pegged to the midpoint of the best
+// bid and best ask. It has no price of its own: it rests until an order on
+// the other side is willing to trade at the midpoint, and fills at that
+// midpoint, worked out when the match is made.
+func NewMidpointPeggedOrder(orderID string, side Side, quantity fpdecimal.Decimal, oco string, userAddress string) (*Order, error) {
+	if quantity.LessThanOrEqual(fpdecimal.Zero) {
+		return nil, ErrInvalidQuantity
+	}
+
+	return &Order{
+		id:          orderID,
+		orderType:   TypeMidpoint,
+		side:        side,
+		quantity:    quantity,
+		originalQty: quantity,
+		price:       fpdecimal.Zero,
+		state:       StatePending,
+		tif:         GTC,
+		oco:         oco,
+		userAddress: normalizeUserAddress(userAddress),
+		createdAt:   time.Now(),
+	}, nil
+}
+
+// NewPeggedOrder creates a limit order whose price follows the book: the
+// best bid, the best ask or their midpoint, as pegType says, plus offset,
+// which may be negative. The order is priced when it is processed and
+// repriced whenever the price it follows moves while it rests. A buy pegged
+// to the best bid, or a sell to the best ask, is a primary peg that joins
+// its own side; pegged to the other side it is a market peg that takes
+// liquidity unless offset keeps it back.
+func NewPeggedOrder(orderID string, side Side, quantity fpdecimal.Decimal, pegType PegType, offset fpdecimal.Decimal, tif TIF, oco string, userAddress string) (*Order, error) {
+	if quantity.LessThanOrEqual(fpdecimal.Zero) {
+		return nil, ErrInvalidQuantity
+	}
+	if !pegType.valid() {
+		return nil, ErrInvalidPegType
+	}
+	if tif != "" && tif != GTC && tif != FOK && tif != IOC {
+		return nil, ErrInvalidTif
+	}
+
+	return &Order{
+		id:          orderID,
+		orderType:   TypeLimit,
+		side:        side,
+		quantity:    quantity,
+		originalQty: quantity,
+		price:       fpdecimal.Zero,
+		state:       StatePending,
+		tif:         tif,
+		oco:         oco,
+		userAddress: normalizeUserAddress(userAddress),
+		createdAt:   time.Now(),
+		pegType:     pegType,
+		pegOffset:   offset,
 	}, nil
 }
 
@@ -281,14 +526,81 @@ func (o *Order) OriginalQty() fpdecimal.Decimal {
 	return o.originalQty
 }
 
+// IsIceberg reports whether the order shows only part of its quantity while
+// it rests on the book
+func (o *Order) IsIceberg() bool {
+	return o.displayQty.GreaterThan(fpdecimal.Zero)
+}
+
+// DisplayQty returns how much of an iceberg order is shown at a time, or
+// zero for other orders
+func (o *Order) DisplayQty() fpdecimal.Decimal {
+	return o.displayQty
+}
+
+// HiddenQty returns the quantity an iceberg order keeps in reserve, not
+// shown by Quantity
+func (o *Order) HiddenQty() fpdecimal.Decimal {
+	return o.hidden
+}
+
+// RemainingQty returns the quantity left to fill: Quantity plus any hidden reserve
+func (o *Order) RemainingQty() fpdecimal.Decimal {
+	return o.quantity.Add(o.hidden)
+}
+
+// hideReserve moves what an iceberg order shows beyond its display
+// quantity into its reserve, before it rests on the book
+func (o *Order) hideReserve() {
+	if !o.IsIceberg() || !o.quantity.GreaterThan(o.displayQty) {
+		return
+	}
+	o.hidden = o.hidden.Add(o.quantity.Sub(o.displayQty))
+	o.quantity = o.displayQty
+}
+
+// replenish shows up to the display quantity of an iceberg order's reserve
+// once its shown quantity is filled. It reports whether it did.
+func (o *Order) replenish() bool {
+	if o.quantity.GreaterThan(fpdecimal.Zero) || !o.hidden.GreaterThan(fpdecimal.Zero) {
+		return false
+	}
+	o.quantity = min(o.displayQty, o.hidden)
+	o.hidden = o.hidden.Sub(o.quantity)
+	return true
+}
+
+// IsPegged reports whether the order's price follows the book
+func (o *Order) IsPegged() bool {
+	return o.pegType != ""
+}
+
+// PegType returns the price a pegged order follows, or "" for other orders
+func (o *Order) PegType() PegType {
+	return o.pegType
+}
+
+// PegOffset returns how far a pegged order's price is from the price it follows
+func (o *Order) PegOffset() fpdecimal.Decimal {
+	return o.pegOffset
+}
+
 // SetQuantity set Quantity field
 func (o *Order) SetQuantity(quantity fpdecimal.Decimal) {
 	o.quantity = quantity
 }
 
-// DecreaseQuantity set Quantity field
-func (o *Order) DecreaseQuantity(quantity fpdecimal.Decimal) {
-	o.quantity = o.quantity.Sub(quantity)
+// DecreaseQuantity reduces Quantity by a filled amount and moves the order to
+// PARTIALLY_FILLED, or FILLED once nothing remains, counting any hidden
+// reserve. The quantity is left unchanged if the order cannot be filled in
+// its current state.
+func (o *Order) DecreaseQuantity(quantity fpdecimal.Decimal) error {
+	remaining := o.quantity.Sub(quantity)
+	if err := o.Transition(fillState(remaining.Add(o.hidden))); err != nil {
+		return err
+	}
+	o.quantity = remaining
+	return nil
 }
 
 // Price returns Price field copy
@@ -311,15 +623,65 @@ func (o *Order) TIF() TIF {
 	return o.tif
 }
 
+// ExpiresAt returns when a GTD order expires, or the zero time for other TIFs
+func (o *Order) ExpiresAt() time.Time {
+	return o.expiresAt
+}
+
+// expired reports whether o is a GTD order whose expiry is at or before now
+func (o *Order) expired(now time.Time) bool {
+	return o.tif == GTD && !o.expiresAt.After(now)
+}
+
+// ClientOrderID returns the idempotency key the client submitted the order with
+func (o *Order) ClientOrderID() string {
+	return o.clientOrderID
+}
+
+// SetClientOrderID sets the idempotency key the client submitted the order with
+func (o *Order) SetClientOrderID(clientOrderID string) {
+	o.clientOrderID = clientOrderID
+}
+
+// IsPostOnly reports whether the order may only rest on the book
+func (o *Order) IsPostOnly() bool {
+	return o.postOnly
+}
+
 // IsCanceled returns Canceled status
 func (o *Order) IsCanceled() bool {
-	return o.canceled
+	return o.state == StateCanceled
+}
+
+// Cancel moves the order to CANCELED. Filled and already canceled orders
+// return ErrInvalidStateTransition.
+func (o *Order) Cancel() error {
+	return o.Transition(StateCanceled)
 }
 
-// Cancel set Canceled status
-func (o *Order) Cancel() bool {
-	o.canceled = true
-	return o.canceled
+// State returns the lifecycle state of the order
+func (o *Order) State() OrderState {
+	return o.state
+}
+
+// Transition moves the order to newState, returning ErrInvalidStateTransition
+// if the move is not allowed from the current state
+func (o *Order) Transition(newState OrderState) error {
+	for _, allowed := range validTransitions[o.state] {
+		if allowed == newState {
+			o.state = newState
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidStateTransition, o.state, newState)
+}
+
+// fillState returns the state of an order with remaining quantity left after a fill
+func fillState(remaining fpdecimal.Decimal) OrderState {
+	if remaining.LessThanOrEqual(fpdecimal.Zero) {
+		return StateFilled
+	}
+	return StatePartiallyFilled
 }
 
 // IsMarketOrder returns true if Order is MARKET
@@ -337,6 +699,11 @@ func (o *Order) IsStopOrder() bool {
 	return o.orderType == TypeStopLimit
 }
 
+// IsMidpointOrder returns true if Order is pegged to the midpoint
+func (o *Order) IsMidpointOrder() bool {
+	return o.orderType == TypeMidpoint
+}
+
 // ActivateStopOrder transforms Stop-GetOrder into Order
 func (o *Order) ActivateStopOrder() {
 	if !o.IsStopOrder() {
@@ -394,19 +761,34 @@ func (o *Order) ToLimitOrder() *Order {
 		quantity:    o.quantity,
 		originalQty: o.originalQty,
 		price:       o.price,
-		canceled:    o.canceled,
+		state:       o.state,
 		role:        o.role,
 		tif:         o.tif,
 		oco:         o.oco,
 		userAddress: o.userAddress,
+		createdAt:   o.createdAt,
+		expiresAt:   o.expiresAt,
 	}
 }
 
-// UserAddress returns the user's address
+// Clone returns a copy of the order that can be modified independently, for
+// example to process it against a simulated book
+func (o *Order) Clone() *Order {
+	c := *o
+	return &c
+}
+
+// UserAddress returns the user's address, in EIP-55 checksum form when it is
+// an Ethereum address
 func (o *Order) UserAddress() string {
 	return o.userAddress
 }
 
+// CreatedAt returns the time the order was created
+func (o *Order) CreatedAt() time.Time {
+	return o.createdAt
+}
+
 func (o *Order) OrderType() OrderType {
 	return o.orderType
 }
@@ -1,8 +1,12 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nikolaydubina/fpdecimal"
 	"github.com/stretchr/testify/assert"
@@ -129,6 +133,50 @@ func TestNewLimitOrder(t *testing.T) {
 	}
 }
 
+func TestNewGTDLimitOrder(t *testing.T) {
+	expiresAt := time.Now().Add(time.Minute)
+	order, err := NewGTDLimitOrder("gtd", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), expiresAt, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, GTD, order.TIF())
+	assert.True(t, order.IsLimitOrder())
+	assert.Equal(t, expiresAt, order.ExpiresAt())
+	assert.False(t, order.expired(expiresAt.Add(-time.Second)))
+	assert.True(t, order.expired(expiresAt))
+
+	_, err = NewGTDLimitOrder("no-expiry", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), time.Time{}, "", "")
+	assert.ErrorIs(t, err, ErrInvalidExpiry)
+	_, err = NewLimitOrder("plain-gtd", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTD, "", "")
+	assert.ErrorIs(t, err, ErrInvalidTif, "GTD orders need an expiry")
+}
+
+func TestNewIcebergOrder(t *testing.T) {
+	order, err := NewIcebergOrder("iceberg", Sell, fpdecimal.FromInt(10), fpdecimal.FromInt(3), fpdecimal.FromInt(100), GTC, "", "test_user")
+	require.NoError(t, err)
+	assert.True(t, order.IsLimitOrder())
+	assert.True(t, order.IsIceberg())
+	assert.Equal(t, "10.000", order.Quantity().String(), "the whole quantity takes liquidity")
+
+	// Resting shows 3 at a time; each refill shows at most what is left
+	order.hideReserve()
+	assert.Equal(t, "3.000", order.Quantity().String())
+	assert.Equal(t, "7.000", order.HiddenQty().String())
+	require.NoError(t, order.Transition(StateOpen))
+	for _, want := range []string{"3.000", "3.000", "1.000"} {
+		require.NoError(t, order.DecreaseQuantity(order.Quantity()))
+		assert.Equal(t, StatePartiallyFilled, order.State())
+		require.True(t, order.replenish())
+		assert.Equal(t, want, order.Quantity().String())
+	}
+	require.NoError(t, order.DecreaseQuantity(order.Quantity()))
+	assert.Equal(t, StateFilled, order.State())
+	assert.False(t, order.replenish())
+
+	_, err = NewIcebergOrder("too-visible", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(2), fpdecimal.FromInt(100), GTC, "", "test_user")
+	assert.ErrorIs(t, err, ErrInvalidVisibleQty)
+	_, err = NewIcebergOrder("hidden-only", Sell, fpdecimal.FromInt(1), fpdecimal.Zero, fpdecimal.FromInt(100), GTC, "", "test_user")
+	assert.ErrorIs(t, err, ErrInvalidVisibleQty)
+}
+
 func TestNewStopLimitOrder(t *testing.T) {
 	orderID := "test-123"
 	quantity := fpdecimal.FromFloat(10.5)
@@ -148,6 +196,27 @@ func TestNewStopLimitOrder(t *testing.T) {
 	}
 }
 
+func TestOrder_UserAddressNormalized(t *testing.T) {
+	const checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	// The same user, submitting with two spellings of their address
+	bid, err := NewLimitOrder("bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", strings.ToLower(checksummed))
+	require.NoError(t, err)
+	ask, err := NewStopLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), fpdecimal.FromInt(99), "", checksummed)
+	require.NoError(t, err)
+	market, err := NewMarketOrder("market", Buy, fpdecimal.FromInt(1), strings.ToUpper(checksummed[2:]))
+	require.NoError(t, err)
+
+	assert.Equal(t, checksummed, bid.UserAddress())
+	assert.True(t, bid.UserAddress() == ask.UserAddress(), "orders from one user should compare as the same user")
+	assert.Equal(t, checksummed, market.UserAddress())
+
+	// Identifiers that are not Ethereum addresses are kept as given
+	other, err := NewLimitOrder("other", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "test_user")
+	require.NoError(t, err)
+	assert.Equal(t, "test_user", other.UserAddress())
+}
+
 func TestOrderJSON(t *testing.T) {
 	orderID := "test-123"
 	quantity := fpdecimal.FromFloat(10.5)
@@ -189,6 +258,196 @@ func TestOrderJSON(t *testing.T) {
 	if newOrder.OCO() != "oco-456" {
 		t.Errorf("Expected OCO oco-456, got %v", newOrder.OCO())
 	}
+
+	if order.CreatedAt().IsZero() {
+		t.Error("Expected CreatedAt to be set by the constructor")
+	}
+
+	if !newOrder.CreatedAt().Equal(order.CreatedAt()) {
+		t.Errorf("Expected CreatedAt %v, got %v", order.CreatedAt(), newOrder.CreatedAt())
+	}
+
+	if newOrder.State() != StatePending {
+		t.Errorf("Expected State %s, got %s", StatePending, newOrder.State())
+	}
+
+	// Orders stored without a state are assumed to be resting on the book
+	var legacy Order
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"legacy","orderType":"LIMIT","quantity":"1","price":"1"}`), &legacy))
+	assert.Equal(t, StateOpen, legacy.State())
+}
+
+func TestOrderJSONRoundTrip(t *testing.T) {
+	qty := fpdecimal.FromFloat(2.5)
+	price := fpdecimal.FromFloat(100.125)
+	stop := fpdecimal.FromFloat(99.5)
+
+	partiallyFilled, err := NewLimitOrder("limit-partial", Sell, qty, price, GTC, "", "0xabc")
+	require.NoError(t, err)
+	require.NoError(t, partiallyFilled.Transition(StateOpen))
+	partiallyFilled.SetMaker()
+	require.NoError(t, partiallyFilled.DecreaseQuantity(fpdecimal.FromInt(1)))
+
+	canceled, err := NewLimitOrder("limit-canceled", Buy, qty, price, GTC, "", "0xabc")
+	require.NoError(t, err)
+	require.NoError(t, canceled.Cancel())
+
+	tests := map[string]func() (*Order, error){
+		"Market": func() (*Order, error) { return NewMarketOrder("market", Buy, qty, "0xabc") },
+		"MarketQuote": func() (*Order, error) {
+			return NewMarketQuoteOrder("market-quote", Buy, qty, "0xabc")
+		},
+		"Limit": func() (*Order, error) { return NewLimitOrder("limit", Sell, qty, price, GTC, "oco-1", "0xabc") },
+		"IOC":   func() (*Order, error) { return NewLimitOrder("ioc", Buy, qty, price, IOC, "", "0xabc") },
+		"FOK":   func() (*Order, error) { return NewLimitOrder("fok", Sell, qty, price, FOK, "", "0xabc") },
+		"PostOnly": func() (*Order, error) {
+			return NewPostOnlyLimitOrder("post-only", Sell, qty, price, "", "0xabc")
+		},
+		"GTD": func() (*Order, error) {
+			return NewGTDLimitOrder("gtd", Buy, qty, price, time.Now().Add(time.Hour), "", "0xabc")
+		},
+		"StopLimit": func() (*Order, error) {
+			return NewStopLimitOrder("stop-limit", Sell, qty, price, stop, "oco-2", "0xabc")
+		},
+		"ClientOrderID": func() (*Order, error) {
+			order, err := NewLimitOrder("client", Buy, qty, price, GTC, "", "0xabc")
+			if err == nil {
+				order.SetClientOrderID("client-1")
+			}
+			return order, err
+		},
+		"Iceberg": func() (*Order, error) {
+			order, err := NewIcebergOrder("iceberg", Buy, qty, fpdecimal.FromInt(1), price, GTC, "", "test_user")
+			if err == nil {
+				order.hideReserve()
+			}
+			return order, err
+		},
+		"PartiallyFilled": func() (*Order, error) { return partiallyFilled, nil },
+		"Canceled":        func() (*Order, error) { return canceled, nil },
+	}
+
+	for name, newOrder := range tests {
+		t.Run(name, func(t *testing.T) {
+			order, err := newOrder()
+			require.NoError(t, err)
+
+			data, err := json.Marshal(order)
+			require.NoError(t, err)
+			var restored Order
+			require.NoError(t, json.Unmarshal(data, &restored))
+
+			assert.Equal(t, order.ID(), restored.ID())
+			assert.Equal(t, order.Side(), restored.Side())
+			assert.Equal(t, order.OrderType(), restored.OrderType())
+			assert.Equal(t, order.Quantity(), restored.Quantity())
+			assert.Equal(t, order.OriginalQty(), restored.OriginalQty())
+			assert.Equal(t, order.Price(), restored.Price())
+			assert.Equal(t, order.StopPrice(), restored.StopPrice())
+			assert.Equal(t, order.TIF(), restored.TIF())
+			assert.Equal(t, order.OCO(), restored.OCO())
+			assert.Equal(t, order.UserAddress(), restored.UserAddress())
+			assert.Equal(t, order.IsQuote(), restored.IsQuote())
+			assert.Equal(t, order.IsStopOrder(), restored.IsStopOrder())
+			assert.Equal(t, order.IsCanceled(), restored.IsCanceled())
+			assert.Equal(t, order.State(), restored.State())
+			assert.Equal(t, order.Role(), restored.Role())
+			assert.Equal(t, order.ClientOrderID(), restored.ClientOrderID())
+			assert.Equal(t, order.DisplayQty(), restored.DisplayQty())
+			assert.Equal(t, order.HiddenQty(), restored.HiddenQty())
+			assert.True(t, order.CreatedAt().Equal(restored.CreatedAt()))
+			assert.True(t, order.ExpiresAt().Equal(restored.ExpiresAt()))
+
+			// Nothing is lost, so a second round trip is identical
+			again, err := json.Marshal(&restored)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(data), string(again))
+		})
+	}
+
+	var bad Order
+	assert.Error(t, json.Unmarshal([]byte(`{"id":"bad","orderType":"LIMIT","quantity":"one","price":"1"}`), &bad))
+}
+
+func TestOrderJSONRestoredOrderMatches(t *testing.T) {
+	setupMockSender(t)
+
+	ask, err := NewLimitOrder("ask-1", Sell, fpdecimal.FromInt(3), fpdecimal.FromInt(100), GTC, "", "0xseller")
+	require.NoError(t, err)
+	require.NoError(t, ask.Transition(StateOpen))
+	data, err := json.Marshal(ask)
+	require.NoError(t, err)
+
+	var restored Order
+	require.NoError(t, json.Unmarshal(data, &restored))
+
+	book := NewOrderBook(newMockBackend())
+	require.NoError(t, book.Restore(&Snapshot{Asks: []*Order{&restored}}))
+
+	buy, err := NewMarketOrder("buy-1", Buy, fpdecimal.FromInt(2), "0xbuyer")
+	require.NoError(t, err)
+	done, err := book.Process(context.Background(), buy)
+	require.NoError(t, err)
+	assert.Equal(t, fpdecimal.FromInt(2), done.Processed)
+
+	resting := book.GetOrder("ask-1")
+	require.NotNil(t, resting)
+	assert.Equal(t, fpdecimal.FromInt(1), resting.Quantity())
+	assert.Equal(t, StatePartiallyFilled, resting.State())
+}
+
+func TestOrderStateTransitions(t *testing.T) {
+	tests := []struct {
+		from    OrderState
+		to      OrderState
+		allowed bool
+	}{
+		{StatePending, StateOpen, true},
+		{StatePending, StateCanceled, true},
+		{StatePending, StateFilled, false},
+		{StateOpen, StatePartiallyFilled, true},
+		{StateOpen, StateFilled, true},
+		{StateOpen, StateCanceled, true},
+		{StateOpen, StatePending, false},
+		{StatePartiallyFilled, StatePartiallyFilled, true},
+		{StatePartiallyFilled, StateFilled, true},
+		{StatePartiallyFilled, StateCanceled, true},
+		{StateFilled, StateCanceled, false},
+		{StateFilled, StateOpen, false},
+		{StateCanceled, StateOpen, false},
+		{StateCanceled, StateCanceled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			order := &Order{state: tt.from}
+			err := order.Transition(tt.to)
+			if tt.allowed {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.to, order.State())
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidStateTransition)
+				assert.Equal(t, tt.from, order.State())
+			}
+		})
+	}
+}
+
+func TestCancelFilledOrder(t *testing.T) {
+	order, err := NewLimitOrder("filled", Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(100), GTC, "", "test_user")
+	require.NoError(t, err)
+	assert.Equal(t, StatePending, order.State())
+
+	require.NoError(t, order.Transition(StateOpen))
+	require.NoError(t, order.DecreaseQuantity(fpdecimal.FromInt(1)))
+	assert.Equal(t, StatePartiallyFilled, order.State())
+	require.NoError(t, order.DecreaseQuantity(fpdecimal.FromInt(1)))
+	assert.Equal(t, StateFilled, order.State())
+
+	err = order.Cancel()
+	assert.ErrorIs(t, err, ErrInvalidStateTransition)
+	assert.Equal(t, StateFilled, order.State())
+	assert.False(t, order.IsCanceled())
 }
 
 func TestOrderSettersAndGetters(t *testing.T) {
@@ -205,11 +464,7 @@ func TestOrderSettersAndGetters(t *testing.T) {
 		t.Error("Order should not be canceled initially")
 	}
 
-	order.Cancel()
-
-	if !order.IsCanceled() {
-		t.Error("Order should be canceled after Cancel() call")
-	}
+	require.NoError(t, order.Transition(StateOpen))
 
 	// Test quantity modification
 	newQuantity := fpdecimal.FromFloat(5.0)
@@ -222,12 +477,22 @@ func TestOrderSettersAndGetters(t *testing.T) {
 	// Test decrease quantity
 	decrease := fpdecimal.FromFloat(2.0)
 	expectedAfterDecrease := newQuantity.Sub(decrease)
-	order.DecreaseQuantity(decrease)
+	require.NoError(t, order.DecreaseQuantity(decrease))
 
 	if !order.Quantity().Equal(expectedAfterDecrease) {
 		t.Errorf("Expected Quantity %v after DecreaseQuantity, got %v", expectedAfterDecrease, order.Quantity())
 	}
 
+	require.NoError(t, order.Cancel())
+
+	if !order.IsCanceled() {
+		t.Error("Order should be canceled after Cancel() call")
+	}
+
+	// A canceled order can no longer be filled
+	assert.ErrorIs(t, order.DecreaseQuantity(decrease), ErrInvalidStateTransition)
+	assert.True(t, order.Quantity().Equal(expectedAfterDecrease))
+
 	// Test role
 	if order.Role() != TAKER {
 		t.Errorf("Expected default Role TAKER, got %v", order.Role())
@@ -246,6 +511,64 @@ func TestOrderSettersAndGetters(t *testing.T) {
 	}
 }
 
+func TestOrderClone(t *testing.T) {
+	order, err := NewLimitOrder("clone-1", Buy, fpdecimal.FromInt(10), fpdecimal.FromInt(100), GTC, "", "test_user")
+	require.NoError(t, err)
+	require.NoError(t, order.Transition(StateOpen))
+	order.SetClientOrderID("client-1")
+
+	clone := order.Clone()
+	assert.NotSame(t, order, clone)
+	assert.Equal(t, order.ID(), clone.ID())
+	assert.Equal(t, order, clone)
+
+	// Filling the clone leaves the original untouched
+	require.NoError(t, clone.DecreaseQuantity(fpdecimal.FromInt(4)))
+	assert.True(t, clone.Quantity().Equal(fpdecimal.FromInt(6)))
+	assert.Equal(t, StatePartiallyFilled, clone.State())
+	assert.True(t, order.Quantity().Equal(fpdecimal.FromInt(10)))
+	assert.Equal(t, StateOpen, order.State())
+}
+
+// orderSink keeps benchmarked copies from being optimized away
+var orderSink *Order
+
+// BenchmarkOrderClone compares Clone with a JSON round trip for copying 1000 orders
+func BenchmarkOrderClone(b *testing.B) {
+	orders := make([]*Order, 1000)
+	for i := range orders {
+		order, err := NewLimitOrder(fmt.Sprintf("order-%d", i), Buy, fpdecimal.FromInt(10), fpdecimal.FromInt(int64(100+i)), GTC, "", "test_user")
+		require.NoError(b, err)
+		orders[i] = order
+	}
+
+	b.Run("Clone", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, order := range orders {
+				orderSink = order.Clone()
+			}
+		}
+	})
+
+	b.Run("JSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, order := range orders {
+				data, err := json.Marshal(order)
+				if err != nil {
+					b.Fatal(err)
+				}
+				copied := &Order{}
+				if err := json.Unmarshal(data, copied); err != nil {
+					b.Fatal(err)
+				}
+				orderSink = copied
+			}
+		}
+	})
+}
+
 func TestActivateStopOrder(t *testing.T) {
 	orderID := "test-123"
 	quantity := fpdecimal.FromFloat(10.5)
@@ -2,53 +2,428 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/erain9/matchingo/pkg/db/queue"
+	"github.com/erain9/matchingo/pkg/logging"
 	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/erain9/matchingo/pkg/oracle"
 	"github.com/erain9/matchingo/pkg/otel"
 	"github.com/nikolaydubina/fpdecimal"
+	zlog "github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // OrderBook implements standard matching algorithm
 type OrderBook struct {
+	// mu serializes order processing and cancellation so concurrent callers
+	// always see a consistent book and lastTradePrice. It is taken once at
+	// the entry of each operation: by exported methods, or by the unexported
+	// methods they hand the whole operation to, such as processOrder and
+	// amendOrder. The methods those call document that the caller must hold
+	// mu, so the nested paths of one order, such as a trade triggering a
+	// stop whose fill cancels an OCO leg, run under the single lock taken
+	// for Process. sync.RWMutex is not re-entrant: nothing called with mu
+	// held may call a method that takes it.
+	mu             sync.RWMutex
 	backend        OrderBookBackend
 	lastTradePrice fpdecimal.Decimal
+	instrument     InstrumentConfig
+	rules          MatchingRules
+	// seq is the sequence number of the last Done published for this book
+	seq atomic.Uint64
+	// peggedOrders holds the resting orders pegged to the lit book, which
+	// rest on the backend's sides; see recomputePegs
+	peggedOrders []*Order
+	// priceFeed provides the price of PegOracleMid orders; see SetPriceFeed
+	priceFeed oracle.PriceFeed
+	// detached books publish no messages and record no metrics
+	detached bool
+
+	// eventChan is sent the book's Done objects; see SetEventChan
+	eventChan chan<- *Done
+
+	// haltedUntil is when matching resumes after the circuit breaker
+	// tripped, and haltReason why it tripped
+	haltedUntil time.Time
+	haltReason  string
+	// operatorHalted is set by SetHalted and lasts until it is cleared
+	operatorHalted bool
+
+	// expiryCtx and expiryInterval are set by StartExpiryWorker, and
+	// expiryRunning while its goroutine runs
+	expiryCtx      context.Context
+	expiryInterval time.Duration
+	expiryRunning  bool
+
+	// Set by OrderBookOptions
+	name           string
+	circuitBreaker CircuitBreakerConfig
+	fees           FeeSchedule
+	maxOrderAge    time.Duration
+	riskChecker    RiskChecker
+	tradeHandler   TradeHandler
+	tradeHistory   *TradeHistory
+	middleware     []MatchingMiddleware
+	snapshotPath   string
+
+	// closeMu guards closed; inflight counts Process calls that were
+	// admitted before the book was closed
+	closeMu  sync.RWMutex
+	closed   bool
+	inflight sync.WaitGroup
 }
 
-// NewOrderBook creates Orderbook object with a backend
-func NewOrderBook(backend OrderBookBackend) *OrderBook {
-	return &OrderBook{
+// NewOrderBook creates Orderbook object with a backend, configured by opts
+func NewOrderBook(backend OrderBookBackend, opts ...OrderBookOption) *OrderBook {
+	ob := &OrderBook{
 		backend: backend,
 	}
+	for _, opt := range opts {
+		opt(ob)
+	}
+	return ob
+}
+
+// NewDetachedOrderBook creates an Orderbook whose processing has no side
+// effects outside backend: no messages are sent and no metrics are recorded.
+// It is meant for what-if processing such as order simulation.
+func NewDetachedOrderBook(backend OrderBookBackend) *OrderBook {
+	return &OrderBook{
+		backend:  backend,
+		detached: true,
+	}
 }
 
-// GetOrder returns Order by id
+// GetOrder returns Order by id. It is a copy taken under the book's lock,
+// so it does not change as the book goes on matching.
 func (ob *OrderBook) GetOrder(orderID string) *Order {
-	return ob.backend.GetOrder(orderID)
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	if order := ob.backend.GetOrder(orderID); order != nil {
+		return order.Clone()
+	}
+	return nil
+}
+
+// GetOrders returns the orders with the given IDs, in the same order, with
+// nil for IDs that are not on the book. Like GetOrder, it returns copies.
+// Backends that can read many orders at once, such as Redis, do so in a
+// single round trip.
+func (ob *OrderBook) GetOrders(orderIDs []string) []*Order {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	var orders []*Order
+	if batch, ok := ob.backend.(interface {
+		GetOrders(orderIDs []string) []*Order
+	}); ok {
+		orders = batch.GetOrders(orderIDs)
+	} else {
+		orders = make([]*Order, len(orderIDs))
+		for i, orderID := range orderIDs {
+			orders[i] = ob.backend.GetOrder(orderID)
+		}
+	}
+	for i, order := range orders {
+		if order != nil {
+			orders[i] = order.Clone()
+		}
+	}
+	return orders
+}
+
+// OrderCount returns how many orders rest on the book: bids, asks, stop
+// orders waiting for their trigger and midpoint orders. Backends that can
+// count their orders without reading them implement OrderCount() int.
+func (ob *OrderBook) OrderCount() int {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	if counter, ok := ob.backend.(interface{ OrderCount() int }); ok {
+		return counter.OrderCount()
+	}
+	return len(ob.backend.GetAllOrders())
+}
+
+// GetAllStopOrders returns the stop orders waiting to be triggered: buys,
+// then sells, each by stop price and then by creation time
+func (ob *OrderBook) GetAllStopOrders() []*Order {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	stopBook, ok := ob.backend.GetStopBook().(interface {
+		BuyOrders() []*Order
+		SellOrders() []*Order
+	})
+	if !ok {
+		return nil
+	}
+
+	var stops []*Order
+	for _, side := range [][]*Order{stopBook.BuyOrders(), stopBook.SellOrders()} {
+		sort.SliceStable(side, func(i, j int) bool {
+			if !side[i].StopPrice().Equal(side[j].StopPrice()) {
+				return side[i].StopPrice().LessThan(side[j].StopPrice())
+			}
+			if !side[i].CreatedAt().Equal(side[j].CreatedAt()) {
+				return side[i].CreatedAt().Before(side[j].CreatedAt())
+			}
+			return side[i].ID() < side[j].ID()
+		})
+		stops = append(stops, side...)
+	}
+	return stops
+}
+
+// TotalNotional returns the value, price times quantity, of the resting
+// orders on each side. Stop orders, which are not on the book yet, and
+// midpoint orders, which have no price of their own, are not counted.
+func (ob *OrderBook) TotalNotional() (bidNotional, askNotional fpdecimal.Decimal) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	for _, order := range ob.backend.GetAllOrders() {
+		if order.IsStopOrder() || order.IsMidpointOrder() {
+			continue
+		}
+		notional := order.Price().Mul(order.Quantity())
+		if order.Side() == Buy {
+			bidNotional = bidNotional.Add(notional)
+		} else {
+			askNotional = askNotional.Add(notional)
+		}
+	}
+	return bidNotional, askNotional
+}
+
+// MaxDrawdown estimates how far the best price on side would move if the
+// best shockPct percent of the side's depth, by value, were taken at once.
+// It returns the distance from the current best price to the best price
+// left afterwards, which is how much the spread widens. A shock that takes
+// the whole side reports the distance to its deepest level.
+func (ob *OrderBook) MaxDrawdown(side Side, shockPct float64) fpdecimal.Decimal {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	sideOrders := ob.backend.GetAsks()
+	if side == Buy {
+		sideOrders = ob.backend.GetBids()
+	}
+	levels, ok := sideOrders.(interface {
+		Prices() []fpdecimal.Decimal
+		Orders(price fpdecimal.Decimal) []*Order
+	})
+	if !ok || shockPct <= 0 {
+		return fpdecimal.Zero
+	}
+	prices := levels.Prices()
+	if len(prices) == 0 {
+		return fpdecimal.Zero
+	}
+
+	// Value of each level, best first
+	values := make([]fpdecimal.Decimal, len(prices))
+	total := fpdecimal.Zero
+	for i, price := range prices {
+		for _, order := range levels.Orders(price) {
+			values[i] = values[i].Add(price.Mul(order.Quantity()))
+		}
+		total = total.Add(values[i])
+	}
+
+	// Take whole levels until the shock is used up; the first level it
+	// cannot clear is the new best
+	shock := total.Mul(fpdecimal.FromFloat(shockPct)).Div(fpdecimal.FromInt(100))
+	newBest := prices[len(prices)-1]
+	for i, value := range values {
+		if value.GreaterThan(shock) {
+			newBest = prices[i]
+			break
+		}
+		shock = shock.Sub(value)
+	}
+
+	if newBest.LessThan(prices[0]) {
+		return prices[0].Sub(newBest)
+	}
+	return newBest.Sub(prices[0])
 }
 
 // CancelOrder removes Order with given ID from the Order book or the Stop book
+// and reports it as a user requested cancellation
 func (ob *OrderBook) CancelOrder(orderID string) *Order {
-	order := ob.GetOrder(orderID)
+	return ob.CancelOrderWithReason(context.Background(), orderID, messaging.CancelReasonUserRequested)
+}
+
+// CancelOrderWithReason removes Order with given ID from the Order book or the Stop book
+// and sends a cancel message carrying reason
+func (ob *OrderBook) CancelOrderWithReason(ctx context.Context, orderID string, reason messaging.CancelReason) *Order {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	order := ob.cancelOrder(ctx, orderID, reason)
+	if order != nil {
+		ob.recomputePegs(ctx)
+	}
+	return order
+}
+
+// cancelOrder is CancelOrderWithReason without locking, for use while mu is held
+func (ob *OrderBook) cancelOrder(ctx context.Context, orderID string, reason messaging.CancelReason) *Order {
+	order := ob.backend.GetOrder(orderID)
 	if order == nil {
 		return nil
 	}
 
-	order.Cancel()
+	ob.removeOrder(ctx, order)
+	ob.sendCancelToKafka(ctx, order, reason)
+	canceled := changeDone(order)
+	canceled.Canceled = append(canceled.Canceled, order)
+	ob.emit(canceled)
+	return order
+}
+
+// removeOrder marks order canceled and removes it from the Order book or the
+// Stop book. The caller must hold mu.
+func (ob *OrderBook) removeOrder(ctx context.Context, order *Order) {
+	transition(ctx, order, StateCanceled)
 
 	if order.IsStopOrder() {
 		ob.backend.RemoveFromStopBook(order)
 		ob.backend.DeleteOrder(order.ID())
+	} else if order.IsMidpointOrder() {
+		ob.backend.RemoveFromMidpoint(order)
+		ob.backend.DeleteOrder(order.ID())
 	} else {
 		ob.deleteOrder(order)
 	}
+}
 
-	return order
+// transition moves order to state, which the book has just put it in. The
+// book only makes moves the state machine allows, so a refused one means the
+// order's state was out of step with the book: that is logged, and the order
+// takes the state the book gave it.
+func transition(ctx context.Context, order *Order, state OrderState) {
+	if err := order.Transition(state); err != nil {
+		zlog.Ctx(ctx).Error().
+			Err(err).
+			Str("order_id", order.ID()).
+			Msg("Order state out of step with the book")
+		order.state = state
+	}
+}
+
+// Reset removes every bid, ask, stop and midpoint order from the book,
+// forgets the last trade price, lifts any circuit breaker halt and restarts
+// Done sequence numbers from 1. No cancel messages are sent. Backends that
+// can delete their resting orders in bulk implement FlushOrderBook, which
+// replaces removing them one at a time. Backends that can clear their
+// storage in one step implement ClearAll, which is called afterwards.
+func (ob *OrderBook) Reset() error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if flusher, ok := ob.backend.(interface {
+		FlushOrderBook(ctx context.Context) error
+	}); ok {
+		if err := flusher.FlushOrderBook(context.Background()); err != nil {
+			return fmt.Errorf("flushing order book: %w", err)
+		}
+	} else {
+		for _, order := range ob.backend.GetAllOrders() {
+			ob.removeOrder(context.Background(), order)
+		}
+	}
+
+	if clearer, ok := ob.backend.(interface{ ClearAll() }); ok {
+		clearer.ClearAll()
+	}
+	ob.peggedOrders = nil
+	ob.lastTradePrice = fpdecimal.Zero
+	ob.haltedUntil, ob.haltReason = time.Time{}, ""
+	ob.seq.Store(0)
+
+	for _, side := range []interface{}{ob.backend.GetBids(), ob.backend.GetAsks()} {
+		if orderSide, ok := side.(interface{ Prices() []fpdecimal.Decimal }); ok && len(orderSide.Prices()) > 0 {
+			return ErrResetIncomplete
+		}
+	}
+	return nil
+}
+
+// SetEventChan makes the book send ch a Done whenever its resting orders
+// change: the Done of every processed order, and an unnumbered one for each
+// canceled or amended order, holding that order and, if it was canceled,
+// listing it in Canceled. Sends never block: a Done that finds ch full is
+// dropped, so a receiver should treat each Done as a sign the book changed
+// rather than as a full record of the change. Passing nil stops the sends.
+func (ob *OrderBook) SetEventChan(ch chan<- *Done) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.eventChan = ch
+}
+
+// emit sends done to the event channel if it has room. The caller must hold mu.
+func (ob *OrderBook) emit(done *Done) {
+	if ob.eventChan == nil {
+		return
+	}
+	select {
+	case ob.eventChan <- done:
+	default:
+	}
+}
+
+// Close stops the book from accepting new orders, waits for in-flight Process
+// calls to finish, flushes backends that buffer writes and releases backends
+// that hold a claim on shared storage. It returns ctx.Err() if ctx is done first.
+func (ob *OrderBook) Close(ctx context.Context) error {
+	ob.closeMu.Lock()
+	ob.closed = true
+	ob.closeMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		ob.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var err error
+	if flusher, ok := ob.backend.(interface {
+		Flush(ctx context.Context) error
+	}); ok {
+		err = flusher.Flush(ctx)
+	}
+	if releaser, ok := ob.backend.(interface{ Release() error }); ok {
+		err = errors.Join(err, releaser.Release())
+	}
+	return err
+}
+
+// beginProcess registers an in-flight Process call, or reports false if the book is closed
+func (ob *OrderBook) beginProcess() bool {
+	ob.closeMu.RLock()
+	defer ob.closeMu.RUnlock()
+	if ob.closed {
+		return false
+	}
+	ob.inflight.Add(1)
+	return true
 }
 
 // Process public method
@@ -57,6 +432,24 @@ func (ob *OrderBook) Process(ctx context.Context, order *Order) (done *Done, err
 		return nil, fmt.Errorf("cannot process nil order")
 	}
 
+	if !ob.beginProcess() {
+		return nil, ErrOrderBookClosed
+	}
+	defer ob.inflight.Done()
+
+	process := ob.processOrder
+	for i := len(ob.middleware) - 1; i >= 0; i-- {
+		process = ob.middleware[i](process)
+	}
+	return process(ctx, order)
+}
+
+// processOrder is the innermost ProcessFunc of Process. It holds mu for the
+// whole order, including the stops it triggers and the OCO legs it cancels.
+func (ob *OrderBook) processOrder(ctx context.Context, order *Order) (done *Done, err error) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
 	// Start a new span for order processing
 	ctx, span := otel.StartOrderSpan(ctx, otel.SpanProcessOrder,
 		attribute.String(otel.AttributeOrderID, order.ID()),
@@ -67,12 +460,48 @@ func (ob *OrderBook) Process(ctx context.Context, order *Order) (done *Done, err
 	)
 	defer span.End()
 
+	logger := zlog.Ctx(ctx)
+	logger.Debug().
+		Str("order_id", order.ID()).
+		Str("type", string(order.OrderType())).
+		Str("side", order.Side().String()).
+		Msg("Processing order")
+
+	if reason, halted := ob.halted(time.Now()); halted {
+		span.SetStatus(codes.Error, "market halted")
+		return nil, fmt.Errorf("%w: %s", ErrMarketHalted, reason)
+	}
+
+	if ob.riskChecker != nil {
+		if err := ob.riskChecker.CheckOrder(ctx, order); err != nil {
+			span.SetStatus(codes.Error, "risk check failed")
+			return nil, fmt.Errorf("%w: %w", ErrRiskCheckFailed, err)
+		}
+	}
+
+	if order.IsPegged() {
+		if err := ob.priceNewPeg(order); err != nil {
+			span.SetStatus(codes.Error, "no peg reference price")
+			return nil, err
+		}
+	}
+
+	if err := ob.checkRules(order); err != nil {
+		span.SetStatus(codes.Error, "order breaks matching rules")
+		return nil, err
+	}
+	if ob.rules.PostOnlyDefault && order.IsLimitOrder() && order.TIF() == GTC {
+		order.postOnly = true
+	}
+
 	if order.IsMarketOrder() {
 		done, err = ob.processMarketOrder(ctx, order)
 	} else if order.IsLimitOrder() {
 		done, err = ob.processLimitOrder(ctx, order)
 	} else if order.IsStopOrder() {
 		done, err = ob.processStopOrder(ctx, order)
+	} else if order.IsMidpointOrder() {
+		done, err = ob.processMidpointOrder(ctx, order)
 	} else {
 		if span != nil {
 			span.SetStatus(codes.Error, "unrecognized order type")
@@ -80,11 +509,33 @@ func (ob *OrderBook) Process(ctx context.Context, order *Order) (done *Done, err
 		return nil, fmt.Errorf("unrecognized order type")
 	}
 
+	if done != nil {
+		if order.IsPegged() && done.Stored {
+			ob.peggedOrders = append(ob.peggedOrders, order)
+		}
+		if order.TIF() == GTD && done.Stored {
+			ob.runExpiryWorker()
+		}
+		ob.recomputePegs(ctx)
+	}
+
 	if err != nil {
+		logger.Debug().Err(err).Str("order_id", order.ID()).Msg("Order processing failed")
 		span.SetStatus(codes.Error, "failed to process order")
+		if done != nil {
+			// A timed out order keeps the fills it made
+			ob.emit(done)
+		}
 		return done, err
 	}
 
+	logger.Debug().
+		Str("order_id", order.ID()).
+		Str("processed", done.Processed.String()).
+		Str("left", done.Left.String()).
+		Int("trades", len(done.Trades)).
+		Msg("Order processed")
+
 	// Add trade attributes to span
 	otel.AddAttributes(span,
 		attribute.String(otel.AttributeExecutedQuantity, done.Processed.String()),
@@ -93,11 +544,16 @@ func (ob *OrderBook) Process(ctx context.Context, order *Order) (done *Done, err
 	)
 	span.SetStatus(codes.Ok, "order processed successfully")
 
+	ob.emit(done)
 	return done, nil
 }
 
-// CalculateMarketPrice returns total market Price for requested quantity
+// CalculateMarketPrice returns total market Price for requested quantity.
+// It holds the book's read lock so resting quantities cannot change mid-walk.
 func (ob *OrderBook) CalculateMarketPrice(side Side, quantity fpdecimal.Decimal) (price fpdecimal.Decimal, err error) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
 	price = fpdecimal.Zero
 	remaining := quantity
 
@@ -168,6 +624,7 @@ func (ob *OrderBook) CalculateMarketPrice(side Side, quantity fpdecimal.Decimal)
 
 // private methods
 
+// deleteOrder removes order from the backend and its side. The caller must hold mu.
 func (ob *OrderBook) deleteOrder(order *Order) {
 	ob.backend.DeleteOrder(order.ID())
 
@@ -180,7 +637,12 @@ func (ob *OrderBook) deleteOrder(order *Order) {
 	}
 }
 
+// processMarketOrder fills marketOrder from the opposite side and cancels
+// what is left. A FOK market order is canceled without trading unless it can
+// be filled whole. It runs with mu held by processOrder.
 func (ob *OrderBook) processMarketOrder(ctx context.Context, marketOrder *Order) (*Done, error) {
+	matchStarted := time.Now()
+
 	// Start a new span for market order matching
 	ctx, span := otel.StartOrderSpan(ctx, otel.SpanMatchOrder,
 		attribute.String(otel.AttributeOrderID, marketOrder.ID()),
@@ -188,6 +650,7 @@ func (ob *OrderBook) processMarketOrder(ctx context.Context, marketOrder *Order)
 		attribute.String(otel.AttributeOrderType, string(marketOrder.OrderType())),
 		attribute.String(otel.AttributeOrderQuantity, marketOrder.Quantity().String()),
 		attribute.String(otel.AttributeOrderPrice, marketOrder.Price().String()),
+		attribute.String(otel.AttributeUserAddress, marketOrder.UserAddress()),
 	)
 	defer span.End()
 
@@ -211,6 +674,11 @@ func (ob *OrderBook) processMarketOrder(ctx context.Context, marketOrder *Order)
 		return nil, ErrInvalidQuantity
 	}
 
+	if err := marketOrder.Transition(StateOpen); err != nil {
+		span.SetStatus(codes.Error, "invalid order state")
+		return nil, err
+	}
+
 	// Store the order first
 	err := ob.backend.StoreOrder(marketOrder)
 	if err != nil {
@@ -218,7 +686,9 @@ func (ob *OrderBook) processMarketOrder(ctx context.Context, marketOrder *Order)
 		return nil, err
 	}
 
-	done := newDone(marketOrder)
+	done := ob.newDone(marketOrder)
+	done.MatchStartedAt = matchStarted
+	defer done.completeMatch()
 	remainingQty := quantity
 	originalQty := quantity // Save for IOC checks
 
@@ -233,8 +703,41 @@ func (ob *OrderBook) processMarketOrder(ctx context.Context, marketOrder *Order)
 	}); isOrderSideInterface {
 		prices := ordersInterface.Prices()
 
+		// A FOK market order takes any price, so it trades only if the
+		// whole opposite side holds its quantity. Nothing is matched
+		// before this is known.
+		if marketOrder.TIF() == FOK {
+			availableQty := fpdecimal.Zero
+		marketLevels:
+			for _, orderPrice := range prices {
+				for _, makerOrder := range ordersInterface.Orders(orderPrice) {
+					qty, stop := ob.fokLiquidity(marketOrder, makerOrder)
+					availableQty = availableQty.Add(spent(marketOrder, qty, orderPrice))
+					if stop {
+						break marketLevels
+					}
+				}
+				if availableQty.GreaterThanOrEqual(quantity) {
+					break
+				}
+			}
+			if availableQty.LessThan(quantity) {
+				transition(ctx, marketOrder, StateCanceled)
+				done.appendCanceled(marketOrder)
+				otel.AddEvent(span, otel.EventFOKCanceled, attribute.String(otel.AttributeRemainingQuantity, quantity.String()))
+				ob.backend.DeleteOrder(marketOrder.ID())
+				done.Left = originalQty
+				done.Processed = fpdecimal.Zero
+				done.Stored = false
+				done.appendOrder(marketOrder, fpdecimal.Zero, fpdecimal.Zero)
+				span.SetStatus(codes.Ok, "insufficient liquidity for FOK")
+				return done, nil
+			}
+		}
+
 		if len(prices) == 0 {
 			// No liquidity to satisfy the market order
+			transition(ctx, marketOrder, StateCanceled)
 			done.Left = remainingQty
 			done.appendOrder(marketOrder, fpdecimal.Zero, fpdecimal.Zero)
 			done.Stored = false
@@ -243,36 +746,87 @@ func (ob *OrderBook) processMarketOrder(ctx context.Context, marketOrder *Order)
 			return done, nil
 		}
 
+		if err := ob.checkPriceDeviation(marketOrder, ordersInterface); err != nil {
+			ob.backend.DeleteOrder(marketOrder.ID())
+			span.SetStatus(codes.Error, "price deviation exceeded")
+			return nil, err
+		}
+
 		processedQty := fpdecimal.Zero
 		lastMatchPrice := fpdecimal.Zero
 		matchedOrderCount := int64(0) // Keep track of how many orders were matched
+		timedOut := false
+		selfTradeStopped := false
+		var fillErr error
 
 		// Iterate through prices from best to worst
 		for _, price := range prices {
-			if remainingQty.Equal(fpdecimal.Zero) {
-				break // Market order fully filled
+			if remainingQty.Equal(fpdecimal.Zero) || timedOut || selfTradeStopped || fillErr != nil {
+				break // Market order fully filled, out of time, stopped by STP or the backend failed
 			}
 
-			orders := ordersInterface.Orders(price)
-			for _, makerOrder := range orders {
-				if remainingQty.Equal(fpdecimal.Zero) {
+			// A quote order's budget buys a quantity that depends on the price
+			levelQty := ob.affordable(marketOrder, remainingQty, price)
+			if !levelQty.GreaterThan(fpdecimal.Zero) {
+				break // Too little budget left to buy anything
+			}
+			makers, allotted := ob.makersAt(ordersInterface, price, levelQty)
+			for makerOrder, ok := makers.Next(); ok; makerOrder, ok = makers.Next() {
+				if levelQty.Equal(fpdecimal.Zero) {
 					break // Market order fully filled
 				}
 
 				makerOrder.SetMaker()
 				makerQty := makerOrder.Quantity()
+				if !makerQty.GreaterThan(fpdecimal.Zero) {
+					ob.dropEmptyMaker(ctx, makerOrder)
+					continue
+				}
+				if ob.selfTrade(marketOrder, makerOrder) {
+					if ob.preventSelfTrade(ctx, makerOrder, done) {
+						selfTradeStopped = true
+						break
+					}
+					continue
+				}
+				if allotted != nil {
+					makerQty = allotted[makerOrder.ID()]
+					if !makerQty.GreaterThan(fpdecimal.Zero) {
+						continue
+					}
+				}
 
 				// Calculate match quantity (min of remaining and maker's quantity)
 				var matchQty fpdecimal.Decimal
-				if remainingQty.LessThan(makerQty) {
-					matchQty = remainingQty
+				if levelQty.LessThan(makerQty) {
+					matchQty = levelQty
 				} else {
 					matchQty = makerQty
 				}
 
+				matchQty, stored, err := ob.claimFill(makerOrder, matchQty)
+				if err != nil {
+					err = fmt.Errorf("filling maker order %s: %w", makerOrder.ID(), err)
+					if !processedQty.GreaterThan(fpdecimal.Zero) {
+						span.SetStatus(codes.Error, "failed to fill maker order")
+						return nil, err
+					}
+					// The fills made so far are stored and must be published
+					fillErr = err
+					break
+				}
+				if !matchQty.GreaterThan(fpdecimal.Zero) {
+					continue
+				}
+
 				// Update remaining quantities
-				remainingQty = remainingQty.Sub(matchQty)
-				makerOrder.DecreaseQuantity(matchQty)
+				levelQty = levelQty.Sub(matchQty)
+				remainingQty = remainingQty.Sub(spent(marketOrder, matchQty, price))
+				if err := makerOrder.DecreaseQuantity(matchQty); err != nil {
+					span.SetStatus(codes.Error, "invalid maker order state")
+					return nil, fmt.Errorf("filling maker order %s: %w", makerOrder.ID(), err)
+				}
+				clampMakerQuantity(ctx, makerOrder)
 				processedQty = processedQty.Add(matchQty)
 				lastMatchPrice = price
 				matchedOrderCount++ // Increment counter for each matched order
@@ -280,30 +834,40 @@ func (ob *OrderBook) processMarketOrder(ctx context.Context, marketOrder *Order)
 				// Record the trades - use matchQty for both sides
 				done.appendOrder(marketOrder, matchQty, price)
 				done.appendOrder(makerOrder, matchQty, price)
+				ob.chargeFees(done, matchQty, price)
+				recordFill(span, makerOrder, matchQty, price)
 
 				// Update the maker order or remove it if fully filled
-				if makerOrder.Quantity().Equal(fpdecimal.Zero) {
-					// Completely filled, delete from book
-					ob.backend.RemoveFromSide(makerOrder.Side(), makerOrder)
-					ob.backend.DeleteOrder(makerOrder.ID())
+				if ob.settleMaker(ctx, makerOrder, done, stored) && allotted == nil {
+					makers.requeue(makerOrder)
+				}
 
-					// Check if maker order is part of OCO group
-					ob.checkOCO(makerOrder, done)
-				} else {
-					// Update the partially filled maker order in storage
-					ob.backend.UpdateOrder(makerOrder)
+				// A FOK order's liquidity was checked up front, so it is
+				// not cut short
+				if marketOrder.TIF() != FOK && remainingQty.GreaterThan(fpdecimal.Zero) && ctx.Err() != nil {
+					timedOut = true
+					break
 				}
 			}
 		}
+		if timedOut {
+			// The fills made so far stand and must still be published
+			ctx = context.WithoutCancel(ctx)
+		}
 
 		// Update market order and done
 		done.Processed = processedQty
+		if processedQty.GreaterThan(fpdecimal.Zero) {
+			transition(ctx, marketOrder, fillState(remainingQty))
+		}
 
-		// Market orders are always immediate-or-cancel in nature
+		// Other market orders are immediate-or-cancel in nature
 		// So we need to set unmatched quantity as canceled
 		if remainingQty.GreaterThan(fpdecimal.Zero) {
 			// For IOC market orders, we need to explicitly cancel the remaining quantity
+			transition(ctx, marketOrder, StateCanceled)
 			done.appendCanceled(marketOrder)
+			otel.AddEvent(span, otel.EventIOCCanceled, attribute.String(otel.AttributeRemainingQuantity, remainingQty.String()))
 			// Record the remaining quantity properly
 			done.Left = remainingQty
 		} else {
@@ -338,18 +902,20 @@ func (ob *OrderBook) processMarketOrder(ctx context.Context, marketOrder *Order)
 		}
 
 		// Record metrics for matched orders if we had any matches
-		if matchedOrderCount > 0 {
+		if matchedOrderCount > 0 && !ob.detached {
 			metrics := otel.GetOrderBookMetrics()
 			metrics.RecordMatchedOrders(ctx, "market", matchedOrderCount)
 		}
 
 		// Update last trade price for stop orders if a trade occurred
 		if processedQty.GreaterThan(fpdecimal.Zero) {
+			ob.checkCircuitBreaker(lastMatchPrice, done)
 			ob.lastTradePrice = lastMatchPrice
 			ob.checkStopOrderTrigger(ctx, ob.lastTradePrice)
-
+		}
+		if processedQty.GreaterThan(fpdecimal.Zero) || done.STPTriggered {
 			// Send to Kafka using the parent context
-			sendToKafka(ctx, done)
+			ob.publishMatch(ctx, done)
 		}
 
 		// Add trade attributes to span
@@ -358,6 +924,14 @@ func (ob *OrderBook) processMarketOrder(ctx context.Context, marketOrder *Order)
 			attribute.String(otel.AttributeRemainingQuantity, done.Left.String()),
 			attribute.Int(otel.AttributeTradeCount, len(done.Trades)),
 		)
+		if fillErr != nil {
+			span.SetStatus(codes.Error, "failed to fill maker order")
+			return done, fillErr
+		}
+		if timedOut {
+			span.SetStatus(codes.Error, "matching timed out")
+			return done, ErrMatchingTimeout
+		}
 		span.SetStatus(codes.Ok, "market order processed successfully")
 
 		return done, nil
@@ -371,7 +945,12 @@ func (ob *OrderBook) processMarketOrder(ctx context.Context, marketOrder *Order)
 	return done, nil
 }
 
+// processLimitOrder matches limitOrder and rests what is left. It runs with
+// mu held, either by processOrder or by triggerStopOrder when a stop
+// order is activated, and may cancel OCO legs through cancelOrder.
 func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (*Done, error) {
+	matchStarted := time.Now()
+
 	// Start a new span for limit order matching
 	ctx, span := otel.StartOrderSpan(ctx, otel.SpanMatchOrder,
 		attribute.String(otel.AttributeOrderID, limitOrder.ID()),
@@ -379,6 +958,7 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 		attribute.String(otel.AttributeOrderType, string(limitOrder.OrderType())),
 		attribute.String(otel.AttributeOrderQuantity, limitOrder.Quantity().String()),
 		attribute.String(otel.AttributeOrderPrice, limitOrder.Price().String()),
+		attribute.String(otel.AttributeUserAddress, limitOrder.UserAddress()),
 	)
 	defer span.End()
 
@@ -414,7 +994,24 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 		}
 	}
 
-	done := newDone(limitOrder)
+	// Post-only orders are rejected before anything is stored
+	if limitOrder.IsPostOnly() && ob.wouldTake(limitOrder) {
+		if span != nil {
+			span.SetStatus(codes.Error, "post-only order would take")
+		}
+		return nil, ErrPostOnlyWouldTake
+	}
+
+	if err := limitOrder.Transition(StateOpen); err != nil {
+		if span != nil {
+			span.SetStatus(codes.Error, "invalid order state")
+		}
+		return nil, err
+	}
+
+	done := ob.newDone(limitOrder)
+	done.MatchStartedAt = matchStarted
+	defer done.completeMatch()
 
 	// Store the limit order
 	err := ob.backend.StoreOrder(limitOrder)
@@ -449,7 +1046,9 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 			// First check if there are any prices available
 			if len(prices) == 0 {
 				// No liquidity, cancel FOK order
+				transition(ctx, limitOrder, StateCanceled)
 				done.appendCanceled(limitOrder)
+				otel.AddEvent(span, otel.EventFOKCanceled, attribute.String(otel.AttributeRemainingQuantity, quantity.String()))
 				ob.backend.DeleteOrder(limitOrder.ID())
 				done.Left = quantity
 				done.Processed = fpdecimal.Zero
@@ -460,6 +1059,7 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 
 			// Calculate available quantity across all valid price levels
 			availableQty := fpdecimal.Zero
+		limitLevels:
 			for _, orderPrice := range prices {
 				// Check if price condition is met
 				isPriceMatching := false
@@ -472,7 +1072,11 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 				if isPriceMatching {
 					orders := ordersInterface.Orders(orderPrice)
 					for _, makerOrder := range orders {
-						availableQty = availableQty.Add(makerOrder.Quantity())
+						qty, stop := ob.fokLiquidity(limitOrder, makerOrder)
+						availableQty = availableQty.Add(qty)
+						if stop {
+							break limitLevels
+						}
 					}
 				} else {
 					break // No need to check worse prices
@@ -481,7 +1085,9 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 
 			// If available quantity is less than the FOK order quantity, cancel the order
 			if availableQty.LessThan(quantity) {
+				transition(ctx, limitOrder, StateCanceled)
 				done.appendCanceled(limitOrder)
+				otel.AddEvent(span, otel.EventFOKCanceled, attribute.String(otel.AttributeRemainingQuantity, quantity.String()))
 				ob.backend.DeleteOrder(limitOrder.ID())
 				done.Left = quantity
 				done.Processed = fpdecimal.Zero
@@ -491,13 +1097,41 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 			}
 		}
 
+		if err := ob.checkPriceDeviation(limitOrder, ordersInterface); err != nil {
+			ob.backend.DeleteOrder(limitOrder.ID())
+			if span != nil {
+				span.SetStatus(codes.Error, "price deviation exceeded")
+			}
+			return nil, err
+		}
+
 		processedQty := fpdecimal.Zero
 		lastMatchPrice := fpdecimal.Zero
+		selfTradeStopped := false
+
+		// Resting midpoint orders trade first when the order reaches the
+		// midpoint, which is at least as good as any lit price it could take
+		if mid, ok := ob.midpoint(); ok && limitOrder.TIF() != FOK && !limitOrder.IsPostOnly() && limitCrosses(limitOrder, mid) {
+			var err error
+			quantity, processedQty, selfTradeStopped, err = ob.fillPegged(ctx, limitOrder, quantity, mid, done)
+			if err != nil {
+				if span != nil {
+					span.SetStatus(codes.Error, "invalid maker order state")
+				}
+				return nil, err
+			}
+			if processedQty.GreaterThan(fpdecimal.Zero) {
+				lastMatchPrice = mid
+			}
+		}
+
 		matchedOrderCount := int64(0) // Keep track of how many orders were matched
+		timedOut := false
+		var fillErr error
 
 		// Iterate through the prices
 		for _, orderPrice := range prices {
-			if quantity.Equal(fpdecimal.Zero) {
+			if quantity.Equal(fpdecimal.Zero) || timedOut || selfTradeStopped || fillErr != nil {
 				break
 			}
 
@@ -510,15 +1144,36 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 			}
 
 			if isPriceMatching {
-				// Get all orders at this price level
-				orders := ordersInterface.Orders(orderPrice)
-				for _, makerOrder := range orders {
+				// Walk the orders at this price level
+				makers, allotted := ob.makersAt(ordersInterface, orderPrice, quantity)
+				fillPrice := orderPrice
+				if ob.rules.NoPriceImprovement {
+					fillPrice = price
+				}
+				for makerOrder, ok := makers.Next(); ok; makerOrder, ok = makers.Next() {
 					if quantity.Equal(fpdecimal.Zero) {
 						break
 					}
 
 					makerOrder.SetMaker()
 					makerQty := makerOrder.Quantity()
+					if !makerQty.GreaterThan(fpdecimal.Zero) {
+						ob.dropEmptyMaker(ctx, makerOrder)
+						continue
+					}
+					if ob.selfTrade(limitOrder, makerOrder) {
+						if ob.preventSelfTrade(ctx, makerOrder, done) {
+							selfTradeStopped = true
+							break
+						}
+						continue
+					}
+					if allotted != nil {
+						makerQty = allotted[makerOrder.ID()]
+						if !makerQty.GreaterThan(fpdecimal.Zero) {
+							continue
+						}
+					}
 
 					// Calculate match quantity (min of remaining and maker's quantity)
 					var matchQty fpdecimal.Decimal
@@ -528,27 +1183,52 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 						matchQty = makerQty
 					}
 
+					matchQty, stored, err := ob.claimFill(makerOrder, matchQty)
+					if err != nil {
+						err = fmt.Errorf("filling maker order %s: %w", makerOrder.ID(), err)
+						if !processedQty.GreaterThan(fpdecimal.Zero) {
+							if span != nil {
+								span.SetStatus(codes.Error, "failed to fill maker order")
+							}
+							return nil, err
+						}
+						// The fills made so far are stored and must be published
+						fillErr = err
+						break
+					}
+					if !matchQty.GreaterThan(fpdecimal.Zero) {
+						continue
+					}
+
 					// Update remaining quantities
 					quantity = quantity.Sub(matchQty)
-					makerOrder.DecreaseQuantity(matchQty)
+					if err := makerOrder.DecreaseQuantity(matchQty); err != nil {
+						if span != nil {
+							span.SetStatus(codes.Error, "invalid maker order state")
+						}
+						return nil, fmt.Errorf("filling maker order %s: %w", makerOrder.ID(), err)
+					}
+					clampMakerQuantity(ctx, makerOrder)
 					processedQty = processedQty.Add(matchQty)
-					lastMatchPrice = orderPrice
+					lastMatchPrice = fillPrice
 					matchedOrderCount++ // Increment counter for each matched order
 
 					// Record the trades for both sides - use matchQty for both
-					done.appendOrder(limitOrder, matchQty, orderPrice)
-					done.appendOrder(makerOrder, matchQty, orderPrice)
+					done.appendOrder(limitOrder, matchQty, fillPrice)
+					done.appendOrder(makerOrder, matchQty, fillPrice)
+					ob.chargeFees(done, matchQty, fillPrice)
+					recordFill(span, makerOrder, matchQty, fillPrice)
 
 					// Update the maker order or remove it if fully filled
-					if makerOrder.Quantity().Equal(fpdecimal.Zero) {
-						ob.backend.RemoveFromSide(makerOrder.Side(), makerOrder)
-						ob.backend.DeleteOrder(makerOrder.ID())
+					if ob.settleMaker(ctx, makerOrder, done, stored) && allotted == nil {
+						makers.requeue(makerOrder)
+					}
 
-						// Check if maker order is part of OCO group
-						ob.checkOCO(makerOrder, done)
-					} else {
-						// Update the partially filled maker order in storage
-						ob.backend.UpdateOrder(makerOrder)
+					// FOK orders run to completion: their liquidity was checked
+					// up front and a partial fill cannot be undone
+					if limitOrder.TIF() != FOK && quantity.GreaterThan(fpdecimal.Zero) && ctx.Err() != nil {
+						timedOut = true
+						break
 					}
 				}
 			} else {
@@ -556,18 +1236,30 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 				break
 			}
 		}
+		if timedOut {
+			// The fills made so far stand and must still be published
+			ctx = context.WithoutCancel(ctx)
+		}
 
 		// Record metrics for matched orders if we had any matches
-		if matchedOrderCount > 0 {
+		if matchedOrderCount > 0 && !ob.detached {
 			metrics := otel.GetOrderBookMetrics()
 			metrics.RecordMatchedOrders(ctx, "limit", matchedOrderCount)
 		}
 
+		if processedQty.GreaterThan(fpdecimal.Zero) {
+			transition(ctx, limitOrder, fillState(quantity))
+		}
+
 		// Handle FOK orders specially - if we didn't fill the entire order, cancel the whole thing
-		if limitOrder.TIF() == FOK && !quantity.Equal(fpdecimal.Zero) {
+		// A FOK order the backend failed to fill whole keeps the fills it
+		// made, as they are already stored, and is canceled like an IOC order
+		if limitOrder.TIF() == FOK && !quantity.Equal(fpdecimal.Zero) && fillErr == nil {
 			// Undo all matches since we're canceling the FOK order
 			// This is a simplification; ideally we should revert the state of all maker orders
+			transition(ctx, limitOrder, StateCanceled)
 			done.appendCanceled(limitOrder)
+			otel.AddEvent(span, otel.EventFOKCanceled, attribute.String(otel.AttributeRemainingQuantity, originalQty.String()))
 			ob.backend.DeleteOrder(limitOrder.ID())
 			done.Left = originalQty
 			done.Processed = fpdecimal.Zero
@@ -578,7 +1270,7 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 
 			// FOK cancellation should also send a message
 			fmt.Printf("Sending FOK cancellation message for order %s\n", limitOrder.ID())
-			sendToKafka(ctx, done)
+			ob.sendToKafka(ctx, done)
 
 			return done, nil
 		}
@@ -635,19 +1327,25 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 			}
 
 			// Update last trade price for stop orders
+			ob.checkCircuitBreaker(lastMatchPrice, done)
 			ob.lastTradePrice = lastMatchPrice
 			ob.checkStopOrderTrigger(ctx, ob.lastTradePrice)
 
 			// Send the message to Kafka
-			sendToKafka(ctx, done)
+			ob.publishMatch(ctx, done)
 
 			return done, nil
 		}
 
-		// Check if we need to add a partially filled or unfilled order to the book
+		// Check if we need to add a partially filled or unfilled order to the book.
+		// An order that ran out of time, was stopped by self-trade
+		// prevention or a failed fill, or is a GTD order past its expiry is
+		// treated as IOC.
 		if !limitOrder.Quantity().Equal(fpdecimal.Zero) && !quantity.Equal(fpdecimal.Zero) {
-			if limitOrder.TIF() == IOC {
+			if limitOrder.TIF() == IOC || timedOut || selfTradeStopped || fillErr != nil || limitOrder.expired(time.Now()) {
+				transition(ctx, limitOrder, StateCanceled)
 				done.appendCanceled(limitOrder)
+				otel.AddEvent(span, otel.EventIOCCanceled, attribute.String(otel.AttributeRemainingQuantity, quantity.String()))
 				ob.backend.DeleteOrder(limitOrder.ID())
 				done.Left = quantity
 				done.Processed = processedQty
@@ -658,15 +1356,31 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 
 				// Update last trade price for stop orders if a trade occurred
 				if processedQty.GreaterThan(fpdecimal.Zero) {
+					ob.checkCircuitBreaker(lastMatchPrice, done)
 					ob.lastTradePrice = lastMatchPrice
 					ob.checkStopOrderTrigger(ctx, ob.lastTradePrice)
-					sendToKafka(ctx, done)
+				}
+				if processedQty.GreaterThan(fpdecimal.Zero) || done.STPTriggered {
+					ob.publishMatch(ctx, done)
 				}
 
+				if fillErr != nil {
+					if span != nil {
+						span.SetStatus(codes.Error, "failed to fill maker order")
+					}
+					return done, fillErr
+				}
+				if timedOut {
+					if span != nil {
+						span.SetStatus(codes.Error, "matching timed out")
+					}
+					return done, ErrMatchingTimeout
+				}
 				return done, nil
 			}
 			// For GTC or other TIFs that allow resting orders:
 			limitOrder.SetQuantity(quantity)
+			limitOrder.hideReserve()
 			ob.backend.UpdateOrder(limitOrder) // Update the order with the new quantity
 			ob.backend.AppendToSide(limitOrder.Side(), limitOrder)
 			// Append to done to indicate the order is now resting on the book with remaining qty
@@ -698,11 +1412,15 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 		// Update last trade price for stop orders if a trade occurred
 		if processedQty.GreaterThan(fpdecimal.Zero) {
 			// Use the price of the last matched order as the trade price
+			ob.checkCircuitBreaker(lastMatchPrice, done)
 			ob.lastTradePrice = lastMatchPrice
 			ob.checkStopOrderTrigger(ctx, ob.lastTradePrice)
-
+		}
+		// Orders that come to rest are published even without a trade, so
+		// the book can be rebuilt from its messages
+		if processedQty.GreaterThan(fpdecimal.Zero) || done.STPTriggered || done.Stored {
 			// Send to Kafka using the parent context
-			sendToKafka(ctx, done)
+			ob.publishMatch(ctx, done)
 		}
 
 		// Add trade attributes to span
@@ -724,6 +1442,8 @@ func (ob *OrderBook) processLimitOrder(ctx context.Context, limitOrder *Order) (
 	return done, nil
 }
 
+// processStopOrder stores stopOrder in the stop book, or activates it at
+// once if the last trade already reached its stop price. It runs with mu held.
 func (ob *OrderBook) processStopOrder(ctx context.Context, stopOrder *Order) (*Done, error) {
 	if !stopOrder.IsStopOrder() {
 		return nil, ErrInvalidArgument
@@ -734,7 +1454,7 @@ func (ob *OrderBook) processStopOrder(ctx context.Context, stopOrder *Order) (*D
 		return nil, ErrOrderExists
 	}
 
-	done := newDone(stopOrder)
+	done := ob.newDone(stopOrder)
 
 	// Store the stop order
 	err := ob.backend.StoreOrder(stopOrder)
@@ -764,13 +1484,15 @@ func (ob *OrderBook) processStopOrder(ctx context.Context, stopOrder *Order) (*D
 				return nil, fmt.Errorf("error processing triggered stop order: %w", err)
 			}
 
-			// Merge the results
+			// Merge the results, followed by the activation
 			done.Trades = limitDone.Trades
+			done.Processed = limitDone.Processed
 			done.Left = limitDone.Left
 			done.Stored = limitDone.Stored
+			done.appendActivated(stopOrder)
 
 			// Send done message to Kafka
-			sendToKafka(ctx, done)
+			ob.sendToKafka(ctx, done)
 			return done, nil
 		}
 	}
@@ -783,17 +1505,41 @@ func (ob *OrderBook) processStopOrder(ctx context.Context, stopOrder *Order) (*D
 	done.appendOrder(stopOrder, fpdecimal.Zero, stopOrder.Price())
 
 	// Send the message about storing the stop order
-	sendToKafka(ctx, done)
+	ob.sendToKafka(ctx, done)
 	return done, nil
 }
 
-// Helper function to check if a stop order should be triggered
+// recordFill adds a fill event for a match against maker to span
+func recordFill(span trace.Span, maker *Order, quantity, price fpdecimal.Decimal) {
+	if !span.IsRecording() {
+		return
+	}
+	otel.AddEvent(span, otel.EventFill,
+		attribute.String(otel.AttributeMakerOrderID, maker.ID()),
+		attribute.String(otel.AttributeMatchQuantity, quantity.String()),
+		attribute.String(otel.AttributeMatchPrice, price.String()),
+	)
+}
+
+// checkStopOrderTrigger activates the stop orders lastPrice reaches. The
+// caller must hold mu.
 func (ob *OrderBook) checkStopOrderTrigger(ctx context.Context, lastPrice fpdecimal.Decimal) {
 	// Update the last trade price
 	ob.lastTradePrice = lastPrice
 	stopBook := ob.backend.GetStopBook()
 
-	// First try the BuyOrders/SellOrders interface
+	// Stop books that keep stops sorted by stop price hand out only the
+	// triggered ones
+	if stopBookInterface, ok := stopBook.(interface {
+		PopTriggered(lastPrice fpdecimal.Decimal) *Order
+	}); ok {
+		for order := stopBookInterface.PopTriggered(lastPrice); order != nil; order = stopBookInterface.PopTriggered(lastPrice) {
+			ob.triggerStopOrder(ctx, order)
+		}
+		return
+	}
+
+	// Then try the BuyOrders/SellOrders interface
 	if stopBookInterface, ok := stopBook.(interface {
 		BuyOrders() []*Order
 		SellOrders() []*Order
@@ -840,8 +1586,15 @@ func (ob *OrderBook) checkStopOrderTrigger(ctx context.Context, lastPrice fpdeci
 	}
 }
 
-// Helper to trigger a stop order
+// triggerStopOrder moves order from the stop book and processes it as a
+// limit order, under the lock the caller already holds
 func (ob *OrderBook) triggerStopOrder(ctx context.Context, order *Order) {
+	otel.AddEvent(trace.SpanFromContext(ctx), otel.EventStopTriggered,
+		attribute.String(otel.AttributeOrderID, order.ID()),
+		attribute.String(otel.AttributeOrderStopPrice, order.StopPrice().String()),
+		attribute.String(otel.AttributeLastTradePrice, ob.lastTradePrice.String()),
+	)
+
 	// Remove the stop order from the stop book
 	ob.backend.RemoveFromStopBook(order)
 
@@ -874,50 +1627,61 @@ func (ob *OrderBook) triggerStopOrder(ctx context.Context, order *Order) {
 		return
 	}
 
-	// Update the order record in the backend
-	err = ob.backend.StoreOrder(limitOrder)
-	if err != nil {
-		fmt.Printf("Error storing converted limit order: %v\n", err)
-		return
-	}
-
 	// Create a done object to track the activation
-	done := newDone(order)
+	done := ob.newDone(order)
 	done.appendActivated(order)
 
-	// Process the newly activated limit order
-	limitDone, processErr := ob.processLimitOrder(ctx, limitOrder)
+	// An activated stop order cancels the other leg of its OCO pair
+	ob.cancelOCO(ctx, order, done, messaging.CancelReasonStopActivated)
+
+	// Process the newly activated limit order. It was not submitted by the
+	// caller, so the caller's deadline does not cut its matching short.
+	limitDone, processErr := ob.processLimitOrder(context.WithoutCancel(ctx), limitOrder)
 	if processErr != nil {
 		fmt.Printf("Error processing activated limit order: %v\n", processErr)
 		// Still send activation message to Kafka
-		sendToKafka(ctx, done)
+		ob.sendToKafka(ctx, done)
 		return
 	}
 
-	// Merge the results from the limit order processing
-	done.Trades = limitDone.Trades
+	// Merge the results from the limit order processing, keeping the
+	// activation after the fills
+	done.Trades = append(limitDone.Trades, done.Trades...)
 	done.Left = limitDone.Left
 	done.Processed = limitDone.Processed
 	done.Stored = limitDone.Stored
 
 	// Send the complete message to Kafka
-	sendToKafka(ctx, done)
+	ob.sendToKafka(ctx, done)
 }
 
-func (ob *OrderBook) checkOCO(order *Order, done *Done) bool {
+// checkOCO cancels the other leg of a filled order's OCO pair and marks done
+// as having done so. It uses cancelOrder, not CancelOrder, because the caller
+// holds mu.
+func (ob *OrderBook) checkOCO(ctx context.Context, order *Order, done *Done) bool {
+	if !ob.cancelOCO(ctx, order, done, messaging.CancelReasonOCOTriggered) {
+		return false
+	}
+	done.CancelledByOCO = true
+	return true
+}
+
+// cancelOCO cancels the other leg of order's OCO pair, if any, with the given reason
+func (ob *OrderBook) cancelOCO(ctx context.Context, order *Order, done *Done, reason messaging.CancelReason) bool {
 	if order.OCO() == "" {
 		return false
 	}
 
-	// Check if OCO order exists and cancel it
+	// Check if OCO order exists and cancel it. The backend mapping is gone
+	// once order itself has been deleted, so fall back to the order's own link.
 	ocoID := ob.backend.CheckOCO(order.ID())
 	if ocoID == "" {
-		return false
+		ocoID = order.OCO()
 	}
 
-	ocoOrder := ob.GetOrder(ocoID)
+	ocoOrder := ob.backend.GetOrder(ocoID)
 	if ocoOrder != nil {
-		ob.CancelOrder(ocoID)
+		ob.cancelOrder(ctx, ocoID, reason)
 		done.appendCanceled(ocoOrder)
 		return true
 	}
@@ -947,6 +1711,12 @@ func (ob *OrderBook) String() string {
 	}
 	builder.WriteString("\n")
 
+	// The stop book writes its own buy and sell headers
+	if stringer, ok := ob.backend.GetStopBook().(fmt.Stringer); ok {
+		builder.WriteString(stringer.String())
+		builder.WriteString("\n")
+	}
+
 	return builder.String()
 }
 
@@ -960,6 +1730,12 @@ func (ob *OrderBook) getOppositeOrders(side Side) interface{} {
 	return ob.backend.GetBids() // For sell orders, get buy orders
 }
 
+// wouldTake reports whether limit order crosses the best price on the opposite side
+func (ob *OrderBook) wouldTake(order *Order) bool {
+	best, ok := bestPrice(ob.getOppositeOrders(order.Side()))
+	return ok && limitCrosses(order, best)
+}
+
 // oppositeOrder returns the opposite side
 func oppositeOrder(side Side) Side {
 	if side == Buy {
@@ -984,18 +1760,229 @@ func (ob *OrderBook) matchPrice(side Side, orderPrice, bookPrice fpdecimal.Decim
 	return orderPrice.LessThanOrEqual(bookPrice)
 }
 
-// GetBids returns the bid side of the order book
+// GetBids returns the bid side of the order book. The side is not guarded by
+// the book's lock; use GetDepthAtPrice or Snapshot for reads that must not
+// race with Process.
 func (ob *OrderBook) GetBids() interface{} {
 	return ob.backend.GetBids()
 }
 
-// GetAsks returns the ask side of the order book
+// GetAsks returns the ask side of the order book. Like GetBids, it is not
+// guarded by the book's lock.
 func (ob *OrderBook) GetAsks() interface{} {
 	return ob.backend.GetAsks()
 }
 
-// Implement convertTrades function
-func convertTrades(trades []TradeOrder) []messaging.Trade {
+// GetOrdersAtPrice returns the resting orders on side at exactly price
+func (ob *OrderBook) GetOrdersAtPrice(side Side, price fpdecimal.Decimal) ([]*Order, error) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.ordersAtPrice(side, price)
+}
+
+// GetDepthAtPrice returns the total quantity and number of resting orders on
+// side at exactly price. An empty price level is not an error.
+func (ob *OrderBook) GetDepthAtPrice(side Side, price fpdecimal.Decimal) (qty fpdecimal.Decimal, count int, err error) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	orders, err := ob.ordersAtPrice(side, price)
+	if err != nil {
+		return fpdecimal.Zero, 0, err
+	}
+
+	qty = fpdecimal.Zero
+	for _, order := range orders {
+		qty = qty.Add(order.Quantity())
+	}
+	return qty, len(orders), nil
+}
+
+// BestBid returns the highest bid price and the quantity resting at it. It
+// reports false when there are no bids.
+func (ob *OrderBook) BestBid() (price, qty fpdecimal.Decimal, ok bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.bestLevel(Buy)
+}
+
+// BestAsk returns the lowest ask price and the quantity resting at it. It
+// reports false when there are no asks.
+func (ob *OrderBook) BestAsk() (price, qty fpdecimal.Decimal, ok bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.bestLevel(Sell)
+}
+
+// BBO is the best bid and offer of an order book at one moment
+type BBO struct {
+	BidPrice, BidQty fpdecimal.Decimal
+	AskPrice, AskQty fpdecimal.Decimal
+	// HasBid and HasAsk report whether the sides have orders; the prices
+	// and quantities of an empty side are zero
+	HasBid, HasAsk bool
+}
+
+// Midpoint returns the mid price of the BBO, as OrderBook.Midpoint does. It
+// reports false when either side is empty.
+func (b BBO) Midpoint() (fpdecimal.Decimal, bool) {
+	if !b.HasBid || !b.HasAsk {
+		return fpdecimal.Zero, false
+	}
+	return MidPrice(b.BidPrice, b.AskPrice), true
+}
+
+// BBO returns the best bid and ask with the quantity resting at each, read
+// under one lock so they belong to the same state of the book
+func (ob *OrderBook) BBO() BBO {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	var bbo BBO
+	bbo.BidPrice, bbo.BidQty, bbo.HasBid = ob.bestLevel(Buy)
+	bbo.AskPrice, bbo.AskQty, bbo.HasAsk = ob.bestLevel(Sell)
+	return bbo
+}
+
+// bestLevel returns the best price on side and its total quantity, for use
+// while mu is held
+func (ob *OrderBook) bestLevel(side Side) (price, qty fpdecimal.Decimal, ok bool) {
+	orders := ob.backend.GetAsks()
+	if side == Buy {
+		orders = ob.backend.GetBids()
+	}
+	price, ok = bestPrice(orders)
+	if !ok {
+		return fpdecimal.Zero, fpdecimal.Zero, false
+	}
+
+	level, err := ob.ordersAtPrice(side, price)
+	if err != nil {
+		return fpdecimal.Zero, fpdecimal.Zero, false
+	}
+	qty = fpdecimal.Zero
+	for _, order := range level {
+		qty = qty.Add(order.Quantity())
+	}
+	return price, qty, true
+}
+
+// makerIterator iterates the orders at price on side, incrementally if the
+// side supports it
+func makerIterator(side interface {
+	Orders(price fpdecimal.Decimal) []*Order
+}, price fpdecimal.Decimal) OrderIterator {
+	if iterable, ok := side.(interface {
+		IterateOrders(price fpdecimal.Decimal) OrderIterator
+	}); ok {
+		return iterable.IterateOrders(price)
+	}
+	return &sliceIterator{orders: side.Orders(price)}
+}
+
+// claimFill takes quantity of makerOrder for a trade and returns how much
+// was taken, and whether the backend already stored the fill. A backend able
+// to fill a resting order atomically checks the stored quantity first and
+// may grant less, or nothing, when the order was filled elsewhere since it
+// was read. makerOrder's quantity is then set from what the backend has
+// left, so that once the fill is taken off it holds the stored quantity
+// rather than one worked out from a stale read.
+func (ob *OrderBook) claimFill(makerOrder *Order, quantity fpdecimal.Decimal) (filled fpdecimal.Decimal, stored bool, err error) {
+	filler, ok := ob.backend.(interface {
+		FillOrder(order *Order, quantity fpdecimal.Decimal) (filled, remaining fpdecimal.Decimal, err error)
+	})
+	if !ok {
+		return quantity, false, nil
+	}
+	filled, remaining, err := filler.FillOrder(makerOrder, quantity)
+	if err != nil {
+		return fpdecimal.Zero, false, err
+	}
+	makerOrder.SetQuantity(remaining.Add(filled))
+	return filled, true, nil
+}
+
+// settleMaker stores makerOrder after a fill. A filled order leaves the book
+// and cancels its OCO leg. An iceberg order whose shown quantity was filled
+// shows more of its reserve and goes to the back of the queue at its price;
+// settleMaker then reports true so the order can be matched again. When
+// stored is set, the backend already applied the fill to the order and its
+// side, so only what the fill did not cover is written.
+func (ob *OrderBook) settleMaker(ctx context.Context, makerOrder *Order, done *Done, stored bool) bool {
+	if makerOrder.replenish() {
+		if !stored {
+			ob.backend.RemoveFromSide(makerOrder.Side(), makerOrder)
+		}
+		ob.backend.UpdateOrder(makerOrder)
+		ob.backend.AppendToSide(makerOrder.Side(), makerOrder)
+		done.Replenished = true
+		return true
+	}
+
+	if !makerOrder.Quantity().GreaterThan(fpdecimal.Zero) {
+		// Completely filled, delete from book
+		if !stored {
+			ob.backend.RemoveFromSide(makerOrder.Side(), makerOrder)
+		}
+		ob.backend.DeleteOrder(makerOrder.ID())
+
+		// Check if maker order is part of OCO group
+		ob.checkOCO(ctx, makerOrder, done)
+		return false
+	}
+
+	// Update the partially filled maker order in storage
+	if !stored {
+		ob.backend.UpdateOrder(makerOrder)
+	}
+	return false
+}
+
+// dropEmptyMaker removes a resting order that has no quantity left from the
+// book without trading with it. Only a backend holding stale or corrupted
+// orders returns one.
+func (ob *OrderBook) dropEmptyMaker(ctx context.Context, order *Order) {
+	zlog.Ctx(ctx).Error().
+		Str("order_id", order.ID()).
+		Str("quantity", order.Quantity().String()).
+		Msg("Removing resting order without quantity")
+	ob.backend.RemoveFromSide(order.Side(), order)
+	ob.backend.DeleteOrder(order.ID())
+}
+
+// clampMakerQuantity zeroes the quantity of a maker order filled past zero,
+// so it leaves the book instead of resting with a negative quantity
+func clampMakerQuantity(ctx context.Context, order *Order) {
+	if order.Quantity().LessThan(fpdecimal.Zero) {
+		zlog.Ctx(ctx).Error().
+			Str("order_id", order.ID()).
+			Str("quantity", order.Quantity().String()).
+			Msg("Maker order filled past zero")
+		order.SetQuantity(fpdecimal.Zero)
+	}
+}
+
+// ordersAtPrice is GetOrdersAtPrice without locking
+func (ob *OrderBook) ordersAtPrice(side Side, price fpdecimal.Decimal) ([]*Order, error) {
+	var orders interface{}
+	if side == Buy {
+		orders = ob.backend.GetBids()
+	} else {
+		orders = ob.backend.GetAsks()
+	}
+
+	orderSide, ok := orders.(interface {
+		Orders(price fpdecimal.Decimal) []*Order
+	})
+	if !ok {
+		return nil, ErrInvalidArgument
+	}
+	return orderSide.Orders(price), nil
+}
+
+// convertTrades converts trades to messages, formatting prices and
+// quantities with the given precisions
+func convertTrades(trades []TradeOrder, pricePrecision, qtyPrecision int) []messaging.Trade {
 	converted := make([]messaging.Trade, len(trades))
 	for i, trade := range trades {
 		role := "MAKER"
@@ -1003,36 +1990,43 @@ func convertTrades(trades []TradeOrder) []messaging.Trade {
 			role = "TAKER"
 		}
 
-		// Format decimal values consistently with 3 decimal places
-		formatDecimal := func(d fpdecimal.Decimal) string {
-			// Ensure decimal is formatted with 3 decimal places
-			val := d.String()
-			parts := strings.Split(val, ".")
-			if len(parts) == 1 {
-				// No decimal part, add .000
-				return val + ".000"
-			} else if len(parts[1]) < 3 {
-				// Fewer than 3 decimal places, add zeroes
-				return val + strings.Repeat("0", 3-len(parts[1]))
-			}
-			return val
-		}
-
 		converted[i] = messaging.Trade{
 			OrderID:     trade.OrderID,
 			Role:        role,
-			Price:       formatDecimal(trade.Price),
-			Quantity:    formatDecimal(trade.Quantity),
+			Price:       format(trade.Price, pricePrecision),
+			Quantity:    format(trade.Quantity, qtyPrecision),
 			IsQuote:     trade.IsQuote,
 			UserAddress: trade.UserAddress,
+			MakerFee:    formatFee(trade.MakerFee, pricePrecision),
+			TakerFee:    formatFee(trade.TakerFee, pricePrecision),
 		}
 	}
 	return converted
 }
 
+// publishMatch sends the result of matching an order to Kafka and, if the
+// order traded, records its fills in the trade history and passes it to the
+// trade handler. Every order that matches, the
+// caller's or a stop order activated on the way, publishes its fills through
+// it once, including fills kept by an order that ran out of time; the Dones
+// reporting a stop's activation repeat fills already published this way and
+// use sendToKafka.
+func (ob *OrderBook) publishMatch(ctx context.Context, done *Done) {
+	ob.sendToKafka(ctx, done)
+	if !done.Processed.GreaterThan(fpdecimal.Zero) {
+		return
+	}
+	if ob.tradeHistory != nil {
+		ob.tradeHistory.recordFills(done)
+	}
+	if ob.tradeHandler != nil {
+		ob.tradeHandler(ctx, done)
+	}
+}
+
 // sendToKafka sends the order execution result to Kafka.
-func sendToKafka(ctx context.Context, done *Done) {
-	if done == nil {
+func (ob *OrderBook) sendToKafka(ctx context.Context, done *Done) {
+	if done == nil || ob.detached {
 		return
 	}
 
@@ -1045,24 +2039,105 @@ func sendToKafka(ctx context.Context, done *Done) {
 	)
 	defer span.End()
 
+	logger := zlog.Ctx(ctx)
+
+	// Matching is over once its result is sent. Only sent Dones are
+	// numbered, so consumers can tell a missing message from one that was
+	// never published.
+	done.completeMatch()
+	if done.seq == 0 {
+		done.seq = ob.seq.Add(1)
+	}
+
 	// Convert to message format
 	msg := done.ToMessagingDoneMessage()
 	if msg == nil {
+		logger.Error().Str("order_id", done.Order.ID()).Msg("Failed to convert done message")
 		if span != nil {
 			span.SetStatus(codes.Error, "failed to convert order to message format")
 		}
 		return
 	}
+	msg.RequestID = logging.RequestIDFromContext(ctx)
+	msg.OrderBookName = ob.name
+
+	logger.Debug().Str("order_id", msg.OrderID).Msg("Sending done message")
 
 	// Send to queue
-	if err := queue.SendMessage(ctx, msg); err != nil {
+	var err error
+	if sender := factorySender(); sender != nil {
+		err = sender.SendDoneMessage(ctx, msg)
+	} else {
+		err = queue.SendMessage(ctx, msg)
+	}
+	if err != nil {
+		logger.Error().Err(err).Str("order_id", msg.OrderID).Msg("Failed to send done message")
 		if span != nil {
 			span.SetStatus(codes.Error, fmt.Sprintf("failed to send order message: %v", err))
 		}
 		return
 	}
 
+	logger.Debug().Str("order_id", msg.OrderID).Msg("Done message sent")
 	if span != nil {
 		span.SetStatus(codes.Ok, "order message sent successfully")
 	}
 }
+
+// sendCancelToKafka sends a cancellation message for order to Kafka.
+func (ob *OrderBook) sendCancelToKafka(ctx context.Context, order *Order, reason messaging.CancelReason) {
+	if ob.detached {
+		return
+	}
+
+	ctx, span := otel.StartOrderSpan(ctx, otel.SpanSendToKafka,
+		attribute.String(otel.AttributeOrderID, order.ID()),
+		attribute.String(otel.AttributeCancelReason, string(reason)),
+	)
+	defer span.End()
+
+	msg := &messaging.CancelMessage{
+		OrderID:       order.ID(),
+		CanceledAt:    time.Now(),
+		CancelReason:  reason,
+		RemainingQty:  format(order.RemainingQty(), ob.instrument.QtyPrecision),
+		UserAddress:   order.UserAddress(),
+		OrderBookName: ob.name,
+	}
+
+	var err error
+	if sender := factorySender(); sender != nil {
+		err = sender.SendCancelMessage(ctx, msg)
+	} else {
+		err = queue.SendCancelMessage(ctx, msg)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("failed to send cancel message: %v", err))
+		return
+	}
+
+	span.SetStatus(codes.Ok, "cancel message sent successfully")
+}
+
+var (
+	senderFactoryMu sync.RWMutex
+	senderFactory   func() messaging.MessageSender
+)
+
+// SetMessageSenderFactory overrides where order books send their messages.
+// Passing nil restores the default Kafka sender pool.
+func SetMessageSenderFactory(factory func() messaging.MessageSender) {
+	senderFactoryMu.Lock()
+	defer senderFactoryMu.Unlock()
+	senderFactory = factory
+}
+
+// factorySender returns a sender from the configured factory, or nil to use the sender pool
+func factorySender() messaging.MessageSender {
+	senderFactoryMu.RLock()
+	defer senderFactoryMu.RUnlock()
+	if senderFactory == nil {
+		return nil
+	}
+	return senderFactory()
+}
@@ -1,13 +1,26 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
 	"sort"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/erain9/matchingo/pkg/otel"
 	"github.com/nikolaydubina/fpdecimal"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"pgregory.net/rapid"
 )
 
 // mockBackend implements the OrderBookBackend interface for testing with enhanced functionality
@@ -16,6 +29,7 @@ type mockBackend struct {
 	sellSide mockOrderSide
 	buySide  mockOrderSide
 	stopBook mockStopBook
+	midpoint map[Side][]*Order
 }
 
 func newMockBackend() *mockBackend {
@@ -27,6 +41,7 @@ func newMockBackend() *mockBackend {
 			buy:  mockOrderSide{orders: make(map[string]fpdecimalOrders)},
 			sell: mockOrderSide{orders: make(map[string]fpdecimalOrders)},
 		},
+		midpoint: make(map[Side][]*Order),
 	}
 }
 
@@ -78,6 +93,25 @@ func (m *mockBackend) RemoveFromStopBook(order *Order) bool {
 	return m.stopBook.sell.removeOrder(order)
 }
 
+func (m *mockBackend) AppendToMidpoint(order *Order) {
+	m.midpoint[order.Side()] = append(m.midpoint[order.Side()], order)
+}
+
+func (m *mockBackend) RemoveFromMidpoint(order *Order) bool {
+	queue := m.midpoint[order.Side()]
+	for i, queued := range queue {
+		if queued.ID() == order.ID() {
+			m.midpoint[order.Side()] = append(queue[:i:i], queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockBackend) GetMidpointOrders(side Side) []*Order {
+	return append([]*Order(nil), m.midpoint[side]...)
+}
+
 func (m *mockBackend) CheckOCO(orderID string) string {
 	return ""
 }
@@ -94,12 +128,32 @@ func (m *mockBackend) GetStopBook() interface{} {
 	return &m.stopBook
 }
 
+func (m *mockBackend) GetAllOrders() []*Order {
+	var orders []*Order
+	for _, side := range []*mockOrderSide{&m.buySide, &m.sellSide, &m.stopBook.buy, &m.stopBook.sell} {
+		for _, level := range side.orders {
+			for _, order := range level {
+				orders = append(orders, order.Clone())
+			}
+		}
+	}
+	for _, side := range []Side{Buy, Sell} {
+		for _, order := range m.midpoint[side] {
+			orders = append(orders, order.Clone())
+		}
+	}
+	return orders
+}
+
 // fpdecimalOrders is a map from order ID to Order
 type fpdecimalOrders map[string]*Order
 
 // mockOrderSide is a mock implementation of the OrderSide interface for testing
 type mockOrderSide struct {
 	orders map[string]fpdecimalOrders
+	// arrival numbers the appended orders so each level keeps time priority
+	arrival map[string]uint64
+	next    uint64
 }
 
 func (m *mockOrderSide) appendOrder(order *Order) {
@@ -113,6 +167,11 @@ func (m *mockOrderSide) appendOrder(order *Order) {
 		m.orders[priceStr] = make(fpdecimalOrders)
 	}
 	m.orders[priceStr][order.ID()] = order
+	if m.arrival == nil {
+		m.arrival = make(map[string]uint64)
+	}
+	m.next++
+	m.arrival[order.ID()] = m.next
 }
 
 func (m *mockOrderSide) removeOrder(order *Order) bool {
@@ -179,6 +238,10 @@ func (m *mockOrderSide) Orders(price fpdecimal.Decimal) []*Order {
 	for _, order := range m.orders[priceStr] {
 		orders = append(orders, order)
 	}
+	// Oldest first, like the real backends
+	sort.Slice(orders, func(i, j int) bool {
+		return m.arrival[orders[i].ID()] < m.arrival[orders[j].ID()]
+	})
 
 	return orders
 }
@@ -630,6 +693,259 @@ func BenchmarkOrderMatching(b *testing.B) {
 	}
 }
 
+func TestOrderBook_CalculateMarketPrice_Concurrent(t *testing.T) {
+	const (
+		workers         = 10
+		ordersPerWorker = 50
+	)
+
+	setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(2)
+
+		// Writers rest asks and cross some of them, changing resting quantities
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < ordersPerWorker; i++ {
+				side := Sell
+				if i%3 == 2 {
+					side = Buy
+				}
+				price := fpdecimal.FromInt(100 + i%5)
+				order, err := NewLimitOrder(fmt.Sprintf("order-%d-%d", w, i), side, fpdecimal.FromFloat(1.5), price, GTC, "", "test_user")
+				if !assert.NoError(t, err) {
+					return
+				}
+				_, err = book.Process(context.Background(), order)
+				assert.NoError(t, err)
+			}
+		}(w)
+
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ordersPerWorker; i++ {
+				price, err := book.CalculateMarketPrice(Buy, fpdecimal.FromInt(3))
+				if err != nil {
+					assert.ErrorIs(t, err, ErrInsufficientQuantity)
+					continue
+				}
+				assert.True(t, price.GreaterThan(fpdecimal.Zero))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOrderBook_MaxPriceDeviation(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+	book.SetInstrumentConfig(InstrumentConfig{MaxPriceDeviationPct: 10})
+
+	process := func(order *Order, err error) error {
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		return err
+	}
+	restAsk := func(id string, quantity, price int64) {
+		require.NoError(t, process(NewLimitOrder(id, Sell, fpdecimal.FromInt(quantity), fpdecimal.FromInt(price), GTC, "", "test_user")))
+	}
+
+	// The first trade has nothing to deviate from
+	restAsk("ask-100", 1, 100)
+	require.NoError(t, process(NewMarketOrder("buy-1", Buy, fpdecimal.FromInt(1), "test_user")))
+	assert.Equal(t, fpdecimal.FromInt(100), book.Snapshot().LastTradePrice)
+
+	// A fill at 150 is 50% away from 100
+	restAsk("ask-150", 2, 150)
+	err := process(NewMarketOrder("buy-2", Buy, fpdecimal.FromInt(1), "test_user"))
+	assert.ErrorIs(t, err, ErrPriceDeviationExceeded)
+	assert.Equal(t, fpdecimal.FromInt(2), book.GetOrder("ask-150").Quantity())
+	assert.Nil(t, book.GetOrder("buy-2"))
+	assert.Equal(t, fpdecimal.FromInt(100), book.Snapshot().LastTradePrice)
+
+	// A fill at 105 is within 10%
+	restAsk("ask-105", 1, 105)
+	require.NoError(t, process(NewMarketOrder("buy-3", Buy, fpdecimal.FromInt(1), "test_user")))
+	assert.Nil(t, book.GetOrder("ask-105"))
+	assert.Equal(t, fpdecimal.FromInt(105), book.Snapshot().LastTradePrice)
+
+	// Each fill is checked against the one before it: 110 passes, 150 does
+	// not, and the fill at 110 is not applied either
+	restAsk("ask-110", 1, 110)
+	err = process(NewLimitOrder("buy-4", Buy, fpdecimal.FromInt(3), fpdecimal.FromInt(150), GTC, "", "test_user"))
+	assert.ErrorIs(t, err, ErrPriceDeviationExceeded)
+	assert.Equal(t, fpdecimal.FromInt(1), book.GetOrder("ask-110").Quantity())
+	assert.Equal(t, fpdecimal.FromInt(2), book.GetOrder("ask-150").Quantity())
+	assert.Nil(t, book.GetOrder("buy-4"))
+	assert.Equal(t, fpdecimal.FromInt(105), book.Snapshot().LastTradePrice)
+
+	// A quote budget is checked in base quantity: 120 buys one lot at 100,
+	// and the 20 left cannot reach the level at 150
+	book = NewOrderBook(newMockBackend(), WithLotSize(fpdecimal.FromInt(1)))
+	book.SetInstrumentConfig(InstrumentConfig{MaxPriceDeviationPct: 10})
+	restAsk("ask-100-2", 1, 100)
+	require.NoError(t, process(NewMarketOrder("buy-5", Buy, fpdecimal.FromInt(1), "test_user")))
+	restAsk("ask-100-3", 1, 100)
+	restAsk("ask-150-2", 1, 150)
+	order, err := NewMarketQuoteOrder("buy-quote", Buy, fpdecimal.FromInt(120), "test_user")
+	require.NoError(t, err)
+	done, err := book.Process(ctx, order)
+	require.NoError(t, err)
+	assert.Equal(t, "1.000", done.Processed.String())
+	assert.Equal(t, fpdecimal.FromInt(1), book.GetOrder("ask-150-2").Quantity())
+}
+
+func TestOrderBook_PostOnly(t *testing.T) {
+	tests := map[string]struct {
+		bestBid  int64
+		postOnly bool
+		wantErr  error
+		filled   bool
+	}{
+		"PostOnlyWouldTake": {bestBid: 100, postOnly: true, wantErr: ErrPostOnlyWouldTake},
+		"PostOnlyRests":     {bestBid: 99, postOnly: true},
+		"RegularFills":      {bestBid: 100, filled: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			setupMockSender(t)
+			ctx := context.Background()
+			book := NewOrderBook(newMockBackend())
+
+			bid, err := NewLimitOrder("bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(tt.bestBid), GTC, "", "test_user")
+			require.NoError(t, err)
+			_, err = book.Process(ctx, bid)
+			require.NoError(t, err)
+
+			var sell *Order
+			if tt.postOnly {
+				sell, err = NewPostOnlyLimitOrder("sell", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), "", "test_user")
+			} else {
+				sell, err = NewLimitOrder("sell", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "test_user")
+			}
+			require.NoError(t, err)
+
+			done, err := book.Process(ctx, sell)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, book.GetOrder("sell"))
+				assert.NotNil(t, book.GetOrder("bid"), "the resting bid must be untouched")
+				return
+			}
+			require.NoError(t, err)
+			if tt.filled {
+				assert.Equal(t, fpdecimal.FromInt(1), done.Processed)
+				assert.Nil(t, book.GetOrder("bid"))
+				return
+			}
+			assert.True(t, done.Stored)
+			assert.True(t, book.GetOrder("sell").IsPostOnly())
+			assert.NotNil(t, book.GetOrder("bid"))
+		})
+	}
+}
+
+func TestOrderBook_MatchingTimeout(t *testing.T) {
+	const makers = 100000
+
+	tests := map[string]func() (*Order, error){
+		"Market": func() (*Order, error) {
+			return NewMarketOrder("buy", Buy, fpdecimal.FromInt(makers), "test_user")
+		},
+		"Limit": func() (*Order, error) {
+			return NewLimitOrder("buy", Buy, fpdecimal.FromInt(makers), fpdecimal.FromInt(100), GTC, "", "test_user")
+		},
+	}
+
+	for name, newTaker := range tests {
+		t.Run(name, func(t *testing.T) {
+			setupMockSender(t)
+			backend := newMockBackend()
+			var traded []*Done
+			book := NewOrderBook(backend, WithTradeHandler(func(ctx context.Context, done *Done) {
+				traded = append(traded, done)
+			}))
+			for i := 0; i < makers; i++ {
+				ask, err := NewLimitOrder(fmt.Sprintf("ask-%d", i), Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "test_user")
+				require.NoError(t, err)
+				require.NoError(t, ask.Transition(StateOpen))
+				require.NoError(t, backend.StoreOrder(ask))
+				backend.AppendToSide(Sell, ask)
+			}
+
+			taker, err := newTaker()
+			require.NoError(t, err)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+
+			done, err := book.Process(ctx, taker)
+			assert.ErrorIs(t, err, ErrMatchingTimeout)
+			require.NotNil(t, done)
+
+			// What was filled stands; the rest is canceled rather than resting
+			assert.True(t, done.Processed.GreaterThan(fpdecimal.Zero))
+			assert.True(t, done.Processed.LessThan(fpdecimal.FromInt(makers)))
+			assert.Equal(t, fpdecimal.FromInt(makers).Sub(done.Processed), done.Left)
+			assert.False(t, done.Stored)
+			assert.True(t, taker.IsCanceled())
+			assert.Nil(t, book.GetOrder("buy"))
+			assert.Equal(t, fpdecimal.FromInt(100), book.Snapshot().LastTradePrice)
+			// The fills made before the timeout reach the trade handler
+			require.Len(t, traded, 1)
+			assert.Same(t, done, traded[0])
+		})
+	}
+}
+
+func TestOrderBook_SpanEvents(t *testing.T) {
+	setupMockSender(t)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	require.NoError(t, otel.InitForTesting(tp.Tracer("test")))
+	t.Cleanup(otel.ResetForTesting)
+
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+	ask, err := NewLimitOrder("ask-1", Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(100), GTC, "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, ask)
+	require.NoError(t, err)
+
+	exporter.Reset()
+	bid, err := NewLimitOrder("bid-1", Buy, fpdecimal.FromInt(3), fpdecimal.FromInt(100), IOC, "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, bid)
+	require.NoError(t, err)
+
+	events := make(map[string]map[attribute.Key]string)
+	for _, span := range exporter.GetSpans() {
+		if span.Name != otel.SpanMatchOrder {
+			continue
+		}
+		for _, event := range span.Events {
+			attrs := make(map[attribute.Key]string)
+			for _, attr := range event.Attributes {
+				attrs[attr.Key] = attr.Value.AsString()
+			}
+			events[event.Name] = attrs
+		}
+	}
+
+	require.Contains(t, events, otel.EventFill)
+	assert.Equal(t, map[attribute.Key]string{
+		otel.AttributeMakerOrderID:  "ask-1",
+		otel.AttributeMatchQuantity: fpdecimal.FromInt(2).String(),
+		otel.AttributeMatchPrice:    fpdecimal.FromInt(100).String(),
+	}, events[otel.EventFill])
+	require.Contains(t, events, otel.EventIOCCanceled)
+	assert.Equal(t, fpdecimal.FromInt(1).String(), events[otel.EventIOCCanceled][otel.AttributeRemainingQuantity])
+}
+
 func TestStopOrder(t *testing.T) {
 	backend := newMockBackend()
 	book := NewOrderBook(backend)
@@ -684,6 +1000,56 @@ func TestStopOrder(t *testing.T) {
 	ordersAfterTrigger := stopBook.Orders(stopPrice)
 	t.Logf("Orders at stop price after trigger: %d", len(ordersAfterTrigger))
 	assert.Equal(t, 0, len(ordersAfterTrigger), "Stop order should be removed after triggering")
+
+	// The activated stop rests on the book as a limit order
+	activated := backend.buySide.Orders(limitPrice)
+	require.Len(t, activated, 1)
+	assert.Equal(t, "stop-buy", activated[0].ID())
+	assert.False(t, backend.GetOrder("stop-buy").IsStopOrder())
+}
+
+func TestStopOrderTriggeredOnArrival(t *testing.T) {
+	sender := setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+
+	process := func(order *Order, err error) *Done {
+		t.Helper()
+		require.NoError(t, err)
+		done, err := book.Process(ctx, order)
+		require.NoError(t, err)
+		return done
+	}
+
+	// Trade at 105, then leave an ask at 106 for the stop to take
+	process(NewLimitOrder("ask-105", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(105), GTC, "", "test_user"))
+	process(NewLimitOrder("bid-105", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(105), GTC, "", "test_user"))
+	process(NewLimitOrder("ask-106", Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(106), GTC, "", "test_user"))
+	sender.ClearSentMessages()
+
+	// The last trade is already above the stop price
+	done := process(NewStopLimitOrder("stop-buy", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(106), fpdecimal.FromInt(104), "", "test_user"))
+	assert.True(t, done.IsActivated())
+	assert.True(t, done.StopActivated)
+	assert.Equal(t, "1.000", done.Processed.String())
+
+	require.NotNil(t, done.GetTradeOrder("ask-106"), "the activated order is filled")
+	assert.Equal(t, "1.000", done.GetTradeOrder("ask-106").Quantity.String())
+	sentinel := done.Trades[len(done.Trades)-1]
+	assert.Equal(t, "stop-buy", sentinel.OrderID)
+	assert.Equal(t, TAKER, sentinel.Role)
+	assert.True(t, sentinel.Quantity.Equal(fpdecimal.Zero))
+	assert.Equal(t, "104.000", sentinel.Price.String())
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	// The fills of the activated order are reported before the activation
+	msgs, err := sender.WaitForMessages(waitCtx, 2)
+	require.NoError(t, err)
+	last := msgs[len(msgs)-1]
+	assert.Equal(t, "stop-buy", last.OrderID)
+	assert.True(t, last.StopActivated)
+	assert.Equal(t, []string{"stop-buy"}, last.Activated)
 }
 
 func TestIOCOrder(t *testing.T) {
@@ -1288,6 +1654,121 @@ func TestFOKLimitOrderCancelled(t *testing.T) {
 	assert.True(t, unaffectedSellOrder.Quantity().Equal(fpdecimal.FromFloat(3.0)), "Sell order quantity should be unaffected")
 }
 
+func TestFOKMarketOrder(t *testing.T) {
+	backend := newMockBackend()
+	book := NewOrderBook(backend)
+
+	for i, price := range []float64{10, 11} {
+		sell, err := NewLimitOrder(fmt.Sprintf("sell-%d", i+1), Sell, fpdecimal.FromFloat(3.0), fpdecimal.FromFloat(price), GTC, "", "test_user")
+		require.NoError(t, err)
+		_, err = book.Process(context.Background(), sell)
+		require.NoError(t, err)
+	}
+
+	// Six are offered across both levels, so seven cannot be filled
+	killed, err := NewFOKMarketOrder("buy-fok-kill", Buy, fpdecimal.FromFloat(7.0), "test_user")
+	require.NoError(t, err)
+	done, err := book.Process(context.Background(), killed)
+	require.NoError(t, err)
+	assert.True(t, done.Processed.Equal(fpdecimal.Zero), "Expected nothing processed, got %s", done.Processed)
+	assert.True(t, done.Left.Equal(fpdecimal.FromFloat(7.0)), "Expected the whole order left, got %s", done.Left)
+	assert.False(t, done.Stored)
+	assert.Nil(t, backend.GetOrder("buy-fok-kill"))
+	for _, id := range []string{"sell-1", "sell-2"} {
+		maker := backend.GetOrder(id)
+		require.NotNil(t, maker)
+		assert.True(t, maker.Quantity().Equal(fpdecimal.FromFloat(3.0)), "%s should be untouched", id)
+	}
+
+	filled, err := NewFOKMarketOrder("buy-fok-fill", Buy, fpdecimal.FromFloat(5.0), "test_user")
+	require.NoError(t, err)
+	done, err = book.Process(context.Background(), filled)
+	require.NoError(t, err)
+	assert.True(t, done.Processed.Equal(fpdecimal.FromFloat(5.0)), "Expected 5 processed, got %s", done.Processed)
+	assert.True(t, done.Left.Equal(fpdecimal.Zero), "Expected nothing left, got %s", done.Left)
+	assert.Nil(t, backend.GetOrder("sell-1"))
+	remaining := backend.GetOrder("sell-2")
+	require.NotNil(t, remaining)
+	assert.True(t, remaining.Quantity().Equal(fpdecimal.FromFloat(1.0)))
+}
+
+func TestMarketQuoteOrder(t *testing.T) {
+	newBook := func(opts ...OrderBookOption) (*mockBackend, *OrderBook) {
+		backend := newMockBackend()
+		book := NewOrderBook(backend, opts...)
+		for i, price := range []float64{10, 11} {
+			sell, err := NewLimitOrder(fmt.Sprintf("sell-%d", i+1), Sell, fpdecimal.FromFloat(3.0), fpdecimal.FromFloat(price), GTC, "", "maker")
+			require.NoError(t, err)
+			_, err = book.Process(context.Background(), sell)
+			require.NoError(t, err)
+		}
+		return backend, book
+	}
+
+	// 45 buys all 3 at 10 for 30, then as much as the other 15 buys at 11
+	backend, book := newBook()
+	order, err := NewMarketQuoteOrder("buy-quote", Buy, fpdecimal.FromFloat(45.0), "taker")
+	require.NoError(t, err)
+	done, err := book.Process(context.Background(), order)
+	require.NoError(t, err)
+	assert.Equal(t, "4.363", done.Processed.String())
+	assert.Equal(t, "0.007", done.Left.String(), "15 - 1.363 * 11 is left unspent")
+	assert.False(t, done.Stored)
+	require.Len(t, done.Trades, 3)
+	assert.Equal(t, "4.363", done.Trades[0].Quantity.String())
+	assert.Equal(t, "1.363", done.Trades[2].Quantity.String())
+	assert.Nil(t, backend.GetOrder("sell-1"))
+	assert.Equal(t, "1.637", backend.GetOrder("sell-2").Quantity().String())
+
+	// Fills are rounded down to the lot size, and the budget is not checked against it
+	_, book = newBook(WithLotSize(fpdecimal.FromFloat(0.1)))
+	order, err = NewMarketQuoteOrder("buy-quote", Buy, fpdecimal.FromFloat(45.05), "taker")
+	require.NoError(t, err)
+	done, err = book.Process(context.Background(), order)
+	require.NoError(t, err)
+	assert.Equal(t, "4.300", done.Processed.String())
+	assert.Equal(t, "0.750", done.Left.String())
+
+	// A FOK quote order needs the whole budget to be spendable
+	_, book = newBook()
+	order, err = NewFOKMarketQuoteOrder("buy-fok", Buy, fpdecimal.FromFloat(64.0), "taker")
+	require.NoError(t, err)
+	done, err = book.Process(context.Background(), order)
+	require.NoError(t, err)
+	assert.True(t, done.Processed.Equal(fpdecimal.Zero), "63 is offered, got %s processed", done.Processed)
+}
+
+func TestSetEventChan(t *testing.T) {
+	setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+	events := make(chan *Done, 4)
+	book.SetEventChan(events)
+
+	order, err := NewLimitOrder("sell-1", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "")
+	require.NoError(t, err)
+	done, err := book.Process(context.Background(), order)
+	require.NoError(t, err)
+	assert.Same(t, done, <-events)
+
+	require.NotNil(t, book.CancelOrder("sell-1"))
+	canceled := <-events
+	assert.Equal(t, uint64(0), canceled.Seq())
+	assert.False(t, canceled.Stored)
+	require.Len(t, canceled.Canceled, 1)
+	assert.Equal(t, "sell-1", canceled.Canceled[0].ID())
+
+	// A full channel drops events instead of blocking the book
+	book.SetEventChan(make(chan *Done))
+	order, err = NewLimitOrder("sell-2", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "")
+	require.NoError(t, err)
+	_, err = book.Process(context.Background(), order)
+	require.NoError(t, err)
+
+	book.SetEventChan(nil)
+	require.NotNil(t, book.CancelOrder("sell-2"))
+	assert.Empty(t, events)
+}
+
 // TestMarketOrderIOC verifies that market orders behave correctly when partially filled
 func TestMarketOrderIOC(t *testing.T) {
 	backend := newMockBackend()
@@ -1312,3 +1793,1039 @@ func TestMarketOrderIOC(t *testing.T) {
 	assert.True(t, len(done.Canceled) > 0, "Should mark the order as canceled")
 	assert.False(t, done.Stored, "Market orders should not be stored")
 }
+
+// TestConcurrentOrderProcessing submits crossing limit orders from many goroutines
+// against one book. Run with -race to catch unsynchronized access.
+func TestConcurrentOrderProcessing(t *testing.T) {
+	const (
+		workers         = 50
+		ordersPerWorker = 100
+	)
+
+	backend := newMockBackend()
+	book := NewOrderBook(backend)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		matched = fpdecimal.Zero
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < ordersPerWorker; i++ {
+				side := Buy
+				if i%2 == 1 {
+					side = Sell
+				}
+				price := fpdecimal.FromInt(int64(95 + (w+i)%10))
+				order, err := NewLimitOrder(fmt.Sprintf("order-%d-%d", w, i), side, fpdecimal.FromInt(1), price, GTC, "", "test_user")
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				done, err := book.Process(context.Background(), order)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				// Each fill consumes quantity from both the taker and the maker
+				mu.Lock()
+				matched = matched.Add(done.Processed).Add(done.Processed)
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	bids := backend.buySide.Prices()
+	asks := backend.sellSide.Prices()
+
+	// (1) the book is not crossed
+	if len(bids) > 0 && len(asks) > 0 {
+		bestBid := bids[0]
+		for _, p := range bids {
+			if p.GreaterThan(bestBid) {
+				bestBid = p
+			}
+		}
+		bestAsk := asks[0]
+		for _, p := range asks {
+			bestAsk = min(bestAsk, p)
+		}
+		assert.True(t, bestBid.LessThan(bestAsk), "book is crossed: best bid %s >= best ask %s", bestBid, bestAsk)
+	}
+
+	// (2) matched quantity accounts for everything that is not resting
+	resting := fpdecimal.Zero
+	for _, side := range []*mockOrderSide{&backend.buySide, &backend.sellSide} {
+		for _, orders := range side.orders {
+			for _, order := range orders {
+				resting = resting.Add(order.Quantity())
+			}
+		}
+	}
+	submitted := fpdecimal.FromInt(workers * ordersPerWorker)
+	assert.Equal(t, submitted.Sub(resting).String(), matched.String())
+
+	// (3) no order ID rests in the book more than once
+	seen := make(map[string]bool)
+	for _, side := range []*mockOrderSide{&backend.buySide, &backend.sellSide} {
+		for _, orders := range side.orders {
+			for id := range orders {
+				assert.False(t, seen[id], "order %s is resting more than once", id)
+				seen[id] = true
+				assert.NotNil(t, backend.GetOrder(id), "resting order %s missing from backend", id)
+			}
+		}
+	}
+}
+
+// TestConcurrentCancelAndProcess races CancelOrder against Process on the same book
+func TestConcurrentCancelAndProcess(t *testing.T) {
+	const (
+		workers         = 20
+		ordersPerWorker = 100
+	)
+
+	backend := newMockBackend()
+	book := NewOrderBook(backend)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+
+			if w%2 == 0 {
+				// Cancel randomly selected IDs, some of which were never submitted
+				for i := 0; i < ordersPerWorker; i++ {
+					id := fmt.Sprintf("order-%d-%d", rng.Intn(workers), rng.Intn(ordersPerWorker))
+					book.CancelOrder(id)
+				}
+				return
+			}
+
+			for i := 0; i < ordersPerWorker; i++ {
+				side := Buy
+				if i%2 == 1 {
+					side = Sell
+				}
+				price := fpdecimal.FromInt(int64(95 + rng.Intn(10)))
+				order, err := NewLimitOrder(fmt.Sprintf("order-%d-%d", w, i), side, fpdecimal.FromInt(1), price, GTC, "", "test_user")
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				_, err = book.Process(context.Background(), order)
+				assert.NotErrorIs(t, err, ErrOrderExists, "order %s was never submitted before", order.ID())
+				assert.NoError(t, err)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// cancelMessages returns the cancellations captured by sender in order
+func cancelMessages(sender *messaging.MockMessageSender) []*messaging.CancelMessage {
+	var cancels []*messaging.CancelMessage
+	for _, msg := range sender.GetSentMessages() {
+		if msg.Cancel != nil {
+			cancels = append(cancels, msg.Cancel)
+		}
+	}
+	return cancels
+}
+
+func setupMockSender(t *testing.T) *messaging.MockMessageSender {
+	sender := messaging.NewMockMessageSender()
+	SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	t.Cleanup(func() { SetMessageSenderFactory(nil) })
+	return sender
+}
+
+func TestCancelMessageReasons(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("UserRequested", func(t *testing.T) {
+		sender := setupMockSender(t)
+		book := NewOrderBook(newMockBackend())
+
+		order, err := NewLimitOrder("buy-1", Buy, fpdecimal.FromInt(5), fpdecimal.FromInt(99), GTC, "", "test_user")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+		sender.ClearSentMessages()
+
+		require.NotNil(t, book.CancelOrder("buy-1"))
+
+		msgs := sender.GetSentMessages()
+		require.Len(t, msgs, 1)
+		require.NotNil(t, msgs[0].Cancel)
+		assert.Equal(t, "buy-1", msgs[0].Cancel.OrderID)
+		assert.Equal(t, messaging.CancelReasonUserRequested, msgs[0].Cancel.CancelReason)
+		assert.Equal(t, fpdecimal.FromInt(5).String(), msgs[0].Cancel.RemainingQty)
+		assert.Equal(t, "test_user", msgs[0].Cancel.UserAddress)
+		assert.False(t, msgs[0].Cancel.CanceledAt.IsZero())
+
+		// Canceling an unknown order sends nothing
+		sender.ClearSentMessages()
+		assert.Nil(t, book.CancelOrder("missing"))
+		assert.Empty(t, sender.GetSentMessages())
+	})
+
+	t.Run("OCOTriggered", func(t *testing.T) {
+		sender := setupMockSender(t)
+		backend := newMockBackend()
+		book := NewOrderBook(backend)
+
+		legA, err := NewLimitOrder("sell-a", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "sell-b", "test_user")
+		require.NoError(t, err)
+		legB, err := NewLimitOrder("sell-b", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(110), GTC, "sell-a", "test_user")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, legA)
+		require.NoError(t, err)
+		_, err = book.Process(ctx, legB)
+		require.NoError(t, err)
+
+		// Filling leg A cancels leg B
+		buy, err := NewMarketOrder("buy-1", Buy, fpdecimal.FromInt(1), "test_user")
+		require.NoError(t, err)
+		done, err := book.Process(ctx, buy)
+		require.NoError(t, err)
+
+		assert.Nil(t, backend.GetOrder("sell-b"))
+		require.NotEmpty(t, done.Canceled)
+		assert.True(t, done.CancelledByOCO)
+		cancels := cancelMessages(sender)
+		require.Len(t, cancels, 1)
+		assert.Equal(t, "sell-b", cancels[0].OrderID)
+		assert.Equal(t, messaging.CancelReasonOCOTriggered, cancels[0].CancelReason)
+	})
+
+	t.Run("StopActivated", func(t *testing.T) {
+		sender := setupMockSender(t)
+		backend := newMockBackend()
+		book := NewOrderBook(backend)
+
+		stop, err := NewStopLimitOrder("stop-sell", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(94), fpdecimal.FromInt(95), "take-profit", "test_user")
+		require.NoError(t, err)
+		takeProfit, err := NewLimitOrder("take-profit", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(110), GTC, "stop-sell", "test_user")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, stop)
+		require.NoError(t, err)
+		_, err = book.Process(ctx, takeProfit)
+		require.NoError(t, err)
+
+		// A trade at 95 activates the stop, which cancels the take-profit leg
+		bid, err := NewLimitOrder("bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(95), GTC, "", "other_user")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, bid)
+		require.NoError(t, err)
+		ask, err := NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(95), GTC, "", "other_user")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, ask)
+		require.NoError(t, err)
+
+		assert.Nil(t, backend.GetOrder("take-profit"))
+		cancels := cancelMessages(sender)
+		require.Len(t, cancels, 1)
+		assert.Equal(t, "take-profit", cancels[0].OrderID)
+		assert.Equal(t, messaging.CancelReasonStopActivated, cancels[0].CancelReason)
+	})
+
+	for _, reason := range []messaging.CancelReason{messaging.CancelReasonExpired, messaging.CancelReasonSTP} {
+		t.Run(string(reason), func(t *testing.T) {
+			sender := setupMockSender(t)
+			book := NewOrderBook(newMockBackend())
+
+			order, err := NewLimitOrder("sell-1", Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(101), GTC, "", "test_user")
+			require.NoError(t, err)
+			_, err = book.Process(ctx, order)
+			require.NoError(t, err)
+			sender.ClearSentMessages()
+
+			require.NotNil(t, book.CancelOrderWithReason(ctx, "sell-1", reason))
+
+			cancels := cancelMessages(sender)
+			require.Len(t, cancels, 1)
+			assert.Equal(t, "sell-1", cancels[0].OrderID)
+			assert.Equal(t, reason, cancels[0].CancelReason)
+		})
+	}
+}
+
+func TestSweepExpiredOrders(t *testing.T) {
+	ctx := context.Background()
+	sender := setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+
+	for _, o := range []struct {
+		id    string
+		side  Side
+		price int64
+	}{
+		{"bid-1", Buy, 99},
+		{"ask-1", Sell, 101},
+	} {
+		order, err := NewLimitOrder(o.id, o.side, fpdecimal.FromInt(1), fpdecimal.FromInt(o.price), GTC, "", "test_user")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+	sender.ClearSentMessages()
+
+	// Nothing is old enough yet
+	assert.Empty(t, book.SweepExpiredOrders(ctx, time.Minute, time.Now()))
+	assert.NotNil(t, book.GetOrder("bid-1"))
+
+	swept := book.SweepExpiredOrders(ctx, time.Minute, time.Now().Add(2*time.Minute))
+	assert.Len(t, swept, 2)
+	assert.Nil(t, book.GetOrder("bid-1"))
+	assert.Nil(t, book.GetOrder("ask-1"))
+
+	cancels := cancelMessages(sender)
+	require.Len(t, cancels, 2)
+	for _, c := range cancels {
+		assert.Equal(t, messaging.CancelReasonExpired, c.CancelReason)
+	}
+}
+
+func TestGTDOrders(t *testing.T) {
+	ctx := context.Background()
+	sender := setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+	now := time.Now()
+
+	gtd, err := NewGTDLimitOrder("gtd-bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(99), now.Add(time.Minute), "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, gtd)
+	require.NoError(t, err)
+	gtc, err := NewLimitOrder("gtc-bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(98), GTC, "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, gtc)
+	require.NoError(t, err)
+	sender.ClearSentMessages()
+
+	assert.Empty(t, book.CancelExpiredGTDOrders(ctx, now))
+	expired := book.CancelExpiredGTDOrders(ctx, now.Add(time.Minute))
+	require.Len(t, expired, 1)
+	assert.Equal(t, "gtd-bid", expired[0].ID())
+	assert.Nil(t, book.GetOrder("gtd-bid"))
+	assert.NotNil(t, book.GetOrder("gtc-bid"), "GTC orders do not expire")
+	cancels := cancelMessages(sender)
+	require.Len(t, cancels, 1)
+	assert.Equal(t, messaging.CancelReasonExpired, cancels[0].CancelReason)
+
+	// An order that is already past its expiry trades but does not rest
+	ask, err := NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, ask)
+	require.NoError(t, err)
+	late, err := NewGTDLimitOrder("late-bid", Buy, fpdecimal.FromInt(3), fpdecimal.FromInt(100), now.Add(-time.Second), "", "test_user")
+	require.NoError(t, err)
+	done, err := book.Process(ctx, late)
+	require.NoError(t, err)
+	assert.Equal(t, "1.000", done.Processed.String())
+	assert.False(t, done.Stored)
+	assert.Nil(t, book.GetOrder("late-bid"))
+
+	// The expiry worker cancels orders once they expire
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	soon, err := NewGTDLimitOrder("soon-bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(97), time.Now().Add(20*time.Millisecond), "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, soon)
+	require.NoError(t, err)
+	book.StartExpiryWorker(workerCtx, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return book.GetOrder("soon-bid") == nil }, time.Second, 5*time.Millisecond)
+
+	// The worker stops once no GTD orders are left, and a new one starts it again
+	running := func() bool {
+		book.mu.RLock()
+		defer book.mu.RUnlock()
+		return book.expiryRunning
+	}
+	assert.Eventually(t, func() bool { return !running() }, time.Second, 5*time.Millisecond)
+	again, err := NewGTDLimitOrder("again-bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(97), time.Now().Add(20*time.Millisecond), "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, again)
+	require.NoError(t, err)
+	assert.True(t, running())
+	assert.Eventually(t, func() bool { return book.GetOrder("again-bid") == nil }, time.Second, 5*time.Millisecond)
+}
+
+func TestOrderBookReset(t *testing.T) {
+	ctx := context.Background()
+	sender := setupMockSender(t)
+	backend := newMockBackend()
+	book := NewOrderBook(backend)
+
+	orders := []struct {
+		id    string
+		side  Side
+		price int64
+	}{
+		{"bid-1", Buy, 99},
+		{"bid-2", Buy, 98},
+		{"ask-1", Sell, 101},
+		{"ask-2", Sell, 102},
+	}
+	for _, o := range orders {
+		order, err := NewLimitOrder(o.id, o.side, fpdecimal.FromInt(1), fpdecimal.FromInt(o.price), GTC, "", "test_user")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+	book.lastTradePrice = fpdecimal.FromInt(100)
+	sender.ClearSentMessages()
+
+	require.NoError(t, book.Reset())
+
+	for _, o := range orders {
+		assert.Nil(t, book.GetOrder(o.id))
+	}
+	assert.Empty(t, backend.buySide.Prices())
+	assert.Empty(t, backend.sellSide.Prices())
+	assert.True(t, book.lastTradePrice.Equal(fpdecimal.Zero))
+	assert.Empty(t, sender.GetSentMessages(), "reset must not emit cancel messages")
+}
+
+func TestOrderBookDoneSequence(t *testing.T) {
+	ctx := context.Background()
+	sender := setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+
+	process := func(id string, side Side, price int64, tif TIF) *Done {
+		t.Helper()
+		order, err := NewLimitOrder(id, side, fpdecimal.FromInt(1), fpdecimal.FromInt(price), tif, "", "test_user")
+		require.NoError(t, err)
+		done, err := book.Process(ctx, order)
+		require.NoError(t, err)
+		return done
+	}
+
+	// Rest bids, fill them with asks and send IOC orders that find nothing
+	// to trade with, which are not published and so not numbered
+	var last uint64
+	for i := 0; i < 1000; i++ {
+		var done *Done
+		switch i % 3 {
+		case 0:
+			done = process(fmt.Sprintf("order-%d", i), Buy, 100, GTC)
+		case 1:
+			done = process(fmt.Sprintf("order-%d", i), Sell, 100, GTC)
+		case 2:
+			done = process(fmt.Sprintf("order-%d", i), Buy, 50, IOC)
+			require.Zero(t, done.Seq())
+			continue
+		}
+		require.Equal(t, last+1, done.Seq(), "published Dones are numbered without gaps")
+		last = done.Seq()
+	}
+
+	messages := sender.GetSentMessages()
+	require.Len(t, messages, int(last))
+	for i, msg := range messages {
+		assert.Equal(t, uint64(i+1), msg.SequenceNumber)
+	}
+
+	require.NoError(t, book.Reset())
+	assert.Equal(t, uint64(1), process("after-reset", Buy, 100, GTC).Seq())
+}
+
+func TestOrderBookClose(t *testing.T) {
+	ctx := context.Background()
+	setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+
+	// Hold the book lock so the next Process call stays in flight
+	book.mu.Lock()
+	processed := make(chan error, 1)
+	go func() {
+		order, _ := NewLimitOrder("in-flight", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "test_user")
+		_, err := book.Process(ctx, order)
+		processed <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, book.Close(timeoutCtx), context.DeadlineExceeded)
+
+	// New orders are refused while the in-flight one is still allowed to finish
+	late, err := NewLimitOrder("late", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, late)
+	assert.ErrorIs(t, err, ErrOrderBookClosed)
+
+	book.mu.Unlock()
+	require.NoError(t, <-processed)
+	require.NoError(t, book.Close(ctx))
+	assert.NotNil(t, book.GetOrder("in-flight"))
+	assert.Nil(t, book.GetOrder("late"))
+}
+
+func TestGetDepthAtPrice(t *testing.T) {
+	ctx := context.Background()
+	setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+
+	for i, qty := range []float64{1.5, 2, 0.25} {
+		order, err := NewLimitOrder(fmt.Sprintf("ask-%d", i), Sell, fpdecimal.FromFloat(qty), fpdecimal.FromInt(101), GTC, "", "test_user")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+
+	t.Run("SumsLevel", func(t *testing.T) {
+		qty, count, err := book.GetDepthAtPrice(Sell, fpdecimal.FromInt(101))
+		require.NoError(t, err)
+		assert.Equal(t, "3.750", qty.String())
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("EmptyLevel", func(t *testing.T) {
+		qty, count, err := book.GetDepthAtPrice(Sell, fpdecimal.FromInt(102))
+		require.NoError(t, err)
+		assert.True(t, qty.Equal(fpdecimal.Zero))
+		assert.Zero(t, count)
+
+		// The other side of the book has nothing at the same price
+		qty, count, err = book.GetDepthAtPrice(Buy, fpdecimal.FromInt(101))
+		require.NoError(t, err)
+		assert.True(t, qty.Equal(fpdecimal.Zero))
+		assert.Zero(t, count)
+	})
+}
+
+func TestTotalNotional(t *testing.T) {
+	ctx := context.Background()
+	setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+
+	process := func(order *Order, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+	levels := []struct {
+		side     Side
+		price    int64
+		quantity float64
+	}{
+		{Buy, 100, 2}, {Buy, 99, 1}, {Buy, 98, 3},
+		{Sell, 101, 1.5}, {Sell, 102, 1}, {Sell, 103, 2},
+	}
+	for i, level := range levels {
+		process(NewLimitOrder(fmt.Sprintf("order-%d", i), level.side, fpdecimal.FromFloat(level.quantity), fpdecimal.FromInt(level.price), GTC, "", "test_user"))
+	}
+	// Stop orders are not on the book and carry no notional
+	process(NewStopLimitOrder("stop", Buy, fpdecimal.FromInt(5), fpdecimal.FromInt(110), fpdecimal.FromInt(110), "", "test_user"))
+
+	bidNotional, askNotional := book.TotalNotional()
+	// 100*2 + 99*1 + 98*3 and 101*1.5 + 102*1 + 103*2
+	assert.Equal(t, "593.000", bidNotional.String())
+	assert.Equal(t, "459.500", askNotional.String())
+
+	// 40% of the bids (237.2) clears the 100 level but not the 99 level
+	assert.Equal(t, "1.000", book.MaxDrawdown(Buy, 40).String())
+	// 80% of the asks (367.6) clears the 101 and 102 levels
+	assert.Equal(t, "2.000", book.MaxDrawdown(Sell, 80).String())
+	// Taking the whole side reaches its deepest level
+	assert.Equal(t, "2.000", book.MaxDrawdown(Buy, 100).String())
+	assert.True(t, book.MaxDrawdown(Sell, 0).Equal(fpdecimal.Zero))
+}
+
+func TestBestBidAsk(t *testing.T) {
+	ctx := context.Background()
+	setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+
+	_, _, ok := book.BestBid()
+	assert.False(t, ok)
+	price, qty, ok := book.BestAsk()
+	assert.False(t, ok)
+	assert.True(t, price.Equal(fpdecimal.Zero))
+	assert.True(t, qty.Equal(fpdecimal.Zero))
+
+	// Bids at 95-99 and asks at 101-105, two orders at each level
+	for i := int64(0); i < 5; i++ {
+		for j := 0; j < 2; j++ {
+			bid, err := NewLimitOrder(fmt.Sprintf("bid-%d-%d", i, j), Buy, fpdecimal.FromInt(i+1), fpdecimal.FromInt(95+i), GTC, "", "test_user")
+			require.NoError(t, err)
+			_, err = book.Process(ctx, bid)
+			require.NoError(t, err)
+			ask, err := NewLimitOrder(fmt.Sprintf("ask-%d-%d", i, j), Sell, fpdecimal.FromInt(i+1), fpdecimal.FromInt(105-i), GTC, "", "test_user")
+			require.NoError(t, err)
+			_, err = book.Process(ctx, ask)
+			require.NoError(t, err)
+		}
+	}
+
+	price, qty, ok = book.BestBid()
+	require.True(t, ok)
+	assert.Equal(t, "99.000", price.String())
+	assert.Equal(t, "10.000", qty.String())
+
+	price, qty, ok = book.BestAsk()
+	require.True(t, ok)
+	assert.Equal(t, "101.000", price.String())
+	assert.Equal(t, "10.000", qty.String())
+
+	bbo := book.BBO()
+	assert.Equal(t, BBO{
+		BidPrice: fpdecimal.FromInt(99), BidQty: fpdecimal.FromInt(10),
+		AskPrice: fpdecimal.FromInt(101), AskQty: fpdecimal.FromInt(10),
+		HasBid: true, HasAsk: true,
+	}, bbo)
+	mid, ok := bbo.Midpoint()
+	require.True(t, ok)
+	bookMid, _ := book.Midpoint()
+	assert.Equal(t, bookMid, mid)
+
+	_, ok = NewOrderBook(newMockBackend()).BBO().Midpoint()
+	assert.False(t, ok)
+}
+
+func TestConcurrentProcessAndGetDepthAtPrice(t *testing.T) {
+	const orders = 200
+
+	book := NewOrderBook(newMockBackend())
+	price := fpdecimal.FromInt(100)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < orders; i++ {
+			order, err := NewLimitOrder(fmt.Sprintf("bid-%d", i), Buy, fpdecimal.FromInt(1), price, GTC, "", "test_user")
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = book.Process(context.Background(), order)
+			assert.NoError(t, err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < orders; i++ {
+			qty, count, err := book.GetDepthAtPrice(Buy, price)
+			assert.NoError(t, err)
+			assert.True(t, qty.Equal(fpdecimal.FromInt(int64(count))), "quantity %s does not match %d orders", qty, count)
+		}
+	}()
+	wg.Wait()
+
+	qty, count, err := book.GetDepthAtPrice(Buy, price)
+	require.NoError(t, err)
+	assert.Equal(t, orders, count)
+	assert.True(t, qty.Equal(fpdecimal.FromInt(orders)))
+}
+
+// staleBackend is a mockBackend whose asks hand back resting orders with the
+// quantities in stale, as a backend holding outdated orders would
+type staleBackend struct {
+	*mockBackend
+	stale map[string]fpdecimal.Decimal
+}
+
+func (b *staleBackend) GetAsks() interface{} {
+	return &staleOrderSide{mockOrderSide: &b.sellSide, stale: b.stale}
+}
+
+type staleOrderSide struct {
+	*mockOrderSide
+	stale map[string]fpdecimal.Decimal
+}
+
+func (s *staleOrderSide) Orders(price fpdecimal.Decimal) []*Order {
+	orders := s.mockOrderSide.Orders(price)
+	for _, order := range orders {
+		if qty, ok := s.stale[order.ID()]; ok {
+			order.SetQuantity(qty)
+			delete(s.stale, order.ID())
+		}
+	}
+	return orders
+}
+
+func TestMatchingRemovesEmptyMakers(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name  string
+		taker func() (*Order, error)
+	}{
+		{"limit", func() (*Order, error) {
+			return NewLimitOrder("taker", Buy, fpdecimal.FromFloat(0.004), fpdecimal.FromInt(101), GTC, "", "")
+		}},
+		{"market", func() (*Order, error) {
+			return NewMarketOrder("taker", Buy, fpdecimal.FromFloat(0.004), "")
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := &staleBackend{
+				mockBackend: newMockBackend(),
+				stale: map[string]fpdecimal.Decimal{
+					"empty":    fpdecimal.Zero,
+					"negative": fpdecimal.FromFloat(-0.001),
+					"dust-1":   fpdecimal.FromFloat(0.001),
+					"dust-2":   fpdecimal.FromFloat(0.002),
+				},
+			}
+			book := NewOrderBook(backend)
+
+			for _, maker := range []struct {
+				id    string
+				price int64
+			}{
+				{"empty", 99}, {"negative", 99}, {"dust-1", 100}, {"dust-2", 100}, {"full", 101},
+			} {
+				order, err := NewLimitOrder(maker.id, Sell, fpdecimal.FromInt(5), fpdecimal.FromInt(maker.price), GTC, "", "")
+				require.NoError(t, err)
+				_, err = book.Process(ctx, order)
+				require.NoError(t, err)
+			}
+
+			taker, err := tc.taker()
+			require.NoError(t, err)
+			done, err := book.Process(ctx, taker)
+			require.NoError(t, err)
+			assert.Equal(t, "0.004", done.Processed.String())
+
+			for _, trade := range done.Trades {
+				assert.True(t, trade.Quantity.GreaterThan(fpdecimal.Zero), "trade of %s has quantity %s", trade.OrderID, trade.Quantity)
+			}
+			for id, order := range backend.orders {
+				assert.True(t, order.Quantity().GreaterThan(fpdecimal.Zero), "order %s rests with quantity %s", id, order.Quantity())
+			}
+			for _, level := range backend.sellSide.orders {
+				for id, order := range level {
+					assert.True(t, order.Quantity().GreaterThan(fpdecimal.Zero), "order %s rests with quantity %s", id, order.Quantity())
+				}
+			}
+			require.NotNil(t, backend.GetOrder("full"))
+			assert.Equal(t, "4.999", backend.GetOrder("full").Quantity().String())
+			assert.Len(t, backend.orders, 1)
+		})
+	}
+}
+
+// fillingBackend is a mockBackend that fills resting orders atomically
+// against quantities of its own, which another matcher may have changed
+// since the book read its orders
+type fillingBackend struct {
+	*mockBackend
+	stored  map[string]fpdecimal.Decimal
+	failOn  string
+	updates []string
+}
+
+func (b *fillingBackend) UpdateOrder(order *Order) error {
+	b.updates = append(b.updates, order.ID())
+	return b.mockBackend.UpdateOrder(order)
+}
+
+func (b *fillingBackend) FillOrder(order *Order, quantity fpdecimal.Decimal) (filled, remaining fpdecimal.Decimal, err error) {
+	if order.ID() == b.failOn {
+		return fpdecimal.Zero, fpdecimal.Zero, errors.New("connection lost")
+	}
+	available := b.stored[order.ID()]
+	filled = min(quantity, available)
+	b.stored[order.ID()] = available.Sub(filled)
+	if !b.stored[order.ID()].GreaterThan(fpdecimal.Zero) {
+		b.mockBackend.RemoveFromSide(order.Side(), order)
+	}
+	return filled, b.stored[order.ID()], nil
+}
+
+func TestMatchingKeepsStoredFill(t *testing.T) {
+	sender := setupMockSender(t)
+	ctx := context.Background()
+	backend := &fillingBackend{mockBackend: newMockBackend(), stored: map[string]fpdecimal.Decimal{}}
+	book := NewOrderBook(backend)
+
+	for _, maker := range []struct {
+		id    string
+		price int64
+	}{{"ask-1", 100}, {"ask-2", 101}} {
+		order, err := NewLimitOrder(maker.id, Sell, fpdecimal.FromInt(5), fpdecimal.FromInt(maker.price), GTC, "", "")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+		backend.stored[maker.id] = fpdecimal.FromInt(5)
+	}
+
+	// Another matcher took 1 of ask-1 since it was read, so 1 is left
+	// after this fill of 3, not the 2 the book's copy would give
+	backend.stored["ask-1"] = fpdecimal.FromInt(4)
+	backend.updates = nil
+	bid, err := NewLimitOrder("bid-1", Buy, fpdecimal.FromInt(3), fpdecimal.FromInt(100), GTC, "", "")
+	require.NoError(t, err)
+	done, err := book.Process(ctx, bid)
+	require.NoError(t, err)
+	assert.Equal(t, "3.000", done.Processed.String())
+	assert.Equal(t, "1.000", backend.stored["ask-1"].String())
+	assert.Equal(t, "1.000", backend.GetOrder("ask-1").Quantity().String())
+	assert.NotContains(t, backend.updates, "ask-1")
+
+	// A failed fill ends the sweep, and the fills before it are kept and
+	// published with the error
+	backend.failOn = "ask-2"
+	sent := len(sender.GetSentMessages())
+	bid, err = NewLimitOrder("bid-2", Buy, fpdecimal.FromInt(3), fpdecimal.FromInt(101), GTC, "", "")
+	require.NoError(t, err)
+	done, err = book.Process(ctx, bid)
+	require.Error(t, err)
+	require.NotNil(t, done)
+	assert.Equal(t, "1.000", done.Processed.String())
+	assert.False(t, done.Stored)
+	require.NotNil(t, done.GetTradeOrder("ask-1"))
+	assert.Nil(t, backend.GetOrder("ask-1"))
+	messages := sender.GetSentMessages()
+	require.Len(t, messages, sent+1)
+	assert.Equal(t, "bid-2", messages[sent].OrderID)
+
+	// Nothing is kept when the first fill fails
+	bid, err = NewLimitOrder("bid-3", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(101), GTC, "", "")
+	require.NoError(t, err)
+	done, err = book.Process(ctx, bid)
+	require.Error(t, err)
+	assert.Nil(t, done)
+}
+
+// TestOrderBook_PriceTimePriorityInvariant processes random sequences of
+// limit and market orders and checks the book after every one of them. CI
+// runs it with -rapid.checks=1000.
+func TestOrderBook_PriceTimePriorityInvariant(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+
+	rapid.Check(t, func(t *rapid.T) {
+		backend := newMockBackend()
+		book := NewOrderBook(backend)
+		// arrival is the position of each order in the sequence
+		arrival := make(map[string]int)
+
+		steps := rapid.IntRange(1, 60).Draw(t, "steps")
+		for i := 0; i < steps; i++ {
+			id := fmt.Sprintf("order-%d", i)
+			arrival[id] = i
+			side := rapid.SampledFrom([]Side{Buy, Sell}).Draw(t, "side")
+
+			var order *Order
+			var err error
+			if rapid.IntRange(0, 3).Draw(t, "kind") == 0 {
+				qty := rapid.Int64Range(1, 5).Draw(t, "market_qty")
+				order, err = NewMarketOrder(id, side, fpdecimal.FromInt(qty), "")
+			} else {
+				price := rapid.Int64Range(90, 110).Draw(t, "price")
+				qty := rapid.Int64Range(1, 10).Draw(t, "qty")
+				order, err = NewLimitOrder(id, side, fpdecimal.FromInt(qty), fpdecimal.FromInt(price), GTC, "", "")
+			}
+			require.NoError(t, err)
+
+			done, err := book.Process(ctx, order)
+			require.NoError(t, err)
+			checkPriceTimePriority(t, book, backend, done, arrival)
+		}
+	})
+}
+
+// checkPriceTimePriority fails t if the book is crossed or unsorted, holds an
+// order without quantity, prices market orders differently from its depth,
+// or filled a maker of done ahead of an older order at the same price
+func checkPriceTimePriority(t *rapid.T, book *OrderBook, backend *mockBackend, done *Done, arrival map[string]int) {
+	bids := backend.buySide.Prices()
+	asks := backend.sellSide.Prices()
+	for i := 1; i < len(bids); i++ {
+		require.True(t, bids[i-1].GreaterThan(bids[i]), "bids %v are not sorted descending", bids)
+	}
+	for i := 1; i < len(asks); i++ {
+		require.True(t, asks[i-1].LessThan(asks[i]), "asks %v are not sorted ascending", asks)
+	}
+	if len(bids) > 0 && len(asks) > 0 {
+		require.True(t, bids[0].LessThan(asks[0]), "book is crossed: best bid %s, best ask %s", bids[0], asks[0])
+	}
+
+	for id, order := range backend.orders {
+		require.True(t, order.Quantity().GreaterThan(fpdecimal.Zero), "order %s rests with quantity %s", id, order.Quantity())
+	}
+
+	// A market order for the whole opposite side costs its depth at each level
+	for _, side := range []struct {
+		taker  Side
+		levels *mockOrderSide
+		prices []fpdecimal.Decimal
+	}{
+		{Buy, &backend.sellSide, asks},
+		{Sell, &backend.buySide, bids},
+	} {
+		depth, cost := fpdecimal.Zero, fpdecimal.Zero
+		for _, price := range side.prices {
+			for _, order := range side.levels.Orders(price) {
+				depth = depth.Add(order.Quantity())
+				cost = cost.Add(price.Mul(order.Quantity()))
+			}
+		}
+		if depth.Equal(fpdecimal.Zero) {
+			continue
+		}
+		price, err := book.CalculateMarketPrice(side.taker, depth)
+		require.NoError(t, err)
+		require.Equal(t, cost.String(), price.String(), "market %v price of %s", side.taker, depth)
+		_, err = book.CalculateMarketPrice(side.taker, depth.Add(fpdecimal.FromInt(1)))
+		require.ErrorIs(t, err, ErrInsufficientQuantity)
+	}
+
+	makerLevels := &backend.buySide
+	if done.Order.Side() == Buy {
+		makerLevels = &backend.sellSide
+	}
+	for _, trade := range done.makerTrades() {
+		for _, resting := range makerLevels.Orders(trade.Price) {
+			require.False(t, arrival[resting.ID()] < arrival[trade.OrderID],
+				"maker %s was filled ahead of older order %s at %s", trade.OrderID, resting.ID(), trade.Price)
+		}
+	}
+}
+
+// TestNestedOperationsDoNotDeadlock runs stop trigger -> limit fill -> OCO
+// cancel inside one Process call, all of which must share its single lock
+func TestNestedOperationsDoNotDeadlock(t *testing.T) {
+	setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+	bg := context.Background()
+
+	process := func(order *Order, err error) *Done {
+		t.Helper()
+		require.NoError(t, err)
+		done, err := book.Process(bg, order)
+		require.NoError(t, err)
+		return done
+	}
+	// "first" sets off the stop; "leg" is filled by the stop and cancels "other"
+	process(NewLimitOrder("first", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(105), GTC, "", ""))
+	process(NewLimitOrder("leg", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(105), GTC, "other", ""))
+	process(NewLimitOrder("other", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(120), GTC, "leg", ""))
+	process(NewStopLimitOrder("stop", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(105), fpdecimal.FromInt(105), "", ""))
+
+	taker, err := NewLimitOrder("taker", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(105), GTC, "", "")
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(bg, 5*time.Second)
+	defer cancel()
+	result := make(chan error, 1)
+	go func() {
+		_, err := book.Process(ctx, taker)
+		result <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("Process deadlocked in the stop -> limit -> OCO -> cancel chain")
+	case err := <-result:
+		require.NoError(t, err)
+	}
+
+	assert.Nil(t, book.GetOrder("first"), "taker fills the first ask")
+	assert.Nil(t, book.GetOrder("leg"), "activated stop fills the OCO leg")
+	assert.Nil(t, book.GetOrder("other"), "filled leg cancels the other one")
+	assert.Empty(t, book.GetAllStopOrders())
+}
+
+func TestIcebergOrder(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+
+	process := func(order *Order, err error) *Done {
+		t.Helper()
+		require.NoError(t, err)
+		done, err := book.Process(ctx, order)
+		require.NoError(t, err)
+		return done
+	}
+	makerFills := func(done *Done) map[string]string {
+		fills := make(map[string]string)
+		for _, trade := range done.makerTrades() {
+			fills[trade.OrderID] = fills[trade.OrderID] + trade.Quantity.String() + " "
+		}
+		return fills
+	}
+
+	iceberg, err := NewIcebergOrder("iceberg", Sell, fpdecimal.FromInt(10), fpdecimal.FromInt(3), fpdecimal.FromInt(100), GTC, "", "test_user")
+	require.NoError(t, err)
+	done := process(iceberg, nil)
+	assert.True(t, done.Stored)
+	assert.Equal(t, "10.000", done.Left.String())
+	assert.Equal(t, "3.000", iceberg.Quantity().String())
+	assert.Equal(t, "7.000", iceberg.HiddenQty().String())
+	_, depth, _ := book.BestAsk()
+	assert.Equal(t, "3.000", depth.String(), "only the shown quantity is on the book")
+
+	process(NewLimitOrder("plain", Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(100), GTC, "", ""))
+
+	// The refilled iceberg order goes behind "plain"
+	done = process(NewLimitOrder("taker-1", Buy, fpdecimal.FromInt(4), fpdecimal.FromInt(100), IOC, "", ""))
+	assert.True(t, done.Replenished)
+	assert.Equal(t, map[string]string{"iceberg": "3.000 ", "plain": "1.000 "}, makerFills(done))
+	assert.Equal(t, "3.000", iceberg.Quantity().String())
+	assert.Equal(t, "4.000", iceberg.HiddenQty().String())
+
+	// A taker larger than the shown quantity keeps filling from the reserve
+	done = process(NewLimitOrder("taker-2", Buy, fpdecimal.FromInt(10), fpdecimal.FromInt(100), GTC, "", ""))
+	assert.True(t, done.Replenished)
+	assert.Equal(t, map[string]string{"iceberg": "3.000 3.000 1.000 ", "plain": "1.000 "}, makerFills(done))
+	assert.Equal(t, "8.000", done.Processed.String())
+	assert.Nil(t, book.GetOrder("iceberg"))
+	assert.Equal(t, StateFilled, iceberg.State())
+
+	// The rest of taker-2 is an ordinary maker: filling it is no replenishment
+	done = process(NewMarketOrder("taker-3", Sell, fpdecimal.FromInt(1), ""))
+	assert.Equal(t, map[string]string{"taker-2": "1.000 "}, makerFills(done))
+	assert.False(t, done.Replenished)
+}
+
+func TestOrderBook_StateTransitions(t *testing.T) {
+	setupMockSender(t)
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs).Level(zerolog.ErrorLevel)
+	ctx := logger.WithContext(context.Background())
+	book := NewOrderBook(newMockBackend())
+
+	process := func(order *Order, err error) (*Order, *Done) {
+		t.Helper()
+		require.NoError(t, err)
+		done, err := book.Process(ctx, order)
+		require.NoError(t, err)
+		return order, done
+	}
+
+	// Fills, IOC and FOK cancellations and cancels only make allowed moves
+	process(NewLimitOrder("ask", Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(100), GTC, "", "seller"))
+	ioc, _ := process(NewLimitOrder("ioc", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), IOC, "", "buyer"))
+	assert.Equal(t, StateFilled, ioc.State())
+	fok, _ := process(NewLimitOrder("fok", Buy, fpdecimal.FromInt(5), fpdecimal.FromInt(100), FOK, "", "buyer"))
+	assert.Equal(t, StateCanceled, fok.State())
+	market, _ := process(NewMarketOrder("market", Buy, fpdecimal.FromInt(3), "buyer"))
+	assert.Equal(t, StateCanceled, market.State())
+	resting, _ := process(NewLimitOrder("resting", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(101), GTC, "", "seller"))
+	require.NotNil(t, book.CancelOrder("resting"))
+	assert.Equal(t, StateCanceled, resting.State())
+	assert.Empty(t, logs.String())
+
+	// A resting order whose state is out of step with the book is logged and
+	// still canceled
+	stale, _ := process(NewLimitOrder("stale", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(102), GTC, "", "seller"))
+	stale.state = StateFilled
+	require.NotNil(t, book.CancelOrderWithReason(ctx, "stale", messaging.CancelReasonUserRequested))
+	assert.Equal(t, StateCanceled, stale.State())
+	assert.Contains(t, logs.String(), "Order state out of step with the book")
+	assert.Contains(t, logs.String(), `"order_id":"stale"`)
+}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"context"
+
+	"github.com/erain9/matchingo/pkg/oracle"
+	"github.com/nikolaydubina/fpdecimal"
+	zlog "github.com/rs/zerolog/log"
+)
+
+// PegType is the price a pegged order follows
+type PegType string
+
+// Peg types
+const (
+	PegMid     PegType = "MID"      // Midpoint of the best bid and best ask
+	PegBestBid PegType = "BEST_BID" // Best bid
+	PegBestAsk PegType = "BEST_ASK" // Best ask
+	// PegOracleMid follows the external mid price the book's price feed
+	// reports for the pair named like the book
+	PegOracleMid PegType = "ORACLE_MID"
+)
+
+// valid reports whether t is one of the peg types
+func (t PegType) valid() bool {
+	return t == PegMid || t == PegBestBid || t == PegBestAsk || t == PegOracleMid
+}
+
+// SetPriceFeed sets the feed orders pegged with PegOracleMid follow. The
+// feed is asked for the price of the pair named like the book whenever pegs
+// are priced, which is when a pegged order is placed and after orders,
+// amendments and sweeps change the book; RepricePegs reprices them when only
+// the feed's price moved. Without a feed, or while it has no fresh price,
+// new oracle pegs are rejected with ErrNoPegReference and resting ones stay
+// where they are.
+func (ob *OrderBook) SetPriceFeed(feed oracle.PriceFeed) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.priceFeed = feed
+}
+
+// RepricePegs moves the resting pegged orders to their current reference
+// prices, as is done after every order
+func (ob *OrderBook) RepricePegs(ctx context.Context) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.recomputePegs(ctx)
+}
+
+// pegReferences are the prices pegged orders follow. Pegged orders are left
+// out of them, so a peg never follows itself or another peg.
+type pegReferences struct {
+	bid, ask       fpdecimal.Decimal
+	hasBid, hasAsk bool
+	// oracle is the price feed's price for the book's pair
+	oracle    fpdecimal.Decimal
+	hasOracle bool
+}
+
+// pegReferences returns the best bid and ask of the orders that are not
+// pegged and the price feed's price. The caller must hold mu.
+func (ob *OrderBook) pegReferences() pegReferences {
+	var refs pegReferences
+	refs.bid, refs.hasBid = bestUnpegged(ob.backend.GetBids())
+	refs.ask, refs.hasAsk = bestUnpegged(ob.backend.GetAsks())
+	if ob.priceFeed != nil {
+		if price, err := ob.priceFeed.LatestPrice(ob.name); err == nil && price.GreaterThan(fpdecimal.Zero) {
+			refs.oracle, refs.hasOracle = price, true
+		}
+	}
+	return refs
+}
+
+// bestUnpegged returns the best price on side holding an order that is not
+// pegged, if there is one
+func bestUnpegged(side interface{}) (fpdecimal.Decimal, bool) {
+	orderSide, ok := side.(interface {
+		Prices() []fpdecimal.Decimal
+		Orders(price fpdecimal.Decimal) []*Order
+	})
+	if !ok {
+		return fpdecimal.Zero, false
+	}
+	for _, price := range orderSide.Prices() {
+		for _, order := range orderSide.Orders(price) {
+			if !order.IsPegged() {
+				return price, true
+			}
+		}
+	}
+	return fpdecimal.Zero, false
+}
+
+// pegPrice returns the price order is pegged to under refs: the reference
+// price plus the order's offset, rounded to the book's tick away from the
+// other side. It reports false if the reference price is missing or the
+// result is not positive.
+func (ob *OrderBook) pegPrice(order *Order, refs pegReferences) (fpdecimal.Decimal, bool) {
+	var price fpdecimal.Decimal
+	switch order.PegType() {
+	case PegBestBid:
+		if !refs.hasBid {
+			return fpdecimal.Zero, false
+		}
+		price = refs.bid
+	case PegBestAsk:
+		if !refs.hasAsk {
+			return fpdecimal.Zero, false
+		}
+		price = refs.ask
+	case PegMid:
+		if !refs.hasBid || !refs.hasAsk {
+			return fpdecimal.Zero, false
+		}
+		price = MidPrice(refs.bid, refs.ask)
+	case PegOracleMid:
+		if !refs.hasOracle {
+			return fpdecimal.Zero, false
+		}
+		price = refs.oracle
+	default:
+		return fpdecimal.Zero, false
+	}
+
+	price = roundToTick(price.Add(order.PegOffset()), ob.rules.TickSize, order.Side() == Sell)
+	if !price.GreaterThan(fpdecimal.Zero) {
+		return fpdecimal.Zero, false
+	}
+	return price, true
+}
+
+// roundToTick rounds price to a multiple of tick, up if up is set and down
+// otherwise. A tick that is not positive leaves price as it is.
+func roundToTick(price, tick fpdecimal.Decimal, up bool) fpdecimal.Decimal {
+	if !tick.GreaterThan(fpdecimal.Zero) {
+		return price
+	}
+	scaled, step := price.Scaled(), tick.Scaled()
+	rem := scaled % step
+	if rem < 0 {
+		rem += step
+	}
+	if rem == 0 {
+		return price
+	}
+	down := scaled - rem
+	if up {
+		return fpdecimal.FromIntScaled(down + step)
+	}
+	return fpdecimal.FromIntScaled(down)
+}
+
+// priceNewPeg sets the price of a pegged order that is about to be
+// processed. The caller must hold mu.
+func (ob *OrderBook) priceNewPeg(order *Order) error {
+	price, ok := ob.pegPrice(order, ob.pegReferences())
+	if !ok {
+		return ErrNoPegReference
+	}
+	order.price = price
+	return nil
+}
+
+// recomputePegs reprices the resting pegged orders whose reference price
+// moved, taking each off its side and appending it at the new price, so a
+// repriced order loses its time priority. An order is not moved to a price
+// that would match the other side; it stays where it is until its reference
+// comes back. Repricing makes no trades, so it triggers no stops: the trade
+// that moved the book has already checked them. The caller must hold mu.
+func (ob *OrderBook) recomputePegs(ctx context.Context) {
+	if len(ob.peggedOrders) == 0 {
+		return
+	}
+
+	refs := ob.pegReferences()
+	resting := make([]*Order, 0, len(ob.peggedOrders))
+	var moves []pegMove
+	for _, pegged := range ob.peggedOrders {
+		// Fills update the stored order rather than this one
+		order := ob.backend.GetOrder(pegged.ID())
+		if order == nil || order.IsCanceled() {
+			continue
+		}
+		resting = append(resting, order)
+		if price, ok := ob.pegPrice(order, refs); ok && !price.Equal(order.Price()) {
+			moves = append(moves, pegMove{order: order, price: price})
+		}
+	}
+	ob.peggedOrders = resting
+
+	// A peg blocked by one on the other side may be free once that one
+	// has moved, so blocked pegs are retried until no more can move
+	for len(moves) > 0 {
+		blocked := moves[:0]
+		for _, move := range moves {
+			if ob.amendCrosses(move.order.Side(), move.price) {
+				blocked = append(blocked, move)
+				continue
+			}
+			ob.repricePeg(ctx, move.order, move.price)
+		}
+		if len(blocked) == len(moves) {
+			return
+		}
+		moves = blocked
+	}
+}
+
+// pegMove is a pegged order and the price it should move to
+type pegMove struct {
+	order *Order
+	price fpdecimal.Decimal
+}
+
+// repricePeg moves a resting pegged order to the back of the level at price
+func (ob *OrderBook) repricePeg(ctx context.Context, order *Order, price fpdecimal.Decimal) {
+	logger := zlog.Ctx(ctx)
+	logger.Debug().
+		Str("order_id", order.ID()).
+		Str("from", order.Price().String()).
+		Str("to", price.String()).
+		Msg("Repricing pegged order")
+
+	// The order is found on its side by its current price
+	ob.backend.RemoveFromSide(order.Side(), order)
+	order.price = price
+	if err := ob.backend.UpdateOrder(order); err != nil {
+		logger.Error().Err(err).Str("order_id", order.ID()).Msg("Failed to store repriced pegged order")
+	}
+	ob.backend.AppendToSide(order.Side(), order)
+	ob.emit(changeDone(order))
+}
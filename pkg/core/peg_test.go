@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/oracle"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeggedOrder(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+
+	process := func(order *Order, err error) *Done {
+		t.Helper()
+		require.NoError(t, err)
+		done, err := book.Process(ctx, order)
+		require.NoError(t, err)
+		return done
+	}
+	price := func(orderID string) string {
+		t.Helper()
+		order := book.GetOrder(orderID)
+		require.NotNil(t, order)
+		return order.Price().String()
+	}
+
+	_, err := NewPeggedOrder("bad", Buy, fpdecimal.FromInt(1), "LAST", fpdecimal.Zero, GTC, "", "test_user")
+	assert.ErrorIs(t, err, ErrInvalidPegType)
+	order, err := NewPeggedOrder("early", Buy, fpdecimal.FromInt(1), PegBestBid, fpdecimal.Zero, GTC, "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, order)
+	assert.ErrorIs(t, err, ErrNoPegReference)
+
+	process(NewLimitOrder("bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", ""))
+	process(NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(110), GTC, "", ""))
+
+	// Each peg is priced from the orders that are not pegged
+	done := process(NewPeggedOrder("primary", Buy, fpdecimal.FromInt(2), PegBestBid, fpdecimal.FromInt(1), GTC, "", "test_user"))
+	assert.True(t, done.Stored)
+	assert.Equal(t, "101.000", price("primary"))
+	assert.Equal(t, "test_user", book.GetOrder("primary").UserAddress())
+	msg := done.ToMessagingDoneMessage()
+	assert.Equal(t, "BEST_BID", msg.PegType)
+	assert.Equal(t, "101.000", msg.EffectivePrice)
+
+	process(NewPeggedOrder("mid", Sell, fpdecimal.FromInt(2), PegMid, fpdecimal.Zero, GTC, "", "test_user"))
+	assert.Equal(t, "105.000", price("mid"))
+
+	_, err = book.AmendOrder(ctx, "primary", fpdecimal.FromInt(102), fpdecimal.FromInt(2))
+	assert.ErrorIs(t, err, ErrNotAmendable)
+
+	// A higher bid moves both pegs
+	process(NewLimitOrder("bid-2", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(104), GTC, "", ""))
+	assert.Equal(t, "105.000", price("primary"))
+	assert.Equal(t, "107.000", price("mid"))
+
+	// Without an ask the mid peg has no reference and stays; the primary
+	// peg follows the bid back down once bid-2 goes
+	require.NotNil(t, book.CancelOrder("ask"))
+	assert.Equal(t, "107.000", price("mid"))
+	require.NotNil(t, book.CancelOrder("bid-2"))
+	assert.Equal(t, "101.000", price("primary"))
+
+	// A peg is never repriced into the other side: 107.5 would match the
+	// mid peg at 107
+	process(NewLimitOrder("bid-3", Buy, fpdecimal.FromInt(1), fpdecimal.FromFloat(106.5), GTC, "", ""))
+	assert.Equal(t, "101.000", price("primary"))
+
+	// Filled pegs are forgotten
+	process(NewLimitOrder("hit", Sell, fpdecimal.FromInt(3), fpdecimal.FromInt(100), GTC, "", ""))
+	assert.Nil(t, book.GetOrder("primary"))
+	book.mu.RLock()
+	defer book.mu.RUnlock()
+	for _, pegged := range book.peggedOrders {
+		assert.NotEqual(t, "primary", pegged.ID())
+	}
+}
+
+func TestRoundToTick(t *testing.T) {
+	tick := fpdecimal.FromFloat(0.5)
+	assert.Equal(t, "100.500", roundToTick(fpdecimal.FromFloat(100.7), tick, false).String())
+	assert.Equal(t, "101.000", roundToTick(fpdecimal.FromFloat(100.7), tick, true).String())
+	assert.Equal(t, "100.500", roundToTick(fpdecimal.FromFloat(100.5), tick, true).String())
+	assert.Equal(t, "100.700", roundToTick(fpdecimal.FromFloat(100.7), fpdecimal.Zero, true).String())
+}
+
+// stubFeed is a PriceFeed serving fixed prices
+type stubFeed map[string]fpdecimal.Decimal
+
+func (f stubFeed) LatestPrice(pair string) (fpdecimal.Decimal, error) {
+	price, ok := f[pair]
+	if !ok {
+		return fpdecimal.Zero, oracle.ErrUnknownPair
+	}
+	return price, nil
+}
+
+func TestOraclePeggedOrder(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend(), WithName("btc-usd"))
+
+	process := func(order *Order, err error) (*Done, error) {
+		t.Helper()
+		require.NoError(t, err)
+		return book.Process(ctx, order)
+	}
+
+	// Without a feed there is nothing to follow
+	_, err := process(NewPeggedOrder("early", Buy, fpdecimal.FromInt(1), PegOracleMid, fpdecimal.Zero, GTC, "", "test_user"))
+	assert.ErrorIs(t, err, ErrNoPegReference)
+
+	feed := stubFeed{"btc-usd": fpdecimal.FromInt(100)}
+	book.SetPriceFeed(feed)
+
+	// The external price is followed on an empty book
+	done, err := process(NewPeggedOrder("bid", Buy, fpdecimal.FromInt(1), PegOracleMid, fpdecimal.FromInt(-1), GTC, "", "test_user"))
+	require.NoError(t, err)
+	assert.True(t, done.Stored)
+	assert.Equal(t, "99.000", book.GetOrder("bid").Price().String())
+	assert.Equal(t, "ORACLE_MID", done.ToMessagingDoneMessage().PegType)
+
+	// A move of the feed alone is picked up by RepricePegs
+	feed["btc-usd"] = fpdecimal.FromInt(110)
+	book.RepricePegs(ctx)
+	assert.Equal(t, "109.000", book.GetOrder("bid").Price().String())
+
+	// The peg trades like any resting order
+	done, err = process(NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(105), GTC, "", ""))
+	require.NoError(t, err)
+	require.Len(t, done.Trades, 2)
+	assert.Equal(t, "109.000", done.Trades[1].Price.String())
+
+	// A pair the feed has no price for leaves new pegs without a reference
+	other := NewOrderBook(newMockBackend(), WithName("eth-usd"))
+	other.SetPriceFeed(feed)
+	order, err := NewPeggedOrder("eth-bid", Buy, fpdecimal.FromInt(1), PegOracleMid, fpdecimal.Zero, GTC, "", "test_user")
+	require.NoError(t, err)
+	_, err = other.Process(ctx, order)
+	assert.ErrorIs(t, err, ErrNoPegReference)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// PositionBook tracks the net position of every user in every order book it
+// is registered with: the quantity bought less the quantity sold. It is safe
+// for concurrent use.
+type PositionBook struct {
+	mu sync.RWMutex
+	// positions maps a user address to its net position in each book
+	positions map[string]map[string]fpdecimal.Decimal
+}
+
+// NewPositionBook creates an empty PositionBook
+func NewPositionBook() *PositionBook {
+	return &PositionBook{
+		positions: make(map[string]map[string]fpdecimal.Decimal),
+	}
+}
+
+// OnTrade records that userAddress traded qty on side in bookName. Buys add
+// to the user's position and sells subtract from it.
+func (pb *PositionBook) OnTrade(bookName, userAddress string, side Side, qty fpdecimal.Decimal) {
+	if side == Sell {
+		qty = fpdecimal.Zero.Sub(qty)
+	}
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	books, ok := pb.positions[userAddress]
+	if !ok {
+		books = make(map[string]fpdecimal.Decimal)
+		pb.positions[userAddress] = books
+	}
+	books[bookName] = books[bookName].Add(qty)
+}
+
+// Net returns the position of userAddress in bookName
+func (pb *PositionBook) Net(userAddress, bookName string) fpdecimal.Decimal {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+	return pb.positions[userAddress][bookName]
+}
+
+// Total returns the sum of the positions of userAddress across all books
+func (pb *PositionBook) Total(userAddress string) fpdecimal.Decimal {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+	total := fpdecimal.Zero
+	for _, net := range pb.positions[userAddress] {
+		total = total.Add(net)
+	}
+	return total
+}
+
+// Users returns the addresses of every user with a position, sorted
+func (pb *PositionBook) Users() []string {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+	users := make([]string, 0, len(pb.positions))
+	for user := range pb.positions {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// Positions returns a copy of the positions of userAddress, keyed by book name
+func (pb *PositionBook) Positions(userAddress string) map[string]fpdecimal.Decimal {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+	books := make(map[string]fpdecimal.Decimal, len(pb.positions[userAddress]))
+	for book, net := range pb.positions[userAddress] {
+		books[book] = net
+	}
+	return books
+}
+
+// TradeHandler returns a TradeHandler that records the trades of the book
+// named bookName: the processed order trades its filled quantity on its own
+// side, and each resting order it matched trades on the other.
+func (pb *PositionBook) TradeHandler(bookName string) TradeHandler {
+	return func(ctx context.Context, done *Done) {
+		taker := done.Order
+		pb.OnTrade(bookName, taker.UserAddress(), taker.Side(), done.Processed)
+		makerSide := Sell
+		if taker.Side() == Sell {
+			makerSide = Buy
+		}
+		for _, trade := range done.makerTrades() {
+			pb.OnTrade(bookName, trade.UserAddress, makerSide, trade.Quantity)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositionBook_TradeHandler(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+
+	positions := NewPositionBook()
+	var handled int
+	btc := NewOrderBook(newMockBackend(),
+		WithTradeHandler(positions.TradeHandler("BTC-USD")),
+		WithTradeHandler(func(ctx context.Context, done *Done) { handled++ }),
+	)
+	eth := NewOrderBook(newMockBackend(), WithTradeHandler(positions.TradeHandler("ETH-USD")))
+
+	process := func(book *OrderBook, id string, side Side, quantity, price int64, user string) {
+		t.Helper()
+		order, err := NewLimitOrder(id, side, fpdecimal.FromInt(quantity), fpdecimal.FromInt(price), GTC, "", user)
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+
+	// alice sells 5 BTC to bob, who then sells 2 of it back
+	process(btc, "btc-ask", Sell, 5, 100, "alice")
+	process(btc, "btc-bid", Buy, 5, 100, "bob")
+	process(btc, "btc-bid-2", Buy, 2, 100, "alice")
+	process(btc, "btc-ask-2", Sell, 2, 100, "bob")
+	assert.Equal(t, 2, handled, "every trade handler is called")
+
+	// bob sells 4 ETH to alice, taking only part of her bid
+	process(eth, "eth-bid", Buy, 10, 50, "alice")
+	process(eth, "eth-ask", Sell, 4, 50, "bob")
+
+	assert.Equal(t, "-3.000", positions.Net("alice", "BTC-USD").String())
+	assert.Equal(t, "3.000", positions.Net("bob", "BTC-USD").String())
+	assert.Equal(t, "4.000", positions.Net("alice", "ETH-USD").String())
+	assert.Equal(t, "-4.000", positions.Net("bob", "ETH-USD").String())
+
+	assert.Equal(t, "1.000", positions.Total("alice").String())
+	assert.Equal(t, "-1.000", positions.Total("bob").String())
+	assert.True(t, positions.Total("carol").Equal(fpdecimal.Zero))
+
+	assert.Equal(t, []string{"alice", "bob"}, positions.Users())
+	assert.Len(t, positions.Positions("alice"), 2)
+}
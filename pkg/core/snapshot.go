@@ -0,0 +1,247 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sort"
+
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// Snapshot is a point-in-time deep copy of an order book's resting orders.
+// Changes to the snapshot never affect the book it was taken from.
+type Snapshot struct {
+	// Bids are ordered from the best (highest) price level down
+	Bids []*Order
+	// Asks are ordered from the best (lowest) price level up
+	Asks       []*Order
+	StopOrders []*Order
+	// Midpoint holds the resting midpoint orders, buys then sells, each
+	// oldest first
+	Midpoint       []*Order
+	LastTradePrice fpdecimal.Decimal
+}
+
+// Snapshot returns a deep copy of the book's bids, asks, stop orders,
+// midpoint orders and last trade price
+func (ob *OrderBook) Snapshot() *Snapshot {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	snapshot := &Snapshot{LastTradePrice: ob.lastTradePrice}
+	for _, order := range ob.backend.GetAllOrders() {
+		switch {
+		case order.IsMidpointOrder():
+			// Listed below in queue order
+		case order.IsStopOrder():
+			snapshot.StopOrders = append(snapshot.StopOrders, order)
+		case order.Side() == Buy:
+			snapshot.Bids = append(snapshot.Bids, order)
+		default:
+			snapshot.Asks = append(snapshot.Asks, order)
+		}
+	}
+	// Sorting by price keeps the order the backend gave within each level,
+	// which is its time priority where the backend keeps one
+	sort.SliceStable(snapshot.Bids, func(i, j int) bool {
+		return snapshot.Bids[i].Price().GreaterThan(snapshot.Bids[j].Price())
+	})
+	sort.SliceStable(snapshot.Asks, func(i, j int) bool {
+		return snapshot.Asks[i].Price().LessThan(snapshot.Asks[j].Price())
+	})
+	for _, side := range []Side{Buy, Sell} {
+		for _, order := range ob.backend.GetMidpointOrders(side) {
+			snapshot.Midpoint = append(snapshot.Midpoint, order.Clone())
+		}
+	}
+	return snapshot
+}
+
+// Restore loads the orders in snapshot into the book's backend and sets its
+// last trade price. The orders are copied, so the snapshot can be restored again.
+func (ob *OrderBook) Restore(snapshot *Snapshot) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	for _, order := range snapshot.Bids {
+		if err := ob.restoreOrder(order.Clone()); err != nil {
+			return err
+		}
+	}
+	for _, order := range snapshot.Asks {
+		if err := ob.restoreOrder(order.Clone()); err != nil {
+			return err
+		}
+	}
+	for _, order := range snapshot.StopOrders {
+		order = order.Clone()
+		if err := ob.backend.StoreOrder(order); err != nil {
+			return err
+		}
+		ob.backend.AppendToStopBook(order)
+	}
+	for _, order := range snapshot.Midpoint {
+		order = order.Clone()
+		if err := ob.backend.StoreOrder(order); err != nil {
+			return err
+		}
+		ob.backend.AppendToMidpoint(order)
+	}
+
+	ob.lastTradePrice = snapshot.LastTradePrice
+	ob.watchExpiry()
+	return nil
+}
+
+// stateVersion is the version of the format MarshalState writes. Version 1
+// states hold only the backend's state, as written by MarshalState before
+// the book's own state was saved with it. Version 2 states list the midpoint
+// queues beside it, as written before the backend kept them.
+const stateVersion = 3
+
+// bookState is the gob form of an order book: the backend's own serialised
+// state and what the book keeps beside it. Pegged orders are stored by the
+// backend, so they are listed by ID. PeggedBids and PeggedAsks are the
+// midpoint queues of version 2 states, oldest first; they are no longer
+// written.
+type bookState struct {
+	Version        int
+	Backend        []byte
+	PeggedBids     []string
+	PeggedAsks     []string
+	PeggedOrders   []string
+	Seq            uint64
+	LastTradePrice string
+}
+
+// stateBackend is a backend that can serialise its state and load it back
+type stateBackend interface {
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
+}
+
+// WithSnapshotPath sets the file the book's state is saved to and restored
+// from; see MarshalState
+func WithSnapshotPath(path string) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.snapshotPath = path
+	}
+}
+
+// SnapshotPath returns the file the book's state is saved to and restored
+// from, or an empty string if it has none
+func (ob *OrderBook) SnapshotPath() string {
+	return ob.snapshotPath
+}
+
+// MarshalState serialises the book, taken while no order is being
+// processed: the backend's state, the pegged orders, the sequence number of
+// the last published Done and the last trade price. Backends that cannot
+// snapshot themselves return ErrSnapshotUnsupported.
+func (ob *OrderBook) MarshalState() ([]byte, error) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	backend, ok := ob.backend.(stateBackend)
+	if !ok {
+		return nil, ErrSnapshotUnsupported
+	}
+	data, err := backend.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	state := bookState{
+		Version:        stateVersion,
+		Backend:        data,
+		PeggedOrders:   orderIDs(ob.peggedOrders),
+		Seq:            ob.seq.Load(),
+		LastTradePrice: ob.lastTradePrice.String(),
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&state); err != nil {
+		return nil, fmt.Errorf("encode order book state: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalState replaces the book's state with one taken by MarshalState.
+// A state that fails to load may leave the book partly restored, so the
+// book should then be discarded.
+func (ob *OrderBook) UnmarshalState(data []byte) error {
+	var state bookState
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&state); err != nil {
+		return fmt.Errorf("decode order book state: %w", err)
+	}
+	if state.Version == 1 {
+		state = bookState{Version: stateVersion, Backend: data, LastTradePrice: "0"}
+	}
+	if state.Version != 2 && state.Version != stateVersion {
+		return fmt.Errorf("%w: %d", ErrStateVersion, state.Version)
+	}
+	lastTradePrice, err := fpdecimal.FromString(state.LastTradePrice)
+	if err != nil {
+		return fmt.Errorf("decode last trade price: %w", err)
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	backend, ok := ob.backend.(stateBackend)
+	if !ok {
+		return ErrSnapshotUnsupported
+	}
+	if err := backend.Restore(state.Backend); err != nil {
+		return err
+	}
+
+	// The book's pegged orders are the ones the backend has just stored
+	stored := func(ids []string) ([]*Order, error) {
+		orders := make([]*Order, 0, len(ids))
+		for _, id := range ids {
+			order := ob.backend.GetOrder(id)
+			if order == nil {
+				return nil, fmt.Errorf("restore pegged order %s: %w", id, ErrNonexistentOrder)
+			}
+			orders = append(orders, order)
+		}
+		return orders, nil
+	}
+	// Version 2 states kept the midpoint queues outside the backend
+	midpoint, err := stored(append(state.PeggedBids, state.PeggedAsks...))
+	if err != nil {
+		return err
+	}
+	for _, order := range midpoint {
+		ob.backend.AppendToMidpoint(order)
+	}
+	if ob.peggedOrders, err = stored(state.PeggedOrders); err != nil {
+		return err
+	}
+	ob.seq.Store(state.Seq)
+	ob.lastTradePrice = lastTradePrice
+	ob.watchExpiry()
+	return nil
+}
+
+// orderIDs returns the IDs of orders, in the same order
+func orderIDs(orders []*Order) []string {
+	ids := make([]string, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID()
+	}
+	return ids
+}
+
+// restoreOrder stores a resting order and appends it to its side
+func (ob *OrderBook) restoreOrder(order *Order) error {
+	if err := ob.backend.StoreOrder(order); err != nil {
+		return err
+	}
+	ob.backend.AppendToSide(order.Side(), order)
+	if order.IsPegged() {
+		ob.peggedOrders = append(ob.peggedOrders, order)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	setupMockSender(t)
+	book := NewOrderBook(newMockBackend())
+
+	bid, err := NewLimitOrder("bid", Buy, fpdecimal.FromInt(2), fpdecimal.FromInt(99), GTC, "", "test_user")
+	require.NoError(t, err)
+	ask, err := NewLimitOrder("ask", Sell, fpdecimal.FromInt(3), fpdecimal.FromInt(101), GTC, "", "test_user")
+	require.NoError(t, err)
+	stop, err := NewStopLimitOrder("stop", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(90), fpdecimal.FromInt(95), "", "test_user")
+	require.NoError(t, err)
+	mid, err := NewMidpointPeggedOrder("mid", Buy, fpdecimal.FromInt(1), "", "test_user")
+	require.NoError(t, err)
+	for _, o := range []*Order{bid, ask, stop, mid} {
+		_, err := book.Process(ctx, o)
+		require.NoError(t, err)
+	}
+	book.lastTradePrice = fpdecimal.FromInt(100)
+
+	snapshot := book.Snapshot()
+	require.Len(t, snapshot.Bids, 1)
+	require.Len(t, snapshot.Asks, 1)
+	require.Len(t, snapshot.StopOrders, 1)
+	require.Len(t, snapshot.Midpoint, 1)
+	assert.True(t, snapshot.LastTradePrice.Equal(fpdecimal.FromInt(100)))
+
+	// Snapshot orders are copies
+	snapshot.Asks[0].DecreaseQuantity(fpdecimal.FromInt(1))
+	assert.True(t, book.GetOrder("ask").Quantity().Equal(fpdecimal.FromInt(3)))
+
+	copyBook := NewDetachedOrderBook(newMockBackend())
+	require.NoError(t, copyBook.Restore(snapshot))
+	assert.NotNil(t, copyBook.GetOrder("bid"))
+	assert.NotNil(t, copyBook.GetOrder("stop"))
+	require.Len(t, copyBook.backend.GetMidpointOrders(Buy), 1)
+	assert.Equal(t, "mid", copyBook.backend.GetMidpointOrders(Buy)[0].ID())
+	assert.True(t, copyBook.lastTradePrice.Equal(fpdecimal.FromInt(100)))
+
+	// Trading on the copy leaves the original alone
+	buy, err := NewMarketOrder("buy", Buy, fpdecimal.FromInt(2), "test_user")
+	require.NoError(t, err)
+	done, err := copyBook.Process(ctx, buy)
+	require.NoError(t, err)
+	assert.True(t, done.Processed.Equal(fpdecimal.FromInt(2)))
+	assert.Nil(t, copyBook.GetOrder("ask"))
+	assert.True(t, book.GetOrder("ask").Quantity().Equal(fpdecimal.FromInt(3)))
+}
+
+// jsonStateBackend is a mockBackend whose state is its orders as JSON
+type jsonStateBackend struct {
+	*mockBackend
+}
+
+func (b jsonStateBackend) Snapshot() ([]byte, error) {
+	return json.Marshal(b.GetAllOrders())
+}
+
+func (b jsonStateBackend) Restore(data []byte) error {
+	var orders []*Order
+	if err := json.Unmarshal(data, &orders); err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if err := b.StoreOrder(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestUnmarshalState_Version2MidpointQueues(t *testing.T) {
+	setupMockSender(t)
+
+	// Version 2 states listed the midpoint queues beside the backend's state
+	first, err := NewMidpointPeggedOrder("mid-1", Sell, fpdecimal.FromInt(1), "", "test_user")
+	require.NoError(t, err)
+	second, err := NewMidpointPeggedOrder("mid-2", Sell, fpdecimal.FromInt(2), "", "test_user")
+	require.NoError(t, err)
+	orders, err := json.Marshal([]*Order{second, first})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&bookState{
+		Version:        2,
+		Backend:        orders,
+		PeggedAsks:     []string{"mid-1", "mid-2"},
+		Seq:            7,
+		LastTradePrice: "100",
+	}))
+
+	book := NewOrderBook(jsonStateBackend{newMockBackend()})
+	require.NoError(t, book.UnmarshalState(buf.Bytes()))
+	queue := book.backend.GetMidpointOrders(Sell)
+	require.Len(t, queue, 2)
+	assert.Equal(t, "mid-1", queue[0].ID())
+	assert.Equal(t, "mid-2", queue[1].ID())
+	assert.Equal(t, 2, book.OrderCount())
+
+	// The queues are saved with the backend's state from now on
+	data, err := book.MarshalState()
+	require.NoError(t, err)
+	var state bookState
+	require.NoError(t, gob.NewDecoder(bytes.NewReader(data)).Decode(&state))
+	assert.Equal(t, stateVersion, state.Version)
+	assert.Empty(t, state.PeggedAsks)
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"strings"
+
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// STPMode is what an order book does when an incoming order would trade with
+// a resting order of the same user address. Orders without a user address
+// never count as the same user.
+type STPMode string
+
+const (
+	// STPNone lets a user's orders trade with each other
+	STPNone STPMode = ""
+	// STPCancelMaker cancels the resting order and goes on matching
+	STPCancelMaker STPMode = "cancel-maker"
+	// STPCancelTaker cancels what is left of the incoming order
+	STPCancelTaker STPMode = "cancel-taker"
+	// STPCancelBoth cancels the resting order and what is left of the
+	// incoming one
+	STPCancelBoth STPMode = "cancel-both"
+)
+
+// Valid reports whether m is one of the defined modes
+func (m STPMode) Valid() bool {
+	switch m {
+	case STPNone, STPCancelMaker, STPCancelTaker, STPCancelBoth:
+		return true
+	}
+	return false
+}
+
+// WithSTPMode sets the book's self-trade prevention, keeping its other
+// matching rules. Apply it after WithMatchingRules.
+func WithSTPMode(mode STPMode) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.rules.STP = mode
+	}
+}
+
+// selfTrade reports whether the book's self-trade prevention keeps taker
+// from trading with maker
+func (ob *OrderBook) selfTrade(taker, maker *Order) bool {
+	return ob.rules.STP != STPNone &&
+		taker.UserAddress() != "" &&
+		strings.EqualFold(taker.UserAddress(), maker.UserAddress())
+}
+
+// preventSelfTrade applies the book's STP mode to a taker that reached
+// maker, a resting order of the same user. It cancels maker unless the mode
+// keeps it, and reports whether the taker must stop matching. The caller
+// must hold mu.
+func (ob *OrderBook) preventSelfTrade(ctx context.Context, maker *Order, done *Done) bool {
+	done.STPTriggered = true
+	if ob.rules.STP != STPCancelTaker {
+		ob.cancelOrder(ctx, maker.ID(), messaging.CancelReasonSTP)
+		done.appendCanceled(maker)
+	}
+	return ob.rules.STP != STPCancelMaker
+}
+
+// fokLiquidity returns how much of maker a FOK taker can count on, and
+// whether self-trade prevention would stop the taker at maker
+func (ob *OrderBook) fokLiquidity(taker, maker *Order) (fpdecimal.Decimal, bool) {
+	if !ob.selfTrade(taker, maker) {
+		return maker.Quantity(), false
+	}
+	return fpdecimal.Zero, ob.rules.STP != STPCancelMaker
+}
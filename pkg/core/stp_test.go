@@ -0,0 +1,176 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	stpUser  = "0xAbCdEf0123456789aBcDeF0123456789AbCdEf01"
+	stpOther = "0x1111111111111111111111111111111111111111"
+)
+
+func TestSelfTradePrevention(t *testing.T) {
+	ctx := context.Background()
+
+	// newBook rests an ask of the taker's user at 100 and another user's ask
+	// at 101
+	newBook := func(t *testing.T, mode STPMode) (*OrderBook, *messaging.MockMessageSender) {
+		sender := setupMockSender(t)
+		book := NewOrderBook(newMockBackend(), WithSTPMode(mode))
+		for _, ask := range []struct {
+			id, user string
+			price    int64
+		}{{"self-ask", stpUser, 100}, {"other-ask", stpOther, 101}} {
+			order, err := NewLimitOrder(ask.id, Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(ask.price), GTC, "", ask.user)
+			require.NoError(t, err)
+			_, err = book.Process(ctx, order)
+			require.NoError(t, err)
+		}
+		sender.ClearSentMessages()
+		return book, sender
+	}
+	// The taker's address differs from the resting order's only in case
+	buy := func(t *testing.T, book *OrderBook, tif TIF) *Done {
+		order, err := NewLimitOrder("buy", Buy, fpdecimal.FromInt(2), fpdecimal.FromInt(101), tif, "", "0xabcdef0123456789abcdef0123456789abcdef01")
+		require.NoError(t, err)
+		done, err := book.Process(ctx, order)
+		require.NoError(t, err)
+		return done
+	}
+	canceledIDs := func(done *Done) []string {
+		ids := make([]string, 0, len(done.Canceled))
+		for _, order := range done.Canceled {
+			ids = append(ids, order.ID())
+		}
+		return ids
+	}
+
+	t.Run("None", func(t *testing.T) {
+		book, _ := newBook(t, STPNone)
+		done := buy(t, book, GTC)
+		assert.Equal(t, "2.000", done.Processed.String())
+		assert.False(t, done.STPTriggered)
+	})
+
+	t.Run("CancelMaker", func(t *testing.T) {
+		book, sender := newBook(t, STPCancelMaker)
+		done := buy(t, book, GTC)
+		assert.True(t, done.STPTriggered)
+		assert.Equal(t, []string{"self-ask"}, canceledIDs(done))
+		assert.Equal(t, "1.000", done.Processed.String(), "the other user's ask still fills")
+		assert.True(t, done.Stored)
+		assert.Nil(t, book.GetOrder("self-ask"))
+
+		cancels := cancelMessages(sender)
+		require.Len(t, cancels, 1)
+		assert.Equal(t, "self-ask", cancels[0].OrderID)
+		assert.Equal(t, messaging.CancelReasonSTP, cancels[0].CancelReason)
+		messages := sender.GetSentMessages()
+		require.Len(t, messages, 2)
+		assert.True(t, messages[0].STPTriggered)
+		assert.True(t, messages[1].STPTriggered)
+		assert.Equal(t, "buy", messages[1].OrderID)
+	})
+
+	t.Run("CancelTaker", func(t *testing.T) {
+		book, sender := newBook(t, STPCancelTaker)
+		done := buy(t, book, GTC)
+		assert.True(t, done.STPTriggered)
+		assert.Equal(t, []string{"buy"}, canceledIDs(done))
+		assert.Equal(t, "0", done.Processed.String())
+		assert.Equal(t, "2.000", done.Left.String())
+		assert.False(t, done.Stored)
+		assert.NotNil(t, book.GetOrder("self-ask"), "the resting order is kept")
+		assert.Nil(t, book.GetOrder("buy"))
+
+		assert.Empty(t, cancelMessages(sender))
+		messages := sender.GetSentMessages()
+		require.Len(t, messages, 1, "the taker is reported although nothing traded")
+		assert.True(t, messages[0].STPTriggered)
+		assert.Equal(t, []string{"buy"}, messages[0].Canceled)
+	})
+
+	t.Run("CancelBoth", func(t *testing.T) {
+		book, sender := newBook(t, STPCancelBoth)
+		done := buy(t, book, GTC)
+		assert.True(t, done.STPTriggered)
+		assert.Equal(t, []string{"self-ask", "buy"}, canceledIDs(done))
+		assert.Equal(t, "0", done.Processed.String())
+		assert.Nil(t, book.GetOrder("self-ask"))
+		assert.NotNil(t, book.GetOrder("other-ask"))
+		require.Len(t, cancelMessages(sender), 1)
+	})
+
+	t.Run("FOKIgnoresOwnLiquidity", func(t *testing.T) {
+		book, sender := newBook(t, STPCancelMaker)
+		done := buy(t, book, FOK)
+		assert.False(t, done.STPTriggered, "a FOK order that cannot fill cancels nothing")
+		assert.Equal(t, "0", done.Processed.String())
+		assert.NotNil(t, book.GetOrder("self-ask"))
+		assert.Empty(t, cancelMessages(sender))
+	})
+
+	t.Run("MarketOrder", func(t *testing.T) {
+		book, _ := newBook(t, STPCancelTaker)
+		order, err := NewMarketOrder("market-buy", Buy, fpdecimal.FromInt(2), stpUser)
+		require.NoError(t, err)
+		done, err := book.Process(ctx, order)
+		require.NoError(t, err)
+		assert.True(t, done.STPTriggered)
+		assert.Equal(t, "0", done.Processed.String())
+		assert.NotNil(t, book.GetOrder("other-ask"), "matching stops at the first own order")
+	})
+
+	t.Run("NoAddress", func(t *testing.T) {
+		sender := setupMockSender(t)
+		book := NewOrderBook(newMockBackend(), WithSTPMode(STPCancelBoth))
+		ask, err := NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, ask)
+		require.NoError(t, err)
+		bid, err := NewLimitOrder("bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "")
+		require.NoError(t, err)
+		done, err := book.Process(ctx, bid)
+		require.NoError(t, err)
+		assert.Equal(t, "1.000", done.Processed.String())
+		assert.False(t, done.STPTriggered)
+		assert.Empty(t, cancelMessages(sender))
+	})
+
+	t.Run("MidpointOrder", func(t *testing.T) {
+		setupMockSender(t)
+		book := NewOrderBook(newMockBackend(), WithSTPMode(STPCancelBoth))
+		process := func(order *Order, err error) *Done {
+			require.NoError(t, err)
+			done, err := book.Process(ctx, order)
+			require.NoError(t, err)
+			return done
+		}
+		// The lit book of another user puts the midpoint at 100
+		process(NewLimitOrder("bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(98), GTC, "", stpOther))
+		process(NewLimitOrder("ask", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(102), GTC, "", stpOther))
+
+		process(NewMidpointPeggedOrder("mid-ask", Sell, fpdecimal.FromInt(1), "", stpUser))
+		done := process(NewMidpointPeggedOrder("mid-bid", Buy, fpdecimal.FromInt(1), "", stpUser))
+		assert.True(t, done.STPTriggered)
+		assert.Equal(t, []string{"mid-ask", "mid-bid"}, canceledIDs(done))
+		assert.Equal(t, "0", done.Processed.String())
+		assert.False(t, done.Stored)
+		assert.Nil(t, book.GetOrder("mid-ask"))
+		assert.Nil(t, book.GetOrder("mid-bid"))
+
+		// A limit order reaching the midpoint is checked too
+		process(NewMidpointPeggedOrder("mid-ask-2", Sell, fpdecimal.FromInt(1), "", stpUser))
+		done = process(NewLimitOrder("limit-bid", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(101), GTC, "", stpUser))
+		assert.True(t, done.STPTriggered)
+		assert.Equal(t, []string{"mid-ask-2", "limit-bid"}, canceledIDs(done))
+		assert.Nil(t, book.GetOrder("limit-bid"))
+		assert.NotNil(t, book.GetOrder("ask"), "the lit book is not reached")
+	})
+}
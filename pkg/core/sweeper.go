@@ -0,0 +1,187 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/erain9/matchingo/pkg/otel"
+)
+
+// DefaultSweepInterval is how often the sweeper checks resting orders when no interval is set
+const DefaultSweepInterval = time.Second
+
+// OrderBookPolicy holds limits that are enforced on an order book in the background
+type OrderBookPolicy struct {
+	// MaxOrderAge is how long an order may rest before it is canceled. Zero disables the limit.
+	MaxOrderAge time.Duration
+	// SweepInterval is how often resting orders are checked against MaxOrderAge
+	// and their GTD expiry
+	SweepInterval time.Duration
+}
+
+// SweepExpiredOrders cancels every resting order created more than maxAge
+// before now and returns the canceled orders
+func (ob *OrderBook) SweepExpiredOrders(ctx context.Context, maxAge time.Duration, now time.Time) []*Order {
+	return ob.cancelRestingOrders(ctx, func(order *Order) bool {
+		return now.Sub(order.CreatedAt()) > maxAge
+	})
+}
+
+// CancelExpiredGTDOrders cancels every resting GTD order whose expiry is at
+// or before now and returns the canceled orders
+func (ob *OrderBook) CancelExpiredGTDOrders(ctx context.Context, now time.Time) []*Order {
+	return ob.cancelRestingOrders(ctx, func(order *Order) bool {
+		return order.expired(now)
+	})
+}
+
+// cancelRestingOrders cancels the resting orders expired reports true for,
+// sending an EXPIRED cancellation for each, and returns them
+func (ob *OrderBook) cancelRestingOrders(ctx context.Context, expired func(*Order) bool) []*Order {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	return ob.cancelExpired(ctx, expired)
+}
+
+// cancelExpired does the work of cancelRestingOrders. The caller must hold mu.
+func (ob *OrderBook) cancelExpired(ctx context.Context, expired func(*Order) bool) []*Order {
+	// Collect first so sides are not modified while being iterated. Stop
+	// orders waiting for their trigger are not resting yet.
+	var expiredIDs []string
+	for _, order := range ob.backend.GetAllOrders() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !order.IsStopOrder() && expired(order) {
+			expiredIDs = append(expiredIDs, order.ID())
+		}
+	}
+
+	swept := make([]*Order, 0, len(expiredIDs))
+	for _, orderID := range expiredIDs {
+		if order := ob.cancelOrder(ctx, orderID, messaging.CancelReasonExpired); order != nil {
+			swept = append(swept, order)
+		}
+	}
+	if len(swept) > 0 {
+		ob.recomputePegs(ctx)
+	}
+	return swept
+}
+
+// StartSweeper starts a background goroutine that cancels orders older than
+// policy.MaxOrderAge every policy.SweepInterval. It stops when ctx is done.
+// name identifies the book in metrics. An age set with WithMaxOrderAge
+// replaces policy.MaxOrderAge.
+func (ob *OrderBook) StartSweeper(ctx context.Context, name string, policy OrderBookPolicy) {
+	if ob.maxOrderAge > 0 {
+		policy.MaxOrderAge = ob.maxOrderAge
+	}
+	if policy.MaxOrderAge <= 0 {
+		return
+	}
+
+	interval := policy.SweepInterval
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				swept := ob.SweepExpiredOrders(ctx, policy.MaxOrderAge, now)
+				if len(swept) > 0 {
+					otel.GetOrderBookMetrics().RecordSweptOrders(ctx, name, string(messaging.CancelReasonExpired), int64(len(swept)))
+				}
+			}
+		}
+	}()
+}
+
+// StartExpiryWorker sets the book up to cancel resting GTD orders past their
+// expiry every interval, or every DefaultSweepInterval if interval is not
+// positive, until ctx is done. The background goroutine doing so only runs
+// while GTD orders rest on the book: it starts now if any do, or once one is
+// stored, and stops when none are left.
+func (ob *OrderBook) StartExpiryWorker(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.expiryCtx, ob.expiryInterval = ctx, interval
+	ob.watchExpiry()
+}
+
+// watchExpiry starts the expiry worker if a GTD order rests on the book. The
+// caller must hold mu.
+func (ob *OrderBook) watchExpiry() {
+	if slices.ContainsFunc(ob.backend.GetAllOrders(), func(order *Order) bool {
+		return order.TIF() == GTD
+	}) {
+		ob.runExpiryWorker()
+	}
+}
+
+// runExpiryWorker starts the goroutine set up by StartExpiryWorker, unless
+// it is already running or was never set up. The caller must hold mu.
+func (ob *OrderBook) runExpiryWorker() {
+	ctx, interval := ob.expiryCtx, ob.expiryInterval
+	if ctx == nil || ctx.Err() != nil || ob.expiryRunning {
+		return
+	}
+	ob.expiryRunning = true
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				ob.mu.Lock()
+				ob.expiryRunning = false
+				ob.mu.Unlock()
+				return
+			case now := <-ticker.C:
+				if !ob.expireGTDOrders(ctx, now) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// expireGTDOrders cancels the resting GTD orders past their expiry at now
+// and reports whether any GTD orders are left. If none are, the expiry
+// worker is marked stopped in the same critical section, so a GTD order
+// stored afterwards starts it again.
+func (ob *OrderBook) expireGTDOrders(ctx context.Context, now time.Time) bool {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	left := false
+	ob.cancelExpired(ctx, func(order *Order) bool {
+		if order.TIF() != GTD {
+			return false
+		}
+		if order.expired(now) {
+			return true
+		}
+		left = true
+		return false
+	})
+	if !left {
+		ob.expiryRunning = false
+	}
+	return left
+}
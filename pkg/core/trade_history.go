@@ -0,0 +1,155 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// DefaultTradeHistorySize is how many trades a TradeHistory keeps when no
+// size is given
+const DefaultTradeHistorySize = 10000
+
+// HistoricTrade is one match recorded by a TradeHistory
+type HistoricTrade struct {
+	Price    fpdecimal.Decimal
+	Quantity fpdecimal.Decimal
+	At       time.Time
+}
+
+// TradeHistory keeps the most recent trades of an order book in a ring
+// buffer, oldest first. It is safe for concurrent use.
+type TradeHistory struct {
+	mu     sync.RWMutex
+	trades []HistoricTrade
+	// next is where the next trade goes; once the buffer is full it is
+	// also the oldest trade
+	next int
+	full bool
+	// count and volume cover every trade recorded, including dropped ones
+	count  int64
+	volume fpdecimal.Decimal
+}
+
+// NewTradeHistory creates a TradeHistory keeping the last size trades, or
+// DefaultTradeHistorySize if size is not positive
+func NewTradeHistory(size int) *TradeHistory {
+	if size <= 0 {
+		size = DefaultTradeHistorySize
+	}
+	return &TradeHistory{trades: make([]HistoricTrade, size)}
+}
+
+// Record adds a trade, dropping the oldest one if the history is full
+func (h *TradeHistory) Record(price, quantity fpdecimal.Decimal, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.trades[h.next] = HistoricTrade{Price: price, Quantity: quantity, At: at}
+	h.count++
+	h.volume = h.volume.Add(quantity)
+	h.next++
+	if h.next == len(h.trades) {
+		h.next = 0
+		h.full = true
+	}
+}
+
+// WithTradeHistory makes the book record its trades in history as it
+// publishes them, one for every resting order an order matched
+func WithTradeHistory(history *TradeHistory) OrderBookOption {
+	return func(ob *OrderBook) {
+		ob.tradeHistory = history
+	}
+}
+
+// recordFills records a trade for every resting order done's order matched
+func (h *TradeHistory) recordFills(done *Done) {
+	now := time.Now()
+	for _, trade := range done.makerTrades() {
+		h.Record(trade.Price, trade.Quantity, now)
+	}
+}
+
+// Trades returns a copy of the recorded trades, oldest first
+func (h *TradeHistory) Trades() []HistoricTrade {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if !h.full {
+		return append([]HistoricTrade(nil), h.trades[:h.next]...)
+	}
+	trades := make([]HistoricTrade, 0, len(h.trades))
+	trades = append(trades, h.trades[h.next:]...)
+	return append(trades, h.trades[:h.next]...)
+}
+
+// Totals returns how many trades were ever recorded and their total
+// quantity, including trades the history no longer keeps
+func (h *TradeHistory) Totals() (count int64, volume fpdecimal.Decimal) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.count, h.volume
+}
+
+// VWAP returns the volume-weighted average price of the trades made at or
+// after since, their total quantity and how many there were. The price is
+// zero if there were none.
+func (h *TradeHistory) VWAP(since time.Time) (vwap, volume fpdecimal.Decimal, count int) {
+	notional := fpdecimal.Zero
+	for _, trade := range h.Trades() {
+		if trade.At.Before(since) {
+			continue
+		}
+		notional = notional.Add(trade.Price.Mul(trade.Quantity))
+		volume = volume.Add(trade.Quantity)
+		count++
+	}
+	if count == 0 || !volume.GreaterThan(fpdecimal.Zero) {
+		return fpdecimal.Zero, volume, count
+	}
+	return notional.Div(volume), volume, count
+}
+
+// TWAP returns the time-weighted average of the last trade price from since
+// until now, and how many trades were made in that time. Each price counts
+// for as long as it was the last one; a trade before since sets the price
+// the window starts with, otherwise the window starts at its first trade.
+// The price is zero if no price is known in the window.
+func (h *TradeHistory) TWAP(since, now time.Time) (twap fpdecimal.Decimal, count int) {
+	var (
+		weighted, total float64
+		price           fpdecimal.Decimal
+		from            time.Time
+		known           bool
+	)
+	for _, trade := range h.Trades() {
+		if trade.At.After(now) {
+			break
+		}
+		if trade.At.Before(since) {
+			price, from, known = trade.Price, since, true
+			continue
+		}
+		if known {
+			held := trade.At.Sub(from).Seconds()
+			weighted += price.Float64() * held
+			total += held
+		}
+		price, from, known = trade.Price, trade.At, true
+		count++
+	}
+	if !known {
+		return fpdecimal.Zero, count
+	}
+
+	held := now.Sub(from).Seconds()
+	weighted += price.Float64() * held
+	total += held
+	if total <= 0 {
+		// Every trade happened at now
+		return price, count
+	}
+	return fpdecimal.FromFloat(weighted / total), count
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTradeHistory(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	history := NewTradeHistory(3)
+	history.Record(fpdecimal.FromInt(90), fpdecimal.FromInt(5), at(0))
+	history.Record(fpdecimal.FromInt(100), fpdecimal.FromInt(1), at(10))
+	history.Record(fpdecimal.FromInt(110), fpdecimal.FromInt(3), at(20))
+	// The oldest trade makes room for this one
+	history.Record(fpdecimal.FromInt(120), fpdecimal.FromInt(1), at(40))
+
+	trades := history.Trades()
+	require.Len(t, trades, 3)
+	assert.Equal(t, "100.000", trades[0].Price.String())
+	assert.Equal(t, "120.000", trades[2].Price.String())
+
+	// The totals still count the dropped trade
+	total, totalVolume := history.Totals()
+	assert.Equal(t, int64(4), total)
+	assert.Equal(t, "10.000", totalVolume.String())
+
+	// (100*1 + 110*3 + 120*1) / 5
+	vwap, volume, count := history.VWAP(time.Time{})
+	assert.Equal(t, "110.000", vwap.String())
+	assert.Equal(t, "5.000", volume.String())
+	assert.Equal(t, 3, count)
+
+	vwap, volume, count = history.VWAP(at(15))
+	assert.Equal(t, "112.500", vwap.String())
+	assert.Equal(t, "4.000", volume.String())
+	assert.Equal(t, 2, count)
+
+	vwap, _, count = history.VWAP(at(50))
+	assert.True(t, vwap.Equal(fpdecimal.Zero))
+	assert.Zero(t, count)
+
+	// From 15 to 60: 100 for 5s, 110 for 20s and 120 for 20s
+	twap, count := history.TWAP(at(15), at(60))
+	assert.Equal(t, "113.333", twap.String())
+	assert.Equal(t, 2, count)
+
+	// Without a trade before it, the window starts at its first trade
+	twap, count = history.TWAP(at(5), at(30))
+	assert.Equal(t, "105.000", twap.String())
+	assert.Equal(t, 2, count)
+
+	// A window without trades has the last price before it
+	twap, count = history.TWAP(at(45), at(60))
+	assert.Equal(t, "120.000", twap.String())
+	assert.Zero(t, count)
+
+	twap, _ = NewTradeHistory(0).TWAP(at(0), at(60))
+	assert.True(t, twap.Equal(fpdecimal.Zero))
+}
+
+func TestWithTradeHistory(t *testing.T) {
+	setupMockSender(t)
+	ctx := context.Background()
+	history := NewTradeHistory(0)
+	book := NewOrderBook(newMockBackend(), WithTradeHistory(history))
+
+	process := func(order *Order, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+	process(NewLimitOrder("ask-1", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", ""))
+	process(NewLimitOrder("ask-2", Sell, fpdecimal.FromInt(2), fpdecimal.FromInt(101), GTC, "", ""))
+	assert.Empty(t, history.Trades())
+
+	// One trade per resting order matched
+	process(NewMarketOrder("buy", Buy, fpdecimal.FromInt(2), ""))
+	trades := history.Trades()
+	require.Len(t, trades, 2)
+	assert.Equal(t, "100.000", trades[0].Price.String())
+	assert.Equal(t, "1.000", trades[0].Quantity.String())
+	assert.Equal(t, "101.000", trades[1].Price.String())
+	assert.Equal(t, "1.000", trades[1].Quantity.String())
+
+	// A stop order activated by a trade has its fills recorded once
+	process(NewLimitOrder("ask-3", Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(102), GTC, "", ""))
+	process(NewStopLimitOrder("stop", Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(102), fpdecimal.FromInt(101), "", ""))
+	process(NewMarketOrder("buy-2", Buy, fpdecimal.FromInt(1), ""))
+	trades = history.Trades()
+	require.Len(t, trades, 4)
+	assert.Equal(t, "101.000", trades[2].Price.String())
+	assert.Equal(t, "102.000", trades[3].Price.String())
+}
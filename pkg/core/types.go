@@ -2,7 +2,7 @@ package core
 
 import (
 	"encoding/json"
-	"strings"
+	"time"
 
 	"github.com/erain9/matchingo/pkg/messaging"
 	"github.com/nikolaydubina/fpdecimal"
@@ -16,6 +16,10 @@ type TradeOrder struct {
 	IsQuote     bool
 	Quantity    fpdecimal.Decimal
 	UserAddress string
+	// MakerFee and TakerFee are what the maker and the taker owe on this
+	// fill under the book's FeeSchedule; zero on the taker's own entry
+	MakerFee fpdecimal.Decimal
+	TakerFee fpdecimal.Decimal
 }
 
 // MarshalJSON implements Marshaler interface
@@ -27,6 +31,8 @@ func (t *TradeOrder) MarshalJSON() ([]byte, error) {
 		Price       string `json:"price"`
 		Quantity    string `json:"quantity"`
 		UserAddress string `json:"userAddress"`
+		MakerFee    string `json:"makerFee,omitempty"`
+		TakerFee    string `json:"takerFee,omitempty"`
 	}{
 		OrderID:     t.OrderID,
 		Role:        t.Role,
@@ -34,6 +40,8 @@ func (t *TradeOrder) MarshalJSON() ([]byte, error) {
 		Price:       t.Price.String(),
 		Quantity:    t.Quantity.String(),
 		UserAddress: t.UserAddress,
+		MakerFee:    formatFee(t.MakerFee, 0),
+		TakerFee:    formatFee(t.TakerFee, 0),
 	}
 	return json.Marshal(customStruct)
 }
@@ -50,16 +58,61 @@ type Done struct {
 	Canceled []*Order
 	// Activations, e.g., stop orders converted to limit
 	Activated []*Order
-	// Remaining quantity left for the initial order
+	// StopActivated is set when the processed order is a stop order that was
+	// activated, on arrival or by a later trade
+	StopActivated bool
+	// CancelledByOCO is set when a resting order the processed order filled
+	// canceled the other leg of its OCO pair
+	CancelledByOCO bool
+	// STPTriggered is set when self-trade prevention canceled the processed
+	// order or a resting order it reached
+	STPTriggered bool
+	// HaltReason is set when the processed order's trade tripped the book's
+	// circuit breaker
+	HaltReason string
+	// Remaining quantity left for the initial order. For a quote order it
+	// is, like Quantity, the unspent budget in the quote currency.
 	Left fpdecimal.Decimal
-	// Total quantity processed for the initial order
+	// Total quantity processed for the initial order, in the base currency
+	// even for a quote order
 	Processed fpdecimal.Decimal
 	// Whether the order was stored in the book (e.g., partial fill GTC)
 	Stored bool
+	// Replenished is set when a resting iceberg order filled by the order
+	// showed more of its hidden reserve
+	Replenished bool
+	// Amended is set when the Done reports an amendment of the resting
+	// order by AmendOrder rather than its processing
+	Amended bool
+	// When matching of a limit or market order started and finished; zero
+	// for other orders
+	MatchStartedAt   time.Time
+	MatchCompletedAt time.Time
+	// seq numbers the published Done objects of one book in the order they
+	// were sent, starting from 1
+	seq uint64
+	// pricePrecision and qtyPrecision are the book's display precisions
+	pricePrecision int
+	qtyPrecision   int
+}
+
+// changeDone creates an unnumbered Done describing a change to the resting
+// order, for the event channel
+func changeDone(order *Order) *Done {
+	return &Done{
+		Order:     order,
+		Quantity:  order.OriginalQty(),
+		Trades:    make([]TradeOrder, 0),
+		Canceled:  make([]*Order, 0),
+		Activated: make([]*Order, 0),
+		Left:      order.RemainingQty(),
+		Processed: fpdecimal.Zero,
+		Stored:    order.State() != StateCanceled,
+	}
 }
 
 // newDone creates a new Done object for the given order
-func newDone(order *Order) *Done {
+func (ob *OrderBook) newDone(order *Order) *Done {
 	return &Done{
 		Order:     order,
 		Quantity:  order.OriginalQty(),
@@ -69,6 +122,32 @@ func newDone(order *Order) *Done {
 		Left:      fpdecimal.Zero,
 		Processed: fpdecimal.Zero,
 		Stored:    false,
+
+		pricePrecision: ob.instrument.PricePrecision,
+		qtyPrecision:   ob.instrument.QtyPrecision,
+	}
+}
+
+// Seq returns the sequence number of d within its order book, given when d
+// is published, or zero if it was not
+func (d *Done) Seq() uint64 {
+	return d.seq
+}
+
+// MatchDuration returns how long matching the order took, or zero if it was
+// not timed
+func (d *Done) MatchDuration() time.Duration {
+	if d.MatchStartedAt.IsZero() || d.MatchCompletedAt.IsZero() {
+		return 0
+	}
+	return d.MatchCompletedAt.Sub(d.MatchStartedAt)
+}
+
+// completeMatch records that matching finished now, unless it already did or
+// was never timed
+func (d *Done) completeMatch() {
+	if !d.MatchStartedAt.IsZero() && d.MatchCompletedAt.IsZero() {
+		d.MatchCompletedAt = time.Now()
 	}
 }
 
@@ -134,9 +213,29 @@ func (d *Done) appendCanceled(order *Order) {
 	d.Canceled = append(d.Canceled, order)
 }
 
-// appendActivated adds an activated order to the Done object
+// appendActivated adds an activated order to the Done object. If it is the
+// processed order itself, the Done is marked as triggered and gets a
+// zero-quantity taker entry at the stop price marking the activation.
 func (d *Done) appendActivated(order *Order) {
 	d.Activated = append(d.Activated, order)
+	if d.Order == nil || d.Order.ID() != order.ID() {
+		return
+	}
+	d.StopActivated = true
+	d.Trades = append(d.Trades, TradeOrder{
+		OrderID:     order.ID(),
+		Role:        TAKER,
+		Price:       order.StopPrice(),
+		IsQuote:     order.IsQuote(),
+		Quantity:    fpdecimal.Zero,
+		UserAddress: order.UserAddress(),
+	})
+}
+
+// IsActivated reports whether the processed order is a stop order that was
+// triggered
+func (d *Done) IsActivated() bool {
+	return d.StopActivated
 }
 
 // SetLeftQuantity updates the left quantity and recalculates processed quantity
@@ -162,7 +261,7 @@ func (d *Done) ToMessagingDoneMessage() *messaging.DoneMessage {
 		return nil
 	}
 
-	msgTrades := convertTrades(d.Trades)
+	msgTrades := convertTrades(d.Trades, d.pricePrecision, d.qtyPrecision)
 
 	// Convert canceled orders from []*Order to []string
 	msgCanceled := make([]string, len(d.Canceled))
@@ -176,33 +275,45 @@ func (d *Done) ToMessagingDoneMessage() *messaging.DoneMessage {
 		msgActivated[i] = order.ID()
 	}
 
-	// Format decimal values consistently with 3 decimal places
-	formatDecimal := func(d fpdecimal.Decimal) string {
-		// Ensure decimal is formatted with 3 decimal places
-		val := d.String()
-		parts := strings.Split(val, ".")
-		if len(parts) == 1 {
-			// No decimal part, add .000
-			return val + ".000"
-		} else if len(parts[1]) < 3 {
-			// Fewer than 3 decimal places, add zeroes
-			return val + strings.Repeat("0", 3-len(parts[1]))
-		}
-		return val
+	// Quantities are formatted with the book's precision
+	formatDecimal := func(q fpdecimal.Decimal) string {
+		return format(q, d.qtyPrecision)
+	}
+
+	var pegType, effectivePrice string
+	if d.Order.IsPegged() {
+		pegType = string(d.Order.PegType())
+		effectivePrice = format(d.Order.Price(), d.pricePrecision)
 	}
 
 	return &messaging.DoneMessage{
-		OrderID:      d.Order.ID(),
-		ExecutedQty:  formatDecimal(d.Processed),
-		RemainingQty: formatDecimal(d.Left),
-		Trades:       msgTrades,
-		Canceled:     msgCanceled,
-		Activated:    msgActivated,
-		Stored:       d.Stored,
-		Quantity:     formatDecimal(d.Quantity),
-		Processed:    formatDecimal(d.Processed),
-		Left:         formatDecimal(d.Left),
-		UserAddress:  d.Order.UserAddress(),
+		OrderID:         d.Order.ID(),
+		ExecutedQty:     formatDecimal(d.Processed),
+		RemainingQty:    formatDecimal(d.Left),
+		Trades:          msgTrades,
+		Canceled:        msgCanceled,
+		Activated:       msgActivated,
+		Stored:          d.Stored,
+		StopActivated:   d.StopActivated,
+		CancelledByOCO:  d.CancelledByOCO,
+		STPTriggered:    d.STPTriggered,
+		HaltReason:      d.HaltReason,
+		Quantity:        formatDecimal(d.Quantity),
+		Processed:       formatDecimal(d.Processed),
+		Left:            formatDecimal(d.Left),
+		UserAddress:     d.Order.UserAddress(),
+		SequenceNumber:  d.seq,
+		MatchDurationMs: float64(d.MatchDuration()) / float64(time.Millisecond),
+		PegType:         pegType,
+		EffectivePrice:  effectivePrice,
+		Side:            d.Order.Side().String(),
+		Price:           format(d.Order.Price(), d.pricePrecision),
+		OrderType:       string(d.Order.OrderType()),
+		TimeInForce:     string(d.Order.TIF()),
+		ExpiresAt:       d.Order.ExpiresAt(),
+		Amended:         d.Amended,
+		Iceberg:         d.Order.IsIceberg(),
+		OCOID:           d.Order.OCO(),
 	}
 }
 
@@ -233,20 +344,22 @@ func (d *Done) MarshalJSON() ([]byte, error) {
 	}
 
 	return json.Marshal(struct {
-		Order     *TradeOrder  `json:"order"`
-		Trades    []TradeOrder `json:"trades"`
-		Canceled  []string     `json:"canceled"`
-		Activated []string     `json:"activated"`
-		Left      string       `json:"left"`
-		Processed string       `json:"processed"`
-		Stored    bool         `json:"stored"`
+		Order         *TradeOrder  `json:"order"`
+		Trades        []TradeOrder `json:"trades"`
+		Canceled      []string     `json:"canceled"`
+		Activated     []string     `json:"activated"`
+		Left          string       `json:"left"`
+		Processed     string       `json:"processed"`
+		Stored        bool         `json:"stored"`
+		StopActivated bool         `json:"stop_activated"`
 	}{
-		Order:     d.Order.ToSimple(),
-		Trades:    d.tradesToSlice(),
-		Canceled:  canceledIDs,
-		Activated: activatedIDs,
-		Left:      d.Left.String(),
-		Processed: d.Processed.String(),
-		Stored:    d.Stored,
+		Order:         d.Order.ToSimple(),
+		Trades:        d.tradesToSlice(),
+		Canceled:      canceledIDs,
+		Activated:     activatedIDs,
+		Left:          d.Left.String(),
+		Processed:     d.Processed.String(),
+		Stored:        d.Stored,
+		StopActivated: d.StopActivated,
 	})
 }
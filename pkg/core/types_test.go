@@ -1,8 +1,11 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/nikolaydubina/fpdecimal"
 	"github.com/stretchr/testify/assert"
@@ -37,7 +40,7 @@ func TestNewDone(t *testing.T) {
 	order, err := NewLimitOrder(orderID, Buy, quantity, price, GTC, "", "test_user")
 	require.NoError(t, err)
 
-	done := newDone(order)
+	done := new(OrderBook).newDone(order)
 
 	if done == nil {
 		t.Fatal("Expected non-nil Done object")
@@ -81,7 +84,7 @@ func TestDone_GetTradeOrder(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create a Done object
-	done := newDone(order)
+	done := new(OrderBook).newDone(order)
 
 	// Initially, there should be no trades
 	tradeOrder := done.GetTradeOrder(orderID)
@@ -120,7 +123,7 @@ func TestDone_TradesToSlice(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create a Done object
-	done := newDone(order)
+	done := new(OrderBook).newDone(order)
 
 	// Initially, there should be no trades
 	trades := done.tradesToSlice()
@@ -151,7 +154,7 @@ func TestDone_CancelAndActivation(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create a Done object
-	done := newDone(order)
+	done := new(OrderBook).newDone(order)
 
 	// Initially, there should be no canceled or activated orders
 	if len(done.Canceled) != 0 {
@@ -198,7 +201,7 @@ func TestDone_SetLeftQuantity(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create a Done object
-	done := newDone(order)
+	done := new(OrderBook).newDone(order)
 
 	// Initially, left quantity should be zero and processed should be zero
 	if !done.Left.Equal(fpdecimal.Zero) {
@@ -233,7 +236,7 @@ func TestDone_MarshalJSON(t *testing.T) {
 	order.SetMaker()
 
 	// Create a Done object
-	done := newDone(order)
+	done := new(OrderBook).newDone(order)
 
 	// Add some data to the Done object
 	cancelID := "cancel-123"
@@ -296,7 +299,7 @@ func TestDone_ToMessagingDoneMessage(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create a Done object
-	done := newDone(order)
+	done := new(OrderBook).newDone(order)
 
 	// Add some data
 	leftQty := fpdecimal.FromFloat(3.0)
@@ -333,3 +336,70 @@ func TestDone_ToMessagingDoneMessage(t *testing.T) {
 	assert.Equal(t, "7.000", msg.Trades[1].Quantity)
 	assert.Equal(t, userAddress, msg.Trades[1].UserAddress)
 }
+
+func TestDone_ToMessagingDoneMessage_Precision(t *testing.T) {
+	book := NewOrderBook(newMockBackend(), WithInstrumentConfig(InstrumentConfig{PricePrecision: 8, QtyPrecision: 2}))
+	order, err := NewLimitOrder("test-123", Buy, fpdecimal.FromInt(10), fpdecimal.FromFloat(100.5), GTC, "", "test_user")
+	require.NoError(t, err)
+	maker, err := NewLimitOrder("match-1", Sell, fpdecimal.FromInt(7), fpdecimal.FromFloat(100.5), GTC, "", "test_user")
+	require.NoError(t, err)
+	maker.SetMaker()
+
+	done := book.newDone(order)
+	done.appendOrder(maker, fpdecimal.FromFloat(6.5), fpdecimal.FromFloat(100.5))
+	done.Processed = fpdecimal.FromFloat(6.125)
+
+	msg := done.ToMessagingDoneMessage()
+	require.Len(t, msg.Trades, 2)
+	assert.Equal(t, "match-1", msg.Trades[1].OrderID)
+	assert.Equal(t, "100.50000000", msg.Trades[1].Price)
+	assert.Equal(t, "6.50", msg.Trades[1].Quantity)
+	// Rounded half away from zero
+	assert.Equal(t, "6.13", msg.Processed)
+	assert.Equal(t, "10.00", msg.Quantity)
+	assert.Equal(t, "100.50000000", done.FormatVWAP())
+
+	assert.Equal(t, "0.00100000", book.FormatPrice(fpdecimal.FromFloat(0.001)))
+	assert.Equal(t, "-1.50", book.FormatQty(fpdecimal.FromFloat(-1.495)))
+	// Zero keeps the engine's precision
+	assert.Equal(t, "100.500", new(OrderBook).FormatPrice(fpdecimal.FromFloat(100.5)))
+}
+
+func TestDone_MatchDuration(t *testing.T) {
+	sender := setupMockSender(t)
+	ctx := context.Background()
+	book := NewOrderBook(newMockBackend())
+
+	// Alternate resting asks with market buys that take them
+	var dones []*Done
+	for i := 0; i < 50; i++ {
+		ask, err := NewLimitOrder(fmt.Sprintf("ask-%d", i), Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(100), GTC, "", "test_user")
+		require.NoError(t, err)
+		done, err := book.Process(ctx, ask)
+		require.NoError(t, err)
+		dones = append(dones, done)
+
+		bid, err := NewMarketOrder(fmt.Sprintf("bid-%d", i), Buy, fpdecimal.FromInt(1), "test_user")
+		require.NoError(t, err)
+		done, err = book.Process(ctx, bid)
+		require.NoError(t, err)
+		dones = append(dones, done)
+	}
+
+	require.Len(t, dones, 100)
+	for _, done := range dones {
+		assert.Greater(t, done.MatchDuration(), time.Duration(0), done.Order.ID())
+		assert.Less(t, done.MatchDuration(), time.Second, done.Order.ID())
+		assert.False(t, done.MatchCompletedAt.Before(done.MatchStartedAt))
+	}
+
+	// Done messages carry the duration of the match they report
+	msgs := sender.GetSentMessages()
+	require.NotEmpty(t, msgs)
+	for _, msg := range msgs {
+		assert.Greater(t, msg.MatchDurationMs, 0.0, msg.OrderID)
+	}
+
+	// Orders that were never matched are not timed
+	assert.Zero(t, (&Done{}).MatchDuration())
+}
@@ -0,0 +1,257 @@
+package queue
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/erain9/matchingo/pkg/otel"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// DefaultConsumerGroup is the consumer group a QueueMessageConsumer
+	// joins when its config names none
+	DefaultConsumerGroup = "matchingo-done-consumer"
+	// DefaultBatchSize is the most messages handed to a BatchProcessor at
+	// once when the config sets no batch size
+	DefaultBatchSize = 100
+	// DefaultTopicRefreshInterval is how often a consumer with a topic
+	// prefix looks for the topics of new order books when the config sets
+	// no interval
+	DefaultTopicRefreshInterval = 10 * time.Second
+)
+
+// ConsumerConfig configures a QueueMessageConsumer. Zero fields take their
+// defaults.
+type ConsumerConfig struct {
+	// GroupID is the Kafka consumer group to join
+	GroupID string
+	// BatchSize is the most messages processed together. A batch holds
+	// the messages already fetched when it starts, so it is smaller while
+	// the consumer keeps up.
+	BatchSize int
+	// NumWorkers is how many goroutines decode the messages of a batch;
+	// it defaults to the number of CPUs
+	NumWorkers int
+	// TopicPrefix consumes the topics messaging.PrefixRouter sends each
+	// order book's messages to with this prefix, instead of the single
+	// topic set with SetTopic
+	TopicPrefix string
+	// TopicRefreshInterval is how often the topics of new order books are
+	// looked for when TopicPrefix is set
+	TopicRefreshInterval time.Duration
+}
+
+// withDefaults returns c with its zero fields set to their defaults
+func (c ConsumerConfig) withDefaults() ConsumerConfig {
+	if c.GroupID == "" {
+		c.GroupID = DefaultConsumerGroup
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultBatchSize
+	}
+	if c.NumWorkers <= 0 {
+		c.NumWorkers = runtime.NumCPU()
+	}
+	if c.TopicRefreshInterval <= 0 {
+		c.TopicRefreshInterval = DefaultTopicRefreshInterval
+	}
+	return c
+}
+
+// BatchProcessor handles the done messages consumed from Kafka, a batch at
+// a time, in the order they were produced within a partition. Messages that
+// cannot be decoded are left out of the batch. Batches of different
+// partitions may be processed at the same time; PartitionFromContext tells
+// which partition a batch was read from.
+type BatchProcessor interface {
+	ProcessBatch(ctx context.Context, msgs []*messaging.DoneMessage) error
+}
+
+// Partition names the topic partition a batch of messages was read from
+type Partition struct {
+	Topic string
+	ID    int32
+}
+
+// partitionKey is the context key of the Partition of a batch
+type partitionKey struct{}
+
+// ContextWithPartition returns a copy of ctx carrying partition
+func ContextWithPartition(ctx context.Context, partition Partition) context.Context {
+	return context.WithValue(ctx, partitionKey{}, partition)
+}
+
+// PartitionFromContext returns the partition the batch handed to a
+// BatchProcessor with ctx was read from. It reports false for batches of
+// consumers without partitions.
+func PartitionFromContext(ctx context.Context) (Partition, bool) {
+	partition, ok := ctx.Value(partitionKey{}).(Partition)
+	return partition, ok
+}
+
+// LoggingBatchProcessor logs every message of each batch
+type LoggingBatchProcessor struct {
+	logger zerolog.Logger
+}
+
+// NewLoggingBatchProcessor creates a LoggingBatchProcessor writing to logger
+func NewLoggingBatchProcessor(logger zerolog.Logger) *LoggingBatchProcessor {
+	return &LoggingBatchProcessor{logger: logger}
+}
+
+// ProcessBatch logs msgs
+func (p *LoggingBatchProcessor) ProcessBatch(ctx context.Context, msgs []*messaging.DoneMessage) error {
+	p.logger.Debug().Int("messages", len(msgs)).Msg("Received done message batch")
+	for _, msg := range msgs {
+		p.logger.Info().
+			Str("order_id", msg.OrderID).
+			Str("order_book", msg.OrderBookName).
+			Str("side", msg.Side).
+			Str("price", msg.Price).
+			Uint64("sequence_number", msg.SequenceNumber).
+			Str("executed_qty", msg.ExecutedQty).
+			Str("remaining_qty", msg.RemainingQty).
+			Strs("canceled", msg.Canceled).
+			Strs("activated", msg.Activated).
+			Bool("stored", msg.Stored).
+			Bool("stop_activated", msg.StopActivated).
+			Bool("amended", msg.Amended).
+			Bool("cancelled_by_oco", msg.CancelledByOCO).
+			Bool("stp_triggered", msg.STPTriggered).
+			Str("halt_reason", msg.HaltReason).
+			Str("quantity", msg.Quantity).
+			Str("processed", msg.Processed).
+			Str("left", msg.Left).
+			Interface("trades", msg.Trades).
+			Msg("Received done message")
+	}
+	return nil
+}
+
+// NoopBatchProcessor discards every batch
+type NoopBatchProcessor struct{}
+
+// ProcessBatch does nothing
+func (NoopBatchProcessor) ProcessBatch(context.Context, []*messaging.DoneMessage) error {
+	return nil
+}
+
+// batchHandler is the sarama.ConsumerGroupHandler that reads each claimed
+// partition in batches
+type batchHandler struct {
+	config    ConsumerConfig
+	processor BatchProcessor
+}
+
+// Setup is run at the start of a new session, before ConsumeClaim
+func (h *batchHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited
+func (h *batchHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim processes the messages of one partition until the session
+// ends. Each batch is every message already fetched, up to BatchSize, so a
+// message never waits for a batch to fill.
+func (h *batchHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	batch := make([]*sarama.ConsumerMessage, 0, h.config.BatchSize)
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			batch = append(batch[:0], msg)
+		fill:
+			for len(batch) < h.config.BatchSize {
+				select {
+				case msg, ok := <-claim.Messages():
+					if !ok {
+						break fill
+					}
+					batch = append(batch, msg)
+				default:
+					break fill
+				}
+			}
+			h.processBatch(session, claim, batch)
+
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// processBatch decodes and processes batch, then marks it consumed. A batch
+// the processor fails is logged and not retried, as single messages were not.
+func (h *batchHandler) processBatch(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, batch []*sarama.ConsumerMessage) {
+	ctx := ContextWithPartition(session.Context(), Partition{Topic: claim.Topic(), ID: claim.Partition()})
+	logger := logging.FromContext(ctx).With().
+		Str("topic", claim.Topic()).
+		Int32("partition", claim.Partition()).
+		Logger()
+	if msgs := decodeBatch(logger, batch, h.config.NumWorkers); len(msgs) > 0 {
+		if err := h.processor.ProcessBatch(ctx, msgs); err != nil {
+			logger.Error().Err(err).Int("messages", len(msgs)).Msg("Failed to process message batch")
+		}
+	}
+
+	last := batch[len(batch)-1]
+	session.MarkMessage(last, "")
+
+	// The offset committed for a partition is the next one to consume
+	lag := claim.HighWaterMarkOffset() - (last.Offset + 1)
+	if lag < 0 {
+		lag = 0
+	}
+	otel.GetKafkaMetrics().RecordConsumerLag(ctx, h.config.GroupID, claim.Topic(), claim.Partition(), lag)
+}
+
+// decodeBatch unmarshals msgs on up to workers goroutines. The decoded
+// messages keep the order of msgs; those that fail to decode are logged and
+// dropped.
+func decodeBatch(logger zerolog.Logger, msgs []*sarama.ConsumerMessage, workers int) []*messaging.DoneMessage {
+	decoded := make([]*messaging.DoneMessage, len(msgs))
+	if workers > len(msgs) {
+		workers = len(msgs)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				doneMsg := &messaging.DoneMessage{}
+				if err := UnmarshalDoneMessage(msgs[i], doneMsg); err != nil {
+					logger.Error().Err(err).Int64("offset", msgs[i].Offset).Msg("Failed to unmarshal message")
+					continue
+				}
+				decoded[i] = doneMsg
+			}
+		}()
+	}
+	for i := range msgs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	result := decoded[:0]
+	for _, doneMsg := range decoded {
+		if doneMsg != nil {
+			result = append(result, doneMsg)
+		}
+	}
+	return result
+}
@@ -2,15 +2,16 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/IBM/sarama"
-	orderbookpb "github.com/erain9/matchingo/pkg/api/proto"
 	"github.com/erain9/matchingo/pkg/messaging"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
-	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -34,6 +35,7 @@ func SetTopic(topicName string) {
 type QueueMessageSender struct {
 	producer   sarama.AsyncProducer
 	propagator propagation.TextMapPropagator
+	serializer messaging.Serializer
 }
 
 // NewQueueMessageSender creates a new QueueMessageSender with an initialized Kafka producer
@@ -85,51 +87,26 @@ func NewQueueMessageSender() (*QueueMessageSender, error) {
 	return &QueueMessageSender{
 		producer:   producer,
 		propagator: otel.GetTextMapPropagator(),
+		serializer: messaging.ProtoSerializer{},
 	}, nil
 }
 
 // SendDoneMessage sends the DoneMessage to the Kafka queue
 func (q *QueueMessageSender) SendDoneMessage(ctx context.Context, done *messaging.DoneMessage) error {
-	// Convert to proto message
-	protoMsg := &orderbookpb.DoneMessage{
-		OrderId:           done.OrderID,
-		ExecutedQuantity:  done.ExecutedQty,
-		RemainingQuantity: done.RemainingQty,
-		Canceled:          done.Canceled,
-		Activated:         done.Activated,
-		Stored:            done.Stored,
-		Quantity:          done.Quantity,
-		Processed:         done.Processed,
-		Left:              done.Left,
-		UserAddress:       done.UserAddress,
-	}
-
-	// Convert trades to proto format
-	if len(done.Trades) > 0 {
-		protoMsg.Trades = make([]*orderbookpb.Trade, 0, len(done.Trades))
-		for _, trade := range done.Trades {
-			protoMsg.Trades = append(protoMsg.Trades, &orderbookpb.Trade{
-				OrderId:     trade.OrderID,
-				Role:        trade.Role,
-				Price:       trade.Price,
-				Quantity:    trade.Quantity,
-				IsQuote:     trade.IsQuote,
-				UserAddress: trade.UserAddress,
-			})
-		}
-	}
-
-	// Serialize to protobuf
-	messageBytes, err := proto.Marshal(protoMsg)
+	messageBytes, err := q.serializer.Marshal(done)
 	if err != nil {
 		return fmt.Errorf("failed to marshal done message: %v", err)
 	}
 
 	// Create a Kafka producer message
 	msg := &sarama.ProducerMessage{
-		Topic:   topic,
-		Value:   sarama.ByteEncoder(messageBytes),
-		Headers: []sarama.RecordHeader{},
+		Topic: topic,
+		Key:   sarama.ByteEncoder(messaging.PartitionKey(done)),
+		Value: sarama.ByteEncoder(messageBytes),
+		Headers: []sarama.RecordHeader{{
+			Key:   []byte(messaging.ContentTypeHeader),
+			Value: []byte(q.serializer.ContentType()),
+		}},
 	}
 
 	// Inject OpenTelemetry context into headers
@@ -151,6 +128,11 @@ func (q *QueueMessageSender) SendDoneMessage(ctx context.Context, done *messagin
 	}
 }
 
+// SendCancelMessage sends the CancelMessage to the Kafka queue wrapped in a DoneMessage
+func (q *QueueMessageSender) SendCancelMessage(ctx context.Context, cancel *messaging.CancelMessage) error {
+	return q.SendDoneMessage(ctx, cancel.ToDoneMessage())
+}
+
 // Close closes the Kafka producer
 func (q *QueueMessageSender) Close() error {
 	if q.producer != nil {
@@ -160,85 +142,162 @@ func (q *QueueMessageSender) Close() error {
 }
 
 // QueueMessageConsumer implements the MessageConsumer interface
-// for consuming messages from Kafka
+// for consuming messages from Kafka as a member of a consumer group
 type QueueMessageConsumer struct {
-	consumer sarama.Consumer
-	done     chan struct{}
+	client sarama.Client
+	group  sarama.ConsumerGroup
+	config ConsumerConfig
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-// NewQueueMessageConsumer creates a new Kafka consumer
-func NewQueueMessageConsumer() (*QueueMessageConsumer, error) {
-	consumer, err := sarama.NewConsumer([]string{brokerList}, nil)
+// NewQueueMessageConsumer creates a new Kafka consumer that joins the
+// consumer group named by config. A group without committed offsets starts
+// from the newest message.
+func NewQueueMessageConsumer(config ConsumerConfig) (*QueueMessageConsumer, error) {
+	config = config.withDefaults()
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+
+	client, err := sarama.NewClient([]string{brokerList}, saramaConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Kafka consumer: %v", err)
+		return nil, fmt.Errorf("failed to create Kafka client: %v", err)
+	}
+	group, err := sarama.NewConsumerGroupFromClient(config.GroupID, client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create Kafka consumer group: %v", err)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return &QueueMessageConsumer{
-		consumer: consumer,
-		done:     make(chan struct{}),
+		client: client,
+		group:  group,
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
 	}, nil
 }
 
-// Close closes the Kafka consumer
+// Close stops consuming and leaves the consumer group, committing the
+// offsets of the batches already processed
 func (q *QueueMessageConsumer) Close() error {
-	close(q.done)
-	return q.consumer.Close()
+	q.cancel()
+	return errors.Join(q.group.Close(), q.client.Close())
 }
 
-// ConsumeDoneMessages starts consuming DoneMessages from Kafka
-func (q *QueueMessageConsumer) ConsumeDoneMessages(handler func(*messaging.DoneMessage) error) error {
-	partitionConsumer, err := q.consumer.ConsumePartition(topic, 0, sarama.OffsetNewest)
-	if err != nil {
-		return fmt.Errorf("failed to start consumer for partition: %v", err)
+// ConsumeDoneMessages consumes DoneMessages from Kafka until the consumer is
+// closed, handing them to processor in batches of up to config.BatchSize.
+// With a topic prefix, it rejoins the group whenever an order book's topic
+// appears or goes away.
+func (q *QueueMessageConsumer) ConsumeDoneMessages(processor BatchProcessor) error {
+	handler := &batchHandler{
+		config:    q.config,
+		processor: processor,
 	}
-	defer partitionConsumer.Close()
 
 	for {
-		select {
-		case msg := <-partitionConsumer.Messages():
-			// Deserialize the protobuf message
-			protoMsg := &orderbookpb.DoneMessage{}
-			if err := proto.Unmarshal(msg.Value, protoMsg); err != nil {
-				fmt.Printf("Failed to unmarshal message: %v\n", err)
+		topics, err := q.topics()
+		if err != nil {
+			return fmt.Errorf("failed to list done message topics: %v", err)
+		}
+		if len(topics) == 0 {
+			// No order book has published yet
+			select {
+			case <-q.ctx.Done():
+				return nil
+			case <-time.After(q.config.TopicRefreshInterval):
 				continue
 			}
+		}
 
-			// Convert to DoneMessage
-			doneMsg := &messaging.DoneMessage{
-				OrderID:      protoMsg.OrderId,
-				ExecutedQty:  protoMsg.ExecutedQuantity,
-				RemainingQty: protoMsg.RemainingQuantity,
-				Canceled:     protoMsg.Canceled,
-				Activated:    protoMsg.Activated,
-				Stored:       protoMsg.Stored,
-				Quantity:     protoMsg.Quantity,
-				Processed:    protoMsg.Processed,
-				Left:         protoMsg.Left,
-				UserAddress:  protoMsg.UserAddress,
+		// Consume returns whenever the group rebalances or the topics
+		// change, and is called again to rejoin it
+		ctx, stop := q.watchTopics(topics)
+		err = q.group.Consume(ctx, topics, handler)
+		stop()
+		if err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return nil
 			}
+			return fmt.Errorf("failed to consume done messages: %v", err)
+		}
+		if q.ctx.Err() != nil {
+			return nil
+		}
+	}
+}
 
-			// Convert trades
-			if len(protoMsg.Trades) > 0 {
-				doneMsg.Trades = make([]messaging.Trade, 0, len(protoMsg.Trades))
-				for _, trade := range protoMsg.Trades {
-					doneMsg.Trades = append(doneMsg.Trades, messaging.Trade{
-						OrderID:     trade.OrderId,
-						Role:        trade.Role,
-						Price:       trade.Price,
-						Quantity:    trade.Quantity,
-						IsQuote:     trade.IsQuote,
-						UserAddress: trade.UserAddress,
-					})
-				}
-			}
+// topics returns the topics the consumer reads: the package topic, or the
+// order book topics starting with the configured prefix
+func (q *QueueMessageConsumer) topics() ([]string, error) {
+	if q.config.TopicPrefix == "" {
+		return []string{topic}, nil
+	}
 
-			// Process the message
-			if err := handler(doneMsg); err != nil {
-				fmt.Printf("Failed to process message: %v\n", err)
+	if err := q.client.RefreshMetadata(); err != nil {
+		return nil, err
+	}
+	all, err := q.client.Topics()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := messaging.PrefixRouter{Prefix: q.config.TopicPrefix}.Route("")
+	var topics []string
+	for _, name := range all {
+		if strings.HasPrefix(name, prefix) {
+			topics = append(topics, name)
+		}
+	}
+	slices.Sort(topics)
+	return topics, nil
+}
+
+// watchTopics returns the context of a group session reading topics. It is
+// canceled when the consumer closes or, with a topic prefix, when the
+// prefixed topics no longer match topics.
+func (q *QueueMessageConsumer) watchTopics(topics []string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(q.ctx)
+	if q.config.TopicPrefix == "" {
+		return ctx, cancel
+	}
+
+	go func() {
+		ticker := time.NewTicker(q.config.TopicRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// A failed lookup keeps the current topics until the next one
+				current, err := q.topics()
+				if err == nil && !slices.Equal(current, topics) {
+					cancel()
+					return
+				}
 			}
+		}
+	}()
+	return ctx, cancel
+}
 
-		case <-q.done:
-			return nil
+// UnmarshalDoneMessage decodes msg with the serializer named by its
+// content-type header. Messages without the header predate it and are protobuf.
+func UnmarshalDoneMessage(msg *sarama.ConsumerMessage, done *messaging.DoneMessage) error {
+	contentType := messaging.ContentTypeProtobuf
+	for _, header := range msg.Headers {
+		if header != nil && string(header.Key) == messaging.ContentTypeHeader {
+			contentType = string(header.Value)
+			break
 		}
 	}
+
+	serializer, err := messaging.SerializerForContentType(contentType)
+	if err != nil {
+		return err
+	}
+	return serializer.Unmarshal(msg.Value, done)
 }
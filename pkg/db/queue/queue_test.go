@@ -1,87 +1,23 @@
 package queue
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/IBM/sarama"
 	orderbookpb "github.com/erain9/matchingo/pkg/api/proto"
 	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
 )
 
-type mockConsumer struct {
-	messages chan *sarama.ConsumerMessage
-	errors   chan *sarama.ConsumerError
-}
-
-func (m *mockConsumer) ConsumePartition(topic string, partition int32, offset int64) (sarama.PartitionConsumer, error) {
-	return &mockPartitionConsumer{
-		messages: m.messages,
-		errors:   m.errors,
-	}, nil
-}
-
-func (m *mockConsumer) Topics() ([]string, error) {
-	return []string{}, nil
-}
-
-func (m *mockConsumer) Partitions(topic string) ([]int32, error) {
-	return []int32{}, nil
-}
-
-func (m *mockConsumer) HighWaterMarks() map[string]map[int32]int64 {
-	return nil
-}
-
-func (m *mockConsumer) Close() error {
-	close(m.messages)
-	close(m.errors)
-	return nil
-}
-
-func (m *mockConsumer) Pause(topicPartitions map[string][]int32) {}
-
-func (m *mockConsumer) Resume(topicPartitions map[string][]int32) {}
-
-func (m *mockConsumer) PauseAll() {}
-
-func (m *mockConsumer) ResumeAll() {}
-
-type mockPartitionConsumer struct {
-	messages chan *sarama.ConsumerMessage
-	errors   chan *sarama.ConsumerError
-}
-
-func (m *mockPartitionConsumer) AsyncClose() {}
-
-func (m *mockPartitionConsumer) Close() error {
-	return nil
-}
-
-func (m *mockPartitionConsumer) Messages() <-chan *sarama.ConsumerMessage {
-	return m.messages
-}
-
-func (m *mockPartitionConsumer) Errors() <-chan *sarama.ConsumerError {
-	return m.errors
-}
-
-func (m *mockPartitionConsumer) HighWaterMarkOffset() int64 {
-	return 0
-}
-
-func (m *mockPartitionConsumer) IsPaused() bool {
-	return false
-}
-
-func (m *mockPartitionConsumer) Pause() {}
-
-func (m *mockPartitionConsumer) Resume() {}
-
 func TestQueueMessageSender_SendDoneMessage(t *testing.T) {
 	// Create a test message
 	userAddress := "0x1234567890123456789012345678901234567890"
@@ -181,56 +117,228 @@ func TestQueueMessageConsumer_ConsumeDoneMessages(t *testing.T) {
 		UserAddress: userAddress,
 	}
 
-	// Create a mock consumer
-	mockConsumer := &mockConsumer{
-		messages: make(chan *sarama.ConsumerMessage, 1),
-		errors:   make(chan *sarama.ConsumerError, 1),
+	// Publish 1000 messages to a mock broker
+	const total = 1000
+	startMockKafka(t, total, func(offset int64) *messaging.DoneMessage {
+		if offset == 0 {
+			return expectedMessage
+		}
+		return &messaging.DoneMessage{OrderID: fmt.Sprintf("order-%d", offset)}
+	})
+
+	consumer, err := NewQueueMessageConsumer(ConsumerConfig{NumWorkers: 4})
+	require.NoError(t, err)
+
+	processor := &collectingProcessor{}
+	consumed := make(chan error, 1)
+	go func() {
+		consumed <- consumer.ConsumeDoneMessages(processor)
+	}()
+
+	require.Eventually(t, func() bool { return processor.count() == total }, 10*time.Second, 10*time.Millisecond)
+	require.NoError(t, consumer.Close())
+	require.NoError(t, <-consumed)
+
+	msgs := processor.messages()
+	require.Len(t, msgs, total)
+	msg := msgs[0]
+	assert.Equal(t, expectedMessage.OrderID, msg.OrderID)
+	assert.Equal(t, expectedMessage.ExecutedQty, msg.ExecutedQty)
+	assert.Equal(t, expectedMessage.RemainingQty, msg.RemainingQty)
+	assert.Equal(t, expectedMessage.Quantity, msg.Quantity)
+	assert.Equal(t, expectedMessage.Processed, msg.Processed)
+	assert.Equal(t, expectedMessage.Left, msg.Left)
+	assert.Equal(t, expectedMessage.Stored, msg.Stored)
+	assert.Equal(t, expectedMessage.UserAddress, msg.UserAddress)
+	assert.Equal(t, len(expectedMessage.Trades), len(msg.Trades))
+	assert.Equal(t, len(expectedMessage.Canceled), len(msg.Canceled))
+	assert.Equal(t, len(expectedMessage.Activated), len(msg.Activated))
+
+	// Batches keep the order of the partition
+	for i, msg := range msgs[1:] {
+		require.Equal(t, fmt.Sprintf("order-%d", i+1), msg.OrderID)
 	}
+	assert.LessOrEqual(t, processor.largestBatch(), DefaultBatchSize)
+}
 
-	// Create consumer
-	consumer := &QueueMessageConsumer{
-		consumer: mockConsumer,
-		done:     make(chan struct{}),
+func TestQueueMessageConsumer_TopicPrefix(t *testing.T) {
+	// Messages are put on the topics the prefix router sends each book to.
+	// The mock broker assigns the group every topic it serves, so the topic
+	// of another application is left empty.
+	router := messaging.PrefixRouter{Prefix: "matchingo"}
+	books := []string{"btc-usd", "eth-usd"}
+	counts := map[string]int{"other-app": 0}
+	for _, book := range books {
+		counts[router.Route(book)] = 10
 	}
+	startMockKafkaTopics(t, counts, func(topic string, offset int64) *messaging.DoneMessage {
+		return &messaging.DoneMessage{OrderID: fmt.Sprintf("%s-%d", topic, offset), OrderBookName: strings.TrimPrefix(topic, "matchingo.")}
+	})
 
-	// Create a channel to receive the processed message
-	receivedMessage := make(chan *messaging.DoneMessage, 1)
+	consumer, err := NewQueueMessageConsumer(ConsumerConfig{TopicPrefix: router.Prefix})
+	require.NoError(t, err)
+	topics, err := consumer.topics()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"matchingo.btc-usd", "matchingo.eth-usd"}, topics)
 
-	// Start consuming in a goroutine
+	processor := &collectingProcessor{}
+	consumed := make(chan error, 1)
 	go func() {
-		err := consumer.ConsumeDoneMessages(func(msg *messaging.DoneMessage) error {
-			receivedMessage <- msg
-			return nil
-		})
-		require.NoError(t, err)
+		consumed <- consumer.ConsumeDoneMessages(processor)
 	}()
 
-	// Send a test message
-	mockConsumer.messages <- &sarama.ConsumerMessage{
-		Value: mustMarshalProto(t, expectedMessage),
+	require.Eventually(t, func() bool { return processor.count() == 20 }, 10*time.Second, 10*time.Millisecond)
+	require.NoError(t, consumer.Close())
+	require.NoError(t, <-consumed)
+
+	received := map[string]int{}
+	for _, msg := range processor.messages() {
+		received[msg.OrderBookName]++
 	}
+	assert.Equal(t, map[string]int{"btc-usd": 10, "eth-usd": 10}, received)
+}
 
-	// Wait for message to be processed
-	select {
-	case msg := <-receivedMessage:
-		assert.Equal(t, expectedMessage.OrderID, msg.OrderID)
-		assert.Equal(t, expectedMessage.ExecutedQty, msg.ExecutedQty)
-		assert.Equal(t, expectedMessage.RemainingQty, msg.RemainingQty)
-		assert.Equal(t, expectedMessage.Quantity, msg.Quantity)
-		assert.Equal(t, expectedMessage.Processed, msg.Processed)
-		assert.Equal(t, expectedMessage.Left, msg.Left)
-		assert.Equal(t, expectedMessage.Stored, msg.Stored)
-		assert.Equal(t, expectedMessage.UserAddress, msg.UserAddress)
-		assert.Equal(t, len(expectedMessage.Trades), len(msg.Trades))
-		assert.Equal(t, len(expectedMessage.Canceled), len(msg.Canceled))
-		assert.Equal(t, len(expectedMessage.Activated), len(msg.Activated))
-	case <-time.After(time.Second):
-		t.Fatal("timeout waiting for message")
+func TestDecodeBatch_SkipsUndecodable(t *testing.T) {
+	msgs := []*sarama.ConsumerMessage{
+		{Offset: 0, Value: mustMarshalProto(t, &messaging.DoneMessage{OrderID: "first"})},
+		{Offset: 1, Value: []byte("not a protobuf"), Headers: []*sarama.RecordHeader{{
+			Key:   []byte(messaging.ContentTypeHeader),
+			Value: []byte("application/unknown"),
+		}}},
+		{Offset: 2, Value: mustMarshalProto(t, &messaging.DoneMessage{OrderID: "last"})},
 	}
 
-	// Close the consumer
-	err := consumer.Close()
-	require.NoError(t, err)
+	var logs bytes.Buffer
+	decoded := decodeBatch(zerolog.New(&logs), msgs, 8)
+	require.Len(t, decoded, 2)
+	assert.Equal(t, "first", decoded[0].OrderID)
+	assert.Equal(t, "last", decoded[1].OrderID)
+	assert.Contains(t, logs.String(), `"offset":1`)
+}
+
+func BenchmarkKafkaConsumerBatch(b *testing.B) {
+	for _, batchSize := range []int{1, DefaultBatchSize} {
+		b.Run(fmt.Sprintf("batch=%d", batchSize), func(b *testing.B) {
+			startMockKafka(b, b.N, func(offset int64) *messaging.DoneMessage {
+				return &messaging.DoneMessage{OrderID: fmt.Sprintf("order-%d", offset), SequenceNumber: uint64(offset + 1)}
+			})
+			consumer, err := NewQueueMessageConsumer(ConsumerConfig{BatchSize: batchSize})
+			require.NoError(b, err)
+
+			processor := &collectingProcessor{}
+			b.ResetTimer()
+			go func() {
+				_ = consumer.ConsumeDoneMessages(processor)
+			}()
+			for processor.count() < b.N {
+				time.Sleep(time.Millisecond)
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "msgs/s")
+			require.NoError(b, consumer.Close())
+		})
+	}
+}
+
+// startMockKafka serves n done messages, built by message for each offset,
+// from partition 0 of the topic on a mock broker, and points the package's
+// broker and topic at it until tb ends
+func startMockKafka(tb testing.TB, n int, message func(offset int64) *messaging.DoneMessage) {
+	const mockTopic = "mock-done-messages"
+	startMockKafkaTopics(tb, map[string]int{mockTopic: n}, func(_ string, offset int64) *messaging.DoneMessage {
+		return message(offset)
+	})
+
+	oldTopic := topic
+	SetTopic(mockTopic)
+	tb.Cleanup(func() {
+		SetTopic(oldTopic)
+	})
+}
+
+// startMockKafkaTopics serves the done messages built by message from
+// partition 0 of each topic on a mock broker, as many as counts gives the
+// topic, and points the package's broker at it until tb ends
+func startMockKafkaTopics(tb testing.TB, counts map[string]int, message func(topic string, offset int64) *messaging.DoneMessage) {
+	const groupID = DefaultConsumerGroup
+	broker := sarama.NewMockBroker(tb, 0)
+	tb.Cleanup(broker.Close)
+
+	fetch := sarama.NewMockFetchResponse(tb, DefaultBatchSize)
+	metadata := sarama.NewMockMetadataResponse(tb).SetBroker(broker.Addr(), broker.BrokerID())
+	offsets := sarama.NewMockOffsetResponse(tb)
+	committed := sarama.NewMockOffsetFetchResponse(tb).SetError(sarama.ErrNoError)
+	assignment := &sarama.ConsumerGroupMemberAssignment{Topics: map[string][]int32{}}
+	serializer := messaging.ProtoSerializer{}
+	for mockTopic, n := range counts {
+		for offset := int64(0); offset < int64(n); offset++ {
+			value, err := serializer.Marshal(message(mockTopic, offset))
+			require.NoError(tb, err)
+			fetch.SetMessage(mockTopic, 0, offset, sarama.ByteEncoder(value))
+		}
+		fetch.SetHighWaterMark(mockTopic, 0, int64(n))
+		metadata.SetLeader(mockTopic, 0, broker.BrokerID())
+		offsets.SetOffset(mockTopic, 0, sarama.OffsetOldest, 0).
+			SetOffset(mockTopic, 0, sarama.OffsetNewest, int64(n))
+		committed.SetOffset(groupID, mockTopic, 0, 0, "", sarama.ErrNoError)
+		assignment.Topics[mockTopic] = []int32{0}
+	}
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(tb),
+		"MetadataRequest":    metadata,
+		"OffsetRequest":      offsets,
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(tb).
+			SetCoordinator(sarama.CoordinatorGroup, groupID, broker),
+		"JoinGroupRequest": sarama.NewMockJoinGroupResponse(tb).
+			SetGroupProtocol(sarama.RangeBalanceStrategyName),
+		"SyncGroupRequest":    sarama.NewMockSyncGroupResponse(tb).SetMemberAssignment(assignment),
+		"HeartbeatRequest":    sarama.NewMockHeartbeatResponse(tb),
+		"OffsetFetchRequest":  committed,
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(tb),
+		"LeaveGroupRequest":   sarama.NewMockLeaveGroupResponse(tb),
+		"FetchRequest":        fetch,
+	})
+
+	oldBrokerList := brokerList
+	SetBrokerList(broker.Addr())
+	tb.Cleanup(func() {
+		SetBrokerList(oldBrokerList)
+	})
+}
+
+// collectingProcessor is a BatchProcessor that keeps every message
+type collectingProcessor struct {
+	mu       sync.Mutex
+	received []*messaging.DoneMessage
+	largest  int
+}
+
+func (p *collectingProcessor) ProcessBatch(_ context.Context, msgs []*messaging.DoneMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.received = append(p.received, msgs...)
+	p.largest = max(p.largest, len(msgs))
+	return nil
+}
+
+func (p *collectingProcessor) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.received)
+}
+
+func (p *collectingProcessor) messages() []*messaging.DoneMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*messaging.DoneMessage(nil), p.received...)
+}
+
+func (p *collectingProcessor) largestBatch() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.largest
 }
 
 func mustMarshalProto(t *testing.T, msg *messaging.DoneMessage) []byte {
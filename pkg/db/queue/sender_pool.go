@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/erain9/matchingo/pkg/logging"
 	"github.com/erain9/matchingo/pkg/messaging"
 )
 
@@ -84,3 +85,23 @@ func SendMessage(ctx context.Context, msg *messaging.DoneMessage) error {
 
 	return nil
 }
+
+// SendCancelMessage sends a cancellation using a pooled sender
+func SendCancelMessage(ctx context.Context, msg *messaging.CancelMessage) error {
+	sender := GetSender()
+	if sender == nil {
+		return fmt.Errorf("failed to get message sender from pool")
+	}
+
+	if err := sender.SendCancelMessage(ctx, msg); err != nil {
+		logger := logging.FromContext(ctx)
+		logger.Error().Err(err).Str("order_id", msg.OrderID).Msg("Failed to send cancel message")
+		// A sender that failed may have lost its connection, so it is
+		// closed rather than returned to the pool
+		_ = sender.Close()
+		return err
+	}
+
+	ReturnSender(sender)
+	return nil
+}
@@ -0,0 +1,122 @@
+// Package lock serializes the operations on an order book across the server
+// instances sharing its backend.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyPrefix is prepended to the order book name to form its lock's key
+const KeyPrefix = "matchingo:lock:"
+
+// DefaultRetryInterval is how often Acquire retries a lock held by another owner
+const DefaultRetryInterval = 10 * time.Millisecond
+
+// ErrNotAcquired is returned when the context ends before another owner
+// releases the lock
+var ErrNotAcquired = errors.New("order book lock is held by another owner")
+
+// renewScript extends a lock's lease only if it still holds the owner's token.
+// KEYS: lock. ARGV: token, lease in milliseconds.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes a lock only if it still holds the owner's token.
+// KEYS: lock. ARGV: token.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisLock is a lease-based lock per order book, held in Redis with
+// SET key token NX PX ttl. The lease is renewed while the lock is held, so
+// it only expires when its owner died without releasing it.
+type RedisLock struct {
+	client        redis.UniversalClient
+	ttl           time.Duration
+	retryInterval time.Duration
+}
+
+// NewRedisLock creates a RedisLock whose leases last ttl
+func NewRedisLock(client redis.UniversalClient, ttl time.Duration) *RedisLock {
+	return &RedisLock{client: client, ttl: ttl, retryInterval: DefaultRetryInterval}
+}
+
+// SetRetryInterval sets how often Acquire retries a lock held by another owner
+func (l *RedisLock) SetRetryInterval(interval time.Duration) {
+	l.retryInterval = interval
+}
+
+// Acquire locks the named order book, waiting until ctx ends for another
+// owner to release it, and returns the function that releases it. The lease
+// is renewed every third of its ttl until then. Calling unlock again is a
+// no-op, and it never deletes a lock that expired and was taken by another
+// owner.
+func (l *RedisLock) Acquire(ctx context.Context, bookName string) (unlock func(), err error) {
+	key := KeyPrefix + bookName
+	token := uuid.NewString()
+	for {
+		locked, err := l.client.SetNX(ctx, key, token, l.ttl).Result()
+		if err != nil {
+			return nil, fmt.Errorf("lock order book %s: %w", bookName, err)
+		}
+		if locked {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %s: %w", ErrNotAcquired, bookName, ctx.Err())
+		case <-time.After(l.retryInterval):
+		}
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go l.renew(key, token, stop, stopped)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-stopped
+			// The caller's context may have ended; the lock is released anyway
+			releaseScript.Run(context.Background(), l.client, []string{key}, token)
+		})
+	}, nil
+}
+
+// renew extends the lease on key until stop is closed or the lock is lost,
+// then closes stopped. A failed renewal is retried at the next tick.
+func (l *RedisLock) renew(key, token string, stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	interval := l.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			renewed, err := renewScript.Run(ctx, l.client, []string{key}, token, l.ttl.Milliseconds()).Int()
+			cancel()
+			if err == nil && renewed == 0 {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisLock_Acquire(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	lock := NewRedisLock(client, time.Minute)
+
+	unlock, err := lock.Acquire(context.Background(), "btc-usd")
+	require.NoError(t, err)
+	assert.True(t, mr.Exists(KeyPrefix+"btc-usd"))
+
+	// Other books have locks of their own
+	unlockOther, err := lock.Acquire(context.Background(), "eth-usd")
+	require.NoError(t, err)
+	unlockOther()
+
+	// A second owner waits until its context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = lock.Acquire(ctx, "btc-usd")
+	assert.ErrorIs(t, err, ErrNotAcquired)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// and gets the lock once it is released
+	acquired := make(chan func())
+	go func() {
+		unlock, err := lock.Acquire(context.Background(), "btc-usd")
+		assert.NoError(t, err)
+		acquired <- unlock
+	}()
+	time.Sleep(20 * time.Millisecond)
+	unlock()
+	unlock()
+	select {
+	case unlock = <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("lock was not handed over")
+	}
+	unlock()
+	assert.False(t, mr.Exists(KeyPrefix+"btc-usd"))
+}
+
+func TestRedisLock_Renewal(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ttl := 90 * time.Millisecond
+	lock := NewRedisLock(client, ttl)
+	key := KeyPrefix + "btc-usd"
+
+	unlock, err := lock.Acquire(context.Background(), "btc-usd")
+	require.NoError(t, err)
+	assert.Equal(t, ttl, mr.TTL(key))
+
+	// The lease is extended back to its full length while the lock is held
+	mr.FastForward(60 * time.Millisecond)
+	assert.Eventually(t, func() bool { return mr.TTL(key) == ttl }, time.Second, 5*time.Millisecond)
+
+	// An expired lock taken by another owner is not released by the first
+	mr.FastForward(ttl)
+	require.False(t, mr.Exists(key))
+	unlockOther, err := NewRedisLock(client, time.Minute).Acquire(context.Background(), "btc-usd")
+	require.NoError(t, err)
+	unlock()
+	assert.True(t, mr.Exists(key))
+	unlockOther()
+	assert.False(t, mr.Exists(key))
+}
@@ -20,6 +20,8 @@ type contextKey string
 const (
 	// RequestIDKey is the key used to store request IDs in context
 	RequestIDKey contextKey = "request_id"
+	// RequestIDMetadataKey is the gRPC metadata key carrying the request ID
+	RequestIDMetadataKey = "x-request-id"
 )
 
 // Config defines logging configuration
@@ -90,6 +92,38 @@ func FromContext(ctx context.Context) zerolog.Logger {
 	return log.Logger
 }
 
+// WithRequestID returns a copy of ctx that carries requestID in its incoming
+// gRPC metadata and as a context value, with a logger tagged with the ID
+// attached for zerolog's log.Ctx
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	md.Set(RequestIDMetadataKey, requestID)
+	ctx = metadata.NewIncomingContext(ctx, md)
+	ctx = context.WithValue(ctx, RequestIDKey, requestID)
+
+	logger := log.With().Str("request_id", requestID).Logger()
+	return logger.WithContext(ctx)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or an empty
+// string if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	if requestID, ok := ctx.Value(RequestIDKey).(string); ok {
+		return requestID
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if requestIDs := md.Get(RequestIDMetadataKey); len(requestIDs) > 0 {
+			return requestIDs[0]
+		}
+	}
+	return ""
+}
+
 // LoggingInterceptor returns a gRPC interceptor for request logging
 func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(
@@ -107,7 +141,7 @@ func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 
 		// Extract metadata
 		if md, ok := metadata.FromIncomingContext(ctx); ok {
-			if requestIDs := md.Get("x-request-id"); len(requestIDs) > 0 {
+			if requestIDs := md.Get(RequestIDMetadataKey); len(requestIDs) > 0 {
 				requestID := requestIDs[0]
 				logger = logger.With().Str("request_id", requestID).Logger()
 				ctx = context.WithValue(ctx, RequestIDKey, requestID)
@@ -171,7 +205,7 @@ func StreamServerInterceptor() grpc.StreamServerInterceptor {
 
 		// Extract metadata
 		if md, ok := metadata.FromIncomingContext(stream.Context()); ok {
-			if requestIDs := md.Get("x-request-id"); len(requestIDs) > 0 {
+			if requestIDs := md.Get(RequestIDMetadataKey); len(requestIDs) > 0 {
 				requestID := requestIDs[0]
 				logger = logger.With().Str("request_id", requestID).Logger()
 				wrappedStream.ctx = context.WithValue(wrappedStream.ctx, RequestIDKey, requestID)
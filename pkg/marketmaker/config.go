@@ -2,6 +2,7 @@ package marketmaker
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -19,6 +20,7 @@ type Config struct {
 	PriceSourceURL string // e.g., "https://api.binance.com"
 
 	// Market making parameters
+	Strategy          string // Strategy name, e.g. "layered_symmetric"
 	NumLevels         int
 	BaseSpreadPercent float64
 	PriceStepPercent  float64
@@ -29,6 +31,25 @@ type Config struct {
 	// HTTP client settings
 	HTTPTimeout time.Duration
 	MaxRetries  int
+
+	// PriceFetcher configures fallback price sources and caching
+	PriceFetcher PriceFetcherConfig
+
+	// WebSocket configures a streaming price feed, asked before PriceSourceURL
+	WebSocket WebSocketPriceFetcherConfig
+
+	// HTTPAddr is the listen address for the introspection HTTP server
+	HTTPAddr string
+}
+
+// PriceFetcherConfig configures how prices are fetched when the primary
+// source at PriceSourceURL fails
+type PriceFetcherConfig struct {
+	// FallbackURLs are asked in order when PriceSourceURL fails
+	FallbackURLs []string
+	// CacheTTL is how long the last price may be served while every source
+	// fails. Zero disables the cache.
+	CacheTTL time.Duration
 }
 
 // LoadConfig loads configuration from environment variables
@@ -41,6 +62,7 @@ func LoadConfig() (*Config, error) {
 	v.SetDefault("MARKET_SYMBOL", "BTC-USDT")
 	v.SetDefault("EXTERNAL_SYMBOL", "BTCUSDT")
 	v.SetDefault("PRICE_SOURCE_URL", "https://api.binance.com")
+	v.SetDefault("STRATEGY", StrategyLayeredSymmetric)
 	v.SetDefault("NUM_LEVELS", 3)
 	v.SetDefault("BASE_SPREAD_PERCENT", 0.1)
 	v.SetDefault("PRICE_STEP_PERCENT", 0.05)
@@ -49,6 +71,12 @@ func LoadConfig() (*Config, error) {
 	v.SetDefault("MARKET_MAKER_ID", "mm-01")
 	v.SetDefault("HTTP_TIMEOUT_SECONDS", 5)
 	v.SetDefault("MAX_RETRIES", 3)
+	v.SetDefault("PRICE_FALLBACK_URLS", "")
+	v.SetDefault("PRICE_CACHE_TTL_SECONDS", 30)
+	v.SetDefault("MM_HTTP_ADDR", ":8090")
+	v.SetDefault("PRICE_WS_URL", "")
+	v.SetDefault("PRICE_WS_RECONNECT_DELAY_MS", 500)
+	v.SetDefault("PRICE_WS_MAX_RECONNECTS", 0)
 
 	// Allow environment variables
 	v.AutomaticEnv()
@@ -59,6 +87,7 @@ func LoadConfig() (*Config, error) {
 		MarketSymbol:      v.GetString("MARKET_SYMBOL"),
 		ExternalSymbol:    v.GetString("EXTERNAL_SYMBOL"),
 		PriceSourceURL:    v.GetString("PRICE_SOURCE_URL"),
+		Strategy:          v.GetString("STRATEGY"),
 		NumLevels:         v.GetInt("NUM_LEVELS"),
 		BaseSpreadPercent: v.GetFloat64("BASE_SPREAD_PERCENT"),
 		PriceStepPercent:  v.GetFloat64("PRICE_STEP_PERCENT"),
@@ -67,6 +96,17 @@ func LoadConfig() (*Config, error) {
 		MarketMakerID:     v.GetString("MARKET_MAKER_ID"),
 		HTTPTimeout:       time.Duration(v.GetInt("HTTP_TIMEOUT_SECONDS")) * time.Second,
 		MaxRetries:        v.GetInt("MAX_RETRIES"),
+		HTTPAddr:          v.GetString("MM_HTTP_ADDR"),
+		PriceFetcher: PriceFetcherConfig{
+			FallbackURLs: splitList(v.GetString("PRICE_FALLBACK_URLS")),
+			CacheTTL:     time.Duration(v.GetInt("PRICE_CACHE_TTL_SECONDS")) * time.Second,
+		},
+		WebSocket: WebSocketPriceFetcherConfig{
+			URL:            v.GetString("PRICE_WS_URL"),
+			Symbol:         v.GetString("EXTERNAL_SYMBOL"),
+			ReconnectDelay: time.Duration(v.GetInt("PRICE_WS_RECONNECT_DELAY_MS")) * time.Millisecond,
+			MaxReconnects:  v.GetInt("PRICE_WS_MAX_RECONNECTS"),
+		},
 	}
 
 	// Validate configuration
@@ -90,6 +130,9 @@ func validateConfig(cfg *Config) error {
 	if cfg.PriceSourceURL == "" {
 		return fmt.Errorf("PRICE_SOURCE_URL must not be empty")
 	}
+	if cfg.Strategy != StrategyLayeredSymmetric && cfg.Strategy != StrategyExponentialLayered {
+		return fmt.Errorf("STRATEGY must be %s or %s", StrategyLayeredSymmetric, StrategyExponentialLayered)
+	}
 	if cfg.NumLevels <= 0 {
 		return fmt.Errorf("NUM_LEVELS must be positive")
 	}
@@ -108,5 +151,25 @@ func validateConfig(cfg *Config) error {
 	if cfg.MarketMakerID == "" {
 		return fmt.Errorf("MARKET_MAKER_ID must not be empty")
 	}
+	if cfg.PriceFetcher.CacheTTL < 0 {
+		return fmt.Errorf("PRICE_CACHE_TTL_SECONDS must not be negative")
+	}
+	if cfg.WebSocket.ReconnectDelay < 0 {
+		return fmt.Errorf("PRICE_WS_RECONNECT_DELAY_MS must not be negative")
+	}
+	if cfg.WebSocket.MaxReconnects < 0 {
+		return fmt.Errorf("PRICE_WS_MAX_RECONNECTS must not be negative")
+	}
 	return nil
 }
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
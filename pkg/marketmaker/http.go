@@ -0,0 +1,31 @@
+package marketmaker
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// strategyResponse is the JSON body served by the strategy endpoint
+type strategyResponse struct {
+	Name       string            `json:"name"`
+	Parameters map[string]string `json:"parameters"`
+}
+
+// NewHTTPHandler returns the market maker introspection endpoints:
+// GET /mm/strategy reports the active strategy name and parameters
+func NewHTTPHandler(strategy Strategy) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mm/strategy", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(strategyResponse{
+			Name:       strategy.Name(),
+			Parameters: strategy.Parameters(),
+		})
+	})
+	return mux
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	pb "github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/nikolaydubina/fpdecimal"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -27,3 +28,19 @@ type MarketMakerStrategy interface {
 	// CalculateOrders calculates the orders to be placed based on the current price
 	CalculateOrders(ctx context.Context, currentPrice float64, userAddress string) ([]*pb.CreateOrderRequest, error)
 }
+
+// Strategy defines a quoting strategy that can be inspected at runtime
+type Strategy interface {
+	// GenerateQuotes returns the quotes to place around midPrice given the current inventory
+	GenerateQuotes(ctx context.Context, midPrice fpdecimal.Decimal, inventory fpdecimal.Decimal) ([]Quote, error)
+	// Name returns the strategy identifier
+	Name() string
+	// Parameters returns the current strategy configuration as strings
+	Parameters() map[string]string
+}
+
+// QuotingStrategy is a MarketMakerStrategy that also exposes its quotes and parameters
+type QuotingStrategy interface {
+	MarketMakerStrategy
+	Strategy
+}
@@ -0,0 +1,219 @@
+package marketmaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultBackoffBaseDelay is the wait before the first retry of an
+// ExponentialBackoffPriceFetcher
+const DefaultBackoffBaseDelay = 100 * time.Millisecond
+
+// ErrNoPriceFetchers is returned by a FallbackPriceFetcher with nothing to ask
+var ErrNoPriceFetchers = errors.New("no price fetchers configured")
+
+// FallbackPriceFetcher asks a primary PriceFetcher first and, when it fails,
+// each fallback in order until one returns a price
+type FallbackPriceFetcher struct {
+	fetchers []PriceFetcher
+	logger   *slog.Logger
+}
+
+// NewFallbackPriceFetcher creates a FallbackPriceFetcher trying primary, then fallbacks
+func NewFallbackPriceFetcher(logger *slog.Logger, primary PriceFetcher, fallbacks ...PriceFetcher) *FallbackPriceFetcher {
+	return &FallbackPriceFetcher{
+		fetchers: append([]PriceFetcher{primary}, fallbacks...),
+		logger:   logger.With("component", "fallbackPriceFetcher"),
+	}
+}
+
+// FetchPrice returns the first price any fetcher returns. If all of them
+// fail, the error wraps every fetcher's error.
+func (f *FallbackPriceFetcher) FetchPrice(ctx context.Context) (float64, error) {
+	if len(f.fetchers) == 0 {
+		return 0, ErrNoPriceFetchers
+	}
+
+	errs := make([]error, 0, len(f.fetchers))
+	for i, fetcher := range f.fetchers {
+		price, err := fetcher.FetchPrice(ctx)
+		if err == nil {
+			if i > 0 {
+				f.logger.Info("Price served by fallback fetcher", "fallback", i)
+			}
+			return price, nil
+		}
+
+		f.logger.Warn("Price fetcher failed", "fetcher", i, "error", err)
+		errs = append(errs, fmt.Errorf("fetcher %d: %w", i, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return 0, errors.Join(errs...)
+}
+
+// Close closes every fetcher and returns their errors joined
+func (f *FallbackPriceFetcher) Close() error {
+	var errs []error
+	for _, fetcher := range f.fetchers {
+		errs = append(errs, fetcher.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// CachedPriceFetcher remembers the last price its fetcher returned and serves
+// it, for up to the cache TTL, while the fetcher fails
+type CachedPriceFetcher struct {
+	fetcher  PriceFetcher
+	cacheTTL time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+
+	mu        sync.Mutex
+	price     float64
+	fetchedAt time.Time
+}
+
+// NewCachedPriceFetcher creates a CachedPriceFetcher around fetcher
+func NewCachedPriceFetcher(fetcher PriceFetcher, cacheTTL time.Duration, logger *slog.Logger) *CachedPriceFetcher {
+	return &CachedPriceFetcher{
+		fetcher:  fetcher,
+		cacheTTL: cacheTTL,
+		logger:   logger.With("component", "cachedPriceFetcher"),
+		now:      time.Now,
+	}
+}
+
+// FetchPrice returns a fresh price, or the cached one if the fetcher fails and
+// the cached price is no older than the cache TTL
+func (f *CachedPriceFetcher) FetchPrice(ctx context.Context) (float64, error) {
+	price, err := f.fetcher.FetchPrice(ctx)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		f.price = price
+		f.fetchedAt = f.now()
+		return price, nil
+	}
+
+	if f.fetchedAt.IsZero() {
+		return 0, err
+	}
+	age := f.now().Sub(f.fetchedAt)
+	if age > f.cacheTTL {
+		return 0, fmt.Errorf("cached price expired %s ago: %w", age-f.cacheTTL, err)
+	}
+
+	f.logger.Warn("Serving stale cached price",
+		"price", f.price,
+		"age", age,
+		"error", err)
+	return f.price, nil
+}
+
+// Close closes the wrapped fetcher
+func (f *CachedPriceFetcher) Close() error {
+	return f.fetcher.Close()
+}
+
+// ExponentialBackoffPriceFetcher retries a failing fetcher, doubling the wait
+// after every attempt
+type ExponentialBackoffPriceFetcher struct {
+	fetcher    PriceFetcher
+	maxRetries int
+	baseDelay  time.Duration
+	logger     *slog.Logger
+}
+
+// NewExponentialBackoffPriceFetcher creates a fetcher that retries fetcher up
+// to maxRetries times, waiting baseDelay before the first retry
+func NewExponentialBackoffPriceFetcher(fetcher PriceFetcher, maxRetries int, baseDelay time.Duration, logger *slog.Logger) *ExponentialBackoffPriceFetcher {
+	return &ExponentialBackoffPriceFetcher{
+		fetcher:    fetcher,
+		maxRetries: maxRetries,
+		baseDelay:  baseDelay,
+		logger:     logger.With("component", "exponentialBackoffPriceFetcher"),
+	}
+}
+
+// FetchPrice calls the wrapped fetcher until it succeeds, the retries run out
+// or ctx is done
+func (f *ExponentialBackoffPriceFetcher) FetchPrice(ctx context.Context) (float64, error) {
+	delay := f.baseDelay
+	for retry := 0; ; retry++ {
+		price, err := f.fetcher.FetchPrice(ctx)
+		if err == nil {
+			return price, nil
+		}
+		if retry >= f.maxRetries {
+			return 0, fmt.Errorf("failed to fetch price after %d retries: %w", retry, err)
+		}
+
+		f.logger.Warn("Price fetch failed, backing off",
+			"retry", retry+1,
+			"max_retries", f.maxRetries,
+			"delay", delay,
+			"error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, errors.Join(err, ctx.Err())
+		}
+		delay *= 2
+	}
+}
+
+// Close closes the wrapped fetcher
+func (f *ExponentialBackoffPriceFetcher) Close() error {
+	return f.fetcher.Close()
+}
+
+// NewPriceFetcherChain creates the market maker's price source from cfg. The
+// WebSocket feed, when cfg.WebSocket.URL is set, and each of PriceSourceURL
+// and cfg.PriceFetcher.FallbackURLs are asked once per round, in order; failed rounds are retried with exponential backoff up to
+// MaxRetries times. A positive cfg.PriceFetcher.CacheTTL serves the last
+// price while every source is failing.
+func NewPriceFetcherChain(cfg *Config, logger *slog.Logger) (PriceFetcher, error) {
+	urls := append([]string{cfg.PriceSourceURL}, cfg.PriceFetcher.FallbackURLs...)
+
+	fetchers := make([]PriceFetcher, 0, len(urls)+1)
+	if cfg.WebSocket.URL != "" {
+		fetcher, err := NewWebSocketPriceFetcher(cfg.WebSocket, logger)
+		if err != nil {
+			return nil, fmt.Errorf("WebSocket price feed: %w", err)
+		}
+		fetchers = append(fetchers, fetcher)
+	}
+	for _, url := range urls {
+		// Retries happen around the whole chain, so each source gets one attempt
+		sourceCfg := *cfg
+		sourceCfg.PriceSourceURL = url
+		sourceCfg.MaxRetries = 1
+
+		fetcher, err := NewPriceFetcher(&sourceCfg, logger.With("price_source", url))
+		if err != nil {
+			for _, fetcher := range fetchers {
+				fetcher.Close()
+			}
+			return nil, fmt.Errorf("price source %s: %w", url, err)
+		}
+		fetchers = append(fetchers, fetcher)
+	}
+
+	var fetcher PriceFetcher = NewFallbackPriceFetcher(logger, fetchers[0], fetchers[1:]...)
+	fetcher = NewExponentialBackoffPriceFetcher(fetcher, cfg.MaxRetries, DefaultBackoffBaseDelay, logger)
+	if cfg.PriceFetcher.CacheTTL > 0 {
+		fetcher = NewCachedPriceFetcher(fetcher, cfg.PriceFetcher.CacheTTL, logger)
+	}
+	return fetcher, nil
+}
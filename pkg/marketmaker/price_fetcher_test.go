@@ -3,6 +3,8 @@ package marketmaker
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -162,3 +164,104 @@ func TestBinancePriceFetcher_FetchPrice_Timeout(t *testing.T) {
 		t.Error("Expected timeout error, got nil")
 	}
 }
+
+// scriptedPriceFetcher returns its results in order, repeating the last one
+type scriptedPriceFetcher struct {
+	results []scriptedPrice
+	calls   int
+}
+
+type scriptedPrice struct {
+	price float64
+	err   error
+}
+
+func (f *scriptedPriceFetcher) FetchPrice(ctx context.Context) (float64, error) {
+	r := f.results[min(f.calls, len(f.results)-1)]
+	f.calls++
+	return r.price, r.err
+}
+
+func (f *scriptedPriceFetcher) Close() error { return nil }
+
+func TestFallbackPriceFetcher(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	errDown := errors.New("source down")
+
+	primary := &scriptedPriceFetcher{results: []scriptedPrice{{err: errDown}}}
+	fallback := &scriptedPriceFetcher{results: []scriptedPrice{{price: 101}}}
+	fetcher := NewFallbackPriceFetcher(logger, primary, fallback)
+
+	price, err := fetcher.FetchPrice(context.Background())
+	if err != nil {
+		t.Fatalf("FetchPrice failed: %v", err)
+	}
+	if price != 101 {
+		t.Errorf("Expected fallback price 101, got %f", price)
+	}
+
+	fallback.results = []scriptedPrice{{err: errDown}}
+	if _, err := fetcher.FetchPrice(context.Background()); !errors.Is(err, errDown) {
+		t.Errorf("Expected error wrapping %v, got %v", errDown, err)
+	}
+}
+
+func TestCachedPriceFetcher_ServesCachedPriceDuringFailures(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	errDown := errors.New("source down")
+
+	// Succeeds once, fails twice, then succeeds again
+	source := &scriptedPriceFetcher{results: []scriptedPrice{
+		{price: 100},
+		{err: errDown},
+		{err: errDown},
+		{price: 102},
+	}}
+	fetcher := NewCachedPriceFetcher(NewFallbackPriceFetcher(logger, source), time.Minute, logger)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fetcher.now = func() time.Time { return now }
+
+	for i, want := range []float64{100, 100, 100, 102} {
+		price, err := fetcher.FetchPrice(context.Background())
+		if err != nil {
+			t.Fatalf("FetchPrice %d failed: %v", i, err)
+		}
+		if price != want {
+			t.Errorf("FetchPrice %d: expected %f, got %f", i, want, price)
+		}
+		now = now.Add(10 * time.Second)
+	}
+
+	// Once the cached price is older than the TTL, failures are returned
+	source.results = []scriptedPrice{{err: errDown}}
+	source.calls = 0
+	now = now.Add(time.Minute)
+	if _, err := fetcher.FetchPrice(context.Background()); !errors.Is(err, errDown) {
+		t.Errorf("Expected error wrapping %v, got %v", errDown, err)
+	}
+}
+
+func TestExponentialBackoffPriceFetcher(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	errDown := errors.New("source down")
+
+	source := &scriptedPriceFetcher{results: []scriptedPrice{{err: errDown}, {err: errDown}, {price: 100}}}
+	fetcher := NewExponentialBackoffPriceFetcher(source, 3, time.Millisecond, logger)
+
+	price, err := fetcher.FetchPrice(context.Background())
+	if err != nil {
+		t.Fatalf("FetchPrice failed: %v", err)
+	}
+	if price != 100 || source.calls != 3 {
+		t.Errorf("Expected price 100 after 3 calls, got %f after %d", price, source.calls)
+	}
+
+	source = &scriptedPriceFetcher{results: []scriptedPrice{{err: errDown}, {err: errDown}, {price: 100}}}
+	fetcher = NewExponentialBackoffPriceFetcher(source, 1, time.Millisecond, logger)
+	if _, err := fetcher.FetchPrice(context.Background()); !errors.Is(err, errDown) {
+		t.Errorf("Expected error wrapping %v, got %v", errDown, err)
+	}
+	if source.calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", source.calls)
+	}
+}
@@ -6,11 +6,44 @@ import (
 	"log/slog"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	pb "github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/nikolaydubina/fpdecimal"
 )
 
+// Strategy names accepted in Config.Strategy
+const (
+	StrategyLayeredSymmetric   = "layered_symmetric"
+	StrategyExponentialLayered = "exponential_layered"
+)
+
+// exponentialGrowthFactor is the spread multiplier between consecutive exponential layers
+const exponentialGrowthFactor = 2
+
+// Quote is a single price level a strategy wants to quote
+type Quote struct {
+	Side     core.Side
+	Price    fpdecimal.Decimal
+	Quantity fpdecimal.Decimal
+	// Level is the 1-based layer index, innermost first
+	Level int
+}
+
+// NewStrategy creates the strategy selected by cfg.Strategy
+func NewStrategy(cfg *Config, logger *slog.Logger) (QuotingStrategy, error) {
+	switch cfg.Strategy {
+	case "", StrategyLayeredSymmetric:
+		return NewLayeredSymmetricQuoting(cfg, logger), nil
+	case StrategyExponentialLayered:
+		return NewExponentialLayeredQuoting(cfg, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown strategy: %s", cfg.Strategy)
+	}
+}
+
 // LayeredSymmetricQuoting implements a symmetric market making strategy with multiple price levels
 type LayeredSymmetricQuoting struct {
 	cfg    *Config
@@ -18,7 +51,7 @@ type LayeredSymmetricQuoting struct {
 }
 
 // NewLayeredSymmetricQuoting creates a new LayeredSymmetricQuoting strategy
-func NewLayeredSymmetricQuoting(cfg *Config, logger *slog.Logger) MarketMakerStrategy {
+func NewLayeredSymmetricQuoting(cfg *Config, logger *slog.Logger) QuotingStrategy {
 	return &LayeredSymmetricQuoting{
 		cfg:    cfg,
 		logger: logger.With("component", "LayeredSymmetricQuoting"),
@@ -81,3 +114,134 @@ func (s *LayeredSymmetricQuoting) CalculateOrders(ctx context.Context, currentPr
 
 	return orders, nil
 }
+
+// GenerateQuotes implements Strategy. Inventory is ignored since quoting is symmetric.
+func (s *LayeredSymmetricQuoting) GenerateQuotes(ctx context.Context, midPrice fpdecimal.Decimal, inventory fpdecimal.Decimal) ([]Quote, error) {
+	quantity, err := fpdecimal.FromString(s.cfg.OrderSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order size %q: %w", s.cfg.OrderSize, err)
+	}
+
+	mid := midPrice.Float64()
+	baseHalfSpread := mid * (s.cfg.BaseSpreadPercent / 2 / 100)
+	basePriceStep := mid * (s.cfg.PriceStepPercent / 100)
+
+	quotes := make([]Quote, 0, s.cfg.NumLevels*2)
+	for i := 1; i <= s.cfg.NumLevels; i++ {
+		offset := baseHalfSpread + basePriceStep*float64(i)
+		quotes = append(quotes,
+			Quote{Side: core.Buy, Price: fpdecimal.FromFloat(mid - offset), Quantity: quantity, Level: i},
+			Quote{Side: core.Sell, Price: fpdecimal.FromFloat(mid + offset), Quantity: quantity, Level: i},
+		)
+	}
+
+	return quotes, nil
+}
+
+// Name implements Strategy
+func (s *LayeredSymmetricQuoting) Name() string {
+	return StrategyLayeredSymmetric
+}
+
+// Parameters implements Strategy
+func (s *LayeredSymmetricQuoting) Parameters() map[string]string {
+	return map[string]string{
+		"num_levels":          strconv.Itoa(s.cfg.NumLevels),
+		"base_spread_percent": strconv.FormatFloat(s.cfg.BaseSpreadPercent, 'f', -1, 64),
+		"price_step_percent":  strconv.FormatFloat(s.cfg.PriceStepPercent, 'f', -1, 64),
+		"order_size":          s.cfg.OrderSize,
+	}
+}
+
+// ExponentialLayeredQuoting quotes layers whose distance from mid doubles at
+// each level: 1x, 2x, 4x, ... the base spread
+type ExponentialLayeredQuoting struct {
+	cfg    *Config
+	logger *slog.Logger
+}
+
+// NewExponentialLayeredQuoting creates a new ExponentialLayeredQuoting strategy
+func NewExponentialLayeredQuoting(cfg *Config, logger *slog.Logger) QuotingStrategy {
+	return &ExponentialLayeredQuoting{
+		cfg:    cfg,
+		logger: logger.With("component", "ExponentialLayeredQuoting"),
+	}
+}
+
+// GenerateQuotes implements Strategy. Inventory is ignored since quoting is symmetric.
+func (s *ExponentialLayeredQuoting) GenerateQuotes(ctx context.Context, midPrice fpdecimal.Decimal, inventory fpdecimal.Decimal) ([]Quote, error) {
+	quantity, err := fpdecimal.FromString(s.cfg.OrderSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order size %q: %w", s.cfg.OrderSize, err)
+	}
+
+	mid := midPrice.Float64()
+	baseSpread := mid * (s.cfg.BaseSpreadPercent / 100)
+
+	quotes := make([]Quote, 0, s.cfg.NumLevels*2)
+	multiplier := 1.0
+	for i := 1; i <= s.cfg.NumLevels; i++ {
+		offset := baseSpread * multiplier
+		quotes = append(quotes,
+			Quote{Side: core.Buy, Price: fpdecimal.FromFloat(mid - offset), Quantity: quantity, Level: i},
+			Quote{Side: core.Sell, Price: fpdecimal.FromFloat(mid + offset), Quantity: quantity, Level: i},
+		)
+		multiplier *= exponentialGrowthFactor
+	}
+
+	return quotes, nil
+}
+
+// CalculateOrders implements MarketMakerStrategy
+func (s *ExponentialLayeredQuoting) CalculateOrders(ctx context.Context, currentPrice float64, userAddress string) ([]*pb.CreateOrderRequest, error) {
+	quotes, err := s.GenerateQuotes(ctx, fpdecimal.FromFloat(currentPrice), fpdecimal.Zero)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().UnixNano()
+	orders := make([]*pb.CreateOrderRequest, 0, len(quotes))
+	for _, q := range quotes {
+		side := pb.OrderSide_BUY
+		if q.Side == core.Sell {
+			side = pb.OrderSide_SELL
+		}
+
+		// Format prices with appropriate precision (8 decimal places for crypto)
+		priceStr := strconv.FormatFloat(math.Round(q.Price.Float64()*1e8)/1e8, 'f', 8, 64)
+
+		orders = append(orders, &pb.CreateOrderRequest{
+			OrderBookName: s.cfg.MarketSymbol,
+			OrderId:       fmt.Sprintf("%s-%s-%d-%d", s.cfg.MarketMakerID, strings.ToLower(q.Side.String()), q.Level, timestamp),
+			Side:          side,
+			OrderType:     pb.OrderType_LIMIT,
+			Quantity:      q.Quantity.String(),
+			Price:         priceStr,
+			TimeInForce:   pb.TimeInForce_GTC,
+			UserAddress:   userAddress,
+		})
+
+		s.logger.Debug("Calculated order",
+			"level", q.Level,
+			"side", q.Side.String(),
+			"price", priceStr,
+			"quantity", q.Quantity.String())
+	}
+
+	return orders, nil
+}
+
+// Name implements Strategy
+func (s *ExponentialLayeredQuoting) Name() string {
+	return StrategyExponentialLayered
+}
+
+// Parameters implements Strategy
+func (s *ExponentialLayeredQuoting) Parameters() map[string]string {
+	return map[string]string{
+		"num_levels":          strconv.Itoa(s.cfg.NumLevels),
+		"base_spread_percent": strconv.FormatFloat(s.cfg.BaseSpreadPercent, 'f', -1, 64),
+		"growth_factor":       strconv.Itoa(exponentialGrowthFactor),
+		"order_size":          s.cfg.OrderSize,
+	}
+}
@@ -2,12 +2,19 @@ package marketmaker
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"testing"
 
 	pb "github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMarketMakerStrategy(t *testing.T) {
@@ -92,3 +99,73 @@ func parseFloat(t *testing.T, s string) float64 {
 	}
 	return f
 }
+
+func TestExponentialLayeredQuoting(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	config := &Config{
+		MarketSymbol:      "BTC-USDT",
+		Strategy:          StrategyExponentialLayered,
+		NumLevels:         3,
+		BaseSpreadPercent: 0.1, // 10bps
+		OrderSize:         "0.5",
+		MarketMakerID:     "test-mm",
+	}
+
+	strategy := NewExponentialLayeredQuoting(config, logger)
+	mid := fpdecimal.FromInt(10000)
+
+	quotes, err := strategy.GenerateQuotes(context.Background(), mid, fpdecimal.Zero)
+	require.NoError(t, err)
+	require.Len(t, quotes, 6)
+
+	// Layers at 10, 20 and 40bps from mid
+	expectedOffsets := []int64{10, 20, 40}
+	for i, offset := range expectedOffsets {
+		bid, ask := quotes[2*i], quotes[2*i+1]
+
+		assert.Equal(t, core.Buy, bid.Side)
+		assert.Equal(t, core.Sell, ask.Side)
+		assert.Equal(t, i+1, bid.Level)
+		assert.Equal(t, mid.Sub(fpdecimal.FromInt(offset)).String(), bid.Price.String(), "bid at level %d", i+1)
+		assert.Equal(t, mid.Add(fpdecimal.FromInt(offset)).String(), ask.Price.String(), "ask at level %d", i+1)
+		assert.Equal(t, "0.500", bid.Quantity.String())
+	}
+
+	orders, err := strategy.CalculateOrders(context.Background(), 10000, "test-mm")
+	require.NoError(t, err)
+	require.Len(t, orders, 6)
+	assert.Equal(t, pb.OrderSide_BUY, orders[0].Side)
+	assert.Equal(t, "9990.00000000", orders[0].Price)
+	assert.Equal(t, "10040.00000000", orders[5].Price)
+	for _, order := range orders {
+		assert.Equal(t, "test-mm", order.UserAddress)
+	}
+
+	assert.Equal(t, StrategyExponentialLayered, strategy.Name())
+	assert.Equal(t, "3", strategy.Parameters()["num_levels"])
+	assert.Equal(t, "0.1", strategy.Parameters()["base_spread_percent"])
+}
+
+func TestStrategyHTTPHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	strategy, err := NewStrategy(&Config{NumLevels: 2, BaseSpreadPercent: 0.2, PriceStepPercent: 0.05, OrderSize: "1"}, logger)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	NewHTTPHandler(strategy).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/mm/strategy", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Name       string            `json:"name"`
+		Parameters map[string]string `json:"parameters"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, StrategyLayeredSymmetric, body.Name)
+	assert.Equal(t, "2", body.Parameters["num_levels"])
+	assert.Equal(t, "0.2", body.Parameters["base_spread_percent"])
+
+	rec = httptest.NewRecorder()
+	NewHTTPHandler(strategy).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mm/strategy", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,255 @@
+package marketmaker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+const (
+	// DefaultReconnectDelay is the wait before the first reconnect of a
+	// WebSocketPriceFetcher
+	DefaultReconnectDelay = 500 * time.Millisecond
+
+	// maxReconnectDelay caps the exponential reconnect backoff
+	maxReconnectDelay = 30 * time.Second
+)
+
+var (
+	// ErrNoQuote is returned while a WebSocketPriceFetcher has no best bid and ask
+	ErrNoQuote = errors.New("no quote received from price feed")
+
+	// ErrPriceFeedClosed is returned once a WebSocketPriceFetcher has been
+	// closed or has given up reconnecting
+	ErrPriceFeedClosed = errors.New("price feed closed")
+)
+
+// WebSocketPriceFetcherConfig configures a WebSocketPriceFetcher
+type WebSocketPriceFetcherConfig struct {
+	// URL is the stream endpoint, e.g. "wss://stream.binance.com:9443/ws".
+	// An empty URL disables the WebSocket feed.
+	URL string
+	// Symbol selects the "<symbol>@bookTicker" stream under URL. Empty
+	// connects to URL as is.
+	Symbol string
+	// ReconnectDelay is the wait before the first reconnect; it doubles after
+	// every failed attempt
+	ReconnectDelay time.Duration
+	// MaxReconnects is how many reconnects in a row may fail before the
+	// fetcher gives up. Zero retries forever.
+	MaxReconnects int
+}
+
+// bookTickerFrame is a best bid and ask update from a Binance bookTicker
+// stream. The quantities are declared so that encoding/json, which matches
+// keys case-insensitively, does not read "B" and "A" into the prices.
+type bookTickerFrame struct {
+	Bid    string `json:"b"`
+	BidQty string `json:"B"`
+	Ask    string `json:"a"`
+	AskQty string `json:"A"`
+}
+
+// WebSocketPriceFetcher keeps a WebSocket connection to a book ticker stream
+// open and serves the midpoint of the latest best bid and ask. Dropped
+// connections are redialled with exponential backoff; no price is served
+// until the new connection delivers a quote.
+type WebSocketPriceFetcher struct {
+	cfg    WebSocketPriceFetcherConfig
+	url    string
+	logger *slog.Logger
+	dialer *websocket.Dialer
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu   sync.Mutex
+	conn *websocket.Conn
+	bid  fpdecimal.Decimal
+	ask  fpdecimal.Decimal
+	// hasQuote is false until a quote arrives on the current connection
+	hasQuote bool
+	// err is set when the feed stops for good
+	err error
+}
+
+// NewWebSocketPriceFetcher creates a WebSocketPriceFetcher and starts
+// connecting to the feed in the background. Close stops it.
+func NewWebSocketPriceFetcher(cfg WebSocketPriceFetcherConfig, logger *slog.Logger) (*WebSocketPriceFetcher, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("WebSocket price feed URL must not be empty")
+	}
+	if cfg.ReconnectDelay <= 0 {
+		cfg.ReconnectDelay = DefaultReconnectDelay
+	}
+
+	url := cfg.URL
+	if cfg.Symbol != "" {
+		url = strings.TrimSuffix(url, "/") + "/" + strings.ToLower(cfg.Symbol) + "@bookTicker"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &WebSocketPriceFetcher{
+		cfg:    cfg,
+		url:    url,
+		logger: logger.With("component", "webSocketPriceFetcher", "url", url),
+		dialer: websocket.DefaultDialer,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go f.run(ctx)
+	return f, nil
+}
+
+// GetMidPrice returns the midpoint of the latest best bid and ask
+func (f *WebSocketPriceFetcher) GetMidPrice() (fpdecimal.Decimal, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return fpdecimal.Zero, f.err
+	}
+	if !f.hasQuote {
+		return fpdecimal.Zero, ErrNoQuote
+	}
+	return f.bid.Add(f.ask).Div(fpdecimal.FromInt(2)), nil
+}
+
+// FetchPrice implements PriceFetcher with the current midpoint
+func (f *WebSocketPriceFetcher) FetchPrice(ctx context.Context) (float64, error) {
+	mid, err := f.GetMidPrice()
+	if err != nil {
+		return 0, err
+	}
+	return mid.Float64(), nil
+}
+
+// Close disconnects from the feed and stops reconnecting
+func (f *WebSocketPriceFetcher) Close() error {
+	f.cancel()
+	f.mu.Lock()
+	if f.conn != nil {
+		f.conn.Close()
+	}
+	f.mu.Unlock()
+	<-f.done
+	return nil
+}
+
+// run connects to the feed and reads from it until ctx is done, reconnecting
+// with exponential backoff whenever the connection drops
+func (f *WebSocketPriceFetcher) run(ctx context.Context) {
+	defer close(f.done)
+
+	delay := f.cfg.ReconnectDelay
+	failures := 0
+	for {
+		connected, err := f.connectAndRead(ctx)
+		if ctx.Err() != nil {
+			f.stop(ErrPriceFeedClosed)
+			return
+		}
+
+		if connected {
+			// The connection worked, so start backing off afresh
+			delay = f.cfg.ReconnectDelay
+			failures = 0
+		}
+		failures++
+		if f.cfg.MaxReconnects > 0 && failures > f.cfg.MaxReconnects {
+			f.logger.Error("Giving up on price feed", "reconnects", f.cfg.MaxReconnects, "error", err)
+			f.stop(fmt.Errorf("%w after %d reconnects: %w", ErrPriceFeedClosed, f.cfg.MaxReconnects, err))
+			return
+		}
+
+		f.logger.Warn("Price feed disconnected, reconnecting",
+			"attempt", failures,
+			"delay", delay,
+			"error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			f.stop(ErrPriceFeedClosed)
+			return
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// connectAndRead dials the feed and applies its frames until the connection
+// fails. It reports whether the dial succeeded.
+func (f *WebSocketPriceFetcher) connectAndRead(ctx context.Context) (bool, error) {
+	conn, _, err := f.dialer.DialContext(ctx, f.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	f.mu.Lock()
+	f.conn = conn
+	f.hasQuote = false
+	f.mu.Unlock()
+	// Close may have run before conn was stored
+	if ctx.Err() != nil {
+		conn.Close()
+	}
+
+	defer func() {
+		conn.Close()
+		f.mu.Lock()
+		f.conn = nil
+		f.hasQuote = false
+		f.mu.Unlock()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return true, fmt.Errorf("failed to read: %w", err)
+		}
+
+		bid, ask, err := parseBookTicker(data)
+		if err != nil {
+			f.logger.Warn("Ignoring malformed ticker frame", "error", err)
+			continue
+		}
+
+		f.mu.Lock()
+		f.bid, f.ask, f.hasQuote = bid, ask, true
+		f.mu.Unlock()
+	}
+}
+
+// stop records why the feed stopped
+func (f *WebSocketPriceFetcher) stop(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// parseBookTicker reads the best bid and ask from a bookTicker frame
+func parseBookTicker(data []byte) (bid, ask fpdecimal.Decimal, err error) {
+	var frame bookTickerFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return bid, ask, fmt.Errorf("failed to decode frame: %w", err)
+	}
+	if bid, err = fpdecimal.FromString(frame.Bid); err != nil {
+		return bid, ask, fmt.Errorf("invalid bid %q: %w", frame.Bid, err)
+	}
+	if ask, err = fpdecimal.FromString(frame.Ask); err != nil {
+		return bid, ask, fmt.Errorf("invalid ask %q: %w", frame.Ask, err)
+	}
+	if !bid.GreaterThan(fpdecimal.Zero) || !ask.GreaterThan(fpdecimal.Zero) {
+		return bid, ask, fmt.Errorf("bid %s and ask %s must be positive", bid, ask)
+	}
+	return bid, ask, nil
+}
@@ -0,0 +1,121 @@
+package marketmaker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTickerServer starts a WebSocket server that sends every frame written to
+// frames to the connected client and drops the connection when frames gets nil
+func newTickerServer(t *testing.T, frames <-chan []byte) (*httptest.Server, <-chan string) {
+	t.Helper()
+	paths := make(chan string, 10)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		paths <- r.URL.Path
+
+		for frame := range frames {
+			if frame == nil {
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, paths
+}
+
+func TestWebSocketPriceFetcher(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	frames := make(chan []byte)
+	defer close(frames)
+	srv, paths := newTickerServer(t, frames)
+
+	fetcher, err := NewWebSocketPriceFetcher(WebSocketPriceFetcherConfig{
+		URL:            "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws",
+		Symbol:         "BTCUSDT",
+		ReconnectDelay: 10 * time.Millisecond,
+	}, logger)
+	require.NoError(t, err)
+	defer fetcher.Close()
+
+	assert.Equal(t, "/ws/btcusdt@bookTicker", <-paths)
+	_, err = fetcher.GetMidPrice()
+	assert.ErrorIs(t, err, ErrNoQuote)
+
+	frames <- []byte(`{"u":400900217,"s":"BTCUSDT","b":"100.5","B":"31.2","a":"100.6","A":"40.6"}`)
+	require.Eventually(t, func() bool {
+		mid, err := fetcher.GetMidPrice()
+		return err == nil && mid.String() == "100.550"
+	}, 100*time.Millisecond, time.Millisecond)
+
+	price, err := fetcher.FetchPrice(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, 100.55, price, 1e-9)
+
+	// Malformed frames are skipped
+	frames <- []byte(`{"b":"oops","a":"100.6"}`)
+	frames <- []byte(`{"b":"101","a":"102"}`)
+	require.Eventually(t, func() bool {
+		mid, err := fetcher.GetMidPrice()
+		return err == nil && mid.String() == "101.500"
+	}, 100*time.Millisecond, time.Millisecond)
+
+	// A dropped connection is redialled, and the old quote is not served meanwhile
+	frames <- nil
+	require.Eventually(t, func() bool {
+		_, err := fetcher.GetMidPrice()
+		return err != nil
+	}, 100*time.Millisecond, time.Millisecond)
+	assert.Equal(t, "/ws/btcusdt@bookTicker", <-paths)
+	frames <- []byte(`{"b":"200","a":"202"}`)
+	require.Eventually(t, func() bool {
+		mid, err := fetcher.GetMidPrice()
+		return err == nil && mid.Equal(fpdecimal.FromInt(201))
+	}, 100*time.Millisecond, time.Millisecond)
+
+	require.NoError(t, fetcher.Close())
+	_, err = fetcher.GetMidPrice()
+	assert.ErrorIs(t, err, ErrPriceFeedClosed)
+}
+
+func TestWebSocketPriceFetcher_MaxReconnects(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	fetcher, err := NewWebSocketPriceFetcher(WebSocketPriceFetcherConfig{
+		URL:            "ws" + strings.TrimPrefix(srv.URL, "http"),
+		ReconnectDelay: time.Millisecond,
+		MaxReconnects:  3,
+	}, logger)
+	require.NoError(t, err)
+	defer fetcher.Close()
+
+	// 1+2+4ms of backoff before giving up
+	require.Eventually(t, func() bool {
+		_, err := fetcher.GetMidPrice()
+		return err != ErrNoQuote
+	}, time.Second, time.Millisecond)
+	_, err = fetcher.GetMidPrice()
+	assert.ErrorIs(t, err, ErrPriceFeedClosed)
+	assert.Contains(t, err.Error(), "after 3 reconnects")
+}
@@ -2,15 +2,18 @@ package kafka
 
 import (
 	"context"
+	"sync"
 
 	"github.com/erain9/matchingo/pkg/db/queue"
 	"github.com/erain9/matchingo/pkg/messaging"
 	"github.com/rs/zerolog"
 )
 
-// SetupConsumer initializes and starts the Kafka consumer for processing done messages
-func SetupConsumer(ctx context.Context, logger zerolog.Logger) (*queue.QueueMessageConsumer, error) {
-	kafkaConsumer, err := queue.NewQueueMessageConsumer()
+// SetupConsumer initializes and starts the Kafka consumer for processing done
+// messages. A non-empty topicPrefix reads the topic of every order book
+// instead of the single configured topic.
+func SetupConsumer(ctx context.Context, logger zerolog.Logger, topicPrefix string) (*queue.QueueMessageConsumer, error) {
+	kafkaConsumer, err := queue.NewQueueMessageConsumer(queue.ConsumerConfig{TopicPrefix: topicPrefix})
 	if err != nil {
 		logger.Warn().Err(err).Msg("Failed to create Kafka consumer - continuing without Kafka support")
 		return nil, err
@@ -19,20 +22,9 @@ func SetupConsumer(ctx context.Context, logger zerolog.Logger) (*queue.QueueMess
 	// Start Kafka consumer in a goroutine
 	go func() {
 		logger.Info().Msg("Starting Kafka consumer")
-		err := kafkaConsumer.ConsumeDoneMessages(func(msg *messaging.DoneMessage) error {
-			logger.Info().
-				Str("order_id", msg.OrderID).
-				Str("executed_qty", msg.ExecutedQty).
-				Str("remaining_qty", msg.RemainingQty).
-				Strs("canceled", msg.Canceled).
-				Strs("activated", msg.Activated).
-				Bool("stored", msg.Stored).
-				Str("quantity", msg.Quantity).
-				Str("processed", msg.Processed).
-				Str("left", msg.Left).
-				Interface("trades", msg.Trades).
-				Msg("Received done message")
-			return nil
+		err := kafkaConsumer.ConsumeDoneMessages(&sequenceCheckingProcessor{
+			next:   queue.NewLoggingBatchProcessor(logger),
+			logger: logger,
 		})
 		if err != nil {
 			logger.Error().Err(err).Msg("Kafka consumer error")
@@ -41,3 +33,68 @@ func SetupConsumer(ctx context.Context, logger zerolog.Logger) (*queue.QueueMess
 
 	return kafkaConsumer, nil
 }
+
+// sequenceCheckingProcessor warns about gaps in the sequence numbers of the
+// done messages it passes on to next
+type sequenceCheckingProcessor struct {
+	next   queue.BatchProcessor
+	logger zerolog.Logger
+
+	// mu guards sequence, as batches arrive concurrently from each partition
+	mu       sync.Mutex
+	sequence sequenceChecker
+}
+
+// ProcessBatch checks the sequence numbers of msgs and passes them on
+func (p *sequenceCheckingProcessor) ProcessBatch(ctx context.Context, msgs []*messaging.DoneMessage) error {
+	partition, _ := queue.PartitionFromContext(ctx)
+
+	p.mu.Lock()
+	for _, msg := range msgs {
+		stream := sequenceStream{book: msg.OrderBookName, partition: partition}
+		if expected, ok := p.sequence.check(stream, msg.SequenceNumber); !ok {
+			p.logger.Warn().
+				Str("order_book", msg.OrderBookName).
+				Str("topic", partition.Topic).
+				Int32("partition", partition.ID).
+				Uint64("expected_seq", expected).
+				Uint64("sequence_number", msg.SequenceNumber).
+				Str("order_id", msg.OrderID).
+				Msg("Gap in done message sequence")
+		}
+	}
+	p.mu.Unlock()
+
+	return p.next.ProcessBatch(ctx, msgs)
+}
+
+// sequenceStream is the messages of one order book read from one partition.
+// Kafka keeps the order of messages only within a partition, and each book
+// numbers its messages on its own.
+type sequenceStream struct {
+	book      string
+	partition queue.Partition
+}
+
+// sequenceChecker follows the sequence numbers of consumed done messages,
+// stream by stream
+type sequenceChecker struct {
+	expected map[sequenceStream]uint64
+}
+
+// check returns the sequence number expected next on stream and whether seq
+// is it. Messages without a sequence number, such as cancellations, the
+// first numbered message of a stream and a 1, which a book restarts from
+// when it is reset, always pass. After a gap, checking resumes from seq.
+func (c *sequenceChecker) check(stream sequenceStream, seq uint64) (expected uint64, ok bool) {
+	if seq == 0 {
+		return c.expected[stream], true
+	}
+	if c.expected == nil {
+		c.expected = make(map[sequenceStream]uint64)
+	}
+	expected = c.expected[stream]
+	ok = expected == 0 || seq == expected || seq == 1
+	c.expected[stream] = seq + 1
+	return expected, ok
+}
@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/db/queue"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequenceChecker(t *testing.T) {
+	var c sequenceChecker
+	btc := sequenceStream{book: "btc-usd", partition: queue.Partition{Topic: "done", ID: 0}}
+	eth := sequenceStream{book: "eth-usd", partition: queue.Partition{Topic: "done", ID: 1}}
+
+	// Books are numbered on their own, so interleaving them is no gap
+	for _, step := range []struct {
+		stream sequenceStream
+		seq    uint64
+	}{{btc, 1}, {eth, 1}, {btc, 2}, {eth, 2}, {btc, 0}, {btc, 3}} {
+		_, ok := c.check(step.stream, step.seq)
+		assert.True(t, ok, "%s %d", step.stream.book, step.seq)
+	}
+
+	expected, ok := c.check(btc, 5)
+	assert.False(t, ok)
+	assert.Equal(t, uint64(4), expected)
+	// Checking resumes after the gap
+	_, ok = c.check(btc, 6)
+	assert.True(t, ok)
+
+	// A reset book starts again from 1
+	_, ok = c.check(eth, 1)
+	assert.True(t, ok)
+	_, ok = c.check(eth, 3)
+	assert.False(t, ok)
+
+	// A book read from a partition it moved to is followed separately
+	_, ok = c.check(sequenceStream{book: "btc-usd", partition: queue.Partition{Topic: "done", ID: 2}}, 42)
+	assert.True(t, ok)
+	_, ok = c.check(btc, 7)
+	assert.True(t, ok)
+}
+
+func TestSequenceCheckingProcessor(t *testing.T) {
+	var logs bytes.Buffer
+	var received int
+	p := &sequenceCheckingProcessor{
+		next:   processorFunc(func(_ context.Context, msgs []*messaging.DoneMessage) error { received += len(msgs); return nil }),
+		logger: zerolog.New(&logs),
+	}
+
+	// Batches of two partitions interleave without a gap being reported
+	batch := func(partition int32, seqs ...uint64) {
+		t.Helper()
+		ctx := queue.ContextWithPartition(context.Background(), queue.Partition{Topic: "done", ID: partition})
+		msgs := make([]*messaging.DoneMessage, len(seqs))
+		for i, seq := range seqs {
+			msgs[i] = &messaging.DoneMessage{OrderBookName: "btc-usd", SequenceNumber: seq}
+		}
+		assert.NoError(t, p.ProcessBatch(ctx, msgs))
+	}
+	batch(0, 1, 2)
+	batch(1, 10, 11)
+	batch(0, 3)
+	batch(1, 12)
+	assert.Empty(t, logs.String())
+
+	batch(0, 5)
+	assert.Contains(t, logs.String(), "Gap in done message sequence")
+	assert.Equal(t, 7, received, "messages after a gap are passed on")
+}
+
+// processorFunc adapts a function to queue.BatchProcessor
+type processorFunc func(ctx context.Context, msgs []*messaging.DoneMessage) error
+
+func (f processorFunc) ProcessBatch(ctx context.Context, msgs []*messaging.DoneMessage) error {
+	return f(ctx, msgs)
+}
+
+var _ queue.BatchProcessor = processorFunc(nil)
@@ -2,7 +2,6 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -17,22 +16,53 @@ type KafkaMessageSender struct {
 	writer     *kafka.Writer
 	topic      string
 	propagator propagation.TextMapPropagator
+	serializer messaging.Serializer
+	router     messaging.TopicRouter
+}
+
+// Option configures a KafkaMessageSender
+type Option func(*KafkaMessageSender)
+
+// WithSerializer sets the format messages are written in. The default is JSON.
+func WithSerializer(s messaging.Serializer) Option {
+	return func(k *KafkaMessageSender) {
+		k.serializer = s
+	}
+}
+
+// WithTopicRouter sends the messages of each order book to the topic router
+// picks for it. Messages without an order book name still go to the
+// sender's topic. Topics that do not exist yet are created on first use.
+func WithTopicRouter(router messaging.TopicRouter) Option {
+	return func(k *KafkaMessageSender) {
+		k.router = router
+	}
 }
 
 // NewKafkaMessageSender creates a new Kafka message sender
-func NewKafkaMessageSender(brokerAddr, topic string) (*KafkaMessageSender, error) {
+func NewKafkaMessageSender(brokerAddr, topic string, opts ...Option) (*KafkaMessageSender, error) {
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(brokerAddr),
 		Topic:        topic,
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     &kafka.Hash{},
 		BatchTimeout: 10 * time.Millisecond,
 	}
 
-	return &KafkaMessageSender{
+	sender := &KafkaMessageSender{
 		writer:     writer,
 		topic:      topic,
 		propagator: otel.GetTextMapPropagator(),
-	}, nil
+		serializer: messaging.JSONSerializer{},
+	}
+	for _, opt := range opts {
+		opt(sender)
+	}
+	if sender.router != nil {
+		// Each message names its topic, which the writer only allows without one of its own
+		writer.Topic = ""
+		writer.AllowAutoTopicCreation = true
+	}
+	return sender, nil
 }
 
 // kafkaHeadersCarrier implements TextMapCarrier for Kafka message headers
@@ -64,22 +94,28 @@ func (c *kafkaHeadersCarrier) Keys() []string {
 
 // SendDoneMessage sends a done message to Kafka
 func (k *KafkaMessageSender) SendDoneMessage(ctx context.Context, done *messaging.DoneMessage) error {
-	data, err := json.Marshal(done)
+	data, err := k.serializer.Marshal(done)
 	if err != nil {
 		return fmt.Errorf("failed to marshal done message: %w", err)
 	}
 
 	// Create headers carrier and inject trace context
-	headers := make(kafkaHeadersCarrier, 0)
+	headers := kafkaHeadersCarrier{{
+		Key:   messaging.ContentTypeHeader,
+		Value: []byte(k.serializer.ContentType()),
+	}}
 	k.propagator.Inject(ctx, &headers)
 
 	// Create a Kafka message with trace context headers
 	msg := kafka.Message{
-		Key:     []byte(done.OrderID),
+		Key:     messaging.PartitionKey(done),
 		Value:   data,
 		Time:    time.Now(),
 		Headers: []kafka.Header(headers),
 	}
+	if k.router != nil {
+		msg.Topic = k.topicFor(done)
+	}
 
 	// Create timeout context while preserving parent context
 	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -94,6 +130,20 @@ func (k *KafkaMessageSender) SendDoneMessage(ctx context.Context, done *messagin
 	return nil
 }
 
+// topicFor returns the topic done is sent to when the sender has a router
+func (k *KafkaMessageSender) topicFor(done *messaging.DoneMessage) string {
+	if done.OrderBookName == "" {
+		return k.topic
+	}
+	return k.router.Route(done.OrderBookName)
+}
+
+// SendCancelMessage sends a cancellation to Kafka on the topic of its order
+// book's done messages
+func (k *KafkaMessageSender) SendCancelMessage(ctx context.Context, cancel *messaging.CancelMessage) error {
+	return k.SendDoneMessage(ctx, cancel.ToDoneMessage())
+}
+
 // Close closes the Kafka writer
 func (k *KafkaMessageSender) Close() error {
 	return k.writer.Close()
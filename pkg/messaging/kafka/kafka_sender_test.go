@@ -0,0 +1,26 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicRouter(t *testing.T) {
+	sender, err := NewKafkaMessageSender("localhost:9092", "matchingo-test", WithTopicRouter(messaging.PrefixRouter{Prefix: "matchingo"}))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	assert.Empty(t, sender.writer.Topic)
+	assert.Equal(t, "matchingo.btc-usd", sender.topicFor(&messaging.DoneMessage{OrderBookName: "btc-usd"}))
+	assert.Equal(t, "matchingo.eth-usd", sender.topicFor((&messaging.CancelMessage{OrderBookName: "eth-usd"}).ToDoneMessage()))
+	// Messages of unnamed books keep the sender's topic
+	assert.Equal(t, "matchingo-test", sender.topicFor(&messaging.DoneMessage{}))
+
+	plain, err := NewKafkaMessageSender("localhost:9092", "matchingo-test")
+	require.NoError(t, err)
+	defer plain.Close()
+	assert.Equal(t, "matchingo-test", plain.writer.Topic)
+}
@@ -1,12 +1,16 @@
 package messaging
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // MessageSender defines an interface for sending messages
 // This helps decouple the core package from specific implementations
 // like Kafka in the queue package
 type MessageSender interface {
 	SendDoneMessage(ctx context.Context, done *DoneMessage) error
+	SendCancelMessage(ctx context.Context, cancel *CancelMessage) error
 	Close() error
 }
 
@@ -20,10 +24,95 @@ type DoneMessage struct {
 	Canceled     []string
 	Activated    []string
 	Stored       bool
-	Quantity     string
-	Processed    string
-	Left         string
-	UserAddress  string // User's wallet address
+	// StopActivated is set when the order is a stop order that was activated
+	StopActivated bool
+	Quantity      string
+	Processed     string
+	Left          string
+	UserAddress   string // User's wallet address
+	// Cancel is set when this message reports an order cancellation
+	Cancel *CancelMessage
+	// RequestID identifies the request that produced this message, if known
+	RequestID string
+	// SequenceNumber orders the done messages of one order book, starting
+	// from 1. Cancel messages carry none.
+	SequenceNumber uint64
+	// MatchDurationMs is how long matching the order took in milliseconds,
+	// or zero if it was not timed
+	MatchDurationMs float64
+	// PegType is the price a pegged order follows, such as "MID"; empty
+	// for orders that are not pegged
+	PegType string
+	// EffectivePrice is the price a pegged order was placed at when
+	// processed; empty for orders that are not pegged
+	EffectivePrice string
+	// CancelledByOCO is set when the message reports the other leg of an
+	// OCO pair being canceled because one leg filled
+	CancelledByOCO bool
+	// STPTriggered is set when self-trade prevention canceled the order or
+	// a resting order it reached
+	STPTriggered bool
+	// HaltReason is set when the order's trade tripped the book's circuit
+	// breaker and says why matching was halted
+	HaltReason string
+	// OrderBookName is the order book the message comes from; empty for
+	// books created without a name
+	OrderBookName string
+	// Side, Price, OrderType and TimeInForce describe the processed order,
+	// so a resting order can be rebuilt from its message. Side is "BUY" or
+	// "SELL"; Price is empty for cancellations.
+	Side        string
+	Price       string
+	OrderType   string
+	TimeInForce string
+	// ExpiresAt is when a GTD order expires; zero for other orders
+	ExpiresAt time.Time
+	// Amended is set when the message reports an amendment of a resting
+	// order: Price, Left and RemainingQty are its new price and remaining
+	// quantity
+	Amended bool
+	// Iceberg is set when the order is an iceberg order
+	Iceberg bool
+	// OCOID is the OCO group the order belongs to; empty for orders placed
+	// alone
+	OCOID string
+}
+
+// CancelReason describes why an order was canceled
+type CancelReason string
+
+// Cancellation reasons
+const (
+	CancelReasonUserRequested CancelReason = "USER_REQUESTED"
+	CancelReasonOCOTriggered  CancelReason = "OCO_TRIGGERED"
+	CancelReasonStopActivated CancelReason = "STOP_ACTIVATED"
+	CancelReasonExpired       CancelReason = "EXPIRED"
+	CancelReasonSTP           CancelReason = "STP"
+)
+
+// CancelMessage represents an order cancellation to be sent to Kafka
+type CancelMessage struct {
+	OrderID       string
+	CanceledAt    time.Time
+	CancelReason  CancelReason
+	RemainingQty  string
+	UserAddress   string // User's wallet address
+	OrderBookName string
+}
+
+// ToDoneMessage wraps the cancellation in a DoneMessage so it can share
+// the done message topic with execution reports
+func (c *CancelMessage) ToDoneMessage() *DoneMessage {
+	return &DoneMessage{
+		OrderID:        c.OrderID,
+		RemainingQty:   c.RemainingQty,
+		Canceled:       []string{c.OrderID},
+		UserAddress:    c.UserAddress,
+		Cancel:         c,
+		CancelledByOCO: c.CancelReason == CancelReasonOCOTriggered,
+		STPTriggered:   c.CancelReason == CancelReasonSTP,
+		OrderBookName:  c.OrderBookName,
+	}
 }
 
 // Trade represents a single trade execution
@@ -34,4 +123,15 @@ type Trade struct {
 	Quantity    string
 	IsQuote     bool
 	UserAddress string // User's wallet address
+	// Fees owed on a fill by its maker and by the taker, from the order
+	// book's fee schedule; empty when nothing is owed, as on the taker's
+	// own entry
+	MakerFee string
+	TakerFee string
+}
+
+// PartitionKey returns the Kafka message key of done: its order book's name,
+// so that the messages of one book go to one partition and keep their order
+func PartitionKey(done *DoneMessage) []byte {
+	return []byte(done.OrderBookName)
 }
@@ -2,7 +2,9 @@ package messaging
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
 )
 
 // MockMessageSender implements the MessageSender interface for testing purposes.
@@ -11,6 +13,16 @@ type MockMessageSender struct {
 	mu           sync.Mutex
 	SentMessages []*DoneMessage
 	SendError    error // Optional error to return on SendDoneMessage
+
+	// arrived, if set, is closed when the next message is captured
+	arrived      chan struct{}
+	expectations []expectation
+}
+
+// expectation is a channel to close once count messages have been captured
+type expectation struct {
+	count int
+	ch    chan struct{}
 }
 
 // NewMockMessageSender creates a new mock sender.
@@ -30,6 +42,36 @@ func (m *MockMessageSender) SendDoneMessage(ctx context.Context, done *DoneMessa
 	}
 
 	m.SentMessages = append(m.SentMessages, done)
+	m.notify()
+	return nil
+}
+
+// notify wakes waiters after a message is captured. The caller must hold m.mu.
+func (m *MockMessageSender) notify() {
+	if m.arrived != nil {
+		close(m.arrived)
+		m.arrived = nil
+	}
+
+	pending := m.expectations[:0]
+	for _, e := range m.expectations {
+		if len(m.SentMessages) == e.count {
+			close(e.ch)
+		} else {
+			pending = append(pending, e)
+		}
+	}
+	m.expectations = pending
+}
+
+// SendCancelMessage captures the cancellation wrapped in a DoneMessage and
+// returns an optional pre-configured error.
+func (m *MockMessageSender) SendCancelMessage(ctx context.Context, cancel *CancelMessage) error {
+	return m.SendDoneMessage(ctx, cancel.ToDoneMessage())
+}
+
+// Close is a no-op for the mock sender.
+func (m *MockMessageSender) Close() error {
 	return nil
 }
 
@@ -44,6 +86,61 @@ func (m *MockMessageSender) GetSentMessages() []*DoneMessage {
 	return msgsCopy
 }
 
+// SetExpectedMessages returns a channel that is closed when the number of
+// captured messages reaches n, so tests can wait for asynchronous messages
+// instead of sleeping. The channel is closed at once if n messages have
+// already been captured.
+func (m *MockMessageSender) SetExpectedMessages(n int) <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch := make(chan struct{})
+	if len(m.SentMessages) >= n {
+		close(ch)
+		return ch
+	}
+	m.expectations = append(m.expectations, expectation{count: n, ch: ch})
+	return ch
+}
+
+// WaitForMessages blocks until at least n messages have been captured and
+// returns a copy of all of them. If ctx is done first, it returns the
+// messages captured so far with an error wrapping ctx.Err().
+func (m *MockMessageSender) WaitForMessages(ctx context.Context, n int) ([]*DoneMessage, error) {
+	for {
+		m.mu.Lock()
+		msgs := make([]*DoneMessage, len(m.SentMessages))
+		copy(msgs, m.SentMessages)
+		if m.arrived == nil {
+			m.arrived = make(chan struct{})
+		}
+		arrived := m.arrived
+		m.mu.Unlock()
+
+		if len(msgs) >= n {
+			return msgs, nil
+		}
+		select {
+		case <-arrived:
+		case <-ctx.Done():
+			return msgs, fmt.Errorf("received %d of %d messages: %w", len(msgs), n, ctx.Err())
+		}
+	}
+}
+
+// DrainMessages discards every captured message and any that arrive within
+// timeout or before ctx is done. Call it when tearing down a test so that
+// late messages from its goroutines do not reach the next test.
+func (m *MockMessageSender) DrainMessages(ctx context.Context, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	m.ClearSentMessages()
+}
+
 // ClearSentMessages removes all captured messages.
 func (m *MockMessageSender) ClearSentMessages() {
 	m.mu.Lock()
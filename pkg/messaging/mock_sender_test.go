@@ -0,0 +1,54 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockMessageSender_Wait(t *testing.T) {
+	ctx := context.Background()
+	sender := NewMockMessageSender()
+
+	expected := sender.SetExpectedMessages(2)
+	go func() {
+		for _, id := range []string{"a", "b"} {
+			time.Sleep(5 * time.Millisecond)
+			assert.NoError(t, sender.SendDoneMessage(ctx, &DoneMessage{OrderID: id}))
+		}
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	msgs, err := sender.WaitForMessages(waitCtx, 2)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "b", msgs[1].OrderID)
+
+	select {
+	case <-expected:
+	default:
+		t.Fatal("expectation not met after two messages")
+	}
+
+	// Waiting times out when too few messages arrive
+	shortCtx, cancelShort := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancelShort()
+	msgs, err = sender.WaitForMessages(shortCtx, 3)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, msgs, 2)
+
+	// Late messages are drained along with the captured ones
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		assert.NoError(t, sender.SendDoneMessage(ctx, &DoneMessage{OrderID: "late"}))
+	}()
+	sender.DrainMessages(ctx, 50*time.Millisecond)
+	assert.Empty(t, sender.GetSentMessages())
+
+	// An expectation already met is closed at once
+	<-sender.SetExpectedMessages(0)
+}
@@ -0,0 +1,182 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/db/queue"
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+)
+
+// NATSQueueMessageConsumer consumes done messages from JetStream through a
+// durable consumer, the counterpart of a Kafka consumer group
+type NATSQueueMessageConsumer struct {
+	conn     *nats.Conn
+	consumer jetstream.Consumer
+	config   queue.ConsumerConfig
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewNATSQueueMessageConsumer connects to the NATS servers at url and binds
+// to the durable consumer named by config.GroupID, creating it if needed. A
+// new durable consumer starts from the newest message. NumWorkers is not
+// used: messages are decoded as they arrive.
+func NewNATSQueueMessageConsumer(url string, config queue.ConsumerConfig) (*NATSQueueMessageConsumer, error) {
+	if config.GroupID == "" {
+		config.GroupID = queue.DefaultConsumerGroup
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = queue.DefaultBatchSize
+	}
+
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	setupCtx, cancelSetup := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelSetup()
+	if err := ensureStream(setupCtx, js); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	consumer, err := js.CreateOrUpdateConsumer(setupCtx, StreamName, jetstream.ConsumerConfig{
+		Durable:       config.GroupID,
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+		// Acknowledging the last message of a batch acknowledges the batch
+		AckPolicy:     jetstream.AckAllPolicy,
+		FilterSubject: SubjectPrefix + ">",
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream consumer %s: %w", config.GroupID, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &NATSQueueMessageConsumer{
+		conn:     conn,
+		consumer: consumer,
+		config:   config,
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// Close stops consuming and closes the NATS connection. The durable consumer
+// keeps its position for the next consumer bound to it.
+func (c *NATSQueueMessageConsumer) Close() error {
+	c.cancel()
+	c.conn.Close()
+	return nil
+}
+
+// ConsumeDoneMessages consumes DoneMessages until the consumer is closed,
+// handing them to processor in batches of up to config.BatchSize. Each batch
+// is every message already received, so a message never waits for a batch
+// to fill.
+func (c *NATSQueueMessageConsumer) ConsumeDoneMessages(processor queue.BatchProcessor) error {
+	received := make(chan jetstream.Msg, c.config.BatchSize)
+	consumeCtx, err := c.consumer.Consume(func(msg jetstream.Msg) {
+		select {
+		case received <- msg:
+		case <-c.ctx.Done():
+		}
+	}, jetstream.PullMaxMessages(c.config.BatchSize))
+	if err != nil {
+		return fmt.Errorf("failed to consume done messages: %w", err)
+	}
+	defer consumeCtx.Stop()
+
+	batch := make([]jetstream.Msg, 0, c.config.BatchSize)
+	for {
+		select {
+		case msg := <-received:
+			batch = append(batch[:0], msg)
+		fill:
+			for len(batch) < c.config.BatchSize {
+				select {
+				case msg := <-received:
+					batch = append(batch, msg)
+				default:
+					break fill
+				}
+			}
+			c.processBatch(processor, batch)
+
+		case <-c.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// processBatch decodes and processes batch, then acknowledges it. A batch
+// the processor fails is logged and not redelivered, as with Kafka.
+func (c *NATSQueueMessageConsumer) processBatch(processor queue.BatchProcessor, batch []jetstream.Msg) {
+	logger := logging.FromContext(c.ctx).With().
+		Str("stream", StreamName).
+		Str("consumer", c.config.GroupID).
+		Logger()
+	msgs := make([]*messaging.DoneMessage, 0, len(batch))
+	for _, msg := range batch {
+		done := &messaging.DoneMessage{}
+		if err := UnmarshalDoneMessage(msg, done); err != nil {
+			logger.Error().Err(err).Str("subject", msg.Subject()).Msg("Failed to unmarshal message")
+			continue
+		}
+		msgs = append(msgs, done)
+	}
+	if len(msgs) > 0 {
+		if err := processor.ProcessBatch(c.ctx, msgs); err != nil {
+			logger.Error().Err(err).Int("messages", len(msgs)).Msg("Failed to process message batch")
+		}
+	}
+
+	if err := batch[len(batch)-1].Ack(); err != nil {
+		logger.Error().Err(err).Int("messages", len(batch)).Msg("Failed to acknowledge message batch")
+	}
+}
+
+// UnmarshalDoneMessage decodes msg with the serializer named by its
+// content-type header, defaulting to JSON
+func UnmarshalDoneMessage(msg jetstream.Msg, done *messaging.DoneMessage) error {
+	contentType := msg.Headers().Get(messaging.ContentTypeHeader)
+	if contentType == "" {
+		contentType = messaging.ContentTypeJSON
+	}
+
+	serializer, err := messaging.SerializerForContentType(contentType)
+	if err != nil {
+		return err
+	}
+	return serializer.Unmarshal(msg.Data(), done)
+}
+
+// SetupConsumer creates a consumer of the done messages on the NATS servers
+// at url and starts logging them
+func SetupConsumer(ctx context.Context, logger zerolog.Logger, url string) (*NATSQueueMessageConsumer, error) {
+	consumer, err := NewNATSQueueMessageConsumer(url, queue.ConsumerConfig{})
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to create NATS consumer - continuing without NATS support")
+		return nil, err
+	}
+
+	go func() {
+		logger.Info().Msg("Starting NATS consumer")
+		if err := consumer.ConsumeDoneMessages(queue.NewLoggingBatchProcessor(logger)); err != nil {
+			logger.Error().Err(err).Msg("NATS consumer error")
+		}
+	}()
+
+	return consumer, nil
+}
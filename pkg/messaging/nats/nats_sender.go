@@ -0,0 +1,143 @@
+// Package nats publishes and consumes done messages through NATS JetStream,
+// as an alternative to Kafka. Messages are encoded by the same serializers
+// and carry the same content-type header, so a consumer reads them the same
+// way from either broker.
+package nats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// SubjectPrefix starts the subject of every done message; the rest of
+	// the subject is the name of the message's order book
+	SubjectPrefix = "matchingo.trades."
+	// StreamName is the JetStream stream persisting the done message subjects
+	StreamName = "MATCHINGO_TRADES"
+	// unnamedBook is the subject token used for books created without a name
+	unnamedBook = "_"
+)
+
+// Subject returns the subject the done messages of the named order book are
+// published on
+func Subject(bookName string) string {
+	if bookName == "" {
+		bookName = unnamedBook
+	}
+	return SubjectPrefix + bookName
+}
+
+// ensureStream creates the stream holding every done message subject, or
+// updates it to match this version's configuration
+func ensureStream(ctx context.Context, js jetstream.JetStream) error {
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     StreamName,
+		Subjects: []string{SubjectPrefix + ">"},
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create JetStream stream %s: %w", StreamName, err)
+	}
+	return nil
+}
+
+// NATSMessageSender implements MessageSender using NATS JetStream
+type NATSMessageSender struct {
+	conn       *nats.Conn
+	js         jetstream.JetStream
+	propagator propagation.TextMapPropagator
+	serializer messaging.Serializer
+}
+
+// Option configures a NATSMessageSender
+type Option func(*NATSMessageSender)
+
+// WithSerializer sets the format messages are written in. The default is JSON.
+func WithSerializer(s messaging.Serializer) Option {
+	return func(n *NATSMessageSender) {
+		n.serializer = s
+	}
+}
+
+// NewNATSMessageSender connects to the NATS servers at url, a comma-separated
+// list, and creates the done message stream if it does not exist yet
+func NewNATSMessageSender(url string, opts ...Option) (*NATSMessageSender, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ensureStream(ctx, js); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	sender := &NATSMessageSender{
+		conn:       conn,
+		js:         js,
+		propagator: otel.GetTextMapPropagator(),
+		serializer: messaging.JSONSerializer{},
+	}
+	for _, opt := range opts {
+		opt(sender)
+	}
+	return sender, nil
+}
+
+// SendDoneMessage publishes a done message on the subject of its order book
+// and waits for JetStream to store it
+func (n *NATSMessageSender) SendDoneMessage(ctx context.Context, done *messaging.DoneMessage) error {
+	data, err := n.serializer.Marshal(done)
+	if err != nil {
+		return fmt.Errorf("failed to marshal done message: %w", err)
+	}
+
+	msg := &nats.Msg{
+		Subject: Subject(done.OrderBookName),
+		Data:    data,
+		Header:  nats.Header{},
+	}
+	msg.Header.Set(messaging.ContentTypeHeader, n.serializer.ContentType())
+
+	// Inject OpenTelemetry context into headers
+	carrier := propagation.MapCarrier{}
+	n.propagator.Inject(ctx, carrier)
+	for k, v := range carrier {
+		msg.Header.Set(k, v)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := n.js.PublishMsg(timeoutCtx, msg); err != nil {
+		return fmt.Errorf("failed to publish message to NATS: %w", err)
+	}
+	return nil
+}
+
+// SendCancelMessage publishes a cancellation on the subject of its order
+// book's done messages
+func (n *NATSMessageSender) SendCancelMessage(ctx context.Context, cancel *messaging.CancelMessage) error {
+	return n.SendDoneMessage(ctx, cancel.ToDoneMessage())
+}
+
+// Close closes the NATS connection
+func (n *NATSMessageSender) Close() error {
+	n.conn.Close()
+	return nil
+}
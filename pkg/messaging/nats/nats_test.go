@@ -0,0 +1,146 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/db/queue"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runServer starts an in-process NATS server with JetStream and returns its URL
+func runServer(t *testing.T) string {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	require.NoError(t, err)
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(5*time.Second), "NATS server not ready")
+	return srv.ClientURL()
+}
+
+// recordingProcessor keeps every message it is handed
+type recordingProcessor struct {
+	mu   sync.Mutex
+	msgs []*messaging.DoneMessage
+}
+
+func (p *recordingProcessor) ProcessBatch(_ context.Context, msgs []*messaging.DoneMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func (p *recordingProcessor) received() []*messaging.DoneMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*messaging.DoneMessage(nil), p.msgs...)
+}
+
+func TestNATSMessageSender(t *testing.T) {
+	url := runServer(t)
+	ctx := context.Background()
+
+	conn, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer conn.Close()
+	raw, err := conn.SubscribeSync(SubjectPrefix + ">")
+	require.NoError(t, err)
+
+	sender, err := NewNATSMessageSender(url)
+	require.NoError(t, err)
+	defer sender.Close()
+
+	done := &messaging.DoneMessage{
+		OrderID:        "order-1",
+		ExecutedQty:    "1.000",
+		RemainingQty:   "0",
+		OrderBookName:  "btc-usd",
+		SequenceNumber: 7,
+		Trades: []messaging.Trade{
+			{OrderID: "order-1", Role: "TAKER", Price: "100.000", Quantity: "1.000"},
+		},
+	}
+	require.NoError(t, sender.SendDoneMessage(ctx, done))
+
+	msg, err := raw.NextMsg(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "matchingo.trades.btc-usd", msg.Subject)
+	assert.Equal(t, messaging.ContentTypeJSON, msg.Header.Get(messaging.ContentTypeHeader))
+
+	// The payload is the one the Kafka sender writes
+	expected, err := json.Marshal(done)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(msg.Data))
+
+	require.NoError(t, sender.SendDoneMessage(ctx, &messaging.DoneMessage{OrderID: "order-2"}))
+	msg, err = raw.NextMsg(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "matchingo.trades._", msg.Subject)
+}
+
+func TestNATSQueueMessageConsumer(t *testing.T) {
+	url := runServer(t)
+	ctx := context.Background()
+
+	consumer, err := NewNATSQueueMessageConsumer(url, queue.ConsumerConfig{GroupID: "test-group", BatchSize: 2})
+	require.NoError(t, err)
+	processor := &recordingProcessor{}
+	consumed := make(chan error, 1)
+	go func() { consumed <- consumer.ConsumeDoneMessages(processor) }()
+
+	sender, err := NewNATSMessageSender(url, WithSerializer(messaging.ProtoSerializer{}))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	for _, id := range []string{"order-1", "order-2", "order-3"} {
+		require.NoError(t, sender.SendDoneMessage(ctx, &messaging.DoneMessage{OrderID: id, OrderBookName: "btc-usd"}))
+	}
+	require.NoError(t, sender.SendCancelMessage(ctx, &messaging.CancelMessage{
+		OrderID:       "order-1",
+		CancelReason:  messaging.CancelReasonUserRequested,
+		OrderBookName: "btc-usd",
+	}))
+
+	require.Eventually(t, func() bool { return len(processor.received()) == 4 }, 5*time.Second, 10*time.Millisecond)
+	received := processor.received()
+	for i, id := range []string{"order-1", "order-2", "order-3"} {
+		assert.Equal(t, id, received[i].OrderID)
+		assert.Equal(t, "btc-usd", received[i].OrderBookName)
+	}
+	require.NotNil(t, received[3].Cancel)
+	assert.Equal(t, "order-1", received[3].Cancel.OrderID)
+
+	require.NoError(t, consumer.Close())
+	select {
+	case err := <-consumed:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ConsumeDoneMessages did not return after Close")
+	}
+
+	// A consumer bound to the same durable consumer resumes after the
+	// acknowledged messages
+	resumed, err := NewNATSQueueMessageConsumer(url, queue.ConsumerConfig{GroupID: "test-group"})
+	require.NoError(t, err)
+	defer resumed.Close()
+	processor = &recordingProcessor{}
+	go resumed.ConsumeDoneMessages(processor)
+
+	require.NoError(t, sender.SendDoneMessage(ctx, &messaging.DoneMessage{OrderID: "order-4", OrderBookName: "btc-usd"}))
+	require.Eventually(t, func() bool { return len(processor.received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "order-4", processor.received()[0].OrderID)
+}
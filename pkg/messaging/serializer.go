@@ -0,0 +1,464 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	orderbookpb "github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ContentTypeHeader is the Kafka message header that carries the serializer's
+// content type, so consumers can pick the matching deserializer
+const ContentTypeHeader = "content-type"
+
+// Content types written by the built-in serializers
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeAvro     = "application/avro"
+)
+
+// Serializer converts DoneMessages to and from their wire format
+type Serializer interface {
+	Marshal(msg *DoneMessage) ([]byte, error)
+	Unmarshal(data []byte, msg *DoneMessage) error
+	ContentType() string
+}
+
+// SerializerForContentType returns the built-in serializer for contentType
+func SerializerForContentType(contentType string) (Serializer, error) {
+	switch contentType {
+	case ContentTypeJSON:
+		return JSONSerializer{}, nil
+	case ContentTypeProtobuf:
+		return ProtoSerializer{}, nil
+	case ContentTypeAvro:
+		s, err := sharedAvroSerializer()
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+}
+
+// JSONSerializer encodes DoneMessages with encoding/json
+type JSONSerializer struct{}
+
+// Marshal encodes msg as JSON
+func (JSONSerializer) Marshal(msg *DoneMessage) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+// Unmarshal decodes JSON data into msg
+func (JSONSerializer) Unmarshal(data []byte, msg *DoneMessage) error {
+	return json.Unmarshal(data, msg)
+}
+
+// ContentType returns ContentTypeJSON
+func (JSONSerializer) ContentType() string {
+	return ContentTypeJSON
+}
+
+// ProtoSerializer encodes DoneMessages as the DoneMessage defined in orderbook.proto
+type ProtoSerializer struct{}
+
+// Marshal encodes msg as protobuf
+func (ProtoSerializer) Marshal(msg *DoneMessage) ([]byte, error) {
+	return proto.Marshal(doneMessageToProto(msg))
+}
+
+// Unmarshal decodes protobuf data into msg
+func (ProtoSerializer) Unmarshal(data []byte, msg *DoneMessage) error {
+	protoMsg := &orderbookpb.DoneMessage{}
+	if err := proto.Unmarshal(data, protoMsg); err != nil {
+		return err
+	}
+	*msg = *doneMessageFromProto(protoMsg)
+	return nil
+}
+
+// ContentType returns ContentTypeProtobuf
+func (ProtoSerializer) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func doneMessageToProto(done *DoneMessage) *orderbookpb.DoneMessage {
+	protoMsg := &orderbookpb.DoneMessage{
+		OrderId:           done.OrderID,
+		ExecutedQuantity:  done.ExecutedQty,
+		RemainingQuantity: done.RemainingQty,
+		Canceled:          done.Canceled,
+		Activated:         done.Activated,
+		Stored:            done.Stored,
+		Quantity:          done.Quantity,
+		Processed:         done.Processed,
+		Left:              done.Left,
+		UserAddress:       done.UserAddress,
+		RequestId:         done.RequestID,
+		SequenceNumber:    done.SequenceNumber,
+		MatchDurationMs:   done.MatchDurationMs,
+		StopActivated:     done.StopActivated,
+		PegType:           done.PegType,
+		EffectivePrice:    done.EffectivePrice,
+		CancelledByOco:    done.CancelledByOCO,
+		StpTriggered:      done.STPTriggered,
+		HaltReason:        done.HaltReason,
+		OrderBookName:     done.OrderBookName,
+		Side:              done.Side,
+		Price:             done.Price,
+		OrderType:         done.OrderType,
+		TimeInForce:       done.TimeInForce,
+		Amended:           done.Amended,
+		Iceberg:           done.Iceberg,
+		OcoId:             done.OCOID,
+	}
+	if !done.ExpiresAt.IsZero() {
+		protoMsg.ExpiresAt = timestamppb.New(done.ExpiresAt)
+	}
+
+	if len(done.Trades) > 0 {
+		protoMsg.Trades = make([]*orderbookpb.Trade, 0, len(done.Trades))
+		for _, trade := range done.Trades {
+			protoMsg.Trades = append(protoMsg.Trades, &orderbookpb.Trade{
+				OrderId:     trade.OrderID,
+				Role:        trade.Role,
+				Price:       trade.Price,
+				Quantity:    trade.Quantity,
+				IsQuote:     trade.IsQuote,
+				UserAddress: trade.UserAddress,
+				MakerFee:    trade.MakerFee,
+				TakerFee:    trade.TakerFee,
+			})
+		}
+	}
+
+	if done.Cancel != nil {
+		protoMsg.Cancel = &orderbookpb.CancelMessage{
+			OrderId:           done.Cancel.OrderID,
+			CanceledAt:        timestamppb.New(done.Cancel.CanceledAt),
+			CancelReason:      orderbookpb.CancelReason(orderbookpb.CancelReason_value[string(done.Cancel.CancelReason)]),
+			RemainingQuantity: done.Cancel.RemainingQty,
+			UserAddress:       done.Cancel.UserAddress,
+		}
+	}
+	return protoMsg
+}
+
+func doneMessageFromProto(protoMsg *orderbookpb.DoneMessage) *DoneMessage {
+	done := &DoneMessage{
+		OrderID:         protoMsg.OrderId,
+		ExecutedQty:     protoMsg.ExecutedQuantity,
+		RemainingQty:    protoMsg.RemainingQuantity,
+		Canceled:        protoMsg.Canceled,
+		Activated:       protoMsg.Activated,
+		Stored:          protoMsg.Stored,
+		Quantity:        protoMsg.Quantity,
+		Processed:       protoMsg.Processed,
+		Left:            protoMsg.Left,
+		UserAddress:     protoMsg.UserAddress,
+		RequestID:       protoMsg.RequestId,
+		SequenceNumber:  protoMsg.SequenceNumber,
+		MatchDurationMs: protoMsg.MatchDurationMs,
+		StopActivated:   protoMsg.StopActivated,
+		PegType:         protoMsg.PegType,
+		EffectivePrice:  protoMsg.EffectivePrice,
+		CancelledByOCO:  protoMsg.CancelledByOco,
+		STPTriggered:    protoMsg.StpTriggered,
+		HaltReason:      protoMsg.HaltReason,
+		OrderBookName:   protoMsg.OrderBookName,
+		Side:            protoMsg.Side,
+		Price:           protoMsg.Price,
+		OrderType:       protoMsg.OrderType,
+		TimeInForce:     protoMsg.TimeInForce,
+		Amended:         protoMsg.Amended,
+		Iceberg:         protoMsg.Iceberg,
+		OCOID:           protoMsg.OcoId,
+	}
+	if protoMsg.ExpiresAt != nil {
+		done.ExpiresAt = protoMsg.ExpiresAt.AsTime()
+	}
+
+	if len(protoMsg.Trades) > 0 {
+		done.Trades = make([]Trade, 0, len(protoMsg.Trades))
+		for _, trade := range protoMsg.Trades {
+			done.Trades = append(done.Trades, Trade{
+				OrderID:     trade.OrderId,
+				Role:        trade.Role,
+				Price:       trade.Price,
+				Quantity:    trade.Quantity,
+				IsQuote:     trade.IsQuote,
+				UserAddress: trade.UserAddress,
+				MakerFee:    trade.MakerFee,
+				TakerFee:    trade.TakerFee,
+			})
+		}
+	}
+
+	if protoMsg.Cancel != nil {
+		done.Cancel = &CancelMessage{
+			OrderID:       protoMsg.Cancel.OrderId,
+			CanceledAt:    protoMsg.Cancel.CanceledAt.AsTime(),
+			CancelReason:  CancelReason(protoMsg.Cancel.CancelReason.String()),
+			RemainingQty:  protoMsg.Cancel.RemainingQuantity,
+			UserAddress:   protoMsg.Cancel.UserAddress,
+			OrderBookName: protoMsg.OrderBookName,
+		}
+	}
+	return done
+}
+
+// doneMessageAvroSchema mirrors DoneMessage. canceled_at and expires_at hold
+// Unix nanoseconds so times keep their full precision; an expires_at of zero
+// means the order does not expire.
+const doneMessageAvroSchema = `{
+	"type": "record",
+	"name": "DoneMessage",
+	"namespace": "matchingo",
+	"fields": [
+		{"name": "order_id", "type": "string"},
+		{"name": "executed_qty", "type": "string"},
+		{"name": "remaining_qty", "type": "string"},
+		{"name": "trades", "type": {"type": "array", "items": {
+			"type": "record",
+			"name": "Trade",
+			"fields": [
+				{"name": "order_id", "type": "string"},
+				{"name": "role", "type": "string"},
+				{"name": "price", "type": "string"},
+				{"name": "quantity", "type": "string"},
+				{"name": "is_quote", "type": "boolean"},
+				{"name": "user_address", "type": "string"},
+				{"name": "maker_fee", "type": "string", "default": ""},
+				{"name": "taker_fee", "type": "string", "default": ""}
+			]
+		}}},
+		{"name": "canceled", "type": {"type": "array", "items": "string"}},
+		{"name": "activated", "type": {"type": "array", "items": "string"}},
+		{"name": "stored", "type": "boolean"},
+		{"name": "quantity", "type": "string"},
+		{"name": "processed", "type": "string"},
+		{"name": "left", "type": "string"},
+		{"name": "user_address", "type": "string"},
+		{"name": "cancel", "type": ["null", {
+			"type": "record",
+			"name": "CancelMessage",
+			"fields": [
+				{"name": "order_id", "type": "string"},
+				{"name": "canceled_at", "type": "long"},
+				{"name": "cancel_reason", "type": "string"},
+				{"name": "remaining_qty", "type": "string"},
+				{"name": "user_address", "type": "string"}
+			]
+		}], "default": null},
+		{"name": "request_id", "type": "string", "default": ""},
+		{"name": "sequence_number", "type": "long", "default": 0},
+		{"name": "match_duration_ms", "type": "double", "default": 0},
+		{"name": "stop_activated", "type": "boolean", "default": false},
+		{"name": "peg_type", "type": "string", "default": ""},
+		{"name": "effective_price", "type": "string", "default": ""},
+		{"name": "cancelled_by_oco", "type": "boolean", "default": false},
+		{"name": "stp_triggered", "type": "boolean", "default": false},
+		{"name": "halt_reason", "type": "string", "default": ""},
+		{"name": "order_book_name", "type": "string", "default": ""},
+		{"name": "side", "type": "string", "default": ""},
+		{"name": "price", "type": "string", "default": ""},
+		{"name": "order_type", "type": "string", "default": ""},
+		{"name": "time_in_force", "type": "string", "default": ""},
+		{"name": "expires_at", "type": "long", "default": 0},
+		{"name": "amended", "type": "boolean", "default": false},
+		{"name": "iceberg", "type": "boolean", "default": false},
+		{"name": "oco_id", "type": "string", "default": ""}
+	]
+}`
+
+// avroCancelType is the union branch name of a non-null cancel field
+const avroCancelType = "matchingo.CancelMessage"
+
+// sharedAvroSerializer parses the schema once for SerializerForContentType
+var sharedAvroSerializer = sync.OnceValues(NewAvroSerializer)
+
+// AvroSerializer encodes DoneMessages as Avro binary without an embedded schema
+type AvroSerializer struct {
+	codec *goavro.Codec
+}
+
+// NewAvroSerializer creates an AvroSerializer for the DoneMessage schema
+func NewAvroSerializer() (*AvroSerializer, error) {
+	codec, err := goavro.NewCodec(doneMessageAvroSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse done message schema: %w", err)
+	}
+	return &AvroSerializer{codec: codec}, nil
+}
+
+// Marshal encodes msg as Avro binary
+func (s *AvroSerializer) Marshal(msg *DoneMessage) ([]byte, error) {
+	trades := make([]interface{}, 0, len(msg.Trades))
+	for _, trade := range msg.Trades {
+		trades = append(trades, map[string]interface{}{
+			"order_id":     trade.OrderID,
+			"role":         trade.Role,
+			"price":        trade.Price,
+			"quantity":     trade.Quantity,
+			"is_quote":     trade.IsQuote,
+			"user_address": trade.UserAddress,
+			"maker_fee":    trade.MakerFee,
+			"taker_fee":    trade.TakerFee,
+		})
+	}
+
+	var cancel interface{}
+	if msg.Cancel != nil {
+		cancel = goavro.Union(avroCancelType, map[string]interface{}{
+			"order_id":      msg.Cancel.OrderID,
+			"canceled_at":   msg.Cancel.CanceledAt.UnixNano(),
+			"cancel_reason": string(msg.Cancel.CancelReason),
+			"remaining_qty": msg.Cancel.RemainingQty,
+			"user_address":  msg.Cancel.UserAddress,
+		})
+	}
+
+	var expiresAt int64
+	if !msg.ExpiresAt.IsZero() {
+		expiresAt = msg.ExpiresAt.UnixNano()
+	}
+
+	return s.codec.BinaryFromNative(nil, map[string]interface{}{
+		"order_id":          msg.OrderID,
+		"executed_qty":      msg.ExecutedQty,
+		"remaining_qty":     msg.RemainingQty,
+		"trades":            trades,
+		"canceled":          avroStrings(msg.Canceled),
+		"activated":         avroStrings(msg.Activated),
+		"stored":            msg.Stored,
+		"quantity":          msg.Quantity,
+		"processed":         msg.Processed,
+		"left":              msg.Left,
+		"user_address":      msg.UserAddress,
+		"cancel":            cancel,
+		"request_id":        msg.RequestID,
+		"sequence_number":   int64(msg.SequenceNumber),
+		"match_duration_ms": msg.MatchDurationMs,
+		"stop_activated":    msg.StopActivated,
+		"peg_type":          msg.PegType,
+		"effective_price":   msg.EffectivePrice,
+		"cancelled_by_oco":  msg.CancelledByOCO,
+		"stp_triggered":     msg.STPTriggered,
+		"halt_reason":       msg.HaltReason,
+		"order_book_name":   msg.OrderBookName,
+		"side":              msg.Side,
+		"price":             msg.Price,
+		"order_type":        msg.OrderType,
+		"time_in_force":     msg.TimeInForce,
+		"expires_at":        expiresAt,
+		"amended":           msg.Amended,
+		"iceberg":           msg.Iceberg,
+		"oco_id":            msg.OCOID,
+	})
+}
+
+// Unmarshal decodes Avro binary data into msg
+func (s *AvroSerializer) Unmarshal(data []byte, msg *DoneMessage) error {
+	native, _, err := s.codec.NativeFromBinary(data)
+	if err != nil {
+		return err
+	}
+	record, ok := native.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected avro value %T", native)
+	}
+
+	done := DoneMessage{
+		OrderID:         record["order_id"].(string),
+		ExecutedQty:     record["executed_qty"].(string),
+		RemainingQty:    record["remaining_qty"].(string),
+		Canceled:        fromAvroStrings(record["canceled"]),
+		Activated:       fromAvroStrings(record["activated"]),
+		Stored:          record["stored"].(bool),
+		Quantity:        record["quantity"].(string),
+		Processed:       record["processed"].(string),
+		Left:            record["left"].(string),
+		UserAddress:     record["user_address"].(string),
+		RequestID:       record["request_id"].(string),
+		SequenceNumber:  uint64(record["sequence_number"].(int64)),
+		MatchDurationMs: record["match_duration_ms"].(float64),
+		StopActivated:   record["stop_activated"].(bool),
+		PegType:         record["peg_type"].(string),
+		EffectivePrice:  record["effective_price"].(string),
+		CancelledByOCO:  record["cancelled_by_oco"].(bool),
+		STPTriggered:    record["stp_triggered"].(bool),
+		HaltReason:      record["halt_reason"].(string),
+		OrderBookName:   record["order_book_name"].(string),
+		Side:            record["side"].(string),
+		Price:           record["price"].(string),
+		OrderType:       record["order_type"].(string),
+		TimeInForce:     record["time_in_force"].(string),
+		Amended:         record["amended"].(bool),
+		Iceberg:         record["iceberg"].(bool),
+		OCOID:           record["oco_id"].(string),
+	}
+	if expiresAt := record["expires_at"].(int64); expiresAt != 0 {
+		done.ExpiresAt = time.Unix(0, expiresAt).UTC()
+	}
+
+	for _, item := range record["trades"].([]interface{}) {
+		trade := item.(map[string]interface{})
+		done.Trades = append(done.Trades, Trade{
+			OrderID:     trade["order_id"].(string),
+			Role:        trade["role"].(string),
+			Price:       trade["price"].(string),
+			Quantity:    trade["quantity"].(string),
+			IsQuote:     trade["is_quote"].(bool),
+			UserAddress: trade["user_address"].(string),
+			MakerFee:    trade["maker_fee"].(string),
+			TakerFee:    trade["taker_fee"].(string),
+		})
+	}
+
+	if union, ok := record["cancel"].(map[string]interface{}); ok {
+		cancel := union[avroCancelType].(map[string]interface{})
+		done.Cancel = &CancelMessage{
+			OrderID:       cancel["order_id"].(string),
+			CanceledAt:    time.Unix(0, cancel["canceled_at"].(int64)).UTC(),
+			CancelReason:  CancelReason(cancel["cancel_reason"].(string)),
+			RemainingQty:  cancel["remaining_qty"].(string),
+			UserAddress:   cancel["user_address"].(string),
+			OrderBookName: done.OrderBookName,
+		}
+	}
+
+	*msg = done
+	return nil
+}
+
+// ContentType returns ContentTypeAvro
+func (s *AvroSerializer) ContentType() string {
+	return ContentTypeAvro
+}
+
+func avroStrings(values []string) []interface{} {
+	out := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		out = append(out, v)
+	}
+	return out
+}
+
+func fromAvroStrings(value interface{}) []string {
+	items := value.([]interface{})
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.(string))
+	}
+	return out
+}
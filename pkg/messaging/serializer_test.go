@@ -0,0 +1,118 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// normalize treats nil and empty slices alike, since not every format can tell them apart
+func normalize(msg *DoneMessage) DoneMessage {
+	out := *msg
+	if len(out.Trades) == 0 {
+		out.Trades = nil
+	}
+	if len(out.Canceled) == 0 {
+		out.Canceled = nil
+	}
+	if len(out.Activated) == 0 {
+		out.Activated = nil
+	}
+	return out
+}
+
+func TestSerializerRoundTrip(t *testing.T) {
+	avro, err := NewAvroSerializer()
+	require.NoError(t, err)
+
+	serializers := []Serializer{JSONSerializer{}, ProtoSerializer{}, avro}
+
+	messages := map[string]*DoneMessage{
+		"Full": {
+			OrderID:      "buy-1",
+			ExecutedQty:  "3.000",
+			RemainingQty: "1.500",
+			Trades: []Trade{
+				{OrderID: "buy-1", Role: "TAKER", Price: "100.000", Quantity: "3.000", UserAddress: "0xaaa"},
+				{OrderID: "sell-1", Role: "MAKER", Price: "100.000", Quantity: "3.000", IsQuote: true, UserAddress: "0xbbb", MakerFee: "0.030", TakerFee: "0.150"},
+			},
+			Canceled:        []string{"oco-1"},
+			Activated:       []string{"stop-1", "stop-2"},
+			Stored:          true,
+			Quantity:        "4.500",
+			Processed:       "3.000",
+			Left:            "1.500",
+			UserAddress:     "0xaaa",
+			RequestID:       "req-1",
+			SequenceNumber:  42,
+			MatchDurationMs: 0.125,
+			StopActivated:   true,
+			PegType:         "MID",
+			EffectivePrice:  "100.500",
+			CancelledByOCO:  true,
+			STPTriggered:    true,
+			HaltReason:      "price moved too fast",
+			OrderBookName:   "btc-usd",
+			Side:            "BUY",
+			Price:           "100.000",
+			OrderType:       "LIMIT",
+			TimeInForce:     "GTD",
+			ExpiresAt:       time.Date(2025, 3, 15, 0, 0, 0, 123456789, time.UTC),
+			Amended:         true,
+			Iceberg:         true,
+			OCOID:           "oco-1",
+		},
+		"Cancel": (&CancelMessage{
+			OrderID:       "sell-2",
+			CanceledAt:    time.Date(2025, 3, 14, 15, 9, 26, 535897932, time.UTC),
+			CancelReason:  CancelReasonExpired,
+			RemainingQty:  "2.000",
+			UserAddress:   "0xccc",
+			OrderBookName: "eth-usd",
+		}).ToDoneMessage(),
+		"ZeroDecimals": {
+			OrderID:      "buy-2",
+			ExecutedQty:  "0.000",
+			RemainingQty: "0.000",
+			Quantity:     "0.000",
+			Processed:    "0.000",
+			Left:         "0.000",
+		},
+		"EmptyTrades": {
+			OrderID: "buy-3",
+			Trades:  []Trade{},
+		},
+		"ZeroValue": {},
+	}
+
+	for _, s := range serializers {
+		for name, msg := range messages {
+			t.Run(s.ContentType()+"/"+name, func(t *testing.T) {
+				data, err := s.Marshal(msg)
+				require.NoError(t, err)
+
+				var got DoneMessage
+				require.NoError(t, s.Unmarshal(data, &got))
+				assert.Equal(t, normalize(msg), normalize(&got))
+			})
+		}
+	}
+}
+
+func TestSerializerForContentType(t *testing.T) {
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeProtobuf, ContentTypeAvro} {
+		s, err := SerializerForContentType(contentType)
+		require.NoError(t, err)
+		assert.Equal(t, contentType, s.ContentType())
+	}
+
+	_, err := SerializerForContentType("text/plain")
+	assert.Error(t, err)
+}
+
+func TestPartitionKey(t *testing.T) {
+	assert.Equal(t, []byte("btc-usd"), PartitionKey(&DoneMessage{OrderBookName: "btc-usd", SequenceNumber: 7}))
+	assert.Empty(t, PartitionKey(&DoneMessage{SequenceNumber: 7}))
+}
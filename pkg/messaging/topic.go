@@ -0,0 +1,19 @@
+package messaging
+
+// TopicRouter picks the topic the messages of an order book are sent to
+type TopicRouter interface {
+	// Route returns the topic for the messages of the named order book
+	Route(bookName string) string
+}
+
+// PrefixRouter sends the messages of each order book to a topic of its own,
+// named after the book: "matchingo.btc-usd" for the book "btc-usd" with the
+// prefix "matchingo"
+type PrefixRouter struct {
+	Prefix string
+}
+
+// Route returns the prefix and the book name joined by a dot
+func (r PrefixRouter) Route(bookName string) string {
+	return r.Prefix + "." + bookName
+}
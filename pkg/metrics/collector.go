@@ -0,0 +1,96 @@
+// Package metrics exposes the state of the managed order books to Prometheus
+package metrics
+
+import (
+	"context"
+
+	"github.com/erain9/matchingo/pkg/server"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bidLevelsDesc = prometheus.NewDesc(
+		"matchingo_bid_levels",
+		"Number of price levels on the bid side of the order book",
+		[]string{"book"}, nil,
+	)
+	askLevelsDesc = prometheus.NewDesc(
+		"matchingo_ask_levels",
+		"Number of price levels on the ask side of the order book",
+		[]string{"book"}, nil,
+	)
+	totalVolumeDesc = prometheus.NewDesc(
+		"matchingo_total_volume",
+		"Total quantity traded on the order book",
+		[]string{"book"}, nil,
+	)
+	tradeCountDesc = prometheus.NewDesc(
+		"matchingo_trade_count",
+		"Number of trades made on the order book",
+		[]string{"book"}, nil,
+	)
+	spreadDesc = prometheus.NewDesc(
+		"matchingo_spread",
+		"Best ask minus best bid of the order book",
+		[]string{"book"}, nil,
+	)
+)
+
+// Collector is a prometheus.Collector reading the order books of a manager
+// on every scrape. Deleted books are not reported.
+type Collector struct {
+	manager *server.OrderBookManager
+}
+
+// NewCollector creates a Collector for the order books of manager
+func NewCollector(manager *server.OrderBookManager) *Collector {
+	return &Collector{manager: manager}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- bidLevelsDesc
+	ch <- askLevelsDesc
+	ch <- totalVolumeDesc
+	ch <- tradeCountDesc
+	ch <- spreadDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+	for _, info := range c.manager.ListOrderBooks(ctx, false) {
+		book, _, err := c.manager.GetOrderBook(ctx, info.Name)
+		if err != nil {
+			// Deleted since it was listed
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(bidLevelsDesc, prometheus.GaugeValue, float64(priceLevels(book.GetBids())), info.Name)
+		ch <- prometheus.MustNewConstMetric(askLevelsDesc, prometheus.GaugeValue, float64(priceLevels(book.GetAsks())), info.Name)
+
+		if info.TradeHistory != nil {
+			count, volume := info.TradeHistory.Totals()
+			ch <- prometheus.MustNewConstMetric(tradeCountDesc, prometheus.CounterValue, float64(count), info.Name)
+			ch <- prometheus.MustNewConstMetric(totalVolumeDesc, prometheus.CounterValue, volume.Float64(), info.Name)
+		}
+
+		// The spread is only reported while both sides have orders
+		if bbo := book.BBO(); bbo.HasBid && bbo.HasAsk {
+			ch <- prometheus.MustNewConstMetric(spreadDesc, prometheus.GaugeValue, bbo.AskPrice.Sub(bbo.BidPrice).Float64(), info.Name)
+		}
+	}
+}
+
+// priceLevels returns how many prices side has orders at, or 0 if the
+// backend's side does not list its prices
+func priceLevels(side interface{}) int {
+	orderSide, ok := side.(interface {
+		Prices() []fpdecimal.Decimal
+	})
+	if !ok {
+		return 0
+	}
+	return len(orderSide.Prices())
+}
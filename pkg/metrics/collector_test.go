@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/erain9/matchingo/pkg/server"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+	manager := server.NewOrderBookManager()
+	defer manager.Close()
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := manager.CreateMemoryOrderBook(ctx, "btc-usd", "")
+	require.NoError(t, err)
+	_, err = manager.CreateMemoryOrderBook(ctx, "empty", "")
+	require.NoError(t, err)
+	book, _, err := manager.GetOrderBook(ctx, "btc-usd")
+	require.NoError(t, err)
+
+	for _, o := range []struct {
+		id    string
+		side  core.Side
+		qty   float64
+		price int64
+	}{
+		{"bid-1", core.Buy, 1, 99},
+		{"bid-2", core.Buy, 1, 98},
+		{"ask-1", core.Sell, 2, 101},
+		{"ask-2", core.Sell, 1, 102},
+		{"ask-3", core.Sell, 1, 103},
+		// Two trades of 1.5 in total at 101
+		{"taker", core.Buy, 1, 101},
+		{"taker-2", core.Buy, 0.5, 101},
+	} {
+		order, err := core.NewLimitOrder(o.id, o.side, fpdecimal.FromFloat(o.qty), fpdecimal.FromInt(o.price), core.GTC, "", "")
+		require.NoError(t, err)
+		_, err = book.Process(ctx, order)
+		require.NoError(t, err)
+	}
+
+	expected := `
+# HELP matchingo_ask_levels Number of price levels on the ask side of the order book
+# TYPE matchingo_ask_levels gauge
+matchingo_ask_levels{book="btc-usd"} 3
+matchingo_ask_levels{book="empty"} 0
+# HELP matchingo_bid_levels Number of price levels on the bid side of the order book
+# TYPE matchingo_bid_levels gauge
+matchingo_bid_levels{book="btc-usd"} 2
+matchingo_bid_levels{book="empty"} 0
+# HELP matchingo_spread Best ask minus best bid of the order book
+# TYPE matchingo_spread gauge
+matchingo_spread{book="btc-usd"} 2
+# HELP matchingo_total_volume Total quantity traded on the order book
+# TYPE matchingo_total_volume counter
+matchingo_total_volume{book="btc-usd"} 1.5
+matchingo_total_volume{book="empty"} 0
+# HELP matchingo_trade_count Number of trades made on the order book
+# TYPE matchingo_trade_count counter
+matchingo_trade_count{book="btc-usd"} 2
+matchingo_trade_count{book="empty"} 0
+`
+	require.NoError(t, testutil.CollectAndCompare(NewCollector(manager), strings.NewReader(expected)))
+
+	// Deleted books are no longer reported
+	require.NoError(t, manager.DeleteOrderBook(ctx, "empty"))
+	require.Equal(t, 5, testutil.CollectAndCount(NewCollector(manager)))
+}
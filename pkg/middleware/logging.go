@@ -0,0 +1,84 @@
+// Package middleware holds gRPC server interceptors shared by the services
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryLoggingInterceptor logs the method, latency and status code of every
+// unary call with logger, along with the order ID of requests that carry
+// one. Handlers get the call's logger from zerolog.Ctx, tagged with the
+// method and the request ID when the caller sent one.
+func UnaryLoggingInterceptor(logger zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		callLogger := methodLogger(ctx, logger, info.FullMethod)
+		if r, ok := req.(interface{ GetOrderId() string }); ok && r.GetOrderId() != "" {
+			callLogger = callLogger.With().Str("order_id", r.GetOrderId()).Logger()
+		}
+
+		resp, err := handler(callLogger.WithContext(ctx), req)
+		logCompletion(callLogger, time.Since(start), err, "Request completed")
+		return resp, err
+	}
+}
+
+// StreamLoggingInterceptor logs the method, duration and status code of
+// every streaming call with logger once the stream ends. The stream's
+// context carries the call's logger like UnaryLoggingInterceptor's.
+func StreamLoggingInterceptor(logger zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		callLogger := methodLogger(stream.Context(), logger, info.FullMethod).With().Bool("grpc.stream", true).Logger()
+		err := handler(srv, &loggingServerStream{
+			ServerStream: stream,
+			ctx:          callLogger.WithContext(stream.Context()),
+		})
+		logCompletion(callLogger, time.Since(start), err, "Stream completed")
+		return err
+	}
+}
+
+// methodLogger returns logger tagged with the method and the request ID sent
+// in ctx's metadata, if any
+func methodLogger(ctx context.Context, logger zerolog.Logger, fullMethod string) zerolog.Logger {
+	logCtx := logger.With().Str("grpc.method", fullMethod)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if requestIDs := md.Get(logging.RequestIDMetadataKey); len(requestIDs) > 0 {
+			logCtx = logCtx.Str("request_id", requestIDs[0])
+		}
+	}
+	return logCtx.Logger()
+}
+
+// logCompletion logs the end of a call, at error level if it failed
+func logCompletion(logger zerolog.Logger, duration time.Duration, err error, msg string) {
+	code := status.Code(err)
+	event := logger.Info()
+	if err != nil {
+		event = logger.Error().Err(err)
+	}
+	event.Dur("duration", duration).
+		Str("grpc.code", code.String()).
+		Msg(msg)
+}
+
+// loggingServerStream replaces the context of a grpc.ServerStream
+type loggingServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the stream's context with the call's logger attached
+func (s *loggingServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// lastEntry decodes the last line logged to buf
+func lastEntry(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+	return entry
+}
+
+func TestUnaryLoggingInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := UnaryLoggingInterceptor(zerolog.New(&buf))
+	info := &grpc.UnaryServerInfo{FullMethod: "/orderbook.OrderBookService/CancelOrder"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(logging.RequestIDMetadataKey, "req-1"))
+
+	// Handlers log with the call's logger
+	_, err := interceptor(ctx, &proto.CancelOrderRequest{OrderId: "order-1"}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		zerolog.Ctx(ctx).Info().Msg("cancelling")
+		return nil, status.Error(codes.NotFound, "order not found")
+	})
+	require.Error(t, err)
+
+	entries := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, entries, 2)
+	assert.Contains(t, string(entries[0]), `"order_id":"order-1"`)
+
+	entry := lastEntry(t, &buf)
+	assert.Equal(t, "error", entry["level"])
+	assert.Equal(t, "/orderbook.OrderBookService/CancelOrder", entry["grpc.method"])
+	assert.Equal(t, "NotFound", entry["grpc.code"])
+	assert.Equal(t, "order-1", entry["order_id"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Contains(t, entry, "duration")
+
+	// Requests without an order ID
+	_, err = interceptor(context.Background(), &proto.ListOrderBooksRequest{}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &proto.ListOrderBooksResponse{}, nil
+	})
+	require.NoError(t, err)
+	entry = lastEntry(t, &buf)
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "OK", entry["grpc.code"])
+	assert.NotContains(t, entry, "order_id")
+	assert.NotContains(t, entry, "request_id")
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamLoggingInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := StreamLoggingInterceptor(zerolog.New(&buf))
+	info := &grpc.StreamServerInfo{FullMethod: "/orderbook.OrderBookService/WatchOrderBook", IsServerStream: true}
+
+	err := interceptor(nil, &testServerStream{ctx: context.Background()}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		require.NotNil(t, zerolog.Ctx(stream.Context()))
+		zerolog.Ctx(stream.Context()).Info().Msg("streaming")
+		return nil
+	})
+	require.NoError(t, err)
+
+	entries := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, entries, 2)
+	assert.Contains(t, string(entries[0]), `"grpc.stream":true`)
+
+	entry := lastEntry(t, &buf)
+	assert.Equal(t, "Stream completed", entry["message"])
+	assert.Equal(t, "/orderbook.OrderBookService/WatchOrderBook", entry["grpc.method"])
+	assert.Equal(t, "OK", entry["grpc.code"])
+}
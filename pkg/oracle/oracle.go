@@ -0,0 +1,165 @@
+// Package oracle provides external reference prices, such as the mid price
+// of a pair on another venue, for orders pegged to them.
+package oracle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+var (
+	// ErrUnknownPair is returned for a pair the feed has no price for
+	ErrUnknownPair = errors.New("no price for pair")
+	// ErrStalePrice is returned when a pair's price is older than the
+	// feed's staleness TTL
+	ErrStalePrice = errors.New("price is stale")
+)
+
+// PriceFeed reports the latest external price of trading pairs. Order books
+// call LatestPrice while holding their lock, so implementations should
+// answer from memory rather than fetch the price.
+type PriceFeed interface {
+	LatestPrice(pair string) (fpdecimal.Decimal, error)
+}
+
+// Default polling settings of an HTTPPriceFeed
+const (
+	DefaultPollInterval = time.Second
+	DefaultStaleTTL     = 10 * time.Second
+)
+
+// cachedPrice is a price and when it was fetched
+type cachedPrice struct {
+	price     fpdecimal.Decimal
+	fetchedAt time.Time
+}
+
+// HTTPPriceFeed is a PriceFeed that polls a REST endpoint and caches the
+// prices it returns. The endpoint answers GET requests with a JSON object
+// mapping each pair to its price, as a string or a number:
+//
+//	{"btc-usd": "50000.25", "eth-usd": 3000.5}
+//
+// A price is served until it is older than the staleness TTL, so a feed
+// whose endpoint stops answering fails instead of serving old prices.
+type HTTPPriceFeed struct {
+	url      string
+	interval time.Duration
+	ttl      time.Duration
+	client   *http.Client
+	now      func() time.Time
+
+	mu     sync.RWMutex
+	prices map[string]cachedPrice
+}
+
+// NewHTTPPriceFeed creates an HTTPPriceFeed polling url every interval and
+// serving prices for ttl after they were fetched. Intervals and TTLs that
+// are not positive take their defaults.
+func NewHTTPPriceFeed(url string, interval, ttl time.Duration) *HTTPPriceFeed {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	if ttl <= 0 {
+		ttl = DefaultStaleTTL
+	}
+	return &HTTPPriceFeed{
+		url:      url,
+		interval: interval,
+		ttl:      ttl,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		now:      time.Now,
+		prices:   make(map[string]cachedPrice),
+	}
+}
+
+// SetHTTPClient replaces the client the endpoint is polled with
+func (f *HTTPPriceFeed) SetHTTPClient(client *http.Client) {
+	f.client = client
+}
+
+// Start polls the endpoint now and then every interval in a background
+// goroutine until ctx is done. Failed polls are logged and leave the cached
+// prices as they were.
+func (f *HTTPPriceFeed) Start(ctx context.Context) {
+	logger := logging.FromContext(ctx).With().Str("component", "oracle").Str("url", f.url).Logger()
+
+	go func() {
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := f.Refresh(ctx); err != nil && ctx.Err() == nil {
+				logger.Warn().Err(err).Msg("Failed to poll price feed")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Refresh fetches the prices from the endpoint once and caches them. Pairs
+// the endpoint no longer lists keep their price until it goes stale.
+func (f *HTTPPriceFeed) Refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prices: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch prices: status %d", resp.StatusCode)
+	}
+
+	var body map[string]json.Number
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode prices: %w", err)
+	}
+	fetched := make(map[string]fpdecimal.Decimal, len(body))
+	for pair, value := range body {
+		price, err := fpdecimal.FromString(value.String())
+		if err != nil {
+			return fmt.Errorf("invalid price %q for %s: %w", value, pair, err)
+		}
+		fetched[pair] = price
+	}
+
+	fetchedAt := f.now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for pair, price := range fetched {
+		f.prices[pair] = cachedPrice{price: price, fetchedAt: fetchedAt}
+	}
+	return nil
+}
+
+// LatestPrice returns the last price fetched for pair. It fails with
+// ErrUnknownPair if the endpoint never listed the pair and with
+// ErrStalePrice if the price is older than the TTL.
+func (f *HTTPPriceFeed) LatestPrice(pair string) (fpdecimal.Decimal, error) {
+	f.mu.RLock()
+	cached, ok := f.prices[pair]
+	f.mu.RUnlock()
+
+	if !ok {
+		return fpdecimal.Zero, fmt.Errorf("%w %s", ErrUnknownPair, pair)
+	}
+	if age := f.now().Sub(cached.fetchedAt); age > f.ttl {
+		return fpdecimal.Zero, fmt.Errorf("%w: %s fetched %s ago", ErrStalePrice, pair, age.Truncate(time.Millisecond))
+	}
+	return cached.price, nil
+}
@@ -0,0 +1,91 @@
+package oracle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPPriceFeed(t *testing.T) {
+	var body atomic.Value
+	body.Store(`{"btc-usd": "50000.25", "eth-usd": 3000.5}`)
+	status := atomic.Int32{}
+	status.Store(http.StatusOK)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	feed := NewHTTPPriceFeed(srv.URL, time.Second, 10*time.Second)
+	feed.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := feed.LatestPrice("btc-usd")
+	assert.ErrorIs(t, err, ErrUnknownPair)
+
+	require.NoError(t, feed.Refresh(ctx))
+	price, err := feed.LatestPrice("btc-usd")
+	require.NoError(t, err)
+	assert.Equal(t, fpdecimal.FromFloat(50000.25), price)
+	price, err = feed.LatestPrice("eth-usd")
+	require.NoError(t, err)
+	assert.Equal(t, fpdecimal.FromFloat(3000.5), price)
+
+	// Failed polls keep the cached prices until they go stale
+	status.Store(http.StatusServiceUnavailable)
+	assert.Error(t, feed.Refresh(ctx))
+	status.Store(http.StatusOK)
+	body.Store(`{"btc-usd": "not a price"}`)
+	assert.Error(t, feed.Refresh(ctx))
+
+	now = now.Add(5 * time.Second)
+	_, err = feed.LatestPrice("btc-usd")
+	assert.NoError(t, err)
+	now = now.Add(6 * time.Second)
+	_, err = feed.LatestPrice("btc-usd")
+	assert.ErrorIs(t, err, ErrStalePrice)
+
+	body.Store(`{"btc-usd": "51000"}`)
+	require.NoError(t, feed.Refresh(ctx))
+	price, err = feed.LatestPrice("btc-usd")
+	require.NoError(t, err)
+	assert.Equal(t, fpdecimal.FromInt(51000), price)
+	_, err = feed.LatestPrice("eth-usd")
+	assert.ErrorIs(t, err, ErrStalePrice)
+}
+
+func TestHTTPPriceFeedStart(t *testing.T) {
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls.Add(1)
+		w.Write([]byte(`{"btc-usd": "100"}`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feed := NewHTTPPriceFeed(srv.URL, 10*time.Millisecond, time.Minute)
+	feed.Start(ctx)
+
+	// The first poll is made at once, then on every tick
+	require.Eventually(t, func() bool {
+		_, err := feed.LatestPrice("btc-usd")
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return polls.Load() >= 3 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	time.Sleep(30 * time.Millisecond)
+	stopped := polls.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stopped, polls.Load())
+}
@@ -0,0 +1,52 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	// kafkaMetrics holds the singleton instance
+	kafkaMetrics *KafkaMetrics
+)
+
+// KafkaMetrics holds metrics for the Kafka done message consumer
+type KafkaMetrics struct {
+	// Tracks how many messages of each partition are not yet committed
+	consumerLag metric.Int64Gauge
+}
+
+// GetKafkaMetrics returns the KafkaMetrics singleton
+func GetKafkaMetrics() *KafkaMetrics {
+	if kafkaMetrics == nil {
+		consumerLag, err := meter.Int64Gauge(
+			"matchingo_kafka_consumer_lag",
+			metric.WithDescription("Latest offset of a partition minus the offset its consumer group committed"),
+			metric.WithUnit("{message}"),
+		)
+		if err != nil {
+			return &KafkaMetrics{}
+		}
+
+		kafkaMetrics = &KafkaMetrics{
+			consumerLag: consumerLag,
+		}
+	}
+
+	return kafkaMetrics
+}
+
+// RecordConsumerLag sets the lag of group on one partition of topic
+func (m *KafkaMetrics) RecordConsumerLag(ctx context.Context, group, topic string, partition int32, lag int64) {
+	if m.consumerLag == nil {
+		return
+	}
+
+	m.consumerLag.Record(ctx, lag, metric.WithAttributes(
+		attribute.String("group", group),
+		attribute.String("topic", topic),
+		attribute.Int("partition", int(partition)),
+	))
+}
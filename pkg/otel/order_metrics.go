@@ -2,6 +2,7 @@ package otel
 
 import (
 	"context"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -19,6 +20,22 @@ var (
 type OrderBookMetrics struct {
 	// Tracks the total number of matched orders by type (market, limit)
 	matchedOrdersTotal metric.Int64Counter
+	// Tracks the total number of resting orders canceled by background sweeps
+	sweptOrdersTotal metric.Int64Counter
+	// Tracks the value of the resting orders on each side of each book
+	bookNotional metric.Float64Gauge
+	// Track the best bid and best ask price of each book
+	bestBid metric.Float64Gauge
+	bestAsk metric.Float64Gauge
+	// Tracks how long matching each order took
+	matchDuration metric.Float64Histogram
+	// Track the pipelines the Redis backend executes and the commands in them
+	redisPipelineFlushes metric.Int64Counter
+	redisPipelineCmds    metric.Int64Counter
+	// Tracks the trade subscribers dropped for falling behind
+	droppedTradeSubscribers metric.Int64Counter
+	// Tracks the orders rejected by the per-user rate limit
+	rateLimitedOrders metric.Int64Counter
 }
 
 // GetOrderBookMetrics returns the OrderBookMetrics singleton
@@ -34,8 +51,95 @@ func GetOrderBookMetrics() *OrderBookMetrics {
 			return &OrderBookMetrics{}
 		}
 
+		sweptOrdersTotal, err := meter.Int64Counter(
+			"matchingo_orders_swept_total",
+			metric.WithDescription("Total number of resting orders canceled by the order sweeper"),
+			metric.WithUnit("{order}"),
+		)
+		if err != nil {
+			return &OrderBookMetrics{}
+		}
+
+		bookNotional, err := meter.Float64Gauge(
+			"matchingo_book_notional",
+			metric.WithDescription("Value, price times quantity, of the resting orders on one side of an order book"),
+		)
+		if err != nil {
+			return &OrderBookMetrics{}
+		}
+
+		bestBid, err := meter.Float64Gauge(
+			"matchingo_best_bid",
+			metric.WithDescription("Highest bid price of an order book, or 0 when it has no bids"),
+		)
+		if err != nil {
+			return &OrderBookMetrics{}
+		}
+
+		bestAsk, err := meter.Float64Gauge(
+			"matchingo_best_ask",
+			metric.WithDescription("Lowest ask price of an order book, or 0 when it has no asks"),
+		)
+		if err != nil {
+			return &OrderBookMetrics{}
+		}
+
+		matchDuration, err := meter.Float64Histogram(
+			"matchingo_match_duration_seconds",
+			metric.WithDescription("Time taken to match an order against an order book"),
+			metric.WithUnit("s"),
+		)
+		if err != nil {
+			return &OrderBookMetrics{}
+		}
+
+		redisPipelineFlushes, err := meter.Int64Counter(
+			"matchingo_redis_pipeline_flush_total",
+			metric.WithDescription("Total number of pipelines executed by the Redis backend"),
+			metric.WithUnit("{flush}"),
+		)
+		if err != nil {
+			return &OrderBookMetrics{}
+		}
+
+		redisPipelineCmds, err := meter.Int64Counter(
+			"matchingo_redis_pipeline_cmds_total",
+			metric.WithDescription("Total number of commands sent in Redis backend pipelines"),
+			metric.WithUnit("{command}"),
+		)
+		if err != nil {
+			return &OrderBookMetrics{}
+		}
+
+		droppedTradeSubscribers, err := meter.Int64Counter(
+			"matchingo_trade_subscribers_dropped_total",
+			metric.WithDescription("Total number of SubscribeTrades streams closed because the subscriber fell behind"),
+			metric.WithUnit("{subscriber}"),
+		)
+		if err != nil {
+			return &OrderBookMetrics{}
+		}
+
+		rateLimitedOrders, err := meter.Int64Counter(
+			"matchingo_rate_limited_orders_total",
+			metric.WithDescription("Total number of orders rejected because their user address exceeded its rate limit"),
+			metric.WithUnit("{order}"),
+		)
+		if err != nil {
+			return &OrderBookMetrics{}
+		}
+
 		orderBookMetrics = &OrderBookMetrics{
-			matchedOrdersTotal: matchedOrdersTotal,
+			matchedOrdersTotal:      matchedOrdersTotal,
+			sweptOrdersTotal:        sweptOrdersTotal,
+			bookNotional:            bookNotional,
+			bestBid:                 bestBid,
+			bestAsk:                 bestAsk,
+			matchDuration:           matchDuration,
+			redisPipelineFlushes:    redisPipelineFlushes,
+			redisPipelineCmds:       redisPipelineCmds,
+			droppedTradeSubscribers: droppedTradeSubscribers,
+			rateLimitedOrders:       rateLimitedOrders,
 		}
 	}
 
@@ -53,3 +157,86 @@ func (m *OrderBookMetrics) RecordMatchedOrders(ctx context.Context, orderType st
 	}
 	m.matchedOrdersTotal.Add(ctx, count, metric.WithAttributes(attrs...))
 }
+
+// RecordSweptOrders increments the swept orders counter for a book
+func (m *OrderBookMetrics) RecordSweptOrders(ctx context.Context, book, reason string, count int64) {
+	if m.sweptOrdersTotal == nil {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("book", book),
+		attribute.String("reason", reason),
+	}
+	m.sweptOrdersTotal.Add(ctx, count, metric.WithAttributes(attrs...))
+}
+
+// RecordBookNotional sets the notional gauges of a book's bid and ask sides
+func (m *OrderBookMetrics) RecordBookNotional(ctx context.Context, book string, bidNotional, askNotional float64) {
+	if m.bookNotional == nil {
+		return
+	}
+
+	m.bookNotional.Record(ctx, bidNotional, metric.WithAttributes(
+		attribute.String("book", book),
+		attribute.String("side", "bid"),
+	))
+	m.bookNotional.Record(ctx, askNotional, metric.WithAttributes(
+		attribute.String("book", book),
+		attribute.String("side", "ask"),
+	))
+}
+
+// RecordBestPrices sets the best bid and best ask gauges of a book
+func (m *OrderBookMetrics) RecordBestPrices(ctx context.Context, book string, bestBid, bestAsk float64) {
+	if m.bestBid == nil || m.bestAsk == nil {
+		return
+	}
+
+	attrs := metric.WithAttributes(attribute.String("book", book))
+	m.bestBid.Record(ctx, bestBid, attrs)
+	m.bestAsk.Record(ctx, bestAsk, attrs)
+}
+
+// RecordMatchDuration records how long matching an order of orderType took in a book
+func (m *OrderBookMetrics) RecordMatchDuration(ctx context.Context, book, orderType string, duration time.Duration) {
+	if m.matchDuration == nil {
+		return
+	}
+
+	m.matchDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("book", book),
+		attribute.String("order_type", orderType),
+	))
+}
+
+// RecordRedisPipelineFlush counts one pipeline of cmds commands executed by
+// the Redis backend with the given key prefix
+func (m *OrderBookMetrics) RecordRedisPipelineFlush(ctx context.Context, prefix string, cmds int64) {
+	if m.redisPipelineFlushes == nil || m.redisPipelineCmds == nil {
+		return
+	}
+
+	attrs := metric.WithAttributes(attribute.String("prefix", prefix))
+	m.redisPipelineFlushes.Add(ctx, 1, attrs)
+	m.redisPipelineCmds.Add(ctx, cmds, attrs)
+}
+
+// RecordDroppedTradeSubscriber counts a trade subscriber of book that was
+// dropped for falling behind
+func (m *OrderBookMetrics) RecordDroppedTradeSubscriber(ctx context.Context, book string) {
+	if m.droppedTradeSubscribers == nil {
+		return
+	}
+
+	m.droppedTradeSubscribers.Add(ctx, 1, metric.WithAttributes(attribute.String("book", book)))
+}
+
+// RecordRateLimitedOrder counts an order of userAddress rejected by the rate limit
+func (m *OrderBookMetrics) RecordRateLimitedOrder(ctx context.Context, userAddress string) {
+	if m.rateLimitedOrders == nil {
+		return
+	}
+
+	m.rateLimitedOrders.Add(ctx, 1, metric.WithAttributes(attribute.String("user_address", userAddress)))
+}
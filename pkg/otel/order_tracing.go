@@ -25,6 +25,19 @@ const (
 	AttributeExecutedQuantity  = "order.executed_quantity"
 	AttributeRemainingQuantity = "order.remaining_quantity"
 	AttributeTradeCount        = "trade.count"
+	AttributeCancelReason      = "order.cancel_reason"
+	AttributeOrderStopPrice    = "order.stop_price"
+	AttributeMakerOrderID      = "maker.order_id"
+	AttributeMatchQuantity     = "match.quantity"
+	AttributeMatchPrice        = "match.price"
+	AttributeLastTradePrice    = "trade.last_price"
+	AttributeUserAddress       = UserAddressBaggageKey
+
+	// Span event names
+	EventFill          = "fill"
+	EventStopTriggered = "stop_triggered"
+	EventFOKCanceled   = "fok_canceled"
+	EventIOCCanceled   = "ioc_canceled"
 )
 
 // StartOrderSpan starts a new span for order processing
@@ -56,3 +69,11 @@ func AddAttributes(span trace.Span, attrs ...attribute.KeyValue) {
 	}
 	span.SetAttributes(attrs...)
 }
+
+// AddEvent records a named event on span if the span is sampled
+func AddEvent(span trace.Span, name string, attrs ...attribute.KeyValue) {
+	if span == nil || !span.IsRecording() {
+		return
+	}
+	span.AddEvent(name, trace.WithAttributes(attrs...))
+}
@@ -62,6 +62,15 @@ func Init(cfg Config) (func(), error) {
 
 	var cleanup []func()
 
+	// Set the text map propagator (this is shared between services). It is
+	// set even without a collector so trace context and the user address
+	// still reach Kafka consumers.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+		UserAddressBaggagePropagator{},
+	))
+
 	// Initialize resources for both services
 	orderResource = initResource(ServiceOrder, cfg.ServiceVersion)
 	matchingEngineResource = initResource(ServiceMatchingEngine, cfg.ServiceVersion)
@@ -190,9 +199,6 @@ func initTracerProvider(cfg Config, resource *sdkresource.Resource) (*sdktrace.T
 		)),
 	)
 
-	// Set the text map propagator (this is shared between services)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-
 	// Set the tracer provider
 	otel.SetTracerProvider(tp)
 
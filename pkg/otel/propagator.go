@@ -0,0 +1,70 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// UserAddressBaggageKey is the baggage member holding the address of the
+// user who submitted an order
+const UserAddressBaggageKey = "user.address"
+
+// userAddressField is the carrier key UserAddressBaggagePropagator uses
+const userAddressField = "user_address"
+
+// UserAddressBaggagePropagator carries the user address baggage member in a
+// plain user_address field, so gRPC metadata and Kafka headers can hold it
+// without a W3C baggage header. Its ExtractRequest method adds the address of
+// an incoming request to the baggage, from where it reaches every span and
+// message produced on the request's behalf.
+type UserAddressBaggagePropagator struct{}
+
+var _ propagation.TextMapPropagator = UserAddressBaggagePropagator{}
+
+// Inject sets the user_address field from the baggage in ctx
+func (UserAddressBaggagePropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	if addr := UserAddressFromContext(ctx); addr != "" {
+		carrier.Set(userAddressField, addr)
+	}
+}
+
+// Extract adds the user_address field of carrier to the baggage in ctx
+func (UserAddressBaggagePropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return withUserAddress(ctx, carrier.Get(userAddressField))
+}
+
+// Fields returns the keys Inject sets
+func (UserAddressBaggagePropagator) Fields() []string {
+	return []string{userAddressField}
+}
+
+// ExtractRequest adds the user address of req, such as a CreateOrderRequest,
+// to the baggage in ctx
+func (UserAddressBaggagePropagator) ExtractRequest(ctx context.Context, req interface{ GetUserAddress() string }) context.Context {
+	return withUserAddress(ctx, req.GetUserAddress())
+}
+
+// UserAddressFromContext returns the user address in the baggage of ctx, or
+// "" if there is none
+func UserAddressFromContext(ctx context.Context) string {
+	return baggage.FromContext(ctx).Member(UserAddressBaggageKey).Value()
+}
+
+// withUserAddress returns ctx with addr as its user address baggage member.
+// Empty addresses, and ones baggage cannot hold, leave ctx unchanged.
+func withUserAddress(ctx context.Context, addr string) context.Context {
+	if addr == "" {
+		return ctx
+	}
+	member, err := baggage.NewMember(UserAddressBaggageKey, addr)
+	if err != nil {
+		return ctx
+	}
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
@@ -0,0 +1,54 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	// webSocketMetrics holds the singleton instance
+	webSocketMetrics *WebSocketMetrics
+)
+
+// WebSocketMetrics holds metrics for WebSocket streaming connections
+type WebSocketMetrics struct {
+	// Tracks the number of currently open WebSocket connections
+	activeConnections metric.Int64UpDownCounter
+}
+
+// GetWebSocketMetrics returns the WebSocketMetrics singleton
+func GetWebSocketMetrics() *WebSocketMetrics {
+	if webSocketMetrics == nil {
+		activeConnections, err := meter.Int64UpDownCounter(
+			"active_websocket_connections",
+			metric.WithDescription("Number of active WebSocket connections"),
+			metric.WithUnit("{connection}"),
+		)
+		if err != nil {
+			return &WebSocketMetrics{}
+		}
+
+		webSocketMetrics = &WebSocketMetrics{
+			activeConnections: activeConnections,
+		}
+	}
+
+	return webSocketMetrics
+}
+
+// RecordConnectionOpened increments the active connections gauge
+func (m *WebSocketMetrics) RecordConnectionOpened(ctx context.Context) {
+	if m.activeConnections == nil {
+		return
+	}
+	m.activeConnections.Add(ctx, 1)
+}
+
+// RecordConnectionClosed decrements the active connections gauge
+func (m *WebSocketMetrics) RecordConnectionClosed(ctx context.Context) {
+	if m.activeConnections == nil {
+		return
+	}
+	m.activeConnections.Add(ctx, -1)
+}
@@ -0,0 +1,113 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/IBM/sarama"
+	"github.com/erain9/matchingo/pkg/db/queue"
+	"github.com/erain9/matchingo/pkg/messaging"
+)
+
+// KafkaSource reads the done messages of a Kafka topic from the earliest
+// offset each partition still holds
+type KafkaSource struct {
+	Brokers []string
+	Topic   string
+}
+
+// Read reads every partition of the topic up to the offset it had reached
+// when Read was called, then calls fn with the messages in the order of the
+// timestamps Kafka gave them. The messages of one book are keyed by sequence
+// number and so spread over the partitions, which is why they are all read
+// before fn is called. Messages that cannot be decoded are skipped.
+func (s *KafkaSource) Read(ctx context.Context, fn func(*messaging.DoneMessage) error) error {
+	config := sarama.NewConfig()
+	config.Consumer.Return.Errors = true
+	client, err := sarama.NewClient(s.Brokers, config)
+	if err != nil {
+		return fmt.Errorf("connect to Kafka: %w", err)
+	}
+	defer client.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return fmt.Errorf("create Kafka consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	partitions, err := client.Partitions(s.Topic)
+	if err != nil {
+		return fmt.Errorf("list partitions of %s: %w", s.Topic, err)
+	}
+
+	var read []*sarama.ConsumerMessage
+	for _, partition := range partitions {
+		msgs, err := readPartition(ctx, client, consumer, s.Topic, partition)
+		if err != nil {
+			return err
+		}
+		read = append(read, msgs...)
+	}
+
+	type decoded struct {
+		done *messaging.DoneMessage
+		msg  *sarama.ConsumerMessage
+	}
+	messages := make([]decoded, 0, len(read))
+	for _, msg := range read {
+		var done messaging.DoneMessage
+		if err := queue.UnmarshalDoneMessage(msg, &done); err != nil {
+			continue
+		}
+		messages = append(messages, decoded{done: &done, msg: msg})
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].msg.Timestamp.Before(messages[j].msg.Timestamp)
+	})
+
+	for _, m := range messages {
+		if err := fn(m.done); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPartition returns the messages of one partition, from the oldest it
+// holds to the last one written before the call
+func readPartition(ctx context.Context, client sarama.Client, consumer sarama.Consumer, topic string, partition int32) ([]*sarama.ConsumerMessage, error) {
+	oldest, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return nil, fmt.Errorf("get oldest offset of %s/%d: %w", topic, partition, err)
+	}
+	newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return nil, fmt.Errorf("get newest offset of %s/%d: %w", topic, partition, err)
+	}
+	if oldest >= newest {
+		return nil, nil
+	}
+
+	pc, err := consumer.ConsumePartition(topic, partition, oldest)
+	if err != nil {
+		return nil, fmt.Errorf("consume %s/%d: %w", topic, partition, err)
+	}
+	defer pc.Close()
+
+	var msgs []*sarama.ConsumerMessage
+	for {
+		select {
+		case msg := <-pc.Messages():
+			msgs = append(msgs, msg)
+			if msg.Offset >= newest-1 {
+				return msgs, nil
+			}
+		case err := <-pc.Errors():
+			return nil, fmt.Errorf("consume %s/%d: %w", topic, partition, err)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
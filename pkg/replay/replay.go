@@ -0,0 +1,332 @@
+// Package replay rebuilds the resting orders of an order book from the done
+// messages it published to Kafka, so a memory order book can be recovered
+// after the process holding it died.
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// ErrBookNotEmpty is returned when replaying into an order book that already
+// holds orders
+var ErrBookNotEmpty = errors.New("order book is not empty")
+
+// ErrUnsupportedOrders is returned when the messages of an order book leave
+// orders on it that cannot be rebuilt from them. The error names them.
+var ErrUnsupportedOrders = errors.New("order book holds orders replay cannot rebuild")
+
+// Source reads published done messages
+type Source interface {
+	// Read calls fn with each message, in the order the messages were
+	// published, and returns once every message published before the call
+	// was read. It stops at the first error fn returns.
+	Read(ctx context.Context, fn func(*messaging.DoneMessage) error) error
+}
+
+// Replayer rebuilds order books from the done messages of a Source.
+//
+// Only limit orders resting on the bids and asks are rebuilt, with their
+// price, remaining quantity, time in force and GTD expiry, and with the
+// amendments made to them. A book left holding OCO, iceberg or pegged
+// orders, or stop orders waiting for their trigger, is refused with
+// ErrUnsupportedOrders rather than rebuilt without them.
+type Replayer struct {
+	source Source
+	now    func() time.Time
+}
+
+// NewReplayer creates a Replayer reading from source
+func NewReplayer(source Source) *Replayer {
+	return &Replayer{source: source, now: time.Now}
+}
+
+// Snapshot reads the done messages of the named order book and returns the
+// orders left resting once they are all applied, with the price of the last
+// trade. Orders appear in the order they hold priority in, so restoring the
+// snapshot keeps it. GTD orders that expired while no book was running to
+// cancel them are left out. It fails with ErrUnsupportedOrders if orders
+// that cannot be rebuilt are left.
+func (r *Replayer) Snapshot(ctx context.Context, bookName string) (*core.Snapshot, error) {
+	state := newBookState()
+	err := r.source.Read(ctx, func(msg *messaging.DoneMessage) error {
+		if msg.OrderBookName == bookName {
+			state.apply(msg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read done messages: %w", err)
+	}
+	return state.snapshot(r.now())
+}
+
+// Replay restores the orders of ob's book, named by ob.Name, into ob and
+// returns how many were restored. ob must be empty; nothing is published
+// for the restored orders.
+func (r *Replayer) Replay(ctx context.Context, ob *core.OrderBook) (int, error) {
+	if ob.OrderCount() > 0 {
+		return 0, ErrBookNotEmpty
+	}
+	snapshot, err := r.Snapshot(ctx, ob.Name())
+	if err != nil {
+		return 0, err
+	}
+	if err := ob.Restore(snapshot); err != nil {
+		return 0, fmt.Errorf("restore orders: %w", err)
+	}
+	return len(snapshot.Bids) + len(snapshot.Asks), nil
+}
+
+// restingOrder is an order the messages applied so far leave on the book
+type restingOrder struct {
+	// placed is the message that put the order on the book, or the one
+	// that last amended it
+	placed   *messaging.DoneMessage
+	quantity fpdecimal.Decimal
+}
+
+// unsupported returns what keeps the order from being rebuilt, or an empty
+// string if nothing does
+func (o *restingOrder) unsupported() string {
+	switch {
+	case o.placed.PegType != "":
+		return "pegged"
+	case o.placed.Iceberg:
+		return "iceberg"
+	case o.placed.OCOID != "":
+		return "OCO"
+	}
+	return ""
+}
+
+// bookState follows the resting orders of one order book through its done
+// messages
+type bookState struct {
+	// applied holds the orders whose placement was applied, so a message
+	// Kafka delivered twice only counts once; amended does the same for
+	// amendments by sequence number
+	applied map[string]bool
+	amended map[uint64]bool
+	resting map[string]*restingOrder
+	// waiting holds the stop orders placed and not yet activated
+	waiting map[string]bool
+	// arrivals lists the orders in the order they hold priority in,
+	// including some that have left since
+	arrivals       []string
+	lastTradePrice fpdecimal.Decimal
+}
+
+func newBookState() *bookState {
+	return &bookState{
+		applied: make(map[string]bool),
+		amended: make(map[uint64]bool),
+		resting: make(map[string]*restingOrder),
+		waiting: make(map[string]bool),
+	}
+}
+
+// apply updates the book with msg. Cancellations remove their order: the
+// user's own, the other leg of an OCO pair (CancelledByOCO), resting orders
+// canceled by self-trade prevention (STPTriggered) and GTD orders canceled
+// at their expiry all arrive as one. Any other message reports a processed
+// order: it fills the makers it traded with, removes the orders it
+// canceled and leaves the order resting if it was stored with quantity left.
+func (s *bookState) apply(msg *messaging.DoneMessage) {
+	if msg.Cancel != nil {
+		delete(s.resting, msg.Cancel.OrderID)
+		delete(s.waiting, msg.Cancel.OrderID)
+		return
+	}
+	if msg.Amended {
+		s.amend(msg)
+		return
+	}
+
+	// A stop order's placement is followed by a message of its own once it
+	// is activated, which is the one that can leave it resting
+	if msg.OrderType == string(core.TypeStopLimit) && !msg.StopActivated {
+		if !s.applied[msg.OrderID] {
+			s.waiting[msg.OrderID] = true
+		}
+		return
+	}
+	delete(s.waiting, msg.OrderID)
+	if s.applied[msg.OrderID] {
+		return
+	}
+	s.applied[msg.OrderID] = true
+
+	for _, trade := range msg.Trades {
+		quantity, err := fpdecimal.FromString(trade.Quantity)
+		if err != nil || !quantity.GreaterThan(fpdecimal.Zero) {
+			continue
+		}
+		if price, err := fpdecimal.FromString(trade.Price); err == nil {
+			s.lastTradePrice = price
+		}
+		if trade.Role == string(core.MAKER) {
+			s.fill(trade.OrderID, quantity)
+		}
+	}
+
+	// Makers canceled by self-trade prevention and OCO legs are listed
+	// here as well as in cancellations of their own
+	for _, orderID := range msg.Canceled {
+		delete(s.resting, orderID)
+		delete(s.waiting, orderID)
+	}
+
+	// A taker that self-trade prevention stopped is not stored, so
+	// STPTriggered needs no more than Stored to be handled
+	if !msg.Stored {
+		return
+	}
+	remaining, err := fpdecimal.FromString(msg.RemainingQty)
+	if err != nil || !remaining.GreaterThan(fpdecimal.Zero) {
+		return
+	}
+	s.resting[msg.OrderID] = &restingOrder{placed: msg, quantity: remaining}
+	s.arrivals = append(s.arrivals, msg.OrderID)
+}
+
+// amend applies an amendment to its resting order, moving the order in the
+// priority order as AmendOrder moves it in its queue: to the front for a
+// better price, to the back for a larger quantity at the same price, and
+// nowhere otherwise, which keeps it ahead of the orders that came after it
+func (s *bookState) amend(msg *messaging.DoneMessage) {
+	if s.amended[msg.SequenceNumber] {
+		return
+	}
+	s.amended[msg.SequenceNumber] = true
+
+	order, ok := s.resting[msg.OrderID]
+	if !ok {
+		return
+	}
+	price, err := fpdecimal.FromString(msg.Price)
+	if err != nil {
+		return
+	}
+	quantity, err := fpdecimal.FromString(msg.RemainingQty)
+	if err != nil {
+		return
+	}
+	previous, err := fpdecimal.FromString(order.placed.Price)
+	if err != nil {
+		return
+	}
+
+	better := price.GreaterThan(previous)
+	if msg.Side == core.Sell.String() {
+		better = price.LessThan(previous)
+	}
+	switch {
+	case better:
+		s.arrivals = append([]string{msg.OrderID}, slices.DeleteFunc(s.arrivals, isOrder(msg.OrderID))...)
+	case price.Equal(previous) && quantity.GreaterThan(order.quantity):
+		s.arrivals = append(slices.DeleteFunc(s.arrivals, isOrder(msg.OrderID)), msg.OrderID)
+	}
+	order.placed = msg
+	order.quantity = quantity
+}
+
+// isOrder returns a function reporting whether an order ID is orderID
+func isOrder(orderID string) func(string) bool {
+	return func(id string) bool { return id == orderID }
+}
+
+// fill takes quantity off a resting order, removing it once none is left
+func (s *bookState) fill(orderID string, quantity fpdecimal.Decimal) {
+	order, ok := s.resting[orderID]
+	if !ok {
+		return
+	}
+	order.quantity = order.quantity.Sub(quantity)
+	if !order.quantity.GreaterThan(fpdecimal.Zero) {
+		delete(s.resting, orderID)
+	}
+}
+
+// snapshot returns the resting orders as of now, or ErrUnsupportedOrders
+// naming the orders it cannot rebuild
+func (s *bookState) snapshot(now time.Time) (*core.Snapshot, error) {
+	var unsupported []string
+	for _, orderID := range s.arrivals {
+		if resting, ok := s.resting[orderID]; ok && resting.unsupported() != "" {
+			unsupported = append(unsupported, fmt.Sprintf("%s (%s)", orderID, resting.unsupported()))
+		}
+	}
+	for orderID := range s.waiting {
+		unsupported = append(unsupported, fmt.Sprintf("%s (stop)", orderID))
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedOrders, strings.Join(unsupported, ", "))
+	}
+
+	snapshot := &core.Snapshot{LastTradePrice: s.lastTradePrice}
+	for _, orderID := range s.arrivals {
+		resting, ok := s.resting[orderID]
+		if !ok {
+			continue
+		}
+		placed := resting.placed
+		if core.TIF(placed.TimeInForce) == core.GTD && !placed.ExpiresAt.After(now) {
+			continue
+		}
+
+		order, err := resting.order()
+		if err != nil {
+			return nil, fmt.Errorf("rebuild order %s: %w", orderID, err)
+		}
+		if order.Side() == core.Buy {
+			snapshot.Bids = append(snapshot.Bids, order)
+		} else {
+			snapshot.Asks = append(snapshot.Asks, order)
+		}
+	}
+	return snapshot, nil
+}
+
+// order rebuilds the resting order as an open limit order
+func (o *restingOrder) order() (*core.Order, error) {
+	placed := o.placed
+
+	var side core.Side
+	switch placed.Side {
+	case core.Buy.String():
+		side = core.Buy
+	case core.Sell.String():
+		side = core.Sell
+	default:
+		return nil, fmt.Errorf("unknown side %q", placed.Side)
+	}
+	price, err := fpdecimal.FromString(placed.Price)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price %q: %w", placed.Price, err)
+	}
+
+	var order *core.Order
+	if core.TIF(placed.TimeInForce) == core.GTD {
+		order, err = core.NewGTDLimitOrder(placed.OrderID, side, o.quantity, price, placed.ExpiresAt, "", placed.UserAddress)
+	} else {
+		order, err = core.NewLimitOrder(placed.OrderID, side, o.quantity, price, core.TIF(placed.TimeInForce), "", placed.UserAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := order.Transition(core.StateOpen); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
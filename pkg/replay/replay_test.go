@@ -0,0 +1,216 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/backend/memory"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceSource serves a fixed list of messages
+type sliceSource []*messaging.DoneMessage
+
+func (s sliceSource) Read(ctx context.Context, fn func(*messaging.DoneMessage) error) error {
+	for _, msg := range s {
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type failingSource struct{}
+
+func (failingSource) Read(context.Context, func(*messaging.DoneMessage) error) error {
+	return errors.New("broker down")
+}
+
+// publishedMessages runs orders through a book named btc-usd and returns the
+// done messages it published
+func publishedMessages(t *testing.T, now time.Time) []*messaging.DoneMessage {
+	t.Helper()
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	ctx := context.Background()
+	ob := core.NewOrderBook(memory.NewMemoryBackend(), core.WithName("btc-usd"), core.WithSTPMode(core.STPCancelMaker))
+	process := func(order *core.Order, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		_, err = ob.Process(ctx, order)
+		require.NoError(t, err)
+	}
+
+	process(core.NewLimitOrder("ask-1", core.Sell, fpdecimal.FromInt(5), fpdecimal.FromInt(101), core.GTC, "", "0xa"))
+	process(core.NewLimitOrder("ask-2", core.Sell, fpdecimal.FromInt(3), fpdecimal.FromInt(102), core.GTC, "", "0xb"))
+	process(core.NewLimitOrder("bid-1", core.Buy, fpdecimal.FromInt(2), fpdecimal.FromInt(99), core.GTC, "", "0xc"))
+	process(core.NewGTDLimitOrder("gtd-expired", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(105), now.Add(time.Hour), "", "0xc"))
+	process(core.NewGTDLimitOrder("gtd-live", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(106), now.Add(48*time.Hour), "", "0xc"))
+	// Fills 2 of ask-1
+	process(core.NewLimitOrder("buy-1", core.Buy, fpdecimal.FromInt(2), fpdecimal.FromInt(101), core.GTC, "", "0xd"))
+	require.NotNil(t, ob.CancelOrder("bid-1"))
+	// Self-trade prevention cancels ask-1 and the rest of the order rests
+	process(core.NewLimitOrder("stp-bid", core.Buy, fpdecimal.FromInt(4), fpdecimal.FromInt(101), core.GTC, "", "0xa"))
+
+	return sender.GetSentMessages()
+}
+
+func TestReplayer_Snapshot(t *testing.T) {
+	now := time.Now()
+	msgs := publishedMessages(t, now)
+
+	// Kafka delivers a message twice and the topic holds another book
+	var source sliceSource
+	for _, msg := range msgs {
+		source = append(source, msg)
+		if msg.OrderID == "buy-1" {
+			source = append(source, msg)
+		}
+	}
+	source = append(source, &messaging.DoneMessage{
+		OrderID: "other-1", OrderBookName: "eth-usd", Stored: true, RemainingQty: "1.000",
+		Side: "BUY", Price: "10.000", OrderType: "LIMIT", TimeInForce: "GTC",
+	})
+
+	replayer := NewReplayer(source)
+	replayer.now = func() time.Time { return now.Add(2 * time.Hour) }
+	snapshot, err := replayer.Snapshot(context.Background(), "btc-usd")
+	require.NoError(t, err)
+
+	require.Len(t, snapshot.Bids, 1)
+	assert.Equal(t, "stp-bid", snapshot.Bids[0].ID())
+	assert.Equal(t, fpdecimal.FromInt(4), snapshot.Bids[0].Quantity())
+	assert.Equal(t, "0xa", snapshot.Bids[0].UserAddress())
+
+	require.Len(t, snapshot.Asks, 2)
+	assert.Equal(t, "ask-2", snapshot.Asks[0].ID())
+	assert.Equal(t, fpdecimal.FromInt(3), snapshot.Asks[0].Quantity())
+	assert.Equal(t, fpdecimal.FromInt(102), snapshot.Asks[0].Price())
+	assert.Equal(t, "gtd-live", snapshot.Asks[1].ID())
+	assert.Equal(t, core.GTD, snapshot.Asks[1].TIF())
+	assert.True(t, snapshot.Asks[1].ExpiresAt().Equal(now.Add(48*time.Hour)))
+	assert.Equal(t, core.StateOpen, snapshot.Asks[1].State())
+
+	assert.Equal(t, fpdecimal.FromInt(101), snapshot.LastTradePrice)
+
+	_, err = NewReplayer(failingSource{}).Snapshot(context.Background(), "btc-usd")
+	assert.Error(t, err)
+}
+
+func TestReplayer_Replay(t *testing.T) {
+	now := time.Now()
+	msgs := publishedMessages(t, now)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	ob := core.NewOrderBook(memory.NewMemoryBackend(), core.WithName("btc-usd"))
+	restored, err := NewReplayer(sliceSource(msgs)).Replay(context.Background(), ob)
+	require.NoError(t, err)
+	assert.Equal(t, 4, restored)
+	assert.Equal(t, 4, ob.OrderCount())
+	assert.Empty(t, sender.GetSentMessages())
+
+	// The restored orders trade like the originals
+	taker, err := core.NewLimitOrder("buy-2", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(102), core.GTC, "", "0xe")
+	require.NoError(t, err)
+	done, err := ob.Process(context.Background(), taker)
+	require.NoError(t, err)
+	require.Len(t, done.Trades, 2)
+	assert.Equal(t, "ask-2", done.Trades[1].OrderID)
+
+	_, err = NewReplayer(sliceSource(msgs)).Replay(context.Background(), ob)
+	assert.ErrorIs(t, err, ErrBookNotEmpty)
+}
+
+func TestReplayer_Amendments(t *testing.T) {
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	ctx := context.Background()
+	ob := core.NewOrderBook(memory.NewMemoryBackend(), core.WithName("btc-usd"))
+	for _, id := range []string{"bid-1", "bid-2", "bid-3"} {
+		order, err := core.NewLimitOrder(id, core.Buy, fpdecimal.FromInt(2), fpdecimal.FromInt(99), core.GTC, "", "")
+		require.NoError(t, err)
+		_, err = ob.Process(ctx, order)
+		require.NoError(t, err)
+	}
+	// bid-3 improves to the front of 100, bid-1 grows to the back of 99
+	// and bid-2 shrinks in place
+	_, err := ob.AmendPrice(ctx, "bid-3", fpdecimal.FromInt(100))
+	require.NoError(t, err)
+	_, err = ob.AmendQuantity(ctx, "bid-1", fpdecimal.FromInt(5))
+	require.NoError(t, err)
+	_, err = ob.AmendQuantity(ctx, "bid-2", fpdecimal.FromInt(1))
+	require.NoError(t, err)
+
+	// Kafka delivers an amendment twice
+	msgs := sender.GetSentMessages()
+	msgs = append(msgs, msgs[len(msgs)-1])
+
+	snapshot, err := NewReplayer(sliceSource(msgs)).Snapshot(ctx, "btc-usd")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Bids, 3)
+	assert.Equal(t, "bid-3", snapshot.Bids[0].ID())
+	assert.Equal(t, fpdecimal.FromInt(100), snapshot.Bids[0].Price())
+	assert.Equal(t, "bid-2", snapshot.Bids[1].ID())
+	assert.Equal(t, fpdecimal.FromInt(1), snapshot.Bids[1].Quantity())
+	assert.Equal(t, "bid-1", snapshot.Bids[2].ID())
+	assert.Equal(t, fpdecimal.FromInt(5), snapshot.Bids[2].Quantity())
+}
+
+func TestReplayer_UnsupportedOrders(t *testing.T) {
+	ctx := context.Background()
+	publish := func(orders ...*core.Order) []*messaging.DoneMessage {
+		t.Helper()
+		sender := messaging.NewMockMessageSender()
+		core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+		defer core.SetMessageSenderFactory(nil)
+
+		ob := core.NewOrderBook(memory.NewMemoryBackend(), core.WithName("btc-usd"))
+		for _, order := range orders {
+			_, err := ob.Process(ctx, order)
+			require.NoError(t, err)
+		}
+		return sender.GetSentMessages()
+	}
+	order := func(order *core.Order, err error) *core.Order {
+		t.Helper()
+		require.NoError(t, err)
+		return order
+	}
+
+	msgs := publish(
+		order(core.NewLimitOrder("ask-1", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(101), core.GTC, "", "")),
+		order(core.NewIcebergOrder("iceberg-1", core.Sell, fpdecimal.FromInt(10), fpdecimal.FromInt(2), fpdecimal.FromInt(102), core.GTC, "", "test_user")),
+		order(core.NewStopLimitOrder("stop-1", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(110), fpdecimal.FromInt(105), "", "")),
+		order(core.NewLimitOrder("oco-limit", core.Sell, fpdecimal.FromInt(1), fpdecimal.FromInt(120), core.GTC, "oco-1", "")),
+	)
+	_, err := NewReplayer(sliceSource(msgs)).Snapshot(ctx, "btc-usd")
+	require.ErrorIs(t, err, ErrUnsupportedOrders)
+	assert.Contains(t, err.Error(), "iceberg-1 (iceberg)")
+	assert.Contains(t, err.Error(), "stop-1 (stop)")
+	assert.Contains(t, err.Error(), "oco-limit (OCO)")
+	assert.NotContains(t, err.Error(), "ask-1")
+
+	// Orders that left the book no longer stop it being replayed
+	msgs = append(msgs,
+		&messaging.DoneMessage{OrderBookName: "btc-usd", Cancel: &messaging.CancelMessage{OrderID: "iceberg-1"}},
+		&messaging.DoneMessage{OrderBookName: "btc-usd", Cancel: &messaging.CancelMessage{OrderID: "stop-1"}},
+		&messaging.DoneMessage{OrderBookName: "btc-usd", Cancel: &messaging.CancelMessage{OrderID: "oco-limit"}},
+	)
+	snapshot, err := NewReplayer(sliceSource(msgs)).Snapshot(ctx, "btc-usd")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Asks, 1)
+	assert.Equal(t, "ask-1", snapshot.Asks[0].ID())
+}
@@ -0,0 +1,255 @@
+// Package router splits an order across several order books to reach the
+// liquidity each of them holds.
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+var (
+	// ErrNoBooks is returned when an order is routed to no books
+	ErrNoBooks = errors.New("no order books to route to")
+	// ErrNoLiquidity is returned when no book can fill any part of a market order
+	ErrNoLiquidity = errors.New("no liquidity in any order book")
+	// ErrUnsupportedOrder is returned for orders the router cannot split
+	ErrUnsupportedOrder = errors.New("only market and limit orders in base quantity can be routed")
+	// ErrDuplicateBook is returned when an order is routed to the same book twice
+	ErrDuplicateBook = errors.New("order book listed more than once")
+)
+
+// Strategy decides how an order's quantity is divided between books
+type Strategy int
+
+const (
+	// BestPriceFirst fills the book with the best average price as far as
+	// its liquidity allows, then moves to the next best
+	BestPriceFirst Strategy = iota
+	// ProportionalSplit divides the order in proportion to each book's liquidity
+	ProportionalSplit
+)
+
+// BookLookup resolves an order book by name
+type BookLookup func(ctx context.Context, name string) (*core.OrderBook, error)
+
+// SmartOrderRouter routes orders across several order books
+type SmartOrderRouter struct {
+	lookup   BookLookup
+	strategy Strategy
+}
+
+// NewSmartOrderRouter creates a router that finds books with lookup and
+// allocates quantity with strategy
+func NewSmartOrderRouter(lookup BookLookup, strategy Strategy) *SmartOrderRouter {
+	return &SmartOrderRouter{
+		lookup:   lookup,
+		strategy: strategy,
+	}
+}
+
+// ChildOrderID returns the ID of the slice of parent order orderID sent to book
+func ChildOrderID(orderID, book string) string {
+	return fmt.Sprintf("%s-%s", orderID, book)
+}
+
+// candidate is a book that can fill part of the order being routed
+type candidate struct {
+	name      string
+	book      *core.OrderBook
+	available fpdecimal.Decimal
+	// avgPrice is the average price of filling min(order quantity, available)
+	avgPrice fpdecimal.Decimal
+	alloc    fpdecimal.Decimal
+}
+
+// Route allocates order's quantity across books and processes one child order
+// per book with a non-zero allocation. Child orders are IDed by ChildOrderID
+// and copy the order's side, price, TIF and user. A limit order's quantity
+// beyond the available liquidity goes to the best book, where it rests.
+// One Done is returned per processed child order, in allocation order. When
+// a book fails to process its child order, the Dones of the books processed
+// before it are returned with the error.
+func (r *SmartOrderRouter) Route(ctx context.Context, order *core.Order, books []string) ([]*core.Done, error) {
+	if len(books) == 0 {
+		return nil, ErrNoBooks
+	}
+	if order.IsQuote() || (!order.IsMarketOrder() && !order.IsLimitOrder()) {
+		return nil, ErrUnsupportedOrder
+	}
+
+	// Child orders are IDed by book, so each book can only get one
+	seen := make(map[string]bool, len(books))
+	for _, name := range books {
+		if seen[name] {
+			return nil, fmt.Errorf("order book %s: %w", name, ErrDuplicateBook)
+		}
+		seen[name] = true
+	}
+
+	candidates := make([]*candidate, 0, len(books))
+	for _, name := range books {
+		book, err := r.lookup(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("order book %s: %w", name, err)
+		}
+		c, err := evaluate(name, book, order)
+		if err != nil {
+			return nil, fmt.Errorf("order book %s: %w", name, err)
+		}
+		candidates = append(candidates, c)
+	}
+
+	// Best average price first; ties keep the caller's book order
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		// Books without liquidity have no price and go last
+		aEmpty, bEmpty := a.available.Equal(fpdecimal.Zero), b.available.Equal(fpdecimal.Zero)
+		if aEmpty || bEmpty {
+			return !aEmpty && bEmpty
+		}
+		if order.Side() == core.Buy {
+			return a.avgPrice.LessThan(b.avgPrice)
+		}
+		return a.avgPrice.GreaterThan(b.avgPrice)
+	})
+
+	var unallocated fpdecimal.Decimal
+	switch r.strategy {
+	case ProportionalSplit:
+		unallocated = allocateProportionally(candidates, order.Quantity())
+	default:
+		unallocated = allocateGreedily(candidates, order.Quantity())
+	}
+
+	if unallocated.GreaterThan(fpdecimal.Zero) && order.IsLimitOrder() {
+		candidates[0].alloc = candidates[0].alloc.Add(unallocated)
+	}
+	if candidates[0].alloc.Equal(fpdecimal.Zero) {
+		return nil, ErrNoLiquidity
+	}
+
+	var dones []*core.Done
+	for _, c := range candidates {
+		if c.alloc.Equal(fpdecimal.Zero) {
+			continue
+		}
+
+		child, err := childOrder(order, c)
+		if err != nil {
+			return dones, err
+		}
+		done, err := c.book.Process(ctx, child)
+		if err != nil {
+			return dones, fmt.Errorf("order book %s: %w", c.name, err)
+		}
+		dones = append(dones, done)
+	}
+	return dones, nil
+}
+
+// evaluate measures how much of order book can fill and at what average price
+func evaluate(name string, book *core.OrderBook, order *core.Order) (*candidate, error) {
+	c := &candidate{name: name, book: book, available: fpdecimal.Zero, alloc: fpdecimal.Zero}
+
+	snapshot := book.Snapshot()
+	resting := snapshot.Asks
+	if order.Side() == core.Sell {
+		resting = snapshot.Bids
+	}
+	for _, o := range resting {
+		// Resting orders are best first, so the first one that does not
+		// cross a limit order ends the usable liquidity
+		if order.IsLimitOrder() && !crosses(order, o.Price()) {
+			break
+		}
+		c.available = c.available.Add(o.Quantity())
+	}
+	if c.available.Equal(fpdecimal.Zero) {
+		return c, nil
+	}
+
+	quantity := order.Quantity()
+	if c.available.LessThan(quantity) {
+		quantity = c.available
+	}
+	total, err := book.CalculateMarketPrice(order.Side(), quantity)
+	if err != nil {
+		return nil, err
+	}
+	c.avgPrice = total.Div(quantity)
+	return c, nil
+}
+
+// crosses reports whether a limit order would trade at price
+func crosses(order *core.Order, price fpdecimal.Decimal) bool {
+	if order.Side() == core.Buy {
+		return price.LessThanOrEqual(order.Price())
+	}
+	return price.GreaterThanOrEqual(order.Price())
+}
+
+// allocateGreedily fills candidates in order up to their liquidity and
+// returns the quantity left over
+func allocateGreedily(candidates []*candidate, quantity fpdecimal.Decimal) fpdecimal.Decimal {
+	remaining := quantity
+	for _, c := range candidates {
+		c.alloc = minDecimal(remaining, c.available)
+		remaining = remaining.Sub(c.alloc)
+	}
+	return remaining
+}
+
+// allocateProportionally gives each candidate a share of quantity matching its
+// share of the total liquidity and returns the quantity left over. Rounding
+// leftovers go to the best candidates that still have liquidity.
+func allocateProportionally(candidates []*candidate, quantity fpdecimal.Decimal) fpdecimal.Decimal {
+	total := fpdecimal.Zero
+	for _, c := range candidates {
+		total = total.Add(c.available)
+	}
+	if total.Equal(fpdecimal.Zero) {
+		return quantity
+	}
+	if total.LessThanOrEqual(quantity) {
+		return allocateGreedily(candidates, quantity)
+	}
+
+	remaining := quantity
+	for _, c := range candidates {
+		c.alloc = quantity.Mul(c.available).Div(total)
+		remaining = remaining.Sub(c.alloc)
+	}
+	for _, c := range candidates {
+		extra := minDecimal(remaining, c.available.Sub(c.alloc))
+		c.alloc = c.alloc.Add(extra)
+		remaining = remaining.Sub(extra)
+	}
+	return remaining
+}
+
+// childOrder builds the slice of order sent to c
+func childOrder(order *core.Order, c *candidate) (*core.Order, error) {
+	id := ChildOrderID(order.ID(), c.name)
+	if order.IsMarketOrder() {
+		if order.TIF() == core.FOK {
+			return core.NewFOKMarketOrder(id, order.Side(), c.alloc, order.UserAddress())
+		}
+		return core.NewMarketOrder(id, order.Side(), c.alloc, order.UserAddress())
+	}
+	if order.TIF() == core.GTD {
+		return core.NewGTDLimitOrder(id, order.Side(), c.alloc, order.Price(), order.ExpiresAt(), "", order.UserAddress())
+	}
+	return core.NewLimitOrder(id, order.Side(), c.alloc, order.Price(), order.TIF(), "", order.UserAddress())
+}
+
+func minDecimal(a, b fpdecimal.Decimal) fpdecimal.Decimal {
+	if a.LessThan(b) {
+		return a
+	}
+	return b
+}
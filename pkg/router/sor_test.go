@@ -0,0 +1,149 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/backend/memory"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBooks creates memory books "A" (5 asks at 100) and "B" (10 asks at 101)
+// and a lookup that finds them
+func newBooks(t *testing.T) (map[string]*core.OrderBook, BookLookup) {
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	t.Cleanup(func() { core.SetMessageSenderFactory(nil) })
+
+	books := map[string]*core.OrderBook{
+		"A": core.NewOrderBook(memory.NewMemoryBackend()),
+		"B": core.NewOrderBook(memory.NewMemoryBackend()),
+	}
+	for name, level := range map[string]struct{ qty, price int64 }{"A": {5, 100}, "B": {10, 101}} {
+		ask, err := core.NewLimitOrder("ask-"+name, core.Sell, fpdecimal.FromInt(level.qty), fpdecimal.FromInt(level.price), core.GTC, "", "maker")
+		require.NoError(t, err)
+		_, err = books[name].Process(context.Background(), ask)
+		require.NoError(t, err)
+	}
+
+	lookup := func(_ context.Context, name string) (*core.OrderBook, error) {
+		book, ok := books[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown book %s", name)
+		}
+		return book, nil
+	}
+	return books, lookup
+}
+
+// filled returns the quantity each child order in dones executed, by child order ID
+func filled(dones []*core.Done) map[string]string {
+	out := make(map[string]string)
+	for _, done := range dones {
+		out[done.Order.ID()] = done.Processed.String()
+	}
+	return out
+}
+
+func TestRoute_BestPriceFirst(t *testing.T) {
+	books, lookup := newBooks(t)
+	router := NewSmartOrderRouter(lookup, BestPriceFirst)
+
+	order, err := core.NewMarketOrder("buy-1", core.Buy, fpdecimal.FromInt(12), "taker")
+	require.NoError(t, err)
+
+	// The worse book is listed first; the router still fills the cheaper one first
+	dones, err := router.Route(context.Background(), order, []string{"B", "A"})
+	require.NoError(t, err)
+	require.Len(t, dones, 2)
+	assert.Equal(t, ChildOrderID("buy-1", "A"), dones[0].Order.ID())
+	assert.Equal(t, map[string]string{
+		ChildOrderID("buy-1", "A"): "5.000",
+		ChildOrderID("buy-1", "B"): "7.000",
+	}, filled(dones))
+
+	assert.Nil(t, books["A"].GetOrder("ask-A"), "book A should be swept")
+	assert.Equal(t, "3.000", books["B"].GetOrder("ask-B").Quantity().String())
+}
+
+func TestRoute_ProportionalSplit(t *testing.T) {
+	_, lookup := newBooks(t)
+	router := NewSmartOrderRouter(lookup, ProportionalSplit)
+
+	order, err := core.NewMarketOrder("buy-1", core.Buy, fpdecimal.FromInt(12), "taker")
+	require.NoError(t, err)
+
+	// A holds 5 of the 15 units available, so it gets a third of the order
+	dones, err := router.Route(context.Background(), order, []string{"A", "B"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		ChildOrderID("buy-1", "A"): "4.000",
+		ChildOrderID("buy-1", "B"): "8.000",
+	}, filled(dones))
+}
+
+func TestRoute_LimitOrder(t *testing.T) {
+	books, lookup := newBooks(t)
+	router := NewSmartOrderRouter(lookup, BestPriceFirst)
+
+	// Only book A's level crosses 100; the rest of the order rests there
+	order, err := core.NewLimitOrder("buy-1", core.Buy, fpdecimal.FromInt(8), fpdecimal.FromInt(100), core.GTC, "", "taker")
+	require.NoError(t, err)
+
+	dones, err := router.Route(context.Background(), order, []string{"A", "B"})
+	require.NoError(t, err)
+	require.Len(t, dones, 1)
+	assert.Equal(t, "5.000", dones[0].Processed.String())
+	assert.True(t, dones[0].Stored)
+
+	resting := books["A"].GetOrder(ChildOrderID("buy-1", "A"))
+	require.NotNil(t, resting)
+	assert.Equal(t, "3.000", resting.Quantity().String())
+	assert.Nil(t, books["B"].GetOrder(ChildOrderID("buy-1", "B")))
+}
+
+func TestRoute_Errors(t *testing.T) {
+	_, lookup := newBooks(t)
+	router := NewSmartOrderRouter(lookup, BestPriceFirst)
+
+	sell, err := core.NewMarketOrder("sell-1", core.Sell, fpdecimal.FromInt(1), "taker")
+	require.NoError(t, err)
+
+	_, err = router.Route(context.Background(), sell, nil)
+	assert.ErrorIs(t, err, ErrNoBooks)
+
+	// Neither book has bids
+	_, err = router.Route(context.Background(), sell, []string{"A", "B"})
+	assert.ErrorIs(t, err, ErrNoLiquidity)
+
+	_, err = router.Route(context.Background(), sell, []string{"A", "missing"})
+	assert.ErrorContains(t, err, "order book missing")
+
+	_, err = router.Route(context.Background(), sell, []string{"A", "B", "A"})
+	assert.ErrorIs(t, err, ErrDuplicateBook)
+
+	stop, err := core.NewStopLimitOrder("stop-1", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), fpdecimal.FromInt(99), "", "taker")
+	require.NoError(t, err)
+	_, err = router.Route(context.Background(), stop, []string{"A"})
+	assert.ErrorIs(t, err, ErrUnsupportedOrder)
+}
+
+func TestRoute_PartialFailure(t *testing.T) {
+	books, lookup := newBooks(t)
+	router := NewSmartOrderRouter(lookup, BestPriceFirst)
+	books["B"].SetHalted(true)
+
+	buy, err := core.NewMarketOrder("buy-1", core.Buy, fpdecimal.FromInt(8), "taker")
+	require.NoError(t, err)
+
+	// A fills first, then B refuses its share
+	dones, err := router.Route(context.Background(), buy, []string{"A", "B"})
+	assert.ErrorIs(t, err, core.ErrMarketHalted)
+	assert.ErrorContains(t, err, "order book B")
+	assert.Equal(t, map[string]string{"buy-1-A": "5.000"}, filled(dones))
+}
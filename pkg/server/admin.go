@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/erain9/matchingo/pkg/logging"
+)
+
+// DefaultPProfAddr is where the admin server listens when no address is set.
+// It is bound to localhost so profiles are not reachable from other hosts.
+const DefaultPProfAddr = "localhost:6060"
+
+// AdminServer serves pprof profiling endpoints on a listener separate from the
+// public HTTP server, so profiling is never exposed alongside the API
+type AdminServer struct {
+	addr     string
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewAdminServer creates an AdminServer that will listen on addr
+func NewAdminServer(addr string) *AdminServer {
+	if addr == "" {
+		addr = DefaultPProfAddr
+	}
+
+	mux := http.NewServeMux()
+	// Index also serves the named profiles such as /debug/pprof/goroutine and /debug/pprof/heap
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return &AdminServer{
+		addr:   addr,
+		server: &http.Server{Handler: mux},
+	}
+}
+
+// Start binds the listener and serves requests in the background. Binding
+// happens before Start returns so address errors are reported to the caller.
+func (s *AdminServer) Start(ctx context.Context) error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.listener = lis
+
+	logger := logging.FromContext(ctx)
+	go func() {
+		logger.Info().Str("addr", lis.Addr().String()).Msg("Starting admin server")
+		if err := s.server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error().Err(err).Msg("Admin server stopped")
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the server is listening on, which differs from the
+// configured address when it used port 0
+func (s *AdminServer) Addr() string {
+	if s.listener == nil {
+		return s.addr
+	}
+	return s.listener.Addr().String()
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests,
+// such as a running CPU profile, until ctx is done
+func (s *AdminServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
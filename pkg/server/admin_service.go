@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"runtime"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultAdminGRPCAddr is where the admin gRPC service listens when no address
+// is set. Like the pprof listener it is bound to localhost.
+const DefaultAdminGRPCAddr = "localhost:50052"
+
+// AdminService changes the rate limit, circuit breakers and halts of a
+// running server without a restart
+type AdminService struct {
+	proto.UnimplementedAdminServiceServer
+	manager *OrderBookManager
+	orders  *GRPCOrderBookService
+}
+
+// NewAdminService creates an AdminService for the order books of manager and
+// the orders submitted through orders
+func NewAdminService(manager *OrderBookManager, orders *GRPCOrderBookService) *AdminService {
+	return &AdminService{manager: manager, orders: orders}
+}
+
+// UpdateRateLimit replaces the rate limit of CreateOrder. Buckets of user
+// addresses already seen keep their tokens; a zero rate disables the limit.
+func (s *AdminService) UpdateRateLimit(ctx context.Context, req *proto.UpdateRateLimitRequest) (*proto.RateLimitResponse, error) {
+	logger := logging.FromContext(ctx).With().Str("method", "UpdateRateLimit").Logger()
+
+	var violations []Violation
+	if req.OrdersPerSecond < 0 {
+		violations = append(violations, Violation{Field: "orders_per_second", Description: "must not be negative"})
+	}
+	if req.OrdersPerSecond > 0 && req.Burst < 1 {
+		violations = append(violations, Violation{Field: "burst", Description: "must be at least 1"})
+	}
+	if len(violations) > 0 {
+		return nil, validationError(violations...)
+	}
+
+	switch limiter := s.orders.OrderRateLimiter(); {
+	case req.OrdersPerSecond == 0:
+		s.orders.SetOrderRateLimiter(nil)
+	case limiter == nil:
+		s.orders.SetOrderRateLimiter(NewOrderRateLimiter(req.OrdersPerSecond, int(req.Burst)))
+	default:
+		limiter.SetLimit(req.OrdersPerSecond, int(req.Burst))
+	}
+
+	logger.Info().Float64("orders_per_second", req.OrdersPerSecond).Int32("burst", req.Burst).Msg("Updated rate limit")
+	return &proto.RateLimitResponse{OrdersPerSecond: req.OrdersPerSecond, Burst: req.Burst}, nil
+}
+
+// UpdateCircuitBreaker replaces the circuit breaker of an order book
+func (s *AdminService) UpdateCircuitBreaker(ctx context.Context, req *proto.UpdateCircuitBreakerRequest) (*proto.OrderBookResponse, error) {
+	breaker := req.GetCircuitBreaker()
+	var violations []Violation
+	if breaker == nil {
+		violations = append(violations, Violation{Field: "circuit_breaker", Description: "is required"})
+	} else {
+		if breaker.MaxPriceMovePct < 0 {
+			violations = append(violations, Violation{Field: "circuit_breaker.max_price_move_pct", Description: "must not be negative"})
+		}
+		if breaker.CooldownSeconds < 0 {
+			violations = append(violations, Violation{Field: "circuit_breaker.cooldown_seconds", Description: "must not be negative"})
+		}
+	}
+	if len(violations) > 0 {
+		return nil, validationError(violations...)
+	}
+
+	info, err := s.manager.UpdateCircuitBreaker(ctx, req.OrderBookName, core.CircuitBreakerConfig{
+		MaxPriceMovePct: breaker.MaxPriceMovePct,
+		CooldownSeconds: int(breaker.CooldownSeconds),
+	})
+	if err != nil {
+		return nil, orderBookError(req.OrderBookName, err)
+	}
+	return orderBookInfoToProto(info), nil
+}
+
+// SetOrderBookHalted halts or resumes matching on an order book
+func (s *AdminService) SetOrderBookHalted(ctx context.Context, req *proto.SetOrderBookHaltedRequest) (*proto.SetOrderBookHaltedResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "SetOrderBookHalted").
+		Str("order_book", req.OrderBookName).
+		Logger()
+
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		return nil, orderBookError(req.OrderBookName, err)
+	}
+	orderBook.SetHalted(req.Halted)
+	reason, halted := orderBook.Halted()
+
+	logger.Info().Bool("halted", halted).Msg("Set order book halt")
+	return &proto.SetOrderBookHaltedResponse{
+		OrderBookName: req.OrderBookName,
+		Halted:        halted,
+		HaltReason:    reason,
+	}, nil
+}
+
+// GetServerStats reports the active order books, the orders processed and
+// the Go runtime's goroutines and heap
+func (s *AdminService) GetServerStats(ctx context.Context, req *proto.GetServerStatsRequest) (*proto.ServerStatsResponse, error) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return &proto.ServerStatsResponse{
+		ActiveOrderBooks: int32(len(s.manager.ListOrderBooks(ctx, false))),
+		OrdersProcessed:  uint64(s.orders.OrdersProcessed()),
+		Goroutines:       int32(runtime.NumGoroutine()),
+		HeapAllocBytes:   mem.HeapAlloc,
+		HeapSysBytes:     mem.HeapSys,
+	}, nil
+}
+
+// orderBookError converts a manager error about the named order book to a
+// gRPC status
+func orderBookError(name string, err error) error {
+	switch {
+	case errors.Is(err, ErrOrderBookNotFound):
+		return status.Errorf(codes.NotFound, "order book %s not found", name)
+	case errors.Is(err, ErrOrderBookDeleted):
+		return status.Errorf(codes.NotFound, "order book %s has been deleted", name)
+	default:
+		return status.Errorf(codes.Internal, "order book %s: %v", name, err)
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newAdminTestService returns an order service with a memory book named
+// admin-book and the admin service controlling it
+func newAdminTestService(t *testing.T) (*GRPCOrderBookService, *AdminService) {
+	t.Helper()
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	t.Cleanup(func() { core.SetMessageSenderFactory(nil) })
+
+	manager := NewOrderBookManager()
+	t.Cleanup(func() { manager.Close() })
+	service := NewGRPCOrderBookService(manager)
+	_, err := service.CreateOrderBook(context.Background(), &proto.CreateOrderBookRequest{
+		Name:        "admin-book",
+		BackendType: proto.BackendType_MEMORY,
+	})
+	require.NoError(t, err)
+	return service, NewAdminService(manager, service)
+}
+
+func adminTestOrder(id string) *proto.CreateOrderRequest {
+	return &proto.CreateOrderRequest{
+		OrderBookName: "admin-book",
+		OrderId:       id,
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+		UserAddress:   "0x52908400098527886e0f7030069857d2e4169ee7",
+	}
+}
+
+func TestAdminUpdateRateLimit(t *testing.T) {
+	ctx := context.Background()
+	service, admin := newAdminTestService(t)
+
+	resp, err := admin.UpdateRateLimit(ctx, &proto.UpdateRateLimitRequest{OrdersPerSecond: 0.001, Burst: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 0.001, resp.OrdersPerSecond)
+	assert.Equal(t, int32(1), resp.Burst)
+
+	_, err = service.CreateOrder(ctx, adminTestOrder("ask-1"))
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, adminTestOrder("ask-2"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// The faster rate refills the bucket already in use within milliseconds
+	_, err = admin.UpdateRateLimit(ctx, &proto.UpdateRateLimitRequest{OrdersPerSecond: 1000, Burst: 10})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = service.CreateOrder(ctx, adminTestOrder("ask-3"))
+	require.NoError(t, err)
+
+	_, err = admin.UpdateRateLimit(ctx, &proto.UpdateRateLimitRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, service.OrderRateLimiter())
+
+	_, err = admin.UpdateRateLimit(ctx, &proto.UpdateRateLimitRequest{OrdersPerSecond: 10})
+	assert.Equal(t, map[string]string{"burst": "must be at least 1"}, fieldViolations(t, err))
+	_, err = admin.UpdateRateLimit(ctx, &proto.UpdateRateLimitRequest{OrdersPerSecond: -1})
+	assert.Equal(t, map[string]string{"orders_per_second": "must not be negative"}, fieldViolations(t, err))
+}
+
+func TestAdminUpdateCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	service, admin := newAdminTestService(t)
+
+	resp, err := admin.UpdateCircuitBreaker(ctx, &proto.UpdateCircuitBreakerRequest{
+		OrderBookName:  "admin-book",
+		CircuitBreaker: &proto.CreateOrderBookRequest_CircuitBreaker{MaxPriceMovePct: 5, CooldownSeconds: 60},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, resp.CircuitBreaker.GetMaxPriceMovePct())
+
+	book, err := service.GetOrderBook(ctx, &proto.GetOrderBookRequest{Name: "admin-book"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(60), book.CircuitBreaker.GetCooldownSeconds())
+
+	_, err = admin.UpdateCircuitBreaker(ctx, &proto.UpdateCircuitBreakerRequest{
+		OrderBookName:  "missing",
+		CircuitBreaker: &proto.CreateOrderBookRequest_CircuitBreaker{MaxPriceMovePct: 5},
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = admin.UpdateCircuitBreaker(ctx, &proto.UpdateCircuitBreakerRequest{OrderBookName: "admin-book"})
+	assert.Equal(t, map[string]string{"circuit_breaker": "is required"}, fieldViolations(t, err))
+}
+
+func TestAdminSetOrderBookHalted(t *testing.T) {
+	ctx := context.Background()
+	service, admin := newAdminTestService(t)
+
+	resp, err := admin.SetOrderBookHalted(ctx, &proto.SetOrderBookHaltedRequest{OrderBookName: "admin-book", Halted: true})
+	require.NoError(t, err)
+	assert.True(t, resp.Halted)
+	assert.Equal(t, core.HaltReasonOperator, resp.HaltReason)
+
+	_, err = service.CreateOrder(ctx, adminTestOrder("ask-1"))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	resp, err = admin.SetOrderBookHalted(ctx, &proto.SetOrderBookHaltedRequest{OrderBookName: "admin-book"})
+	require.NoError(t, err)
+	assert.False(t, resp.Halted)
+	assert.Empty(t, resp.HaltReason)
+
+	_, err = service.CreateOrder(ctx, adminTestOrder("ask-1"))
+	require.NoError(t, err)
+
+	_, err = admin.SetOrderBookHalted(ctx, &proto.SetOrderBookHaltedRequest{OrderBookName: "missing", Halted: true})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAdminGetServerStats(t *testing.T) {
+	ctx := context.Background()
+	service, admin := newAdminTestService(t)
+
+	for _, id := range []string{"ask-1", "ask-2"} {
+		_, err := service.CreateOrder(ctx, adminTestOrder(id))
+		require.NoError(t, err)
+	}
+
+	stats, err := admin.GetServerStats(ctx, &proto.GetServerStatsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), stats.ActiveOrderBooks)
+	assert.Equal(t, uint64(2), stats.OrdersProcessed)
+	assert.Positive(t, stats.Goroutines)
+	assert.Positive(t, stats.HeapAllocBytes)
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminServer(t *testing.T) {
+	admin := NewAdminServer("127.0.0.1:0")
+	require.NoError(t, admin.Start(context.Background()))
+
+	resp, err := http.Get("http://" + admin.Addr() + "/debug/pprof/goroutine?debug=1")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, body)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, admin.Shutdown(ctx))
+
+	_, err = http.Get("http://" + admin.Addr() + "/debug/pprof/")
+	assert.Error(t, err, "admin server still serving after shutdown")
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nikolaydubina/fpdecimal"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Violation describes why a single request field is invalid
+type Violation struct {
+	Field       string
+	Description string
+}
+
+// validationError returns an InvalidArgument status whose details carry a
+// BadRequest listing every violation, so clients can point at the bad fields
+func validationError(violations ...Violation) error {
+	descriptions := make([]string, 0, len(violations))
+	fieldViolations := make([]*errdetails.BadRequest_FieldViolation, 0, len(violations))
+	for _, v := range violations {
+		descriptions = append(descriptions, fmt.Sprintf("%s %s", v.Field, v.Description))
+		fieldViolations = append(fieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+
+	st := status.New(codes.InvalidArgument, "invalid request: "+strings.Join(descriptions, "; "))
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: fieldViolations})
+	if err != nil {
+		// Details could not be attached; the message still names the fields
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// parsePositiveDecimal parses value as a decimal greater than zero. When it is
+// not, a violation for field is appended to violations and zero is returned.
+// A field that already has a violation is not reported again.
+func parsePositiveDecimal(field, value string, violations *[]Violation) fpdecimal.Decimal {
+	for _, v := range *violations {
+		if v.Field == field {
+			return fpdecimal.Zero
+		}
+	}
+	d, err := fpdecimal.FromString(value)
+	if err != nil {
+		*violations = append(*violations, Violation{Field: field, Description: "must be a decimal number"})
+		return fpdecimal.Zero
+	}
+	if d.LessThanOrEqual(fpdecimal.Zero) {
+		*violations = append(*violations, Violation{Field: field, Description: "must be positive"})
+		return fpdecimal.Zero
+	}
+	return d
+}
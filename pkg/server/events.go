@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/otel"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// watcherBufferSize is how many events a watcher may fall behind before
+// further events are dropped for it, or before it is dropped itself if it
+// subscribed with dropSlow
+const watcherBufferSize = 256
+
+// subscription is how a watcher wants the events of its book
+type subscription struct {
+	// types are the event types sent to the watcher; all of them if empty
+	types []proto.OrderBookEventType
+	// dropSlow drops a watcher that has no room for an event and closes
+	// its channel, so it never misses an event without knowing. Other
+	// watchers miss the event instead. Trade subscribers are held this way.
+	dropSlow bool
+}
+
+// watcher is a channel registered with an eventBroker
+type watcher struct {
+	ch       chan *proto.OrderBookEvent
+	types    map[proto.OrderBookEventType]bool
+	dropSlow bool
+}
+
+// wants reports whether the watcher is sent events of type t
+func (w *watcher) wants(t proto.OrderBookEventType) bool {
+	return len(w.types) == 0 || w.types[t]
+}
+
+// eventBroker fans order book events out to the watchers of each book
+type eventBroker struct {
+	mu       sync.Mutex
+	watchers map[string]map[*watcher]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		watchers: make(map[string]map[*watcher]struct{}),
+	}
+}
+
+// subscribe registers a watcher of book. The returned function unregisters it.
+func (b *eventBroker) subscribe(book string, sub subscription) (<-chan *proto.OrderBookEvent, func()) {
+	w := &watcher{
+		ch:       make(chan *proto.OrderBookEvent, watcherBufferSize),
+		types:    make(map[proto.OrderBookEventType]bool, len(sub.types)),
+		dropSlow: sub.dropSlow,
+	}
+	for _, t := range sub.types {
+		w.types[t] = true
+	}
+
+	b.mu.Lock()
+	if b.watchers[book] == nil {
+		b.watchers[book] = make(map[*watcher]struct{})
+	}
+	b.watchers[book][w] = struct{}{}
+	b.mu.Unlock()
+
+	return w.ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(book, w)
+	}
+}
+
+// remove unregisters w from book, if it is still registered. The caller
+// must hold mu.
+func (b *eventBroker) remove(book string, w *watcher) {
+	delete(b.watchers[book], w)
+	if len(b.watchers[book]) == 0 {
+		delete(b.watchers, book)
+	}
+}
+
+// publish sends the events of book to its watchers before returning.
+// Watchers that are too far behind miss the events rather than slowing down
+// order processing, except those subscribed with dropSlow: one without room
+// for all the events it wants is dropped and its channel closed.
+func (b *eventBroker) publish(ctx context.Context, book string, events ...*proto.OrderBookEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for w := range b.watchers[book] {
+		if w.dropSlow {
+			wanted := 0
+			for _, event := range events {
+				if w.wants(event.Type) {
+					wanted++
+				}
+			}
+			if cap(w.ch)-len(w.ch) < wanted {
+				b.remove(book, w)
+				close(w.ch)
+				otel.GetOrderBookMetrics().RecordDroppedTradeSubscriber(ctx, book)
+				continue
+			}
+		}
+		for _, event := range events {
+			if !w.wants(event.Type) {
+				continue
+			}
+			select {
+			case w.ch <- event:
+			default:
+			}
+		}
+	}
+}
+
+// doneEvents describes what processing order did to book: one TRADE event per
+// resting order it matched and an ADD event if it came to rest
+func doneEvents(book string, order *core.Order, done *core.Done, now time.Time) []*proto.OrderBookEvent {
+	side := convertCoreSideToProto(order.Side())
+	timestamp := timestamppb.New(now)
+
+	var events []*proto.OrderBookEvent
+	for _, trade := range done.Trades {
+		if trade.Role != core.MAKER {
+			continue
+		}
+		events = append(events, &proto.OrderBookEvent{
+			Type:          proto.OrderBookEventType_TRADE,
+			OrderBookName: book,
+			OrderId:       order.ID(),
+			Side:          side,
+			Price:         trade.Price.String(),
+			Quantity:      trade.Quantity.String(),
+			MakerOrderId:  trade.OrderID,
+			Timestamp:     timestamp,
+		})
+	}
+	if done.Stored && order.IsLimitOrder() {
+		events = append(events, &proto.OrderBookEvent{
+			Type:          proto.OrderBookEventType_ADD,
+			OrderBookName: book,
+			OrderId:       order.ID(),
+			Side:          side,
+			Price:         order.Price().String(),
+			Quantity:      done.Left.String(),
+			Timestamp:     timestamp,
+		})
+	}
+	return events
+}
+
+// cancelEvent describes the cancellation of a resting order
+func cancelEvent(book string, order *core.Order, now time.Time) *proto.OrderBookEvent {
+	return &proto.OrderBookEvent{
+		Type:          proto.OrderBookEventType_CANCEL,
+		OrderBookName: book,
+		OrderId:       order.ID(),
+		Side:          convertCoreSideToProto(order.Side()),
+		Price:         order.Price().String(),
+		Quantity:      order.Quantity().String(),
+		Timestamp:     timestamppb.New(now),
+	}
+}
+
+// amendEvent describes a resting order after its price or quantity changed
+func amendEvent(book string, order *core.Order, now time.Time) *proto.OrderBookEvent {
+	return &proto.OrderBookEvent{
+		Type:          proto.OrderBookEventType_AMEND,
+		OrderBookName: book,
+		OrderId:       order.ID(),
+		Side:          convertCoreSideToProto(order.Side()),
+		Price:         order.Price().String(),
+		Quantity:      order.Quantity().String(),
+		Timestamp:     timestamppb.New(now),
+	}
+}
+
+// tradeEvent describes a TRADE event the way SubscribeTrades sends it
+func tradeEvent(event *proto.OrderBookEvent) *proto.TradeEvent {
+	return &proto.TradeEvent{
+		OrderBookName: event.OrderBookName,
+		MakerOrderId:  event.MakerOrderId,
+		TakerOrderId:  event.OrderId,
+		Price:         event.Price,
+		Quantity:      event.Quantity,
+		Side:          event.Side,
+		Timestamp:     event.Timestamp,
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/erain9/matchingo/api/openapi"
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// GatewayPath is the path prefix of the REST routes NewGateway serves, as
+// declared by the google.api.http options in orderbook.proto
+const GatewayPath = "/v1/"
+
+// OpenAPIPath is the path NewOpenAPIHandler serves the gateway's OpenAPI
+// description at
+const OpenAPIPath = "/openapi.json"
+
+// NewGateway returns a handler translating REST/JSON requests into calls to
+// the OrderBookService listening on grpcAddr, so they pass through the same
+// interceptors as gRPC clients. JSON fields use the proto field names.
+// Failed calls get the HTTP status runtime.HTTPStatusFromCode maps their
+// gRPC code to, with the status as the JSON body. The connection to
+// grpcAddr is insecure unless opts set other credentials.
+func NewGateway(ctx context.Context, grpcAddr string, opts ...grpc.DialOption) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+	)
+
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	if err := proto.RegisterOrderBookServiceHandlerFromEndpoint(ctx, mux, grpcAddr, opts); err != nil {
+		return nil, fmt.Errorf("register gateway: %w", err)
+	}
+	return mux, nil
+}
+
+// gatewayHeaderMatcher forwards the request ID header as gRPC metadata on
+// top of the headers runtime.DefaultHeaderMatcher forwards
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, logging.RequestIDMetadataKey) {
+		return logging.RequestIDMetadataKey, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// NewOpenAPIHandler returns a handler serving the OpenAPI description of the
+// routes NewGateway serves. Any origin may fetch it, so Swagger UI and other
+// tools hosted elsewhere can load it from a running server.
+func NewOpenAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write(openapi.Spec)
+	})
+}
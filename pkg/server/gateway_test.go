@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGateway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	proto.RegisterOrderBookServiceServer(grpcServer, NewGRPCOrderBookService(manager))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	gateway, err := NewGateway(ctx, "passthrough:///bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	require.NoError(t, err)
+
+	call := func(method, target, body string) (int, map[string]interface{}) {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		gateway.ServeHTTP(rec, req)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), rec.Body.String())
+		return rec.Code, resp
+	}
+
+	code, resp := call(http.MethodPost, "/v1/books", `{"name": "rest-book"}`)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "rest-book", resp["name"])
+	code, _ = call(http.MethodPost, "/v1/books", `{"name": "rest-book-2"}`)
+	require.Equal(t, http.StatusOK, code)
+
+	// Pagination is read from the query string
+	code, resp = call(http.MethodGet, "/v1/books?limit=1&offset=1", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.EqualValues(t, 2, resp["total"])
+	assert.Len(t, resp["order_books"], 1)
+
+	// The book name comes from the path
+	code, resp = call(http.MethodPost, "/v1/books/rest-book/orders",
+		`{"order_id": "ask-1", "side": "SELL", "quantity": "2", "price": "100", "order_type": "LIMIT"}`)
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "rest-book", resp["order_book_name"])
+
+	code, resp = call(http.MethodGet, "/v1/books/rest-book/orders/ask-1", "")
+	require.Equal(t, http.StatusOK, code, resp)
+	assert.Equal(t, "ask-1", resp["order_id"])
+	assert.Equal(t, "2.000", resp["remaining_quantity"])
+
+	code, resp = call(http.MethodGet, "/v1/books/rest-book/bbo", "")
+	require.Equal(t, http.StatusOK, code, resp)
+
+	code, _ = call(http.MethodDelete, "/v1/books/rest-book-2", "")
+	require.Equal(t, http.StatusOK, code)
+	code, resp = call(http.MethodPost, "/v1/books/rest-book-2:undelete", "{}")
+	require.Equal(t, http.StatusOK, code, resp)
+
+	// gRPC codes become HTTP statuses
+	code, resp = call(http.MethodGet, "/v1/books/missing", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.EqualValues(t, 5, resp["code"])
+
+	code, _ = call(http.MethodPost, "/v1/books/rest-book/orders",
+		`{"order_id": "bad", "side": "BUY", "quantity": "-1", "price": "100", "order_type": "LIMIT"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = call(http.MethodPost, "/v1/books", `{"name": "rest-book"}`)
+	assert.Equal(t, http.StatusConflict, code)
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	handler := NewOpenAPIHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, OpenAPIPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+
+	var spec struct {
+		Swagger     string                            `json:"swagger"`
+		Paths       map[string]map[string]interface{} `json:"paths"`
+		Definitions map[string]map[string]interface{} `json:"definitions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+	assert.Equal(t, "2.0", spec.Swagger)
+	assert.Contains(t, spec.Paths["/v1/books/{order_book_name}/orders"], "post")
+	assert.Contains(t, spec.Definitions["apiCreateOrderRequest"], "example")
+	for _, enum := range []string{"apiOrderType", "apiTimeInForce", "apiOrderSide", "apiBackendType"} {
+		assert.NotEmpty(t, spec.Definitions[enum]["description"], enum)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, OpenAPIPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -5,18 +5,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/erain9/matchingo/pkg/api/proto"
 	"github.com/erain9/matchingo/pkg/backend/memory"
+	"github.com/erain9/matchingo/pkg/backend/redis"
 	"github.com/erain9/matchingo/pkg/core"
 	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/erain9/matchingo/pkg/messaging"
 	"github.com/erain9/matchingo/pkg/otel"
+	"github.com/erain9/matchingo/pkg/router"
+	"github.com/google/uuid"
 	"github.com/nikolaydubina/fpdecimal"
 	"go.opentelemetry.io/otel/attribute"
 	otelcodes "go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -24,16 +36,69 @@ import (
 // GRPCOrderBookService implements the OrderBookService gRPC interface
 type GRPCOrderBookService struct {
 	proto.UnimplementedOrderBookServiceServer
-	manager *OrderBookManager
+	manager   *OrderBookManager
+	events    *eventBroker
+	validator *RequestValidator
+	// matchingTimeout bounds the matching of each order in CreateOrder; zero disables it
+	matchingTimeout time.Duration
+	// idempotency replays CreateOrder responses for repeated client order IDs
+	idempotency *IdempotencyCache
+	// matchSLO warns when matching gets slow; nil disables it
+	matchSLO *matchLatencySLO
+	// rateLimiter limits how fast each user address may create orders; nil
+	// disables it. The admin service may replace it while serving.
+	rateLimiter atomic.Pointer[OrderRateLimiter]
+	// ordersProcessed counts the orders CreateOrder has matched
+	ordersProcessed atomic.Int64
+	// bookLocker serializes order book changes across server instances; nil disables it
+	bookLocker BookLocker
+}
+
+// BookLocker locks an order book for the server instances sharing it
+type BookLocker interface {
+	// Acquire locks the named order book, waiting until ctx ends if another
+	// instance holds it, and returns the function that releases it
+	Acquire(ctx context.Context, bookName string) (unlock func(), err error)
 }
 
 // NewGRPCOrderBookService creates a new GRPCOrderBookService
 func NewGRPCOrderBookService(manager *OrderBookManager) *GRPCOrderBookService {
 	return &GRPCOrderBookService{
-		manager: manager,
+		manager:     manager,
+		events:      newEventBroker(),
+		validator:   NewRequestValidator(),
+		idempotency: NewIdempotencyCache(DefaultIdempotencyCacheSize, DefaultIdempotencyTTL),
 	}
 }
 
+// SetRequestValidator replaces the validator that checks the identifiers,
+// addresses and decimal strings of incoming requests. Call it before the
+// service starts serving.
+func (s *GRPCOrderBookService) SetRequestValidator(validator *RequestValidator) {
+	s.validator = validator
+}
+
+// SetMatchingTimeout sets how long CreateOrder may spend matching an order.
+// An order still unfilled when it expires keeps its fills and has the rest
+// canceled. Zero disables the limit. Call it before the service starts serving.
+func (s *GRPCOrderBookService) SetMatchingTimeout(timeout time.Duration) {
+	s.matchingTimeout = timeout
+}
+
+// SetMatchLatencySLO sets the latency objective for matching: the 99th
+// percentile of recent match durations is checked against target, and a
+// warning is logged while it is not met. Zero disables the check. Call it
+// before the service starts serving.
+func (s *GRPCOrderBookService) SetMatchLatencySLO(target time.Duration) {
+	s.matchSLO = newMatchLatencySLO(target)
+}
+
+// SetIdempotencyCache replaces the cache CreateOrder replays responses from
+// for requests with a client order ID. Call it before the service starts serving.
+func (s *GRPCOrderBookService) SetIdempotencyCache(cache *IdempotencyCache) {
+	s.idempotency = cache
+}
+
 // Helper function to convert proto TIF enum to core TIF string
 func convertProtoTIFToCore(tif proto.TimeInForce) core.TIF {
 	switch tif {
@@ -41,6 +106,8 @@ func convertProtoTIFToCore(tif proto.TimeInForce) core.TIF {
 		return core.IOC
 	case proto.TimeInForce_FOK:
 		return core.FOK
+	case proto.TimeInForce_GTD:
+		return core.GTD
 	case proto.TimeInForce_GTC:
 		fallthrough // Default to GTC
 	default:
@@ -48,37 +115,184 @@ func convertProtoTIFToCore(tif proto.TimeInForce) core.TIF {
 	}
 }
 
+// Helper function to convert a core side to the proto side enum
+func convertCoreSideToProto(side core.Side) proto.OrderSide {
+	if side == core.Sell {
+		return proto.OrderSide_SELL
+	}
+	return proto.OrderSide_BUY
+}
+
+// Helper function to convert a core order state to the proto status enum
+func convertCoreStateToProto(state core.OrderState) proto.OrderStatus {
+	switch state {
+	case core.StateOpen:
+		return proto.OrderStatus_OPEN
+	case core.StatePartiallyFilled:
+		return proto.OrderStatus_PARTIALLY_FILLED
+	case core.StateFilled:
+		return proto.OrderStatus_FILLED
+	case core.StateCanceled:
+		return proto.OrderStatus_CANCELED
+	default:
+		return proto.OrderStatus_PENDING
+	}
+}
+
+// Helper function to convert the proto STP mode enum to a core STP mode. It
+// reports false for values the enum does not define.
+func convertProtoSTPModeToCore(mode proto.STPMode) (core.STPMode, bool) {
+	switch mode {
+	case proto.STPMode_STP_NONE:
+		return core.STPNone, true
+	case proto.STPMode_STP_CANCEL_MAKER:
+		return core.STPCancelMaker, true
+	case proto.STPMode_STP_CANCEL_TAKER:
+		return core.STPCancelTaker, true
+	case proto.STPMode_STP_CANCEL_BOTH:
+		return core.STPCancelBoth, true
+	default:
+		return core.STPNone, false
+	}
+}
+
+// Helper function to convert a core STP mode to the proto STP mode enum
+func convertCoreSTPModeToProto(mode core.STPMode) proto.STPMode {
+	switch mode {
+	case core.STPCancelMaker:
+		return proto.STPMode_STP_CANCEL_MAKER
+	case core.STPCancelTaker:
+		return proto.STPMode_STP_CANCEL_TAKER
+	case core.STPCancelBoth:
+		return proto.STPMode_STP_CANCEL_BOTH
+	default:
+		return proto.STPMode_STP_NONE
+	}
+}
+
 // CreateOrderBook implements the CreateOrderBook RPC method
 func (s *GRPCOrderBookService) CreateOrderBook(ctx context.Context, req *proto.CreateOrderBookRequest) (*proto.OrderBookResponse, error) {
 	logger := logging.FromContext(ctx).With().Str("method", "CreateOrderBook").Logger()
 	logger.Debug().Str("name", req.Name).Str("backend", req.BackendType.String()).Msg("Request received")
 
+	violations := s.validator.ValidateCreateOrderBook(req)
+	if req.BackendType != proto.BackendType_MEMORY && req.BackendType != proto.BackendType_REDIS {
+		violations = append(violations, Violation{Field: "backend_type", Description: fmt.Sprintf("unsupported backend type %v", req.BackendType)})
+	}
+	opts := orderBookOptions(req, &violations)
+	if len(violations) > 0 {
+		return nil, validationError(violations...)
+	}
+
 	var info *OrderBookInfo
 	var err error
 
 	switch req.BackendType {
 	case proto.BackendType_MEMORY:
-		info, err = s.manager.CreateMemoryOrderBook(ctx, req.Name)
+		// Snapshot paths are server configuration, never taken from clients
+		info, err = s.manager.CreateMemoryOrderBook(ctx, req.Name, req.StrategyName, opts...)
 	case proto.BackendType_REDIS:
-		info, err = s.manager.CreateRedisOrderBook(ctx, req.Name, req.Options)
-	default:
-		return nil, status.Errorf(codes.InvalidArgument, "unsupported backend type: %v", req.BackendType)
+		info, err = s.manager.CreateRedisOrderBook(ctx, req.Name, req.StrategyName, req.Options, opts...)
 	}
 
 	if err != nil {
 		if err == ErrOrderBookExists {
 			return nil, status.Errorf(codes.AlreadyExists, "order book %s already exists", req.Name)
 		}
+		if errors.Is(err, ErrUnknownStrategy) {
+			return nil, validationError(Violation{Field: "strategy_name", Description: fmt.Sprintf("unknown strategy %q", req.StrategyName)})
+		}
+		if errors.Is(err, redis.ErrInvalidPrefix) {
+			// The prefix defaults to the book name
+			field := "name"
+			if req.Options["prefix"] != "" {
+				field = "options.prefix"
+			}
+			return nil, validationError(Violation{Field: field, Description: "must be 1 to 64 letters, digits, underscores or hyphens for the Redis backend"})
+		}
+		if errors.Is(err, redis.ErrPrefixConflict) {
+			return nil, status.Errorf(codes.AlreadyExists, "%v", err)
+		}
 		logger.Error().Err(err).Msg("Failed to create order book")
 		return nil, status.Errorf(codes.Internal, "failed to create order book: %v", err)
 	}
 
-	return &proto.OrderBookResponse{
-		Name:        info.Name,
-		BackendType: req.BackendType,
-		CreatedAt:   timestamppb.New(info.CreatedAt),
-		OrderCount:  uint64(info.OrderCount),
-	}, nil
+	return orderBookInfoToProto(info), nil
+}
+
+// MaxPrecision is the most fraction digits a book's prices or quantities
+// may be shown with
+const MaxPrecision = 18
+
+// MaxFeeBps is the highest maker or taker fee a book may charge, the whole
+// notional of a fill
+const MaxFeeBps = 10000
+
+// orderBookOptions converts the instrument, policy, tick size, lot size, STP
+// mode, circuit breaker and fees of a create request to order book options,
+// appending a violation for each invalid field
+func orderBookOptions(req *proto.CreateOrderBookRequest, violations *[]Violation) []core.OrderBookOption {
+	var opts []core.OrderBookOption
+	if instrument := req.GetInstrument(); instrument != nil {
+		if instrument.MaxPriceDeviationPct < 0 {
+			*violations = append(*violations, Violation{Field: "instrument.max_price_deviation_pct", Description: "must not be negative"})
+		}
+		if instrument.PricePrecision < 0 || instrument.PricePrecision > MaxPrecision {
+			*violations = append(*violations, Violation{Field: "instrument.price_precision", Description: fmt.Sprintf("must be between 0 and %d", MaxPrecision)})
+		}
+		if instrument.QtyPrecision < 0 || instrument.QtyPrecision > MaxPrecision {
+			*violations = append(*violations, Violation{Field: "instrument.qty_precision", Description: fmt.Sprintf("must be between 0 and %d", MaxPrecision)})
+		}
+		opts = append(opts, core.WithInstrumentConfig(core.InstrumentConfig{
+			MaxPriceDeviationPct: instrument.MaxPriceDeviationPct,
+			PricePrecision:       int(instrument.PricePrecision),
+			QtyPrecision:         int(instrument.QtyPrecision),
+		}))
+	}
+	if maxAge := req.GetPolicy().GetMaxOrderAge(); maxAge != nil {
+		if err := maxAge.CheckValid(); err != nil || maxAge.AsDuration() < 0 {
+			*violations = append(*violations, Violation{Field: "policy.max_order_age", Description: "must be a valid, non-negative duration"})
+		}
+		opts = append(opts, core.WithMaxOrderAge(maxAge.AsDuration()))
+	}
+	if req.TickSize != "" {
+		opts = append(opts, core.WithTickSize(parsePositiveDecimal("tick_size", req.TickSize, violations)))
+	}
+	if req.LotSize != "" {
+		opts = append(opts, core.WithLotSize(parsePositiveDecimal("lot_size", req.LotSize, violations)))
+	}
+	if req.StpMode != proto.STPMode_STP_NONE {
+		mode, ok := convertProtoSTPModeToCore(req.StpMode)
+		if !ok {
+			*violations = append(*violations, Violation{Field: "stp_mode", Description: fmt.Sprintf("unknown mode %v", req.StpMode)})
+		}
+		opts = append(opts, core.WithSTPMode(mode))
+	}
+	if breaker := req.GetCircuitBreaker(); breaker != nil {
+		if breaker.MaxPriceMovePct < 0 {
+			*violations = append(*violations, Violation{Field: "circuit_breaker.max_price_move_pct", Description: "must not be negative"})
+		}
+		if breaker.CooldownSeconds < 0 {
+			*violations = append(*violations, Violation{Field: "circuit_breaker.cooldown_seconds", Description: "must not be negative"})
+		}
+		opts = append(opts, core.WithCircuitBreaker(core.CircuitBreakerConfig{
+			MaxPriceMovePct: breaker.MaxPriceMovePct,
+			CooldownSeconds: int(breaker.CooldownSeconds),
+		}))
+	}
+	if req.MakerFeeBps != 0 || req.TakerFeeBps != 0 {
+		if req.MakerFeeBps < 0 || req.MakerFeeBps > MaxFeeBps {
+			*violations = append(*violations, Violation{Field: "maker_fee_bps", Description: fmt.Sprintf("must be between 0 and %d", MaxFeeBps)})
+		}
+		if req.TakerFeeBps < 0 || req.TakerFeeBps > MaxFeeBps {
+			*violations = append(*violations, Violation{Field: "taker_fee_bps", Description: fmt.Sprintf("must be between 0 and %d", MaxFeeBps)})
+		}
+		opts = append(opts, core.WithFeeSchedule(core.FeeSchedule{
+			MakerFeeBps: int(req.MakerFeeBps),
+			TakerFeeBps: int(req.TakerFeeBps),
+		}))
+	}
+	return opts
 }
 
 // GetOrderBook retrieves information about an order book
@@ -86,27 +300,20 @@ func (s *GRPCOrderBookService) GetOrderBook(ctx context.Context, req *proto.GetO
 	logger := logging.FromContext(ctx).With().Str("method", "GetOrderBook").Logger()
 	logger.Debug().Str("name", req.Name).Msg("Request received")
 
-	_, info, err := s.manager.GetOrderBook(ctx, req.Name)
+	orderBook, info, err := s.manager.GetOrderBook(ctx, req.Name)
 	if err != nil {
 		if err == ErrOrderBookNotFound {
 			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.Name)
 		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.Name)
+		}
 		logger.Error().Err(err).Msg("Failed to get order book")
 		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
 	}
 
-	// Determine backend type
-	backendType := proto.BackendType_MEMORY
-	if info.Backend == "redis" {
-		backendType = proto.BackendType_REDIS
-	}
-
-	return &proto.OrderBookResponse{
-		Name:        info.Name,
-		BackendType: backendType,
-		CreatedAt:   timestamppb.New(info.CreatedAt),
-		OrderCount:  uint64(info.OrderCount),
-	}, nil
+	info.refreshOrderCount(orderBook)
+	return orderBookInfoToProto(info), nil
 }
 
 // ListOrderBooks lists all available order books
@@ -115,7 +322,7 @@ func (s *GRPCOrderBookService) ListOrderBooks(ctx context.Context, req *proto.Li
 	logger.Debug().Int32("limit", req.Limit).Int32("offset", req.Offset).Msg("Request received")
 
 	// Get all order books
-	infoList := s.manager.ListOrderBooks(ctx)
+	infoList := s.manager.ListOrderBooks(ctx, req.IncludeDeleted)
 
 	// Apply pagination
 	offset := int(req.Offset)
@@ -148,20 +355,7 @@ func (s *GRPCOrderBookService) ListOrderBooks(ctx context.Context, req *proto.Li
 
 	// Add paginated items
 	for i := offset; i < end; i++ {
-		info := infoList[i]
-
-		// Determine backend type
-		backendType := proto.BackendType_MEMORY
-		if info.Backend == "redis" {
-			backendType = proto.BackendType_REDIS
-		}
-
-		responseItems = append(responseItems, &proto.OrderBookResponse{
-			Name:        info.Name,
-			BackendType: backendType,
-			CreatedAt:   timestamppb.New(info.CreatedAt),
-			OrderCount:  uint64(info.OrderCount),
-		})
+		responseItems = append(responseItems, orderBookInfoToProto(infoList[i]))
 	}
 
 	return &proto.ListOrderBooksResponse{
@@ -180,6 +374,9 @@ func (s *GRPCOrderBookService) DeleteOrderBook(ctx context.Context, req *proto.D
 		if err == ErrOrderBookNotFound {
 			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.Name)
 		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.Name)
+		}
 		logger.Error().Err(err).Msg("Failed to delete order book")
 		return nil, status.Errorf(codes.Internal, "failed to delete order book: %v", err)
 	}
@@ -187,8 +384,178 @@ func (s *GRPCOrderBookService) DeleteOrderBook(ctx context.Context, req *proto.D
 	return &emptypb.Empty{}, nil
 }
 
+// UndeleteOrderBook restores a soft-deleted order book
+func (s *GRPCOrderBookService) UndeleteOrderBook(ctx context.Context, req *proto.UndeleteRequest) (*proto.UndeleteResponse, error) {
+	logger := logging.FromContext(ctx).With().Str("method", "UndeleteOrderBook").Logger()
+	logger.Debug().Str("name", req.Name).Msg("Request received")
+
+	info, err := s.manager.UndeleteOrderBook(ctx, req.Name)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.Name)
+		}
+		if err == ErrOrderBookNotDeleted {
+			return nil, status.Errorf(codes.FailedPrecondition, "order book %s is not deleted", req.Name)
+		}
+		logger.Error().Err(err).Msg("Failed to undelete order book")
+		return nil, status.Errorf(codes.Internal, "failed to undelete order book: %v", err)
+	}
+
+	return &proto.UndeleteResponse{
+		OrderBook: orderBookInfoToProto(info),
+	}, nil
+}
+
+// ResetOrderBook removes all bids, asks and stop orders from an order book
+func (s *GRPCOrderBookService) ResetOrderBook(ctx context.Context, req *proto.ResetOrderBookRequest) (*proto.ResetOrderBookResponse, error) {
+	logger := logging.FromContext(ctx).With().Str("method", "ResetOrderBook").Logger()
+	logger.Debug().Str("name", req.Name).Msg("Request received")
+
+	info, err := s.manager.ResetOrderBook(ctx, req.Name)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.Name)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.Name)
+		}
+		logger.Error().Err(err).Msg("Failed to reset order book")
+		return nil, status.Errorf(codes.Internal, "failed to reset order book: %v", err)
+	}
+
+	return &proto.ResetOrderBookResponse{
+		OrderBook: orderBookInfoToProto(info),
+	}, nil
+}
+
+// orderBookInfoToProto converts order book metadata to its proto representation
+func orderBookInfoToProto(info *OrderBookInfo) *proto.OrderBookResponse {
+	backendType := proto.BackendType_MEMORY
+	if info.Backend == "redis" {
+		backendType = proto.BackendType_REDIS
+	}
+
+	resp := &proto.OrderBookResponse{
+		Name:        info.Name,
+		BackendType: backendType,
+		CreatedAt:   timestamppb.New(info.CreatedAt),
+		OrderCount:  uint64(info.OrderCount()),
+		IsDeleted:   info.IsDeleted(),
+		TickSize:    info.TickSize.String(),
+		LotSize:     info.LotSize.String(),
+		StpMode:     convertCoreSTPModeToProto(info.STPMode),
+		MakerFeeBps: int32(info.FeeSchedule.MakerFeeBps),
+		TakerFeeBps: int32(info.FeeSchedule.TakerFeeBps),
+	}
+	if breaker := info.CircuitBreaker(); breaker.MaxPriceMovePct > 0 {
+		resp.CircuitBreaker = &proto.CreateOrderBookRequest_CircuitBreaker{
+			MaxPriceMovePct: breaker.MaxPriceMovePct,
+			CooldownSeconds: int32(breaker.CooldownSeconds),
+		}
+	}
+	if deletedAt := info.DeletedAt(); !deletedAt.IsZero() {
+		resp.DeletedAt = timestamppb.New(deletedAt)
+	}
+	return resp
+}
+
+// SetOrderRateLimiter makes CreateOrder reject orders of user addresses that
+// exceed limiter's rate. Nil disables the limit. It may be called while the
+// service is serving.
+func (s *GRPCOrderBookService) SetOrderRateLimiter(limiter *OrderRateLimiter) {
+	s.rateLimiter.Store(limiter)
+}
+
+// OrderRateLimiter returns the limiter CreateOrder checks orders against, nil
+// if there is none
+func (s *GRPCOrderBookService) OrderRateLimiter() *OrderRateLimiter {
+	return s.rateLimiter.Load()
+}
+
+// OrdersProcessed returns how many orders CreateOrder has matched since the
+// service was created
+func (s *GRPCOrderBookService) OrdersProcessed() int64 {
+	return s.ordersProcessed.Load()
+}
+
+// SetBookLocker makes CreateOrder, CancelOrder and AmendOrder hold locker's
+// lock on the order book while they change it, for order books whose backend
+// other server instances share. Nil disables locking. Call it before the service starts
+// serving.
+func (s *GRPCOrderBookService) SetBookLocker(locker BookLocker) {
+	s.bookLocker = locker
+}
+
+// lockBook acquires the lock on the named order book, returning an
+// Unavailable status if it cannot. Without a BookLocker it does nothing.
+func (s *GRPCOrderBookService) lockBook(ctx context.Context, name string) (func(), error) {
+	if s.bookLocker == nil {
+		return func() {}, nil
+	}
+	unlock, err := s.bookLocker.Acquire(ctx, name)
+	if err != nil {
+		logger := logging.FromContext(ctx)
+		logger.Warn().Err(err).Str("order_book", name).Msg("Failed to lock order book")
+		return nil, status.Errorf(codes.Unavailable, "failed to lock order book %s: %v", name, err)
+	}
+	return unlock, nil
+}
+
 // CreateOrder submits a new order to the specified order book
 func (s *GRPCOrderBookService) CreateOrder(ctx context.Context, req *proto.CreateOrderRequest) (*proto.OrderResponse, error) {
+	if err := s.checkRateLimit(ctx, req.UserAddress); err != nil {
+		return nil, err
+	}
+	if req.ClientOrderId == "" {
+		return s.createOrder(ctx, req)
+	}
+	// A retry of an accepted request, or of one still being processed, gets
+	// the first response instead of submitting the order again
+	return s.idempotency.Do(ctx, req.OrderBookName, req.ClientOrderId, func() (*proto.OrderResponse, error) {
+		return s.createOrder(ctx, req)
+	})
+}
+
+// checkRateLimit returns ResourceExhausted if userAddress has no order left
+// under the rate limit, telling the client in a retry-after trailer how many
+// seconds to wait
+func (s *GRPCOrderBookService) checkRateLimit(ctx context.Context, userAddress string) error {
+	limiter := s.rateLimiter.Load()
+	if limiter == nil {
+		return nil
+	}
+	allowed, wait := limiter.Allow(userAddress)
+	if allowed {
+		return nil
+	}
+
+	retryAfter := int64(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	if err := grpc.SetTrailer(ctx, metadata.Pairs("retry-after", strconv.FormatInt(retryAfter, 10))); err != nil {
+		logger := logging.FromContext(ctx)
+		logger.Debug().Err(err).Msg("Failed to set retry-after trailer")
+	}
+	otel.GetOrderBookMetrics().RecordRateLimitedOrder(ctx, userAddress)
+	return status.Errorf(codes.ResourceExhausted, "order rate limit exceeded for user address %q, retry after %ds", userAddress, retryAfter)
+}
+
+// createOrder submits an order without checking its client order ID
+func (s *GRPCOrderBookService) createOrder(ctx context.Context, req *proto.CreateOrderRequest) (*proto.OrderResponse, error) {
+	// The request's own ID wins over the x-request-id the logging
+	// interceptor put on ctx; an ID is generated only if neither is set
+	requestID := req.RequestId
+	if requestID == "" {
+		requestID = logging.RequestIDFromContext(ctx)
+	}
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	ctx = logging.WithRequestID(ctx, requestID)
+	// The user address travels as baggage to every span and message below
+	ctx = otel.UserAddressBaggagePropagator{}.ExtractRequest(ctx, req)
+
 	// Start a new span for the gRPC request
 	ctx, span := otel.StartOrderSpan(ctx, otel.SpanCreateOrder,
 		attribute.String(otel.AttributeOrderID, req.OrderId),
@@ -214,91 +581,88 @@ func (s *GRPCOrderBookService) CreateOrder(ctx context.Context, req *proto.Creat
 		Str("user_address", req.UserAddress).
 		Msg("Request received")
 
+	// Build the core order, rejecting invalid fields
+	order, err := newCoreOrder(req, s.validator.ValidateCreateOrder(req)...)
+	if err != nil {
+		if status.Code(err) == codes.Internal {
+			logger.Error().Err(err).Msg("Internal error creating core order")
+		}
+		span.SetStatus(otelcodes.Error, "invalid request")
+		return nil, err
+	}
+	quantity := order.Quantity()
+
 	// Get the order book
-	orderBook, info, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
 	if err != nil {
 		if err == ErrOrderBookNotFound {
 			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
 		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
 		logger.Error().Err(err).Msg("Failed to get order book")
 		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
 	}
 
-	// Parse decimal values
-	quantity, err := fpdecimal.FromString(req.Quantity)
+	unlock, err := s.lockBook(ctx, req.OrderBookName)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid quantity: %v", err)
-	}
-
-	// Convert side to core.Side
-	side := core.Buy
-	if req.Side == proto.OrderSide_SELL {
-		side = core.Sell
+		span.SetStatus(otelcodes.Error, "order book locked")
+		return nil, err
 	}
+	defer unlock()
 
-	// Create core order
-	var order *core.Order
 	var done *core.Done
 	now := time.Now()
 
-	switch req.OrderType {
-	case proto.OrderType_MARKET:
-		order, err = core.NewMarketOrder(req.OrderId, side, quantity, req.UserAddress)
-	case proto.OrderType_LIMIT:
-		price, err := fpdecimal.FromString(req.Price)
-		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid price format: %v", err)
-		}
-		tif := convertProtoTIFToCore(req.TimeInForce)
-		order, err = core.NewLimitOrder(req.OrderId, side, quantity, price, tif, req.OcoId, req.UserAddress)
-	case proto.OrderType_STOP:
-		// Parse stop price
-		stopPrice, err := fpdecimal.FromString(req.StopPrice)
-		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid stop price: %v", err)
-		}
-
-		// Create a limit order with the stop price
-		order, err = core.NewLimitOrder(req.OrderId, side, quantity, stopPrice, core.GTC, req.OcoId, req.UserAddress)
-	case proto.OrderType_STOP_LIMIT:
-		price, err := fpdecimal.FromString(req.Price)
-		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid price: %v", err)
-		}
-		stopPrice, err := fpdecimal.FromString(req.StopPrice)
-		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid stop price: %v", err)
-		}
-
-		// Create a stop limit order
-		order, err = core.NewStopLimitOrder(req.OrderId, side, quantity, price, stopPrice, req.OcoId, req.UserAddress)
-	default:
-		return nil, status.Errorf(codes.InvalidArgument, "unsupported order type: %v", req.OrderType)
-	}
-
-	// Check for order creation errors (e.g., invalid quantity/price from core)
-	if err != nil {
-		if errors.Is(err, core.ErrInvalidQuantity) || errors.Is(err, core.ErrInvalidPrice) || errors.Is(err, core.ErrInvalidTif) {
-			return nil, status.Errorf(codes.InvalidArgument, "order creation failed: %v", err)
-		}
-		// Handle other potential core errors as Internal
-		logger.Error().Err(err).Msg("Internal error creating core order")
-		return nil, status.Errorf(codes.Internal, "failed to create order: %v", err)
+	processCtx := ctx
+	if s.matchingTimeout > 0 {
+		var cancel context.CancelFunc
+		processCtx, cancel = context.WithTimeout(ctx, s.matchingTimeout)
+		defer cancel()
 	}
 
-	// Process the order
-	if order == nil {
-		logger.Error().Msg("Order creation returned nil without error")
-		return nil, status.Error(codes.Internal, "order creation failed: nil order")
+	done, err = orderBook.Process(processCtx, order)
+	if errors.Is(err, core.ErrMatchingTimeout) && done != nil {
+		// The fills made in time stand and the rest was canceled, so this
+		// is answered like an IOC order rather than as a failure
+		logger.Warn().
+			Dur("timeout", s.matchingTimeout).
+			Str("filled", done.Processed.String()).
+			Str("canceled", done.Left.String()).
+			Msg("Matching timed out")
+		err = nil
 	}
-
-	done, err = orderBook.Process(ctx, order)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to process order")
 		if errors.Is(err, core.ErrOrderExists) {
 			span.SetStatus(otelcodes.Error, "order already exists")
 			return nil, status.Errorf(codes.AlreadyExists, "order with ID %s already exists", req.OrderId)
 		}
+		if errors.Is(err, core.ErrOrderBookClosed) {
+			span.SetStatus(otelcodes.Error, "order book closed")
+			return nil, status.Errorf(codes.Unavailable, "order book %s is shutting down", req.OrderBookName)
+		}
+		if errors.Is(err, core.ErrMarketHalted) {
+			span.SetStatus(otelcodes.Error, "market halted")
+			return nil, status.Errorf(codes.Unavailable, "order book %s: %v", req.OrderBookName, err)
+		}
+		if errors.Is(err, core.ErrPriceDeviationExceeded) {
+			span.SetStatus(otelcodes.Error, "price deviation exceeded")
+			return nil, status.Errorf(codes.FailedPrecondition, "order %s would move the price too far from the last trade", req.OrderId)
+		}
+		if errors.Is(err, core.ErrPostOnlyWouldTake) {
+			span.SetStatus(otelcodes.Error, "post-only order would take")
+			return nil, status.Errorf(codes.FailedPrecondition, "post-only order %s would match a resting order", req.OrderId)
+		}
+		if errors.Is(err, core.ErrInvalidTickSize) || errors.Is(err, core.ErrInvalidLotSize) || errors.Is(err, core.ErrBelowMinQty) {
+			span.SetStatus(otelcodes.Error, "order breaks matching rules")
+			return nil, status.Errorf(codes.InvalidArgument, "order %s rejected by order book %s: %v", req.OrderId, req.OrderBookName, err)
+		}
+		if errors.Is(err, core.ErrRiskCheckFailed) {
+			span.SetStatus(otelcodes.Error, "risk check failed")
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
 		span.SetStatus(otelcodes.Error, fmt.Sprintf("failed to process order: %v", err))
 		return nil, status.Errorf(codes.Internal, "failed to process order: %v", err)
 	}
@@ -307,6 +671,7 @@ func (s *GRPCOrderBookService) CreateOrder(ctx context.Context, req *proto.Creat
 		logger.Error().Msg("Order processing returned nil Done object")
 		return nil, status.Error(codes.Internal, "order processing failed: nil Done object")
 	}
+	s.ordersProcessed.Add(1)
 
 	// Create order response
 	resp := &proto.OrderResponse{
@@ -321,6 +686,10 @@ func (s *GRPCOrderBookService) CreateOrder(ctx context.Context, req *proto.Creat
 		CreatedAt:     timestamppb.New(now),
 		UpdatedAt:     timestamppb.New(now),
 		OcoId:         req.OcoId,
+		OrderState:    convertCoreStateToProto(order.State()),
+		RequestId:     requestID,
+		ClientOrderId: req.ClientOrderId,
+		GtdExpiresAt:  req.GtdExpiresAt,
 	}
 
 	// Get remaining quantity
@@ -334,11 +703,16 @@ func (s *GRPCOrderBookService) CreateOrder(ctx context.Context, req *proto.Creat
 
 		resp.FilledQuantity = filledQty.String()
 		resp.RemainingQuantity = remainingQty.String()
+		resp.ProcessingTimeMs = float64(done.MatchDuration()) / float64(time.Millisecond)
 
 		// Create fill records
 		if len(done.Trades) > 0 {
 			fills := make([]*proto.Fill, 0, len(done.Trades))
 			for _, trade := range done.Trades {
+				// The entry marking a stop order's activation fills nothing
+				if trade.Quantity.Equal(fpdecimal.Zero) {
+					continue
+				}
 				fills = append(fills, &proto.Fill{
 					Price:     trade.Price.String(),
 					Quantity:  trade.Quantity.String(),
@@ -348,8 +722,21 @@ func (s *GRPCOrderBookService) CreateOrder(ctx context.Context, req *proto.Creat
 			resp.Fills = fills
 		}
 
-		// Determine order status
-		if filledQty.Equal(quantity) {
+		// Determine order status. A stop order stored without triggering
+		// waits in the stop book, outside the active book, while one that
+		// triggered on arrival rests in the book as a limit order. Process
+		// decides which under the book's lock, so the Done is read rather
+		// than the book, which may have moved on since.
+		// A quote order's quantity is a budget in the quote currency while
+		// the filled quantity is in the base currency, so whether it filled
+		// is told by the budget it has left.
+		filled := filledQty.Equal(quantity)
+		if order.IsQuote() {
+			filled = remainingQty.Equal(fpdecimal.Zero)
+		}
+		if order.IsStopOrder() && done.Stored && !done.StopActivated {
+			resp.Status = proto.OrderStatus_PENDING
+		} else if filled {
 			resp.Status = proto.OrderStatus_FILLED
 		} else if filledQty.Equal(fpdecimal.Zero) {
 			resp.Status = proto.OrderStatus_OPEN
@@ -362,8 +749,24 @@ func (s *GRPCOrderBookService) CreateOrder(ctx context.Context, req *proto.Creat
 		resp.FilledQuantity = "0"
 	}
 
-	// Increment order count
-	s.manager.UpdateOrderBookInfo(ctx, req.OrderBookName, info.OrderCount+1)
+	recordBestPrices(ctx, req.OrderBookName, orderBook)
+	s.events.publish(ctx, req.OrderBookName, doneEvents(req.OrderBookName, order, done, now)...)
+
+	if matchDuration := done.MatchDuration(); matchDuration > 0 {
+		otel.GetOrderBookMetrics().RecordMatchDuration(ctx, req.OrderBookName, req.OrderType.String(), matchDuration)
+		if p99, violated := s.matchSLO.observe(matchDuration); violated {
+			logger.Warn().
+				Dur("p99", p99).
+				Dur("slo", s.matchSLO.target).
+				Msg("Match latency SLO violated")
+		}
+	}
+
+	logger.Debug().
+		Str("status", resp.Status.String()).
+		Str("filled_quantity", resp.FilledQuantity).
+		Str("remaining_quantity", resp.RemainingQuantity).
+		Msg("Order created")
 
 	// Add response attributes to span
 	otel.AddAttributes(span,
@@ -381,131 +784,971 @@ func (s *GRPCOrderBookService) CreateOrder(ctx context.Context, req *proto.Creat
 	return resp, nil
 }
 
-// GetOrder retrieves information about a specific order
-func (s *GRPCOrderBookService) GetOrder(ctx context.Context, req *proto.GetOrderRequest) (*proto.OrderResponse, error) {
-	logger := logging.FromContext(ctx).With().
-		Str("method", "GetOrder").
-		Str("order_book", req.OrderBookName).
-		Str("order_id", req.OrderId).
-		Logger()
-
-	logger.Debug().Msg("Request received")
-
-	// Get the order book
-	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
-	if err != nil {
-		if err == ErrOrderBookNotFound {
-			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+// newCoreOrder validates req and builds the matching core order. Errors are
+// gRPC status errors; invalid fields are reported as BadRequest violations,
+// together with any the caller already found in req.
+func newCoreOrder(req *proto.CreateOrderRequest, found ...Violation) (*core.Order, error) {
+	// Validate and parse decimal values, collecting every invalid field
+	violations := found
+	quantity := parsePositiveDecimal("quantity", req.Quantity, &violations)
+	var price, stopPrice, visibleQty fpdecimal.Decimal
+	switch req.OrderType {
+	case proto.OrderType_MARKET:
+	case proto.OrderType_LIMIT:
+		price = parsePositiveDecimal("price", req.Price, &violations)
+	case proto.OrderType_ICEBERG:
+		price = parsePositiveDecimal("price", req.Price, &violations)
+		visibleQty = parsePositiveDecimal("visible_quantity", req.VisibleQuantity, &violations)
+		if visibleQty.GreaterThan(quantity) && quantity.GreaterThan(fpdecimal.Zero) {
+			violations = append(violations, Violation{Field: "visible_quantity", Description: "must not exceed quantity"})
 		}
-		logger.Error().Err(err).Msg("Failed to get order book")
-		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	case proto.OrderType_STOP:
+		stopPrice = parsePositiveDecimal("stop_price", req.StopPrice, &violations)
+	case proto.OrderType_STOP_LIMIT:
+		price = parsePositiveDecimal("price", req.Price, &violations)
+		stopPrice = parsePositiveDecimal("stop_price", req.StopPrice, &violations)
+	case proto.OrderType_MIDPOINT:
+	default:
+		violations = append(violations, Violation{Field: "order_type", Description: fmt.Sprintf("unsupported order type %v", req.OrderType)})
 	}
-
-	// Get the order
-	order := orderBook.GetOrder(req.OrderId)
-	if order == nil {
-		return nil, status.Errorf(codes.NotFound, "order %s not found", req.OrderId)
+	if req.PostOnly {
+		if req.OrderType != proto.OrderType_LIMIT {
+			violations = append(violations, Violation{Field: "post_only", Description: "is only supported for LIMIT orders"})
+		} else if req.TimeInForce != proto.TimeInForce_GTC {
+			violations = append(violations, Violation{Field: "post_only", Description: "requires GTC time in force"})
+		}
 	}
-
-	// Convert order side
-	side := proto.OrderSide_BUY
-	if order.Side() == core.Sell {
-		side = proto.OrderSide_SELL
+	if req.IsQuote && req.OrderType != proto.OrderType_MARKET {
+		violations = append(violations, Violation{Field: "is_quote", Description: "is only supported for MARKET orders"})
 	}
-
-	// Convert order type
-	orderType := proto.OrderType_LIMIT
-	if order.IsMarketOrder() {
-		orderType = proto.OrderType_MARKET
-	} else if order.IsStopOrder() {
-		if order.IsLimitOrder() {
-			orderType = proto.OrderType_STOP_LIMIT
-		} else {
-			orderType = proto.OrderType_STOP
+	var expiresAt time.Time
+	switch {
+	case req.TimeInForce != proto.TimeInForce_GTD:
+		if req.GtdExpiresAt != nil {
+			violations = append(violations, Violation{Field: "gtd_expires_at", Description: "is only supported for GTD orders"})
 		}
+	case req.OrderType != proto.OrderType_LIMIT:
+		violations = append(violations, Violation{Field: "time_in_force", Description: "GTD is only supported for LIMIT orders"})
+	case req.GtdExpiresAt == nil:
+		violations = append(violations, Violation{Field: "gtd_expires_at", Description: "is required for GTD orders"})
+	case !req.GtdExpiresAt.IsValid():
+		violations = append(violations, Violation{Field: "gtd_expires_at", Description: "is not a valid timestamp"})
+	default:
+		expiresAt = req.GtdExpiresAt.AsTime()
+	}
+	if len(violations) > 0 {
+		return nil, validationError(violations...)
 	}
 
-	// Convert time in force
-	timeInForce := proto.TimeInForce_GTC
-	if !order.IsMarketOrder() && !order.IsStopOrder() {
-		switch order.TIF() {
-		case core.GTC:
-			timeInForce = proto.TimeInForce_GTC
-		case core.IOC:
-			timeInForce = proto.TimeInForce_IOC
-		case core.FOK:
-			timeInForce = proto.TimeInForce_FOK
-		}
+	// Convert side to core.Side
+	side := core.Buy
+	if req.Side == proto.OrderSide_SELL {
+		side = core.Sell
 	}
 
-	// Create response
-	resp := &proto.OrderResponse{
-		OrderId:           req.OrderId,
-		OrderBookName:     req.OrderBookName,
-		Side:              side,
+	var order *core.Order
+	var err error
+	switch req.OrderType {
+	case proto.OrderType_MARKET:
+		switch {
+		case req.IsQuote && req.TimeInForce == proto.TimeInForce_FOK:
+			order, err = core.NewFOKMarketQuoteOrder(req.OrderId, side, quantity, req.UserAddress)
+		case req.IsQuote:
+			order, err = core.NewMarketQuoteOrder(req.OrderId, side, quantity, req.UserAddress)
+		case req.TimeInForce == proto.TimeInForce_FOK:
+			order, err = core.NewFOKMarketOrder(req.OrderId, side, quantity, req.UserAddress)
+		default:
+			order, err = core.NewMarketOrder(req.OrderId, side, quantity, req.UserAddress)
+		}
+	case proto.OrderType_LIMIT:
+		if req.PostOnly {
+			order, err = core.NewPostOnlyLimitOrder(req.OrderId, side, quantity, price, req.OcoId, req.UserAddress)
+			break
+		}
+		if req.TimeInForce == proto.TimeInForce_GTD {
+			order, err = core.NewGTDLimitOrder(req.OrderId, side, quantity, price, expiresAt, req.OcoId, req.UserAddress)
+			break
+		}
+		tif := convertProtoTIFToCore(req.TimeInForce)
+		order, err = core.NewLimitOrder(req.OrderId, side, quantity, price, tif, req.OcoId, req.UserAddress)
+	case proto.OrderType_STOP:
+		// Create a limit order with the stop price
+		order, err = core.NewLimitOrder(req.OrderId, side, quantity, stopPrice, core.GTC, req.OcoId, req.UserAddress)
+	case proto.OrderType_STOP_LIMIT:
+		// Create a stop limit order
+		order, err = core.NewStopLimitOrder(req.OrderId, side, quantity, price, stopPrice, req.OcoId, req.UserAddress)
+	case proto.OrderType_MIDPOINT:
+		order, err = core.NewMidpointPeggedOrder(req.OrderId, side, quantity, req.OcoId, req.UserAddress)
+	case proto.OrderType_ICEBERG:
+		tif := convertProtoTIFToCore(req.TimeInForce)
+		order, err = core.NewIcebergOrder(req.OrderId, side, quantity, visibleQty, price, tif, req.OcoId, req.UserAddress)
+	}
+
+	// Check for order creation errors (e.g., invalid quantity/price from core)
+	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrInvalidQuantity):
+			return nil, validationError(Violation{Field: "quantity", Description: "must be positive"})
+		case errors.Is(err, core.ErrInvalidPrice):
+			return nil, validationError(Violation{Field: "price", Description: "must be positive"})
+		case errors.Is(err, core.ErrInvalidTif):
+			return nil, validationError(Violation{Field: "time_in_force", Description: "is not supported"})
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create order: %v", err)
+	}
+	if order == nil {
+		return nil, status.Error(codes.Internal, "order creation failed: nil order")
+	}
+	order.SetClientOrderID(req.ClientOrderId)
+
+	return order, nil
+}
+
+// simulatedOrderID is used for simulated orders submitted without an ID
+const simulatedOrderID = "simulated-order"
+
+// SimulateOrder estimates how an order would fill. It is processed against a
+// detached copy of the order book, so the real book is never modified.
+func (s *GRPCOrderBookService) SimulateOrder(ctx context.Context, req *proto.SimulateOrderRequest) (*proto.SimulateOrderResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "SimulateOrder").
+		Str("order_book", req.OrderBookName).
+		Logger()
+
+	logger.Debug().
+		Str("side", req.Side.String()).
+		Str("type", req.OrderType.String()).
+		Str("quantity", req.Quantity).
+		Str("price", req.Price).
+		Msg("Request received")
+
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	orderID := req.OrderId
+	if orderID == "" {
+		orderID = simulatedOrderID
+	}
+	order, err := newCoreOrder(&proto.CreateOrderRequest{
+		OrderBookName: req.OrderBookName,
+		OrderId:       orderID,
+		Side:          req.Side,
+		Quantity:      req.Quantity,
+		Price:         req.Price,
+		OrderType:     req.OrderType,
+		TimeInForce:   req.TimeInForce,
+		StopPrice:     req.StopPrice,
+		OcoId:         req.OcoId,
+		UserAddress:   req.UserAddress,
+		GtdExpiresAt:  req.GtdExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay the current book into a throwaway in-memory copy
+	snapshot := orderBook.Snapshot()
+	simulation := core.NewDetachedOrderBook(memory.NewMemoryBackend())
+	if err := simulation.Restore(snapshot); err != nil {
+		logger.Error().Err(err).Msg("Failed to restore order book snapshot")
+		return nil, status.Errorf(codes.Internal, "failed to copy order book: %v", err)
+	}
+
+	done, err := simulation.Process(ctx, order)
+	if err != nil {
+		if errors.Is(err, core.ErrOrderExists) {
+			return nil, status.Errorf(codes.AlreadyExists, "order with ID %s already exists", orderID)
+		}
+		logger.Error().Err(err).Msg("Failed to simulate order")
+		return nil, status.Errorf(codes.Internal, "failed to simulate order: %v", err)
+	}
+
+	resp := &proto.SimulateOrderResponse{
+		EstimatedFillQty:     done.Processed.String(),
+		EstimatedAvgPrice:    fpdecimal.Zero.String(),
+		EstimatedSlippageBps: fpdecimal.Zero.String(),
+		MatchedOrders:        []*proto.SimulatedMatch{},
+	}
+
+	notional := fpdecimal.Zero
+	filled := fpdecimal.Zero
+	for _, trade := range done.Trades {
+		if trade.OrderID == order.ID() {
+			continue
+		}
+		notional = notional.Add(trade.Price.Mul(trade.Quantity))
+		filled = filled.Add(trade.Quantity)
+		resp.MatchedOrders = append(resp.MatchedOrders, &proto.SimulatedMatch{
+			OrderId:  trade.OrderID,
+			Quantity: trade.Quantity.String(),
+			Price:    trade.Price.String(),
+		})
+	}
+	if filled.Equal(fpdecimal.Zero) {
+		return resp, nil
+	}
+
+	avgPrice := notional.Div(filled)
+	resp.EstimatedAvgPrice = avgPrice.String()
+
+	// Slippage is measured against the best opposite price before the order
+	opposite := snapshot.Asks
+	if order.Side() == core.Sell {
+		opposite = snapshot.Bids
+	}
+	if len(opposite) > 0 {
+		bestPrice := opposite[0].Price()
+		diff := avgPrice.Sub(bestPrice)
+		if order.Side() == core.Sell {
+			diff = bestPrice.Sub(avgPrice)
+		}
+		resp.EstimatedSlippageBps = diff.Mul(fpdecimal.FromInt(10000)).Div(bestPrice).String()
+	}
+
+	return resp, nil
+}
+
+// RouteOrder splits an order across several order books with the smart
+// order router. Each book with a share receives a child order whose ID is
+// the order ID suffixed with the book name. When a book fails after others
+// have processed their child orders, those are returned with the error set
+// on the response, as they cannot be undone.
+func (s *GRPCOrderBookService) RouteOrder(ctx context.Context, req *proto.RouteOrderRequest) (*proto.RouteOrderResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "RouteOrder").
+		Strs("order_books", req.OrderBookNames).
+		Str("order_id", req.OrderId).
+		Logger()
+
+	logger.Debug().
+		Str("side", req.Side.String()).
+		Str("type", req.OrderType.String()).
+		Str("quantity", req.Quantity).
+		Str("price", req.Price).
+		Str("strategy", req.Strategy.String()).
+		Msg("Request received")
+
+	var violations []Violation
+	if len(req.OrderBookNames) == 0 {
+		violations = append(violations, Violation{Field: "order_book_names", Description: "must not be empty"})
+	}
+	if req.OrderType != proto.OrderType_MARKET && req.OrderType != proto.OrderType_LIMIT {
+		violations = append(violations, Violation{Field: "order_type", Description: "must be MARKET or LIMIT"})
+	}
+	if len(violations) > 0 {
+		return nil, validationError(violations...)
+	}
+
+	order, err := newCoreOrder(&proto.CreateOrderRequest{
+		OrderId:     req.OrderId,
+		Side:        req.Side,
+		Quantity:    req.Quantity,
+		Price:       req.Price,
+		OrderType:   req.OrderType,
+		TimeInForce: req.TimeInForce,
+		UserAddress: req.UserAddress,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make(map[string]*OrderBookInfo, len(req.OrderBookNames))
+	books := make(map[string]*core.OrderBook, len(req.OrderBookNames))
+	lookup := func(ctx context.Context, name string) (*core.OrderBook, error) {
+		book, info, err := s.manager.GetOrderBook(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		infos[name] = info
+		books[name] = book
+		return book, nil
+	}
+
+	strategy := router.BestPriceFirst
+	if req.Strategy == proto.AllocationStrategy_PROPORTIONAL_SPLIT {
+		strategy = router.ProportionalSplit
+	}
+
+	dones, err := router.NewSmartOrderRouter(lookup, strategy).Route(ctx, order, req.OrderBookNames)
+	if err != nil && len(dones) == 0 {
+		switch {
+		case errors.Is(err, router.ErrDuplicateBook):
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		case errors.Is(err, ErrOrderBookNotFound), errors.Is(err, ErrOrderBookDeleted):
+			return nil, status.Errorf(codes.NotFound, "%v", err)
+		case errors.Is(err, router.ErrNoLiquidity), errors.Is(err, core.ErrPriceDeviationExceeded):
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		case errors.Is(err, core.ErrOrderExists):
+			return nil, status.Errorf(codes.AlreadyExists, "%v", err)
+		case errors.Is(err, core.ErrOrderBookClosed), errors.Is(err, core.ErrMarketHalted):
+			return nil, status.Errorf(codes.Unavailable, "%v", err)
+		}
+		logger.Error().Err(err).Msg("Failed to route order")
+		return nil, status.Errorf(codes.Internal, "failed to route order: %v", err)
+	}
+
+	// Child order IDs identify the book each Done came from
+	bookByChildID := make(map[string]string, len(req.OrderBookNames))
+	for _, name := range req.OrderBookNames {
+		bookByChildID[router.ChildOrderID(req.OrderId, name)] = name
+	}
+
+	resp := &proto.RouteOrderResponse{Orders: make([]*proto.RoutedOrder, 0, len(dones))}
+	if err != nil {
+		logger.Error().Err(err).Int("child_orders", len(dones)).Msg("Order routed in part")
+		resp.Error = err.Error()
+	}
+	for _, done := range dones {
+		name := bookByChildID[done.Order.ID()]
+		resp.Orders = append(resp.Orders, &proto.RoutedOrder{
+			OrderBookName:     name,
+			OrderId:           done.Order.ID(),
+			Quantity:          done.Quantity.String(),
+			ExecutedQuantity:  done.Processed.String(),
+			RemainingQuantity: done.Left.String(),
+			Stored:            done.Stored,
+		})
+		recordBestPrices(ctx, name, books[name])
+		now := time.Now()
+		s.events.publish(ctx, name, doneEvents(name, done.Order, done, now)...)
+	}
+	resp.ExecutedQuantity = fpdecimal.Zero.String()
+	if merged := core.Merge(dones...); merged != nil {
+		resp.ExecutedQuantity = merged.Processed.String()
+	}
+
+	logger.Info().
+		Int("child_orders", len(resp.Orders)).
+		Str("executed_quantity", resp.ExecutedQuantity).
+		Msg("Order routed")
+	return resp, nil
+}
+
+// GetOrder retrieves information about a specific order
+func (s *GRPCOrderBookService) GetOrder(ctx context.Context, req *proto.GetOrderRequest) (*proto.OrderResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "GetOrder").
+		Str("order_book", req.OrderBookName).
+		Str("order_id", req.OrderId).
+		Logger()
+
+	logger.Debug().Msg("Request received")
+
+	// Get the order book
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	// Get the order
+	order := orderBook.GetOrder(req.OrderId)
+	if order == nil {
+		return nil, status.Errorf(codes.NotFound, "order %s not found", req.OrderId)
+	}
+
+	return orderResponse(req.OrderBookName, order), nil
+}
+
+// MaxBatchGetOrders is the most order IDs BatchGetOrders accepts in one request
+const MaxBatchGetOrders = 500
+
+// BatchGetOrders retrieves several orders from one order book. IDs that are
+// not on the book get an entry with status UNKNOWN and error code NOT_FOUND
+// rather than failing the whole call.
+func (s *GRPCOrderBookService) BatchGetOrders(ctx context.Context, req *proto.BatchGetOrdersRequest) (*proto.BatchGetOrdersResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "BatchGetOrders").
+		Str("order_book", req.OrderBookName).
+		Int("order_ids", len(req.OrderIds)).
+		Logger()
+
+	logger.Debug().Msg("Request received")
+
+	if len(req.OrderIds) > MaxBatchGetOrders {
+		return nil, validationError(Violation{
+			Field:       "order_ids",
+			Description: fmt.Sprintf("must contain at most %d IDs", MaxBatchGetOrders),
+		})
+	}
+
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	orders := orderBook.GetOrders(req.OrderIds)
+	resp := &proto.BatchGetOrdersResponse{Orders: make([]*proto.OrderResponse, len(orders))}
+	for i, order := range orders {
+		if order == nil {
+			resp.Orders[i] = &proto.OrderResponse{
+				OrderId:       req.OrderIds[i],
+				OrderBookName: req.OrderBookName,
+				Status:        proto.OrderStatus_UNKNOWN,
+				ErrorCode:     proto.OrderErrorCode_NOT_FOUND,
+			}
+			continue
+		}
+		resp.Orders[i] = orderResponse(req.OrderBookName, order)
+	}
+	return resp, nil
+}
+
+// MaxBatchCreateOrders is the most orders BatchCreateOrders accepts in one request
+const MaxBatchCreateOrders = 500
+
+// BatchCreateOrders submits several orders one after another, in request
+// order, each as CreateOrder would. An order that fails does not stop the
+// others. An atomic batch is checked whole first: if any order is invalid,
+// breaks its book's matching rules, names a missing book or repeats an order
+// ID, no order is submitted. Orders still match one at a time, so an atomic
+// batch is not undone if an order fails while matching, such as a post-only
+// order that would take.
+func (s *GRPCOrderBookService) BatchCreateOrders(ctx context.Context, req *proto.BatchCreateOrdersRequest) (*proto.BatchCreateOrdersResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "BatchCreateOrders").
+		Int("orders", len(req.Orders)).
+		Bool("atomic", req.Atomic).
+		Logger()
+
+	logger.Debug().Msg("Request received")
+
+	if len(req.Orders) > MaxBatchCreateOrders {
+		return nil, validationError(Violation{
+			Field:       "orders",
+			Description: fmt.Sprintf("must contain at most %d orders", MaxBatchCreateOrders),
+		})
+	}
+
+	resp := &proto.BatchCreateOrdersResponse{Orders: make([]*proto.OrderResponse, len(req.Orders))}
+	if req.Atomic {
+		if errs := s.checkBatch(ctx, req.Orders); len(errs) > 0 {
+			for i, order := range req.Orders {
+				resp.Orders[i] = rejectedOrder(order)
+			}
+			resp.Errors = errs
+			logger.Info().Int("errors", len(errs)).Msg("Atomic batch rejected")
+			return resp, nil
+		}
+	}
+
+	for i, order := range req.Orders {
+		orderResp, err := s.CreateOrder(ctx, order)
+		if err != nil {
+			resp.Orders[i] = rejectedOrder(order)
+			resp.Errors = append(resp.Errors, batchOrderError(i, order, err))
+			continue
+		}
+		resp.Orders[i] = orderResp
+	}
+	return resp, nil
+}
+
+// checkBatch returns an error for every order of an atomic batch that would
+// be rejected before matching
+func (s *GRPCOrderBookService) checkBatch(ctx context.Context, orders []*proto.CreateOrderRequest) []*proto.BatchOrderError {
+	var errs []*proto.BatchOrderError
+	seen := make(map[string]bool, len(orders))
+	for i, req := range orders {
+		order, err := newCoreOrder(req, s.validator.ValidateCreateOrder(req)...)
+		if err != nil {
+			errs = append(errs, batchOrderError(i, req, err))
+			continue
+		}
+		orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+		if err != nil {
+			errs = append(errs, batchOrderError(i, req, status.Errorf(codes.NotFound, "order book %s: %v", req.OrderBookName, err)))
+			continue
+		}
+		if err := orderBook.CheckRules(order); err != nil {
+			errs = append(errs, batchOrderError(i, req, status.Errorf(codes.InvalidArgument, "order %s rejected by order book %s: %v", req.OrderId, req.OrderBookName, err)))
+			continue
+		}
+
+		// A repeated client order ID is answered from the idempotency
+		// cache, so only orders without one can clash
+		if req.ClientOrderId != "" {
+			continue
+		}
+		key := req.OrderBookName + "/" + req.OrderId
+		if seen[key] || orderBook.GetOrder(req.OrderId) != nil {
+			errs = append(errs, batchOrderError(i, req, status.Errorf(codes.AlreadyExists, "order with ID %s already exists", req.OrderId)))
+			continue
+		}
+		seen[key] = true
+	}
+	return errs
+}
+
+// rejectedOrder is the batch response entry of an order that was not submitted
+func rejectedOrder(req *proto.CreateOrderRequest) *proto.OrderResponse {
+	return &proto.OrderResponse{
+		OrderId:       req.OrderId,
+		OrderBookName: req.OrderBookName,
+		ClientOrderId: req.ClientOrderId,
+		Status:        proto.OrderStatus_REJECTED,
+	}
+}
+
+// batchOrderError describes why the order at index i of a batch failed
+func batchOrderError(i int, req *proto.CreateOrderRequest, err error) *proto.BatchOrderError {
+	st := status.Convert(err)
+	return &proto.BatchOrderError{
+		Index:   int32(i),
+		OrderId: req.OrderId,
+		Code:    int32(st.Code()),
+		Message: st.Message(),
+	}
+}
+
+// ListStopOrders lists the untriggered stop orders of an order book, buys
+// first, optionally limited to one side
+func (s *GRPCOrderBookService) ListStopOrders(ctx context.Context, req *proto.ListStopOrdersRequest) (*proto.ListStopOrdersResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "ListStopOrders").
+		Str("order_book", req.OrderBookName).
+		Logger()
+
+	logger.Debug().Int32("limit", req.Limit).Int32("offset", req.Offset).Msg("Request received")
+
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	stops := orderBook.GetAllStopOrders()
+	if req.Side != nil {
+		filtered := stops[:0]
+		for _, order := range stops {
+			if convertCoreSideToProto(order.Side()) == req.GetSide() {
+				filtered = append(filtered, order)
+			}
+		}
+		stops = filtered
+	}
+
+	// Apply pagination
+	offset := int(req.Offset)
+	if offset < 0 {
+		offset = 0
+	}
+	limit := int(req.Limit)
+	if limit <= 0 || limit > 100 {
+		limit = 100 // Default limit
+	}
+	total := len(stops)
+	offset = min(offset, total)
+	end := min(offset+limit, total)
+
+	resp := &proto.ListStopOrdersResponse{
+		StopOrders: make([]*proto.StopOrder, 0, end-offset),
+		Total:      int32(total),
+	}
+	for _, order := range stops[offset:end] {
+		resp.StopOrders = append(resp.StopOrders, &proto.StopOrder{
+			OrderId:      order.ID(),
+			Side:         convertCoreSideToProto(order.Side()),
+			TriggerPrice: order.StopPrice().String(),
+			LimitPrice:   order.Price().String(),
+			Quantity:     order.Quantity().String(),
+			UserAddress:  order.UserAddress(),
+			CreatedAt:    timestamppb.New(order.CreatedAt()),
+		})
+	}
+	return resp, nil
+}
+
+// orderResponse describes a resting order of orderBookName
+func orderResponse(orderBookName string, order *core.Order) *proto.OrderResponse {
+	// Convert order side
+	side := proto.OrderSide_BUY
+	if order.Side() == core.Sell {
+		side = proto.OrderSide_SELL
+	}
+
+	// Convert order type
+	orderType := proto.OrderType_LIMIT
+	if order.IsMarketOrder() {
+		orderType = proto.OrderType_MARKET
+	} else if order.IsMidpointOrder() {
+		orderType = proto.OrderType_MIDPOINT
+	} else if order.IsIceberg() {
+		orderType = proto.OrderType_ICEBERG
+	} else if order.IsStopOrder() {
+		if order.IsLimitOrder() {
+			orderType = proto.OrderType_STOP_LIMIT
+		} else {
+			orderType = proto.OrderType_STOP
+		}
+	}
+
+	// Convert time in force
+	timeInForce := proto.TimeInForce_GTC
+	if !order.IsMarketOrder() && !order.IsStopOrder() {
+		switch order.TIF() {
+		case core.GTC:
+			timeInForce = proto.TimeInForce_GTC
+		case core.IOC:
+			timeInForce = proto.TimeInForce_IOC
+		case core.FOK:
+			timeInForce = proto.TimeInForce_FOK
+		case core.GTD:
+			timeInForce = proto.TimeInForce_GTD
+		}
+	}
+
+	// Create response
+	resp := &proto.OrderResponse{
+		OrderId:           order.ID(),
+		OrderBookName:     orderBookName,
+		Side:              side,
 		Quantity:          order.OriginalQty().String(),
-		RemainingQuantity: order.Quantity().String(),
+		RemainingQuantity: order.RemainingQty().String(),
 		OrderType:         orderType,
 		TimeInForce:       timeInForce,
 		CreatedAt:         timestamppb.New(time.Now()), // We don't track creation time in the core lib
 		UpdatedAt:         timestamppb.New(time.Now()),
 		OcoId:             order.OCO(),
+		OrderState:        convertCoreStateToProto(order.State()),
+		ClientOrderId:     order.ClientOrderID(),
+	}
+
+	// Add price if it's a limit order
+	if order.IsLimitOrder() {
+		resp.Price = order.Price().String()
+	}
+
+	// Add stop price if it's a stop order
+	if order.IsStopOrder() {
+		resp.StopPrice = order.StopPrice().String()
+	}
+
+	if expiresAt := order.ExpiresAt(); !expiresAt.IsZero() {
+		resp.GtdExpiresAt = timestamppb.New(expiresAt)
+	}
+
+	// Calculate filled quantity and status
+	filledQty := order.OriginalQty().Sub(order.RemainingQty())
+	resp.FilledQuantity = filledQty.String()
+
+	// Determine order status; a stop order is only stored as one until it
+	// triggers
+	if order.IsStopOrder() {
+		resp.Status = proto.OrderStatus_PENDING
+	} else if filledQty.Equal(order.OriginalQty()) {
+		resp.Status = proto.OrderStatus_FILLED
+	} else if filledQty.Equal(fpdecimal.Zero) {
+		resp.Status = proto.OrderStatus_OPEN
+	} else {
+		resp.Status = proto.OrderStatus_PARTIALLY_FILLED
+	}
+
+	return resp
+}
+
+// CancelOrder cancels an order in the specified order book
+func (s *GRPCOrderBookService) CancelOrder(ctx context.Context, req *proto.CancelOrderRequest) (*emptypb.Empty, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "CancelOrder").
+		Str("order_book", req.OrderBookName).
+		Str("order_id", req.OrderId).
+		Logger()
+
+	logger.Debug().Msg("Request received")
+
+	// Get the order book
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	unlock, err := s.lockBook(ctx, req.OrderBookName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Cancel the order
+	canceledOrder := orderBook.CancelOrderWithReason(ctx, req.OrderId, messaging.CancelReasonUserRequested)
+	if canceledOrder == nil {
+		return nil, status.Errorf(codes.NotFound, "order %s not found", req.OrderId)
+	}
+
+	s.events.publish(ctx, req.OrderBookName, cancelEvent(req.OrderBookName, canceledOrder, time.Now()))
+	recordBestPrices(ctx, req.OrderBookName, orderBook)
+
+	logger.Info().Str("order_id", req.OrderId).Msg("Order canceled")
+	return &emptypb.Empty{}, nil
+}
+
+// AmendOrder changes the price and/or remaining quantity of a resting limit
+// order, leaving whichever of the two is empty in the request unchanged.
+// Where the order then sits in the queue follows core.OrderBook.AmendOrder:
+// a smaller quantity at the same price keeps its place, a better price goes
+// to the front of its new level and a worse one keeps its time priority.
+func (s *GRPCOrderBookService) AmendOrder(ctx context.Context, req *proto.AmendOrderRequest) (*proto.OrderResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "AmendOrder").
+		Str("order_book", req.OrderBookName).
+		Str("order_id", req.OrderId).
+		Logger()
+
+	logger.Debug().
+		Str("price", req.Price).
+		Str("quantity", req.Quantity).
+		Msg("Request received")
+
+	var violations []Violation
+	if req.Price == "" && req.Quantity == "" {
+		violations = append(violations, Violation{Field: "price", Description: "or quantity must be set"})
+	}
+	var price, quantity fpdecimal.Decimal
+	if req.Price != "" {
+		price = parsePositiveDecimal("price", req.Price, &violations)
+	}
+	if req.Quantity != "" {
+		quantity = parsePositiveDecimal("quantity", req.Quantity, &violations)
+	}
+	if len(violations) > 0 {
+		return nil, validationError(violations...)
+	}
+
+	// Get the order book
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	unlock, err := s.lockBook(ctx, req.OrderBookName)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var done *core.Done
+	switch {
+	case req.Quantity == "":
+		done, err = orderBook.AmendPrice(ctx, req.OrderId, price)
+	case req.Price == "":
+		done, err = orderBook.AmendQuantity(ctx, req.OrderId, quantity)
+	default:
+		done, err = orderBook.AmendOrder(ctx, req.OrderId, price, quantity)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrNonexistentOrder):
+			return nil, status.Errorf(codes.NotFound, "order %s not found", req.OrderId)
+		case errors.Is(err, core.ErrNotAmendable):
+			return nil, status.Errorf(codes.FailedPrecondition, "order %s cannot be amended: %v", req.OrderId, err)
+		case errors.Is(err, core.ErrAmendWouldTake):
+			return nil, status.Errorf(codes.FailedPrecondition, "amended order %s would match a resting order", req.OrderId)
+		case errors.Is(err, core.ErrInvalidTickSize) || errors.Is(err, core.ErrInvalidLotSize) || errors.Is(err, core.ErrBelowMinQty):
+			return nil, status.Errorf(codes.InvalidArgument, "amendment of order %s rejected by order book %s: %v", req.OrderId, req.OrderBookName, err)
+		}
+		logger.Error().Err(err).Msg("Failed to amend order")
+		return nil, status.Errorf(codes.Internal, "failed to amend order: %v", err)
 	}
 
-	// Add price if it's a limit order
-	if order.IsLimitOrder() {
-		resp.Price = order.Price().String()
-	}
+	amended := done.Order
+	s.events.publish(ctx, req.OrderBookName, amendEvent(req.OrderBookName, amended, time.Now()))
+	recordBestPrices(ctx, req.OrderBookName, orderBook)
 
-	// Add stop price if it's a stop order
-	if order.IsStopOrder() {
-		resp.StopPrice = order.StopPrice().String()
-	}
+	logger.Info().
+		Str("price", amended.Price().String()).
+		Str("quantity", amended.Quantity().String()).
+		Msg("Order amended")
+	return orderResponse(req.OrderBookName, amended), nil
+}
 
-	// Calculate filled quantity and status
-	filledQty := order.OriginalQty().Sub(order.Quantity())
-	resp.FilledQuantity = filledQty.String()
+// WatchOrderBook streams the trade, add and cancel events of an order book
+// until the client goes away. Events are those caused by CreateOrder,
+// RouteOrder and CancelOrder calls on this server.
+func (s *GRPCOrderBookService) WatchOrderBook(req *proto.WatchOrderBookRequest, stream proto.OrderBookService_WatchOrderBookServer) error {
+	ctx := stream.Context()
+	logger := logging.FromContext(ctx).With().
+		Str("method", "WatchOrderBook").
+		Str("order_book", req.OrderBookName).
+		Logger()
 
-	// Determine order status
-	if filledQty.Equal(order.OriginalQty()) {
-		resp.Status = proto.OrderStatus_FILLED
-	} else if filledQty.Equal(fpdecimal.Zero) {
-		resp.Status = proto.OrderStatus_OPEN
-	} else {
-		resp.Status = proto.OrderStatus_PARTIALLY_FILLED
+	if _, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName); err != nil {
+		if errors.Is(err, ErrOrderBookNotFound) || errors.Is(err, ErrOrderBookDeleted) {
+			return status.Errorf(codes.NotFound, "%v", err)
+		}
+		return status.Errorf(codes.Internal, "failed to get order book: %v", err)
 	}
 
-	return resp, nil
+	events, unsubscribe := s.events.subscribe(req.OrderBookName, subscription{types: req.EventTypes})
+	defer unsubscribe()
+	logger.Info().Msg("Watcher connected")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info().Msg("Watcher disconnected")
+			return nil
+		case event := <-events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
 }
 
-// CancelOrder cancels an order in the specified order book
-func (s *GRPCOrderBookService) CancelOrder(ctx context.Context, req *proto.CancelOrderRequest) (*emptypb.Empty, error) {
+// SubscribeTrades streams the trades of an order book until the client goes
+// away. Trades are those made by CreateOrder and RouteOrder calls on this
+// server. A subscriber that falls too far behind has its stream ended with
+// ResourceExhausted and has to subscribe again.
+func (s *GRPCOrderBookService) SubscribeTrades(req *proto.SubscribeTradesRequest, stream proto.OrderBookService_SubscribeTradesServer) error {
+	ctx := stream.Context()
 	logger := logging.FromContext(ctx).With().
-		Str("method", "CancelOrder").
+		Str("method", "SubscribeTrades").
 		Str("order_book", req.OrderBookName).
-		Str("order_id", req.OrderId).
 		Logger()
 
-	logger.Debug().Msg("Request received")
+	if _, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName); err != nil {
+		if errors.Is(err, ErrOrderBookNotFound) || errors.Is(err, ErrOrderBookDeleted) {
+			return status.Errorf(codes.NotFound, "%v", err)
+		}
+		return status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
 
-	// Get the order book
-	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
-	if err != nil {
-		if err == ErrOrderBookNotFound {
-			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+	trades, unsubscribe := s.events.subscribe(req.OrderBookName, subscription{
+		types:    []proto.OrderBookEventType{proto.OrderBookEventType_TRADE},
+		dropSlow: true,
+	})
+	defer unsubscribe()
+	logger.Info().Msg("Trade subscriber connected")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info().Msg("Trade subscriber disconnected")
+			return nil
+		case trade, ok := <-trades:
+			if !ok {
+				logger.Warn().Msg("Trade subscriber dropped for falling behind")
+				return status.Error(codes.ResourceExhausted, "trade subscriber fell behind")
+			}
+			if err := stream.Send(tradeEvent(trade)); err != nil {
+				return err
+			}
 		}
-		logger.Error().Err(err).Msg("Failed to get order book")
-		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
 	}
+}
 
-	// Cancel the order
-	canceledOrder := orderBook.CancelOrder(req.OrderId)
-	if canceledOrder == nil {
-		return nil, status.Errorf(codes.NotFound, "order %s not found", req.OrderId)
+const (
+	// DefaultStateDepth is how many price levels per side GetOrderBookState returns when no depth is given
+	DefaultStateDepth = 20
+	// MaxStateDepth is the largest depth GetOrderBookState accepts
+	MaxStateDepth = 1000
+)
+
+// topPriceLevels aggregates the best depth price levels of side, formatted
+// with the precision of book. With a positive bucketSize, the levels whose
+// prices round down to the same multiple of bucketSize are merged into one
+// level at that multiple, and depth counts merged levels. Sides that
+// implement TopPrices are asked for only the levels needed.
+func topPriceLevels(book *core.OrderBook, side interface{}, depth int, bucketSize fpdecimal.Decimal) []*proto.PriceLevel {
+	levels := []*proto.PriceLevel{}
+	orderSide, ok := side.(interface {
+		Prices() []fpdecimal.Decimal
+		Orders(price fpdecimal.Decimal) []*core.Order
+	})
+	if !ok {
+		return levels
 	}
 
-	logger.Info().Str("order_id", req.OrderId).Msg("Order canceled")
-	return &emptypb.Empty{}, nil
+	bucketed := bucketSize.GreaterThan(fpdecimal.Zero)
+	var prices []fpdecimal.Decimal
+	if topSide, ok := side.(interface {
+		TopPrices(n int) []fpdecimal.Decimal
+	}); ok && !bucketed {
+		prices = topSide.TopPrices(depth)
+	} else {
+		prices = orderSide.Prices()
+	}
+
+	// Prices are sorted best first, so the prices of a bucket are adjacent
+	var (
+		level         *proto.PriceLevel
+		levelPrice    fpdecimal.Decimal
+		totalQuantity fpdecimal.Decimal
+	)
+	for _, price := range prices {
+		orders := orderSide.Orders(price)
+		if len(orders) == 0 {
+			continue
+		}
+		if bucketed {
+			price = bucketPrice(price, bucketSize)
+		}
+		if level == nil || !price.Equal(levelPrice) {
+			if len(levels) == depth {
+				break
+			}
+			level = &proto.PriceLevel{
+				Price:       book.FormatPrice(price),
+				UserAddress: orders[0].UserAddress(),
+			}
+			levels = append(levels, level)
+			levelPrice = price
+			totalQuantity = fpdecimal.Zero
+		}
+		for _, order := range orders {
+			totalQuantity = totalQuantity.Add(order.Quantity())
+		}
+		level.TotalQuantity = book.FormatQty(totalQuantity)
+		level.OrderCount += int32(len(orders))
+	}
+	return levels
+}
+
+// bucketPrice rounds price down to a multiple of bucketSize
+func bucketPrice(price, bucketSize fpdecimal.Decimal) fpdecimal.Decimal {
+	return fpdecimal.FromIntScaled(price.Scaled() / bucketSize.Scaled() * bucketSize.Scaled())
 }
 
 // GetOrderBookState retrieves the current state of an order book
@@ -514,76 +1757,447 @@ func (s *GRPCOrderBookService) GetOrderBookState(ctx context.Context, req *proto
 		Str("method", "GetOrderBookState").
 		Str("order_book", req.Name).
 		Int32("depth", req.Depth).
+		Str("bucket_size", req.BucketSize).
 		Logger()
 
 	logger.Debug().Msg("Request received")
 
+	var violations []Violation
+	if req.Depth < 0 {
+		violations = append(violations, Violation{Field: "depth", Description: "must not be negative"})
+	}
+	if req.Depth > MaxStateDepth {
+		violations = append(violations, Violation{Field: "depth", Description: fmt.Sprintf("must be at most %d", MaxStateDepth)})
+	}
+	bucketSize := fpdecimal.Zero
+	if req.BucketSize != "" {
+		bucketSize = parsePositiveDecimal("bucket_size", req.BucketSize, &violations)
+	}
+	if len(violations) > 0 {
+		return nil, validationError(violations...)
+	}
+
 	// Get the order book
 	orderBook, _, err := s.manager.GetOrderBook(ctx, req.Name)
 	if err != nil {
 		if err == ErrOrderBookNotFound {
 			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.Name)
 		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.Name)
+		}
 		logger.Error().Err(err).Msg("Failed to get order book")
 		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
 	}
 
 	// Set default depth if not specified
 	depth := int(req.Depth)
-	if depth <= 0 {
-		depth = 10 // Default depth
+	if depth == 0 {
+		depth = DefaultStateDepth
 	}
 
 	// Create response
 	response := &proto.OrderBookStateResponse{
 		Name:      req.Name,
 		Timestamp: timestamppb.New(time.Now()),
-		Bids:      []*proto.PriceLevel{},
-		Asks:      []*proto.PriceLevel{},
-	}
-
-	// Get bids
-	if bids := orderBook.GetBids(); bids != nil {
-		if bidSide, ok := bids.(*memory.OrderSide); ok {
-			prices := bidSide.Prices()
-			for i := 0; i < len(prices) && i < depth; i++ {
-				price := prices[i]
-				orders := bidSide.Orders(price)
-				totalQuantity := fpdecimal.Zero
-				for _, order := range orders {
-					totalQuantity = totalQuantity.Add(order.Quantity())
-				}
-				response.Bids = append(response.Bids, &proto.PriceLevel{
-					Price:         price.String(),
-					TotalQuantity: totalQuantity.String(),
-					OrderCount:    int32(len(orders)),
-					UserAddress:   orders[0].UserAddress(),
-				})
-			}
+		Bids:      topPriceLevels(orderBook, orderBook.GetBids(), depth, bucketSize),
+		Asks:      topPriceLevels(orderBook, orderBook.GetAsks(), depth, bucketSize),
+	}
+
+	logger.Info().Msg("Returning order book state")
+	return response, nil
+}
+
+// GetDepthAtPrice returns the total resting quantity, order count and users
+// at a single price level on one side of an order book
+func (s *GRPCOrderBookService) GetDepthAtPrice(ctx context.Context, req *proto.GetDepthAtPriceRequest) (*proto.DepthAtPriceResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "GetDepthAtPrice").
+		Str("order_book", req.OrderBookName).
+		Str("side", req.Side.String()).
+		Str("price", req.Price).
+		Logger()
+
+	logger.Debug().Msg("Request received")
+
+	var violations []Violation
+	price := parsePositiveDecimal("price", req.Price, &violations)
+	if len(violations) > 0 {
+		return nil, validationError(violations...)
+	}
+
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
 		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
 	}
 
-	// Get asks
-	if asks := orderBook.GetAsks(); asks != nil {
-		if askSide, ok := asks.(*memory.OrderSide); ok {
-			prices := askSide.Prices()
-			for i := 0; i < len(prices) && i < depth; i++ {
-				price := prices[i]
-				orders := askSide.Orders(price)
-				totalQuantity := fpdecimal.Zero
-				for _, order := range orders {
-					totalQuantity = totalQuantity.Add(order.Quantity())
-				}
-				response.Asks = append(response.Asks, &proto.PriceLevel{
-					Price:         price.String(),
-					TotalQuantity: totalQuantity.String(),
-					OrderCount:    int32(len(orders)),
-					UserAddress:   orders[0].UserAddress(),
-				})
+	side := core.Buy
+	if req.Side == proto.OrderSide_SELL {
+		side = core.Sell
+	}
+
+	// Read the level once so the quantity, count and users are consistent
+	orders, err := orderBook.GetOrdersAtPrice(side, price)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get orders at price")
+		return nil, status.Errorf(codes.Internal, "failed to get orders at price: %v", err)
+	}
+
+	totalQuantity := fpdecimal.Zero
+	seen := make(map[string]bool)
+	userAddresses := []string{}
+	for _, order := range orders {
+		totalQuantity = totalQuantity.Add(order.Quantity())
+		if !seen[order.UserAddress()] {
+			seen[order.UserAddress()] = true
+			userAddresses = append(userAddresses, order.UserAddress())
+		}
+	}
+	sort.Strings(userAddresses)
+
+	logger.Info().Int("order_count", len(orders)).Msg("Returning depth at price")
+	return &proto.DepthAtPriceResponse{
+		Price:         price.String(),
+		TotalQuantity: totalQuantity.String(),
+		OrderCount:    int32(len(orders)),
+		UserAddresses: userAddresses,
+	}, nil
+}
+
+// GetBookNotional returns the value of the resting orders on each side of an
+// order book and, when shock_pct is set, how far each best price would move
+// if that share of its side were taken
+func (s *GRPCOrderBookService) GetBookNotional(ctx context.Context, req *proto.GetBookNotionalRequest) (*proto.BookNotionalResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "GetBookNotional").
+		Str("order_book", req.OrderBookName).
+		Float64("shock_pct", req.ShockPct).
+		Logger()
+
+	logger.Debug().Msg("Request received")
+
+	if req.ShockPct < 0 || req.ShockPct > 100 {
+		return nil, validationError(Violation{Field: "shock_pct", Description: "must be between 0 and 100"})
+	}
+
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	bidNotional, askNotional := orderBook.TotalNotional()
+	resp := &proto.BookNotionalResponse{
+		OrderBookName: req.OrderBookName,
+		BidNotional:   bidNotional.String(),
+		AskNotional:   askNotional.String(),
+		BidDrawdown:   orderBook.MaxDrawdown(core.Buy, req.ShockPct).String(),
+		AskDrawdown:   orderBook.MaxDrawdown(core.Sell, req.ShockPct).String(),
+	}
+
+	logger.Info().
+		Str("bid_notional", resp.BidNotional).
+		Str("ask_notional", resp.AskNotional).
+		Msg("Returning book notional")
+	return resp, nil
+}
+
+// GetBBO returns the best bid and best offer of an order book, with the
+// spread and midpoint between them
+func (s *GRPCOrderBookService) GetBBO(ctx context.Context, req *proto.GetBBORequest) (*proto.BBOResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "GetBBO").
+		Str("order_book", req.OrderBookName).
+		Logger()
+
+	logger.Debug().Msg("Request received")
+
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	resp := &proto.BBOResponse{}
+	bbo := orderBook.BBO()
+	if bbo.HasBid {
+		resp.BidPrice, resp.BidQty = bbo.BidPrice.String(), bbo.BidQty.String()
+	}
+	if bbo.HasAsk {
+		resp.AskPrice, resp.AskQty = bbo.AskPrice.String(), bbo.AskQty.String()
+	}
+	if mid, ok := bbo.Midpoint(); ok {
+		resp.Spread = bbo.AskPrice.Sub(bbo.BidPrice).String()
+		resp.MidPrice = mid.String()
+	}
+
+	logger.Info().
+		Str("bid_price", resp.BidPrice).
+		Str("ask_price", resp.AskPrice).
+		Msg("Returning best bid and offer")
+	return resp, nil
+}
+
+// CalculateVWAP returns the volume-weighted average price of the trades in
+// an order book's trade history, all of them or those of the last
+// window_seconds
+func (s *GRPCOrderBookService) CalculateVWAP(ctx context.Context, req *proto.VWAPRequest) (*proto.VWAPResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "CalculateVWAP").
+		Str("order_book", req.OrderBookName).
+		Int64("window_seconds", req.WindowSeconds).
+		Logger()
+
+	logger.Debug().Msg("Request received")
+
+	if req.WindowSeconds < 0 {
+		return nil, validationError(Violation{Field: "window_seconds", Description: "must not be negative"})
+	}
+
+	orderBook, info, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	var since time.Time
+	if req.WindowSeconds > 0 {
+		since = time.Now().Add(-time.Duration(req.WindowSeconds) * time.Second)
+	}
+	vwap, volume, count := info.TradeHistory.VWAP(since)
+	resp := &proto.VWAPResponse{
+		OrderBookName: req.OrderBookName,
+		Vwap:          orderBook.FormatPrice(vwap),
+		Volume:        orderBook.FormatQty(volume),
+		TradeCount:    int32(count),
+	}
+
+	logger.Info().
+		Str("vwap", resp.Vwap).
+		Int32("trade_count", resp.TradeCount).
+		Msg("Returning VWAP")
+	return resp, nil
+}
+
+// CalculateTWAP returns the time-weighted average trade price of an order
+// book over the last window_seconds
+func (s *GRPCOrderBookService) CalculateTWAP(ctx context.Context, req *proto.TWAPRequest) (*proto.TWAPResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "CalculateTWAP").
+		Str("order_book", req.OrderBookName).
+		Int64("window_seconds", req.WindowSeconds).
+		Logger()
+
+	logger.Debug().Msg("Request received")
+
+	if req.WindowSeconds <= 0 {
+		return nil, validationError(Violation{Field: "window_seconds", Description: "must be positive"})
+	}
+
+	orderBook, info, err := s.manager.GetOrderBook(ctx, req.OrderBookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.OrderBookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.OrderBookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	now := time.Now()
+	twap, count := info.TradeHistory.TWAP(now.Add(-time.Duration(req.WindowSeconds)*time.Second), now)
+	resp := &proto.TWAPResponse{
+		OrderBookName: req.OrderBookName,
+		Twap:          orderBook.FormatPrice(twap),
+		TradeCount:    int32(count),
+	}
+
+	logger.Info().
+		Str("twap", resp.Twap).
+		Int32("trade_count", resp.TradeCount).
+		Msg("Returning TWAP")
+	return resp, nil
+}
+
+// recordBestPrices updates the best price gauges of the named book. The
+// notional gauges, which take a pass over every order, are updated by
+// OrderBookManager.StartNotionalRecorder instead.
+func recordBestPrices(ctx context.Context, name string, orderBook *core.OrderBook) {
+	metrics := otel.GetOrderBookMetrics()
+
+	// An empty side is reported as 0
+	bbo := orderBook.BBO()
+	metrics.RecordBestPrices(ctx, name, bbo.BidPrice.Float64(), bbo.AskPrice.Float64())
+}
+
+const (
+	// WarmUpUserAddress is the user address attached to synthetic warm-up orders
+	WarmUpUserAddress = "warmup"
+	// warmUpOrderPrefix prefixes the IDs of synthetic warm-up orders
+	warmUpOrderPrefix = "warmup-"
+)
+
+// WarmUpOrderBook seeds an order book with num_levels resting bids below and
+// num_levels resting asks above the base price, one order per level.
+// It is intended for development and staging environments.
+func (s *GRPCOrderBookService) WarmUpOrderBook(ctx context.Context, req *proto.WarmUpRequest) (*proto.WarmUpResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "WarmUpOrderBook").
+		Str("order_book", req.BookName).
+		Int32("num_levels", req.NumLevels).
+		Logger()
+
+	logger.Debug().
+		Str("base_price", req.BasePrice).
+		Str("tick_size", req.TickSize).
+		Str("qty_per_level", req.QtyPerLevel).
+		Msg("Request received")
+
+	var violations []Violation
+	if req.NumLevels <= 0 {
+		violations = append(violations, Violation{Field: "num_levels", Description: "must be positive"})
+	}
+	basePrice := parsePositiveDecimal("base_price", req.BasePrice, &violations)
+	tickSize := parsePositiveDecimal("tick_size", req.TickSize, &violations)
+	quantity := parsePositiveDecimal("qty_per_level", req.QtyPerLevel, &violations)
+
+	// The lowest bid must still have a positive price
+	if len(violations) == 0 && basePrice.Sub(tickSize.Mul(fpdecimal.FromInt(req.NumLevels))).LessThanOrEqual(fpdecimal.Zero) {
+		violations = append(violations, Violation{Field: "base_price", Description: fmt.Sprintf("is too low for %d levels of %s", req.NumLevels, req.TickSize)})
+	}
+	if len(violations) > 0 {
+		return nil, validationError(violations...)
+	}
+
+	orderBook, _, err := s.manager.GetOrderBook(ctx, req.BookName)
+	if err != nil {
+		if err == ErrOrderBookNotFound {
+			return nil, status.Errorf(codes.NotFound, "order book %s not found", req.BookName)
+		}
+		if err == ErrOrderBookDeleted {
+			return nil, status.Errorf(codes.NotFound, "order book %s has been deleted", req.BookName)
+		}
+		logger.Error().Err(err).Msg("Failed to get order book")
+		return nil, status.Errorf(codes.Internal, "failed to get order book: %v", err)
+	}
+
+	start := time.Now()
+	// Distinguish repeated warm-ups of the same book
+	batch := start.UnixNano()
+	var created int32
+
+	for level := int32(1); level <= req.NumLevels; level++ {
+		offset := tickSize.Mul(fpdecimal.FromInt(level))
+		levels := []struct {
+			side  core.Side
+			price fpdecimal.Decimal
+		}{
+			{core.Buy, basePrice.Sub(offset)},
+			{core.Sell, basePrice.Add(offset)},
+		}
+
+		for _, l := range levels {
+			orderID := fmt.Sprintf("%s%d-%s-%d", warmUpOrderPrefix, batch, strings.ToLower(l.side.String()), level)
+			order, err := core.NewLimitOrder(orderID, l.side, quantity, l.price, core.GTC, "", WarmUpUserAddress)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to create warm-up order: %v", err)
+			}
+			if _, err := orderBook.Process(ctx, order); err != nil {
+				logger.Error().Err(err).Str("order_id", orderID).Msg("Failed to process warm-up order")
+				return nil, status.Errorf(codes.Internal, "failed to process warm-up order %s: %v", orderID, err)
 			}
+			created++
 		}
 	}
 
-	logger.Info().Msg("Returning order book state")
-	return response, nil
+	elapsed := time.Since(start)
+	logger.Info().
+		Int32("orders_created", created).
+		Dur("elapsed", elapsed).
+		Msg("Order book warmed up")
+
+	return &proto.WarmUpResponse{
+		OrdersCreated: created,
+		Elapsed:       durationpb.New(elapsed),
+	}, nil
+}
+
+// GetPositions returns the net position of each user in every order book they
+// traded in, optionally for a single user
+func (s *GRPCOrderBookService) GetPositions(ctx context.Context, req *proto.GetPositionsRequest) (*proto.GetPositionsResponse, error) {
+	logger := logging.FromContext(ctx).With().
+		Str("method", "GetPositions").
+		Str("user_address", req.UserAddress).
+		Logger()
+
+	logger.Debug().Msg("Request received")
+
+	if violations := s.validator.ValidateGetPositions(req); len(violations) > 0 {
+		return nil, validationError(violations...)
+	}
+
+	positions := s.manager.Positions()
+	users := positions.Users()
+	if req.UserAddress != "" {
+		// Positions are keyed by the address orders carry, which is in
+		// checksum form for Ethereum addresses
+		user := req.UserAddress
+		if normalized, err := core.NormalizeAddress(user); err == nil {
+			user = normalized
+		}
+		users = []string{user}
+	}
+
+	resp := &proto.GetPositionsResponse{}
+	for _, user := range users {
+		books := positions.Positions(user)
+		if len(books) == 0 {
+			continue
+		}
+		userPositions := &proto.UserPositions{
+			UserAddress: user,
+			Total:       positions.Total(user).String(),
+			Books:       make([]*proto.BookPosition, 0, len(books)),
+		}
+		for name, net := range books {
+			userPositions.Books = append(userPositions.Books, &proto.BookPosition{
+				OrderBookName: name,
+				Net:           net.String(),
+			})
+		}
+		sort.Slice(userPositions.Books, func(i, j int) bool {
+			return userPositions.Books[i].OrderBookName < userPositions.Books[j].OrderBookName
+		})
+		resp.Users = append(resp.Users, userPositions)
+	}
+
+	logger.Info().Int("users", len(resp.Users)).Msg("Returning positions")
+	return resp, nil
 }
@@ -1,18 +1,39 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/erain9/matchingo/pkg/messaging"
 	pkgotel "github.com/erain9/matchingo/pkg/otel"
+	"github.com/google/uuid"
 	"github.com/nikolaydubina/fpdecimal"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestGRPCOrderBookService(t *testing.T) {
@@ -506,3 +527,2476 @@ func TestGRPCOrderBookService(t *testing.T) {
 		}
 	})
 }
+
+func TestSoftDeleteOrderBook(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "soft-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	orderReq := func(id string) *proto.CreateOrderRequest {
+		return &proto.CreateOrderRequest{
+			OrderBookName: "soft-book",
+			OrderId:       id,
+			Side:          proto.OrderSide_BUY,
+			Quantity:      "1.0",
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+		}
+	}
+
+	_, err = service.CreateOrder(ctx, orderReq("before-delete"))
+	require.NoError(t, err)
+
+	t.Run("Delete", func(t *testing.T) {
+		_, err := service.DeleteOrderBook(ctx, &proto.DeleteOrderBookRequest{Name: "soft-book"})
+		require.NoError(t, err)
+
+		_, err = service.DeleteOrderBook(ctx, &proto.DeleteOrderBookRequest{Name: "soft-book"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		resp, err := service.ListOrderBooks(ctx, &proto.ListOrderBooksRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, resp.OrderBooks)
+
+		resp, err = service.ListOrderBooks(ctx, &proto.ListOrderBooksRequest{IncludeDeleted: true})
+		require.NoError(t, err)
+		require.Len(t, resp.OrderBooks, 1)
+		assert.True(t, resp.OrderBooks[0].IsDeleted)
+		assert.NotNil(t, resp.OrderBooks[0].DeletedAt)
+	})
+
+	t.Run("OrdersRejectedWhileDeleted", func(t *testing.T) {
+		_, err := service.CreateOrder(ctx, orderReq("during-delete"))
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		_, err = service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "soft-book", OrderId: "before-delete"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("Undelete", func(t *testing.T) {
+		resp, err := service.UndeleteOrderBook(ctx, &proto.UndeleteRequest{Name: "soft-book"})
+		require.NoError(t, err)
+		assert.False(t, resp.OrderBook.IsDeleted)
+		assert.Nil(t, resp.OrderBook.DeletedAt)
+
+		// Data is retained across the soft delete
+		order, err := service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "soft-book", OrderId: "before-delete"})
+		require.NoError(t, err)
+		assert.Equal(t, "before-delete", order.OrderId)
+
+		_, err = service.CreateOrder(ctx, orderReq("after-undelete"))
+		require.NoError(t, err)
+
+		_, err = service.UndeleteOrderBook(ctx, &proto.UndeleteRequest{Name: "soft-book"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		_, err = service.UndeleteOrderBook(ctx, &proto.UndeleteRequest{Name: "missing-book"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("DeletedAtReadWhileDeleting", func(t *testing.T) {
+		infos := manager.ListOrderBooks(ctx, true)
+		require.Len(t, infos, 1)
+		info := infos[0]
+
+		// Readers of the returned info need no lock while the book is
+		// deleted and undeleted
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				_ = info.DeletedAt()
+			}
+		}()
+		for range 10 {
+			require.NoError(t, manager.DeleteOrderBook(ctx, "soft-book"))
+			assert.False(t, info.DeletedAt().IsZero())
+			_, err := manager.UndeleteOrderBook(ctx, "soft-book")
+			require.NoError(t, err)
+		}
+		wg.Wait()
+		assert.True(t, info.DeletedAt().IsZero())
+	})
+
+	t.Run("PurgeAfterRetention", func(t *testing.T) {
+		manager.SetRetentionPeriod(time.Hour)
+		require.NoError(t, manager.DeleteOrderBook(ctx, "soft-book"))
+
+		// Still within retention
+		assert.Equal(t, 0, manager.PurgeDeletedOrderBooks(ctx, time.Now()))
+		assert.Len(t, manager.ListOrderBooks(ctx, true), 1)
+
+		assert.Equal(t, 1, manager.PurgeDeletedOrderBooks(ctx, time.Now().Add(2*time.Hour)))
+		assert.Empty(t, manager.ListOrderBooks(ctx, true))
+
+		_, err := service.UndeleteOrderBook(ctx, &proto.UndeleteRequest{Name: "soft-book"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("BackgroundPurger", func(t *testing.T) {
+		_, err := manager.CreateMemoryOrderBook(ctx, "purged-book", "")
+		require.NoError(t, err)
+
+		manager.SetRetentionPeriod(10 * time.Millisecond)
+		manager.StartPurger(ctx, 5*time.Millisecond)
+		require.NoError(t, manager.DeleteOrderBook(ctx, "purged-book"))
+
+		assert.Eventually(t, func() bool {
+			return len(manager.ListOrderBooks(ctx, true)) == 0
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestWarmUpOrderBook(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "warm-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	resp, err := service.WarmUpOrderBook(ctx, &proto.WarmUpRequest{
+		BookName:    "warm-book",
+		NumLevels:   5,
+		BasePrice:   "100.0",
+		TickSize:    "0.5",
+		QtyPerLevel: "2.0",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(10), resp.OrdersCreated)
+	assert.NotNil(t, resp.Elapsed)
+
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "warm-book", Depth: 20})
+	require.NoError(t, err)
+	require.Len(t, state.Bids, 5)
+	require.Len(t, state.Asks, 5)
+
+	for _, level := range append(state.Bids, state.Asks...) {
+		assert.Equal(t, WarmUpUserAddress, level.UserAddress)
+		assert.Equal(t, fpdecimal.FromInt(2).String(), level.TotalQuantity)
+	}
+
+	// The book must not be crossed
+	bestBid, err := fpdecimal.FromString(state.Bids[0].Price)
+	require.NoError(t, err)
+	bestAsk, err := fpdecimal.FromString(state.Asks[0].Price)
+	require.NoError(t, err)
+	assert.True(t, bestBid.LessThan(bestAsk), "best bid %s should be below best ask %s", bestBid, bestAsk)
+	assert.Equal(t, "99.500", state.Bids[0].Price)
+	assert.Equal(t, "100.500", state.Asks[0].Price)
+
+	t.Run("InvalidRequests", func(t *testing.T) {
+		for name, req := range map[string]*proto.WarmUpRequest{
+			"ZeroLevels":   {BookName: "warm-book", NumLevels: 0, BasePrice: "100", TickSize: "1", QtyPerLevel: "1"},
+			"ZeroTick":     {BookName: "warm-book", NumLevels: 5, BasePrice: "100", TickSize: "0", QtyPerLevel: "1"},
+			"NegativeBids": {BookName: "warm-book", NumLevels: 5, BasePrice: "2", TickSize: "1", QtyPerLevel: "1"},
+		} {
+			_, err := service.WarmUpOrderBook(ctx, req)
+			st, _ := status.FromError(err)
+			assert.Equal(t, codes.InvalidArgument, st.Code(), name)
+		}
+
+		_, err := service.WarmUpOrderBook(ctx, &proto.WarmUpRequest{BookName: "missing", NumLevels: 1, BasePrice: "100", TickSize: "1", QtyPerLevel: "1"})
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.NotFound, st.Code())
+	})
+}
+
+func TestMaxOrderAgeSweep(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	manager.SetOrderBookPolicy(core.OrderBookPolicy{
+		MaxOrderAge:   100 * time.Millisecond,
+		SweepInterval: 10 * time.Millisecond,
+	})
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "aging-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "aging-book",
+		OrderId:       "stale-order",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "aging-book", Depth: 20})
+	require.NoError(t, err)
+	require.Len(t, state.Bids, 1)
+
+	time.Sleep(150 * time.Millisecond)
+
+	state, err = service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "aging-book", Depth: 20})
+	require.NoError(t, err)
+	assert.Empty(t, state.Bids, "order older than MaxOrderAge should have been swept")
+
+	_, err = service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "aging-book", OrderId: "stale-order"})
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+}
+
+// fieldViolations extracts the BadRequest field violations from a gRPC error as field -> description
+func fieldViolations(t *testing.T, err error) map[string]string {
+	t.Helper()
+	st, ok := status.FromError(err)
+	require.True(t, ok, "expected a gRPC status error, got %v", err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+
+	violations := make(map[string]string)
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range badRequest.FieldViolations {
+				violations[v.Field] = v.Description
+			}
+		}
+	}
+	return violations
+}
+
+func TestValidationErrorDetails(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "details-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	t.Run("CreateOrder", func(t *testing.T) {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "details-book",
+			OrderId:       "bad-order",
+			Side:          proto.OrderSide_BUY,
+			Quantity:      "-1",
+			Price:         "abc",
+			StopPrice:     "0",
+			OrderType:     proto.OrderType_STOP_LIMIT,
+		})
+		require.Error(t, err)
+
+		assert.Equal(t, map[string]string{
+			"quantity":   "must not be negative",
+			"price":      "must be a decimal number",
+			"stop_price": "must be positive",
+		}, fieldViolations(t, err))
+	})
+
+	t.Run("CreateOrderBook", func(t *testing.T) {
+		_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "", BackendType: proto.BackendType(99)})
+		require.Error(t, err)
+
+		violations := fieldViolations(t, err)
+		assert.Equal(t, "must not be empty", violations["name"])
+		assert.Contains(t, violations, "backend_type")
+	})
+}
+
+func TestCreateOrderBookConfig(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:        "config-book",
+		BackendType: proto.BackendType_MEMORY,
+		Instrument:  &proto.CreateOrderBookRequest_Instrument{MaxPriceDeviationPct: 2.5},
+		Policy:      &proto.CreateOrderBookRequest_Policy{MaxOrderAge: durationpb.New(time.Hour)},
+	})
+	require.NoError(t, err)
+
+	book, _, err := manager.GetOrderBook(ctx, "config-book")
+	require.NoError(t, err)
+	assert.Equal(t, core.InstrumentConfig{MaxPriceDeviationPct: 2.5}, book.InstrumentConfig())
+	assert.Equal(t, time.Hour, book.MaxOrderAge())
+
+	_, err = service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:        "bad-config-book",
+		BackendType: proto.BackendType_MEMORY,
+		Instrument:  &proto.CreateOrderBookRequest_Instrument{MaxPriceDeviationPct: -1},
+		Policy:      &proto.CreateOrderBookRequest_Policy{MaxOrderAge: durationpb.New(-time.Second)},
+	})
+	require.Error(t, err)
+	violations := fieldViolations(t, err)
+	assert.Contains(t, violations, "instrument.max_price_deviation_pct")
+	assert.Contains(t, violations, "policy.max_order_age")
+
+	_, err = service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:        "bad-precision-book",
+		BackendType: proto.BackendType_MEMORY,
+		Instrument:  &proto.CreateOrderBookRequest_Instrument{PricePrecision: -1, QtyPrecision: MaxPrecision + 1},
+	})
+	violations = fieldViolations(t, err)
+	assert.Equal(t, "must be between 0 and 18", violations["instrument.price_precision"])
+	assert.Equal(t, "must be between 0 and 18", violations["instrument.qty_precision"])
+
+	resp, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:        "fee-book",
+		BackendType: proto.BackendType_MEMORY,
+		MakerFeeBps: 2,
+		TakerFeeBps: 5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.MakerFeeBps)
+	assert.Equal(t, int32(5), resp.TakerFeeBps)
+	book, _, err = manager.GetOrderBook(ctx, "fee-book")
+	require.NoError(t, err)
+	assert.Equal(t, core.FeeSchedule{MakerFeeBps: 2, TakerFeeBps: 5}, book.FeeSchedule())
+
+	_, err = service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:        "bad-fee-book",
+		BackendType: proto.BackendType_MEMORY,
+		MakerFeeBps: -1,
+		TakerFeeBps: MaxFeeBps + 1,
+	})
+	violations = fieldViolations(t, err)
+	assert.Equal(t, "must be between 0 and 10000", violations["maker_fee_bps"])
+	assert.Equal(t, "must be between 0 and 10000", violations["taker_fee_bps"])
+}
+
+func TestGetOrderBookState_Precision(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:        "precision-book",
+		BackendType: proto.BackendType_MEMORY,
+		Instrument:  &proto.CreateOrderBookRequest_Instrument{PricePrecision: 8, QtyPrecision: 2},
+	})
+	require.NoError(t, err)
+
+	place := func(id, price string) error {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "precision-book",
+			OrderId:       id,
+			Side:          proto.OrderSide_BUY,
+			Quantity:      "1.5",
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		return err
+	}
+
+	// Prices are stored with 3 fraction digits whatever the display
+	// precision, so a price finer than that is not accepted
+	assert.Equal(t, codes.InvalidArgument, status.Code(place("too-fine", "0.00000001")))
+
+	require.NoError(t, place("bid-1", "0.001"))
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "precision-book"})
+	require.NoError(t, err)
+	require.Len(t, state.Bids, 1)
+	assert.Equal(t, "0.00100000", state.Bids[0].Price)
+	assert.Equal(t, "1.50", state.Bids[0].TotalQuantity)
+}
+
+func TestGetOrderBookState_EightDecimals(t *testing.T) {
+	// Prices keep as many fraction digits as fpdecimal stores, which is
+	// process-wide, so a satoshi price needs 8 of them
+	digits := fpdecimal.FractionDigits
+	fpdecimal.FractionDigits = 8
+	defer func() { fpdecimal.FractionDigits = digits }()
+
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:        "satoshi-book",
+		BackendType: proto.BackendType_MEMORY,
+		Instrument:  &proto.CreateOrderBookRequest_Instrument{PricePrecision: 8},
+	})
+	require.NoError(t, err)
+
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "satoshi-book",
+		OrderId:       "bid-1",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "2",
+		Price:         "0.00000001",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "satoshi-book"})
+	require.NoError(t, err)
+	require.Len(t, state.Bids, 1)
+	assert.Equal(t, "0.00000001", state.Bids[0].Price)
+	// Quantities keep the engine's precision, now 8 digits
+	assert.Equal(t, "2.00000000", state.Bids[0].TotalQuantity)
+}
+
+func TestResetOrderBook(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "reset-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	// 4 bids, 4 asks and 2 stop orders
+	for i := 0; i < 10; i++ {
+		req := &proto.CreateOrderRequest{
+			OrderBookName: "reset-book",
+			OrderId:       fmt.Sprintf("reset-order-%d", i),
+			Quantity:      "1.0",
+			OrderType:     proto.OrderType_LIMIT,
+		}
+		switch {
+		case i < 4:
+			req.Side = proto.OrderSide_BUY
+			req.Price = fmt.Sprintf("%d.0", 90+i)
+		case i < 8:
+			req.Side = proto.OrderSide_SELL
+			req.Price = fmt.Sprintf("%d.0", 110+i)
+		default:
+			req.Side = proto.OrderSide_SELL
+			req.OrderType = proto.OrderType_STOP_LIMIT
+			req.Price = "80.0"
+			req.StopPrice = "85.0"
+		}
+		_, err := service.CreateOrder(ctx, req)
+		require.NoError(t, err)
+	}
+
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "reset-book", Depth: 20})
+	require.NoError(t, err)
+	require.Len(t, state.Bids, 4)
+	require.Len(t, state.Asks, 4)
+
+	resp, err := service.ResetOrderBook(ctx, &proto.ResetOrderBookRequest{Name: "reset-book"})
+	require.NoError(t, err)
+	assert.Equal(t, "reset-book", resp.OrderBook.Name)
+	assert.Zero(t, resp.OrderBook.OrderCount)
+
+	state, err = service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "reset-book", Depth: 20})
+	require.NoError(t, err)
+	assert.Empty(t, state.Bids)
+	assert.Empty(t, state.Asks)
+
+	_, err = service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "reset-book", OrderId: "reset-order-9"})
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.NotFound, st.Code(), "stop orders should be removed too")
+
+	// The book is still registered
+	list, err := service.ListOrderBooks(ctx, &proto.ListOrderBooksRequest{})
+	require.NoError(t, err)
+	var names []string
+	for _, book := range list.OrderBooks {
+		names = append(names, book.Name)
+	}
+	assert.Contains(t, names, "reset-book")
+
+	_, err = service.ResetOrderBook(ctx, &proto.ResetOrderBookRequest{Name: "missing"})
+	st, _ = status.FromError(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+}
+
+func TestSimulateOrder(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "sim-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	// Asks: 1 @ 100, 2 @ 101, 3 @ 102
+	for i, qty := range []string{"1.0", "2.0", "3.0"} {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "sim-book",
+			OrderId:       fmt.Sprintf("ask-%d", i),
+			Side:          proto.OrderSide_SELL,
+			Quantity:      qty,
+			Price:         fmt.Sprintf("%d.0", 100+i),
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+	before, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "sim-book", Depth: 20})
+	require.NoError(t, err)
+	sender.ClearSentMessages()
+
+	resp, err := service.SimulateOrder(ctx, &proto.SimulateOrderRequest{
+		OrderBookName: "sim-book",
+		OrderId:       "big-buy",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "4.0",
+		OrderType:     proto.OrderType_MARKET,
+	})
+	require.NoError(t, err)
+
+	// 1 @ 100 + 2 @ 101 + 1 @ 102 = 404 for 4 units
+	assert.Equal(t, "4.000", resp.EstimatedFillQty)
+	assert.Equal(t, "101.000", resp.EstimatedAvgPrice)
+	assert.Equal(t, "100.000", resp.EstimatedSlippageBps)
+
+	matched := make(map[string]string)
+	for _, m := range resp.MatchedOrders {
+		matched[m.OrderId] = m.Quantity
+	}
+	assert.Equal(t, map[string]string{"ask-0": "1.000", "ask-1": "2.000", "ask-2": "1.000"}, matched)
+
+	// The real book is untouched and nothing was published
+	after, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "sim-book", Depth: 20})
+	require.NoError(t, err)
+	assert.Equal(t, before.Asks, after.Asks)
+	assert.Empty(t, after.Bids)
+	assert.Empty(t, sender.GetSentMessages())
+
+	_, err = service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "sim-book", OrderId: "big-buy"})
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+}
+
+func TestOrderStateInResponses(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "state-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	resting, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "state-book",
+		OrderId:       "ask",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "2.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, proto.OrderStatus_OPEN, resting.OrderState)
+
+	taker, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "state-book",
+		OrderId:       "bid",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, proto.OrderStatus_FILLED, taker.OrderState)
+
+	maker, err := service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "state-book", OrderId: "ask"})
+	require.NoError(t, err)
+	assert.Equal(t, proto.OrderStatus_PARTIALLY_FILLED, maker.OrderState)
+
+	ioc, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "state-book",
+		OrderId:       "ioc",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		Price:         "99.0",
+		OrderType:     proto.OrderType_LIMIT,
+		TimeInForce:   proto.TimeInForce_IOC,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, proto.OrderStatus_CANCELED, ioc.OrderState)
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "rid-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "rid-book",
+		OrderId:       "ask",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+
+	// Capture every log line written while the crossing order is processed
+	var buf bytes.Buffer
+	prevLogger, prevLevel := log.Logger, zerolog.GlobalLevel()
+	log.Logger = zerolog.New(&buf)
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	defer func() {
+		log.Logger = prevLogger
+		zerolog.SetGlobalLevel(prevLevel)
+	}()
+
+	resp, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "rid-book",
+		OrderId:       "bid",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+		RequestId:     "test-req-123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "test-req-123", resp.RequestId)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var messages []string
+	for _, line := range lines {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		assert.Equal(t, "test-req-123", entry["request_id"], line)
+		messages = append(messages, fmt.Sprint(entry["message"]))
+	}
+	// Service, matching engine and message sending all log with the ID
+	assert.Contains(t, messages, "Request received")
+	assert.Contains(t, messages, "Processing order")
+	assert.Contains(t, messages, "Sending done message")
+	assert.Contains(t, messages, "Order created")
+
+	// The ask was published under its own request ID when it came to rest
+	sent := sender.GetSentMessages()
+	require.Len(t, sent, 2)
+	assert.Equal(t, "test-req-123", sent[1].RequestID)
+
+	// Requests without an ID get a generated one
+	resp, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "rid-book",
+		OrderId:       "no-id",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		Price:         "99.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+	_, err = uuid.Parse(resp.RequestId)
+	assert.NoError(t, err)
+
+	// Without one in the request, the x-request-id metadata is used
+	mdCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(logging.RequestIDMetadataKey, "md-req-456"))
+	resp, err = service.CreateOrder(mdCtx, &proto.CreateOrderRequest{
+		OrderBookName: "rid-book",
+		OrderId:       "md-id",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		Price:         "98.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "md-req-456", resp.RequestId)
+	sent = sender.GetSentMessages()
+	assert.Equal(t, "md-req-456", sent[len(sent)-1].RequestID)
+}
+
+func TestCloseWithTimeout(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "close-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	book, _, err := manager.GetOrderBook(ctx, "close-book")
+	require.NoError(t, err)
+
+	// Submit 100 resting orders concurrently and close while they are in flight
+	const numOrders = 100
+	var started, finished sync.WaitGroup
+	var accepted, rejected atomic.Int64
+	started.Add(numOrders)
+	finished.Add(numOrders)
+	for i := 0; i < numOrders; i++ {
+		go func(i int) {
+			defer finished.Done()
+			started.Done()
+			_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+				OrderBookName: "close-book",
+				OrderId:       fmt.Sprintf("order-%d", i),
+				Side:          proto.OrderSide_BUY,
+				Quantity:      "1.0",
+				Price:         fmt.Sprintf("%d.0", 100+i),
+				OrderType:     proto.OrderType_LIMIT,
+			})
+			if err == nil {
+				accepted.Add(1)
+			} else {
+				// Orders that arrive during shutdown are refused, never half-processed
+				assert.Contains(t, []codes.Code{codes.Unavailable, codes.NotFound}, status.Code(err))
+				rejected.Add(1)
+			}
+		}(i)
+	}
+	started.Wait()
+
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, manager.CloseWithTimeout(closeCtx))
+
+	select {
+	case <-manager.Closed():
+	default:
+		t.Fatal("Closed channel was not closed")
+	}
+
+	// Every order Process admitted finished before CloseWithTimeout returned
+	snapshot := book.Snapshot()
+	finished.Wait()
+	assert.Equal(t, int64(numOrders), accepted.Load()+rejected.Load())
+	assert.Equal(t, int(accepted.Load()), len(snapshot.Bids))
+
+	// The book no longer accepts orders
+	order, err := core.NewLimitOrder("late", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(1), core.GTC, "", "test_user")
+	require.NoError(t, err)
+	_, err = book.Process(ctx, order)
+	assert.ErrorIs(t, err, core.ErrOrderBookClosed)
+}
+
+func TestConcurrentCreateOrderBook(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+
+	const numCreators = 100
+	var wg sync.WaitGroup
+	var created, exists atomic.Int64
+	start := make(chan struct{})
+	wg.Add(numCreators)
+	for i := 0; i < numCreators; i++ {
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := manager.CreateMemoryOrderBook(ctx, "race-test", "")
+			switch {
+			case err == nil:
+				created.Add(1)
+			case errors.Is(err, ErrOrderBookExists):
+				exists.Add(1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), created.Load())
+	assert.Equal(t, int64(numCreators-1), exists.Load())
+	assert.Len(t, manager.ListOrderBooks(ctx, false), 1)
+}
+
+func TestGetOrderBookStateDepth(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "deep-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	// 50 bid levels at 1..50
+	for i := 1; i <= 50; i++ {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "deep-book",
+			OrderId:       fmt.Sprintf("bid-%d", i),
+			Side:          proto.OrderSide_BUY,
+			Quantity:      "1.0",
+			Price:         fmt.Sprintf("%d.0", i),
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "deep-book", Depth: 10})
+	require.NoError(t, err)
+	require.Len(t, state.Bids, 10)
+	for i, level := range state.Bids {
+		// Best (highest) bid first
+		assert.Equal(t, fpdecimal.FromInt(50-i).String(), level.Price)
+	}
+	assert.Empty(t, state.Asks)
+
+	state, err = service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "deep-book"})
+	require.NoError(t, err)
+	assert.Len(t, state.Bids, DefaultStateDepth)
+
+	_, err = service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "deep-book", Depth: MaxStateDepth + 1})
+	assert.Equal(t, map[string]string{"depth": "must be at most 1000"}, fieldViolations(t, err))
+}
+
+func TestGetOrderBookStateBuckets(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "bucket-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	for i, o := range []struct {
+		side  proto.OrderSide
+		qty   string
+		price string
+	}{
+		{proto.OrderSide_BUY, "1", "99.5"},
+		{proto.OrderSide_BUY, "2", "98"},
+		{proto.OrderSide_BUY, "1", "98"},
+		{proto.OrderSide_BUY, "4", "94"},
+		{proto.OrderSide_BUY, "1", "80"},
+		{proto.OrderSide_SELL, "1", "100.5"},
+		{proto.OrderSide_SELL, "3", "104.9"},
+		{proto.OrderSide_SELL, "2", "105"},
+	} {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "bucket-book",
+			OrderId:       fmt.Sprintf("order-%d", i),
+			Side:          o.side,
+			Quantity:      o.qty,
+			Price:         o.price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+
+	levels := func(levels []*proto.PriceLevel) [][3]string {
+		out := make([][3]string, len(levels))
+		for i, level := range levels {
+			out[i] = [3]string{level.Price, level.TotalQuantity, fmt.Sprint(level.OrderCount)}
+		}
+		return out
+	}
+
+	// Depth counts buckets, best first
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "bucket-book", Depth: 2, BucketSize: "5"})
+	require.NoError(t, err)
+	assert.Equal(t, [][3]string{{"95.000", "4.000", "3"}, {"90.000", "4.000", "1"}}, levels(state.Bids))
+	assert.Equal(t, [][3]string{{"100.000", "4.000", "2"}, {"105.000", "2.000", "1"}}, levels(state.Asks))
+
+	// Without a bucket size every price is listed
+	state, err = service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "bucket-book"})
+	require.NoError(t, err)
+	assert.Len(t, state.Bids, 4)
+	assert.Len(t, state.Asks, 3)
+
+	_, err = service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "bucket-book", BucketSize: "-1"})
+	assert.Equal(t, map[string]string{"bucket_size": "must be positive"}, fieldViolations(t, err))
+}
+
+func TestGetDepthAtPrice(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "depth-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	const (
+		alice = "0x1111111111111111111111111111111111111111"
+		bob   = "0x2222222222222222222222222222222222222222"
+	)
+	for i, user := range []string{bob, alice, bob} {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "depth-book",
+			OrderId:       fmt.Sprintf("ask-%d", i),
+			Side:          proto.OrderSide_SELL,
+			Quantity:      "2.0",
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+			UserAddress:   user,
+		})
+		require.NoError(t, err)
+	}
+
+	resp, err := service.GetDepthAtPrice(ctx, &proto.GetDepthAtPriceRequest{OrderBookName: "depth-book", Side: proto.OrderSide_SELL, Price: "100"})
+	require.NoError(t, err)
+	assert.Equal(t, "100.000", resp.Price)
+	assert.Equal(t, "6.000", resp.TotalQuantity)
+	assert.Equal(t, int32(3), resp.OrderCount)
+	assert.Equal(t, []string{alice, bob}, resp.UserAddresses)
+
+	resp, err = service.GetDepthAtPrice(ctx, &proto.GetDepthAtPriceRequest{OrderBookName: "depth-book", Side: proto.OrderSide_BUY, Price: "100"})
+	require.NoError(t, err)
+	assert.Equal(t, fpdecimal.Zero.String(), resp.TotalQuantity)
+	assert.Zero(t, resp.OrderCount)
+	assert.Empty(t, resp.UserAddresses)
+
+	_, err = service.GetDepthAtPrice(ctx, &proto.GetDepthAtPriceRequest{OrderBookName: "depth-book", Price: "abc"})
+	assert.Equal(t, map[string]string{"price": "must be a decimal number"}, fieldViolations(t, err))
+
+	_, err = service.GetDepthAtPrice(ctx, &proto.GetDepthAtPriceRequest{OrderBookName: "missing", Price: "100"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestBatchCreateOrders(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "batch-create-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	bid := func(id, quantity string) *proto.CreateOrderRequest {
+		return &proto.CreateOrderRequest{
+			OrderBookName: "batch-create-book",
+			OrderId:       id,
+			Side:          proto.OrderSide_BUY,
+			Quantity:      quantity,
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+		}
+	}
+
+	t.Run("Atomic", func(t *testing.T) {
+		resp, err := service.BatchCreateOrders(ctx, &proto.BatchCreateOrdersRequest{
+			Orders: []*proto.CreateOrderRequest{bid("atomic-1", "1.0"), bid("atomic-2", "-1"), bid("atomic-1", "2.0")},
+			Atomic: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Orders, 3)
+		for _, order := range resp.Orders {
+			assert.Equal(t, proto.OrderStatus_REJECTED, order.Status)
+		}
+		require.Len(t, resp.Errors, 2)
+		assert.Equal(t, int32(1), resp.Errors[0].Index)
+		assert.Equal(t, int32(codes.InvalidArgument), resp.Errors[0].Code)
+		assert.Equal(t, int32(2), resp.Errors[1].Index)
+		assert.Equal(t, int32(codes.AlreadyExists), resp.Errors[1].Code)
+
+		_, err = service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "batch-create-book", OrderId: "atomic-1"})
+		assert.Equal(t, codes.NotFound, status.Code(err), "no order of a rejected atomic batch should be submitted")
+	})
+
+	t.Run("NotAtomic", func(t *testing.T) {
+		resp, err := service.BatchCreateOrders(ctx, &proto.BatchCreateOrdersRequest{
+			Orders: []*proto.CreateOrderRequest{bid("partial-1", "1.0"), bid("partial-2", "-1"), bid("partial-3", "2.0")},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Orders, 3)
+		assert.Equal(t, proto.OrderStatus_OPEN, resp.Orders[0].Status)
+		assert.Equal(t, proto.OrderStatus_REJECTED, resp.Orders[1].Status)
+		assert.Equal(t, proto.OrderStatus_OPEN, resp.Orders[2].Status)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, int32(1), resp.Errors[0].Index)
+		assert.Equal(t, "partial-2", resp.Errors[0].OrderId)
+	})
+
+	t.Run("TooMany", func(t *testing.T) {
+		orders := make([]*proto.CreateOrderRequest, MaxBatchCreateOrders+1)
+		for i := range orders {
+			orders[i] = bid(fmt.Sprintf("many-%d", i), "1.0")
+		}
+		_, err := service.BatchCreateOrders(ctx, &proto.BatchCreateOrdersRequest{Orders: orders})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestBatchGetOrders(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "batch-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	var orderIDs []string
+	for i := 0; i < 50; i++ {
+		orderID := fmt.Sprintf("ask-%d", i)
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "batch-book",
+			OrderId:       orderID,
+			Side:          proto.OrderSide_SELL,
+			Quantity:      "1.0",
+			Price:         fmt.Sprintf("%d", 100+i),
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+		orderIDs = append(orderIDs, orderID)
+	}
+	for i := 0; i < 10; i++ {
+		orderIDs = append(orderIDs, fmt.Sprintf("unknown-%d", i))
+	}
+
+	resp, err := service.BatchGetOrders(ctx, &proto.BatchGetOrdersRequest{OrderBookName: "batch-book", OrderIds: orderIDs})
+	require.NoError(t, err)
+	require.Len(t, resp.Orders, 60)
+
+	counts := map[proto.OrderStatus]int{}
+	for i, order := range resp.Orders {
+		assert.Equal(t, orderIDs[i], order.OrderId)
+		counts[order.Status]++
+		if order.Status == proto.OrderStatus_UNKNOWN {
+			assert.Equal(t, proto.OrderErrorCode_NOT_FOUND, order.ErrorCode)
+		} else {
+			assert.Equal(t, proto.OrderErrorCode_NO_ERROR, order.ErrorCode)
+		}
+	}
+	assert.Equal(t, map[proto.OrderStatus]int{proto.OrderStatus_OPEN: 50, proto.OrderStatus_UNKNOWN: 10}, counts)
+	assert.Equal(t, "149.000", resp.Orders[49].Price)
+
+	_, err = service.BatchGetOrders(ctx, &proto.BatchGetOrdersRequest{OrderBookName: "batch-book", OrderIds: make([]string, MaxBatchGetOrders+1)})
+	assert.Equal(t, map[string]string{"order_ids": "must contain at most 500 IDs"}, fieldViolations(t, err))
+
+	_, err = service.BatchGetOrders(ctx, &proto.BatchGetOrdersRequest{OrderBookName: "missing", OrderIds: orderIDs})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestListStopOrders(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "stop-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	const user = "0x1111111111111111111111111111111111111111"
+	place := func(id string, side proto.OrderSide, stopPrice, price int) {
+		t.Helper()
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "stop-book",
+			OrderId:       id,
+			Side:          side,
+			Quantity:      "1.0",
+			Price:         fmt.Sprintf("%d.0", price),
+			StopPrice:     fmt.Sprintf("%d.0", stopPrice),
+			OrderType:     proto.OrderType_STOP_LIMIT,
+			UserAddress:   user,
+		})
+		require.NoError(t, err)
+	}
+
+	// Set a last trade price of 100 so neither side's stops trigger
+	for _, side := range []proto.OrderSide{proto.OrderSide_SELL, proto.OrderSide_BUY} {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "stop-book",
+			OrderId:       "trade-" + side.String(),
+			Side:          side,
+			Quantity:      "1.0",
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+	for i := 0; i < 5; i++ {
+		place(fmt.Sprintf("buy-stop-%d", i), proto.OrderSide_BUY, 115-i, 120)
+	}
+	for i := 0; i < 3; i++ {
+		place(fmt.Sprintf("sell-stop-%d", i), proto.OrderSide_SELL, 90-i, 85)
+	}
+
+	resp, err := service.ListStopOrders(ctx, &proto.ListStopOrdersRequest{OrderBookName: "stop-book"})
+	require.NoError(t, err)
+	require.Len(t, resp.StopOrders, 8)
+	assert.Equal(t, int32(8), resp.Total)
+	first := resp.StopOrders[0]
+	assert.Equal(t, "buy-stop-4", first.OrderId, "lowest buy trigger first")
+	assert.Equal(t, proto.OrderSide_BUY, first.Side)
+	assert.Equal(t, "111.000", first.TriggerPrice)
+	assert.Equal(t, "120.000", first.LimitPrice)
+	assert.Equal(t, "1.000", first.Quantity)
+	assert.Equal(t, user, first.UserAddress)
+	assert.NotNil(t, first.CreatedAt)
+	assert.Equal(t, proto.OrderSide_SELL, resp.StopOrders[5].Side)
+
+	resp, err = service.ListStopOrders(ctx, &proto.ListStopOrdersRequest{OrderBookName: "stop-book", Side: proto.OrderSide_BUY.Enum()})
+	require.NoError(t, err)
+	assert.Len(t, resp.StopOrders, 5)
+	assert.Equal(t, int32(5), resp.Total)
+
+	resp, err = service.ListStopOrders(ctx, &proto.ListStopOrdersRequest{OrderBookName: "stop-book", Limit: 3})
+	require.NoError(t, err)
+	assert.Len(t, resp.StopOrders, 3)
+	assert.Equal(t, int32(8), resp.Total)
+
+	resp, err = service.ListStopOrders(ctx, &proto.ListStopOrdersRequest{OrderBookName: "stop-book", Limit: 3, Offset: 6})
+	require.NoError(t, err)
+	assert.Len(t, resp.StopOrders, 2)
+
+	_, err = service.ListStopOrders(ctx, &proto.ListStopOrdersRequest{OrderBookName: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestCreateOrderStopStatus(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "stop-status", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	create := func(id string, side proto.OrderSide, orderType proto.OrderType, price, stopPrice string) *proto.OrderResponse {
+		t.Helper()
+		resp, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "stop-status",
+			OrderId:       id,
+			Side:          side,
+			Quantity:      "1.0",
+			Price:         price,
+			StopPrice:     stopPrice,
+			OrderType:     orderType,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	getStatus := func(id string) proto.OrderStatus {
+		t.Helper()
+		resp, err := service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "stop-status", OrderId: id})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	// Trade at 100, below the stop price
+	create("ask-100", proto.OrderSide_SELL, proto.OrderType_LIMIT, "100.0", "")
+	create("bid-100", proto.OrderSide_BUY, proto.OrderType_LIMIT, "100.0", "")
+
+	resp := create("stop-buy", proto.OrderSide_BUY, proto.OrderType_STOP_LIMIT, "104.0", "105.0")
+	assert.Equal(t, proto.OrderStatus_PENDING, resp.Status)
+	assert.Equal(t, proto.OrderStatus_PENDING, getStatus("stop-buy"))
+
+	// A trade at 105 triggers the stop, which rests below the remaining asks
+	create("ask-105", proto.OrderSide_SELL, proto.OrderType_LIMIT, "105.0", "")
+	create("ask-106", proto.OrderSide_SELL, proto.OrderType_LIMIT, "106.0", "")
+	create("bid-105", proto.OrderSide_BUY, proto.OrderType_LIMIT, "105.0", "")
+	assert.Equal(t, proto.OrderStatus_OPEN, getStatus("stop-buy"))
+
+	// A stop already crossed by the last trade triggers on arrival
+	resp = create("stop-fill", proto.OrderSide_BUY, proto.OrderType_STOP_LIMIT, "106.0", "100.0")
+	assert.Equal(t, proto.OrderStatus_FILLED, resp.Status)
+	assert.Equal(t, "1.000", resp.FilledQuantity)
+	// The entry marking the activation is not reported as a fill
+	require.NotEmpty(t, resp.Fills)
+	for _, fill := range resp.Fills {
+		assert.Equal(t, "1.000", fill.Quantity)
+	}
+}
+
+func TestCreateOrderClientOrderID(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "idempotent-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "idempotent-book",
+		OrderId:       "ask",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "2.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+
+	// A client retries its buy while the first attempt may still be in
+	// flight; each attempt carries a fresh order ID but the same client order ID
+	const attempts = 2
+	var wg sync.WaitGroup
+	responses := make([]*proto.OrderResponse, attempts)
+	start := make(chan struct{})
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			resp, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+				OrderBookName: "idempotent-book",
+				OrderId:       fmt.Sprintf("buy-%d", i),
+				ClientOrderId: "client-buy",
+				Side:          proto.OrderSide_BUY,
+				Quantity:      "1.0",
+				Price:         "100.0",
+				OrderType:     proto.OrderType_LIMIT,
+			})
+			assert.NoError(t, err)
+			responses[i] = resp
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	require.NotNil(t, responses[0])
+	require.NotNil(t, responses[1])
+	assert.Equal(t, responses[0].OrderId, responses[1].OrderId)
+	assert.Equal(t, "client-buy", responses[0].ClientOrderId)
+
+	// Exactly one of the attempts traded with the ask
+	book, _, err := manager.GetOrderBook(ctx, "idempotent-book")
+	require.NoError(t, err)
+	assert.Equal(t, fpdecimal.FromInt(1), book.GetOrder("ask").Quantity())
+
+	// The order keeps its client order ID
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "idempotent-book",
+		OrderId:       "rest",
+		ClientOrderId: "client-rest",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		Price:         "90.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+	got, err := service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "idempotent-book", OrderId: "rest"})
+	require.NoError(t, err)
+	assert.Equal(t, "client-rest", got.ClientOrderId)
+}
+
+func TestRouteOrder(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	asks := map[string]struct{ quantity, price string }{
+		"route-a": {"5.0", "100.0"},
+		"route-b": {"10.0", "101.0"},
+	}
+	for name, ask := range asks {
+		_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: name, BackendType: proto.BackendType_MEMORY})
+		require.NoError(t, err)
+		_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: name,
+			OrderId:       "ask-" + name,
+			Side:          proto.OrderSide_SELL,
+			Quantity:      ask.quantity,
+			Price:         ask.price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+
+	resp, err := service.RouteOrder(ctx, &proto.RouteOrderRequest{
+		OrderBookNames: []string{"route-b", "route-a"},
+		OrderId:        "buy-1",
+		Side:           proto.OrderSide_BUY,
+		Quantity:       "12.0",
+		OrderType:      proto.OrderType_MARKET,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "12.000", resp.ExecutedQuantity)
+	require.Len(t, resp.Orders, 2)
+	assert.Equal(t, "route-a", resp.Orders[0].OrderBookName)
+	assert.Equal(t, "buy-1-route-a", resp.Orders[0].OrderId)
+	assert.Equal(t, "5.000", resp.Orders[0].ExecutedQuantity)
+	assert.Equal(t, "route-b", resp.Orders[1].OrderBookName)
+	assert.Equal(t, "7.000", resp.Orders[1].ExecutedQuantity)
+
+	_, err = service.RouteOrder(ctx, &proto.RouteOrderRequest{OrderId: "buy-2", Side: proto.OrderSide_BUY, Quantity: "1.0", OrderType: proto.OrderType_STOP_LIMIT})
+	assert.Equal(t, map[string]string{
+		"order_book_names": "must not be empty",
+		"order_type":       "must be MARKET or LIMIT",
+	}, fieldViolations(t, err))
+
+	_, err = service.RouteOrder(ctx, &proto.RouteOrderRequest{
+		OrderBookNames: []string{"route-a"},
+		OrderId:        "buy-3",
+		Side:           proto.OrderSide_BUY,
+		Quantity:       "1.0",
+		OrderType:      proto.OrderType_MARKET,
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = service.RouteOrder(ctx, &proto.RouteOrderRequest{
+		OrderBookNames: []string{"missing"},
+		OrderId:        "buy-4",
+		Side:           proto.OrderSide_BUY,
+		Quantity:       "1.0",
+		OrderType:      proto.OrderType_MARKET,
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = service.RouteOrder(ctx, &proto.RouteOrderRequest{
+		OrderBookNames: []string{"route-a", "route-a"},
+		OrderId:        "buy-5",
+		Side:           proto.OrderSide_BUY,
+		Quantity:       "1.0",
+		OrderType:      proto.OrderType_LIMIT,
+		Price:          "100.0",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// The child order of a book processed before another fails is reported
+	// with the failure
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "route-a",
+		OrderId:       "ask-again",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+	halted, _, err := manager.GetOrderBook(ctx, "route-b")
+	require.NoError(t, err)
+	halted.SetHalted(true)
+	resp, err = service.RouteOrder(ctx, &proto.RouteOrderRequest{
+		OrderBookNames: []string{"route-a", "route-b"},
+		OrderId:        "buy-6",
+		Side:           proto.OrderSide_BUY,
+		Quantity:       "3.0",
+		OrderType:      proto.OrderType_MARKET,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, resp.Error, "market halted")
+	require.Len(t, resp.Orders, 1)
+	assert.Equal(t, "buy-6-route-a", resp.Orders[0].OrderId)
+	assert.Equal(t, "1.000", resp.ExecutedQuantity)
+}
+
+func TestCreateOrderPriceDeviation(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "deviation-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	book, _, err := manager.GetOrderBook(ctx, "deviation-book")
+	require.NoError(t, err)
+	book.SetInstrumentConfig(core.InstrumentConfig{MaxPriceDeviationPct: 10})
+
+	for i, price := range []string{"100.0", "150.0"} {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "deviation-book",
+			OrderId:       fmt.Sprintf("ask-%d", i),
+			Side:          proto.OrderSide_SELL,
+			Quantity:      "1.0",
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "deviation-book",
+		OrderId:       "buy-1",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		OrderType:     proto.OrderType_MARKET,
+	})
+	require.NoError(t, err)
+
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "deviation-book",
+		OrderId:       "buy-2",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		OrderType:     proto.OrderType_MARKET,
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestCreateOrderFOKMarket(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "fok-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "fok-book",
+		OrderId:       "ask-1",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "2.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+
+	resp, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "fok-book",
+		OrderId:       "buy-fok",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "3.0",
+		OrderType:     proto.OrderType_MARKET,
+		TimeInForce:   proto.TimeInForce_FOK,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "0", resp.FilledQuantity)
+	assert.Equal(t, "3.000", resp.RemainingQuantity)
+
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "fok-book", Depth: 20})
+	require.NoError(t, err)
+	require.Len(t, state.Asks, 1)
+	assert.Equal(t, "2.000", state.Asks[0].TotalQuantity)
+}
+
+func TestCreateOrderQuoteMarket(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "quote-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "quote-book",
+		OrderId:       "ask-1",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "2.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+
+	// 50 in the quote currency buys half of the ask
+	resp, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "quote-book",
+		OrderId:       "buy-quote",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "50",
+		OrderType:     proto.OrderType_MARKET,
+		IsQuote:       true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "0.500", resp.FilledQuantity)
+	assert.Equal(t, proto.OrderStatus_FILLED, resp.Status, "the whole budget is spent")
+
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "quote-book", Depth: 20})
+	require.NoError(t, err)
+	require.Len(t, state.Asks, 1)
+	assert.Equal(t, "1.500", state.Asks[0].TotalQuantity)
+
+	// 200 buys the 1.5 left for 150, leaving 50 of the budget unspent
+	resp, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "quote-book",
+		OrderId:       "buy-quote-2",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "200",
+		OrderType:     proto.OrderType_MARKET,
+		IsQuote:       true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1.500", resp.FilledQuantity)
+	assert.Equal(t, "50.000", resp.RemainingQuantity)
+	assert.Equal(t, proto.OrderStatus_PARTIALLY_FILLED, resp.Status)
+
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "quote-book",
+		OrderId:       "bid-quote",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "50",
+		Price:         "90",
+		OrderType:     proto.OrderType_LIMIT,
+		IsQuote:       true,
+	})
+	assert.Equal(t, map[string]string{"is_quote": "is only supported for MARKET orders"}, fieldViolations(t, err))
+}
+
+func TestCreateOrderPostOnly(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "post-only-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "post-only-book",
+		OrderId:       "bid-1",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+
+	sell := func(id, price string) *proto.CreateOrderRequest {
+		return &proto.CreateOrderRequest{
+			OrderBookName: "post-only-book",
+			OrderId:       id,
+			Side:          proto.OrderSide_SELL,
+			Quantity:      "1.0",
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+			PostOnly:      true,
+		}
+	}
+
+	_, err = service.CreateOrder(ctx, sell("sell-1", "100.0"))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	resp, err := service.CreateOrder(ctx, sell("sell-2", "101.0"))
+	require.NoError(t, err)
+	assert.Equal(t, proto.OrderStatus_OPEN, resp.Status)
+
+	ioc := sell("sell-3", "101.0")
+	ioc.TimeInForce = proto.TimeInForce_IOC
+	_, err = service.CreateOrder(ctx, ioc)
+	assert.Equal(t, map[string]string{"post_only": "requires GTC time in force"}, fieldViolations(t, err))
+
+	market := sell("sell-4", "")
+	market.OrderType = proto.OrderType_MARKET
+	_, err = service.CreateOrder(ctx, market)
+	assert.Equal(t, map[string]string{"post_only": "is only supported for LIMIT orders"}, fieldViolations(t, err))
+}
+
+func TestCreateOrderCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	resp, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:           "breaker-book",
+		BackendType:    proto.BackendType_MEMORY,
+		CircuitBreaker: &proto.CreateOrderBookRequest_CircuitBreaker{MaxPriceMovePct: 5, CooldownSeconds: 60},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, resp.CircuitBreaker.GetMaxPriceMovePct())
+	assert.Equal(t, int32(60), resp.CircuitBreaker.GetCooldownSeconds())
+
+	create := func(id string, side proto.OrderSide, price string) error {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "breaker-book",
+			OrderId:       id,
+			Side:          side,
+			Quantity:      "1.0",
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		return err
+	}
+	require.NoError(t, create("ask-1", proto.OrderSide_SELL, "100.0"))
+	require.NoError(t, create("bid-1", proto.OrderSide_BUY, "100.0"))
+	require.NoError(t, create("ask-2", proto.OrderSide_SELL, "110.0"))
+	require.NoError(t, create("bid-2", proto.OrderSide_BUY, "110.0"))
+
+	messages := sender.GetSentMessages()
+	require.NotEmpty(t, messages)
+	assert.NotEmpty(t, messages[len(messages)-1].HaltReason)
+
+	err = create("bid-3", proto.OrderSide_BUY, "90.0")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.ErrorContains(t, err, core.ErrMarketHalted.Error())
+
+	_, err = service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:           "bad-breaker-book",
+		BackendType:    proto.BackendType_MEMORY,
+		CircuitBreaker: &proto.CreateOrderBookRequest_CircuitBreaker{MaxPriceMovePct: -1, CooldownSeconds: -1},
+	})
+	assert.Equal(t, map[string]string{
+		"circuit_breaker.max_price_move_pct": "must not be negative",
+		"circuit_breaker.cooldown_seconds":   "must not be negative",
+	}, fieldViolations(t, err))
+}
+
+func TestCreateOrderGTD(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	manager.SetOrderBookPolicy(core.OrderBookPolicy{SweepInterval: 10 * time.Millisecond})
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "gtd-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	expiresAt := timestamppb.New(time.Now().Add(100 * time.Millisecond))
+	gtd := func(id string) *proto.CreateOrderRequest {
+		return &proto.CreateOrderRequest{
+			OrderBookName: "gtd-book",
+			OrderId:       id,
+			Side:          proto.OrderSide_BUY,
+			Quantity:      "1.0",
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+			TimeInForce:   proto.TimeInForce_GTD,
+			GtdExpiresAt:  expiresAt,
+		}
+	}
+	resp, err := service.CreateOrder(ctx, gtd("bid-1"))
+	require.NoError(t, err)
+	assert.Equal(t, proto.TimeInForce_GTD, resp.TimeInForce)
+
+	got, err := service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "gtd-book", OrderId: "bid-1"})
+	require.NoError(t, err)
+	assert.Equal(t, proto.TimeInForce_GTD, got.TimeInForce)
+	assert.True(t, expiresAt.AsTime().Equal(got.GtdExpiresAt.AsTime()))
+
+	// The book's expiry worker cancels the order once it expires
+	assert.Eventually(t, func() bool {
+		_, err := service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "gtd-book", OrderId: "bid-1"})
+		return status.Code(err) == codes.NotFound
+	}, 2*time.Second, 10*time.Millisecond)
+	// The order was published when it came to rest and again when it expired
+	messages := sender.GetSentMessages()
+	require.Len(t, messages, 2)
+	assert.True(t, messages[0].Stored)
+	require.NotNil(t, messages[1].Cancel)
+	assert.Equal(t, messaging.CancelReasonExpired, messages[1].Cancel.CancelReason)
+
+	missing := gtd("bid-2")
+	missing.GtdExpiresAt = nil
+	_, err = service.CreateOrder(ctx, missing)
+	assert.Equal(t, map[string]string{"gtd_expires_at": "is required for GTD orders"}, fieldViolations(t, err))
+
+	gtc := gtd("bid-3")
+	gtc.TimeInForce = proto.TimeInForce_GTC
+	_, err = service.CreateOrder(ctx, gtc)
+	assert.Equal(t, map[string]string{"gtd_expires_at": "is only supported for GTD orders"}, fieldViolations(t, err))
+
+	market := gtd("bid-4")
+	market.OrderType = proto.OrderType_MARKET
+	_, err = service.CreateOrder(ctx, market)
+	assert.Equal(t, map[string]string{"time_in_force": "GTD is only supported for LIMIT orders"}, fieldViolations(t, err))
+}
+
+func TestCreateOrderSelfTradePrevention(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	resp, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:        "stp-book",
+		BackendType: proto.BackendType_MEMORY,
+		StpMode:     proto.STPMode_STP_CANCEL_TAKER,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, proto.STPMode_STP_CANCEL_TAKER, resp.StpMode)
+	_, info, err := manager.GetOrderBook(ctx, "stp-book")
+	require.NoError(t, err)
+	assert.Equal(t, core.STPCancelTaker, info.STPMode)
+
+	const user = "0x1234567890abcdef1234567890abcdef12345678"
+	order := func(id string, side proto.OrderSide) *proto.CreateOrderRequest {
+		return &proto.CreateOrderRequest{
+			OrderBookName: "stp-book",
+			OrderId:       id,
+			Side:          side,
+			Quantity:      "1.0",
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+			UserAddress:   user,
+		}
+	}
+	_, err = service.CreateOrder(ctx, order("ask", proto.OrderSide_SELL))
+	require.NoError(t, err)
+	created, err := service.CreateOrder(ctx, order("bid", proto.OrderSide_BUY))
+	require.NoError(t, err)
+	assert.Equal(t, "0", created.FilledQuantity)
+
+	messages := sender.GetSentMessages()
+	require.NotEmpty(t, messages)
+	assert.True(t, messages[len(messages)-1].STPTriggered)
+	assert.Equal(t, []string{"bid"}, messages[len(messages)-1].Canceled)
+	_, err = service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "stp-book", OrderId: "bid"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "the incoming order is canceled")
+	_, err = service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "stp-book", OrderId: "ask"})
+	assert.NoError(t, err, "the resting order is kept")
+
+	// An iceberg order carries its user address too
+	iceberg := order("iceberg-bid", proto.OrderSide_BUY)
+	iceberg.OrderType = proto.OrderType_ICEBERG
+	iceberg.VisibleQuantity = "0.5"
+	created, err = service.CreateOrder(ctx, iceberg)
+	require.NoError(t, err)
+	assert.Equal(t, "0", created.FilledQuantity)
+	messages = sender.GetSentMessages()
+	assert.True(t, messages[len(messages)-1].STPTriggered)
+	assert.Equal(t, []string{"iceberg-bid"}, messages[len(messages)-1].Canceled)
+
+	_, err = service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:        "bad-stp-book",
+		BackendType: proto.BackendType_MEMORY,
+		StpMode:     proto.STPMode(99),
+	})
+	assert.Equal(t, map[string]string{"stp_mode": "unknown mode 99"}, fieldViolations(t, err))
+}
+
+func TestCreateOrderIceberg(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "iceberg-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	iceberg := func(id, visible string) *proto.CreateOrderRequest {
+		return &proto.CreateOrderRequest{
+			OrderBookName:   "iceberg-book",
+			OrderId:         id,
+			Side:            proto.OrderSide_SELL,
+			Quantity:        "10.0",
+			Price:           "100.0",
+			OrderType:       proto.OrderType_ICEBERG,
+			VisibleQuantity: visible,
+		}
+	}
+
+	_, err = service.CreateOrder(ctx, iceberg("too-visible", "11.0"))
+	assert.Equal(t, map[string]string{"visible_quantity": "must not exceed quantity"}, fieldViolations(t, err))
+	_, err = service.CreateOrder(ctx, iceberg("no-visible", ""))
+	assert.Contains(t, fieldViolations(t, err), "visible_quantity")
+
+	resp, err := service.CreateOrder(ctx, iceberg("ice-1", "3.0"))
+	require.NoError(t, err)
+	assert.Equal(t, proto.OrderStatus_OPEN, resp.Status)
+
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "iceberg-book", Depth: 20})
+	require.NoError(t, err)
+	require.Len(t, state.Asks, 1)
+	assert.Equal(t, "3.000", state.Asks[0].TotalQuantity)
+
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "iceberg-book",
+		OrderId:       "buy-1",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "4.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+		TimeInForce:   proto.TimeInForce_IOC,
+	})
+	require.NoError(t, err)
+
+	order, err := service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "iceberg-book", OrderId: "ice-1"})
+	require.NoError(t, err)
+	assert.Equal(t, proto.OrderType_ICEBERG, order.OrderType)
+	assert.Equal(t, "6.000", order.RemainingQuantity)
+	assert.Equal(t, "4.000", order.FilledQuantity)
+}
+
+func TestCreateOrderMatchingTimeout(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+	// Expired before matching starts, so only the first maker is filled
+	service.SetMatchingTimeout(time.Nanosecond)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "timeout-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "timeout-book",
+			OrderId:       fmt.Sprintf("ask-%d", i),
+			Side:          proto.OrderSide_SELL,
+			Quantity:      "1.0",
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+
+	resp, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "timeout-book",
+		OrderId:       "buy-1",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "3.0",
+		OrderType:     proto.OrderType_MARKET,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1.000", resp.FilledQuantity)
+	assert.Equal(t, "2.000", resp.RemainingQuantity)
+	assert.Greater(t, resp.ProcessingTimeMs, 0.0, "a match cut short is still timed")
+
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "timeout-book", Depth: 10})
+	require.NoError(t, err)
+	require.Len(t, state.Asks, 1)
+	assert.Equal(t, int32(2), state.Asks[0].OrderCount)
+	assert.Empty(t, state.Bids)
+}
+
+// watchStream collects the events WatchOrderBook sends
+type watchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *proto.OrderBookEvent
+}
+
+func (s *watchStream) Context() context.Context { return s.ctx }
+
+func (s *watchStream) Send(event *proto.OrderBookEvent) error {
+	s.events <- event
+	return nil
+}
+
+func TestWatchOrderBook(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "watch-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	err = service.WatchOrderBook(&proto.WatchOrderBookRequest{OrderBookName: "missing"}, &watchStream{ctx: ctx})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	stream := &watchStream{ctx: watchCtx, events: make(chan *proto.OrderBookEvent, 16)}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- service.WatchOrderBook(&proto.WatchOrderBookRequest{
+			OrderBookName: "watch-book",
+			EventTypes:    []proto.OrderBookEventType{proto.OrderBookEventType_TRADE, proto.OrderBookEventType_CANCEL},
+		}, stream)
+	}()
+	require.Eventually(t, func() bool {
+		service.events.mu.Lock()
+		defer service.events.mu.Unlock()
+		return len(service.events.watchers["watch-book"]) == 1
+	}, time.Second, time.Millisecond)
+
+	orders := []*proto.CreateOrderRequest{
+		{OrderId: "ask-1", Side: proto.OrderSide_SELL, Quantity: "2.0", Price: "100.0", OrderType: proto.OrderType_LIMIT},
+		{OrderId: "ask-2", Side: proto.OrderSide_SELL, Quantity: "1.0", Price: "101.0", OrderType: proto.OrderType_LIMIT},
+		{OrderId: "buy-1", Side: proto.OrderSide_BUY, Quantity: "1.0", OrderType: proto.OrderType_MARKET},
+	}
+	for _, req := range orders {
+		req.OrderBookName = "watch-book"
+		_, err := service.CreateOrder(ctx, req)
+		require.NoError(t, err)
+	}
+	_, err = service.CancelOrder(ctx, &proto.CancelOrderRequest{OrderBookName: "watch-book", OrderId: "ask-2"})
+	require.NoError(t, err)
+
+	// ADD events for the asks are filtered out
+	trade := <-stream.events
+	assert.Equal(t, proto.OrderBookEventType_TRADE, trade.Type)
+	assert.Equal(t, "buy-1", trade.OrderId)
+	assert.Equal(t, "ask-1", trade.MakerOrderId)
+	assert.Equal(t, "100.000", trade.Price)
+	assert.Equal(t, "1.000", trade.Quantity)
+
+	canceled := <-stream.events
+	assert.Equal(t, proto.OrderBookEventType_CANCEL, canceled.Type)
+	assert.Equal(t, "ask-2", canceled.OrderId)
+	assert.Equal(t, proto.OrderSide_SELL, canceled.Side)
+
+	cancel()
+	require.NoError(t, <-errs)
+	assert.Empty(t, stream.events)
+}
+
+// tradeStream collects the trades SubscribeTrades sends
+type tradeStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	trades chan *proto.TradeEvent
+}
+
+func (s *tradeStream) Context() context.Context { return s.ctx }
+
+func (s *tradeStream) Send(trade *proto.TradeEvent) error {
+	s.trades <- trade
+	return nil
+}
+
+func TestSubscribeTrades(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "trade-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	err = service.SubscribeTrades(&proto.SubscribeTradesRequest{OrderBookName: "missing"}, &tradeStream{ctx: ctx})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	subscribeCtx, cancel := context.WithCancel(ctx)
+	stream := &tradeStream{ctx: subscribeCtx, trades: make(chan *proto.TradeEvent, 16)}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- service.SubscribeTrades(&proto.SubscribeTradesRequest{OrderBookName: "trade-book"}, stream)
+	}()
+	require.Eventually(t, func() bool {
+		service.events.mu.Lock()
+		defer service.events.mu.Unlock()
+		return len(service.events.watchers["trade-book"]) == 1
+	}, time.Second, time.Millisecond)
+
+	orders := []*proto.CreateOrderRequest{
+		{OrderId: "ask-1", Side: proto.OrderSide_SELL, Quantity: "1.0", Price: "100.0", OrderType: proto.OrderType_LIMIT},
+		{OrderId: "ask-2", Side: proto.OrderSide_SELL, Quantity: "1.0", Price: "101.0", OrderType: proto.OrderType_LIMIT},
+		{OrderId: "buy-1", Side: proto.OrderSide_BUY, Quantity: "1.5", OrderType: proto.OrderType_MARKET},
+	}
+	for _, req := range orders {
+		req.OrderBookName = "trade-book"
+		_, err := service.CreateOrder(ctx, req)
+		require.NoError(t, err)
+	}
+
+	// The market order matched both asks; resting orders make no trades
+	for _, want := range []struct{ maker, price, quantity string }{
+		{"ask-1", "100.000", "1.000"},
+		{"ask-2", "101.000", "0.500"},
+	} {
+		trade := <-stream.trades
+		assert.Equal(t, "trade-book", trade.OrderBookName)
+		assert.Equal(t, want.maker, trade.MakerOrderId)
+		assert.Equal(t, "buy-1", trade.TakerOrderId)
+		assert.Equal(t, want.price, trade.Price)
+		assert.Equal(t, want.quantity, trade.Quantity)
+		assert.Equal(t, proto.OrderSide_BUY, trade.Side)
+		assert.NotNil(t, trade.Timestamp)
+	}
+
+	cancel()
+	require.NoError(t, <-errs)
+	assert.Empty(t, stream.trades)
+	assert.Empty(t, service.events.watchers)
+}
+
+func TestEventBrokerDropsSlowWatchers(t *testing.T) {
+	ctx := context.Background()
+	broker := newEventBroker()
+	trades := subscription{types: []proto.OrderBookEventType{proto.OrderBookEventType_TRADE}, dropSlow: true}
+	slow, unsubscribeSlow := broker.subscribe("book", trades)
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := broker.subscribe("book", trades)
+	defer unsubscribeFast()
+	lossy, unsubscribeLossy := broker.subscribe("book", subscription{})
+	defer unsubscribeLossy()
+
+	trade := &proto.OrderBookEvent{Type: proto.OrderBookEventType_TRADE, OrderBookName: "book"}
+	add := &proto.OrderBookEvent{Type: proto.OrderBookEventType_ADD, OrderBookName: "book"}
+	for i := 0; i < watcherBufferSize; i++ {
+		// Events of types a watcher did not ask for take no room
+		broker.publish(ctx, "book", add, trade)
+		<-fast
+	}
+	require.Len(t, slow, watcherBufferSize)
+	require.Len(t, lossy, watcherBufferSize)
+
+	// One more trade does not fit, so the slow subscriber is dropped after
+	// the trades it already has, while the other watcher misses the event
+	broker.publish(ctx, "book", trade)
+	for i := 0; i < watcherBufferSize; i++ {
+		<-slow
+	}
+	_, ok := <-slow
+	assert.False(t, ok)
+	assert.Equal(t, trade, <-fast)
+	assert.Len(t, lossy, watcherBufferSize)
+	assert.Len(t, broker.watchers["book"], 2)
+}
+
+func TestCalculateVWAPAndTWAP(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "vwap-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	twap, err := service.CalculateTWAP(ctx, &proto.TWAPRequest{OrderBookName: "vwap-book", WindowSeconds: 60})
+	require.NoError(t, err)
+	assert.Equal(t, "0.000", twap.Twap)
+	assert.Zero(t, twap.TradeCount)
+
+	orders := []*proto.CreateOrderRequest{
+		{OrderId: "ask-1", Side: proto.OrderSide_SELL, Quantity: "1.0", Price: "100.0", OrderType: proto.OrderType_LIMIT},
+		{OrderId: "ask-2", Side: proto.OrderSide_SELL, Quantity: "3.0", Price: "104.0", OrderType: proto.OrderType_LIMIT},
+		{OrderId: "buy-1", Side: proto.OrderSide_BUY, Quantity: "4.0", OrderType: proto.OrderType_MARKET},
+	}
+	for _, req := range orders {
+		req.OrderBookName = "vwap-book"
+		_, err := service.CreateOrder(ctx, req)
+		require.NoError(t, err)
+	}
+
+	vwap, err := service.CalculateVWAP(ctx, &proto.VWAPRequest{OrderBookName: "vwap-book"})
+	require.NoError(t, err)
+	assert.Equal(t, "vwap-book", vwap.OrderBookName)
+	assert.Equal(t, "103.000", vwap.Vwap)
+	assert.Equal(t, "4.000", vwap.Volume)
+	assert.Equal(t, int32(2), vwap.TradeCount)
+
+	vwap, err = service.CalculateVWAP(ctx, &proto.VWAPRequest{OrderBookName: "vwap-book", WindowSeconds: 60})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), vwap.TradeCount)
+
+	// Both trades happened just now, so the last price holds for nearly the
+	// whole window
+	twap, err = service.CalculateTWAP(ctx, &proto.TWAPRequest{OrderBookName: "vwap-book", WindowSeconds: 60})
+	require.NoError(t, err)
+	assert.Equal(t, "104.000", twap.Twap)
+	assert.Equal(t, int32(2), twap.TradeCount)
+
+	_, err = service.CalculateVWAP(ctx, &proto.VWAPRequest{OrderBookName: "vwap-book", WindowSeconds: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = service.CalculateTWAP(ctx, &proto.TWAPRequest{OrderBookName: "vwap-book"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = service.CalculateVWAP(ctx, &proto.VWAPRequest{OrderBookName: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = service.CalculateTWAP(ctx, &proto.TWAPRequest{OrderBookName: "missing", WindowSeconds: 60})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetBookNotional(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "notional-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	for i, price := range []string{"100.0", "99.0", "98.0"} {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "notional-book",
+			OrderId:       fmt.Sprintf("bid-%d", i),
+			Side:          proto.OrderSide_BUY,
+			Quantity:      "2.0",
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "notional-book",
+		OrderId:       "ask",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "1.5",
+		Price:         "101.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+
+	resp, err := service.GetBookNotional(ctx, &proto.GetBookNotionalRequest{OrderBookName: "notional-book", ShockPct: 50})
+	require.NoError(t, err)
+	assert.Equal(t, "594.000", resp.BidNotional)
+	assert.Equal(t, "151.500", resp.AskNotional)
+	// Half the bids (297) clears the 100 level but not the 99 level
+	assert.Equal(t, "1.000", resp.BidDrawdown)
+	assert.Equal(t, "0", resp.AskDrawdown, "a single ask level has nowhere to move")
+
+	_, err = service.CancelOrder(ctx, &proto.CancelOrderRequest{OrderBookName: "notional-book", OrderId: "bid-0"})
+	require.NoError(t, err)
+	resp, err = service.GetBookNotional(ctx, &proto.GetBookNotionalRequest{OrderBookName: "notional-book"})
+	require.NoError(t, err)
+	assert.Equal(t, "394.000", resp.BidNotional)
+	assert.Equal(t, "0", resp.BidDrawdown)
+
+	_, err = service.GetBookNotional(ctx, &proto.GetBookNotionalRequest{OrderBookName: "notional-book", ShockPct: 150})
+	assert.Contains(t, fieldViolations(t, err), "shock_pct")
+
+	_, err = service.GetBookNotional(ctx, &proto.GetBookNotionalRequest{OrderBookName: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetBBO(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "bbo-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	place := func(id string, side proto.OrderSide, quantity, price string) {
+		t.Helper()
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "bbo-book",
+			OrderId:       id,
+			Side:          side,
+			Quantity:      quantity,
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+
+	place("bid-1", proto.OrderSide_BUY, "1.0", "99.0")
+	resp, err := service.GetBBO(ctx, &proto.GetBBORequest{OrderBookName: "bbo-book"})
+	require.NoError(t, err)
+	assert.Equal(t, "99.000", resp.BidPrice)
+	assert.Empty(t, resp.AskPrice)
+	assert.Empty(t, resp.Spread, "no spread without asks")
+
+	place("bid-2", proto.OrderSide_BUY, "2.0", "100.0")
+	place("bid-3", proto.OrderSide_BUY, "0.5", "100.0")
+	place("ask-1", proto.OrderSide_SELL, "3.0", "101.0")
+	place("ask-2", proto.OrderSide_SELL, "1.0", "102.0")
+
+	resp, err = service.GetBBO(ctx, &proto.GetBBORequest{OrderBookName: "bbo-book"})
+	require.NoError(t, err)
+	assert.Equal(t, "100.000", resp.BidPrice)
+	assert.Equal(t, "2.500", resp.BidQty)
+	assert.Equal(t, "101.000", resp.AskPrice)
+	assert.Equal(t, "3.000", resp.AskQty)
+	assert.Equal(t, "1.000", resp.Spread)
+	assert.Equal(t, "100.500", resp.MidPrice)
+
+	_, err = service.GetBBO(ctx, &proto.GetBBORequest{OrderBookName: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetPositions(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	const (
+		maker = "0x52908400098527886E0F7030069857D2E4169EE7"
+		taker = "0xde709f2102306220921060314715629080e2fb77"
+	)
+	place := func(book, id string, side proto.OrderSide, quantity, user string) {
+		t.Helper()
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: book,
+			OrderId:       id,
+			Side:          side,
+			Quantity:      quantity,
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+			UserAddress:   user,
+		})
+		require.NoError(t, err)
+	}
+	for _, name := range []string{"pos-b", "pos-a"} {
+		_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: name, BackendType: proto.BackendType_MEMORY})
+		require.NoError(t, err)
+	}
+
+	place("pos-a", "a-ask", proto.OrderSide_SELL, "2.0", maker)
+	place("pos-a", "a-bid", proto.OrderSide_BUY, "2.0", taker)
+	place("pos-b", "b-bid", proto.OrderSide_BUY, "3.0", maker)
+	place("pos-b", "b-ask", proto.OrderSide_SELL, "1.0", taker)
+
+	resp, err := service.GetPositions(ctx, &proto.GetPositionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Users, 2)
+	assert.Equal(t, maker, resp.Users[0].UserAddress)
+	assert.Equal(t, "-1.000", resp.Users[0].Total)
+	require.Len(t, resp.Users[0].Books, 2)
+	assert.Equal(t, "pos-a", resp.Users[0].Books[0].OrderBookName)
+	assert.Equal(t, "-2.000", resp.Users[0].Books[0].Net)
+	assert.Equal(t, "pos-b", resp.Users[0].Books[1].OrderBookName)
+	assert.Equal(t, "1.000", resp.Users[0].Books[1].Net)
+
+	// The filter matches the checksummed address whatever its case
+	resp, err = service.GetPositions(ctx, &proto.GetPositionsRequest{UserAddress: "0x52908400098527886e0f7030069857d2e4169ee7"})
+	require.NoError(t, err)
+	require.Len(t, resp.Users, 1)
+	assert.Equal(t, maker, resp.Users[0].UserAddress)
+	assert.Equal(t, "-1.000", resp.Users[0].Total)
+
+	// Users without positions are left out
+	resp, err = service.GetPositions(ctx, &proto.GetPositionsRequest{UserAddress: "0x0000000000000000000000000000000000000001"})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Users)
+
+	_, err = service.GetPositions(ctx, &proto.GetPositionsRequest{UserAddress: "not-an-address"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCreateOrder_UserAddressBaggage(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := trace.NewTracerProvider(trace.WithSyncer(exporter))
+	require.NoError(t, pkgotel.InitForTesting(tp.Tracer("test")))
+	t.Cleanup(pkgotel.ResetForTesting)
+
+	const addr = "0x52908400098527886E0F7030069857D2E4169EE7"
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := &baggageSender{MockMessageSender: messaging.NewMockMessageSender()}
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "baggage-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "baggage-book",
+		OrderId:       "ask-1",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+	exporter.Reset()
+	sender.addresses = nil
+
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "baggage-book",
+		OrderId:       "order-1",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+		UserAddress:   addr,
+	})
+	require.NoError(t, err)
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	require.Contains(t, spans, pkgotel.SpanCreateOrder)
+	require.Contains(t, spans, pkgotel.SpanMatchOrder)
+
+	// The matching span is a descendant of the request's span and names the user
+	match := spans[pkgotel.SpanMatchOrder]
+	assert.Equal(t, spans[pkgotel.SpanCreateOrder].SpanContext.TraceID(), match.SpanContext.TraceID())
+	attrs := make(map[attribute.Key]string)
+	for _, attr := range match.Attributes {
+		attrs[attr.Key] = attr.Value.AsString()
+	}
+	assert.Equal(t, addr, attrs[pkgotel.AttributeUserAddress])
+
+	// The baggage reaches the sender, which injects it into the message headers
+	assert.Equal(t, []string{addr}, sender.addresses)
+}
+
+// baggageSender records the user address baggage each message is sent with
+type baggageSender struct {
+	*messaging.MockMessageSender
+	addresses []string
+}
+
+func (s *baggageSender) SendDoneMessage(ctx context.Context, msg *messaging.DoneMessage) error {
+	s.addresses = append(s.addresses, pkgotel.UserAddressFromContext(ctx))
+	return s.MockMessageSender.SendDoneMessage(ctx, msg)
+}
+
+func TestOrderCountFollowsBook(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "count-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "count-book",
+			OrderId:       fmt.Sprintf("bid-%d", i),
+			Side:          proto.OrderSide_BUY,
+			Quantity:      "1.0",
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+	for i := 0; i < 3; i++ {
+		_, err := service.CancelOrder(ctx, &proto.CancelOrderRequest{OrderBookName: "count-book", OrderId: fmt.Sprintf("bid-%d", i)})
+		require.NoError(t, err)
+	}
+
+	// Fills four bids; the sell order itself does not rest
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "count-book",
+		OrderId:       "ask",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "4.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+
+	resp, err := service.GetOrderBook(ctx, &proto.GetOrderBookRequest{Name: "count-book"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), resp.OrderCount)
+
+	list, err := service.ListOrderBooks(ctx, &proto.ListOrderBooksRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.OrderBooks, 1)
+	assert.Equal(t, uint64(3), list.OrderBooks[0].OrderCount)
+}
+
+func TestAmendOrder(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "amend-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	order := func(id string, side proto.OrderSide, quantity, price string) {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "amend-book",
+			OrderId:       id,
+			Side:          side,
+			Quantity:      quantity,
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+	order("bid-1", proto.OrderSide_BUY, "4.0", "99.0")
+	order("ask-1", proto.OrderSide_SELL, "1.0", "101.0")
+
+	events, unsubscribe := service.events.subscribe("amend-book", subscription{})
+	defer unsubscribe()
+
+	_, err = service.AmendOrder(ctx, &proto.AmendOrderRequest{OrderBookName: "amend-book", OrderId: "bid-1"})
+	assert.Equal(t, map[string]string{"price": "or quantity must be set"}, fieldViolations(t, err))
+	_, err = service.AmendOrder(ctx, &proto.AmendOrderRequest{OrderBookName: "amend-book", OrderId: "bid-1", Quantity: "-1"})
+	assert.Equal(t, map[string]string{"quantity": "must be positive"}, fieldViolations(t, err))
+	_, err = service.AmendOrder(ctx, &proto.AmendOrderRequest{OrderBookName: "amend-book", OrderId: "missing", Price: "98.0"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = service.AmendOrder(ctx, &proto.AmendOrderRequest{OrderBookName: "amend-book", OrderId: "bid-1", Price: "101.0"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	resp, err := service.AmendOrder(ctx, &proto.AmendOrderRequest{OrderBookName: "amend-book", OrderId: "bid-1", Quantity: "2.5"})
+	require.NoError(t, err)
+	assert.Equal(t, "99.000", resp.Price)
+	assert.Equal(t, "2.500", resp.RemainingQuantity)
+
+	resp, err = service.AmendOrder(ctx, &proto.AmendOrderRequest{OrderBookName: "amend-book", OrderId: "bid-1", Price: "100.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "100.000", resp.Price)
+	assert.Equal(t, "2.500", resp.RemainingQuantity)
+
+	state, err := service.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: "amend-book", Depth: 20})
+	require.NoError(t, err)
+	require.Len(t, state.Bids, 1)
+	assert.Equal(t, "100.000", state.Bids[0].Price)
+	assert.Equal(t, "2.500", state.Bids[0].TotalQuantity)
+
+	for _, quantity := range []string{"2.500", "2.500"} {
+		select {
+		case event := <-events:
+			assert.Equal(t, proto.OrderBookEventType_AMEND, event.Type)
+			assert.Equal(t, "bid-1", event.OrderId)
+			assert.Equal(t, quantity, event.Quantity)
+		case <-time.After(time.Second):
+			t.Fatal("no AMEND event published")
+		}
+	}
+}
+
+// recordingLocker records the order books locked through it and whether any
+// lock is still held
+type recordingLocker struct {
+	mu     sync.Mutex
+	locked []string
+	held   int
+	err    error
+}
+
+func (l *recordingLocker) Acquire(ctx context.Context, bookName string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return nil, l.err
+	}
+	l.locked = append(l.locked, bookName)
+	l.held++
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.held--
+	}, nil
+}
+
+func TestBookLocker(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+	locker := &recordingLocker{}
+	service.SetBookLocker(locker)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "lock-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "lock-book",
+		OrderId:       "ask-1",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+	_, err = service.AmendOrder(ctx, &proto.AmendOrderRequest{OrderBookName: "lock-book", OrderId: "ask-1", Price: "101.0"})
+	require.NoError(t, err)
+	_, err = service.CancelOrder(ctx, &proto.CancelOrderRequest{OrderBookName: "lock-book", OrderId: "ask-1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lock-book", "lock-book", "lock-book"}, locker.locked)
+	assert.Zero(t, locker.held, "every lock is released")
+
+	// Nothing is changed without the lock
+	locker.err = errors.New("lock held by another owner")
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "lock-book",
+		OrderId:       "ask-2",
+		Side:          proto.OrderSide_SELL,
+		Quantity:      "1.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "lock-book", OrderId: "ask-2"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = service.CancelOrder(ctx, &proto.CancelOrderRequest{OrderBookName: "lock-book", OrderId: "ask-2"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = service.AmendOrder(ctx, &proto.AmendOrderRequest{OrderBookName: "lock-book", OrderId: "ask-2", Price: "101.0"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthServices are the services the health server reports on: the empty
+// name stands for the whole server
+var healthServices = []string{"", proto.OrderBookService_ServiceDesc.ServiceName}
+
+// NewHealthServer creates the grpc.health.v1.Health server for probes of the
+// order book service. It reports NOT_SERVING until manager holds its first
+// order book, then SERVING. Calling Shutdown on it reports NOT_SERVING for
+// good, which Watch streams receive at once, so it should be called as soon
+// as the server starts shutting down.
+func NewHealthServer(manager *OrderBookManager) *health.Server {
+	healthServer := health.NewServer()
+	for _, service := range healthServices {
+		healthServer.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	go func() {
+		select {
+		case <-manager.Ready():
+			// After Shutdown these are ignored
+			for _, service := range healthServices {
+				healthServer.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+			}
+		case <-manager.Closed():
+		}
+	}()
+	return healthServer
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestHealthServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	healthServer := NewHealthServer(manager)
+
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	next := func() healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := watch.Recv()
+		require.NoError(t, err)
+		return resp.Status
+	}
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, next())
+
+	// Serving starts with the first order book
+	_, err = manager.CreateMemoryOrderBook(ctx, "health-book", "")
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, next())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(proto.OrderBookService_ServiceDesc.ServiceName))
+
+	// Watchers learn about the shutdown right away
+	healthServer.Shutdown()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, next())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+)
+
+const (
+	// DefaultIdempotencyCacheSize is how many CreateOrder responses are kept for replay
+	DefaultIdempotencyCacheSize = 100000
+
+	// DefaultIdempotencyTTL is how long a CreateOrder response is kept for replay
+	DefaultIdempotencyTTL = 24 * time.Hour
+)
+
+// IdempotencyCache remembers the responses of CreateOrder requests by order
+// book and client order ID, so that a retried request is answered without
+// submitting the order again. It holds at most capacity responses, dropping
+// the least recently used, and forgets each one ttl after it was stored.
+type IdempotencyCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	// entries indexes lru, which holds the most recently used entry first
+	entries  map[idempotencyKey]*list.Element
+	lru      *list.List
+	inflight map[idempotencyKey]*idempotentCall
+	now      func() time.Time
+}
+
+type idempotencyKey struct {
+	orderBook     string
+	clientOrderID string
+}
+
+type idempotencyEntry struct {
+	key     idempotencyKey
+	resp    *proto.OrderResponse
+	expires time.Time
+}
+
+// idempotentCall is a request being processed; later requests with the same
+// key wait for it
+type idempotentCall struct {
+	done chan struct{}
+	resp *proto.OrderResponse
+	err  error
+}
+
+// NewIdempotencyCache creates an IdempotencyCache holding up to capacity
+// responses for ttl each
+func NewIdempotencyCache(capacity int, ttl time.Duration) *IdempotencyCache {
+	return &IdempotencyCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[idempotencyKey]*list.Element),
+		lru:      list.New(),
+		inflight: make(map[idempotencyKey]*idempotentCall),
+		now:      time.Now,
+	}
+}
+
+// Get returns the stored response for clientOrderID on orderBook, if any
+func (c *IdempotencyCache) Get(orderBook, clientOrderID string) (*proto.OrderResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(idempotencyKey{orderBook, clientOrderID})
+}
+
+// Do returns the stored response for clientOrderID on orderBook. Without
+// one, it waits for a call with the same key that is in progress, or else
+// calls fn and stores its response if fn succeeds. Errors are not stored so
+// a failed request can be retried. Responses are shared between callers and
+// must not be modified.
+func (c *IdempotencyCache) Do(ctx context.Context, orderBook, clientOrderID string, fn func() (*proto.OrderResponse, error)) (*proto.OrderResponse, error) {
+	key := idempotencyKey{orderBook, clientOrderID}
+
+	c.mu.Lock()
+	if resp, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return resp, nil
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.resp, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &idempotentCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.resp, call.err = fn()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		c.put(key, call.resp)
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.resp, call.err
+}
+
+// get returns an unexpired entry and marks it used. The caller must hold c.mu.
+func (c *IdempotencyCache) get(key idempotencyKey) (*proto.OrderResponse, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*idempotencyEntry)
+	if !c.now().Before(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.resp, true
+}
+
+// put stores resp, evicting the least recently used entry when full. The
+// caller must hold c.mu.
+func (c *IdempotencyCache) put(key idempotencyKey, resp *proto.OrderResponse) {
+	if c.capacity <= 0 {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.lru.Remove(elem)
+	}
+	for c.lru.Len() >= c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*idempotencyEntry).key)
+	}
+	c.entries[key] = c.lru.PushFront(&idempotencyEntry{
+		key:     key,
+		resp:    resp,
+		expires: c.now().Add(c.ttl),
+	})
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewIdempotencyCache(2, time.Hour)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	calls := 0
+	respond := func(orderID string) func() (*proto.OrderResponse, error) {
+		return func() (*proto.OrderResponse, error) {
+			calls++
+			return &proto.OrderResponse{OrderId: orderID}, nil
+		}
+	}
+
+	resp, err := cache.Do(ctx, "book", "a", respond("order-a"))
+	require.NoError(t, err)
+	assert.Equal(t, "order-a", resp.OrderId)
+	resp, err = cache.Do(ctx, "book", "a", respond("order-a2"))
+	require.NoError(t, err)
+	assert.Equal(t, "order-a", resp.OrderId, "the first response is replayed")
+	assert.Equal(t, 1, calls)
+
+	// The same client order ID on another book is a different request
+	_, err = cache.Do(ctx, "other", "a", respond("other-a"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// Errors are not remembered
+	_, err = cache.Do(ctx, "book", "b", func() (*proto.OrderResponse, error) { return nil, errors.New("failed") })
+	require.Error(t, err)
+	_, ok := cache.Get("book", "b")
+	assert.False(t, ok)
+
+	// Adding a third response evicts the least recently used
+	_, ok = cache.Get("book", "a")
+	require.True(t, ok)
+	_, err = cache.Do(ctx, "book", "c", respond("order-c"))
+	require.NoError(t, err)
+	_, ok = cache.Get("other", "a")
+	assert.False(t, ok, "least recently used entry evicted")
+	_, ok = cache.Get("book", "a")
+	assert.True(t, ok)
+
+	// Entries expire after the TTL
+	now = now.Add(time.Hour)
+	_, ok = cache.Get("book", "a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// DefaultMaxResponseSize is the largest response sent without truncation.
+// It matches the default maximum message size gRPC clients accept.
+const DefaultMaxResponseSize = 4 << 20
+
+// DefaultRequestSizeLimits are per-method request size limits in bytes,
+// keyed by method name. grpc.MaxRecvMsgSize still caps every method.
+var DefaultRequestSizeLimits = map[string]int64{
+	"CreateOrder":       64 << 10,
+	"BatchCreateOrders": 4 << 20,
+	"BatchGetOrders":    4 << 20,
+}
+
+// MaxRequestSizeInterceptor rejects requests larger than their method's
+// limit with ResourceExhausted before they reach the handler. Methods are
+// looked up in methodLimits by name, e.g. "CreateOrder"; methods not listed
+// are limited to maxBytes. A limit of zero or less means no limit.
+func MaxRequestSizeInterceptor(maxBytes int64, methodLimits map[string]int64) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		method := methodName(info.FullMethod)
+		limit := sizeLimit(method, maxBytes, methodLimits)
+		if msg, ok := req.(protobuf.Message); ok && limit > 0 {
+			if size := int64(protobuf.Size(msg)); size > limit {
+				return nil, status.Errorf(codes.ResourceExhausted,
+					"request of %d bytes exceeds the %d byte limit for %s", size, limit, method)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// MaxResponseSizeInterceptor keeps responses within their method's limit,
+// looked up like MaxRequestSizeInterceptor's. List responses that are too
+// large lose trailing entries and are marked truncated; any other response
+// over the limit is replaced by a ResourceExhausted error.
+func MaxResponseSizeInterceptor(maxBytes int64, methodLimits map[string]int64) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+
+		method := methodName(info.FullMethod)
+		limit := sizeLimit(method, maxBytes, methodLimits)
+		msg, ok := resp.(protobuf.Message)
+		if !ok || limit <= 0 {
+			return resp, nil
+		}
+		size := int64(protobuf.Size(msg))
+		if size <= limit {
+			return resp, nil
+		}
+
+		if truncateResponse(msg, limit) {
+			logger := logging.FromContext(ctx)
+			logger.Warn().
+				Str("method", method).
+				Int64("size", size).
+				Int64("limit", limit).
+				Msg("Response truncated")
+			return msg, nil
+		}
+		return nil, status.Errorf(codes.ResourceExhausted,
+			"response of %d bytes exceeds the %d byte limit for %s", size, limit, method)
+	}
+}
+
+// methodName returns the method part of a full gRPC method name such as
+// "/orderbook.OrderBookService/CreateOrder"
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+// sizeLimit returns the limit for method, or maxBytes if it has none
+func sizeLimit(method string, maxBytes int64, methodLimits map[string]int64) int64 {
+	if limit, ok := methodLimits[method]; ok {
+		return limit
+	}
+	return maxBytes
+}
+
+// truncateResponse drops as few trailing entries from a list response as
+// needed for it to fit in limit bytes and marks it truncated. It reports
+// false, leaving resp unchanged, if resp is not a list response or does not
+// fit even with no entries.
+func truncateResponse(resp protobuf.Message, limit int64) bool {
+	// keep cuts the response down to its first n entries
+	var keep func(n int)
+	var total int
+	switch r := resp.(type) {
+	case *proto.ListOrderBooksResponse:
+		all := r.OrderBooks
+		total = len(all)
+		keep = func(n int) { r.OrderBooks, r.Truncated = all[:n], n < total }
+	case *proto.BatchGetOrdersResponse:
+		all := r.Orders
+		total = len(all)
+		keep = func(n int) { r.Orders, r.Truncated = all[:n], n < total }
+	case *proto.ListStopOrdersResponse:
+		all := r.StopOrders
+		total = len(all)
+		keep = func(n int) { r.StopOrders, r.Truncated = all[:n], n < total }
+	case *proto.OrderBookStateResponse:
+		// Both sides keep the same number of levels nearest the spread
+		bids, asks := r.Bids, r.Asks
+		total = max(len(bids), len(asks))
+		keep = func(n int) {
+			r.Bids, r.Asks, r.Truncated = bids[:min(n, len(bids))], asks[:min(n, len(asks))], n < total
+		}
+	default:
+		return false
+	}
+
+	fits := func(n int) bool {
+		keep(n)
+		return int64(protobuf.Size(resp)) <= limit
+	}
+	if !fits(0) {
+		keep(total)
+		return false
+	}
+
+	// Find the most entries that fit; lo always fits
+	lo, hi := 0, total
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	keep(lo)
+	return true
+}
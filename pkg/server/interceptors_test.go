@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+func TestMaxRequestSizeInterceptor(t *testing.T) {
+	ctx := context.Background()
+	interceptor := MaxRequestSizeInterceptor(0, map[string]int64{
+		"BatchGetOrders": 10000 * 100,
+		"CreateOrder":    DefaultRequestSizeLimits["CreateOrder"],
+	})
+
+	called := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called++
+		return &proto.BatchGetOrdersResponse{}, nil
+	}
+	batchInfo := &grpc.UnaryServerInfo{FullMethod: "/orderbook.OrderBookService/BatchGetOrders"}
+
+	// Each order ID takes 100 bytes on the wire: 98 characters, a tag and a length
+	batch := func(n int) *proto.BatchGetOrdersRequest {
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("%098d", i)
+		}
+		return &proto.BatchGetOrdersRequest{OrderIds: ids}
+	}
+
+	_, err := interceptor(ctx, batch(10000), batchInfo, handler)
+	require.NoError(t, err)
+	assert.Equal(t, 1, called)
+
+	_, err = interceptor(ctx, batch(10001), batchInfo, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, err.Error(), "BatchGetOrders")
+	assert.Equal(t, 1, called, "oversized requests never reach the handler")
+
+	createInfo := &grpc.UnaryServerInfo{FullMethod: "/orderbook.OrderBookService/CreateOrder"}
+	_, err = interceptor(ctx, &proto.CreateOrderRequest{OrderBookName: "book", OrderId: "order"}, createInfo, handler)
+	require.NoError(t, err)
+	_, err = interceptor(ctx, &proto.CreateOrderRequest{OrderId: strings.Repeat("x", 64<<10)}, createInfo, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Methods without a limit of their own fall back to maxBytes, here none
+	_, err = interceptor(ctx, batch(20000), &grpc.UnaryServerInfo{FullMethod: "/orderbook.OrderBookService/GetOrder"}, handler)
+	require.NoError(t, err)
+}
+
+func TestMaxResponseSizeInterceptor(t *testing.T) {
+	ctx := context.Background()
+	const limit = 2000
+	interceptor := MaxResponseSizeInterceptor(limit, map[string]int64{"GetOrder": 0})
+	info := &grpc.UnaryServerInfo{FullMethod: "/orderbook.OrderBookService/GetOrderBookState"}
+
+	levels := func(n int) []*proto.PriceLevel {
+		var out []*proto.PriceLevel
+		for i := 0; i < n; i++ {
+			out = append(out, &proto.PriceLevel{Price: fmt.Sprintf("%d.000", 100+i), TotalQuantity: "1.000", OrderCount: 1})
+		}
+		return out
+	}
+	respond := func(resp interface{}) grpc.UnaryHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) { return resp, nil }
+	}
+
+	// Small responses pass untouched
+	resp, err := interceptor(ctx, nil, info, respond(&proto.OrderBookStateResponse{Bids: levels(3), Asks: levels(3)}))
+	require.NoError(t, err)
+	state := resp.(*proto.OrderBookStateResponse)
+	assert.False(t, state.Truncated)
+	assert.Len(t, state.Bids, 3)
+
+	// Large ones keep the levels nearest the spread
+	resp, err = interceptor(ctx, nil, info, respond(&proto.OrderBookStateResponse{Bids: levels(200), Asks: levels(50)}))
+	require.NoError(t, err)
+	state = resp.(*proto.OrderBookStateResponse)
+	assert.True(t, state.Truncated)
+	assert.LessOrEqual(t, protobuf.Size(state), limit)
+	require.NotEmpty(t, state.Bids)
+	assert.Less(t, len(state.Bids), 50)
+	assert.Equal(t, len(state.Bids), len(state.Asks))
+	assert.Equal(t, "100.000", state.Bids[0].Price)
+
+	// Responses that cannot be truncated are rejected
+	big := &proto.OrderResponse{OrderId: strings.Repeat("x", limit)}
+	orderInfo := &grpc.UnaryServerInfo{FullMethod: "/orderbook.OrderBookService/CreateOrder"}
+	_, err = interceptor(ctx, nil, orderInfo, respond(big))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// unless the method has no limit
+	orderInfo.FullMethod = "/orderbook.OrderBookService/GetOrder"
+	resp, err = interceptor(ctx, nil, orderInfo, respond(big))
+	require.NoError(t, err)
+	assert.Same(t, big, resp)
+}
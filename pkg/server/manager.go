@@ -3,13 +3,17 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/erain9/matchingo/pkg/backend/memory"
 	"github.com/erain9/matchingo/pkg/backend/redis"
 	"github.com/erain9/matchingo/pkg/core"
 	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/erain9/matchingo/pkg/otel"
+	"github.com/nikolaydubina/fpdecimal"
 	redisClient "github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"go.uber.org/zap"
@@ -21,14 +25,87 @@ var (
 
 	// ErrOrderBookNotFound is returned when trying to access a non-existent order book
 	ErrOrderBookNotFound = errors.New("order book not found")
+
+	// ErrOrderBookDeleted is returned when trying to use an order book that has been soft-deleted
+	ErrOrderBookDeleted = errors.New("order book has been deleted")
+
+	// ErrOrderBookNotDeleted is returned when trying to undelete an order book that is not deleted
+	ErrOrderBookNotDeleted = errors.New("order book is not deleted")
+
+	// ErrNoSnapshotPath is returned when saving a snapshot of an order book created without a snapshot path
+	ErrNoSnapshotPath = errors.New("order book has no snapshot path")
+
+	// ErrReplayUnsupported is returned when replaying an order book whose orders outlive the process holding it
+	ErrReplayUnsupported = errors.New("only memory order books can be replayed")
 )
 
+// DefaultRetentionPeriod is how long a soft-deleted order book is kept before it is purged
+const DefaultRetentionPeriod = 24 * time.Hour
+
 // OrderBookInfo contains metadata about an order book
 type OrderBookInfo struct {
-	Name       string
-	Backend    string
-	CreatedAt  time.Time
-	OrderCount int
+	Name      string
+	Backend   string
+	CreatedAt time.Time
+	// SnapshotPath is the file a memory order book is restored from when it
+	// is created and saved to by SaveSnapshot; empty if it has none
+	SnapshotPath string
+	// TradeHistory holds the book's most recent trades
+	TradeHistory *core.TradeHistory
+	// TickSize and LotSize are the steps the book's prices and quantities
+	// must be multiples of; zero when any value is accepted
+	TickSize fpdecimal.Decimal
+	LotSize  fpdecimal.Decimal
+	// STPMode is what the book does when an order would trade with a
+	// resting order of the same user
+	STPMode core.STPMode
+	// FeeSchedule is what the book charges the maker and the taker of each
+	// fill
+	FeeSchedule core.FeeSchedule
+	// circuitBreaker halts matching on the book for a while after a trade
+	// moves the price too fast. It can be changed while the book is in use.
+	circuitBreaker atomic.Pointer[core.CircuitBreakerConfig]
+	// deletedAt is when the order book was soft-deleted, nil if it is not.
+	// It is stored under the manager's lock but read without it.
+	deletedAt atomic.Pointer[time.Time]
+
+	// orderCount is how many orders rested on the book when it was last
+	// counted. Readers holding the manager's read lock refresh it.
+	orderCount atomic.Int64
+}
+
+// OrderCount returns how many orders rested on the book when it was last
+// counted by GetOrderBook or ListOrderBooks
+func (i *OrderBookInfo) OrderCount() int {
+	return int(i.orderCount.Load())
+}
+
+// refreshOrderCount counts the orders resting on book, which may have been
+// changed by matching, cancellation or, for Redis, by another process
+func (i *OrderBookInfo) refreshOrderCount(book *core.OrderBook) {
+	i.orderCount.Store(int64(book.OrderCount()))
+}
+
+// CircuitBreaker returns the book's circuit breaker settings
+func (i *OrderBookInfo) CircuitBreaker() core.CircuitBreakerConfig {
+	if cfg := i.circuitBreaker.Load(); cfg != nil {
+		return *cfg
+	}
+	return core.CircuitBreakerConfig{}
+}
+
+// DeletedAt returns when the order book was soft-deleted, or the zero time
+// if it is not deleted
+func (i *OrderBookInfo) DeletedAt() time.Time {
+	if deletedAt := i.deletedAt.Load(); deletedAt != nil {
+		return *deletedAt
+	}
+	return time.Time{}
+}
+
+// IsDeleted reports whether the order book has been soft-deleted
+func (i *OrderBookInfo) IsDeleted() bool {
+	return i.deletedAt.Load() != nil
 }
 
 // OrderBookManager manages multiple order books
@@ -37,21 +114,143 @@ type OrderBookManager struct {
 	orderBooks map[string]*core.OrderBook
 	info       map[string]*OrderBookInfo
 	redisPool  map[string]*redisClient.Client
+
+	retentionPeriod time.Duration
+	stopPurger      context.CancelFunc
+	stopNotional    context.CancelFunc
+
+	// policy is applied to every order book created by the manager
+	policy       core.OrderBookPolicy
+	stopSweepers map[string]context.CancelFunc
+
+	// positions tracks every user's position across the manager's books
+	positions *core.PositionBook
+
+	// strategies are the named matching rules books can be created with
+	strategies map[string]BookStrategy
+
+	// tradeHistorySize is how many trades each new book's history keeps
+	tradeHistorySize int
+
+	// ready is closed once the first order book has been created
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	// closed is closed once every order book has been shut down
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 // NewOrderBookManager creates a new OrderBookManager
 func NewOrderBookManager() *OrderBookManager {
 	return &OrderBookManager{
-		orderBooks: make(map[string]*core.OrderBook),
-		info:       make(map[string]*OrderBookInfo),
-		redisPool:  make(map[string]*redisClient.Client),
+		orderBooks:       make(map[string]*core.OrderBook),
+		info:             make(map[string]*OrderBookInfo),
+		redisPool:        make(map[string]*redisClient.Client),
+		retentionPeriod:  DefaultRetentionPeriod,
+		stopSweepers:     make(map[string]context.CancelFunc),
+		positions:        core.NewPositionBook(),
+		strategies:       defaultStrategies(),
+		tradeHistorySize: core.DefaultTradeHistorySize,
+		ready:            make(chan struct{}),
+		closed:           make(chan struct{}),
 	}
 }
 
-// CreateMemoryOrderBook creates a new order book with in-memory backend
-func (m *OrderBookManager) CreateMemoryOrderBook(ctx context.Context, name string) (*OrderBookInfo, error) {
+// Positions returns the positions of users across all order books created by
+// the manager
+func (m *OrderBookManager) Positions() *core.PositionBook {
+	return m.positions
+}
+
+// bookOptions returns opts preceded by the options the manager gives every
+// book it creates, which name it and record its trades in history, and
+// those of the named strategy, if any. The caller must hold m.mu.
+func (m *OrderBookManager) bookOptions(name, strategy string, history *core.TradeHistory, opts []core.OrderBookOption) ([]core.OrderBookOption, error) {
+	bookOpts := []core.OrderBookOption{
+		core.WithName(name),
+		core.WithTradeHandler(m.positions.TradeHandler(name)),
+		core.WithTradeHistory(history),
+	}
+	if strategy != "" {
+		s, ok := m.strategies[strategy]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, strategy)
+		}
+		bookOpts = append(bookOpts, s.Options()...)
+	}
+	return append(bookOpts, opts...), nil
+}
+
+// RegisterBookStrategy makes s available to order books created from now on
+// as name, replacing any strategy registered with that name
+func (m *OrderBookManager) RegisterBookStrategy(name string, s BookStrategy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.strategies[name] = s
+}
+
+// BookStrategies returns a copy of the registered strategies, keyed by name
+func (m *OrderBookManager) BookStrategies() map[string]BookStrategy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	strategies := make(map[string]BookStrategy, len(m.strategies))
+	for name, s := range m.strategies {
+		strategies[name] = s
+	}
+	return strategies
+}
+
+// SetRetentionPeriod sets how long soft-deleted order books are retained before being purged
+func (m *OrderBookManager) SetRetentionPeriod(period time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retentionPeriod = period
+}
+
+// SetTradeHistorySize sets how many trades the history of each order book
+// created from now on keeps
+func (m *OrderBookManager) SetTradeHistorySize(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tradeHistorySize = size
+}
+
+// SetOrderBookPolicy sets the policy applied to order books created from now on
+func (m *OrderBookManager) SetOrderBookPolicy(policy core.OrderBookPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policy = policy
+}
+
+// startSweeper starts the workers that cancel expired orders of a new book:
+// the GTD expiry worker, and the order sweeper if the book has a maximum
+// order age. The caller must hold m.mu.
+func (m *OrderBookManager) startSweeper(name string, orderBook *core.OrderBook) {
+	// The workers outlive the request that created the book
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stopSweepers[name] = cancel
+	orderBook.StartExpiryWorker(ctx, m.policy.SweepInterval)
+	orderBook.StartSweeper(ctx, name, m.policy)
+}
+
+// stopSweeper stops the order sweeper of a book, if any. The caller must hold m.mu.
+func (m *OrderBookManager) stopSweeper(name string) {
+	if cancel, ok := m.stopSweepers[name]; ok {
+		cancel()
+		delete(m.stopSweepers, name)
+	}
+}
+
+// CreateMemoryOrderBook creates a new order book with in-memory backend,
+// configured by the registered strategy named strategy, if not empty, and opts.
+// If opts set a snapshot path with core.WithSnapshotPath and the file exists,
+// the book starts with the state of the snapshot.
+func (m *OrderBookManager) CreateMemoryOrderBook(ctx context.Context, name, strategy string, opts ...core.OrderBookOption) (*OrderBookInfo, error) {
 	logger := logging.FromContext(ctx).With().Str("order_book", name).Logger()
 
+	// Hold the write lock from the existence check until the book is stored
+	// so that concurrent creates of the same name cannot both succeed
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -61,29 +260,51 @@ func (m *OrderBookManager) CreateMemoryOrderBook(ctx context.Context, name strin
 		return nil, ErrOrderBookExists
 	}
 
-	// Create in-memory backend
-	backend := memory.NewMemoryBackend()
+	history := core.NewTradeHistory(m.tradeHistorySize)
+	bookOpts, err := m.bookOptions(name, strategy, history, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create order book
-	orderBook := core.NewOrderBook(backend)
+	// Create order book with in-memory backend
+	orderBook := core.NewOrderBook(memory.NewMemoryBackend(), bookOpts...)
+	snapshotPath := orderBook.SnapshotPath()
+	if snapshotPath != "" {
+		if err := restoreSnapshot(orderBook, snapshotPath); err != nil {
+			logger.Error().Err(err).Str("snapshot_path", snapshotPath).Msg("Failed to restore order book snapshot")
+			return nil, err
+		}
+	}
 
 	// Store order book
 	m.orderBooks[name] = orderBook
+	m.startSweeper(name, orderBook)
 
 	// Store metadata
+	rules := orderBook.MatchingRules()
 	info := &OrderBookInfo{
-		Name:      name,
-		Backend:   "memory",
-		CreatedAt: time.Now(),
+		Name:         name,
+		Backend:      "memory",
+		CreatedAt:    time.Now(),
+		SnapshotPath: snapshotPath,
+		TradeHistory: history,
+		TickSize:     rules.TickSize,
+		LotSize:      rules.LotSize,
+		STPMode:      rules.STP,
+		FeeSchedule:  orderBook.FeeSchedule(),
 	}
+	breaker := orderBook.CircuitBreaker()
+	info.circuitBreaker.Store(&breaker)
 	m.info[name] = info
+	m.readyOnce.Do(func() { close(m.ready) })
 
 	logger.Info().Str("backend", "memory").Msg("Created new memory order book")
 	return info, nil
 }
 
-// CreateRedisOrderBook creates a new order book with Redis backend
-func (m *OrderBookManager) CreateRedisOrderBook(ctx context.Context, name string, options map[string]string) (*OrderBookInfo, error) {
+// CreateRedisOrderBook creates a new order book with Redis backend,
+// configured by the registered strategy named strategy, if not empty, and opts
+func (m *OrderBookManager) CreateRedisOrderBook(ctx context.Context, name, strategy string, options map[string]string, opts ...core.OrderBookOption) (*OrderBookInfo, error) {
 	// Convert zerolog logger to zap logger
 	zapLogger, err := zap.NewDevelopment()
 	if err != nil {
@@ -101,6 +322,12 @@ func (m *OrderBookManager) CreateRedisOrderBook(ctx context.Context, name string
 		return nil, ErrOrderBookExists
 	}
 
+	history := core.NewTradeHistory(m.tradeHistorySize)
+	bookOpts, err := m.bookOptions(name, strategy, history, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	// Extract Redis options
 	addr := "localhost:6379"
 	password := ""
@@ -151,21 +378,35 @@ func (m *OrderBookManager) CreateRedisOrderBook(ctx context.Context, name string
 	}
 
 	// Create Redis backend
-	backend := redis.NewRedisBackend(client, prefix, zapLogger)
+	backend, err := redis.NewRedisBackend(client, prefix, zapLogger)
+	if err != nil {
+		logger.Error().Err(err).Str("prefix", prefix).Msg("Failed to create Redis backend")
+		return nil, err
+	}
 
 	// Create order book
-	orderBook := core.NewOrderBook(backend)
+	orderBook := core.NewOrderBook(backend, bookOpts...)
 
 	// Store order book
 	m.orderBooks[name] = orderBook
+	m.startSweeper(name, orderBook)
 
 	// Store metadata
+	rules := orderBook.MatchingRules()
 	info := &OrderBookInfo{
-		Name:      name,
-		Backend:   "redis",
-		CreatedAt: time.Now(),
+		Name:         name,
+		Backend:      "redis",
+		CreatedAt:    time.Now(),
+		TradeHistory: history,
+		TickSize:     rules.TickSize,
+		LotSize:      rules.LotSize,
+		STPMode:      rules.STP,
+		FeeSchedule:  orderBook.FeeSchedule(),
 	}
+	breaker := orderBook.CircuitBreaker()
+	info.circuitBreaker.Store(&breaker)
 	m.info[name] = info
+	m.readyOnce.Do(func() { close(m.ready) })
 
 	logger.Info().
 		Str("backend", "redis").
@@ -190,32 +431,222 @@ func (m *OrderBookManager) GetOrderBook(ctx context.Context, name string) (*core
 	}
 
 	info := m.info[name]
+	if info.IsDeleted() {
+		logger.Debug().Time("deleted_at", info.DeletedAt()).Msg("Order book is deleted")
+		return nil, nil, ErrOrderBookDeleted
+	}
+
 	logger.Debug().Msg("Retrieved order book")
 	return orderBook, info, nil
 }
 
-// DeleteOrderBook removes an order book
+// DeleteOrderBook soft-deletes an order book. The book stops accepting requests
+// but its data is kept until the retention period expires or it is undeleted.
 func (m *OrderBookManager) DeleteOrderBook(ctx context.Context, name string) error {
 	logger := logging.FromContext(ctx).With().Str("order_book", name).Logger()
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.orderBooks[name]; !exists {
+	info, exists := m.info[name]
+	if !exists {
 		logger.Debug().Msg("Order book not found")
 		return ErrOrderBookNotFound
 	}
+	if info.IsDeleted() {
+		logger.Debug().Msg("Order book already deleted")
+		return ErrOrderBookDeleted
+	}
 
-	// Remove from maps
-	delete(m.orderBooks, name)
-	delete(m.info, name)
+	now := time.Now()
+	info.deletedAt.Store(&now)
 
-	logger.Info().Msg("Deleted order book")
+	logger.Info().Dur("retention", m.retentionPeriod).Msg("Deleted order book")
 	return nil
 }
 
-// ListOrderBooks returns information about all order books
-func (m *OrderBookManager) ListOrderBooks(ctx context.Context) []*OrderBookInfo {
+// UndeleteOrderBook restores a soft-deleted order book
+func (m *OrderBookManager) UndeleteOrderBook(ctx context.Context, name string) (*OrderBookInfo, error) {
+	logger := logging.FromContext(ctx).With().Str("order_book", name).Logger()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, exists := m.info[name]
+	if !exists {
+		logger.Debug().Msg("Order book not found")
+		return nil, ErrOrderBookNotFound
+	}
+	if !info.IsDeleted() {
+		return nil, ErrOrderBookNotDeleted
+	}
+
+	info.deletedAt.Store(nil)
+
+	logger.Info().Msg("Undeleted order book")
+	return info, nil
+}
+
+// ResetOrderBook removes all orders from an order book while keeping it registered
+func (m *OrderBookManager) ResetOrderBook(ctx context.Context, name string) (*OrderBookInfo, error) {
+	logger := logging.FromContext(ctx).With().Str("order_book", name).Logger()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, exists := m.info[name]
+	if !exists {
+		logger.Debug().Msg("Order book not found")
+		return nil, ErrOrderBookNotFound
+	}
+	if info.IsDeleted() {
+		return nil, ErrOrderBookDeleted
+	}
+
+	if err := m.orderBooks[name].Reset(); err != nil {
+		logger.Error().Err(err).Msg("Failed to reset order book")
+		return nil, err
+	}
+	info.orderCount.Store(0)
+
+	logger.Info().Msg("Reset order book")
+	return info, nil
+}
+
+// UpdateCircuitBreaker replaces the circuit breaker settings of the named
+// order book while it is in use
+func (m *OrderBookManager) UpdateCircuitBreaker(ctx context.Context, name string, cfg core.CircuitBreakerConfig) (*OrderBookInfo, error) {
+	logger := logging.FromContext(ctx).With().Str("order_book", name).Logger()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, exists := m.info[name]
+	if !exists {
+		logger.Debug().Msg("Order book not found")
+		return nil, ErrOrderBookNotFound
+	}
+	if info.IsDeleted() {
+		return nil, ErrOrderBookDeleted
+	}
+
+	m.orderBooks[name].SetCircuitBreaker(cfg)
+	info.circuitBreaker.Store(&cfg)
+
+	logger.Info().
+		Float64("max_price_move_pct", cfg.MaxPriceMovePct).
+		Int("cooldown_seconds", cfg.CooldownSeconds).
+		Msg("Updated circuit breaker")
+	return info, nil
+}
+
+// PurgeDeletedOrderBooks permanently removes order books that were deleted
+// more than the retention period before now. It returns the number of books purged.
+func (m *OrderBookManager) PurgeDeletedOrderBooks(ctx context.Context, now time.Time) int {
+	logger := logging.FromContext(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+	for name, info := range m.info {
+		if !info.IsDeleted() || now.Sub(info.DeletedAt()) < m.retentionPeriod {
+			continue
+		}
+
+		m.stopSweeper(name)
+		// Deleted books take no new orders, so closing only releases the backend
+		if err := m.orderBooks[name].Close(ctx); err != nil {
+			logger.Error().Err(err).Str("order_book", name).Msg("Failed to close purged order book")
+		}
+		delete(m.orderBooks, name)
+		delete(m.info, name)
+		purged++
+
+		logger.Info().Str("order_book", name).Time("deleted_at", info.DeletedAt()).Msg("Purged order book")
+	}
+
+	return purged
+}
+
+// StartPurger starts a background goroutine that purges expired soft-deleted
+// order books every interval. It stops when ctx is done or the manager is closed.
+func (m *OrderBookManager) StartPurger(ctx context.Context, interval time.Duration) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	m.mu.Lock()
+	if m.stopPurger != nil {
+		m.stopPurger()
+	}
+	m.stopPurger = cancel
+	m.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				m.PurgeDeletedOrderBooks(ctx, now)
+			}
+		}
+	}()
+}
+
+// RecordBookNotional updates the notional gauges of every order book that is
+// not deleted
+func (m *OrderBookManager) RecordBookNotional(ctx context.Context) {
+	m.mu.RLock()
+	books := make(map[string]*core.OrderBook, len(m.orderBooks))
+	for name, orderBook := range m.orderBooks {
+		if !m.info[name].IsDeleted() {
+			books[name] = orderBook
+		}
+	}
+	m.mu.RUnlock()
+
+	metrics := otel.GetOrderBookMetrics()
+	for name, orderBook := range books {
+		bidNotional, askNotional := orderBook.TotalNotional()
+		metrics.RecordBookNotional(ctx, name, bidNotional.Float64(), askNotional.Float64())
+	}
+}
+
+// StartNotionalRecorder starts a background goroutine that updates the
+// notional gauges of every order book every interval, rather than after each
+// order, since it takes a pass over every resting order. It stops when ctx
+// is done or the manager is closed.
+func (m *OrderBookManager) StartNotionalRecorder(ctx context.Context, interval time.Duration) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	m.mu.Lock()
+	if m.stopNotional != nil {
+		m.stopNotional()
+	}
+	m.stopNotional = cancel
+	m.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.RecordBookNotional(ctx)
+			}
+		}
+	}()
+}
+
+// ListOrderBooks returns information about all order books.
+// Soft-deleted books are only included when includeDeleted is true.
+func (m *OrderBookManager) ListOrderBooks(ctx context.Context, includeDeleted bool) []*OrderBookInfo {
 	logger := logging.FromContext(ctx)
 
 	m.mu.RLock()
@@ -225,7 +656,14 @@ func (m *OrderBookManager) ListOrderBooks(ctx context.Context) []*OrderBookInfo
 	result := make([]*OrderBookInfo, 0, len(m.info))
 
 	// Add each order book info to the result
-	for _, info := range m.info {
+	for name, info := range m.info {
+		if info.IsDeleted() {
+			if !includeDeleted {
+				continue
+			}
+		} else {
+			info.refreshOrderCount(m.orderBooks[name])
+		}
 		result = append(result, info)
 	}
 
@@ -233,12 +671,13 @@ func (m *OrderBookManager) ListOrderBooks(ctx context.Context) []*OrderBookInfo
 	return result
 }
 
-// UpdateOrderBookInfo updates the order count for an order book
+// UpdateOrderBookInfo sets the order count for an order book until it is
+// next counted
 func (m *OrderBookManager) UpdateOrderBookInfo(ctx context.Context, name string, orderCount int) error {
 	logger := logging.FromContext(ctx).With().Str("order_book", name).Logger()
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
 	info, exists := m.info[name]
 	if !exists {
@@ -246,25 +685,85 @@ func (m *OrderBookManager) UpdateOrderBookInfo(ctx context.Context, name string,
 		return ErrOrderBookNotFound
 	}
 
-	info.OrderCount = orderCount
+	info.orderCount.Store(int64(orderCount))
 	logger.Debug().Int("order_count", orderCount).Msg("Updated order book info")
 	return nil
 }
 
-// Close closes all resources used by the manager
+// Close closes all resources used by the manager, waiting as long as it
+// takes for in-flight orders to finish
 func (m *OrderBookManager) Close() {
+	ctx := context.Background()
+	if err := m.CloseWithTimeout(ctx); err != nil {
+		logger := logging.FromContext(ctx)
+		logger.Error().Err(err).Msg("Failed to close order book manager")
+	}
+}
+
+// CloseWithTimeout shuts down every order book: new orders are rejected,
+// in-flight orders are allowed to finish and backends are flushed before the
+// Redis connections are closed. If ctx is done first its error is returned
+// and Closed is not signaled.
+func (m *OrderBookManager) CloseWithTimeout(ctx context.Context) error {
+	logger := logging.FromContext(ctx)
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	// Stop the purger and notional recorder goroutines
+	if m.stopPurger != nil {
+		m.stopPurger()
+		m.stopPurger = nil
+	}
+	if m.stopNotional != nil {
+		m.stopNotional()
+		m.stopNotional = nil
+	}
 
-	// Close all Redis clients
-	for _, client := range m.redisPool {
-		client.Close()
+	// Stop all order sweepers
+	for name := range m.stopSweepers {
+		m.stopSweeper(name)
 	}
 
-	// Clear maps
+	books := m.orderBooks
+	clients := m.redisPool
+
+	// Clear maps so the books can no longer be looked up
 	m.orderBooks = make(map[string]*core.OrderBook)
 	m.info = make(map[string]*OrderBookInfo)
 	m.redisPool = make(map[string]*redisClient.Client)
+	m.mu.Unlock()
+
+	// Drain the books without holding m.mu so in-flight requests can finish
+	var errs []error
+	for name, book := range books {
+		if err := book.Close(ctx); err != nil {
+			logger.Error().Err(err).Str("order_book", name).Msg("Failed to close order book")
+			errs = append(errs, fmt.Errorf("closing order book %s: %w", name, err))
+		}
+	}
+
+	// Close all Redis clients
+	for _, client := range clients {
+		client.Close()
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	m.closeOnce.Do(func() { close(m.closed) })
+	return nil
+}
+
+// Ready returns a channel that is closed once the manager's first order book
+// has been created
+func (m *OrderBookManager) Ready() <-chan struct{} {
+	return m.ready
+}
+
+// Closed returns a channel that is closed once Close or CloseWithTimeout has
+// shut down every order book
+func (m *OrderBookManager) Closed() <-chan struct{} {
+	return m.closed
 }
 
 // LogOrderBookSummary logs summary information about an order book
@@ -274,6 +773,6 @@ func LogOrderBookSummary(ctx context.Context, logger zerolog.Logger, book *core.
 		Str("name", info.Name).
 		Str("backend", info.Backend).
 		Time("created_at", info.CreatedAt).
-		Int("order_count", info.OrderCount).
+		Int("order_count", book.OrderCount()).
 		Msg("Order book summary")
 }
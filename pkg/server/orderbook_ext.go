@@ -46,5 +46,9 @@ func (g *orderBookBackendGetter) AppendToSide(side core.Side, order *core.Order)
 func (g *orderBookBackendGetter) RemoveFromSide(side core.Side, order *core.Order) bool { return false }
 func (g *orderBookBackendGetter) AppendToStopBook(order *core.Order)                    {}
 func (g *orderBookBackendGetter) RemoveFromStopBook(order *core.Order) bool             { return false }
+func (g *orderBookBackendGetter) AppendToMidpoint(order *core.Order)                    {}
+func (g *orderBookBackendGetter) RemoveFromMidpoint(order *core.Order) bool             { return false }
+func (g *orderBookBackendGetter) GetMidpointOrders(side core.Side) []*core.Order        { return nil }
 func (g *orderBookBackendGetter) CheckOCO(orderID string) string                        { return "" }
 func (g *orderBookBackendGetter) GetStopBook() interface{}                              { return nil }
+func (g *orderBookBackendGetter) GetAllOrders() []*core.Order                           { return nil }
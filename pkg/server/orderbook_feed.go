@@ -0,0 +1,321 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/gorilla/websocket"
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// OrderBookFeedPath is the path prefix OrderBookFeeds serves; the order book
+// name follows it
+const OrderBookFeedPath = "/ws/orderbook/"
+
+const (
+	// feedClientBuffer is how many messages a client may fall behind before
+	// it is disconnected. A client that missed a diff could no longer keep
+	// its copy of the book, so diffs are never dropped for it.
+	feedClientBuffer = 64
+
+	// feedPingInterval is how often feed connections are pinged
+	feedPingInterval = 30 * time.Second
+
+	// feedWriteTimeout bounds each write to a feed connection
+	feedWriteTimeout = 10 * time.Second
+)
+
+// DeltaAction says how a price level changed
+type DeltaAction string
+
+const (
+	// DeltaAdd is a price level that was not on the book before
+	DeltaAdd DeltaAction = "add"
+	// DeltaModify is a price level whose total quantity changed
+	DeltaModify DeltaAction = "modify"
+	// DeltaRemove is a price level that is no longer on the book
+	DeltaRemove DeltaAction = "remove"
+)
+
+// LevelDelta is the change of one price level
+type LevelDelta struct {
+	Action DeltaAction `json:"action"`
+	Price  string      `json:"price"`
+	// Quantity is the level's new total quantity; empty for removed levels
+	Quantity string `json:"quantity,omitempty"`
+}
+
+// DiffMessage is the JSON message OrderBookFeeds sends. The first message of
+// a connection adds every price level of the book. Each later one holds the
+// levels that changed since the message before it, numbered one higher, so a
+// client applying them in order keeps a copy of the book. Levels are listed
+// best first, removals last.
+type DiffMessage struct {
+	OrderBook string       `json:"order_book"`
+	Sequence  uint64       `json:"sequence"`
+	Bids      []LevelDelta `json:"bids"`
+	Asks      []LevelDelta `json:"asks"`
+}
+
+// OrderBookFeeds serves GET /ws/orderbook/{name}, a WebSocket that streams a
+// DiffMessage each time the named order book changes. The clients of a book
+// share one feed, which receives the book's Done objects through
+// core.OrderBook.SetEventChan while it has clients. Up to MaxStateDepth
+// levels per side are streamed.
+type OrderBookFeeds struct {
+	manager  *OrderBookManager
+	sockets  *WebSocketHub
+	upgrader websocket.Upgrader
+
+	mu    sync.Mutex
+	feeds map[string]*bookFeed
+}
+
+// NewOrderBookFeeds creates OrderBookFeeds for the books of manager. The
+// connections are registered with sockets, which pings them and closes them
+// on shutdown.
+func NewOrderBookFeeds(manager *OrderBookManager, sockets *WebSocketHub) *OrderBookFeeds {
+	return &OrderBookFeeds{
+		manager: manager,
+		sockets: sockets,
+		feeds:   make(map[string]*bookFeed),
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket and streams the book to it
+func (f *OrderBookFeeds) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	name := strings.TrimPrefix(r.URL.Path, OrderBookFeedPath)
+	if name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	book, _, err := f.manager.GetOrderBook(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, ErrOrderBookNotFound) || errors.Is(err, ErrOrderBookDeleted) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		logger.Error().Err(err).Str("order_book", name).Msg("Failed to get order book for feed")
+		http.Error(w, "failed to get order book", http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := f.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied to the client
+		logger.Debug().Err(err).Str("order_book", name).Msg("WebSocket upgrade failed")
+		return
+	}
+	f.sockets.StartHeartbeat(conn, feedPingInterval)
+	f.join(name, book, conn)
+}
+
+// join adds conn to the feed of book, starting the feed if it has no clients
+func (f *OrderBookFeeds) join(name string, book *core.OrderBook, conn *websocket.Conn) {
+	client := &feedClient{conn: conn, send: make(chan []byte, feedClientBuffer)}
+
+	f.mu.Lock()
+	feed := f.feeds[name]
+	if feed == nil || feed.book != book {
+		feed = newBookFeed(name, book)
+		f.feeds[name] = feed
+		book.SetEventChan(feed.events)
+		go feed.run()
+	}
+	feed.members++
+	f.mu.Unlock()
+
+	feed.join <- client
+	go f.write(feed, client)
+}
+
+// leave removes client from feed, stopping the feed if it was the last one
+func (f *OrderBookFeeds) leave(feed *bookFeed, client *feedClient) {
+	feed.leave <- client
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	feed.members--
+	if feed.members > 0 {
+		return
+	}
+	// Unsubscribing under mu keeps it ordered before a new feed of the
+	// same book subscribes
+	feed.book.SetEventChan(nil)
+	if f.feeds[feed.name] == feed {
+		delete(f.feeds, feed.name)
+	}
+	close(feed.stop)
+}
+
+// write sends the messages of client to its connection until either ends
+func (f *OrderBookFeeds) write(feed *bookFeed, client *feedClient) {
+	defer f.leave(feed, client)
+
+	closed := f.sockets.Closed(client.conn)
+	for {
+		select {
+		case msg, ok := <-client.send:
+			if !ok {
+				// The feed dropped the client for falling behind
+				f.sockets.remove(client.conn)
+				return
+			}
+			client.conn.SetWriteDeadline(time.Now().Add(feedWriteTimeout))
+			if err := client.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				f.sockets.remove(client.conn)
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// feedClient is one connection of a feed
+type feedClient struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// bookFeed turns the events of one order book into diffs for its clients
+type bookFeed struct {
+	name   string
+	book   *core.OrderBook
+	events chan *core.Done
+	join   chan *feedClient
+	leave  chan *feedClient
+	stop   chan struct{}
+
+	// members counts the clients that joined and have not left; it is
+	// guarded by OrderBookFeeds.mu
+	members int
+
+	// Owned by run
+	clients  map[*feedClient]struct{}
+	bids     []*proto.PriceLevel
+	asks     []*proto.PriceLevel
+	sequence uint64
+}
+
+func newBookFeed(name string, book *core.OrderBook) *bookFeed {
+	return &bookFeed{
+		name: name,
+		book: book,
+		// One pending event is enough: each is handled by comparing the
+		// whole book with the levels last sent
+		events:  make(chan *core.Done, 1),
+		join:    make(chan *feedClient),
+		leave:   make(chan *feedClient),
+		stop:    make(chan struct{}),
+		clients: make(map[*feedClient]struct{}),
+	}
+}
+
+// run serves the feed until it is stopped
+func (b *bookFeed) run() {
+	b.bids, b.asks = b.levels()
+	for {
+		select {
+		case client := <-b.join:
+			b.clients[client] = struct{}{}
+			b.send(client, b.snapshot())
+		case client := <-b.leave:
+			delete(b.clients, client)
+		case <-b.events:
+			if msg := b.diff(); msg != nil {
+				for client := range b.clients {
+					b.send(client, msg)
+				}
+			}
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// send queues msg for client, dropping the client if it is too far behind
+func (b *bookFeed) send(client *feedClient, msg []byte) {
+	select {
+	case client.send <- msg:
+	default:
+		delete(b.clients, client)
+		close(client.send)
+	}
+}
+
+// levels returns the current price levels of the book, best first
+func (b *bookFeed) levels() (bids, asks []*proto.PriceLevel) {
+	return topPriceLevels(b.book, b.book.GetBids(), MaxStateDepth, fpdecimal.Zero), topPriceLevels(b.book, b.book.GetAsks(), MaxStateDepth, fpdecimal.Zero)
+}
+
+// snapshot is the first message of a client: every level last sent, added
+func (b *bookFeed) snapshot() []byte {
+	return marshalDiff(&DiffMessage{
+		OrderBook: b.name,
+		Sequence:  b.sequence,
+		Bids:      levelDeltas(nil, b.bids),
+		Asks:      levelDeltas(nil, b.asks),
+	})
+}
+
+// diff compares the book with the levels last sent and returns the message
+// describing the change, or nil if nothing changed
+func (b *bookFeed) diff() []byte {
+	bids, asks := b.levels()
+	msg := &DiffMessage{
+		OrderBook: b.name,
+		Bids:      levelDeltas(b.bids, bids),
+		Asks:      levelDeltas(b.asks, asks),
+	}
+	b.bids, b.asks = bids, asks
+	if len(msg.Bids) == 0 && len(msg.Asks) == 0 {
+		return nil
+	}
+	b.sequence++
+	msg.Sequence = b.sequence
+	return marshalDiff(msg)
+}
+
+// levelDeltas returns the changes that turn the levels before into after
+func levelDeltas(before, after []*proto.PriceLevel) []LevelDelta {
+	previous := make(map[string]string, len(before))
+	for _, level := range before {
+		previous[level.Price] = level.TotalQuantity
+	}
+
+	deltas := []LevelDelta{}
+	current := make(map[string]bool, len(after))
+	for _, level := range after {
+		current[level.Price] = true
+		quantity, existed := previous[level.Price]
+		switch {
+		case !existed:
+			deltas = append(deltas, LevelDelta{Action: DeltaAdd, Price: level.Price, Quantity: level.TotalQuantity})
+		case quantity != level.TotalQuantity:
+			deltas = append(deltas, LevelDelta{Action: DeltaModify, Price: level.Price, Quantity: level.TotalQuantity})
+		}
+	}
+	for _, level := range before {
+		if !current[level.Price] {
+			deltas = append(deltas, LevelDelta{Action: DeltaRemove, Price: level.Price})
+		}
+	}
+	return deltas
+}
+
+// marshalDiff encodes msg, which cannot fail for its plain fields
+func marshalDiff(msg *DiffMessage) []byte {
+	data, _ := json.Marshal(msg)
+	return data
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readDiff(t *testing.T, conn *websocket.Conn) DiffMessage {
+	t.Helper()
+	var msg DiffMessage
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestOrderBookFeeds(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "feed-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	order := func(id string, side proto.OrderSide, quantity, price string) {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "feed-book",
+			OrderId:       id,
+			Side:          side,
+			Quantity:      quantity,
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		require.NoError(t, err)
+	}
+	order("bid-1", proto.OrderSide_BUY, "2.0", "99.0")
+
+	sockets := NewWebSocketHub()
+	srv := httptest.NewServer(NewOrderBookFeeds(manager, sockets))
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + OrderBookFeedPath
+
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL+"missing-book", nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"feed-book", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	snapshot := readDiff(t, conn)
+	assert.Equal(t, "feed-book", snapshot.OrderBook)
+	assert.Equal(t, []LevelDelta{{Action: DeltaAdd, Price: "99.000", Quantity: "2.000"}}, snapshot.Bids)
+	assert.Empty(t, snapshot.Asks)
+
+	order("ask-1", proto.OrderSide_SELL, "1.0", "101.0")
+	diff := readDiff(t, conn)
+	assert.Equal(t, snapshot.Sequence+1, diff.Sequence)
+	assert.Empty(t, diff.Bids)
+	assert.Equal(t, []LevelDelta{{Action: DeltaAdd, Price: "101.000", Quantity: "1.000"}}, diff.Asks)
+
+	order("sell-1", proto.OrderSide_SELL, "0.5", "99.0")
+	diff = readDiff(t, conn)
+	assert.Equal(t, []LevelDelta{{Action: DeltaModify, Price: "99.000", Quantity: "1.500"}}, diff.Bids)
+	assert.Empty(t, diff.Asks)
+
+	_, err = service.CancelOrder(ctx, &proto.CancelOrderRequest{OrderBookName: "feed-book", OrderId: "ask-1"})
+	require.NoError(t, err)
+	diff = readDiff(t, conn)
+	assert.Empty(t, diff.Bids)
+	assert.Equal(t, []LevelDelta{{Action: DeltaRemove, Price: "101.000"}}, diff.Asks)
+
+	require.NoError(t, sockets.Shutdown(ctx))
+	assert.Eventually(t, func() bool {
+		feeds := srv.Config.Handler.(*OrderBookFeeds)
+		feeds.mu.Lock()
+		defer feeds.mu.Unlock()
+		return len(feeds.feeds) == 0
+	}, 5*time.Second, 10*time.Millisecond, "the feed should stop with its last client")
+}
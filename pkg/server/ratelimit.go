@@ -0,0 +1,94 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimiterPruneSize is how many user addresses an OrderRateLimiter tracks
+// before it forgets the idle ones
+const rateLimiterPruneSize = 10000
+
+// OrderRateLimiter limits how fast each user address may submit orders with
+// a token bucket per address. Orders without a user address share one
+// bucket. It is safe for concurrent use.
+type OrderRateLimiter struct {
+	mu       sync.Mutex
+	limit    rate.Limit
+	burst    int
+	limiters map[string]*rate.Limiter
+	now      func() time.Time
+}
+
+// NewOrderRateLimiter creates an OrderRateLimiter letting each user address
+// submit ordersPerSecond orders a second on average and up to burst at once
+func NewOrderRateLimiter(ordersPerSecond float64, burst int) *OrderRateLimiter {
+	return &OrderRateLimiter{
+		limit:    rate.Limit(ordersPerSecond),
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+		now:      time.Now,
+	}
+}
+
+// Allow takes a token for an order of userAddress. If there is none it
+// reports false and how long until there will be.
+func (l *OrderRateLimiter) Allow(userAddress string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	limiter, ok := l.limiters[userAddress]
+	if !ok {
+		if len(l.limiters) >= rateLimiterPruneSize {
+			l.prune(now)
+		}
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[userAddress] = limiter
+	}
+
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		// A burst below one never has a token
+		return false, 0
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// Limit returns how many orders a second each user address may submit on
+// average and how many at once
+func (l *OrderRateLimiter) Limit() (ordersPerSecond float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return float64(l.limit), l.burst
+}
+
+// SetLimit changes the rate and burst of every user address, keeping the
+// tokens their buckets hold
+func (l *OrderRateLimiter) SetLimit(ordersPerSecond float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.limit, l.burst = rate.Limit(ordersPerSecond), burst
+	for _, limiter := range l.limiters {
+		limiter.SetLimitAt(now, l.limit)
+		limiter.SetBurstAt(now, burst)
+	}
+}
+
+// prune forgets the addresses whose bucket has refilled, which behave like
+// new ones. The caller must hold mu.
+func (l *OrderRateLimiter) prune(now time.Time) {
+	for address, limiter := range l.limiters {
+		if limiter.TokensAt(now) >= float64(l.burst) {
+			delete(l.limiters, address)
+		}
+	}
+}
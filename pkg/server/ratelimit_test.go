@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestOrderRateLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewOrderRateLimiter(2, 3)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow("alice")
+		assert.True(t, allowed, "order %d is within the burst", i)
+	}
+	allowed, wait := limiter.Allow("alice")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Other addresses have buckets of their own
+	allowed, _ = limiter.Allow("bob")
+	assert.True(t, allowed)
+
+	// A rejected order takes no token, so one refills in half a second
+	now = now.Add(500 * time.Millisecond)
+	allowed, _ = limiter.Allow("alice")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("alice")
+	assert.False(t, allowed)
+
+	// Full buckets are forgotten once too many addresses are tracked
+	now = now.Add(time.Minute)
+	limiter.prune(now)
+	assert.Empty(t, limiter.limiters)
+}
+
+// trailerStream records the trailer a handler sets
+type trailerStream struct {
+	grpc.ServerTransportStream
+	trailer metadata.MD
+}
+
+func (s *trailerStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func TestCreateOrderRateLimit(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+	service.SetOrderRateLimiter(NewOrderRateLimiter(0.5, 2))
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "limited-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	order := func(id, user string) *proto.CreateOrderRequest {
+		return &proto.CreateOrderRequest{
+			OrderBookName: "limited-book",
+			OrderId:       id,
+			Side:          proto.OrderSide_BUY,
+			Quantity:      "1.0",
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+			UserAddress:   user,
+		}
+	}
+	const alice = "0x1234567890123456789012345678901234567890"
+	const bob = "0x0987654321098765432109876543210987654321"
+
+	for _, id := range []string{"a-1", "a-2"} {
+		_, err := service.CreateOrder(ctx, order(id, alice))
+		require.NoError(t, err)
+	}
+
+	stream := &trailerStream{}
+	_, err = service.CreateOrder(grpc.NewContextWithServerTransportStream(ctx, stream), order("a-3", alice))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"2"}, stream.trailer.Get("retry-after"))
+	book, _, err := manager.GetOrderBook(ctx, "limited-book")
+	require.NoError(t, err)
+	assert.Nil(t, book.GetOrder("a-3"), "a limited order is not submitted")
+
+	_, err = service.CreateOrder(ctx, order("b-1", bob))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/erain9/matchingo/pkg/replay"
+)
+
+// ReplayPath is the path prefix ReplayHandler serves; the order book name
+// follows it
+const ReplayPath = "/admin/replay/"
+
+// ReplayOrderBook replaces the orders of the named memory order book with
+// those its done messages in source leave resting, and returns how many
+// were restored. Orders placed on the book while the messages are read are
+// dropped with the rest. The book is left as it was if its messages leave
+// orders that cannot be rebuilt, reported by replay.ErrUnsupportedOrders.
+func (m *OrderBookManager) ReplayOrderBook(ctx context.Context, name string, source replay.Source) (int, error) {
+	logger := logging.FromContext(ctx).With().Str("order_book", name).Logger()
+
+	_, info, err := m.GetOrderBook(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if info.Backend != "memory" {
+		return 0, ErrReplayUnsupported
+	}
+
+	// Reading the topic can take a while, so it is done before the manager
+	// is locked
+	snapshot, err := replay.NewReplayer(source).Snapshot(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	orderBook, exists := m.orderBooks[name]
+	if !exists {
+		return 0, ErrOrderBookNotFound
+	}
+	if m.info[name].IsDeleted() {
+		return 0, ErrOrderBookDeleted
+	}
+	if err := orderBook.Reset(); err != nil {
+		logger.Error().Err(err).Msg("Failed to reset order book")
+		return 0, err
+	}
+	if err := orderBook.Restore(snapshot); err != nil {
+		logger.Error().Err(err).Msg("Failed to restore replayed orders")
+		return 0, err
+	}
+	restored := len(snapshot.Bids) + len(snapshot.Asks)
+	m.info[name].orderCount.Store(int64(restored))
+
+	logger.Info().Int("orders", restored).Msg("Replayed order book")
+	return restored, nil
+}
+
+// replayJSON is the response to POST /admin/replay/{name}
+type replayJSON struct {
+	OrderBook string `json:"order_book"`
+	Orders    int    `json:"orders"`
+}
+
+// ReplayHandler rebuilds memory order books from their done messages. POST
+// /admin/replay/{name} replaces the book's orders with the ones its messages
+// leave resting.
+type ReplayHandler struct {
+	manager *OrderBookManager
+	// sources returns where the messages of the named book are read from
+	sources func(name string) replay.Source
+}
+
+// NewReplayHandler creates a ReplayHandler for the order books of manager,
+// reading the messages of each book from the source sources returns for it
+func NewReplayHandler(manager *OrderBookManager, sources func(name string) replay.Source) *ReplayHandler {
+	return &ReplayHandler{manager: manager, sources: sources}
+}
+
+// ServeHTTP replays the book named in the path
+func (h *ReplayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.FromContext(ctx)
+
+	name := strings.TrimPrefix(r.URL.Path, ReplayPath)
+	if name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	restored, err := h.manager.ReplayOrderBook(ctx, name, h.sources(name))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrOrderBookNotFound), errors.Is(err, ErrOrderBookDeleted):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrReplayUnsupported), errors.Is(err, replay.ErrUnsupportedOrders):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			logger.Error().Err(err).Str("order_book", name).Msg("Failed to replay order book")
+			http.Error(w, "failed to replay order book", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(replayJSON{OrderBook: name, Orders: restored}); err != nil {
+		logger.Error().Err(err).Str("order_book", name).Msg("Failed to write replay response")
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/erain9/matchingo/pkg/replay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// messageSource replays a fixed list of done messages
+type messageSource []*messaging.DoneMessage
+
+func (s messageSource) Read(ctx context.Context, fn func(*messaging.DoneMessage) error) error {
+	for _, msg := range s {
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestReplayHandler(t *testing.T) {
+	ctx := context.Background()
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	_, err := manager.CreateMemoryOrderBook(ctx, "replay-book", "")
+	require.NoError(t, err)
+
+	service := NewGRPCOrderBookService(manager)
+	orders := []*proto.CreateOrderRequest{
+		{OrderId: "bid", Side: proto.OrderSide_BUY, Quantity: "2.0", Price: "99.0", OrderType: proto.OrderType_LIMIT},
+		{OrderId: "ask", Side: proto.OrderSide_SELL, Quantity: "3.0", Price: "101.0", OrderType: proto.OrderType_LIMIT},
+		{OrderId: "taker", Side: proto.OrderSide_BUY, Quantity: "1.0", OrderType: proto.OrderType_MARKET},
+	}
+	for _, req := range orders {
+		req.OrderBookName = "replay-book"
+		_, err := service.CreateOrder(ctx, req)
+		require.NoError(t, err)
+	}
+	published := messageSource(sender.GetSentMessages())
+
+	// The book loses its orders, as it would with the process holding it
+	_, err = manager.ResetOrderBook(ctx, "replay-book")
+	require.NoError(t, err)
+
+	var requested []string
+	handler := NewReplayHandler(manager, func(name string) replay.Source {
+		requested = append(requested, name)
+		return published
+	})
+	serve := func(method, name string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, ReplayPath+name, nil))
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "replay-book")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp replayJSON
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, replayJSON{OrderBook: "replay-book", Orders: 2}, resp)
+	assert.Equal(t, []string{"replay-book"}, requested)
+
+	got, err := service.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: "replay-book", OrderId: "ask"})
+	require.NoError(t, err)
+	assert.Equal(t, "2.000", got.RemainingQuantity)
+	_, info, err := manager.GetOrderBook(ctx, "replay-book")
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.OrderCount())
+
+	// Replaying again replaces the orders rather than adding to them
+	rec = serve(http.MethodPost, "replay-book")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	book, _, err := manager.GetOrderBook(ctx, "replay-book")
+	require.NoError(t, err)
+	assert.Equal(t, 2, book.OrderCount())
+
+	// A stop order left waiting keeps the book from being replayed
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "replay-book", OrderId: "stop", Side: proto.OrderSide_BUY, Quantity: "1.0",
+		Price: "110.0", StopPrice: "105.0", OrderType: proto.OrderType_STOP_LIMIT,
+	})
+	require.NoError(t, err)
+	published = messageSource(sender.GetSentMessages())
+	rec = serve(http.MethodPost, "replay-book")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "stop (stop)")
+	assert.Equal(t, 3, book.OrderCount())
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "missing").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "").Code)
+	rec = serve(http.MethodGet, "replay-book")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, http.MethodPost, rec.Header().Get("Allow"))
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// matchLatencyWindow is how many of the latest match durations the
+	// latency SLO is checked against
+	matchLatencyWindow = 1000
+	// matchLatencyCheckInterval is how many match durations are observed
+	// between checks of the latency SLO
+	matchLatencyCheckInterval = 100
+)
+
+// matchLatencySLO checks that the 99th percentile of recent match durations
+// stays below a target. A nil *matchLatencySLO checks nothing.
+type matchLatencySLO struct {
+	mu     sync.Mutex
+	target time.Duration
+	// window is a ring of the latest durations; next is where the next one goes
+	window []time.Duration
+	next   int
+	// observed counts every duration since the SLO was created
+	observed uint64
+}
+
+// newMatchLatencySLO creates a matchLatencySLO with the given target, or
+// returns nil if target is not positive
+func newMatchLatencySLO(target time.Duration) *matchLatencySLO {
+	if target <= 0 {
+		return nil
+	}
+	return &matchLatencySLO{
+		target: target,
+		window: make([]time.Duration, 0, matchLatencyWindow),
+	}
+}
+
+// observe records a match duration. Every matchLatencyCheckInterval
+// observations it computes the 99th percentile of the window and reports
+// whether it is at or above the target.
+func (s *matchLatencySLO) observe(d time.Duration) (p99 time.Duration, violated bool) {
+	if s == nil {
+		return 0, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.window) < matchLatencyWindow {
+		s.window = append(s.window, d)
+	} else {
+		s.window[s.next] = d
+	}
+	s.next = (s.next + 1) % matchLatencyWindow
+	s.observed++
+
+	if s.observed%matchLatencyCheckInterval != 0 {
+		return 0, false
+	}
+	p99 = percentile(s.window, 0.99)
+	return p99, p99 >= s.target
+}
+
+// percentile returns the q-th quantile of durations by the nearest-rank method
+func percentile(durations []time.Duration, q float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
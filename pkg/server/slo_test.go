@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchLatencySLO(t *testing.T) {
+	assert.Nil(t, newMatchLatencySLO(0), "a zero target disables the SLO")
+	_, violated := (*matchLatencySLO)(nil).observe(time.Hour)
+	assert.False(t, violated)
+
+	slo := newMatchLatencySLO(5 * time.Millisecond)
+	observe := func(n int, d time.Duration) (p99 time.Duration, violated bool) {
+		for i := 0; i < n; i++ {
+			p99, violated = slo.observe(d)
+		}
+		return p99, violated
+	}
+
+	// The SLO is only checked every matchLatencyCheckInterval observations
+	_, violated = observe(matchLatencyCheckInterval-1, time.Second)
+	assert.False(t, violated)
+	p99, violated := observe(1, time.Millisecond)
+	assert.True(t, violated)
+	assert.Equal(t, time.Second, p99)
+
+	// Once the slow matches leave the window the SLO is met again
+	p99, violated = observe(matchLatencyWindow, time.Millisecond)
+	assert.False(t, violated)
+	assert.Equal(t, time.Millisecond, p99)
+
+	// One slow match in a hundred stays under the 99th percentile
+	observe(matchLatencyWindow-10, time.Millisecond)
+	p99, violated = observe(10, 10*time.Millisecond)
+	assert.False(t, violated)
+	assert.Equal(t, time.Millisecond, p99)
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/logging"
+)
+
+// SnapshotPath is the path prefix SnapshotHandler serves; the order book
+// name follows it
+const SnapshotPath = "/admin/snapshot/"
+
+// restoreSnapshot loads the snapshot at path into orderBook. A missing file
+// is not an error: the book simply starts empty.
+func restoreSnapshot(orderBook *core.OrderBook, path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+	return orderBook.UnmarshalState(data)
+}
+
+// SnapshotOrderBook returns a snapshot of the named order book's state.
+// Only memory order books can be snapshotted.
+func (m *OrderBookManager) SnapshotOrderBook(ctx context.Context, name string) ([]byte, error) {
+	orderBook, _, err := m.GetOrderBook(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return orderBook.MarshalState()
+}
+
+// SaveSnapshot writes a snapshot of the named order book to its snapshot
+// path and returns the path and the snapshot's size. The file is replaced
+// at once, so a crash while saving leaves the previous snapshot in place.
+func (m *OrderBookManager) SaveSnapshot(ctx context.Context, name string) (string, int, error) {
+	logger := logging.FromContext(ctx).With().Str("order_book", name).Logger()
+
+	orderBook, info, err := m.GetOrderBook(ctx, name)
+	if err != nil {
+		return "", 0, err
+	}
+	if info.SnapshotPath == "" {
+		return "", 0, ErrNoSnapshotPath
+	}
+	data, err := orderBook.MarshalState()
+	if err != nil {
+		return "", 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(info.SnapshotPath), filepath.Base(info.SnapshotPath)+".*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", 0, fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", 0, fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), info.SnapshotPath); err != nil {
+		return "", 0, fmt.Errorf("replace snapshot: %w", err)
+	}
+
+	logger.Info().Str("snapshot_path", info.SnapshotPath).Int("size_bytes", len(data)).Msg("Saved order book snapshot")
+	return info.SnapshotPath, len(data), nil
+}
+
+// snapshotJSON is the response to POST /admin/snapshot/{name}
+type snapshotJSON struct {
+	OrderBook string `json:"order_book"`
+	Path      string `json:"path"`
+	SizeBytes int    `json:"size_bytes"`
+}
+
+// SnapshotHandler serves memory order book snapshots. POST
+// /admin/snapshot/{name} saves a snapshot of the book to its snapshot path;
+// GET /admin/snapshot/{name} downloads a fresh one.
+type SnapshotHandler struct {
+	manager *OrderBookManager
+}
+
+// NewSnapshotHandler creates a SnapshotHandler for the order books of manager
+func NewSnapshotHandler(manager *OrderBookManager) *SnapshotHandler {
+	return &SnapshotHandler{manager: manager}
+}
+
+// ServeHTTP saves or downloads the snapshot of the book named in the path
+func (h *SnapshotHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.FromContext(ctx)
+
+	name := strings.TrimPrefix(r.URL.Path, SnapshotPath)
+	if name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		data, err := h.manager.SnapshotOrderBook(ctx, name)
+		if err != nil {
+			h.writeError(w, r, name, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".snapshot"))
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if _, err := w.Write(data); err != nil {
+			logger.Error().Err(err).Str("order_book", name).Msg("Failed to write snapshot")
+		}
+	case http.MethodPost:
+		path, size, err := h.manager.SaveSnapshot(ctx, name)
+		if err != nil {
+			h.writeError(w, r, name, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(snapshotJSON{OrderBook: name, Path: path, SizeBytes: size}); err != nil {
+			logger.Error().Err(err).Str("order_book", name).Msg("Failed to write snapshot response")
+		}
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeError replies with the status matching a failed snapshot of book
+func (h *SnapshotHandler) writeError(w http.ResponseWriter, r *http.Request, book string, err error) {
+	switch {
+	case errors.Is(err, ErrOrderBookNotFound), errors.Is(err, ErrOrderBookDeleted):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, core.ErrSnapshotUnsupported), errors.Is(err, ErrNoSnapshotPath):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logger := logging.FromContext(r.Context())
+		logger.Error().Err(err).Str("order_book", book).Msg("Failed to snapshot order book")
+		http.Error(w, "failed to snapshot order book", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/backend/memory"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotHandler(t *testing.T) {
+	ctx := context.Background()
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	path := filepath.Join(t.TempDir(), "book.snapshot")
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	_, err := manager.CreateMemoryOrderBook(ctx, "snap-book", "", core.WithSnapshotPath(path))
+	require.NoError(t, err, "a missing snapshot file starts the book empty")
+	_, err = manager.CreateMemoryOrderBook(ctx, "plain-book", "")
+	require.NoError(t, err)
+
+	service := NewGRPCOrderBookService(manager)
+	orders := []*proto.CreateOrderRequest{
+		{OrderId: "bid", Side: proto.OrderSide_BUY, Quantity: "2.0", Price: "99.0", OrderType: proto.OrderType_LIMIT},
+		{OrderId: "ask", Side: proto.OrderSide_SELL, Quantity: "3.0", Price: "101.0", OrderType: proto.OrderType_LIMIT},
+		{OrderId: "taker", Side: proto.OrderSide_BUY, Quantity: "1.0", OrderType: proto.OrderType_MARKET},
+	}
+	for _, req := range orders {
+		req.OrderBookName = "snap-book"
+		_, err := service.CreateOrder(ctx, req)
+		require.NoError(t, err)
+	}
+
+	handler := NewSnapshotHandler(manager)
+	serve := func(method, name string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, SnapshotPath+name, nil))
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "snap-book")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var saved snapshotJSON
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &saved))
+	assert.Equal(t, snapshotJSON{OrderBook: "snap-book", Path: path, SizeBytes: saved.SizeBytes}, saved)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(saved.SizeBytes), info.Size())
+
+	rec = serve(http.MethodGet, "snap-book")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+	downloaded := core.NewOrderBook(memory.NewMemoryBackend())
+	require.NoError(t, downloaded.UnmarshalState(rec.Body.Bytes()))
+	assert.Equal(t, 2, downloaded.OrderCount())
+
+	assert.Equal(t, http.StatusConflict, serve(http.MethodPost, "plain-book").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "missing").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodDelete, "snap-book").Code)
+
+	// A book created from the saved snapshot has its orders back, the ask
+	// with what the market order left of it
+	restarted := NewOrderBookManager()
+	defer restarted.Close()
+	_, err = restarted.CreateMemoryOrderBook(ctx, "snap-book", "", core.WithSnapshotPath(path))
+	require.NoError(t, err)
+	book, _, err := restarted.GetOrderBook(ctx, "snap-book")
+	require.NoError(t, err)
+	assert.Equal(t, 2, book.OrderCount())
+	ask := book.GetOrder("ask")
+	require.NotNil(t, ask)
+	assert.Equal(t, "2.000", ask.Quantity().String())
+	price, qty, ok := book.BestBid()
+	require.True(t, ok)
+	assert.Equal(t, "99.000", price.String())
+	assert.Equal(t, "2.000", qty.String())
+
+	// A corrupt snapshot fails the create rather than starting the book empty
+	require.NoError(t, os.WriteFile(path, []byte("corrupt"), 0o600))
+	_, err = restarted.CreateMemoryOrderBook(ctx, "corrupt-book", "", core.WithSnapshotPath(path))
+	assert.Error(t, err)
+}
+
+func TestSnapshotKeepsBookState(t *testing.T) {
+	ctx := context.Background()
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	path := filepath.Join(t.TempDir(), "state.snapshot")
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	_, err := manager.CreateMemoryOrderBook(ctx, "state-book", "", core.WithSnapshotPath(path))
+	require.NoError(t, err)
+	book, _, err := manager.GetOrderBook(ctx, "state-book")
+	require.NoError(t, err)
+
+	// process runs order on whichever book is current
+	process := func(order *core.Order, err error) *core.Done {
+		t.Helper()
+		require.NoError(t, err)
+		done, err := book.Process(ctx, order)
+		require.NoError(t, err)
+		return done
+	}
+	process(core.NewLimitOrder("bid", core.Buy, fpdecimal.FromInt(2), fpdecimal.FromInt(99), core.GTC, "", "maker"))
+	process(core.NewLimitOrder("ask", core.Sell, fpdecimal.FromInt(3), fpdecimal.FromInt(101), core.GTC, "", "maker"))
+	process(core.NewPeggedOrder("peg", core.Buy, fpdecimal.FromInt(1), core.PegBestBid, fpdecimal.Zero, core.GTC, "", "test_user"))
+	process(core.NewMidpointPeggedOrder("mid", core.Sell, fpdecimal.FromInt(1), "", "test_user"))
+	last := process(core.NewMarketOrder("taker", core.Buy, fpdecimal.FromInt(1), "taker"))
+	require.NotZero(t, last.Seq())
+
+	_, _, err = manager.SaveSnapshot(ctx, "state-book")
+	require.NoError(t, err)
+
+	restarted := NewOrderBookManager()
+	defer restarted.Close()
+	_, err = restarted.CreateMemoryOrderBook(ctx, "state-book", "", core.WithSnapshotPath(path))
+	require.NoError(t, err)
+	book, _, err = restarted.GetOrderBook(ctx, "state-book")
+	require.NoError(t, err)
+	assert.Equal(t, "101.000", book.Snapshot().LastTradePrice.String())
+
+	// The midpoint order still rests in its queue and fills at the midpoint
+	done := process(core.NewMidpointPeggedOrder("mid-buy", core.Buy, fpdecimal.FromInt(1), "", "test_user"))
+	assert.Equal(t, "1.000", done.Processed.String())
+	assert.Nil(t, book.GetOrder("mid"))
+	// and numbering carries on from the last Done published before the snapshot
+	assert.Equal(t, last.Seq()+1, done.Seq())
+
+	// The pegged order still follows the best bid
+	process(core.NewLimitOrder("better-bid", core.Buy, fpdecimal.FromInt(1), fpdecimal.FromInt(100), core.GTC, "", "maker"))
+	peg := book.GetOrder("peg")
+	require.NotNil(t, peg)
+	assert.Equal(t, "100.000", peg.Price().String())
+}
+
+func TestSnapshotRestoresBackendOnlySnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	// Snapshots saved before the book's state was included hold only the backend's
+	backend := memory.NewMemoryBackend()
+	bid, err := core.NewLimitOrder("bid", core.Buy, fpdecimal.FromInt(2), fpdecimal.FromInt(99), core.GTC, "", "maker")
+	require.NoError(t, err)
+	require.NoError(t, backend.StoreOrder(bid))
+	backend.AppendToSide(core.Buy, bid)
+	data, err := backend.Snapshot()
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "old.snapshot")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	_, err = manager.CreateMemoryOrderBook(ctx, "old-book", "", core.WithSnapshotPath(path))
+	require.NoError(t, err)
+	book, _, err := manager.GetOrderBook(ctx, "old-book")
+	require.NoError(t, err)
+	assert.NotNil(t, book.GetOrder("bid"))
+}
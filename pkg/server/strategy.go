@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/nikolaydubina/fpdecimal"
+)
+
+// ErrUnknownStrategy is returned when creating an order book with a strategy
+// name that was never registered
+var ErrUnknownStrategy = errors.New("unknown book strategy")
+
+// BookStrategy is a named preset of the matching rules an order book uses
+type BookStrategy struct {
+	MatchingAlgo core.MatchingAlgo
+	// PriceImprovement fills crossing limit orders at the better price of
+	// the orders they match; without it they trade at their own limit price
+	PriceImprovement bool
+	// PostOnlyDefault makes every GTC limit order post-only
+	PostOnlyDefault bool
+	// MinFillQty is the smallest quantity an order may have
+	MinFillQty fpdecimal.Decimal
+	TickSize   fpdecimal.Decimal
+	LotSize    fpdecimal.Decimal
+}
+
+// Options returns the order book options that apply the strategy
+func (s BookStrategy) Options() []core.OrderBookOption {
+	return []core.OrderBookOption{core.WithMatchingRules(core.MatchingRules{
+		Algo:               s.MatchingAlgo,
+		NoPriceImprovement: !s.PriceImprovement,
+		PostOnlyDefault:    s.PostOnlyDefault,
+		MinQty:             s.MinFillQty,
+		TickSize:           s.TickSize,
+		LotSize:            s.LotSize,
+	})}
+}
+
+// Strategies every OrderBookManager starts with, registered as "equity",
+// "crypto" and "futures"
+var (
+	// EquityStrategy quotes prices in cents; post-only is up to each order
+	EquityStrategy = BookStrategy{
+		MatchingAlgo:     core.PriceTime,
+		PriceImprovement: true,
+		TickSize:         fpdecimal.FromIntScaled(10),
+	}
+	// CryptoStrategy accepts the finest price step the engine keeps. Crypto
+	// venues tick in 8 decimals, but prices are stored with
+	// fpdecimal.FractionDigits, so the tick is one unit of that precision.
+	CryptoStrategy = BookStrategy{
+		MatchingAlgo:     core.PriceTime,
+		PriceImprovement: true,
+		TickSize:         fpdecimal.FromIntScaled(1),
+	}
+	// FuturesStrategy shares fills pro rata among the orders at a level
+	FuturesStrategy = BookStrategy{
+		MatchingAlgo:     core.ProRata,
+		PriceImprovement: true,
+	}
+)
+
+// defaultStrategies returns the strategies a new manager is registered with
+func defaultStrategies() map[string]BookStrategy {
+	return map[string]BookStrategy{
+		"equity":  EquityStrategy,
+		"crypto":  CryptoStrategy,
+		"futures": FuturesStrategy,
+	}
+}
+
+// strategyJSON is how GET /admin/strategies shows a strategy
+type strategyJSON struct {
+	Name             string            `json:"name"`
+	MatchingAlgo     core.MatchingAlgo `json:"matching_algo"`
+	PriceImprovement bool              `json:"price_improvement"`
+	PostOnlyDefault  bool              `json:"post_only_default"`
+	MinFillQty       string            `json:"min_fill_qty"`
+	TickSize         string            `json:"tick_size"`
+	LotSize          string            `json:"lot_size"`
+}
+
+// StrategiesHandler serves GET /admin/strategies, the strategies order books
+// can be created with, as a JSON array sorted by name
+type StrategiesHandler struct {
+	manager *OrderBookManager
+}
+
+// NewStrategiesHandler creates a StrategiesHandler listing the strategies
+// registered with manager
+func NewStrategiesHandler(manager *OrderBookManager) *StrategiesHandler {
+	return &StrategiesHandler{manager: manager}
+}
+
+// ServeHTTP writes the strategy list
+func (h *StrategiesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	strategies := h.manager.BookStrategies()
+	list := make([]strategyJSON, 0, len(strategies))
+	for name, s := range strategies {
+		algo := s.MatchingAlgo
+		if algo == "" {
+			algo = core.PriceTime
+		}
+		list = append(list, strategyJSON{
+			Name:             name,
+			MatchingAlgo:     algo,
+			PriceImprovement: s.PriceImprovement,
+			PostOnlyDefault:  s.PostOnlyDefault,
+			MinFillQty:       s.MinFillQty.String(),
+			TickSize:         s.TickSize.String(),
+			LotSize:          s.LotSize.String(),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		logger := logging.FromContext(r.Context())
+		logger.Error().Err(err).Msg("Failed to write strategies")
+	}
+}
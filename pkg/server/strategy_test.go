@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateOrderBookWithStrategy(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	manager.RegisterBookStrategy("half-tick", BookStrategy{
+		MatchingAlgo:     core.PriceTime,
+		PriceImprovement: true,
+		TickSize:         fpdecimal.FromFloat(0.5),
+	})
+
+	_, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:         "strategy-book",
+		BackendType:  proto.BackendType_MEMORY,
+		StrategyName: "half-tick",
+	})
+	require.NoError(t, err)
+
+	create := func(id, price string) error {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: "strategy-book",
+			OrderId:       id,
+			Side:          proto.OrderSide_BUY,
+			Quantity:      "1.0",
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		return err
+	}
+
+	require.NoError(t, create("on-tick", "100.5"))
+	err = create("off-tick", "100.25")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, core.ErrInvalidTickSize.Error())
+
+	// Books without the strategy accept any price
+	_, err = service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: "plain-book", BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: "plain-book",
+		OrderId:       "off-tick",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.0",
+		Price:         "100.25",
+		OrderType:     proto.OrderType_LIMIT,
+	})
+	require.NoError(t, err)
+
+	_, err = service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:         "unknown-strategy",
+		BackendType:  proto.BackendType_MEMORY,
+		StrategyName: "no-such-strategy",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, _, err = manager.GetOrderBook(ctx, "unknown-strategy")
+	assert.ErrorIs(t, err, ErrOrderBookNotFound)
+}
+
+func TestCreateOrderBookTickAndLotSize(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	// The request's lot size is added to the equity strategy's cent tick
+	resp, err := service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:         "sized-book",
+		BackendType:  proto.BackendType_MEMORY,
+		StrategyName: "equity",
+		LotSize:      "0.5",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "0.010", resp.TickSize)
+	assert.Equal(t, "0.500", resp.LotSize)
+
+	// A tick size in the request replaces the strategy's
+	resp, err = service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:         "wide-tick-book",
+		BackendType:  proto.BackendType_MEMORY,
+		StrategyName: "equity",
+		TickSize:     "0.25",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "0.250", resp.TickSize)
+	assert.Equal(t, "0", resp.LotSize)
+
+	got, err := service.GetOrderBook(ctx, &proto.GetOrderBookRequest{Name: "wide-tick-book"})
+	require.NoError(t, err)
+	assert.Equal(t, "0.250", got.TickSize)
+
+	create := func(book, id, quantity, price string) error {
+		_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: book,
+			OrderId:       id,
+			Side:          proto.OrderSide_BUY,
+			Quantity:      quantity,
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+		})
+		return err
+	}
+	require.NoError(t, create("sized-book", "on-lot", "1.5", "100.01"))
+	err = create("sized-book", "off-lot", "1.25", "100.01")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, core.ErrInvalidQuantity.Error())
+	require.NoError(t, create("wide-tick-book", "on-tick", "1", "100.75"))
+	err = create("wide-tick-book", "off-tick", "1", "100.01")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, core.ErrInvalidPrice.Error())
+
+	_, err = service.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{
+		Name:        "bad-sizes",
+		BackendType: proto.BackendType_MEMORY,
+		TickSize:    "-1",
+		LotSize:     "lots",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, "tick_size")
+	assert.ErrorContains(t, err, "lot_size")
+	_, _, err = manager.GetOrderBook(ctx, "bad-sizes")
+	assert.ErrorIs(t, err, ErrOrderBookNotFound)
+}
+
+func TestStrategiesHandler(t *testing.T) {
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	manager.RegisterBookStrategy("custom", BookStrategy{LotSize: fpdecimal.FromInt(10)})
+
+	handler := NewStrategiesHandler(manager)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/strategies", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var list []strategyJSON
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	names := make([]string, len(list))
+	for i, s := range list {
+		names[i] = s.Name
+	}
+	assert.Equal(t, []string{"crypto", "custom", "equity", "futures"}, names)
+	assert.Equal(t, strategyJSON{
+		Name:         "custom",
+		MatchingAlgo: core.PriceTime,
+		MinFillQty:   "0",
+		TickSize:     "0",
+		LotSize:      "10.000",
+	}, list[1])
+	assert.Equal(t, core.ProRata, list[3].MatchingAlgo)
+	assert.Equal(t, "0.010", list[2].TickSize)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/strategies", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
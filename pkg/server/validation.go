@@ -0,0 +1,173 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+)
+
+const (
+	// DefaultMaxOrderIDLength is the longest order ID accepted by default
+	DefaultMaxOrderIDLength = 128
+	// DefaultMaxBookNameLength is the longest order book name accepted by default
+	DefaultMaxBookNameLength = 64
+	// DefaultMaxDecimal is the largest quantity or price accepted by default, 10^18
+	DefaultMaxDecimal int64 = 1_000_000_000_000_000_000
+)
+
+var (
+	identifierPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	addressPattern    = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	decimalPattern    = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+)
+
+// FieldValidator checks the value of one request field. It returns a
+// description of what is wrong with value, or "" if it is valid.
+type FieldValidator func(value string) string
+
+// IdentifierValidator accepts non-empty values of at most maxLength letters,
+// digits, underscores and hyphens
+func IdentifierValidator(maxLength int) FieldValidator {
+	return func(value string) string {
+		switch {
+		case value == "":
+			return "must not be empty"
+		case len(value) > maxLength:
+			return fmt.Sprintf("must be at most %d characters", maxLength)
+		case !identifierPattern.MatchString(value):
+			return "must contain only letters, digits, underscores and hyphens"
+		}
+		return ""
+	}
+}
+
+// AddressValidator accepts 0x-prefixed, 40 hex digit Ethereum addresses in
+// any letter case
+func AddressValidator(value string) string {
+	if !addressPattern.MatchString(value) {
+		return "must be an Ethereum address: 0x followed by 40 hex digits"
+	}
+	return ""
+}
+
+// DecimalValidator accepts plain decimal numbers between 0 and max inclusive
+func DecimalValidator(max int64) FieldValidator {
+	limit := new(big.Rat).SetInt64(max)
+	return func(value string) string {
+		if !decimalPattern.MatchString(value) {
+			return "must be a decimal number"
+		}
+		// The pattern only admits values SetString can parse
+		d, _ := new(big.Rat).SetString(value)
+		if d.Sign() < 0 {
+			return "must not be negative"
+		}
+		if d.Cmp(limit) > 0 {
+			return fmt.Sprintf("must be at most %d", max)
+		}
+		return ""
+	}
+}
+
+// RequestValidator checks the identifiers, user addresses and decimal strings
+// carried by requests before they reach an order book. Each kind of field has
+// its own FieldValidator, which ValidatorOptions can replace.
+type RequestValidator struct {
+	orderID  FieldValidator
+	bookName FieldValidator
+	address  FieldValidator
+	decimal  FieldValidator
+}
+
+// ValidatorOption configures a RequestValidator
+type ValidatorOption func(*RequestValidator)
+
+// WithOrderIDValidator sets the validator for order IDs, including OCO IDs
+func WithOrderIDValidator(validator FieldValidator) ValidatorOption {
+	return func(v *RequestValidator) {
+		v.orderID = validator
+	}
+}
+
+// WithBookNameValidator sets the validator for order book names
+func WithBookNameValidator(validator FieldValidator) ValidatorOption {
+	return func(v *RequestValidator) {
+		v.bookName = validator
+	}
+}
+
+// WithAddressValidator sets the validator for user addresses
+func WithAddressValidator(validator FieldValidator) ValidatorOption {
+	return func(v *RequestValidator) {
+		v.address = validator
+	}
+}
+
+// WithDecimalValidator sets the validator for quantities and prices
+func WithDecimalValidator(validator FieldValidator) ValidatorOption {
+	return func(v *RequestValidator) {
+		v.decimal = validator
+	}
+}
+
+// NewRequestValidator creates a validator with the default limits, changed
+// by opts
+func NewRequestValidator(opts ...ValidatorOption) *RequestValidator {
+	v := &RequestValidator{
+		orderID:  IdentifierValidator(DefaultMaxOrderIDLength),
+		bookName: IdentifierValidator(DefaultMaxBookNameLength),
+		address:  AddressValidator,
+		decimal:  DecimalValidator(DefaultMaxDecimal),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// ValidateCreateOrderBook returns every violation in req
+func (v *RequestValidator) ValidateCreateOrderBook(req *proto.CreateOrderBookRequest) []Violation {
+	var violations []Violation
+	check(&violations, "name", req.Name, v.bookName)
+	return violations
+}
+
+// ValidateCreateOrder returns every violation in req. The user address, OCO
+// ID, client order ID, price and stop price are optional and only checked when set; whether
+// an order type requires them is left to the order itself.
+func (v *RequestValidator) ValidateCreateOrder(req *proto.CreateOrderRequest) []Violation {
+	var violations []Violation
+	check(&violations, "order_book_name", req.OrderBookName, v.bookName)
+	check(&violations, "order_id", req.OrderId, v.orderID)
+	checkOptional(&violations, "oco_id", req.OcoId, v.orderID)
+	checkOptional(&violations, "client_order_id", req.ClientOrderId, v.orderID)
+	checkOptional(&violations, "user_address", req.UserAddress, v.address)
+	check(&violations, "quantity", req.Quantity, v.decimal)
+	checkOptional(&violations, "price", req.Price, v.decimal)
+	checkOptional(&violations, "stop_price", req.StopPrice, v.decimal)
+	return violations
+}
+
+// ValidateGetPositions returns every violation in req. The user address is
+// an optional filter.
+func (v *RequestValidator) ValidateGetPositions(req *proto.GetPositionsRequest) []Violation {
+	var violations []Violation
+	checkOptional(&violations, "user_address", req.UserAddress, v.address)
+	return violations
+}
+
+// check appends a violation for field when validator rejects value
+func check(violations *[]Violation, field, value string, validator FieldValidator) {
+	if description := validator(value); description != "" {
+		*violations = append(*violations, Violation{Field: field, Description: description})
+	}
+}
+
+// checkOptional is check for fields that may be left empty
+func checkOptional(violations *[]Violation, field, value string, validator FieldValidator) {
+	if value != "" {
+		check(violations, field, value, validator)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// validCreateOrderRequest returns a request that passes every check
+func validCreateOrderRequest() *proto.CreateOrderRequest {
+	return &proto.CreateOrderRequest{
+		OrderBookName: "BTC-USD",
+		OrderId:       "order_1",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "1.5",
+		Price:         "50000",
+		StopPrice:     "49000.25",
+		OcoId:         "oco-1",
+		UserAddress:   "0x1234567890abcdefABCDEF1234567890abcdef12",
+		OrderType:     proto.OrderType_STOP_LIMIT,
+	}
+}
+
+func TestRequestValidator_CreateOrder(t *testing.T) {
+	validator := NewRequestValidator()
+	assert.Empty(t, validator.ValidateCreateOrder(validCreateOrderRequest()))
+
+	tests := []struct {
+		name        string
+		modify      func(req *proto.CreateOrderRequest)
+		field       string
+		description string
+	}{
+		{"EmptyBookName", func(r *proto.CreateOrderRequest) { r.OrderBookName = "" }, "order_book_name", "must not be empty"},
+		{"LongBookName", func(r *proto.CreateOrderRequest) { r.OrderBookName = strings.Repeat("b", 65) }, "order_book_name", "must be at most 64 characters"},
+		{"BookNameWithSlash", func(r *proto.CreateOrderRequest) { r.OrderBookName = "BTC/USD" }, "order_book_name", "must contain only letters, digits, underscores and hyphens"},
+		{"BookNameWithSpace", func(r *proto.CreateOrderRequest) { r.OrderBookName = "BTC USD" }, "order_book_name", "must contain only letters, digits, underscores and hyphens"},
+		{"EmptyOrderID", func(r *proto.CreateOrderRequest) { r.OrderId = "" }, "order_id", "must not be empty"},
+		{"LongOrderID", func(r *proto.CreateOrderRequest) { r.OrderId = strings.Repeat("o", 129) }, "order_id", "must be at most 128 characters"},
+		{"OrderIDWithDot", func(r *proto.CreateOrderRequest) { r.OrderId = "order.1" }, "order_id", "must contain only letters, digits, underscores and hyphens"},
+		{"OrderIDWithNUL", func(r *proto.CreateOrderRequest) { r.OrderId = "order\x00" }, "order_id", "must contain only letters, digits, underscores and hyphens"},
+		{"OCOIDWithColon", func(r *proto.CreateOrderRequest) { r.OcoId = "oco:1" }, "oco_id", "must contain only letters, digits, underscores and hyphens"},
+		{"ShortAddress", func(r *proto.CreateOrderRequest) { r.UserAddress = "0x1234" }, "user_address", "must be an Ethereum address: 0x followed by 40 hex digits"},
+		{"AddressWithoutPrefix", func(r *proto.CreateOrderRequest) { r.UserAddress = strings.Repeat("ab", 20) }, "user_address", "must be an Ethereum address: 0x followed by 40 hex digits"},
+		{"AddressNotHex", func(r *proto.CreateOrderRequest) { r.UserAddress = "0x" + strings.Repeat("zz", 20) }, "user_address", "must be an Ethereum address: 0x followed by 40 hex digits"},
+		{"EmptyQuantity", func(r *proto.CreateOrderRequest) { r.Quantity = "" }, "quantity", "must be a decimal number"},
+		{"ExponentQuantity", func(r *proto.CreateOrderRequest) { r.Quantity = "1e3" }, "quantity", "must be a decimal number"},
+		{"PaddedQuantity", func(r *proto.CreateOrderRequest) { r.Quantity = " 1" }, "quantity", "must be a decimal number"},
+		{"NegativeQuantity", func(r *proto.CreateOrderRequest) { r.Quantity = "-1" }, "quantity", "must not be negative"},
+		{"HugeQuantity", func(r *proto.CreateOrderRequest) { r.Quantity = "1000000000000000000.001" }, "quantity", "must be at most 1000000000000000000"},
+		{"WordPrice", func(r *proto.CreateOrderRequest) { r.Price = "abc" }, "price", "must be a decimal number"},
+		{"BareFractionPrice", func(r *proto.CreateOrderRequest) { r.Price = ".5" }, "price", "must be a decimal number"},
+		{"TrailingDotStopPrice", func(r *proto.CreateOrderRequest) { r.StopPrice = "1." }, "stop_price", "must be a decimal number"},
+		{"HugeStopPrice", func(r *proto.CreateOrderRequest) { r.StopPrice = "99999999999999999999" }, "stop_price", "must be at most 1000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validCreateOrderRequest()
+			tt.modify(req)
+			assert.Equal(t, []Violation{{Field: tt.field, Description: tt.description}}, validator.ValidateCreateOrder(req))
+		})
+	}
+
+	t.Run("ReportsEveryViolation", func(t *testing.T) {
+		req := validCreateOrderRequest()
+		req.OrderBookName = "BTC/USD"
+		req.OrderId = ""
+		req.Quantity = "-1"
+		violations := validator.ValidateCreateOrder(req)
+		assert.Len(t, violations, 3)
+	})
+
+	t.Run("OptionalFields", func(t *testing.T) {
+		req := validCreateOrderRequest()
+		req.OcoId, req.UserAddress, req.Price, req.StopPrice = "", "", "", ""
+		assert.Empty(t, validator.ValidateCreateOrder(req))
+	})
+}
+
+func TestRequestValidator_Options(t *testing.T) {
+	validator := NewRequestValidator(
+		WithDecimalValidator(DecimalValidator(100)),
+		WithAddressValidator(func(string) string { return "" }),
+	)
+
+	req := validCreateOrderRequest()
+	req.UserAddress = "alice"
+	assert.Equal(t, []Violation{
+		{Field: "price", Description: "must be at most 100"},
+		{Field: "stop_price", Description: "must be at most 100"},
+	}, validator.ValidateCreateOrder(req))
+
+	assert.Equal(t, []Violation{{Field: "name", Description: "must be at most 64 characters"}},
+		validator.ValidateCreateOrderBook(&proto.CreateOrderBookRequest{Name: strings.Repeat("n", 65)}))
+}
+
+func FuzzRequestValidator(f *testing.F) {
+	for _, seed := range []string{"", "BTC-USD", "0x1234567890abcdef1234567890abcdef12345678", "-12.5", "1e3", "\xff\x00", strings.Repeat("9", 40)} {
+		f.Add([]byte(seed))
+	}
+
+	validator := NewRequestValidator()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		value := string(data)
+		validator.ValidateCreateOrder(&proto.CreateOrderRequest{
+			OrderBookName: value,
+			OrderId:       value,
+			OcoId:         value,
+			UserAddress:   value,
+			Quantity:      value,
+			Price:         value,
+			StopPrice:     value,
+		})
+		validator.ValidateCreateOrderBook(&proto.CreateOrderBookRequest{Name: value})
+	})
+}
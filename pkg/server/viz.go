@@ -0,0 +1,186 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/logging"
+	"github.com/nikolaydubina/fpdecimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultVizLevels is how many price levels per side /viz shows when no levels are given
+	DefaultVizLevels = 10
+
+	// vizBarWidth is the width in characters of the bar for the largest level
+	vizBarWidth = 50
+
+	// vizRefreshSeconds is how often the HTML chart reloads itself
+	vizRefreshSeconds = 5
+
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+// VizHandler serves GET /viz?book=<name>&levels=<n>, an ASCII depth chart of
+// an order book. Asks are drawn above the midpoint and bids below it, each bar
+// as wide as its level's quantity relative to the largest level shown.
+//
+// Clients accepting text/html get the chart in a page that refreshes every
+// five seconds. Clients accepting text/plain with a color parameter, such as
+// "text/plain; color=true", get ANSI colored text. Everyone else gets plain text.
+type VizHandler struct {
+	service *GRPCOrderBookService
+}
+
+// NewVizHandler creates a VizHandler that reads order book state from service
+func NewVizHandler(service *GRPCOrderBookService) *VizHandler {
+	return &VizHandler{service: service}
+}
+
+// ServeHTTP renders the chart
+func (h *VizHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	name := query.Get("book")
+	if name == "" {
+		http.Error(w, "book is required", http.StatusBadRequest)
+		return
+	}
+	levels := DefaultVizLevels
+	if value := query.Get("levels"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > MaxStateDepth {
+			http.Error(w, fmt.Sprintf("levels must be between 1 and %d", MaxStateDepth), http.StatusBadRequest)
+			return
+		}
+		levels = n
+	}
+
+	state, err := h.service.GetOrderBookState(r.Context(), &proto.GetOrderBookStateRequest{
+		Name:  name,
+		Depth: int32(levels),
+	})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			http.Error(w, status.Convert(err).Message(), http.StatusNotFound)
+		case codes.InvalidArgument:
+			http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+		default:
+			logger.Error().Err(err).Str("order_book", name).Msg("Failed to get order book state for chart")
+			http.Error(w, "failed to get order book state", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	accept := r.Header.Get("Accept")
+	if acceptsHTML(accept) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html><head><meta http-equiv=\"refresh\" content=\"%d\"><title>%s</title></head><body><pre>\n",
+			vizRefreshSeconds, html.EscapeString(name))
+		fmt.Fprint(w, html.EscapeString(renderDepthChart(state, false)))
+		fmt.Fprint(w, "</pre></body></html>\n")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, renderDepthChart(state, acceptsColor(accept)))
+}
+
+// acceptsHTML reports whether the Accept header lists text/html
+func acceptsHTML(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		if mediaType, _, err := mime.ParseMediaType(part); err == nil && mediaType == "text/html" {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsColor reports whether the Accept header lists text/plain with a
+// color parameter that is not false
+func acceptsColor(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil || mediaType != "text/plain" {
+			continue
+		}
+		if value, ok := params["color"]; ok {
+			if enabled, err := strconv.ParseBool(value); err != nil || enabled {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderDepthChart draws state as one header line, one line per ask from the
+// highest price down, a midpoint line and one line per bid from the highest
+// price down
+func renderDepthChart(state *proto.OrderBookStateResponse, color bool) string {
+	var maxQuantity float64
+	width := 0
+	for _, levels := range [][]*proto.PriceLevel{state.Asks, state.Bids} {
+		for _, level := range levels {
+			if quantity := parseLevelDecimal(level.TotalQuantity).Float64(); quantity > maxQuantity {
+				maxQuantity = quantity
+			}
+			width = max(width, len(level.Price))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d asks, %d bids\n", state.Name, len(state.Asks), len(state.Bids))
+
+	line := func(level *proto.PriceLevel, code string) {
+		n := 0
+		if maxQuantity > 0 {
+			n = int(parseLevelDecimal(level.TotalQuantity).Float64() / maxQuantity * vizBarWidth)
+		}
+		bar := strings.Repeat("#", max(n, 1))
+		if color {
+			bar = code + bar + ansiReset
+		}
+		fmt.Fprintf(&b, "%*s | %s %s\n", width, level.Price, bar, level.TotalQuantity)
+	}
+
+	// Asks arrive best first, so walk them backwards to put the best ask next to the midpoint
+	for i := len(state.Asks) - 1; i >= 0; i-- {
+		line(state.Asks[i], ansiRed)
+	}
+
+	mid := "n/a"
+	if len(state.Asks) > 0 && len(state.Bids) > 0 {
+		mid = core.MidPrice(parseLevelDecimal(state.Bids[0].Price), parseLevelDecimal(state.Asks[0].Price)).String()
+	}
+	fmt.Fprintf(&b, "%*s + mid %s\n", width, "", mid)
+
+	for _, level := range state.Bids {
+		line(level, ansiGreen)
+	}
+	return b.String()
+}
+
+// parseLevelDecimal parses a price or quantity produced by GetOrderBookState,
+// which is always a valid decimal
+func parseLevelDecimal(value string) fpdecimal.Decimal {
+	d, _ := fpdecimal.FromString(value)
+	return d
+}
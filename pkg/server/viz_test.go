@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVizHandler(t *testing.T) {
+	ctx := context.Background()
+	manager := NewOrderBookManager()
+	defer manager.Close()
+	service := NewGRPCOrderBookService(manager)
+
+	sender := messaging.NewMockMessageSender()
+	core.SetMessageSenderFactory(func() messaging.MessageSender { return sender })
+	defer core.SetMessageSenderFactory(nil)
+
+	_, err := manager.CreateMemoryOrderBook(ctx, "viz-book", "")
+	require.NoError(t, err)
+
+	// Five bids at 96-100 and five asks at 110-114, bigger further from the spread
+	for i := 0; i < 5; i++ {
+		for _, side := range []proto.OrderSide{proto.OrderSide_BUY, proto.OrderSide_SELL} {
+			price := 100 - i
+			if side == proto.OrderSide_SELL {
+				price = 110 + i
+			}
+			_, err := service.CreateOrder(ctx, &proto.CreateOrderRequest{
+				OrderBookName: "viz-book",
+				OrderId:       fmt.Sprintf("%s-%d", side, i),
+				Side:          side,
+				Quantity:      fmt.Sprintf("%d.0", i+1),
+				Price:         fmt.Sprintf("%d.0", price),
+				OrderType:     proto.OrderType_LIMIT,
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	handler := NewVizHandler(service)
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/viz?book=viz-book", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	// Header, five asks, the midpoint and five bids
+	require.Len(t, lines, 12)
+	assert.Contains(t, lines[6], "mid 105.000")
+	assert.True(t, strings.HasPrefix(lines[1], "114.000 |"), "highest ask first: %q", lines[1])
+	assert.True(t, strings.HasPrefix(lines[5], "110.000 |"), "best ask above the midpoint: %q", lines[5])
+	assert.True(t, strings.HasPrefix(lines[7], "100.000 |"), "best bid below the midpoint: %q", lines[7])
+	assert.Equal(t, strings.Count(lines[1], "#"), vizBarWidth, "the largest level gets the full bar")
+	assert.Equal(t, strings.Count(lines[7], "#"), vizBarWidth/5)
+	assert.NotContains(t, body, "\x1b[")
+
+	rec = get("/viz?book=viz-book&levels=2", "text/plain; color=true")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n"), 6)
+	assert.Contains(t, rec.Body.String(), ansiRed)
+	assert.Contains(t, rec.Body.String(), ansiGreen)
+
+	rec = get("/viz?book=viz-book", "text/html,application/xhtml+xml")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `<meta http-equiv="refresh" content="5">`)
+	assert.Contains(t, rec.Body.String(), "mid 105.000")
+
+	assert.Equal(t, http.StatusBadRequest, get("/viz", "").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/viz?book=viz-book&levels=0", "").Code)
+	assert.Equal(t, http.StatusNotFound, get("/viz?book=missing", "").Code)
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/erain9/matchingo/pkg/otel"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultPongTimeout is how long the hub waits for a pong after sending a ping
+const DefaultPongTimeout = 10 * time.Second
+
+// WebSocketHub tracks streaming WebSocket connections and their lifecycle
+type WebSocketHub struct {
+	mu          sync.Mutex
+	subscribers map[*websocket.Conn]chan struct{}
+	wg          sync.WaitGroup
+	pongTimeout time.Duration
+	metrics     *otel.WebSocketMetrics
+}
+
+// NewWebSocketHub creates a new WebSocketHub
+func NewWebSocketHub() *WebSocketHub {
+	return &WebSocketHub{
+		subscribers: make(map[*websocket.Conn]chan struct{}),
+		pongTimeout: DefaultPongTimeout,
+		metrics:     otel.GetWebSocketMetrics(),
+	}
+}
+
+// SetPongTimeout sets how long to wait for a pong before the connection is considered dead
+func (h *WebSocketHub) SetPongTimeout(timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pongTimeout = timeout
+}
+
+// SubscriberCount returns the number of connections currently registered with the hub
+func (h *WebSocketHub) SubscriberCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// StartHeartbeat registers conn with the hub and pings it every interval.
+// If no pong arrives within the pong timeout the connection is closed and removed.
+// The hub takes over reading from conn; incoming data messages are discarded.
+func (h *WebSocketHub) StartHeartbeat(conn *websocket.Conn, interval time.Duration) {
+	h.mu.Lock()
+	if _, exists := h.subscribers[conn]; exists {
+		h.mu.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	h.subscribers[conn] = done
+	h.wg.Add(1)
+	pongTimeout := h.pongTimeout
+	h.mu.Unlock()
+
+	h.metrics.RecordConnectionOpened(context.Background())
+
+	// A missed pong shows up as a read deadline error in the read loop
+	deadline := interval + pongTimeout
+	conn.SetReadDeadline(time.Now().Add(deadline))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(deadline))
+	})
+
+	go func() {
+		defer h.remove(conn)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pongTimeout)); err != nil {
+					h.remove(conn)
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Closed returns a channel that is closed once conn has been removed from
+// the hub. For connections the hub does not hold, it is already closed.
+func (h *WebSocketHub) Closed(conn *websocket.Conn) <-chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if done, exists := h.subscribers[conn]; exists {
+		return done
+	}
+	closed := make(chan struct{})
+	close(closed)
+	return closed
+}
+
+// remove closes conn and drops it from the hub. It is safe to call more than once.
+func (h *WebSocketHub) remove(conn *websocket.Conn) {
+	h.mu.Lock()
+	done, exists := h.subscribers[conn]
+	if !exists {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.subscribers, conn)
+	h.mu.Unlock()
+
+	close(done)
+	conn.Close()
+	h.metrics.RecordConnectionClosed(context.Background())
+	h.wg.Done()
+}
+
+// Shutdown sends a close frame to every connection and waits for them to
+// drain. Connections still open when ctx expires are closed forcibly.
+func (h *WebSocketHub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	conns := make([]*websocket.Conn, 0, len(h.subscribers))
+	for conn := range h.subscribers {
+		conns = append(conns, conn)
+	}
+	h.mu.Unlock()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, conn := range conns {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(time.Second)
+		}
+		if err := conn.WriteControl(websocket.CloseMessage, msg, deadline); err != nil {
+			h.remove(conn)
+		}
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		for _, conn := range conns {
+			h.remove(conn)
+		}
+		return ctx.Err()
+	}
+}
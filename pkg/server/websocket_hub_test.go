@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// droppableConn simulates a connection that silently dies: once dropped,
+// writes are swallowed so the peer never sees them
+type droppableConn struct {
+	net.Conn
+	dropped atomic.Bool
+}
+
+func (c *droppableConn) Write(b []byte) (int, error) {
+	if c.dropped.Load() {
+		return len(b), nil
+	}
+	return c.Conn.Write(b)
+}
+
+func newHubTestServer(t *testing.T, hub *WebSocketHub, interval time.Duration) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		hub.StartHeartbeat(conn, interval)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// dialHub connects to srv and returns the client connection and its underlying droppable transport
+func dialHub(t *testing.T, srv *httptest.Server, readLoop bool) (*websocket.Conn, *droppableConn) {
+	var transport *droppableConn
+	dialer := websocket.Dialer{
+		NetDial: func(network, addr string) (net.Conn, error) {
+			c, err := net.Dial(network, addr)
+			if err != nil {
+				return nil, err
+			}
+			transport = &droppableConn{Conn: c}
+			return transport, nil
+		},
+	}
+
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	// Reading is what makes the client answer pings and close frames
+	if readLoop {
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+	}
+	return conn, transport
+}
+
+func TestWebSocketHub_DroppedConnection(t *testing.T) {
+	hub := NewWebSocketHub()
+	hub.SetPongTimeout(50 * time.Millisecond)
+	srv := newHubTestServer(t, hub, 20*time.Millisecond)
+
+	_, transport := dialHub(t, srv, true)
+	require.Eventually(t, func() bool { return hub.SubscriberCount() == 1 }, time.Second, 5*time.Millisecond)
+
+	// A healthy client keeps answering pings
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, hub.SubscriberCount())
+
+	// Once pongs stop arriving the hub drops the connection
+	transport.dropped.Store(true)
+	assert.Eventually(t, func() bool { return hub.SubscriberCount() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestWebSocketHub_Shutdown(t *testing.T) {
+	hub := NewWebSocketHub()
+	srv := newHubTestServer(t, hub, time.Minute)
+
+	dialHub(t, srv, true)
+	dialHub(t, srv, true)
+	require.Eventually(t, func() bool { return hub.SubscriberCount() == 2 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, hub.Shutdown(ctx))
+	assert.Equal(t, 0, hub.SubscriberCount())
+}
+
+func TestWebSocketHub_ShutdownTimeout(t *testing.T) {
+	hub := NewWebSocketHub()
+	srv := newHubTestServer(t, hub, time.Minute)
+
+	// A client that never reads never acknowledges the close frame
+	dialHub(t, srv, false)
+	require.Eventually(t, func() bool { return hub.SubscriberCount() == 1 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, hub.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Equal(t, 0, hub.SubscriberCount())
+}
@@ -0,0 +1,132 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/erain9/matchingo/pkg/backend/memory"
+	redisbackend "github.com/erain9/matchingo/pkg/backend/redis"
+	"github.com/erain9/matchingo/pkg/core"
+	"github.com/nikolaydubina/fpdecimal"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	// priceLevels is the number of resting sell orders, one per price level
+	priceLevels = 100
+	// levelsPerOrder is how many price levels each market buy consumes
+	levelsPerOrder = 5
+
+	// Minimum acceptable throughput in orders/sec. The CI bench step fails
+	// when a benchmark reports less than this.
+	minMemoryOrdersPerSec     = 5000
+	minRedisOrdersPerSec      = 500
+	minConcurrentOrdersPerSec = 2000
+)
+
+// fillAsks rests priceLevels sell orders of quantity 1 at consecutive prices
+func fillAsks(b *testing.B, book *core.OrderBook, round int) {
+	for i := 0; i < priceLevels; i++ {
+		price := fpdecimal.FromInt(int64(10000 + i))
+		o, err := core.NewLimitOrder(fmt.Sprintf("sell-%d-%d", round, i), core.Sell, fpdecimal.FromInt(1), price, core.GTC, "", "bench_user")
+		require.NoError(b, err)
+		_, err = book.Process(context.Background(), o)
+		require.NoError(b, err)
+	}
+}
+
+// reportThroughput reports orders/sec and fails the benchmark if it is below min.
+// The single-iteration calibration run is not checked since it is dominated by setup noise.
+func reportThroughput(b *testing.B, min float64) {
+	throughput := float64(b.N) / b.Elapsed().Seconds()
+	b.ReportMetric(throughput, "orders/sec")
+	if b.N > 1 && throughput < min {
+		b.Errorf("throughput %.0f orders/sec is below threshold %.0f", throughput, min)
+	}
+}
+
+func benchmarkOrderMatching(b *testing.B, backend core.OrderBookBackend, min float64) {
+	book := core.NewOrderBook(backend)
+	round := 0
+	fillAsks(b, book, round)
+	remaining := priceLevels
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if remaining < levelsPerOrder {
+			b.StopTimer()
+			round++
+			fillAsks(b, book, round)
+			remaining += priceLevels
+			b.StartTimer()
+		}
+
+		o, err := core.NewMarketOrder(fmt.Sprintf("buy-%d", n), core.Buy, fpdecimal.FromInt(levelsPerOrder), "bench_user")
+		require.NoError(b, err)
+		done, err := book.Process(context.Background(), o)
+		require.NoError(b, err)
+		require.Len(b, done.Trades, levelsPerOrder+1)
+		remaining -= levelsPerOrder
+	}
+	b.StopTimer()
+
+	reportThroughput(b, min)
+}
+
+func BenchmarkMemoryBackendOrderMatching(b *testing.B) {
+	benchmarkOrderMatching(b, memory.NewMemoryBackend(), minMemoryOrdersPerSec)
+}
+
+func BenchmarkRedisBackendOrderMatching(b *testing.B) {
+	mr := miniredis.RunT(b)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	backend, err := redisbackend.NewRedisBackend(client, "bench", zap.NewNop())
+	require.NoError(b, err)
+	defer backend.Release()
+
+	benchmarkOrderMatching(b, backend, minRedisOrdersPerSec)
+}
+
+// BenchmarkConcurrentMatching submits crossing limit orders from many goroutines
+// against a single memory-backed book.
+func BenchmarkConcurrentMatching(b *testing.B) {
+	book := core.NewOrderBook(memory.NewMemoryBackend())
+	fillAsks(b, book, 0)
+	var seq atomic.Int64
+
+	b.ReportAllocs()
+	b.SetParallelism(8)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n := seq.Add(1)
+			side := core.Buy
+			if n%2 == 0 {
+				side = core.Sell
+			}
+			price := fpdecimal.FromInt(10000 + n%levelsPerOrder)
+			o, err := core.NewLimitOrder(fmt.Sprintf("order-%d", n), side, fpdecimal.FromInt(1), price, core.GTC, "", "bench_user")
+			if err != nil {
+				b.Error(err)
+				return
+			}
+
+			_, err = book.Process(context.Background(), o)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.StopTimer()
+
+	reportThroughput(b, minConcurrentOrdersPerSec)
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/erain9/matchingo/pkg/api/proto"
+	"github.com/erain9/matchingo/pkg/db/queue"
+	"github.com/erain9/matchingo/pkg/messaging"
+	"github.com/erain9/matchingo/pkg/messaging/kafka"
 	testutil "github.com/erain9/matchingo/test/utils"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
@@ -74,7 +77,8 @@ func TestDockerIntegration_FullFlow(t *testing.T) {
 
 		// Verify state - the stop order should have been triggered and matched
 		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get order book state")
 
@@ -97,7 +101,8 @@ func TestDockerIntegration_FullFlow(t *testing.T) {
 
 		// Verify final state - all orders should be matched
 		finalStateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get final order book state")
 
@@ -112,3 +117,59 @@ func TestDockerIntegration_FullFlow(t *testing.T) {
 		require.NoError(t, err, "Failed to delete order book")
 	})
 }
+
+// TestDockerIntegration_TopicPrefix sends done messages to the topic of each
+// order book and consumes them with the same prefix
+func TestDockerIntegration_TopicPrefix(t *testing.T) {
+	testutil.WithKafkaOnly(t, func(kafkaAddr string) {
+		router := messaging.PrefixRouter{Prefix: "matchingo-prefix-test"}
+		sender, err := kafka.NewKafkaMessageSender(kafkaAddr, "matchingo-test", kafka.WithTopicRouter(router))
+		require.NoError(t, err)
+		defer sender.Close()
+
+		queue.SetBrokerList(kafkaAddr)
+		defer queue.SetBrokerList("localhost:9092")
+		consumer, err := queue.NewQueueMessageConsumer(queue.ConsumerConfig{
+			GroupID:              "topic-prefix-test",
+			TopicPrefix:          router.Prefix,
+			TopicRefreshInterval: 100 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		received := make(chan *messaging.DoneMessage, 100)
+		go func() {
+			_ = consumer.ConsumeDoneMessages(batchFunc(func(_ context.Context, msgs []*messaging.DoneMessage) error {
+				for _, msg := range msgs {
+					received <- msg
+				}
+				return nil
+			}))
+		}()
+		defer consumer.Close()
+
+		// The group starts from the newest messages once it has joined, so
+		// keep sending until a message of each book arrives
+		books := map[string]bool{"btc-usd": false, "eth-usd": false}
+		ctx := context.Background()
+		deadline := time.After(60 * time.Second)
+		for !books["btc-usd"] || !books["eth-usd"] {
+			for book := range books {
+				require.NoError(t, sender.SendDoneMessage(ctx, &messaging.DoneMessage{OrderID: book + "-order", OrderBookName: book}))
+			}
+			select {
+			case msg := <-received:
+				books[msg.OrderBookName] = true
+			case <-time.After(500 * time.Millisecond):
+			case <-deadline:
+				t.Fatalf("done messages of every book not consumed: %v", books)
+			}
+		}
+	})
+}
+
+// batchFunc adapts a function to queue.BatchProcessor
+type batchFunc func(ctx context.Context, msgs []*messaging.DoneMessage) error
+
+func (f batchFunc) ProcessBatch(ctx context.Context, msgs []*messaging.DoneMessage) error {
+	return f(ctx, msgs)
+}
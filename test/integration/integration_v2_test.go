@@ -77,6 +77,8 @@ func setupIntegrationTestV2(tb testing.TB) (proto.OrderBookServiceClient, *messa
 
 	// Teardown function
 	teardown := func() {
+		// Discard late messages so they cannot leak into the next test
+		mockSenderV2.DrainMessages(context.Background(), 10*time.Millisecond)
 		core.SetMessageSenderFactory(nil) // Reset sender factory to default
 		require.NoError(tb, conn.Close())
 		grpcServer.Stop()
@@ -188,7 +190,7 @@ func TestIntegrationV2_BasicLimitOrder(t *testing.T) {
 	assert.Equal(t, proto.OrderStatus_OPEN, orderResp.Status) // Order should be open
 
 	// 3. Verify Order Book State
-	stateReq := &proto.GetOrderBookStateRequest{Name: bookName}
+	stateReq := &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20}
 	stateResp, err := client.GetOrderBookState(ctx, stateReq)
 	require.NoError(t, err, "GetOrderBookState failed")
 	require.Len(t, stateResp.Bids, 1, "Expected 1 bid level")
@@ -259,7 +261,7 @@ func TestIntegrationV2_LimitOrderMatch(t *testing.T) {
 	assert.Equal(t, buyResp.Status, buyResp.Status) // Buy order fully filled
 
 	// 4. Verify Order Book State (Sell order should be partially filled)
-	stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+	stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 	require.NoError(t, err)
 	assert.Empty(t, stateResp.Bids, "Expected no bids after match")
 	require.Len(t, stateResp.Asks, 1, "Expected 1 ask level remaining")
@@ -331,7 +333,7 @@ func TestIntegrationV2_MarketOrderMatch(t *testing.T) {
 	assert.Equal(t, buyResp.Status, buyResp.Status)
 
 	// 4. Verify Order Book State (Sell order should be partially filled)
-	stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+	stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 	require.NoError(t, err)
 	assert.Empty(t, stateResp.Bids, "Expected no bids after market order")
 	require.Len(t, stateResp.Asks, 1, "Expected 1 ask level remaining")
@@ -359,6 +361,146 @@ func TestIntegrationV2_MarketOrderMatch(t *testing.T) {
 	compareDecimalStrings(t, "100.000", makerTrade.Price, "Matched price")
 }
 
+// TestIntegrationV2_MultipleMatchesAtSamePriceLevel verifies that a taker walks
+// the makers resting at one price level in time order.
+func TestIntegrationV2_MultipleMatchesAtSamePriceLevel(t *testing.T) {
+	client, mockSender, teardown := setupIntegrationTestV2(t)
+	defer teardown()
+
+	ctx := context.Background()
+	bookName := "integ-test-book-v2-same-level"
+	buyOrderID := "buy-same-level-1"
+	sellOrderIDs := []string{"sell-same-level-1", "sell-same-level-2", "sell-same-level-3"}
+	users := []string{
+		"0x1111111111111111111111111111111111111111",
+		"0x2222222222222222222222222222222222222222",
+		"0x3333333333333333333333333333333333333333",
+	}
+
+	_, err := client.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: bookName, BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	// Three makers of size 2 at the same price, each from a different user
+	for i, id := range sellOrderIDs {
+		_, err = client.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: bookName,
+			OrderId:       id,
+			Side:          proto.OrderSide_SELL,
+			Quantity:      "2.0",
+			Price:         "100.0",
+			OrderType:     proto.OrderType_LIMIT,
+			TimeInForce:   proto.TimeInForce_GTC,
+			UserAddress:   users[i],
+		})
+		require.NoError(t, err)
+	}
+	mockSender.ClearSentMessages()
+
+	// The taker fills the first two makers and 1 of the third
+	_, err = client.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: bookName,
+		OrderId:       buyOrderID,
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "5.0",
+		Price:         "100.0",
+		OrderType:     proto.OrderType_LIMIT,
+		TimeInForce:   proto.TimeInForce_GTC,
+	})
+	require.NoError(t, err)
+
+	sentMessages := mockSender.GetSentMessages()
+	require.Len(t, sentMessages, 1, "Expected 1 message sent for the limit taker execution")
+	msg := sentMessages[0]
+	assert.Equal(t, buyOrderID, msg.OrderID)
+	assert.False(t, msg.Stored, "Taker order was fully matched")
+	compareDecimalStrings(t, "5.000", msg.ExecutedQty, "Executed quantity")
+	compareDecimalStrings(t, "0.000", msg.RemainingQty, "Remaining quantity")
+
+	// The taker entry comes first, then one entry per maker in time order
+	require.Len(t, msg.Trades, 4, "Expected the taker and 3 maker trade entries")
+	assert.Equal(t, buyOrderID, msg.Trades[0].OrderID)
+	compareDecimalStrings(t, "5.000", msg.Trades[0].Quantity, "Taker quantity")
+	for i, want := range []string{"2.000", "2.000", "1.000"} {
+		maker := msg.Trades[i+1]
+		assert.Equal(t, sellOrderIDs[i], maker.OrderID)
+		assert.Equal(t, users[i], maker.UserAddress)
+		compareDecimalStrings(t, want, maker.Quantity, "Maker quantity")
+		compareDecimalStrings(t, "100.000", maker.Price, "Maker price")
+	}
+
+	// Only the partially filled third maker is left resting
+	stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
+	require.NoError(t, err)
+	assert.Empty(t, stateResp.Bids)
+	require.Len(t, stateResp.Asks, 1)
+	compareDecimalStrings(t, "1.000", stateResp.Asks[0].TotalQuantity, "Remaining ask quantity")
+	assert.Equal(t, int32(1), stateResp.Asks[0].OrderCount)
+
+	thirdMaker, err := client.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: bookName, OrderId: sellOrderIDs[2]})
+	require.NoError(t, err)
+	assert.Equal(t, proto.OrderStatus_PARTIALLY_FILLED, thirdMaker.OrderState)
+}
+
+// TestIntegrationV2_PriceTimePriority verifies that a taker crossing several
+// price levels is filled at the best ask first.
+func TestIntegrationV2_PriceTimePriority(t *testing.T) {
+	client, mockSender, teardown := setupIntegrationTestV2(t)
+	defer teardown()
+
+	ctx := context.Background()
+	bookName := "integ-test-book-v2-priority"
+	buyOrderID := "buy-priority-1"
+
+	_, err := client.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: bookName, BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	// Rest the worst ask first so arrival order cannot explain the fill order
+	for _, price := range []string{"102", "100", "101"} {
+		_, err = client.CreateOrder(ctx, &proto.CreateOrderRequest{
+			OrderBookName: bookName,
+			OrderId:       "sell-priority-" + price,
+			Side:          proto.OrderSide_SELL,
+			Quantity:      "1.0",
+			Price:         price,
+			OrderType:     proto.OrderType_LIMIT,
+			TimeInForce:   proto.TimeInForce_GTC,
+		})
+		require.NoError(t, err)
+	}
+	mockSender.ClearSentMessages()
+
+	_, err = client.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: bookName,
+		OrderId:       buyOrderID,
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "3.0",
+		Price:         "102.0",
+		OrderType:     proto.OrderType_LIMIT,
+		TimeInForce:   proto.TimeInForce_GTC,
+	})
+	require.NoError(t, err)
+
+	sentMessages := mockSender.GetSentMessages()
+	require.Len(t, sentMessages, 1, "Expected 1 message sent for the limit taker execution")
+	msg := sentMessages[0]
+	assert.Equal(t, buyOrderID, msg.OrderID)
+	compareDecimalStrings(t, "0.000", msg.RemainingQty, "Remaining quantity")
+
+	require.Len(t, msg.Trades, 4, "Expected the taker and 3 maker trade entries")
+	assert.Equal(t, buyOrderID, msg.Trades[0].OrderID)
+	for i, price := range []string{"100", "101", "102"} {
+		maker := msg.Trades[i+1]
+		assert.Equal(t, "sell-priority-"+price, maker.OrderID)
+		compareDecimalStrings(t, price, maker.Price, "Maker price")
+		compareDecimalStrings(t, "1.000", maker.Quantity, "Maker quantity")
+	}
+
+	stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
+	require.NoError(t, err)
+	assert.Empty(t, stateResp.Bids)
+	assert.Empty(t, stateResp.Asks)
+}
+
 // TestIntegrationV2_CancelOrder verifies canceling an order and checks Kafka messages.
 func TestIntegrationV2_CancelOrder(t *testing.T) {
 	client, mockSender, teardown := setupIntegrationTestV2(t)
@@ -403,16 +545,89 @@ func TestIntegrationV2_CancelOrder(t *testing.T) {
 	}
 
 	// 6. Verify Order Book State (should be empty)
-	stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+	stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 	require.NoError(t, err)
 	assert.Empty(t, stateResp.Bids, "Expected no bids after cancel")
 	assert.Empty(t, stateResp.Asks, "Expected no asks")
 
-	// 7. Verify Mock Sender (Cancellation itself might not send a message in current core logic)
+	// 7. Verify Mock Sender received a cancel message
 	sentMessages := mockSender.GetSentMessages()
-	// TODO: Adjust assertion based on whether core.CancelOrder is modified to send Kafka messages.
-	// For now, assume it does NOT send a message directly upon cancellation.
-	assert.Empty(t, sentMessages, "Expected no Kafka message sent directly from CancelOrder")
+	require.Len(t, sentMessages, 1, "Expected one cancel message")
+	cancelMsg := sentMessages[0].Cancel
+	require.NotNil(t, cancelMsg, "Expected message to carry cancel details")
+	assert.Equal(t, orderID, cancelMsg.OrderID)
+	assert.Equal(t, messaging.CancelReasonUserRequested, cancelMsg.CancelReason)
+	assert.False(t, cancelMsg.CanceledAt.IsZero(), "Expected cancel timestamp to be set")
+}
+
+// TestIntegrationV2_OCOFill verifies that filling one leg of an OCO pair
+// cancels the other and reports it to Kafka.
+func TestIntegrationV2_OCOFill(t *testing.T) {
+	client, mockSender, teardown := setupIntegrationTestV2(t)
+	defer teardown()
+
+	ctx := context.Background()
+	bookName := "integ-test-book-v2-oco"
+
+	_, err := client.CreateOrderBook(ctx, &proto.CreateOrderBookRequest{Name: bookName, BackendType: proto.BackendType_MEMORY})
+	require.NoError(t, err)
+
+	// 1. Rest a take-profit and a wider exit, each naming the other as its OCO leg
+	for _, req := range []*proto.CreateOrderRequest{
+		{OrderId: "oco-take-profit", Price: "101.0", OcoId: "oco-exit"},
+		{OrderId: "oco-exit", Price: "105.0", OcoId: "oco-take-profit"},
+	} {
+		req.OrderBookName = bookName
+		req.Side = proto.OrderSide_SELL
+		req.Quantity = "2.0"
+		req.OrderType = proto.OrderType_LIMIT
+		req.TimeInForce = proto.TimeInForce_GTC
+		_, err := client.CreateOrder(ctx, req)
+		require.NoError(t, err)
+	}
+	mockSender.ClearSentMessages()
+
+	// 2. Fill the take-profit
+	resp, err := client.CreateOrder(ctx, &proto.CreateOrderRequest{
+		OrderBookName: bookName,
+		OrderId:       "oco-taker",
+		Side:          proto.OrderSide_BUY,
+		Quantity:      "2.0",
+		Price:         "101.0",
+		OrderType:     proto.OrderType_LIMIT,
+		TimeInForce:   proto.TimeInForce_GTC,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, proto.OrderStatus_FILLED, resp.Status)
+
+	// 3. The other leg is gone and the book is empty
+	_, err = client.GetOrder(ctx, &proto.GetOrderRequest{OrderBookName: bookName, OrderId: "oco-exit"})
+	require.Error(t, err, "Expected the other OCO leg to be canceled")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
+	require.NoError(t, err)
+	assert.Empty(t, stateResp.Bids)
+	assert.Empty(t, stateResp.Asks)
+
+	// 4. Kafka got the cancellation of the other leg, then the fill
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	sentMessages, err := mockSender.WaitForMessages(waitCtx, 2)
+	require.NoError(t, err)
+	require.Len(t, sentMessages, 2, "Expected a cancel message and a done message")
+
+	cancelMsg := sentMessages[0]
+	require.NotNil(t, cancelMsg.Cancel, "Expected the first message to carry cancel details")
+	assert.Equal(t, "oco-exit", cancelMsg.Cancel.OrderID)
+	assert.Equal(t, messaging.CancelReasonOCOTriggered, cancelMsg.Cancel.CancelReason)
+	assert.True(t, cancelMsg.CancelledByOCO)
+
+	doneMsg := sentMessages[1]
+	assert.Equal(t, "oco-taker", doneMsg.OrderID)
+	assert.Nil(t, doneMsg.Cancel)
+	assert.True(t, doneMsg.CancelledByOCO)
+	assert.Equal(t, []string{"oco-exit"}, doneMsg.Canceled)
+	assert.Equal(t, "2.000", doneMsg.ExecutedQty)
 }
 
 // TestIntegrationV2_IOC_FOK verifies ImmediateOrCancel and FillOrKill TIF logic.
@@ -458,7 +673,7 @@ func TestIntegrationV2_IOC_FOK(t *testing.T) {
 		t.Logf("DEBUG: Response status: %v", buyResp.Status)
 
 		// Verify book state (should only match against 5.0 out of 10.0)
-		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 		assert.Empty(t, stateResp.Asks, "IOC: Expected asks to be cleared")
 		assert.Empty(t, stateResp.Bids, "IOC: Expected no bids") // IOC order should not rest
@@ -550,7 +765,7 @@ func TestIntegrationV2_IOC_FOK(t *testing.T) {
 		}
 
 		// Verify book state (Asks should be unchanged)
-		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 		require.Len(t, stateResp.Asks, 1, "FOK: Expected asks to be unchanged")
 		assert.Equal(t, "101.000", stateResp.Asks[0].Price)
@@ -576,7 +791,7 @@ func TestIntegrationV2_IOC_FOK(t *testing.T) {
 		assert.Equal(t, buyResp.Status, buyResp.Status)
 
 		// Verify book state (Asks should be empty)
-		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 		assert.Empty(t, stateResp.Bids, "FOK: Expected no bids")
 
@@ -669,14 +884,15 @@ func TestIntegrationV2_StopLimit(t *testing.T) {
 		// TimeInForce:   proto.TimeInForce_GTC, // Implicit GTC for stop?
 	})
 	require.NoError(t, err, "Failed to place stop order")
-	t.Logf("DEBUG: Response status: %v", stopResp.Status)
+	assert.Equal(t, proto.OrderStatus_PENDING, stopResp.Status, "a stop order waits in the stop book until it triggers")
 
 	// Verify state (stop order shouldn't be on book)
-	stateResp1, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+	stateResp1, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 	require.NoError(t, err)
 	t.Logf("DEBUG: Initial state - bids: %v, asks: %v", stateResp1.Bids, stateResp1.Asks)
 
 	// 3. Place a Market Sell Order to Trigger the Stop (Sell Market @ 105)
+	mockSender.ClearSentMessages()
 	_, err = client.CreateOrder(ctx, &proto.CreateOrderRequest{
 		OrderBookName: bookName,
 		OrderId:       triggerSellID,
@@ -688,11 +904,24 @@ func TestIntegrationV2_StopLimit(t *testing.T) {
 	})
 	require.NoError(t, err, "Failed to place trigger sell order")
 
-	// Give the system time to process the stop
-	time.Sleep(100 * time.Millisecond)
+	// Wait for the done messages of the trigger and the stop it activates
+	// rather than sleeping
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	msgs, err := mockSender.WaitForMessages(waitCtx, 2)
+	require.NoError(t, err, "Trigger sell did not produce its done messages")
+	byOrder := make(map[string]*messaging.DoneMessage)
+	for _, msg := range msgs {
+		byOrder[msg.OrderID] = msg
+	}
+	require.Contains(t, byOrder, triggerSellID)
+	assert.Equal(t, "1.000", byOrder[triggerSellID].Processed)
+	require.Contains(t, byOrder, stopBuyID)
+	assert.Equal(t, []string{stopBuyID}, byOrder[stopBuyID].Activated)
+	assert.True(t, byOrder[stopBuyID].StopActivated, "the stop order's message reports its activation")
 
 	// Verify state (trigger sell should be executed, stop order should now be on bids)
-	stateResp2, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+	stateResp2, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 	require.NoError(t, err)
 	t.Logf("DEBUG: After trigger state - bids: %v, asks: %v", stateResp2.Bids, stateResp2.Asks)
 
@@ -766,7 +995,7 @@ func TestIntegrationV2_Redis_StopLimit(t *testing.T) {
 		time.Sleep(200 * time.Millisecond)
 
 		// Verify state (stop order shouldn't be on book)
-		stateResp1, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp1, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 		assert.Empty(t, stateResp1.Bids, "Stop order should not be on bids yet")
 		assert.Empty(t, stateResp1.Asks, "Stop order should not be on asks yet")
@@ -787,7 +1016,7 @@ func TestIntegrationV2_Redis_StopLimit(t *testing.T) {
 		time.Sleep(500 * time.Millisecond)
 
 		// Verify state (trigger sell should be on asks, stop should now be on bids)
-		stateResp2, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp2, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 		require.Len(t, stateResp2.Asks, 1, "Expected trigger sell on asks")
 		assert.Equal(t, "105.000", stateResp2.Asks[0].Price)
@@ -817,7 +1046,7 @@ func TestIntegrationV2_Redis_StopLimit(t *testing.T) {
 			assert.Equal(t, proto.OrderStatus_FILLED, fillResp.Status, "Fill sell order should be filled")
 
 			// Verify final state after matching
-			stateResp3, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+			stateResp3, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 			require.NoError(t, err)
 
 			// There should be no asks except the initial trigger (fill buy was matched)
@@ -871,7 +1100,7 @@ func TestIntegrationV2_Redis_BasicLimitOrder(t *testing.T) {
 		assert.Equal(t, proto.OrderStatus_OPEN, orderResp.Status) // Order should be open
 
 		// 3. Verify Order Book State
-		stateReq := &proto.GetOrderBookStateRequest{Name: bookName}
+		stateReq := &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20}
 		stateResp, err := client.GetOrderBookState(ctx, stateReq)
 		require.NoError(t, err, "GetOrderBookState failed")
 		require.Len(t, stateResp.Bids, 1, "Expected 1 bid level")
@@ -933,7 +1162,7 @@ func TestIntegrationV2_Redis_LimitOrderMatch(t *testing.T) {
 		time.Sleep(200 * time.Millisecond)
 
 		// 4. Verify Order Book State (Sell order should be partially filled)
-		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 		assert.Empty(t, stateResp.Bids, "Expected no bids after match")
 		require.Len(t, stateResp.Asks, 1, "Expected 1 ask level remaining")
@@ -984,7 +1213,7 @@ func TestIntegrationV2_Redis_StopLimitSell(t *testing.T) {
 		time.Sleep(200 * time.Millisecond)
 
 		// Verify state (stop order shouldn't be on book)
-		stateResp1, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp1, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 		assert.Empty(t, stateResp1.Bids, "Stop order should not be on bids yet")
 		assert.Empty(t, stateResp1.Asks, "Stop order should not be on asks yet")
@@ -1005,7 +1234,7 @@ func TestIntegrationV2_Redis_StopLimitSell(t *testing.T) {
 		time.Sleep(500 * time.Millisecond)
 
 		// Verify state (trigger buy should be on bids, stop should now be on asks)
-		stateResp2, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp2, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 		require.Len(t, stateResp2.Bids, 1, "Expected trigger buy on bids")
 		assert.Equal(t, "95.000", stateResp2.Bids[0].Price)
@@ -1035,7 +1264,7 @@ func TestIntegrationV2_Redis_StopLimitSell(t *testing.T) {
 			assert.Equal(t, proto.OrderStatus_FILLED, fillResp.Status, "Fill buy order should be filled")
 
 			// Verify final state after matching
-			stateResp3, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+			stateResp3, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 			require.NoError(t, err)
 
 			// There should be no asks except the initial trigger (fill buy was matched)
@@ -1109,7 +1338,7 @@ func TestIntegrationV2_Redis_StopLimitActivation(t *testing.T) {
 		time.Sleep(200 * time.Millisecond)
 
 		// Verify initial state (no orders should be on the book)
-		stateResp1, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp1, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 		assert.Empty(t, stateResp1.Bids, "No orders should be on bids initially")
 		assert.Empty(t, stateResp1.Asks, "No orders should be on asks initially")
@@ -1130,7 +1359,7 @@ func TestIntegrationV2_Redis_StopLimitActivation(t *testing.T) {
 		time.Sleep(500 * time.Millisecond)
 
 		// Verify state after buy stop trigger
-		stateResp2, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp2, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 
 		// Sell trigger should be on asks
@@ -1163,7 +1392,7 @@ func TestIntegrationV2_Redis_StopLimitActivation(t *testing.T) {
 		time.Sleep(500 * time.Millisecond)
 
 		// Verify final state after both stops triggered
-		stateResp3, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName})
+		stateResp3, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{Name: bookName, Depth: 20})
 		require.NoError(t, err)
 
 		// Check bids - should have trigger buy and possibly activated buy stop
@@ -109,7 +109,8 @@ func TestMarketMakerIntegration(t *testing.T) {
 
 		// Get the order book state
 		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get order book state")
 
@@ -145,7 +146,8 @@ func TestMarketMakerIntegration(t *testing.T) {
 
 		// Get the order book state again
 		stateResp2, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get order book state after update")
 
@@ -165,7 +167,8 @@ func TestMarketMakerIntegration(t *testing.T) {
 
 		// Verify all orders were cancelled
 		stateResp3, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get order book state after stopping")
 
@@ -278,7 +281,8 @@ func TestMarketMakerWithRealDependencies(t *testing.T) {
 
 		// Get the order book state
 		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get order book state")
 
@@ -307,7 +311,8 @@ func TestMarketMakerWithRealDependencies(t *testing.T) {
 
 		// Verify all orders were cancelled
 		stateResp2, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get order book state after stopping")
 
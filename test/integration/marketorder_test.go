@@ -64,7 +64,8 @@ func TestMarketOrder_IOC(t *testing.T) {
 
 		// Verify the book state - should have 3 sell orders
 		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get order book state")
 		require.Len(t, stateResp.Asks, 3, "Expected 3 ask levels")
@@ -87,7 +88,8 @@ func TestMarketOrder_IOC(t *testing.T) {
 
 		// 4. Check the book state after market order
 		stateResp, err = client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get updated order book state")
 
@@ -117,7 +119,8 @@ func TestMarketOrder_IOC(t *testing.T) {
 
 		// 6. Check final book state - should be empty
 		finalStateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get final order book state")
 		assert.Empty(t, finalStateResp.Asks, "Expected no asks")
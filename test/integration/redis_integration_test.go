@@ -58,7 +58,8 @@ func TestRedisIntegration_BasicFlow(t *testing.T) {
 
 		// Verify state
 		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err)
 
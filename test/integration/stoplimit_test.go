@@ -60,7 +60,8 @@ func TestStopLimit_OrderTriggering(t *testing.T) {
 
 		// Verify the book state - no visible orders yet because they're stop orders
 		stateResp, err := client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get order book state")
 		assert.Empty(t, stateResp.Bids, "Expected no visible bids (stop order)")
@@ -95,7 +96,8 @@ func TestStopLimit_OrderTriggering(t *testing.T) {
 
 		// 5. Check the book state again - the buy stop should be visible now as a limit order
 		stateResp, err = client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get updated order book state")
 		require.Len(t, stateResp.Bids, 1, "Expected 1 bid level (triggered stop order)")
@@ -131,7 +133,8 @@ func TestStopLimit_OrderTriggering(t *testing.T) {
 
 		// 7. Book state should still have only the buy stop as a limit order
 		stateResp, err = client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get order book state after second trade")
 		require.Len(t, stateResp.Bids, 1, "Expected 1 bid level (triggered stop order)")
@@ -165,7 +168,8 @@ func TestStopLimit_OrderTriggering(t *testing.T) {
 
 		// 9. Final book state should have both buy and sell orders
 		stateResp, err = client.GetOrderBookState(ctx, &proto.GetOrderBookStateRequest{
-			Name: bookName,
+			Name:  bookName,
+			Depth: 20,
 		})
 		require.NoError(t, err, "Failed to get final order book state")
 		require.Len(t, stateResp.Bids, 1, "Expected 1 bid level")
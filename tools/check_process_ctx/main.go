@@ -0,0 +1,65 @@
+// Command check_process_ctx reports calls to OrderBook.Process that do not
+// pass a context.Context as their first argument.
+//
+// Usage:
+//
+//	go run ./tools/check_process_ctx ./...
+package main
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/analysis/singlechecker"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer flags OrderBook.Process calls missing a leading context argument
+var Analyzer = &analysis.Analyzer{
+	Name:     "processctx",
+	Doc:      "reports OrderBook.Process calls that do not pass a context.Context first",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func main() {
+	singlechecker.Main(Analyzer)
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Process" || !isOrderBook(pass.TypesInfo.TypeOf(sel.X)) {
+			return
+		}
+
+		if len(call.Args) == 0 || !isContext(pass.TypesInfo.TypeOf(call.Args[0])) {
+			pass.Reportf(call.Pos(), "OrderBook.Process must be called with a context.Context as its first argument")
+		}
+	})
+	return nil, nil
+}
+
+// isOrderBook reports whether t is OrderBook or a pointer to it
+func isOrderBook(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Name() == "OrderBook"
+}
+
+// isContext reports whether t is context.Context
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
+}
@@ -0,0 +1,25 @@
+package a
+
+import "context"
+
+type Order struct{}
+
+// OrderBook accepts any arguments so both call patterns compile
+type OrderBook struct{}
+
+func (ob *OrderBook) Process(args ...interface{}) {}
+
+// Queue has an unrelated Process method
+type Queue struct{}
+
+func (q *Queue) Process(order *Order) {}
+
+func calls(ctx context.Context, book *OrderBook, value OrderBook, queue *Queue, order *Order) {
+	book.Process(order)  // want "OrderBook.Process must be called with a context.Context as its first argument"
+	book.Process()       // want "OrderBook.Process must be called with a context.Context as its first argument"
+	value.Process(order) // want "OrderBook.Process must be called with a context.Context as its first argument"
+
+	book.Process(ctx, order)
+	book.Process(context.Background(), order)
+	queue.Process(order)
+}